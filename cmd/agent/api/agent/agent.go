@@ -43,11 +43,14 @@ func SetupHandlers(r *mux.Router) *mux.Router {
 	r.HandleFunc("/version", common.GetVersion).Methods("GET")
 	r.HandleFunc("/hostname", getHostname).Methods("GET")
 	r.HandleFunc("/flare", makeFlare).Methods("POST")
+	r.HandleFunc("/profile", makeProfile).Methods("POST")
 	r.HandleFunc("/stop", stopAgent).Methods("POST")
 	r.HandleFunc("/status", getStatus).Methods("GET")
 	r.HandleFunc("/dogstatsd-stats", getDogstatsdStats).Methods("GET")
+	r.HandleFunc("/dogstatsd-capture/stream", streamDogstatsdCapture).Methods("GET")
 	r.HandleFunc("/status/formatted", getFormattedStatus).Methods("GET")
 	r.HandleFunc("/status/health", getHealth).Methods("GET")
+	r.HandleFunc("/health/detailed", getDetailedHealth).Methods("GET")
 	r.HandleFunc("/{component}/status", componentStatusGetterHandler).Methods("GET")
 	r.HandleFunc("/{component}/status", componentStatusHandler).Methods("POST")
 	r.HandleFunc("/{component}/configs", componentConfigHandler).Methods("GET")
@@ -55,10 +58,16 @@ func SetupHandlers(r *mux.Router) *mux.Router {
 	r.HandleFunc("/config-check", getConfigCheck).Methods("GET")
 	r.HandleFunc("/config", getFullRuntimeConfig).Methods("GET")
 	r.HandleFunc("/config/list-runtime", getRuntimeConfigurableSettings).Methods("GET")
+	r.HandleFunc("/config/layers", getLayeredConfig).Methods("GET")
 	r.HandleFunc("/config/{setting}", getRuntimeConfig).Methods("GET")
 	r.HandleFunc("/config/{setting}", setRuntimeConfig).Methods("POST")
 	r.HandleFunc("/tagger-list", getTaggerList).Methods("GET")
+	r.HandleFunc("/tagger-list", filterTaggerList).Methods("POST")
+	r.HandleFunc("/tagger-entity", setTaggerEntity).Methods("POST")
+	r.HandleFunc("/tagger-entity", deleteTaggerEntity).Methods("DELETE")
 	r.HandleFunc("/secrets", secretInfo).Methods("GET")
+	r.HandleFunc("/workload-list", getWorkloadList).Methods("GET")
+	r.HandleFunc("/events", submitEvent).Methods("POST")
 
 	return r
 }
@@ -97,16 +106,8 @@ func makeFlare(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	logFile := config.Datadog.GetString("log_file")
-	if logFile == "" {
-		logFile = common.DefaultLogFile
-	}
-	jmxLogFile := config.Datadog.GetString("jmx_log_file")
-	if jmxLogFile == "" {
-		jmxLogFile = common.DefaultJmxLogFile
-	}
 	log.Infof("Making a flare")
-	filePath, err := flare.CreateArchive(false, common.GetDistPath(), common.PyChecksPath, []string{logFile, jmxLogFile}, profile)
+	filePath, err := flare.CreateArchive(false, common.GetDistPath(), common.PyChecksPath, flareLogFiles(), profile)
 	if err != nil || filePath == "" {
 		if err != nil {
 			log.Errorf("The flare failed to be created: %s", err)
@@ -256,6 +257,32 @@ func getHealth(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonHealth)
 }
 
+// getDetailedHealth returns the state of every component, with a 503 status code when the agent isn't ready so
+// that it can be used as an orchestration probe
+func getDetailedHealth(w http.ResponseWriter, r *http.Request) {
+	h, err := health.GetDetailedNonBlocking()
+	if err != nil {
+		log.Errorf("Error getting the detailed health: %v", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	jsonHealth, err := json.Marshal(h)
+	if err != nil {
+		log.Errorf("Error marshalling the detailed health. Error: %v, Health: %v", err, h)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !h.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(jsonHealth)
+}
+
 func getCSRFToken(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(gui.CsrfToken))
 }
@@ -314,6 +341,61 @@ func getFullRuntimeConfig(w http.ResponseWriter, r *http.Request) {
 	w.Write(scrubbed)
 }
 
+func getLayeredConfig(w http.ResponseWriter, r *http.Request) {
+	view := config.Datadog.GetLayeredView()
+	for key, setting := range view {
+		scrubbed, err := scrubLayeredSetting(key, setting)
+		if err != nil {
+			log.Errorf("Unable to scrub sensitive data from layered config: %s", err)
+			body, _ := json.Marshal(map[string]string{"error": err.Error()})
+			http.Error(w, string(body), 500)
+			return
+		}
+		view[key] = scrubbed
+	}
+
+	body, err := json.Marshal(view)
+	if err != nil {
+		log.Errorf("Unable to marshal layered config response: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+	w.Write(body)
+}
+
+// scrubLayeredSetting scrubs the values of a setting in every layer
+func scrubLayeredSetting(key string, setting config.LayeredSetting) (config.LayeredSetting, error) {
+	var err error
+	if setting.Value, err = scrubSettingValue(key, setting.Value); err != nil {
+		return setting, err
+	}
+	for source, value := range setting.Layers {
+		if setting.Layers[source], err = scrubSettingValue(key, value); err != nil {
+			return setting, err
+		}
+	}
+	return setting, nil
+}
+
+// scrubSettingValue scrubs a value the same way the runtime configuration is,
+// so that the key name is taken into account
+func scrubSettingValue(key string, value interface{}) (interface{}, error) {
+	raw, err := yaml.Marshal(map[string]interface{}{key: value})
+	if err != nil {
+		return nil, err
+	}
+	scrubbed, err := log.CredentialsCleanerBytes(raw)
+	if err != nil {
+		return nil, err
+	}
+	var setting map[string]interface{}
+	if err := yaml.Unmarshal(scrubbed, &setting); err != nil {
+		return nil, err
+	}
+	return util.GetJSONSerializableMap(setting[key]), nil
+}
+
 func getRuntimeConfig(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	setting := vars["setting"]
@@ -379,11 +461,86 @@ func getRuntimeConfigurableSettings(w http.ResponseWriter, r *http.Request) {
 }
 
 func getTaggerList(w http.ResponseWriter, r *http.Request) {
-	// query at the highest cardinality between checks and dogstatsd cardinalities
-	cardinality := collectors.TagCardinality(max(int(tagger.ChecksCardinality), int(tagger.DogstatsdCardinality)))
-	response := tagger.List(cardinality)
+	writeTaggerList(w, tagger.List(defaultTaggerListCardinality()))
+}
+
+func filterTaggerList(w http.ResponseWriter, r *http.Request) {
+	var request response.TaggerListRequest
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, log.Errorf("Error while reading HTTP request body: %s", err).Error(), 500)
+		return
+	}
+
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &request); err != nil {
+			body, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("invalid tagger list request: %s", err)})
+			http.Error(w, string(body), 400)
+			return
+		}
+	}
+
+	cardinality := defaultTaggerListCardinality()
+	if request.Cardinality != "" {
+		if cardinality, err = tagger.StringToTagCardinality(request.Cardinality); err != nil {
+			body, _ := json.Marshal(map[string]string{"error": err.Error()})
+			http.Error(w, string(body), 400)
+			return
+		}
+	}
+
+	writeTaggerList(w, tagger.ListWithFilter(cardinality, &tagger.ListFilter{
+		Prefixes: request.Prefixes,
+		Entities: request.Entities,
+		Sources:  request.Sources,
+	}))
+}
+
+// setTaggerEntity sets the tags of an entity in the tagger, replacing the ones previously set through the API
+func setTaggerEntity(w http.ResponseWriter, r *http.Request) {
+	var request response.TaggerEntityRequest
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, log.Errorf("Error while reading HTTP request body: %s", err).Error(), 500)
+		return
+	}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		body, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("invalid tagger entity request: %s", err)})
+		http.Error(w, string(body), 400)
+		return
+	}
+
+	err = tagger.SetEntityTags(request.Entity, request.LowCardinalityTags, request.OrchestratorCardinalityTags,
+		request.HighCardinalityTags, request.StandardTags)
+	if err != nil {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 400)
+		return
+	}
+	log.Infof("The tags of %s were set through the API", request.Entity)
+}
+
+// deleteTaggerEntity removes the tags set on the entity of the `entity` query parameter through the API
+func deleteTaggerEntity(w http.ResponseWriter, r *http.Request) {
+	entity := r.URL.Query().Get("entity")
+	if err := tagger.DeleteEntityTags(entity); err != nil {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 400)
+		return
+	}
+	log.Infof("The tags of %s set through the API were deleted", entity)
+}
+
+// defaultTaggerListCardinality returns the highest cardinality between checks and dogstatsd cardinalities
+func defaultTaggerListCardinality() collectors.TagCardinality {
+	return collectors.TagCardinality(max(int(tagger.ChecksCardinality), int(tagger.DogstatsdCardinality)))
+}
 
-	jsonTags, err := json.Marshal(response)
+func writeTaggerList(w http.ResponseWriter, list response.TaggerListResponse) {
+	jsonTags, err := json.Marshal(list)
 	if err != nil {
 		log.Errorf("Unable to marshal tagger list response: %s", err)
 		body, _ := json.Marshal(map[string]string{"error": err.Error()})
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+)
+
+func TestTaggerEntity(t *testing.T) {
+	router := SetupHandlers(mux.NewRouter())
+	serve := func(method, url, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, url, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := serve("POST", "/tagger-entity", `{"entity":"container_id://api-test","low_cardinality_tags":["team:a"],"high_cardinality_tags":["request:1"]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	defer tagger.DeleteEntityTags("container_id://api-test")
+
+	list := tagger.ListWithFilter(collectors.HighCardinality, &tagger.ListFilter{Entities: []string{"container_id://api-test"}})
+	require.Contains(t, list.Entities, "container_id://api-test")
+	assert.ElementsMatch(t, []string{"team:a", "request:1"}, list.Entities["container_id://api-test"].Tags)
+	assert.Equal(t, []string{collectors.APISource}, list.Entities["container_id://api-test"].Sources)
+
+	rec = serve("POST", "/tagger-entity", `{"entity":"api-test","low_cardinality_tags":["team:a"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "invalid entity ID")
+
+	rec = serve("POST", "/tagger-entity", `{"entity":`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "invalid tagger entity request")
+
+	rec = serve("DELETE", "/tagger-entity?entity=container_id://api-test", "")
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serve("DELETE", "/tagger-entity", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// captureBufferSize is the number of samples buffered for each client of the capture stream,
+// the next ones are dropped until the client catches up
+const captureBufferSize = 1024
+
+var (
+	captureStreamsStopped = make(chan struct{})
+	stopCaptureStreams    sync.Once
+)
+
+// StopDogstatsdCaptureStreams ends the dogstatsd capture streams of the clients
+func StopDogstatsdCaptureStreams() {
+	stopCaptureStreams.Do(func() {
+		close(captureStreamsStopped)
+	})
+}
+
+// streamDogstatsdCapture upgrades the connection to a WebSocket and sends the parsed dogstatsd
+// metric samples as JSON messages, filtered by the `prefix` query parameter, until the client
+// disconnects or the agent stops
+func streamDogstatsdCapture(w http.ResponseWriter, r *http.Request) {
+	if !config.Datadog.GetBool("use_dogstatsd") || common.DSD == nil {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := json.Marshal(map[string]string{
+			"error":      "Dogstatsd not enabled in the Agent configuration",
+			"error_type": "no server",
+		})
+		w.WriteHeader(400)
+		w.Write(body)
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	log.Infof("Got a request to stream the dogstatsd capture with the prefix %q.", prefix)
+
+	// the requests are authenticated with the auth token, the origin isn't checked
+	server := websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			// the hijacked connection keeps the deadline set from the server timeout, the stream has none
+			ws.SetDeadline(time.Time{}) //nolint:errcheck
+
+			samples, unsubscribe := common.DSD.SubscribeCapture(prefix, captureBufferSize)
+			defer unsubscribe()
+
+			// the client isn't expected to send anything, reading only detects that it disconnected
+			clientGone := make(chan struct{})
+			go func() {
+				io.Copy(ioutil.Discard, ws) //nolint:errcheck
+				close(clientGone)
+			}()
+
+			for {
+				select {
+				case sample, ok := <-samples:
+					if !ok {
+						return
+					}
+					if err := websocket.JSON.Send(ws, sample); err != nil {
+						log.Debugf("Unable to send the dogstatsd capture: %s", err)
+						return
+					}
+				case <-clientGone:
+					return
+				case <-captureStreamsStopped:
+					return
+				}
+			}
+		},
+	}
+	server.ServeHTTP(w, r)
+}
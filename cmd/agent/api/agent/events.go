@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/metadata/host"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// maxEventPayloadSize is the maximum size of the events submitted to the intake endpoint
+const maxEventPayloadSize = 64 * 1024
+
+// eventIntakeRequest is an event submitted by a local tool, like a build system or a deploy hook.
+// It follows the format of the events API so that the tools can use the same payloads.
+type eventIntakeRequest struct {
+	Title          string   `json:"title"`
+	Text           string   `json:"text"`
+	DateHappened   int64    `json:"date_happened"`
+	Priority       string   `json:"priority"`
+	Host           string   `json:"host"`
+	Tags           []string `json:"tags"`
+	AlertType      string   `json:"alert_type"`
+	AggregationKey string   `json:"aggregation_key"`
+	SourceTypeName string   `json:"source_type_name"`
+}
+
+// submitEvent forwards the event through the event pipeline of the agent, with the host tags attached,
+// so that the local tools don't need API credentials to submit events
+func submitEvent(w http.ResponseWriter, r *http.Request) {
+	var req eventIntakeRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxEventPayloadSize))
+	if err := decoder.Decode(&req); err != nil {
+		setEventError(w, fmt.Errorf("invalid event payload: %v", err), 400)
+		return
+	}
+
+	event, err := req.toEvent(getHostTags())
+	if err != nil {
+		setEventError(w, err, 400)
+		return
+	}
+
+	sender, err := aggregator.GetDefaultSender()
+	if err != nil {
+		setEventError(w, log.Errorf("Unable to submit the event: %v", err), 503)
+		return
+	}
+	sender.Event(event)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(202)
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+func (req *eventIntakeRequest) toEvent(hostTags []string) (metrics.Event, error) {
+	if req.Title == "" {
+		return metrics.Event{}, errors.New("the title of the event is required")
+	}
+	if req.Text == "" {
+		return metrics.Event{}, errors.New("the text of the event is required")
+	}
+
+	event := metrics.Event{
+		Title:          req.Title,
+		Text:           req.Text,
+		Ts:             req.DateHappened,
+		Priority:       metrics.EventPriorityNormal,
+		Host:           req.Host,
+		Tags:           append(append([]string{}, req.Tags...), hostTags...),
+		AlertType:      metrics.EventAlertTypeInfo,
+		AggregationKey: req.AggregationKey,
+		SourceTypeName: req.SourceTypeName,
+	}
+	if event.Ts == 0 {
+		event.Ts = time.Now().Unix()
+	}
+
+	var err error
+	if req.Priority != "" {
+		if event.Priority, err = metrics.GetEventPriorityFromString(req.Priority); err != nil {
+			return metrics.Event{}, err
+		}
+	}
+	if req.AlertType != "" {
+		if event.AlertType, err = metrics.GetAlertTypeFromString(req.AlertType); err != nil {
+			return metrics.Event{}, err
+		}
+	}
+	return event, nil
+}
+
+// getHostTags returns the host tags of the cached host metadata payload
+func getHostTags() []string {
+	hostnameData, err := util.GetHostnameData()
+	if err != nil {
+		log.Debugf("Unable to get the hostname, the host tags won't be attached to the event: %v", err)
+		return nil
+	}
+	payload := host.GetPayloadFromCache(hostnameData)
+	if payload == nil || payload.HostTags == nil {
+		return nil
+	}
+	return payload.HostTags.System
+}
+
+func setEventError(w http.ResponseWriter, err error, code int) {
+	body, _ := json.Marshal(map[string]string{"error": err.Error()})
+	http.Error(w, string(body), code)
+}
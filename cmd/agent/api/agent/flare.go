@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/flare"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// FlareRequest describes a flare built by the agent
+type FlareRequest struct {
+	// Profile holds the performance profiles collected by the caller, shipped in the flare
+	Profile flare.ProfileData
+	// Send uploads the flare to Datadog once it is built
+	Send   bool
+	CaseID string
+	Email  string
+}
+
+// FlareResult is the outcome of a flare built by the agent
+type FlareResult struct {
+	FilePath string
+	// UploadResponse is the response of Datadog to the upload of the flare, if requested
+	UploadResponse string
+}
+
+// ErrFlareUpload wraps the errors of the uploads of the flares, the archive being built
+type ErrFlareUpload struct {
+	Err error
+}
+
+func (e ErrFlareUpload) Error() string {
+	return fmt.Sprintf("the flare couldn't be uploaded: %s", e.Err)
+}
+
+// flareLogFiles returns the log files of the agent shipped in the flares
+func flareLogFiles() []string {
+	logFile := config.Datadog.GetString("log_file")
+	if logFile == "" {
+		logFile = common.DefaultLogFile
+	}
+	jmxLogFile := config.Datadog.GetString("jmx_log_file")
+	if jmxLogFile == "" {
+		jmxLogFile = common.DefaultJmxLogFile
+	}
+	return []string{logFile, jmxLogFile}
+}
+
+// GenerateFlare builds a flare, calling progress after each step of the collection, and uploads it when
+// requested. The path of the archive is returned along with the ErrFlareUpload errors.
+func GenerateFlare(req FlareRequest, progress flare.ProgressFunc) (FlareResult, error) {
+	log.Infof("Making a flare")
+	filePath, err := flare.CreateArchiveWithProgress(false, common.GetDistPath(), common.PyChecksPath, flareLogFiles(), req.Profile, progress)
+	if err != nil {
+		log.Errorf("The flare failed to be created: %s", err)
+		return FlareResult{}, err
+	}
+
+	result := FlareResult{FilePath: filePath}
+	if !req.Send {
+		return result, nil
+	}
+
+	log.Infof("Uploading the flare %s", filePath)
+	result.UploadResponse, err = flare.SendFlare(filePath, req.CaseID, req.Email)
+	if err != nil {
+		log.Errorf("The flare failed to be uploaded: %s", err)
+		return result, ErrFlareUpload{Err: err}
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/profiling"
+)
+
+const (
+	// defaultProfileDuration is the duration of the profiles when none is requested
+	defaultProfileDuration = 10 * time.Second
+	// profileWriteMargin is the time left to archive and send the profiles before the write timeout of
+	// the api server
+	profileWriteMargin = 2 * time.Second
+)
+
+// ProfileRequest describes the runtime profiles requested to the agent
+type ProfileRequest struct {
+	Seconds  int64    `json:"seconds"`
+	Profiles []string `json:"profiles"`
+}
+
+// maxProfileDuration returns the longest profile duration that can be served before the write timeout
+// of the api server
+func maxProfileDuration() time.Duration {
+	return config.Datadog.GetDurationWithUnit("server_timeout", time.Second) - profileWriteMargin
+}
+
+// GetProfileDuration validates the profile request and returns the duration of the capture
+func GetProfileDuration(req ProfileRequest) (time.Duration, error) {
+	for _, profile := range req.Profiles {
+		if !profiling.IsCaptureProfileType(profile) {
+			return 0, fmt.Errorf("unknown profile type '%s', expected one of %v", profile, profiling.CaptureProfileTypes)
+		}
+	}
+
+	maxDuration := maxProfileDuration()
+	if maxDuration <= 0 {
+		return 0, fmt.Errorf("server_timeout is too low to capture profiles")
+	}
+
+	duration := time.Duration(req.Seconds) * time.Second
+	switch {
+	case req.Seconds < 0:
+		return 0, fmt.Errorf("invalid profile duration %ds", req.Seconds)
+	case req.Seconds == 0 && defaultProfileDuration < maxDuration:
+		duration = defaultProfileDuration
+	case req.Seconds == 0:
+		duration = maxDuration
+	case duration > maxDuration:
+		return 0, fmt.Errorf("the profile duration can't exceed %s, increase server_timeout to capture longer profiles", maxDuration)
+	}
+	return duration, nil
+}
+
+func makeProfile(w http.ResponseWriter, r *http.Request) {
+	var req ProfileRequest
+	if r.Body != http.NoBody {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, log.Errorf("Error while reading HTTP request body: %s", err).Error(), 500)
+			return
+		}
+
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, fmt.Sprintf("Error while unmarshaling JSON from request body: %s", err), 400)
+				return
+			}
+		}
+	}
+
+	duration, err := GetProfileDuration(req)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	log.Infof("Capturing a %s profile of the agent", duration)
+	archive, err := profiling.CaptureProfiles(r.Context(), duration, req.Profiles)
+	if err == profiling.ErrCaptureInProgress {
+		http.Error(w, err.Error(), 409)
+		return
+	} else if err != nil {
+		http.Error(w, log.Errorf("The profiles failed to be captured: %s", err).Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"agent-profile.tar.gz\"")
+	w.Write(archive)
+}
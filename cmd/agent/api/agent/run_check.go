@@ -0,0 +1,240 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/status"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// maxCheckRunTimes is the largest number of runs of each instance in a one-shot check run
+	maxCheckRunTimes = 10
+	// runningCheckPollInterval is how often a one-shot run checks whether the scheduled run in progress completed
+	runningCheckPollInterval = 100 * time.Millisecond
+)
+
+var (
+	// ErrCheckNotFound is returned when no instance of the check is scheduled in the agent
+	ErrCheckNotFound = errors.New("no instance of the check is scheduled")
+	// ErrLongRunningCheck is returned for the long running checks, which never complete a run
+	ErrLongRunningCheck = errors.New("long running checks can't be run once")
+
+	// checkRunLoggers is used to give a unique name to the loggers of the concurrent check runs
+	checkRunLoggers uint64
+)
+
+// CheckRunRequest describes a one-shot run of the instances of a check
+type CheckRunRequest struct {
+	Name  string
+	Times int64
+	Pause time.Duration
+}
+
+// CheckRunUpdate is sent while the instances of a check run. It holds either a log line of the agent
+// mentioning the check, the metric stats of one run of an instance, or the final stats of an instance
+// along with what it submitted and its status rendered as in `agent check`.
+type CheckRunUpdate struct {
+	CheckID     check.ID
+	Log         string
+	Run         int64
+	MetricStats map[string]int64
+	Stats       *check.Stats
+	Results     *aggregator.DryRunResults
+	Status      []byte
+}
+
+// ValidateCheckRunRequest validates the check run request, the instances run once when no number of
+// runs is requested
+func ValidateCheckRunRequest(req *CheckRunRequest) error {
+	if req.Name == "" {
+		return fmt.Errorf("missing check name")
+	}
+	if req.Times == 0 {
+		req.Times = 1
+	}
+	if req.Times < 0 || req.Times > maxCheckRunTimes {
+		return fmt.Errorf("invalid number of runs %d, expected between 1 and %d", req.Times, maxCheckRunTimes)
+	}
+	if req.Pause < 0 {
+		return fmt.Errorf("invalid pause %s", req.Pause)
+	}
+	// the pauses are bounded by the write timeout of the api server, the runs themselves can't be
+	if time.Duration(req.Times-1)*req.Pause >= config.Datadog.GetDurationWithUnit("server_timeout", time.Second) {
+		return fmt.Errorf("the pauses between the runs exceed the server timeout")
+	}
+	return nil
+}
+
+// RunCheck runs the instances of the check scheduled in the collector the requested number of times, one
+// after the other. The scheduled runs of an instance are skipped while it runs, and its samples go to a
+// dry-run sender instead of the aggregator: like with `agent check`, nothing is sent to Datadog. send is
+// called with the logs mentioning the check, the metric stats of each run and the final results of each
+// instance, it's never called concurrently.
+func RunCheck(ctx context.Context, req CheckRunRequest, send func(CheckRunUpdate) error) error {
+	if common.Coll == nil {
+		return fmt.Errorf("the collector is not initialized")
+	}
+
+	checks := common.Coll.GetChecksByName(req.Name)
+	if len(checks) == 0 {
+		return ErrCheckNotFound
+	}
+	for _, c := range checks {
+		if c.Interval() == 0 {
+			return ErrLongRunningCheck
+		}
+	}
+
+	var m sync.Mutex
+	syncSend := func(update CheckRunUpdate) error {
+		m.Lock()
+		defer m.Unlock()
+		return send(update)
+	}
+
+	logger, err := registerCheckRunLogger(req.Name, syncSend)
+	if err != nil {
+		log.Warnf("The logs of the %s check run won't be streamed: %s", req.Name, err)
+	} else {
+		defer logger.unregister()
+	}
+
+	for _, c := range checks {
+		update, err := runCheckInstance(ctx, c, req, syncSend)
+		if err != nil {
+			return err
+		}
+
+		// the logs of the last run are sent before its final stats
+		if logger != nil {
+			logger.flush()
+		}
+		if err := syncSend(update); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runCheckInstance waits for the scheduled run of the instance in progress, if any, to complete, and runs
+// the instance with a dry-run sender
+func runCheckInstance(ctx context.Context, c check.Check, req CheckRunRequest, send func(CheckRunUpdate) error) (CheckRunUpdate, error) {
+	var update CheckRunUpdate
+	var err error
+	for !common.Coll.RunExclusively(c, func() { update, err = runCheckInstanceWithDryRunSender(ctx, c, req, send) }) {
+		select {
+		case <-time.After(runningCheckPollInterval):
+		case <-ctx.Done():
+			return update, ctx.Err()
+		}
+	}
+	return update, err
+}
+
+func runCheckInstanceWithDryRunSender(ctx context.Context, c check.Check, req CheckRunRequest, send func(CheckRunUpdate) error) (CheckRunUpdate, error) {
+	sender, err := aggregator.NewDryRunSender(c.ID())
+	if err != nil {
+		return CheckRunUpdate{}, err
+	}
+	defer sender.Close()
+	defer aggregator.SwapSender(sender, c.ID())()
+
+	log.Infof("Running the %s check instance %s %d time(s)", c, c.ID(), req.Times)
+
+	stats := check.NewStats(c)
+	for run := int64(1); run <= req.Times; run++ {
+		start := time.Now()
+		err := c.Run()
+		warnings := c.GetWarnings()
+		metricStats, _ := c.GetMetricStats()
+		stats.Add(time.Since(start), err, warnings, metricStats)
+
+		if err := send(CheckRunUpdate{CheckID: c.ID(), Run: run, MetricStats: metricStats}); err != nil {
+			return CheckRunUpdate{}, err
+		}
+
+		if run < req.Times {
+			select {
+			case <-time.After(req.Pause):
+			case <-ctx.Done():
+				return CheckRunUpdate{}, ctx.Err()
+			}
+		}
+	}
+
+	results := sender.Flush()
+	st, err := status.GetCheckStatus(c, stats)
+	if err != nil {
+		log.Warnf("Unable to render the status of the %s check instance %s: %s", c, c.ID(), err)
+	}
+
+	return CheckRunUpdate{CheckID: c.ID(), Stats: stats, Results: &results, Status: st}, nil
+}
+
+// checkRunLogger receives the logs of the agent while a check runs and sends the lines mentioning the
+// check, the python checks prefixing their logs with their name
+type checkRunLogger struct {
+	name      string
+	checkName string
+	send      func(CheckRunUpdate) error
+	logger    seelog.LoggerInterface
+}
+
+func registerCheckRunLogger(checkName string, send func(CheckRunUpdate) error) (*checkRunLogger, error) {
+	l := &checkRunLogger{
+		name:      fmt.Sprintf("check-run-%d", atomic.AddUint64(&checkRunLoggers, 1)),
+		checkName: checkName,
+		send:      send,
+	}
+
+	logger, err := seelog.LoggerFromWriterWithMinLevelAndFormat(l, seelog.TraceLvl, "%LEVEL | %Msg%n")
+	if err != nil {
+		return nil, err
+	}
+	if err := log.RegisterAdditionalLogger(l.name, logger); err != nil {
+		logger.Close()
+		return nil, err
+	}
+	l.logger = logger
+
+	return l, nil
+}
+
+// Write implements io.Writer, it's called by seelog with the formatted log lines
+func (l *checkRunLogger) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		if strings.Contains(string(line), l.checkName) {
+			l.send(CheckRunUpdate{Log: string(line)}) //nolint:errcheck
+		}
+	}
+	return len(p), nil
+}
+
+func (l *checkRunLogger) flush() {
+	l.logger.Flush()
+}
+
+func (l *checkRunLogger) unregister() {
+	log.UnregisterAdditionalLogger(l.name) //nolint:errcheck
+	l.logger.Close()
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	// workloadDetector is shared between the requests so that the container
+	// runtimes are only detected once
+	workloadDetector     *collectors.Detector
+	workloadDetectorLock sync.Mutex
+)
+
+func getWorkloadList(w http.ResponseWriter, r *http.Request) {
+	jsonWorkload, err := json.Marshal(GetWorkloadList())
+	if err != nil {
+		log.Errorf("Unable to marshal workload list response: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+	w.Write(jsonWorkload)
+}
+
+// GetWorkloadList returns the agent's current view of the containers, pods and
+// container runtimes of the host
+func GetWorkloadList() response.WorkloadListResponse {
+	list := response.WorkloadListResponse{
+		Runtimes:   []string{},
+		Containers: []response.WorkloadContainer{},
+		Pods:       []response.WorkloadPod{},
+	}
+
+	workloadDetectorLock.Lock()
+	if workloadDetector == nil {
+		workloadDetector = collectors.NewDetector("")
+	}
+	collector, _, err := workloadDetector.GetPreferred()
+	list.Runtimes = append(list.Runtimes, workloadDetector.GetDetectedNames()...)
+	workloadDetectorLock.Unlock()
+
+	if err != nil {
+		log.Debugf("No container collector available: %s", err)
+	} else {
+		containers, err := collector.List()
+		if err != nil {
+			log.Debugf("Unable to list the containers: %s", err)
+		}
+		for _, c := range containers {
+			list.Containers = append(list.Containers, response.WorkloadContainer{
+				ID:       c.ID,
+				Name:     c.Name,
+				Image:    c.Image,
+				Runtime:  c.Type,
+				State:    c.State,
+				Excluded: c.Excluded,
+			})
+		}
+	}
+
+	pods, err := getWorkloadPods()
+	if err != nil {
+		log.Debugf("Unable to list the pods: %s", err)
+	}
+	list.Pods = append(list.Pods, pods...)
+
+	return list
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubelet
+
+package agent
+
+import (
+	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+)
+
+func getWorkloadPods() ([]response.WorkloadPod, error) {
+	ku, err := kubelet.GetKubeUtil()
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := ku.GetLocalPodList()
+	if err != nil {
+		return nil, err
+	}
+
+	workloadPods := make([]response.WorkloadPod, 0, len(pods))
+	for _, pod := range pods {
+		containers := []string{}
+		for _, container := range pod.Status.GetAllContainers() {
+			if !container.IsPending() {
+				containers = append(containers, container.ID)
+			}
+		}
+
+		workloadPods = append(workloadPods, response.WorkloadPod{
+			UID:        pod.Metadata.UID,
+			Name:       pod.Metadata.Name,
+			Namespace:  pod.Metadata.Namespace,
+			Phase:      pod.Status.Phase,
+			Containers: containers,
+		})
+	}
+
+	return workloadPods, nil
+}
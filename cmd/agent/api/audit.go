@@ -0,0 +1,271 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// callerContextKey is the key of the caller of a connection in the contexts of its requests
+type callerContextKey struct{}
+
+// caller identifies the process at the other end of a connection to the API. The PID and the UID
+// are resolved from the credentials of the unix socket peers and from the owner of the loopback TCP
+// sockets, they're set to -1 when unknown.
+type caller struct {
+	Addr        string `json:"addr"`
+	PID         int32  `json:"pid"`
+	UID         int32  `json:"uid"`
+	TLSIdentity string `json:"tls_identity,omitempty"`
+}
+
+// auditEntry is an API call recorded in the audit log
+type auditEntry struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Endpoint  string    `json:"endpoint"`
+	Status    string    `json:"status"`
+	LatencyMs float64   `json:"latency_ms"`
+	Caller    caller    `json:"caller"`
+
+	// conn is the caller of the connection of the call, whose PID and UID may be resolved after the call
+	conn *connCaller
+}
+
+// auditLog keeps the last API calls in a ring
+type auditLog struct {
+	sync.Mutex
+	entries []auditEntry
+	next    int
+	full    bool
+}
+
+// apiAudit is the audit log of the API calls, sized by `api_audit_log_size` when the server starts
+var apiAudit = newAuditLog(0)
+
+// newAuditLog returns an audit log keeping the last size calls, nothing is recorded when size isn't positive
+func newAuditLog(size int) *auditLog {
+	if size < 0 {
+		size = 0
+	}
+	return &auditLog{
+		entries: make([]auditEntry, size),
+	}
+}
+
+// record adds an entry to the log, replacing the oldest one once the log is full
+func (a *auditLog) record(entry auditEntry) {
+	a.Lock()
+	defer a.Unlock()
+
+	if len(a.entries) == 0 {
+		return
+	}
+
+	a.entries[a.next] = entry
+	a.next = (a.next + 1) % len(a.entries)
+	if a.next == 0 {
+		a.full = true
+	}
+}
+
+// enabled returns whether the log records the calls, their callers don't need to be resolved otherwise
+func (a *auditLog) enabled() bool {
+	return len(a.entries) > 0
+}
+
+// snapshot returns the recorded entries, newest first
+func (a *auditLog) snapshot() []auditEntry {
+	a.Lock()
+	defer a.Unlock()
+
+	count := a.next
+	if a.full {
+		count = len(a.entries)
+	}
+
+	snapshot := make([]auditEntry, 0, count)
+	for i := 1; i <= count; i++ {
+		entry := a.entries[(a.next-i+len(a.entries))%len(a.entries)]
+		if entry.conn != nil {
+			c := entry.conn.get()
+			entry.Caller.PID, entry.Caller.UID = c.PID, c.UID
+		}
+		snapshot = append(snapshot, entry)
+	}
+	return snapshot
+}
+
+// initAuditLog sizes the audit log from the configuration, dropping the recorded entries
+func initAuditLog() {
+	apiAudit = newAuditLog(config.Datadog.GetInt("api_audit_log_size"))
+}
+
+// callerResolutionQueueSize is the number of connections whose caller waits to be resolved, the callers of the
+// connections accepted while it's full are left unknown
+const callerResolutionQueueSize = 64
+
+var (
+	// callerResolutions are the TCP connections whose caller is resolved in the background by resolveCallers:
+	// finding the owner of a TCP socket scans the file descriptors of all the processes
+	callerResolutions     = make(chan *connCaller, callerResolutionQueueSize)
+	startCallerResolution sync.Once
+)
+
+// connCaller is the caller of a connection, shared by all its requests
+type connCaller struct {
+	sync.Mutex
+	conn   net.Conn
+	caller caller
+}
+
+// resolve sets the PID and the UID of the caller from the credentials of the peer of the connection
+func (c *connCaller) resolve() {
+	c.Lock()
+	conn := c.conn
+	c.conn = nil
+	c.Unlock()
+
+	if conn == nil {
+		return
+	}
+	if pid, uid, found := peerCredentials(conn); found {
+		c.Lock()
+		c.caller.PID, c.caller.UID = pid, uid
+		c.Unlock()
+	}
+}
+
+// get returns the caller, its PID and UID are -1 until they're resolved
+func (c *connCaller) get() caller {
+	c.Lock()
+	defer c.Unlock()
+	return c.caller
+}
+
+// resolveCallers resolves the callers of the queued connections
+func resolveCallers() {
+	for c := range callerResolutions {
+		c.resolve()
+	}
+}
+
+// connCallerContext is the ConnContext of the API servers, it sets the caller of each connection. The credentials
+// of the unix socket peers are read right away. The owners of the TCP sockets are looked up in the background as
+// soon as the connection is accepted, while the client still holds the socket, and only when the calls are audited.
+func connCallerContext(ctx context.Context, conn net.Conn) context.Context {
+	c := &connCaller{
+		conn: conn,
+		caller: caller{
+			Addr: conn.RemoteAddr().String(),
+			PID:  -1,
+			UID:  -1,
+		},
+	}
+
+	if _, isUnix := conn.(*net.UnixConn); isUnix {
+		c.resolve()
+	} else if apiAudit.enabled() {
+		startCallerResolution.Do(func() { go resolveCallers() })
+		select {
+		case callerResolutions <- c:
+		default:
+			c.conn = nil
+		}
+	} else {
+		c.conn = nil
+	}
+	return context.WithValue(ctx, callerContextKey{}, c)
+}
+
+// connCallerFromContext returns the caller set on the context by connCallerContext, if any
+func connCallerFromContext(ctx context.Context) *connCaller {
+	c, _ := ctx.Value(callerContextKey{}).(*connCaller)
+	return c
+}
+
+// auditCaller returns the caller of a call, with the caller of its connection, if any, whose PID and UID are
+// read when the audit log is
+func auditCaller(ctx context.Context, remoteAddr string) (caller, *connCaller) {
+	conn := connCallerFromContext(ctx)
+	if conn == nil {
+		return caller{Addr: remoteAddr, PID: -1, UID: -1}, nil
+	}
+
+	c := conn.get()
+	if c.Addr == "" {
+		c.Addr = remoteAddr
+	}
+	return c, conn
+}
+
+// auditRequests records the REST requests in the audit log, the gRPC requests are recorded by
+// the telemetry interceptors with their gRPC status
+func auditRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isGRPCRequest(r) || !apiAudit.enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+
+		start := time.Now()
+		next.ServeHTTP(recorder, r)
+		latency := time.Since(start)
+
+		c, conn := auditCaller(r.Context(), r.RemoteAddr)
+		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			c.TLSIdentity = r.TLS.PeerCertificates[0].Subject.String()
+		}
+
+		apiAudit.record(auditEntry{
+			Time:      start,
+			Method:    r.Method,
+			Endpoint:  r.URL.Path,
+			Status:    strconv.Itoa(recorder.code),
+			LatencyMs: float64(latency) / float64(time.Millisecond),
+			Caller:    c,
+			conn:      conn,
+		})
+	})
+}
+
+// auditGRPCCall records a gRPC call in the audit log
+func auditGRPCCall(ctx context.Context, fullMethod string, code string, start time.Time, latency time.Duration) {
+	if !apiAudit.enabled() {
+		return
+	}
+	c, conn := auditCaller(ctx, "")
+	apiAudit.record(auditEntry{
+		Time:      start,
+		Method:    "gRPC",
+		Endpoint:  fullMethod,
+		Status:    code,
+		LatencyMs: float64(latency) / float64(time.Millisecond),
+		Caller:    c,
+		conn:      conn,
+	})
+}
+
+// getAPIAudit returns the audit log of the API calls, newest first
+func getAPIAudit(w http.ResponseWriter, r *http.Request) {
+	body, err := json.Marshal(apiAudit.snapshot())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body) //nolint:errcheck
+}
@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// procRoot is the procfs of the agent, the clients of the API share its network namespace
+var procRoot = "/proc"
+
+// peerCredentials returns the PID and the UID of the process at the other end of the connection, from
+// the credentials of the unix socket peers and from the owner of the loopback TCP sockets
+func peerCredentials(conn net.Conn) (int32, int32, bool) {
+	switch c := conn.(type) {
+	case *net.UnixConn:
+		return unixPeerCredentials(c)
+	default:
+		remote, ok := conn.RemoteAddr().(*net.TCPAddr)
+		if !ok || !remote.IP.IsLoopback() {
+			return 0, 0, false
+		}
+		local, ok := conn.LocalAddr().(*net.TCPAddr)
+		if !ok {
+			return 0, 0, false
+		}
+		return tcpPeerCredentials(remote.Port, local.Port)
+	}
+}
+
+func unixPeerCredentials(conn *net.UnixConn) (int32, int32, bool) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, 0, false
+	}
+
+	var cred *unix.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil || credErr != nil {
+		return 0, 0, false
+	}
+	return cred.Pid, int32(cred.Uid), true
+}
+
+// tcpPeerCredentials looks up the socket of the client in the TCP tables, for its owner and its inode,
+// and the process holding the inode
+func tcpPeerCredentials(clientPort, serverPort int) (int32, int32, bool) {
+	for _, table := range []string{"net/tcp", "net/tcp6"} {
+		uid, inode, found := findTCPSocket(filepath.Join(procRoot, table), clientPort, serverPort)
+		if !found {
+			continue
+		}
+		return findSocketOwner(inode), uid, true
+	}
+	return 0, 0, false
+}
+
+// findTCPSocket returns the UID and the inode of the socket bound to the client port and connected to
+// the server port, in a /proc/net/tcp table
+func findTCPSocket(path string, clientPort, serverPort int) (int32, string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", false
+	}
+	defer f.Close()
+
+	localSuffix := fmt.Sprintf(":%04X", clientPort)
+	remoteSuffix := fmt.Sprintf(":%04X", serverPort)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // skip the header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		if !strings.HasSuffix(fields[1], localSuffix) || !strings.HasSuffix(fields[2], remoteSuffix) {
+			continue
+		}
+		uid, err := strconv.ParseInt(fields[7], 10, 32)
+		if err != nil {
+			return 0, "", false
+		}
+		return int32(uid), fields[9], true
+	}
+	return 0, "", false
+}
+
+// findSocketOwner returns the PID of a process holding the socket inode, or -1 if it can't be found,
+// the processes of other users can't be inspected without privileges
+func findSocketOwner(inode string) int32 {
+	target := "socket:[" + inode + "]"
+
+	entries, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return -1
+	}
+	for _, entry := range entries {
+		pid, err := strconv.ParseInt(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(procRoot, entry.Name(), "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			if link, err := os.Readlink(filepath.Join(fdDir, fd.Name())); err == nil && link == target {
+				return int32(pid)
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package api
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerCredentialsUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := net.Listen("unix", filepath.Join(dir, "api.sock"))
+	require.NoError(t, err)
+	defer l.Close()
+
+	client, err := net.Dial("unix", l.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	pid, uid, found := peerCredentials(conn)
+	require.True(t, found)
+	assert.Equal(t, int32(os.Getpid()), pid)
+	assert.Equal(t, int32(os.Getuid()), uid)
+}
+
+func TestPeerCredentialsLoopback(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	pid, uid, found := peerCredentials(conn)
+	require.True(t, found)
+	assert.Equal(t, int32(os.Getpid()), pid)
+	assert.Equal(t, int32(os.Getuid()), uid)
+}
+
+func TestConnCallerContextAsync(t *testing.T) {
+	defer func(audit *auditLog) { apiAudit = audit }(apiAudit)
+	apiAudit = newAuditLog(10)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the owner of the TCP socket is looked up in the background once the connection is accepted
+	ctx := connCallerContext(context.Background(), conn)
+	c := connCallerFromContext(ctx)
+	require.NotNil(t, c)
+	assert.Equal(t, client.LocalAddr().String(), c.get().Addr)
+
+	assert.Eventually(t, func() bool { return c.get().PID == int32(os.Getpid()) }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(os.Getuid()), c.get().UID)
+}
+
+func TestConnCallerContextAuditDisabled(t *testing.T) {
+	defer func(audit *auditLog) { apiAudit = audit }(apiAudit)
+	apiAudit = newAuditLog(0)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the owners of the TCP sockets aren't looked up when the calls aren't audited
+	c := connCallerFromContext(connCallerContext(context.Background(), conn))
+	require.NotNil(t, c)
+	assert.Nil(t, c.conn)
+	assert.Equal(t, int32(-1), c.get().PID)
+}
+
+func TestFindTCPSocket(t *testing.T) {
+	table := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:1389 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1000 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1389 0100007F:D431 01 00000000:00000000 00:00000000 00000000     0        0 1001 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:D431 0100007F:1389 01 00000000:00000000 00:00000000 00000000  1000        0 1002 1 0000000000000000 20 4 30 10 -1
+`
+	f, err := ioutil.TempFile("", "tcp")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(table)
+	require.NoError(t, err)
+	f.Close()
+
+	uid, inode, found := findTCPSocket(f.Name(), 0xD431, 5001)
+	require.True(t, found)
+	assert.Equal(t, int32(1000), uid)
+	assert.Equal(t, "1002", inode)
+
+	_, _, found = findTCPSocket(f.Name(), 0xD432, 5001)
+	assert.False(t, found)
+}
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogRing(t *testing.T) {
+	audit := newAuditLog(3)
+	assert.Empty(t, audit.snapshot())
+
+	for _, endpoint := range []string{"/a", "/b", "/c", "/d"} {
+		audit.record(auditEntry{Endpoint: endpoint})
+	}
+
+	snapshot := audit.snapshot()
+	require.Len(t, snapshot, 3)
+	assert.Equal(t, "/d", snapshot[0].Endpoint)
+	assert.Equal(t, "/c", snapshot[1].Endpoint)
+	assert.Equal(t, "/b", snapshot[2].Endpoint)
+}
+
+func TestAuditLogDisabled(t *testing.T) {
+	audit := newAuditLog(0)
+	audit.record(auditEntry{Endpoint: "/a"})
+	assert.Empty(t, audit.snapshot())
+}
+
+func TestAuditRequests(t *testing.T) {
+	defer func(audit *auditLog) { apiAudit = audit }(apiAudit)
+	apiAudit = newAuditLog(10)
+
+	handler := auditRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+
+	req := httptest.NewRequest("GET", "/agent/status", nil)
+	req = req.WithContext(context.WithValue(req.Context(), callerContextKey{}, &connCaller{caller: caller{Addr: "@", PID: 42, UID: 1000}}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// the gRPC calls are left to the interceptors
+	req = httptest.NewRequest("POST", "/datadog.api.v1.Agent/GetHostname", nil)
+	req.ProtoMajor = 2
+	req.Header.Set("Content-Type", "application/grpc")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec := httptest.NewRecorder()
+	getAPIAudit(rec, httptest.NewRequest("GET", "/agent/api-audit", nil))
+
+	var entries []auditEntry
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "GET", entries[0].Method)
+	assert.Equal(t, "/agent/status", entries[0].Endpoint)
+	assert.Equal(t, "403", entries[0].Status)
+	assert.Equal(t, caller{Addr: "@", PID: 42, UID: 1000}, entries[0].Caller)
+}
+
+func TestAuditLogResolvedCaller(t *testing.T) {
+	audit := newAuditLog(10)
+
+	// the PID and the UID of the caller resolved after the call are reported
+	conn := &connCaller{caller: caller{Addr: "127.0.0.1:4242", PID: -1, UID: -1}}
+	c, _ := auditCaller(context.WithValue(context.Background(), callerContextKey{}, conn), "")
+	audit.record(auditEntry{Endpoint: "/agent/status", Caller: c, conn: conn})
+
+	conn.caller.PID, conn.caller.UID = 42, 1000
+	snapshot := audit.snapshot()
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, caller{Addr: "127.0.0.1:4242", PID: 42, UID: 1000}, snapshot[0].Caller)
+}
+
+func TestAuditCallerUnknown(t *testing.T) {
+	req := httptest.NewRequest("GET", "/agent/status", nil)
+	c, conn := auditCaller(req.Context(), req.RemoteAddr)
+	assert.Nil(t, conn)
+	assert.Equal(t, req.RemoteAddr, c.Addr)
+	assert.Equal(t, int32(-1), c.PID)
+	assert.Equal(t, int32(-1), c.UID)
+}
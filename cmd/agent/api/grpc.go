@@ -12,17 +12,27 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/DataDog/datadog-agent/cmd/agent/api/agent"
 	pb "github.com/DataDog/datadog-agent/cmd/agent/api/pb"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/flare"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 	hostutil "github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/profiling"
 )
 
 type server struct {
@@ -36,6 +46,10 @@ type serverSecure struct {
 	// than it should be. We should make that concrete type private, and
 	// create a new tagger.Tagger interface that replicates it.
 	tagger *tagger.Tagger
+
+	// streamsStopped is closed when the server stops, to end the tagger streams
+	streamsStopped chan struct{}
+	stopStreams    sync.Once
 }
 
 func (s *server) GetHostname(ctx context.Context, in *pb.HostnameRequest) (*pb.HostnameReply, error) {
@@ -71,22 +85,80 @@ func (s *serverSecure) TaggerStreamEntities(in *pb.StreamTagsRequest, out pb.Age
 	eventCh := s.tagger.Subscribe(cardinality)
 	defer s.tagger.Unsubscribe(eventCh)
 
-	for events := range eventCh {
-		for _, event := range events {
-			response, err := tagger2pbEntityEvent(event)
-			if err != nil {
-				log.Warnf("can't convert tagger entity to protobuf: %s", err)
-				continue
+	for {
+		select {
+		case events, ok := <-eventCh:
+			if !ok {
+				return nil
 			}
+			for _, event := range events {
+				response, err := tagger2pbEntityEvent(event)
+				if err != nil {
+					log.Warnf("can't convert tagger entity to protobuf: %s", err)
+					continue
+				}
 
-			err = out.Send(response)
-			if err != nil {
-				return err
+				err = out.Send(response)
+				if err != nil {
+					return err
+				}
 			}
+		case <-s.streamsStopped:
+			return nil
 		}
 	}
+}
 
-	return nil
+// TaggerStream subscribes to the entities of the Tagger matching the prefixes and the IDs of the request, and
+// streams the tags added to and removed from them
+func (s *serverSecure) TaggerStream(in *pb.TaggerStreamRequest, out pb.AgentSecure_TaggerStreamServer) error {
+	cardinality, err := pb2taggerCardinality(in.Cardinality)
+	if err != nil {
+		return err
+	}
+	filter := &tagger.ListFilter{Prefixes: in.Prefixes, Entities: in.Entities}
+
+	eventCh := s.tagger.Subscribe(cardinality)
+	defer s.tagger.Unsubscribe(eventCh)
+
+	// the tags last sent for each entity, the changes are computed against them
+	sent := make(map[string][]string)
+
+	for {
+		select {
+		case events, ok := <-eventCh:
+			if !ok {
+				return nil
+			}
+			for _, event := range events {
+				if !filter.MatchEntity(event.Entity.ID) {
+					continue
+				}
+
+				response, err := tagger2pbTagsEvent(event, sent)
+				if err != nil {
+					log.Warnf("can't convert tagger entity to protobuf: %s", err)
+					continue
+				}
+				if response == nil {
+					continue
+				}
+
+				if err := out.Send(response); err != nil {
+					return err
+				}
+			}
+		case <-s.streamsStopped:
+			return nil
+		}
+	}
+}
+
+// stopStreaming ends the tagger streams of the clients
+func (s *serverSecure) stopStreaming() {
+	s.stopStreams.Do(func() {
+		close(s.streamsStopped)
+	})
 }
 
 // FetchEntity fetches an entity from the Tagger with the desired cardinality tags.
@@ -113,6 +185,236 @@ func (s *serverSecure) TaggerFetchEntity(ctx context.Context, in *pb.FetchEntity
 	}, nil
 }
 
+// TaggerSetEntity sets the tags of an entity in the Tagger, replacing the ones previously set through the API
+func (s *serverSecure) TaggerSetEntity(ctx context.Context, in *pb.TaggerSetEntityRequest) (*pb.TaggerSetEntityResponse, error) {
+	if in.Id == nil {
+		return nil, status.Errorf(codes.InvalidArgument, `missing "id" parameter`)
+	}
+
+	entityID := fmt.Sprintf("%s://%s", in.Id.Prefix, in.Id.Uid)
+	err := s.tagger.SetEntityTags(entityID, in.LowCardinalityTags, in.OrchestratorCardinalityTags, in.HighCardinalityTags, in.StandardTags)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+	log.Infof("The tags of %s were set through the API", entityID)
+
+	return &pb.TaggerSetEntityResponse{}, nil
+}
+
+// TaggerDeleteEntity removes the tags set on an entity through the API
+func (s *serverSecure) TaggerDeleteEntity(ctx context.Context, in *pb.TaggerDeleteEntityRequest) (*pb.TaggerDeleteEntityResponse, error) {
+	if in.Id == nil {
+		return nil, status.Errorf(codes.InvalidArgument, `missing "id" parameter`)
+	}
+
+	entityID := fmt.Sprintf("%s://%s", in.Id.Prefix, in.Id.Uid)
+	if err := s.tagger.DeleteEntityTags(entityID); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+	log.Infof("The tags of %s set through the API were deleted", entityID)
+
+	return &pb.TaggerDeleteEntityResponse{}, nil
+}
+
+// GetWorkloadList returns the agent's current view of the containers, pods and container runtimes of the host
+func (s *serverSecure) GetWorkloadList(ctx context.Context, in *pb.WorkloadListRequest) (*pb.WorkloadListResponse, error) {
+	list := agent.GetWorkloadList()
+
+	response := &pb.WorkloadListResponse{
+		Runtimes: list.Runtimes,
+	}
+	for _, c := range list.Containers {
+		response.Containers = append(response.Containers, &pb.WorkloadContainer{
+			Id:       c.ID,
+			Name:     c.Name,
+			Image:    c.Image,
+			Runtime:  c.Runtime,
+			State:    c.State,
+			Excluded: c.Excluded,
+		})
+	}
+	for _, pod := range list.Pods {
+		response.Pods = append(response.Pods, &pb.WorkloadPod{
+			Uid:        pod.UID,
+			Name:       pod.Name,
+			Namespace:  pod.Namespace,
+			Phase:      pod.Phase,
+			Containers: pod.Containers,
+		})
+	}
+
+	return response, nil
+}
+
+// RotateAuthToken generates a new auth token and saves it to the auth token file, the previous token stays
+// valid for `auth_token_grace_period` seconds
+func (s *serverSecure) RotateAuthToken(ctx context.Context, in *pb.RotateAuthTokenRequest) (*pb.RotateAuthTokenResponse, error) {
+	gracePeriod := config.Datadog.GetDurationWithUnit("auth_token_grace_period", time.Second)
+	if err := util.RotateAuthToken(gracePeriod); err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to rotate the auth token: %s", err)
+	}
+	log.Infof("The auth token was rotated, the previous one stays valid for %s", gracePeriod)
+
+	return &pb.RotateAuthTokenResponse{
+		GracePeriodSeconds: int64(gracePeriod / time.Second),
+	}, nil
+}
+
+// GetProfile captures the requested runtime profiles of the agent and returns them as a gzipped tarball
+func (s *serverSecure) GetProfile(ctx context.Context, in *pb.ProfileRequest) (*pb.ProfileResponse, error) {
+	duration, err := agent.GetProfileDuration(agent.ProfileRequest{
+		Seconds:  in.Seconds,
+		Profiles: in.Profiles,
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	log.Infof("Capturing a %s profile of the agent", duration)
+	archive, err := profiling.CaptureProfiles(ctx, duration, in.Profiles)
+	if err == profiling.ErrCaptureInProgress {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to capture the profiles: %s", err)
+	}
+
+	return &pb.ProfileResponse{
+		Archive: archive,
+	}, nil
+}
+
+// RunCheck runs the scheduled instances of a check with a dry-run sender and streams the logs mentioning the
+// check, the metric stats of each run and the final status of each instance, along with what it submitted
+func (s *serverSecure) RunCheck(in *pb.RunCheckRequest, out pb.AgentSecure_RunCheckServer) error {
+	req := agent.CheckRunRequest{
+		Name:  in.Name,
+		Times: in.Times,
+		Pause: time.Duration(in.PauseMs) * time.Millisecond,
+	}
+	if err := agent.ValidateCheckRunRequest(&req); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err := agent.RunCheck(out.Context(), req, func(update agent.CheckRunUpdate) error {
+		response, err := checkRunUpdate2pb(update)
+		if err != nil {
+			return err
+		}
+		return out.Send(response)
+	})
+	switch err {
+	case nil:
+		return nil
+	case agent.ErrCheckNotFound:
+		return status.Errorf(codes.NotFound, "%s: %s", in.Name, err)
+	case agent.ErrLongRunningCheck:
+		return status.Errorf(codes.FailedPrecondition, "%s: %s", in.Name, err)
+	default:
+		return status.Errorf(codes.Internal, "unable to run the %s check: %s", in.Name, err)
+	}
+}
+
+// GenerateFlare builds a flare in the agent and streams the progress of the collection, the last response holding
+// the path of the archive and the response of the upload, if requested
+func (s *serverSecure) GenerateFlare(in *pb.GenerateFlareRequest, out pb.AgentSecure_GenerateFlareServer) error {
+	req := agent.FlareRequest{
+		Send:   in.Send,
+		CaseID: in.CaseId,
+		Email:  in.Email,
+	}
+	if len(in.Profiles) > 0 {
+		req.Profile = make(flare.ProfileData, len(in.Profiles))
+		for _, profile := range in.Profiles {
+			req.Profile[profile.Name] = profile.Data
+		}
+	}
+
+	// the collection goes on when the client is gone, the archive is left on disk
+	var sendErr error
+	result, err := agent.GenerateFlare(req, func(progress flare.Progress) {
+		if sendErr == nil {
+			sendErr = out.Send(&pb.GenerateFlareResponse{
+				Progress: &pb.FlareProgress{
+					Step:  progress.Step,
+					Files: progress.Files,
+					Bytes: progress.Bytes,
+				},
+			})
+		}
+	})
+	if result.FilePath == "" {
+		return status.Errorf(codes.Internal, "the flare failed to be created: %s", err)
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	if sendErr = out.Send(&pb.GenerateFlareResponse{
+		ArchivePath:    result.FilePath,
+		UploadResponse: result.UploadResponse,
+	}); sendErr != nil {
+		return sendErr
+	}
+
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return nil
+}
+
+func checkRunUpdate2pb(update agent.CheckRunUpdate) (*pb.RunCheckResponse, error) {
+	response := &pb.RunCheckResponse{
+		CheckId: string(update.CheckID),
+		Log:     update.Log,
+	}
+	if update.Run > 0 {
+		response.Metrics = &pb.CheckRunMetrics{
+			Run:              update.Run,
+			MetricSamples:    update.MetricStats["MetricSamples"],
+			Events:           update.MetricStats["Events"],
+			ServiceChecks:    update.MetricStats["ServiceChecks"],
+			HistogramBuckets: update.MetricStats["HistogramBuckets"],
+		}
+	}
+	if stats := update.Stats; stats != nil {
+		response.Status = &pb.CheckRunStatus{
+			TotalRuns:              int64(stats.TotalRuns),
+			TotalErrors:            int64(stats.TotalErrors),
+			AverageExecutionTimeMs: stats.AverageExecutionTime,
+			LastError:              stats.LastError,
+			LastWarnings:           stats.LastWarnings,
+			TotalMetricSamples:     int64(stats.TotalMetricSamples),
+			TotalEvents:            int64(stats.TotalEvents),
+			TotalServiceChecks:     int64(stats.TotalServiceChecks),
+			Text:                   string(update.Status),
+		}
+	}
+	if results := update.Results; results != nil && response.Status != nil {
+		var err error
+		if len(results.Series) > 0 {
+			if response.Status.Series, err = json.Marshal(results.Series); err != nil {
+				return nil, err
+			}
+		}
+		if len(results.Sketches) > 0 {
+			if response.Status.Sketches, err = json.Marshal(results.Sketches); err != nil {
+				return nil, err
+			}
+		}
+		if len(results.ServiceChecks) > 0 {
+			if response.Status.ServiceChecks, err = json.Marshal(results.ServiceChecks); err != nil {
+				return nil, err
+			}
+		}
+		if len(results.Events) > 0 {
+			if response.Status.Events, err = json.Marshal(results.Events); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return response, nil
+}
+
 func tagger2pbEntityID(entityID string) (*pb.EntityId, error) {
 	parts := strings.SplitN(entityID, "://", 2)
 	if len(parts) != 2 {
@@ -157,6 +459,80 @@ func tagger2pbEntityEvent(event tagger.EntityEvent) (*pb.StreamTagsResponse, err
 	}, nil
 }
 
+// tagger2pbTagsEvent returns the tags of the entity added and removed since the ones in sent, which is updated,
+// or nil if they didn't change
+func tagger2pbTagsEvent(event tagger.EntityEvent, sent map[string][]string) (*pb.TaggerStreamResponse, error) {
+	entity := event.Entity
+	entityID, err := tagger2pbEntityID(entity.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	var eventType pb.EventType
+	switch event.EventType {
+	case tagger.EventTypeAdded:
+		eventType = pb.EventType_ADDED
+	case tagger.EventTypeModified:
+		eventType = pb.EventType_MODIFIED
+	case tagger.EventTypeDeleted:
+		eventType = pb.EventType_DELETED
+	default:
+		return nil, fmt.Errorf("invalid event type %q", event.EventType)
+	}
+
+	if event.EventType != tagger.EventTypeDeleted {
+		tags = make([]string, 0, len(entity.LowCardinalityTags)+len(entity.OrchestratorCardinalityTags)+len(entity.HighCardinalityTags))
+		tags = append(tags, entity.LowCardinalityTags...)
+		tags = append(tags, entity.OrchestratorCardinalityTags...)
+		tags = append(tags, entity.HighCardinalityTags...)
+	}
+
+	previous := sent[entity.ID]
+	added, removed := diffTags(previous, tags)
+	if eventType == pb.EventType_MODIFIED && len(added) == 0 && len(removed) == 0 {
+		return nil, nil
+	}
+
+	if event.EventType == tagger.EventTypeDeleted {
+		delete(sent, entity.ID)
+	} else {
+		sent[entity.ID] = tags
+	}
+
+	return &pb.TaggerStreamResponse{
+		Type:        eventType,
+		Id:          entityID,
+		AddedTags:   added,
+		RemovedTags: removed,
+	}, nil
+}
+
+// diffTags returns the tags of current missing from previous, and the ones of previous missing from current
+func diffTags(previous, current []string) ([]string, []string) {
+	previousSet := make(map[string]struct{}, len(previous))
+	for _, tag := range previous {
+		previousSet[tag] = struct{}{}
+	}
+	currentSet := make(map[string]struct{}, len(current))
+	for _, tag := range current {
+		currentSet[tag] = struct{}{}
+	}
+
+	var added, removed []string
+	for _, tag := range current {
+		if _, found := previousSet[tag]; !found {
+			added = append(added, tag)
+		}
+	}
+	for _, tag := range previous {
+		if _, found := currentSet[tag]; !found {
+			removed = append(removed, tag)
+		}
+	}
+	return added, removed
+}
+
 func pb2taggerCardinality(pbCardinality pb.TagCardinality) (collectors.TagCardinality, error) {
 	switch pbCardinality {
 	case pb.TagCardinality_LOW:
@@ -169,3 +545,56 @@ func pb2taggerCardinality(pbCardinality pb.TagCardinality) (collectors.TagCardin
 
 	return 0, status.Errorf(codes.InvalidArgument, "invalid cardinality %q", pbCardinality)
 }
+
+// healthCheckInterval is the interval at which WatchHealth checks the health of the components, in addition
+// to the notifications of the changes, the health of the components which stopped answering the health
+// checks changing without notification
+const healthCheckInterval = 15 * time.Second
+
+// WatchHealth streams the health of the components of the agent, first when the call is made and then each
+// time it changes, until the client or the server stops
+func (s *serverSecure) WatchHealth(in *pb.WatchHealthRequest, out pb.AgentSecure_WatchHealthServer) error {
+	changes, stopWatching := health.Watch()
+	defer stopWatching()
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	var last *health.DetailedStatus
+	for {
+		current := health.GetDetailed()
+		if last == nil || !reflect.DeepEqual(*last, current) {
+			if err := out.Send(health2pbStatus(current)); err != nil {
+				return err
+			}
+			last = &current
+		}
+
+		select {
+		case <-changes:
+		case <-ticker.C:
+		case <-out.Context().Done():
+			return nil
+		case <-s.streamsStopped:
+			return nil
+		}
+	}
+}
+
+func health2pbStatus(h health.DetailedStatus) *pb.HealthStatus {
+	components := make([]*pb.ComponentHealth, 0, len(h.Components))
+	for _, component := range h.Components {
+		components = append(components, &pb.ComponentHealth{
+			Name:   component.Name,
+			State:  string(component.State),
+			Ready:  component.Ready,
+			Live:   component.Live,
+			Reason: component.Reason,
+		})
+	}
+	return &pb.HealthStatus{
+		Ready:      h.Ready,
+		Live:       h.Live,
+		Components: components,
+	}
+}
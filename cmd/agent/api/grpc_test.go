@@ -0,0 +1,177 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gorilla "github.com/gorilla/mux"
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/api/agent"
+	pb "github.com/DataDog/datadog-agent/cmd/agent/api/pb"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+)
+
+func TestCheckRunUpdate2pb(t *testing.T) {
+	response, err := checkRunUpdate2pb(agent.CheckRunUpdate{
+		CheckID:     "foo:123",
+		Run:         1,
+		MetricStats: map[string]int64{"MetricSamples": 2, "ServiceChecks": 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &pb.RunCheckResponse{
+		CheckId: "foo:123",
+		Metrics: &pb.CheckRunMetrics{Run: 1, MetricSamples: 2, ServiceChecks: 1},
+	}, response)
+
+	response, err = checkRunUpdate2pb(agent.CheckRunUpdate{
+		CheckID: "foo:123",
+		Stats:   &check.Stats{TotalRuns: 1, TotalServiceChecks: 1},
+		Results: &aggregator.DryRunResults{
+			ServiceChecks: metrics.ServiceChecks{{CheckName: "foo.can_connect", Status: metrics.ServiceCheckOK, Ts: 1}},
+		},
+		Status: []byte("foo (1.0.0)"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), response.Status.TotalRuns)
+	assert.Equal(t, "foo (1.0.0)", response.Status.Text)
+	assert.Nil(t, response.Status.Series)
+	assert.Nil(t, response.Status.Events)
+	assert.JSONEq(t, `[{"check":"foo.can_connect","host_name":"","timestamp":1,"status":0,"message":"","tags":null}]`, string(response.Status.ServiceChecks))
+}
+
+func TestTagger2pbTagsEvent(t *testing.T) {
+	sent := make(map[string][]string)
+	entityID := &pb.EntityId{Prefix: "container_id", Uid: "abc"}
+
+	response, err := tagger2pbTagsEvent(tagger.EntityEvent{
+		EventType: tagger.EventTypeAdded,
+		Entity: tagger.Entity{
+			ID:                  "container_id://abc",
+			LowCardinalityTags:  []string{"image_name:redis"},
+			HighCardinalityTags: []string{"container_name:redis-1"},
+		},
+	}, sent)
+	require.NoError(t, err)
+	assert.Equal(t, &pb.TaggerStreamResponse{
+		Type:      pb.EventType_ADDED,
+		Id:        entityID,
+		AddedTags: []string{"image_name:redis", "container_name:redis-1"},
+	}, response)
+
+	// only the changes are sent
+	response, err = tagger2pbTagsEvent(tagger.EntityEvent{
+		EventType: tagger.EventTypeModified,
+		Entity: tagger.Entity{
+			ID:                  "container_id://abc",
+			LowCardinalityTags:  []string{"image_name:redis", "env:prod"},
+			HighCardinalityTags: []string{"container_name:redis-2"},
+		},
+	}, sent)
+	require.NoError(t, err)
+	assert.Equal(t, &pb.TaggerStreamResponse{
+		Type:        pb.EventType_MODIFIED,
+		Id:          entityID,
+		AddedTags:   []string{"env:prod", "container_name:redis-2"},
+		RemovedTags: []string{"container_name:redis-1"},
+	}, response)
+
+	// nothing is sent when the tags didn't change
+	response, err = tagger2pbTagsEvent(tagger.EntityEvent{
+		EventType: tagger.EventTypeModified,
+		Entity: tagger.Entity{
+			ID:                  "container_id://abc",
+			LowCardinalityTags:  []string{"image_name:redis", "env:prod"},
+			HighCardinalityTags: []string{"container_name:redis-2"},
+		},
+	}, sent)
+	require.NoError(t, err)
+	assert.Nil(t, response)
+
+	response, err = tagger2pbTagsEvent(tagger.EntityEvent{
+		EventType: tagger.EventTypeDeleted,
+		Entity:    tagger.Entity{ID: "container_id://abc"},
+	}, sent)
+	require.NoError(t, err)
+	assert.Equal(t, &pb.TaggerStreamResponse{
+		Type:        pb.EventType_DELETED,
+		Id:          entityID,
+		RemovedTags: []string{"image_name:redis", "env:prod", "container_name:redis-2"},
+	}, response)
+	assert.Empty(t, sent)
+}
+
+func TestTaggerSetDeleteEntity(t *testing.T) {
+	s := &serverSecure{tagger: tagger.GetDefaultTagger()}
+	id := &pb.EntityId{Prefix: "container_id", Uid: "grpc-test"}
+
+	_, err := s.TaggerSetEntity(context.Background(), &pb.TaggerSetEntityRequest{
+		Id:                 id,
+		LowCardinalityTags: []string{"team:a"},
+		StandardTags:       []string{"service:web"},
+	})
+	require.NoError(t, err)
+	defer s.tagger.DeleteEntityTags("container_id://grpc-test")
+
+	fetched, err := s.TaggerFetchEntity(context.Background(), &pb.FetchEntityRequest{Id: id, Cardinality: pb.TagCardinality_LOW})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team:a"}, fetched.Tags)
+
+	_, err = s.TaggerDeleteEntity(context.Background(), &pb.TaggerDeleteEntityRequest{Id: id})
+	assert.NoError(t, err)
+
+	_, err = s.TaggerSetEntity(context.Background(), &pb.TaggerSetEntityRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.TaggerSetEntity(context.Background(), &pb.TaggerSetEntityRequest{Id: &pb.EntityId{Uid: "grpc-test"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = s.TaggerDeleteEntity(context.Background(), &pb.TaggerDeleteEntityRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTaggerEntityAuthenticated(t *testing.T) {
+	// the secure methods are left to the auth interceptor of the server
+	_, overridden := interface{}(&serverSecure{}).(grpc_auth.ServiceAuthFuncOverride)
+	assert.False(t, overridden)
+
+	_, err := grpcAuth(context.Background())
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = grpcAuth(metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer invalid")))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	router := gorilla.NewRouter()
+	router.Use(validateToken)
+	agent.SetupHandlers(router)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", "/tagger-entity", strings.NewReader(`{"entity":"container_id://auth-test","low_cardinality_tags":["team:a"]}`)),
+		httptest.NewRequest("DELETE", "/tagger-entity?entity=container_id://auth-test", nil),
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		req.Header.Set("Authorization", "Bearer invalid")
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	}
+
+	assert.NotContains(t, tagger.List(collectors.LowCardinality).Entities, "container_id://auth-test")
+}
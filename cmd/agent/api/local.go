@@ -0,0 +1,201 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	stdLog "log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	gorilla "github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/api/agent"
+	"github.com/DataDog/datadog-agent/cmd/agent/api/check"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	corsAllowedMethods = "GET, POST, DELETE, OPTIONS"
+	corsMaxAge         = "600"
+)
+
+// corsAllowedHeaders are the request headers the browsers may send to the api
+var corsAllowedHeaders = strings.Join([]string{"Authorization", "Content-Type", util.APIVersionHeader}, ", ")
+
+// corsExposedHeaders are the response headers the browsers let the scripts read
+var corsExposedHeaders = strings.Join([]string{util.APIVersionHeader, util.DeprecationHeader}, ", ")
+
+// localServers serve the REST api in plain HTTP to the local web GUI and tooling
+var localServers []*http.Server
+
+// startLocalServers serves the REST api, without TLS, on the unix socket set by `api_local_socket` and on
+// the loopback port set by `api_local_port`. Both require the auth token, the access to the socket is also
+// restricted by its file permissions.
+func startLocalServers() error {
+	allowedOrigins := config.Datadog.GetStringSlice("api_cors_allowed_origins")
+
+	if socketPath := config.Datadog.GetString("api_local_socket"); socketPath != "" {
+		l, err := listenUnixSocket(socketPath)
+		if err != nil {
+			return fmt.Errorf("unable to listen on the api socket %s: %v", socketPath, err)
+		}
+		serveLocal(l, newLocalHandler(allowedOrigins))
+		log.Infof("The api is also served on the unix socket %s", socketPath)
+	}
+
+	if port := config.Datadog.GetInt("api_local_port"); port > 0 {
+		address, err := config.GetIPCAddress()
+		if err != nil {
+			return err
+		}
+		// localhost may resolve to a non-loopback address, the api is only served on the loopback interface
+		if address == "localhost" {
+			address = "127.0.0.1"
+		}
+		l, err := net.Listen("tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+		if err != nil {
+			return fmt.Errorf("unable to listen on the api local port %d: %v", port, err)
+		}
+		serveLocal(l, newLocalHandler(allowedOrigins))
+		log.Infof("The api is also served on %s", l.Addr())
+	}
+
+	return nil
+}
+
+// stopLocalServers closes the listeners of the local servers and waits for the in-flight requests to complete
+func stopLocalServers(ctx context.Context) {
+	for _, srv := range localServers {
+		if err := srv.Shutdown(ctx); err != nil {
+			srv.Close() //nolint:errcheck
+		}
+	}
+	localServers = nil
+}
+
+func serveLocal(l net.Listener, handler http.Handler) {
+	srv := &http.Server{
+		Handler: handler,
+		ErrorLog: stdLog.New(&config.ErrorLogWriter{
+			AdditionalDepth: 5, // Use a stack depth of 5 on top of the default one to get a relevant filename in the stdlib
+		}, "Error from the agent local http API server: ", 0), // log errors to seelog,
+		WriteTimeout: config.Datadog.GetDurationWithUnit("server_timeout", time.Second),
+		ConnContext:  connCallerContext,
+	}
+	localServers = append(localServers, srv)
+	go srv.Serve(l) //nolint:errcheck
+}
+
+// listenUnixSocket listens on a unix socket only accessible to the user and the group of the agent. The
+// socket is created in a temporary directory only accessible to the agent, then moved to its path once its
+// permissions are restricted, so that it's never reachable with the permissions set by the umask.
+func listenUnixSocket(path string) (net.Listener, error) {
+	// the socket of a previous run may have been left behind
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	tmpDir, err := ioutil.TempDir(filepath.Dir(path), ".api-socket-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tmpPath := filepath.Join(tmpDir, filepath.Base(path))
+	l, err := net.Listen("unix", tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	// the socket is removed from its path when the listener is closed
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	if err := os.Chmod(tmpPath, 0660); err != nil {
+		l.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return &unixSocketListener{Listener: l, path: path}, nil
+}
+
+// unixSocketListener removes the socket from its path when it's closed
+type unixSocketListener struct {
+	net.Listener
+	path string
+}
+
+// Close closes the listener and removes its socket
+func (l *unixSocketListener) Close() error {
+	err := l.Listener.Close()
+	if rmErr := os.Remove(l.path); err == nil && rmErr != nil && !os.IsNotExist(rmErr) {
+		err = rmErr
+	}
+	return err
+}
+
+// newLocalHandler returns the handler of the REST api served by the local servers
+func newLocalHandler(allowedOrigins []string) http.Handler {
+	agentMux := gorilla.NewRouter()
+	checkMux := gorilla.NewRouter()
+	agentMux.Use(validateToken)
+	checkMux.Use(validateToken)
+	agentMux.HandleFunc(strings.TrimPrefix(util.VersionNegotiationPath, "/agent"), getVersionNegotiation).Methods("GET")
+	agentMux.HandleFunc("/api-audit", getAPIAudit).Methods("GET")
+
+	mux := http.NewServeMux()
+	mux.Handle("/agent/", http.StripPrefix("/agent", agent.SetupHandlers(agentMux)))
+	mux.Handle("/check/", http.StripPrefix("/check", check.SetupHandlers(checkMux)))
+
+	return auditRequests(cors(allowedOrigins, negotiateAPIVersion(mux)))
+}
+
+// cors sets the CORS headers on the responses to the requests of the allowed origins and answers their
+// preflight requests, which don't carry the auth token. "*" allows any origin: the responses to the origins
+// which aren't listed explicitly get the literal "*", which browsers never combine with credentials.
+func cors(allowedOrigins []string, next http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !(allowed["*"] || allowed[origin]) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if allowed[origin] {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		next.ServeHTTP(w, r)
+	})
+}
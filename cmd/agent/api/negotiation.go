@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	gorilla "github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// deprecatedEndpoints maps the deprecated endpoints, still served to the older clients until
+// util.MinAPIVersion is increased, to the endpoints replacing them, if any
+var deprecatedEndpoints = map[string]string{}
+
+// apiDescription describes the IPC API served by the agent, it is set when the server starts
+var apiDescription = &util.VersionNegotiation{}
+
+// describeAPI lists the REST endpoints of the routers, served under their prefix, and the gRPC
+// methods of the server
+func describeAPI(routers map[string]*gorilla.Router, grpcServer *grpc.Server) (*util.VersionNegotiation, error) {
+	description := &util.VersionNegotiation{
+		AgentVersion:  version.AgentVersion,
+		APIVersion:    util.APIVersion,
+		MinAPIVersion: util.MinAPIVersion,
+	}
+
+	addEndpoint := func(path string, methods []string) {
+		endpoint := util.EndpointInfo{Path: path, Methods: methods}
+		if replacement, deprecated := deprecatedEndpoints[path]; deprecated {
+			endpoint.Deprecated = true
+			endpoint.Replacement = replacement
+		}
+		description.Endpoints = append(description.Endpoints, endpoint)
+	}
+
+	for prefix, router := range routers {
+		err := router.Walk(func(route *gorilla.Route, router *gorilla.Router, ancestors []*gorilla.Route) error {
+			path, err := route.GetPathTemplate()
+			if err != nil {
+				return nil
+			}
+			// routes without method matchers match all the methods
+			methods, _ := route.GetMethods()
+			addEndpoint(prefix+path, methods)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for service, info := range grpcServer.GetServiceInfo() {
+		for _, method := range info.Methods {
+			addEndpoint(fmt.Sprintf("/%s/%s", service, method.Name), nil)
+		}
+	}
+
+	sort.Slice(description.Endpoints, func(i, j int) bool {
+		return description.Endpoints[i].Path < description.Endpoints[j].Path
+	})
+
+	return description, nil
+}
+
+// checkClientAPIVersion returns an error if the client announced an API version the agent
+// doesn't serve anymore. The clients that don't announce their version are always served.
+func checkClientAPIVersion(value string) error {
+	clientVersion, err := util.ParseAPIVersion(value)
+	if err != nil {
+		return err
+	}
+
+	if clientVersion != 0 && clientVersion < util.MinAPIVersion {
+		return fmt.Errorf("API version %d is not supported anymore, the agent %s serves API versions %d to %d",
+			clientVersion, version.AgentVersion, util.MinAPIVersion, util.APIVersion)
+	}
+	return nil
+}
+
+// negotiateAPIVersion announces the API version of the agent in the responses, refuses the requests of the
+// clients whose API version isn't served anymore and flags the responses of the deprecated endpoints
+func negotiateAPIVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(util.APIVersionHeader, strconv.Itoa(util.APIVersion))
+
+		if err := checkClientAPIVersion(r.Header.Get(util.APIVersionHeader)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if endpoint := apiDescription.GetEndpoint(r.Method, r.URL.Path); endpoint != nil && endpoint.Deprecated {
+			deprecation := "true"
+			if endpoint.Replacement != "" {
+				deprecation = endpoint.Replacement
+			}
+			w.Header().Set(util.DeprecationHeader, deprecation)
+			log.Debugf("Deprecated endpoint %s %s queried by a client using API version %q", r.Method, r.URL.Path, r.Header.Get(util.APIVersionHeader))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkGRPCAPIVersion is the gRPC counterpart of negotiateAPIVersion, the API versions are exchanged
+// in the metadata
+func checkGRPCAPIVersion(ctx context.Context, fullMethod string, setHeader func(metadata.MD) error) error {
+	if err := setHeader(metadata.Pairs(util.APIVersionMetadataKey, strconv.Itoa(util.APIVersion))); err != nil {
+		log.Debugf("Unable to set the API version header of %s: %v", fullMethod, err)
+	}
+
+	var clientVersion string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(util.APIVersionMetadataKey); len(values) > 0 {
+			clientVersion = values[0]
+		}
+	}
+
+	if err := checkClientAPIVersion(clientVersion); err != nil {
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+
+	if endpoint := apiDescription.GetEndpoint("", fullMethod); endpoint != nil && endpoint.Deprecated {
+		log.Debugf("Deprecated method %s called by a client using API version %q", fullMethod, clientVersion)
+	}
+
+	return nil
+}
+
+func grpcVersionUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	setHeader := func(md metadata.MD) error {
+		return grpc.SetHeader(ctx, md)
+	}
+	if err := checkGRPCAPIVersion(ctx, info.FullMethod, setHeader); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func grpcVersionStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := checkGRPCAPIVersion(ss.Context(), info.FullMethod, ss.SetHeader); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// getVersionNegotiation describes the IPC API served by the agent, for the clients to discover the
+// endpoints they can use
+func getVersionNegotiation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j, err := json.Marshal(apiDescription)
+	if err != nil {
+		log.Errorf("Unable to marshal the API description: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(j)
+}
@@ -22,8 +22,54 @@ type TaggerListResponse struct {
 	Entities map[string]TaggerListEntity `json:"entities"`
 }
 
+// TaggerListRequest holds the filters of a tagger list request, the empty
+// fields don't filter anything
+type TaggerListRequest struct {
+	Cardinality string   `json:"cardinality"`
+	Prefixes    []string `json:"prefixes"`
+	Entities    []string `json:"entities"`
+	Sources     []string `json:"sources"`
+}
+
+// TaggerEntityRequest holds the tags of an entity set through the
+// tagger-entity endpoint, the entity ID is in the prefix://uid format
+type TaggerEntityRequest struct {
+	Entity                      string   `json:"entity"`
+	LowCardinalityTags          []string `json:"low_cardinality_tags"`
+	OrchestratorCardinalityTags []string `json:"orchestrator_cardinality_tags"`
+	HighCardinalityTags         []string `json:"high_cardinality_tags"`
+	StandardTags                []string `json:"standard_tags"`
+}
+
 // TaggerListEntity holds the tagging info about an entity
 type TaggerListEntity struct {
 	Sources []string `json:"sources"`
 	Tags    []string `json:"tags"`
 }
+
+// WorkloadListResponse holds the containers and pods known by the agent,
+// as well as the container runtimes it detected
+type WorkloadListResponse struct {
+	Runtimes   []string            `json:"runtimes"`
+	Containers []WorkloadContainer `json:"containers"`
+	Pods       []WorkloadPod       `json:"pods"`
+}
+
+// WorkloadContainer holds the info about a container known by the agent
+type WorkloadContainer struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Image    string `json:"image"`
+	Runtime  string `json:"runtime"`
+	State    string `json:"state"`
+	Excluded bool   `json:"excluded"`
+}
+
+// WorkloadPod holds the info about a pod known by the agent
+type WorkloadPod struct {
+	UID        string   `json:"uid"`
+	Name       string   `json:"name"`
+	Namespace  string   `json:"namespace"`
+	Phase      string   `json:"phase"`
+	Containers []string `json:"containers"`
+}
@@ -41,7 +41,7 @@ func validateToken(next http.Handler) http.Handler {
 // parseToken parses the token and validate it for our gRPC API, it returns an empty
 // struct and an error or nil
 func parseToken(token string) (struct{}, error) {
-	if token != util.GetAuthToken() {
+	if !util.IsValidAuthToken(token) {
 		return struct{}{}, errors.New("Invalid session token")
 	}
 
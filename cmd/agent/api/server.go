@@ -20,6 +20,7 @@ import (
 	"strings"
 	"time"
 
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"google.golang.org/grpc"
@@ -31,11 +32,14 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 	gorilla "github.com/gorilla/mux"
 )
 
 var (
-	listener net.Listener
+	listener   net.Listener
+	apiServer  *http.Server
+	grpcServer *grpc.Server
 )
 
 // grpcHandlerFunc returns an http.Handler that delegates to grpcServer on incoming gRPC
@@ -43,8 +47,7 @@ var (
 func grpcHandlerFunc(grpcServer *grpc.Server, otherHandler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		// This is a partial recreation of gRPC's internal checks https://github.com/grpc/grpc-go/pull/514/files#diff-95e9a25b738459a2d3030e1e6fa2a718R61
-		if r.ProtoMajor == 2 && strings.Contains(r.Header.Get("Content-Type"), "application/grpc") {
+		if isGRPCRequest(r) {
 			grpcServer.ServeHTTP(w, r)
 		} else {
 			otherHandler.ServeHTTP(w, r)
@@ -52,6 +55,12 @@ func grpcHandlerFunc(grpcServer *grpc.Server, otherHandler http.Handler) http.Ha
 	})
 }
 
+// isGRPCRequest returns whether the request is a gRPC call. This is a partial recreation of gRPC's
+// internal checks https://github.com/grpc/grpc-go/pull/514/files#diff-95e9a25b738459a2d3030e1e6fa2a718R61
+func isGRPCRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.Contains(r.Header.Get("Content-Type"), "application/grpc")
+}
+
 // StartServer creates the router and starts the HTTP server
 func StartServer() error {
 
@@ -70,20 +79,34 @@ func StartServer() error {
 	if err != nil {
 		return err
 	}
+	startAuthTokenRotation()
+	initAuditLog()
 
 	// gRPC server
 	mux := http.NewServeMux()
 	opts := []grpc.ServerOption{
 		grpc.Creds(credentials.NewClientTLSFromCert(tlsCertPool, tlsAddr)),
-		grpc.StreamInterceptor(grpc_auth.StreamServerInterceptor(grpcAuth)),
-		grpc.UnaryInterceptor(grpc_auth.UnaryServerInterceptor(grpcAuth)),
+		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(
+			grpcTelemetryStreamInterceptor,
+			grpc_auth.StreamServerInterceptor(grpcAuth),
+			grpcVersionStreamInterceptor,
+		)),
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
+			grpcTelemetryUnaryInterceptor,
+			grpc_auth.UnaryServerInterceptor(grpcAuth),
+			grpcVersionUnaryInterceptor,
+		)),
 	}
 
 	s := grpc.NewServer(opts...)
+	secure := &serverSecure{
+		tagger:         tagger.GetDefaultTagger(),
+		streamsStopped: make(chan struct{}),
+	}
 	pb.RegisterAgentServer(s, &server{})
-	pb.RegisterAgentSecureServer(s, &serverSecure{
-		tagger: tagger.GetDefaultTagger(),
-	})
+	pb.RegisterAgentSecureServer(s, secure)
+	RegisterShutdownHook("tagger_stream_entities", secure.stopStreaming)
+	RegisterShutdownHook("dogstatsd_capture_stream", agent.StopDogstatsdCaptureStreams)
 
 	dcreds := credentials.NewTLS(&tls.Config{
 		ServerName: tlsAddr,
@@ -116,11 +139,19 @@ func StartServer() error {
 
 	mux.Handle("/agent/", http.StripPrefix("/agent", agent.SetupHandlers(agentMux)))
 	mux.Handle("/check/", http.StripPrefix("/check", check.SetupHandlers(checkMux)))
-	mux.Handle("/", gwmux)
+	mux.Handle("/", gatewayTelemetry(gwmux))
+
+	// Describe the API for the clients of other versions
+	agentMux.HandleFunc(strings.TrimPrefix(util.VersionNegotiationPath, "/agent"), getVersionNegotiation).Methods("GET")
+	agentMux.HandleFunc("/api-audit", getAPIAudit).Methods("GET")
+	apiDescription, err = describeAPI(map[string]*gorilla.Router{"/agent": agentMux, "/check": checkMux}, s)
+	if err != nil {
+		return fmt.Errorf("Unable to describe the api: %v", err)
+	}
 
 	srv := &http.Server{
 		Addr:    tlsAddr,
-		Handler: grpcHandlerFunc(s, mux),
+		Handler: auditRequests(grpcHandlerFunc(s, negotiateAPIVersion(mux))),
 		// Handler: grpcHandlerFunc(s, r),
 		TLSConfig: &tls.Config{
 			Certificates: []tls.Certificate{*tlsKeyPair},
@@ -129,21 +160,48 @@ func StartServer() error {
 		ErrorLog: stdLog.New(&config.ErrorLogWriter{
 			AdditionalDepth: 5, // Use a stack depth of 5 on top of the default one to get a relevant filename in the stdlib
 		}, "Error from the agent http API server: ", 0), // log errors to seelog,
-		WriteTimeout: config.Datadog.GetDuration("server_timeout") * time.Second,
+		WriteTimeout: config.Datadog.GetDurationWithUnit("server_timeout", time.Second),
+		ConnContext:  connCallerContext,
 	}
 
 	tlsListener := tls.NewListener(listener, srv.TLSConfig)
 
+	apiServer = srv
+	grpcServer = s
 	go srv.Serve(tlsListener) //nolint:errcheck
-	return nil
+
+	return startLocalServers()
 }
 
-// StopServer closes the connection and the server
-// stops listening to new commands.
+// StopServer stops listening to new commands, ends the streams and waits for the in-flight
+// requests to complete, up to `server_shutdown_timeout` seconds, before closing the connections.
 func StopServer() {
-	if listener != nil {
-		listener.Close()
+	if apiServer == nil {
+		if listener != nil {
+			listener.Close()
+		}
+		return
+	}
+
+	runShutdownHooks()
+
+	timeout := config.Datadog.GetDurationWithUnit("server_shutdown_timeout", time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	stopLocalServers(ctx)
+
+	// The gRPC requests are served by the http server, which drains them. GracefulStop can only be
+	// called once they're complete, as it can't drain the connections handled through ServeHTTP.
+	if err := apiServer.Shutdown(ctx); err != nil {
+		log.Warnf("Could not drain the connections of the api server in %s, closing them: %v", timeout, err)
+		apiServer.Close() //nolint:errcheck
+		grpcServer.Stop()
+	} else {
+		grpcServer.GracefulStop()
 	}
+	apiServer = nil
+	grpcServer = nil
 }
 
 // ServerAddress retruns the server address.
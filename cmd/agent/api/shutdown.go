@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	shutdownHooksMutex sync.Mutex
+	shutdownHooks      = make(map[string]func())
+)
+
+// RegisterShutdownHook registers a function called when the server stops, before the connections are
+// drained. The streaming endpoints, which never complete on their own, use it to end their streams so
+// that the server doesn't wait for them until the drain timeout.
+func RegisterShutdownHook(name string, hook func()) {
+	shutdownHooksMutex.Lock()
+	defer shutdownHooksMutex.Unlock()
+
+	if _, found := shutdownHooks[name]; found {
+		log.Warnf("The api server shutdown hook %s is already registered, replacing it", name)
+	}
+	shutdownHooks[name] = hook
+}
+
+// runShutdownHooks calls the registered hooks once, they are removed from the registry
+func runShutdownHooks() {
+	shutdownHooksMutex.Lock()
+	hooks := shutdownHooks
+	shutdownHooks = make(map[string]func())
+	shutdownHooksMutex.Unlock()
+
+	for name, hook := range hooks {
+		log.Debugf("Running the api server shutdown hook %s", name)
+		hook()
+	}
+}
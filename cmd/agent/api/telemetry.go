@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+// gatewayMethod is the name under which the requests served by the grpc-gateway are reported
+const gatewayMethod = "gateway"
+
+// latencyBuckets are the buckets of the latency histograms, in seconds
+var latencyBuckets = []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10}
+
+var (
+	tlmGRPCRequests = telemetry.NewCounter("api", "grpc_requests",
+		[]string{"method", "code"}, "Count of gRPC requests served by the API, by method and status code")
+	tlmGRPCLatency = telemetry.NewHistogram("api", "grpc_latency",
+		[]string{"method"}, "Latency of the unary gRPC requests served by the API, in seconds", latencyBuckets)
+	tlmGatewayRequests = telemetry.NewCounter("api", "gateway_requests",
+		[]string{"code"}, "Count of requests served by the grpc-gateway, by HTTP status code")
+	tlmGatewayLatency = telemetry.NewHistogram("api", "gateway_latency",
+		nil, "Latency of the requests served by the grpc-gateway, in seconds", latencyBuckets)
+
+	apiStats = newRequestsStats()
+)
+
+func init() {
+	expvar.Publish("api", expvar.Func(func() interface{} {
+		return apiStats.snapshot()
+	}))
+}
+
+// methodStats holds the statistics of the requests to one method of the API, reported in the status page
+type methodStats struct {
+	Requests     int64
+	Errors       int64
+	LatencyTotal time.Duration
+	// LatencyCount is the number of requests in LatencyTotal, the streams have no latency
+	LatencyCount int64
+}
+
+// requestsStats holds the statistics of the requests served by the API, by method
+type requestsStats struct {
+	sync.Mutex
+	methods map[string]*methodStats
+}
+
+func newRequestsStats() *requestsStats {
+	return &requestsStats{
+		methods: make(map[string]*methodStats),
+	}
+}
+
+// record adds a request to the statistics of the method, a negative latency isn't recorded
+func (s *requestsStats) record(method string, failed bool, latency time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	stats, found := s.methods[method]
+	if !found {
+		stats = &methodStats{}
+		s.methods[method] = stats
+	}
+
+	stats.Requests++
+	if failed {
+		stats.Errors++
+	}
+	if latency >= 0 {
+		stats.LatencyTotal += latency
+		stats.LatencyCount++
+	}
+}
+
+// snapshot returns the statistics of each method, with the average latency in milliseconds
+func (s *requestsStats) snapshot() map[string]map[string]interface{} {
+	s.Lock()
+	defer s.Unlock()
+
+	snapshot := make(map[string]map[string]interface{}, len(s.methods))
+	for method, stats := range s.methods {
+		methodSnapshot := map[string]interface{}{
+			"Requests": stats.Requests,
+			"Errors":   stats.Errors,
+		}
+		if stats.LatencyCount > 0 {
+			methodSnapshot["AvgLatencyMs"] = float64(stats.LatencyTotal) / float64(stats.LatencyCount) / float64(time.Millisecond)
+		}
+		snapshot[method] = methodSnapshot
+	}
+	return snapshot
+}
+
+// grpcTelemetryUnaryInterceptor counts the unary requests and measures their latency, it comes first in
+// the chain so that the requests rejected by the other interceptors are reported too
+func grpcTelemetryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	latency := time.Since(start)
+
+	code := status.Code(err)
+	tlmGRPCRequests.Inc(info.FullMethod, code.String())
+	tlmGRPCLatency.Observe(latency.Seconds(), info.FullMethod)
+	apiStats.record(info.FullMethod, err != nil, latency)
+	auditGRPCCall(ctx, info.FullMethod, code.String(), start, latency)
+
+	return resp, err
+}
+
+// grpcTelemetryStreamInterceptor counts the streams, the latency of a stream being its lifetime isn't measured,
+// the audit log keeps it though
+func grpcTelemetryStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+
+	code := status.Code(err)
+	tlmGRPCRequests.Inc(info.FullMethod, code.String())
+	apiStats.record(info.FullMethod, err != nil, -1)
+	auditGRPCCall(ss.Context(), info.FullMethod, code.String(), start, time.Since(start))
+
+	return err
+}
+
+// statusRecorder keeps the status code written to the response
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// gatewayTelemetry counts the requests served by the grpc-gateway and measures their latency. The gateway
+// reports the failures of its calls to the gRPC server, and its own, with an HTTP error status.
+func gatewayTelemetry(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+
+		start := time.Now()
+		next.ServeHTTP(recorder, r)
+		latency := time.Since(start)
+
+		tlmGatewayRequests.Inc(strconv.Itoa(recorder.code))
+		tlmGatewayLatency.Observe(latency.Seconds())
+		apiStats.record(gatewayMethod, recorder.code >= http.StatusBadRequest, latency)
+	})
+}
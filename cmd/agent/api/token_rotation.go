@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// startAuthTokenRotation rotates the auth token every `auth_token_rotation_interval` seconds, if set. The
+// rotation stops with the api server.
+func startAuthTokenRotation() {
+	interval := config.Datadog.GetDurationWithUnit("auth_token_rotation_interval", time.Second)
+	if interval <= 0 {
+		return
+	}
+	gracePeriod := config.Datadog.GetDurationWithUnit("auth_token_grace_period", time.Second)
+
+	stop := make(chan struct{})
+	RegisterShutdownHook("auth_token_rotation", func() { close(stop) })
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := util.RotateAuthToken(gracePeriod); err != nil {
+					log.Errorf("Unable to rotate the auth token: %s", err)
+					continue
+				}
+				log.Infof("The auth token was rotated, the previous one stays valid for %s", gracePeriod)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
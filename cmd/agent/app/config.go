@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"html"
 	"net/http"
+	"sort"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/app/settings"
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
@@ -25,6 +26,7 @@ func init() {
 	configCommand.AddCommand(listRuntimeCommand)
 	configCommand.AddCommand(setCommand)
 	configCommand.AddCommand(getCommand)
+	configCommand.AddCommand(layersCommand)
 }
 
 var (
@@ -52,8 +54,15 @@ var (
 		Long:  ``,
 		RunE:  getConfigValue,
 	}
+	layersCommand = &cobra.Command{
+		Use:   "layers [setting]",
+		Short: "Print the source of every configuration setting, or the value of a given setting in every layer",
+		Long:  ``,
+		RunE:  showLayeredConfiguration,
+	}
 	agentConfigURLPath = "/agent/config"
 	listRuntimeURLPath = agentConfigURLPath + "/list-runtime"
+	layersURLPath      = agentConfigURLPath + "/layers"
 )
 
 func setupConfig() error {
@@ -113,6 +122,67 @@ func requestConfig() (string, error) {
 	return string(r), nil
 }
 
+func showLayeredConfiguration(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("at most one setting name should be provided")
+	}
+
+	err := setupConfig()
+	if err != nil {
+		return err
+	}
+
+	c := util.GetClient(false)
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("https://%v:%v"+layersURLPath, ipcAddress, config.Datadog.GetInt("cmd_port"))
+	r, err := util.DoGet(c, url)
+	if err != nil {
+		var errMap = make(map[string]string)
+		json.Unmarshal(r, &errMap) //nolint:errcheck
+		// If the error has been marshalled into a json object, check it and return it properly
+		if e, found := errMap["error"]; found {
+			return fmt.Errorf(e)
+		}
+		return fmt.Errorf("Could not reach agent: %v \nMake sure the agent is running before requesting the layered configuration and contact support if you continue having issues", err)
+	}
+
+	var view map[string]config.LayeredSetting
+	if err = json.Unmarshal(r, &view); err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		setting, found := view[args[0]]
+		if !found {
+			return fmt.Errorf("unknown setting %s", args[0])
+		}
+		fmt.Printf("%s is %v, from the %s source\n", args[0], setting.Value, color.GreenString(string(setting.Source)))
+		sources := make([]string, 0, len(setting.Layers))
+		for source := range setting.Layers {
+			sources = append(sources, string(source))
+		}
+		sort.Strings(sources)
+		for _, source := range sources {
+			fmt.Printf("  - %-22s %v\n", source, setting.Layers[config.Source(source)])
+		}
+		return nil
+	}
+
+	keys := make([]string, 0, len(view))
+	for key := range view {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Printf("%-60s %s\n", key, view[key].Source)
+	}
+	return nil
+}
+
 func listRuntimeConfigurableValue(cmd *cobra.Command, args []string) error {
 	err := setupConfig()
 	if err != nil {
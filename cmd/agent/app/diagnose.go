@@ -12,6 +12,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/diagnose"
 
+	// register the intake connectivity diagnosis
+	_ "github.com/DataDog/datadog-agent/pkg/diagnose/connectivity"
+
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
@@ -7,13 +7,23 @@ package app
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
+	pb "github.com/DataDog/datadog-agent/cmd/agent/api/pb"
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -131,7 +141,11 @@ func makeFlare(caseID string) error {
 	if forceLocal {
 		filePath, err = createArchive(logFiles, profile)
 	} else {
-		filePath, err = requestArchive(logFiles, profile)
+		var uploaded bool
+		filePath, uploaded, err = requestArchive(caseID, logFiles, profile)
+		if uploaded {
+			return err
+		}
 	}
 
 	if err != nil {
@@ -161,28 +175,105 @@ func makeFlare(caseID string) error {
 	return nil
 }
 
-func requestArchive(logFiles []string, pdata flare.ProfileData) (string, error) {
+// requestArchive asks the agent to build the flare archive, and to upload it when the upload is confirmed
+// with --send. It returns whether the flare was uploaded, the archive is built locally when the agent can't
+// be reached.
+func requestArchive(caseID string, logFiles []string, pdata flare.ProfileData) (string, bool, error) {
 	fmt.Fprintln(color.Output, color.BlueString("Asking the agent to build the flare archive."))
-	var e error
-	c := util.GetClient(false) // FIX: get certificates right then make this true
 	ipcAddress, err := config.GetIPCAddress()
 	if err != nil {
 		fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("Error getting IPC address for the agent: %s", err)))
-		return createArchive(logFiles, pdata)
+		filePath, err := createArchive(logFiles, pdata)
+		return filePath, false, err
 	}
 
-	urlstr := fmt.Sprintf("https://%v:%v/agent/flare", ipcAddress, config.Datadog.GetInt("cmd_port"))
-
 	// Set session token
-	e = util.SetAuthToken()
-	if e != nil {
+	if e := util.SetAuthToken(); e != nil {
 		fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("Error: %s", e)))
-		return createArchive(logFiles, pdata)
+		filePath, err := createArchive(logFiles, pdata)
+		return filePath, false, err
 	}
 
+	filePath, uploadResponse, err := generateFlare(ipcAddress, caseID, pdata)
+	switch {
+	case err == nil && autoconfirm:
+		fmt.Println(uploadResponse)
+		return filePath, true, nil
+	case err == nil:
+		return filePath, false, nil
+	case filePath != "":
+		// the archive was built, its upload failed
+		fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("The agent was unable to upload the flare: %s", status.Convert(err).Message())))
+		fmt.Fprintln(color.Output, fmt.Sprintf("You can still use %s", color.YellowString(filePath)))
+		return filePath, true, err
+	case status.Code(err) == codes.Unimplemented:
+		// the agents older than the GenerateFlare RPC build the flare through the REST API
+		filePath, err := requestArchiveREST(ipcAddress, logFiles, pdata)
+		return filePath, false, err
+	case status.Code(err) == codes.Internal:
+		fmt.Fprintln(color.Output, fmt.Sprintf("The agent ran into an error while making the flare: %s", color.RedString(status.Convert(err).Message())))
+	default:
+		fmt.Fprintln(color.Output, color.RedString("The agent was unable to make the flare. (is it running?)"))
+	}
+	filePath, err = createArchive(logFiles, pdata)
+	return filePath, false, err
+}
+
+// generateFlare calls the GenerateFlare RPC of the agent, printing the progress of the collection. The agent
+// uploads the flare when --send is set. The path of the archive is returned even if its upload fails.
+func generateFlare(ipcAddress, caseID string, pdata flare.ProfileData) (string, string, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// FIX: get certificates right then verify them, like the HTTP client
+	creds := credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})
+	conn, err := grpc.DialContext(ctx, fmt.Sprintf("%v:%v", ipcAddress, config.Datadog.GetInt("cmd_port")), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return "", "", err
+	}
+	defer conn.Close()
+
+	req := &pb.GenerateFlareRequest{
+		Send:   autoconfirm,
+		CaseId: caseID,
+		Email:  customerEmail,
+	}
+	for name, data := range pdata {
+		req.Profiles = append(req.Profiles, &pb.FlareProfile{Name: name, Data: data})
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+util.GetAuthToken())
+	stream, err := pb.NewAgentSecureClient(conn).GenerateFlare(ctx, req)
+	if err != nil {
+		return "", "", err
+	}
+
+	var filePath, uploadResponse string
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			return filePath, uploadResponse, nil
+		} else if err != nil {
+			return filePath, uploadResponse, err
+		}
+
+		if progress := response.Progress; progress != nil {
+			fmt.Fprintln(color.Output, fmt.Sprintf("  %s: %d files, %s", progress.Step, progress.Files, humanize.Bytes(uint64(progress.Bytes))))
+		}
+		if response.ArchivePath != "" {
+			filePath, uploadResponse = response.ArchivePath, response.UploadResponse
+		}
+	}
+}
+
+// requestArchiveREST asks the agent to build the flare archive through the REST API
+func requestArchiveREST(ipcAddress string, logFiles []string, pdata flare.ProfileData) (string, error) {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	urlstr := fmt.Sprintf("https://%v:%v/agent/flare", ipcAddress, config.Datadog.GetInt("cmd_port"))
+
 	p, err := json.Marshal(pdata)
 	if err != nil {
-		fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("Error while encoding profile: %s", e)))
+		fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("Error while encoding profile: %s", err)))
 		return "", err
 	}
 
@@ -14,6 +14,9 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -36,6 +39,10 @@ const (
 	disclaimer          = "For your security, only use this to install wheels containing an Agent integration " +
 		"and coming from a known source. The Agent cannot perform any verification on local wheels."
 	pythonMinorVersionScript = "import sys;print(sys.version_info[1])"
+	// mirrorMetadataDir and mirrorTargetsDir are the directories of a mirror of the integrations repository,
+	// holding respectively the signed metadata and the wheels
+	mirrorMetadataDir        = "metadata.staged"
+	mirrorTargetsDir         = "targets"
 	integrationVersionScript = `
 import pkg_resources
 try:
@@ -63,6 +70,7 @@ var (
 	pythonMinorVersion  string
 	reqAgentReleasePath string
 	constraintsPath     string
+	repository          string
 )
 
 func init() {
@@ -86,6 +94,11 @@ func init() {
 	installCmd.Flags().BoolVarP(
 		&thirdParty, "third-party", "t", false, "install a community or vendor-contributed integration",
 	)
+	installCmd.Flags().StringVarP(
+		&repository, "repository", "", "",
+		"download the integration from a mirror of the integrations repository, either a URL or a local directory. "+
+			"The wheels of the mirror are verified with the same signed metadata as the default repository",
+	)
 }
 
 var integrationCmd = &cobra.Command{
@@ -354,6 +367,10 @@ func install(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if localWheel && repository != "" {
+		return fmt.Errorf("the --local-wheel and --repository flags can't be used together")
+	}
+
 	pipArgs := []string{
 		"install",
 		"--constraint", constraintsPath,
@@ -492,6 +509,21 @@ func downloadWheel(integration, version, rootLayoutType string) (string, error)
 		"--version", version,
 		"--type", rootLayoutType,
 	}
+
+	var mirrorHost string
+	if repository != "" {
+		repositoryURL, closeMirror, err := serveMirror(repository)
+		if err != nil {
+			return "", err
+		}
+		defer closeMirror()
+
+		if u, err := url.Parse(repositoryURL); err == nil {
+			mirrorHost = u.Hostname()
+		}
+		args = append(args, "--repository", repositoryURL)
+	}
+
 	if verbose > 0 {
 		args = append(args, fmt.Sprintf("-%s", strings.Repeat("v", verbose)))
 	}
@@ -525,10 +557,16 @@ func downloadWheel(integration, version, rootLayoutType string) (string, error)
 	// Proxy support
 	proxies := config.GetProxies()
 	if proxies != nil {
+		noProxy := proxies.NoProxy
+		if mirrorHost == loopbackHost {
+			// the local mirror is served by the agent itself
+			noProxy = append(noProxy, loopbackHost)
+		}
+
 		downloaderCmd.Env = append(downloaderCmd.Env,
 			fmt.Sprintf("HTTP_PROXY=%s", proxies.HTTP),
 			fmt.Sprintf("HTTPS_PROXY=%s", proxies.HTTPS),
-			fmt.Sprintf("NO_PROXY=%s", strings.Join(proxies.NoProxy, ",")),
+			fmt.Sprintf("NO_PROXY=%s", strings.Join(noProxy, ",")),
 		)
 	}
 
@@ -576,6 +614,34 @@ func downloadWheel(integration, version, rootLayoutType string) (string, error)
 	return wheelPath, nil
 }
 
+const loopbackHost = "127.0.0.1"
+
+// serveMirror returns the URL of the given mirror of the integrations repository. Local mirrors, for
+// air-gapped environments, are served over HTTP on the loopback interface for the time of the download
+// so that the downloader verifies them exactly like remote ones. The returned function stops the server.
+func serveMirror(mirror string) (string, func(), error) {
+	if u, err := url.Parse(mirror); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return strings.TrimSuffix(mirror, "/"), func() {}, nil
+	}
+
+	dir := strings.TrimPrefix(mirror, "file://")
+	for _, subdir := range []string{mirrorMetadataDir, mirrorTargetsDir} {
+		if fi, err := os.Stat(filepath.Join(dir, subdir)); err != nil || !fi.IsDir() {
+			return "", nil, fmt.Errorf("%s is not a mirror of the integrations repository: missing %s directory", dir, subdir)
+		}
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(loopbackHost, "0"))
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to serve the local mirror %s: %v", dir, err)
+	}
+
+	server := &http.Server{Handler: http.FileServer(http.Dir(dir))}
+	go server.Serve(listener) //nolint:errcheck
+
+	return "http://" + listener.Addr().String(), func() { server.Close() }, nil
+}
+
 func parseWheelPackageName(wheelPath string) (string, error) {
 	reader, err := zip.OpenReader(wheelPath)
 	if err != nil {
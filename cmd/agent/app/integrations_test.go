@@ -9,6 +9,7 @@ package app
 
 import (
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
@@ -222,3 +223,36 @@ func TestParseWheelPackageNameErrorCases(t *testing.T) {
 		assert.Contains(t, err.Error(), test.expectedErr)
 	}
 }
+
+func TestServeMirror(t *testing.T) {
+	repositoryURL, closeMirror, err := serveMirror("https://mirror.example.com/integrations/")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://mirror.example.com/integrations", repositoryURL)
+	closeMirror()
+
+	dir, _ := ioutil.TempDir("", "mirror")
+	defer os.RemoveAll(dir)
+
+	_, _, err = serveMirror(dir)
+	assert.NotNil(t, err)
+
+	os.MkdirAll(filepath.Join(dir, mirrorMetadataDir), 0755)
+	os.MkdirAll(filepath.Join(dir, mirrorTargetsDir), 0755)
+	ioutil.WriteFile(filepath.Join(dir, mirrorTargetsDir, "datadog_foo-1.0.0-py2.py3-none-any.whl"), []byte("wheel"), 0644)
+
+	repositoryURL, closeMirror, err = serveMirror("file://" + dir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer closeMirror()
+
+	resp, err := http.Get(repositoryURL + "/" + mirrorTargetsDir + "/datadog_foo-1.0.0-py2.py3-none-any.whl")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "wheel", string(body))
+}
@@ -9,7 +9,9 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"strings"
 	"syscall"
+	"time"
 
 	_ "expvar" // Blank import used because this isn't directly used in this file
 	"net/http"
@@ -30,9 +32,11 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed/jmx"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/remote"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/logs"
+	lsched "github.com/DataDog/datadog-agent/pkg/logs/scheduler"
 	"github.com/DataDog/datadog-agent/pkg/metadata"
 	"github.com/DataDog/datadog-agent/pkg/metadata/host"
 	"github.com/DataDog/datadog-agent/pkg/pidfile"
@@ -42,7 +46,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/subprocess"
 	"github.com/DataDog/datadog-agent/pkg/version"
+	"github.com/DataDog/datadog-agent/pkg/watchdog"
 	"github.com/spf13/cobra"
 	"gopkg.in/DataDog/dd-trace-go.v1/profiler"
 
@@ -51,6 +57,7 @@ import (
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/containers"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/ebpf"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed"
+	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/gpu"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/net"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/nvidia/jetson"
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/system"
@@ -73,6 +80,9 @@ var (
 var (
 	// flags variables
 	pidfilePath string
+
+	agentWatchdog *watchdog.Watchdog
+	remoteConfig  *remote.Client
 )
 
 func init() {
@@ -296,7 +306,8 @@ func StartAgent() error {
 	if err != nil {
 		log.Error("Misconfiguration of agent endpoints: ", err)
 	}
-	common.Forwarder = forwarder.NewDefaultForwarder(forwarder.NewOptions(keysPerDomain))
+	defaultForwarder := forwarder.NewDefaultForwarder(forwarder.NewOptions(keysPerDomain))
+	common.Forwarder = defaultForwarder
 	log.Debugf("Starting forwarder")
 	common.Forwarder.Start() //nolint:errcheck
 	log.Debugf("Forwarder started")
@@ -353,11 +364,46 @@ func StartAgent() error {
 	// Append version and timestamp to version history log file if this Agent is different than the last run version
 	util.LogVersionHistory()
 
+	// the subprocesses of the checks run in a dedicated cgroup, it must be created before the checks start
+	if err := subprocess.Setup(); err != nil {
+		log.Errorf("The subprocesses of the checks will run without limits: %v", err)
+	}
+
 	// create and setup the Autoconfig instance
 	common.SetupAutoConfig(config.Datadog.GetString("confd_path"))
 	// start the autoconfig, this will immediately run any configured check
 	common.StartAutoConfig()
 
+	// start the self-monitoring watchdog, it sheds load from the collector when over budget
+	if config.Datadog.GetBool("watchdog.enabled") {
+		agentWatchdog, err = watchdog.NewWatchdog(watchdog.Config{
+			MaxCPUPercent: config.Datadog.GetFloat64("watchdog.max_cpu_percent"),
+			MaxRSS:        uint64(config.Datadog.GetInt64("watchdog.max_rss")),
+			CheckInterval: time.Duration(config.Datadog.GetInt("watchdog.check_interval")) * time.Second,
+			Tolerance:     config.Datadog.GetInt("watchdog.tolerance"),
+		})
+		if err != nil {
+			log.Errorf("Could not start the watchdog: %s", err)
+		} else {
+			agentWatchdog.Register("collector", common.Coll)
+			agentWatchdog.Start()
+		}
+	}
+
+	// start polling the backend for the remote configuration of the agent
+	if config.Datadog.GetBool("remote_configuration.enabled") {
+		remoteConfig, err = remote.NewClientFromConfig(hostname)
+		if err != nil {
+			log.Errorf("Could not start the remote configuration client: %s", err)
+		} else {
+			remoteConfig.Start()
+		}
+	}
+
+	// periodically fetch the secrets again to pick up the rotated ones
+	config.OnSecretRefresh(func(keys []string) { updateRotatedAPIKeys(defaultForwarder, keys) })
+	config.StartSecretRefresh()
+
 	// check for common misconfigurations and report them to log
 	misconfig.ToLog()
 
@@ -373,11 +419,56 @@ func StartAgent() error {
 		}
 	}
 
+	if config.Datadog.GetBool("metric_metadata_enabled") {
+		if err := metadata.SetupMetricMetadata(common.MetadataScheduler); err != nil {
+			return err
+		}
+	}
+
 	// start dependent services
 	startDependentServices()
 	return nil
 }
 
+// updateRotatedAPIKeys hands the API keys to the components reading them at startup, once their secret was rotated
+func updateRotatedAPIKeys(f *forwarder.DefaultForwarder, keys []string) {
+	if hasUpdatedKey(keys, "api_key", "additional_endpoints") {
+		keysPerDomain, err := config.GetMultipleEndpoints()
+		if err != nil {
+			log.Errorf("Misconfiguration of agent endpoints after the secrets were refreshed: %s", err)
+		} else {
+			f.UpdateAPIKeys(keysPerDomain)
+		}
+		if remoteConfig != nil {
+			remoteConfig.SetAPIKey(config.SanitizeAPIKey(config.Datadog.GetString("api_key")))
+		}
+	}
+
+	// the logs-agent builds its endpoints when it starts
+	if logs.IsAgentRunning() && hasUpdatedKey(keys, "api_key", "logs_config") {
+		log.Info("Restarting the logs-agent to use the rotated API keys")
+		logs.Stop()
+		if err := logs.Start(func() *autodiscovery.AutoConfig { return common.AC }); err != nil {
+			log.Errorf("Error while restarting logs-agent: %v", err)
+		} else if common.AC != nil {
+			// the integrations configured through autodiscovery are scheduled again to the new logs-agent
+			common.AC.AddScheduler("logs", lsched.GetScheduler(), true)
+		}
+	}
+}
+
+// hasUpdatedKey returns whether one of the updated keys is one of the given settings, or one of their sub-settings
+func hasUpdatedKey(keys []string, settings ...string) bool {
+	for _, key := range keys {
+		for _, setting := range settings {
+			if key == setting || strings.HasPrefix(key, setting+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // StopAgent Tears down the agent process
 func StopAgent() {
 	// retrieve the agent health before stopping the components
@@ -392,6 +483,13 @@ func StopAgent() {
 	// gracefully shut down any component
 	common.MainCtxCancel()
 
+	if agentWatchdog != nil {
+		agentWatchdog.Stop()
+	}
+	if remoteConfig != nil {
+		remoteConfig.Stop()
+	}
+	config.StopSecretRefresh()
 	if common.DSD != nil {
 		common.DSD.Stop()
 	}
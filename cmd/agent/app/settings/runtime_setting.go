@@ -42,6 +42,9 @@ func InitRuntimeSettings() error {
 	if err := registerRuntimeSetting(logLevelRuntimeSetting("log_level")); err != nil {
 		return err
 	}
+	if err := registerRuntimeSetting(logLevelOverridesRuntimeSetting("log_level_overrides")); err != nil {
+		return err
+	}
 	if err := registerRuntimeSetting(dsdStatsRuntimeSetting("dogstatsd_stats")); err != nil {
 		return err
 	}
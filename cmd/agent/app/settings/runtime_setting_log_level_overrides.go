@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package settings
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// logLevelOverridesRuntimeSetting wraps operations to change the log level of some packages at runtime.
+type logLevelOverridesRuntimeSetting string
+
+func (l logLevelOverridesRuntimeSetting) Description() string {
+	return "Set/get the log levels overriding the global one for some packages, e.g. pkg/security:trace,pkg/forwarder:debug, an empty value removes them"
+}
+
+func (l logLevelOverridesRuntimeSetting) Hidden() bool {
+	return false
+}
+
+func (l logLevelOverridesRuntimeSetting) Name() string {
+	return string(l)
+}
+
+func (l logLevelOverridesRuntimeSetting) Get() (interface{}, error) {
+	return log.GetLogLevelOverrides(), nil
+}
+
+func (l logLevelOverridesRuntimeSetting) Set(v interface{}) error {
+	overrides, err := getLogLevelOverrides(v)
+	if err != nil {
+		return err
+	}
+	if err := config.ChangeLogLevelOverrides(overrides); err != nil {
+		return err
+	}
+	config.Datadog.Set("log_level_overrides", overrides)
+	return nil
+}
+
+// getLogLevelOverrides returns the overrides contained in value.
+// If value is a string, it must be a comma separated list of pattern:level pairs.
+// If value is a map, its keys are the patterns and its values the levels.
+// Else, returns an error.
+func getLogLevelOverrides(v interface{}) (map[string]string, error) {
+	overrides := make(map[string]string)
+	switch value := v.(type) {
+	case string:
+		// cli
+		for _, pair := range strings.Split(value, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			sep := strings.LastIndex(pair, ":")
+			if sep <= 0 {
+				return nil, fmt.Errorf("invalid log level override %s, expected <package pattern>:<level>", pair)
+			}
+			overrides[strings.TrimSpace(pair[:sep])] = strings.TrimSpace(pair[sep+1:])
+		}
+	case map[string]string:
+		for pattern, level := range value {
+			overrides[pattern] = level
+		}
+	case map[string]interface{}:
+		for pattern, level := range value {
+			overrides[pattern] = fmt.Sprint(level)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type for log level overrides: %T", v)
+	}
+	return overrides, nil
+}
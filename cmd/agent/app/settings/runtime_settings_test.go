@@ -108,6 +108,45 @@ func TestLogLevel(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestLogLevelOverrides(t *testing.T) {
+	cleanRuntimeSetting()
+	config.SetupLogger("TEST", "info", "", "", true, true, true)
+
+	ll := logLevelOverridesRuntimeSetting("log_level_overrides")
+	assert.Equal(t, "log_level_overrides", ll.Name())
+
+	err := ll.Set("pkg/security:TRACE, pkg/forwarder:warning")
+	assert.Nil(t, err)
+
+	v, err := ll.Get()
+	assert.Equal(t, map[string]string{"pkg/security": "trace", "pkg/forwarder": "warn"}, v)
+	assert.Nil(t, err)
+
+	err = ll.Set(map[string]interface{}{"pkg/collector": "debug"})
+	assert.Nil(t, err)
+
+	v, err = ll.Get()
+	assert.Equal(t, map[string]string{"pkg/collector": "debug"}, v)
+	assert.Nil(t, err)
+
+	err = ll.Set("pkg/security")
+	assert.NotNil(t, err)
+
+	err = ll.Set("pkg/security:invalid")
+	assert.NotNil(t, err)
+
+	v, err = ll.Get()
+	assert.Equal(t, map[string]string{"pkg/collector": "debug"}, v)
+	assert.Nil(t, err)
+
+	err = ll.Set("")
+	assert.Nil(t, err)
+
+	v, err = ll.Get()
+	assert.Equal(t, map[string]string{}, v)
+	assert.Nil(t, err)
+}
+
 func TestDogstatsdMetricsStats(t *testing.T) {
 	assert := assert.New(t)
 	var err error
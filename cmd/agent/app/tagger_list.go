@@ -6,6 +6,7 @@
 package app
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -21,8 +22,16 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	taggerListRequest response.TaggerListRequest
+)
+
 func init() {
 	AgentCmd.AddCommand(taggerListCommand)
+	taggerListCommand.Flags().StringVarP(&taggerListRequest.Cardinality, "cardinality", "", "", "tags cardinality to list: low, orchestrator or high. defaults to the highest cardinality used by checks and dogstatsd")
+	taggerListCommand.Flags().StringSliceVarP(&taggerListRequest.Prefixes, "prefix", "", nil, "only list the entities whose ID starts with one of the given prefixes, ex: container_id://")
+	taggerListCommand.Flags().StringSliceVarP(&taggerListRequest.Entities, "entity", "", nil, "only list the given entities")
+	taggerListCommand.Flags().StringSliceVarP(&taggerListRequest.Sources, "source", "", nil, "only list the entities with tags from one of the given sources")
 }
 
 var taggerListCommand = &cobra.Command{
@@ -57,7 +66,11 @@ var taggerListCommand = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		r, err := util.DoGet(c, fmt.Sprintf("https://%v:%v/agent/tagger-list", ipcAddress, config.Datadog.GetInt("cmd_port")))
+		body, err := json.Marshal(taggerListRequest)
+		if err != nil {
+			return err
+		}
+		r, err := util.DoPost(c, fmt.Sprintf("https://%v:%v/agent/tagger-list", ipcAddress, config.Datadog.GetInt("cmd_port")), "application/json", bytes.NewBuffer(body))
 		if err != nil {
 			if r != nil && string(r) != "" {
 				fmt.Fprintln(color.Output, fmt.Sprintf("The agent ran into an error while getting tags list: %s", string(r)))
@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	jsonWorkloadList bool
+)
+
+func init() {
+	AgentCmd.AddCommand(workloadListCommand)
+	workloadListCommand.Flags().BoolVarP(&jsonWorkloadList, "json", "j", false, "print out raw json")
+}
+
+var workloadListCommand = &cobra.Command{
+	Use:   "workload-list",
+	Short: "Print the containers, pods and container runtimes known by a running agent",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		err := common.SetupConfigWithoutSecrets(confFilePath, "")
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnv("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		c := util.GetClient(false) // FIX: get certificates right then make this true
+
+		// Set session token
+		err = util.SetAuthToken()
+		if err != nil {
+			return err
+		}
+		ipcAddress, err := config.GetIPCAddress()
+		if err != nil {
+			return err
+		}
+		baseURL := fmt.Sprintf("https://%v:%v", ipcAddress, config.Datadog.GetInt("cmd_port"))
+
+		// the agents predating the version negotiation are queried anyway
+		if negotiation, err := util.NegotiateAPIVersion(c, baseURL); err == nil && negotiation.APIVersion > 0 && !negotiation.Supports("GET", "/agent/workload-list") {
+			return fmt.Errorf("the running agent %s (API version %d) doesn't serve the workload list", negotiation.AgentVersion, negotiation.APIVersion)
+		}
+
+		r, err := util.DoGet(c, baseURL+"/agent/workload-list")
+		if err != nil {
+			if r != nil && string(r) != "" {
+				fmt.Fprintln(color.Output, fmt.Sprintf("The agent ran into an error while getting the workload list: %s", string(r)))
+			} else {
+				fmt.Fprintln(color.Output, fmt.Sprintf("Failed to query the agent (running?): %s", err))
+			}
+			return err
+		}
+
+		if jsonWorkloadList {
+			fmt.Fprintln(color.Output, string(r))
+			return nil
+		}
+
+		workload := response.WorkloadListResponse{}
+		err = json.Unmarshal(r, &workload)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(color.Output, fmt.Sprintf("Runtimes: [%s]", color.BlueString(strings.Join(workload.Runtimes, " "))))
+
+		fmt.Fprintln(color.Output, fmt.Sprintf("\n=== Containers (%d) ===", len(workload.Containers)))
+		for _, container := range workload.Containers {
+			fmt.Fprintln(color.Output, fmt.Sprintf("%s name:%s image:%s runtime:%s state:%s excluded:%t",
+				color.GreenString(container.ID), color.CyanString(container.Name), color.CyanString(container.Image),
+				container.Runtime, container.State, container.Excluded))
+		}
+
+		fmt.Fprintln(color.Output, fmt.Sprintf("\n=== Pods (%d) ===", len(workload.Pods)))
+		for _, pod := range workload.Pods {
+			fmt.Fprintln(color.Output, fmt.Sprintf("%s %s/%s phase:%s",
+				color.GreenString(pod.UID), color.CyanString(pod.Namespace), color.CyanString(pod.Name), pod.Phase))
+			for _, container := range pod.Containers {
+				fmt.Fprintln(color.Output, fmt.Sprintf("  - %s", container))
+			}
+		}
+
+		return nil
+	},
+}
@@ -41,6 +41,7 @@ var (
 	checkTimes           int
 	checkPause           int
 	checkName            string
+	checkLocal           bool
 	checkDelay           int
 	logLevel             string
 	formatJSON           bool
@@ -69,6 +70,7 @@ func setupCmd(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&breakPoint, "breakpoint", "b", "", "set a breakpoint at a particular line number (Python checks only)")
 	cmd.Flags().BoolVarP(&profileMemory, "profile-memory", "m", false, "run the memory profiler (Python checks only)")
 	cmd.Flags().BoolVar(&fullSketches, "full-sketches", false, "output sketches with bins information")
+	cmd.Flags().BoolVar(&checkLocal, "local", false, "run the check in the command instead of asking the running agent to run its scheduled instances")
 	config.Datadog.BindPFlag("cmd.check.fullsketches", cmd.Flags().Lookup("full-sketches")) //nolint:errcheck
 
 	// Power user flags - mark as hidden
@@ -115,6 +117,12 @@ func Check(loggerName config.LoggerName, confFilePath *string, flagNoColor *bool
 				return nil
 			}
 
+			if requestsCheckRun() {
+				if ran, err := requestCheckRun(checkName, resolvedLogLevel != "off"); ran {
+					return err
+				}
+			}
+
 			hostname, err := util.GetHostname()
 			if err != nil {
 				fmt.Printf("Cannot get hostname, exiting: %v\n", err)
@@ -402,18 +410,7 @@ func Check(loggerName config.LoggerName, confFilePath *string, flagNoColor *bool
 
 func runCheck(c check.Check, agg *aggregator.BufferedAggregator) *check.Stats {
 	s := check.NewStats(c)
-	times := checkTimes
-	pause := checkPause
-	if checkRate {
-		if checkTimes > 2 {
-			color.Yellow("The check-rate option is overriding check-times to 2")
-		}
-		if pause > 0 {
-			color.Yellow("The check-rate option is overriding pause to 1000ms")
-		}
-		times = 2
-		pause = 1000
-	}
+	times, pause := checkRunTimesAndPause()
 	for i := 0; i < times; i++ {
 		t0 := time.Now()
 		err := c.Run()
@@ -428,6 +425,23 @@ func runCheck(c check.Check, agg *aggregator.BufferedAggregator) *check.Stats {
 	return s
 }
 
+// checkRunTimesAndPause returns the number of runs of each instance and the pause between them, in milliseconds
+func checkRunTimesAndPause() (int, int) {
+	times := checkTimes
+	pause := checkPause
+	if checkRate {
+		if checkTimes > 2 {
+			color.Yellow("The check-rate option is overriding check-times to 2")
+		}
+		if pause > 0 {
+			color.Yellow("The check-rate option is overriding pause to 1000ms")
+		}
+		times = 2
+		pause = 1000
+	}
+	return times, pause
+}
+
 func printMetrics(agg *aggregator.BufferedAggregator) {
 	series, sketches := agg.GetSeriesAndSketches()
 	if len(series) != 0 {
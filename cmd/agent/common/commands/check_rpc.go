@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/DataDog/datadog-agent/cmd/agent/api/pb"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/flavor"
+)
+
+// requestsCheckRun returns whether the check is run by the agent, the options of the memory profiler, the
+// debugger and the JSON output being only available with a local run
+func requestsCheckRun() bool {
+	return flavor.GetFlavor() == flavor.DefaultAgent && !checkLocal && !formatJSON && !profileMemory && breakPoint == "" && !fullSketches
+}
+
+// requestCheckRun asks the agent to run the scheduled instances of the check, whose metrics aren't sent to
+// Datadog, and prints what they submitted. It returns false when the check must be run locally instead,
+// because the agent can't be reached, doesn't schedule the check or is older than the RunCheck RPC.
+func requestCheckRun(name string, printLogs bool) (bool, error) {
+	ipcAddress, err := config.GetIPCAddress()
+	if err != nil {
+		fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("Error getting IPC address for the agent: %s", err)))
+		return false, nil
+	}
+
+	// Set session token
+	if e := util.SetAuthToken(); e != nil {
+		fmt.Fprintln(color.Output, color.RedString(fmt.Sprintf("Error: %s", e)))
+		return false, nil
+	}
+
+	received, err := runCheckRPC(ipcAddress, name, printLogs)
+	switch {
+	case err == nil:
+		if singleCheckRun() {
+			color.Yellow("Check has run only once, if some metrics are missing you can try again with --check-rate to see any other metric if available.")
+		}
+		return true, nil
+	case received:
+		// the instances started running, running them again locally would repeat the output
+		return true, fmt.Errorf("the agent was unable to run the check: %s", status.Convert(err).Message())
+	case status.Code(err) == codes.NotFound, status.Code(err) == codes.FailedPrecondition:
+		fmt.Fprintln(color.Output, color.YellowString(fmt.Sprintf("The agent can't run the check: %s", status.Convert(err).Message())))
+	case status.Code(err) == codes.Unimplemented:
+		// the agents older than the RunCheck RPC can't run the scheduled instances
+	case status.Code(err) == codes.InvalidArgument, status.Code(err) == codes.Internal:
+		return true, fmt.Errorf("the agent was unable to run the check: %s", status.Convert(err).Message())
+	default:
+		fmt.Fprintln(color.Output, color.RedString("The agent was unable to run the check. (is it running?)"))
+	}
+	fmt.Fprintln(color.Output, color.YellowString("Running the check locally."))
+	return false, nil
+}
+
+// runCheckRPC calls the RunCheck RPC of the agent and prints the responses. It returns whether a response was
+// received before the error, if any.
+func runCheckRPC(ipcAddress, name string, printLogs bool) (bool, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// FIX: get certificates right then verify them, like the HTTP client
+	creds := credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})
+	conn, err := grpc.DialContext(ctx, fmt.Sprintf("%v:%v", ipcAddress, config.Datadog.GetInt("cmd_port")), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	times, pause := checkRunTimesAndPause()
+	req := &pb.RunCheckRequest{
+		Name:    name,
+		Times:   int64(times),
+		PauseMs: int64(pause),
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+util.GetAuthToken())
+	stream, err := pb.NewAgentSecureClient(conn).RunCheck(ctx, req)
+	if err != nil {
+		return false, err
+	}
+
+	received := false
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			return received, nil
+		} else if err != nil {
+			return received, err
+		}
+		received = true
+
+		if response.Log != "" && printLogs {
+			fmt.Println(response.Log)
+		}
+		if st := response.Status; st != nil {
+			printCheckRunResults("Series", st.Series)
+			printCheckRunResults("Sketches", st.Sketches)
+			printCheckRunResults("Service Checks", st.ServiceChecks)
+			printCheckRunResults("Events", st.Events)
+			fmt.Println(st.Text)
+		}
+	}
+}
+
+// printCheckRunResults prints the JSON encoded results the way printMetrics does
+func printCheckRunResults(title string, results []byte) {
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Fprintln(color.Output, fmt.Sprintf("=== %s ===", color.BlueString(title)))
+	var j bytes.Buffer
+	if err := json.Indent(&j, bytes.TrimSpace(results), "", "  "); err != nil {
+		fmt.Println(string(results))
+		return
+	}
+	fmt.Println(j.String())
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/bundle"
 	"github.com/spf13/viper"
 )
 
@@ -68,5 +69,9 @@ func setupConfig(confFilePath string, configName string, withoutSecrets bool, fa
 	if err != nil && (failOnMissingFile || !errors.As(err, &e) || confFilePath != "") {
 		return warnings, fmt.Errorf("unable to load Datadog config file: %w", err)
 	}
+
+	if err == nil && bundle.Enabled(config.Datadog) {
+		return bundle.LoadConfig(true, withoutSecrets)
+	}
 	return warnings, nil
 }
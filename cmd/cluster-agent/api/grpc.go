@@ -0,0 +1,169 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/DataDog/datadog-agent/cmd/cluster-agent/api/pb"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent"
+	as "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// podsMetadataWatchInterval is the interval at which the metadata of the pods is
+// compared to the last one sent on the WatchPodsMetadata streams
+var podsMetadataWatchInterval = 10 * time.Second
+
+// securityPoliciesWatchInterval is the interval at which the security policies are
+// compared to the last ones sent on the WatchSecurityPolicies streams. They're polled
+// once for all the streams.
+var securityPoliciesWatchInterval = 10 * time.Second
+
+// serverSecure implements the gRPC API served to the node agents, authenticated
+// with the cluster agent token. The cluster checks methods are only implemented
+// when cluster checks support is compiled in.
+type serverSecure struct {
+	pb.UnimplementedClusterAgentSecureServer
+
+	sc clusteragent.ServerContext
+
+	securityPolicies *securityPoliciesPoller
+}
+
+func newServerSecure(sc clusteragent.ServerContext) *serverSecure {
+	s := &serverSecure{sc: sc}
+	if sc.SecurityPolicies != nil {
+		s.securityPolicies = newSecurityPoliciesPoller(sc.SecurityPolicies, securityPoliciesWatchInterval)
+	}
+	return s
+}
+
+// grpcAuth validates the cluster agent token passed by the node agents
+func grpcAuth(ctx context.Context) (context.Context, error) {
+	token, err := grpc_auth.AuthFromMD(ctx, "Bearer")
+	if err != nil {
+		return nil, err
+	}
+
+	if token != util.GetDCAAuthToken() {
+		return nil, status.Error(codes.Unauthenticated, "invalid session token")
+	}
+
+	return ctx, nil
+}
+
+// WatchPodsMetadata streams the services of the pods running on a node. The metadata
+// is sent when the stream is opened, then every time it changes.
+func (s *serverSecure) WatchPodsMetadata(in *pb.WatchPodsMetadataRequest, out pb.ClusterAgentSecure_WatchPodsMetadataServer) error {
+	ticker := time.NewTicker(podsMetadataWatchInterval)
+	defer ticker.Stop()
+
+	var last *pb.PodsMetadataResponse
+	for {
+		response, err := getPodsMetadata(in.NodeName)
+		if err != nil {
+			log.Debugf("Could not retrieve the metadata of the pods of the node %s: %v", in.NodeName, err)
+		} else if last == nil || !proto.Equal(last, response) {
+			if err := out.Send(response); err != nil {
+				return err
+			}
+			last = response
+		}
+
+		select {
+		case <-out.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// WatchSecurityPolicies streams the runtime security policies declared in the cluster. The
+// policies are sent when the stream is opened, then every time they change.
+func (s *serverSecure) WatchSecurityPolicies(in *pb.WatchSecurityPoliciesRequest, out pb.ClusterAgentSecure_WatchSecurityPoliciesServer) error {
+	if s.securityPolicies == nil {
+		return status.Error(codes.Unimplemented, "the security policies are not enabled on the cluster agent")
+	}
+
+	policies := s.securityPolicies.subscribe()
+	defer s.securityPolicies.unsubscribe(policies)
+
+	for {
+		select {
+		case <-out.Context().Done():
+			return nil
+		case response := <-policies:
+			if err := out.Send(response); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// getSecurityPolicies returns the security policies sorted by name, so that successive
+// responses can be compared
+func getSecurityPolicies(getter clusteragent.SecurityPoliciesGetter) (*pb.SecurityPoliciesResponse, error) {
+	policies, err := getter.GetPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &pb.SecurityPoliciesResponse{}
+	for name, content := range policies {
+		response.Policies = append(response.Policies, &pb.SecurityPolicy{
+			Name:    name,
+			Content: content,
+		})
+	}
+
+	sort.Slice(response.Policies, func(i, j int) bool {
+		return response.Policies[i].Name < response.Policies[j].Name
+	})
+
+	return response, nil
+}
+
+// getPodsMetadata returns the services of the pods running on a node, sorted
+// by namespace and pod name so that successive responses can be compared
+func getPodsMetadata(nodeName string) (*pb.PodsMetadataResponse, error) {
+	response := &pb.PodsMetadataResponse{}
+
+	metadata, err := as.GetMetadataMapBundleOnNode(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil || metadata.Nodes[nodeName] == nil {
+		return response, nil
+	}
+
+	for namespace, pods := range metadata.Nodes[nodeName].Services {
+		for name, services := range pods {
+			response.Pods = append(response.Pods, &pb.PodMetadata{
+				Namespace: namespace,
+				Name:      name,
+				Services:  services.List(),
+			})
+		}
+	}
+
+	sort.Slice(response.Pods, func(i, j int) bool {
+		if response.Pods[i].Namespace != response.Pods[j].Namespace {
+			return response.Pods[i].Namespace < response.Pods[j].Namespace
+		}
+		return response.Pods[i].Name < response.Pods[j].Name
+	})
+
+	return response, nil
+}
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build clusterchecks
+
+package api
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/DataDog/datadog-agent/cmd/cluster-agent/api/pb"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+	dcautil "github.com/DataDog/datadog-agent/pkg/util/clusteragent"
+)
+
+// WatchClusterChecks streams the cluster checks configurations dispatched to a node
+func (s *serverSecure) WatchClusterChecks(in *pb.WatchConfigsRequest, out pb.ClusterAgentSecure_WatchClusterChecksServer) error {
+	return s.watchConfigs(out, func() (types.ConfigResponse, error) {
+		return s.sc.ClusterCheckHandler.GetConfigs(in.NodeName)
+	}, out.Send)
+}
+
+// WatchEndpointsChecks streams the endpoints checks configurations of a node
+func (s *serverSecure) WatchEndpointsChecks(in *pb.WatchConfigsRequest, out pb.ClusterAgentSecure_WatchEndpointsChecksServer) error {
+	return s.watchConfigs(out, func() (types.ConfigResponse, error) {
+		return s.sc.ClusterCheckHandler.GetEndpointsConfigs(in.NodeName)
+	}, out.Send)
+}
+
+// watchConfigs sends the configurations returned by getConfigs, then sends them
+// again every time the dispatched configurations change, until the stream is closed
+// or the cluster agent stops leading
+func (s *serverSecure) watchConfigs(stream grpc.ServerStream, getConfigs func() (types.ConfigResponse, error), send func(*pb.ConfigsResponse) error) error {
+	handler := s.sc.ClusterCheckHandler
+	if handler == nil {
+		return status.Error(codes.FailedPrecondition, "Cluster-checks are not enabled")
+	}
+
+	for {
+		code, reason := handler.ShouldHandle()
+		switch code {
+		case http.StatusOK:
+		case http.StatusFound:
+			// Let the node agent reconnect to the leader
+			stream.SetTrailer(metadata.Pairs(dcautil.LeaderAddressMetadataKey, reason))
+			return status.Error(codes.Unavailable, "not leading, see the leader address in the trailer")
+		default:
+			return status.Error(codes.Unavailable, reason)
+		}
+
+		// Retrieve the channel before the configs to not miss a change
+		changes := handler.ConfigChanges()
+
+		response, err := getConfigs()
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		if err := send(response.ToProto()); err != nil {
+			return err
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-changes:
+		}
+	}
+}
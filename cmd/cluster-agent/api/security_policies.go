@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/DataDog/datadog-agent/cmd/cluster-agent/api/pb"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// securityPoliciesPoller polls the security policies once for all the WatchSecurityPolicies
+// streams, and sends the policies to each of them when they change. It only polls while
+// at least one stream is open.
+type securityPoliciesPoller struct {
+	getter   clusteragent.SecurityPoliciesGetter
+	interval time.Duration
+
+	sync.Mutex
+	last        *pb.SecurityPoliciesResponse
+	subscribers map[chan *pb.SecurityPoliciesResponse]struct{}
+	stop        chan struct{}
+}
+
+func newSecurityPoliciesPoller(getter clusteragent.SecurityPoliciesGetter, interval time.Duration) *securityPoliciesPoller {
+	return &securityPoliciesPoller{
+		getter:      getter,
+		interval:    interval,
+		subscribers: make(map[chan *pb.SecurityPoliciesResponse]struct{}),
+	}
+}
+
+// subscribe returns a channel receiving the current policies, if they were already
+// polled, then every time they change. The channel only holds the latest policies.
+func (p *securityPoliciesPoller) subscribe() chan *pb.SecurityPoliciesResponse {
+	p.Lock()
+	defer p.Unlock()
+
+	ch := make(chan *pb.SecurityPoliciesResponse, 1)
+	p.subscribers[ch] = struct{}{}
+	if p.last != nil {
+		ch <- p.last
+	}
+
+	if p.stop == nil {
+		p.stop = make(chan struct{})
+		go p.run(p.stop)
+	}
+
+	return ch
+}
+
+// unsubscribe removes a subscriber, and stops polling after the last one
+func (p *securityPoliciesPoller) unsubscribe(ch chan *pb.SecurityPoliciesResponse) {
+	p.Lock()
+	defer p.Unlock()
+
+	delete(p.subscribers, ch)
+	if len(p.subscribers) == 0 && p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+		p.last = nil
+	}
+}
+
+func (p *securityPoliciesPoller) run(stop chan struct{}) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.poll(stop)
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll gets the policies and sends them to the subscribers if they changed
+func (p *securityPoliciesPoller) poll(stop chan struct{}) {
+	response, err := getSecurityPolicies(p.getter)
+	if err != nil {
+		log.Debugf("Could not retrieve the security policies: %v", err)
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	// the poller was stopped, and possibly started again, while getting the policies
+	if p.stop != stop {
+		return
+	}
+	if p.last != nil && proto.Equal(p.last, response) {
+		return
+	}
+	p.last = response
+
+	for ch := range p.subscribers {
+		// replace the policies not received yet by the subscriber
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- response
+	}
+}
@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/cluster-agent/api/pb"
+)
+
+type fakePoliciesGetter struct {
+	sync.Mutex
+	policies map[string][]byte
+	calls    int
+}
+
+func (g *fakePoliciesGetter) GetPolicies() (map[string][]byte, error) {
+	g.Lock()
+	defer g.Unlock()
+	g.calls++
+	return g.policies, nil
+}
+
+func (g *fakePoliciesGetter) set(name, content string) {
+	g.Lock()
+	defer g.Unlock()
+	g.policies = map[string][]byte{name: []byte(content)}
+}
+
+func (g *fakePoliciesGetter) getCalls() int {
+	g.Lock()
+	defer g.Unlock()
+	return g.calls
+}
+
+func receivePolicies(t *testing.T, ch chan *pb.SecurityPoliciesResponse) *pb.SecurityPoliciesResponse {
+	select {
+	case response := <-ch:
+		return response
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the policies were not received")
+		return nil
+	}
+}
+
+func TestSecurityPoliciesPoller(t *testing.T) {
+	getter := &fakePoliciesGetter{}
+	getter.set("foo", "version: 1")
+	poller := newSecurityPoliciesPoller(getter, time.Hour)
+
+	first := poller.subscribe()
+	response := receivePolicies(t, first)
+	require.Len(t, response.Policies, 1)
+	assert.Equal(t, "foo", response.Policies[0].Name)
+
+	// the current policies are sent to a new stream without polling them again
+	second := poller.subscribe()
+	assert.Equal(t, response, receivePolicies(t, second))
+	assert.Equal(t, 1, getter.getCalls())
+
+	// a single poll is shared by the streams
+	getter.set("bar", "version: 1")
+	poller.poll(poller.stop)
+	assert.Equal(t, 2, getter.getCalls())
+	for _, ch := range []chan *pb.SecurityPoliciesResponse{first, second} {
+		response := receivePolicies(t, ch)
+		require.Len(t, response.Policies, 1)
+		assert.Equal(t, "bar", response.Policies[0].Name)
+	}
+
+	// unchanged policies aren't sent again
+	poller.poll(poller.stop)
+	assert.Len(t, first, 0)
+	assert.Len(t, second, 0)
+
+	poller.unsubscribe(first)
+	assert.NotNil(t, poller.stop)
+	poller.unsubscribe(second)
+	assert.Nil(t, poller.stop)
+}
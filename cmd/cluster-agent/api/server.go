@@ -21,8 +21,11 @@ import (
 	"strings"
 
 	"github.com/gorilla/mux"
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+	"google.golang.org/grpc"
 
 	"github.com/DataDog/datadog-agent/cmd/cluster-agent/api/agent"
+	"github.com/DataDog/datadog-agent/cmd/cluster-agent/api/pb"
 	"github.com/DataDog/datadog-agent/pkg/api/security"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent"
@@ -44,6 +47,13 @@ func StartServer(sc clusteragent.ServerContext) error {
 	// Validate token for every request
 	r.Use(validateToken)
 
+	// gRPC API served to the node agents, on the same port
+	grpcSrv := grpc.NewServer(
+		grpc.StreamInterceptor(grpc_auth.StreamServerInterceptor(grpcAuth)),
+		grpc.UnaryInterceptor(grpc_auth.UnaryServerInterceptor(grpcAuth)),
+	)
+	pb.RegisterClusterAgentSecureServer(grpcSrv, newServerSecure(sc))
+
 	// get the transport we're going to use under HTTP
 	var err error
 	listener, err = getListener()
@@ -78,10 +88,11 @@ func StartServer(sc clusteragent.ServerContext) error {
 
 	tlsConfig := tls.Config{
 		Certificates: []tls.Certificate{rootTLSCert},
+		NextProtos:   []string{"h2"},
 	}
 
 	srv := &http.Server{
-		Handler: r,
+		Handler: grpcHandlerFunc(grpcSrv, r),
 		ErrorLog: stdLog.New(&config.ErrorLogWriter{
 			AdditionalDepth: 4, // Use a stack depth of 4 on top of the default one to get a relevant filename in the stdlib
 		}, "Error from the agent http API server: ", 0), // log errors to seelog,
@@ -94,6 +105,19 @@ func StartServer(sc clusteragent.ServerContext) error {
 	return nil
 }
 
+// grpcHandlerFunc returns an http.Handler that delegates to grpcServer on incoming gRPC
+// connections or otherHandler otherwise. Copied from cockroachdb.
+func grpcHandlerFunc(grpcServer *grpc.Server, otherHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// This is a partial recreation of gRPC's internal checks https://github.com/grpc/grpc-go/pull/514/files#diff-95e9a25b738459a2d3030e1e6fa2a718R61
+		if r.ProtoMajor == 2 && strings.Contains(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+		} else {
+			otherHandler.ServeHTTP(w, r)
+		}
+	})
+}
+
 // StopServer closes the connection and the server
 // stops listening to new commands.
 func StopServer() {
@@ -16,6 +16,7 @@ func installKubernetesMetadataEndpoints(r *mux.Router) {
 	r.HandleFunc("/tags/pod/{nodeName}", getPodMetadataForNode).Methods("GET")
 	r.HandleFunc("/tags/pod", getAllMetadata).Methods("GET")
 	r.HandleFunc("/tags/node/{nodeName}", getNodeMetadata).Methods("GET")
+	r.HandleFunc("/services/ips", getServiceNamesByIP).Methods("GET")
 }
 
 // getNodeMetadata is only used when the node agent hits the DCA for the list of labels
@@ -77,6 +78,48 @@ func getNodeMetadata(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(fmt.Sprintf("Could not find labels on the node: %s", nodeName)))
 }
 
+// getServiceNamesByIP is used by the process agent to resolve the destinations of the connections to services
+func getServiceNamesByIP(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			localhost:5001/api/v1/services/ips
+		Outputs
+			Status: 200
+			Returns: map[string][]string
+			Example: {"10.96.0.10": ["checkout-svc.shop"], "10.0.0.1": ["checkout-svc.shop"]}
+
+			Status: 500
+			Returns: string
+			Example: "Metadata collection is disabled on the Cluster Agent"
+	*/
+	namesByIP, err := as.GetServiceNamesByIP()
+	if err != nil {
+		log.Errorf("Could not retrieve the service names of the IPs: %v", err.Error()) //nolint:errcheck
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apiRequests.Inc(
+			"getServiceNamesByIP",
+			strconv.Itoa(http.StatusInternalServerError),
+		)
+		return
+	}
+	namesBytes, err := json.Marshal(namesByIP)
+	if err != nil {
+		log.Errorf("Could not process the service names of the IPs: %v", err.Error()) //nolint:errcheck
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apiRequests.Inc(
+			"getServiceNamesByIP",
+			strconv.Itoa(http.StatusInternalServerError),
+		)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(namesBytes)
+	apiRequests.Inc(
+		"getServiceNamesByIP",
+		strconv.Itoa(http.StatusOK),
+	)
+}
+
 // getPodMetadata is only used when the node agent hits the DCA for the tags list.
 // It returns a list of all the tags that can be directly used in the tagger of the agent.
 func getPodMetadata(w http.ResponseWriter, r *http.Request) {
@@ -34,6 +34,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/mutate"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/orchestrator"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/securitypolicies"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
@@ -288,6 +289,10 @@ func start(cmd *cobra.Command, args []string) error {
 	sc := clusteragent.ServerContext{
 		ClusterCheckHandler: clusterCheckHandler,
 	}
+	if config.Datadog.GetBool("runtime_security_config.policies.custom_resources.enabled") {
+		sc.SecurityPolicies = securitypolicies.NewStore(apiCl.DynamicCl, stopCh)
+		log.Info("Watching the DatadogSecurityPolicy custom resources")
+	}
 	if err = api.StartServer(sc); err != nil {
 		return log.Errorf("Error while starting agent API, exiting: %v", err)
 	}
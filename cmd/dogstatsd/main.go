@@ -22,6 +22,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/api/healthprobe"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/bundle"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/metadata"
@@ -120,6 +121,13 @@ func runAgent(ctx context.Context) (err error) {
 		} else {
 			configFound = true
 		}
+
+		// the bundle is fetched by the core agent
+		if configFound && bundle.Enabled(config.Datadog) {
+			if _, err := bundle.LoadConfig(false, false); err != nil {
+				return err
+			}
+		}
 	}
 
 	if !configFound {
@@ -197,6 +205,12 @@ func runAgent(ctx context.Context) (err error) {
 		}
 	}
 
+	if config.Datadog.GetBool("metric_metadata_enabled") {
+		if err = metadata.SetupMetricMetadata(metaScheduler); err != nil {
+			return
+		}
+	}
+
 	// container tagging initialisation if origin detection is on
 	if config.Datadog.GetBool("dogstatsd_origin_detection") {
 		tagger.Init()
@@ -89,7 +89,7 @@ func (s *Server) Start() error {
 			AdditionalDepth: 4, // Use a stack depth of 4 on top of the default one to get a relevant filename in the stdlib
 		}, "Error from the agent http API server: ", 0), // log errors to seelog,
 		TLSConfig:    &tlsConfig,
-		WriteTimeout: config.Datadog.GetDuration("server_timeout") * time.Second,
+		WriteTimeout: config.Datadog.GetDurationWithUnit("server_timeout", time.Second),
 	}
 	tlsListener := tls.NewListener(s.listener, &tlsConfig)
 
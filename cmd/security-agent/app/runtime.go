@@ -10,6 +10,7 @@ package app
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -18,6 +19,7 @@ import (
 	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	logshttp "github.com/DataDog/datadog-agent/pkg/logs/client/http"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
@@ -108,6 +110,44 @@ func newRuntimeReporter(stopper restart.Stopper, sourceName, sourceType string,
 	return event.NewReporter(logSource, pipelineProvider.NextPipelineChan()), nil
 }
 
+// newRuntimeUploader returns a reporter uploading the events by compressed batches to the logs HTTP
+// intake, the batches being queued on disk until they are accepted
+func newRuntimeUploader(hostname, sourceName string, context *client.DestinationsContext) (*secagent.BatchUploader, error) {
+	endpoints, err := config.BuildHTTPEndpoints()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid runtime security event forwarder endpoints")
+	}
+
+	queue, err := secagent.NewBatchQueue(
+		coreconfig.Datadog.GetString("runtime_security_config.event_forwarder.queue_dir"),
+		int64(coreconfig.Datadog.GetInt("runtime_security_config.event_forwarder.queue_max_size"))*1024*1024,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the runtime security event queue")
+	}
+
+	main := endpoints.Main
+	main.UseCompression = true
+	main.CompressionLevel = coreconfig.Datadog.GetInt("runtime_security_config.event_forwarder.compression_level")
+
+	var additionals []secagent.AsyncBatchSender
+	for _, endpoint := range endpoints.Additionals {
+		endpoint.UseCompression = true
+		endpoint.CompressionLevel = main.CompressionLevel
+		additionals = append(additionals, logshttp.NewDestination(endpoint, logshttp.JSONContentType, context))
+	}
+
+	return secagent.NewBatchUploader(
+		hostname,
+		sourceName,
+		queue,
+		logshttp.NewDestination(main, logshttp.JSONContentType, context),
+		additionals,
+		coreconfig.Datadog.GetInt("runtime_security_config.event_forwarder.batch_size"),
+		time.Duration(coreconfig.Datadog.GetInt("runtime_security_config.event_forwarder.batch_wait"))*time.Second,
+	), nil
+}
+
 func startRuntimeSecurity(hostname string, endpoints *config.Endpoints, context *client.DestinationsContext, stopper restart.Stopper, statsdClient *ddgostatsd.Client) (*secagent.RuntimeSecurityAgent, error) {
 	enabled := coreconfig.Datadog.GetBool("runtime_security_config.enabled")
 	if !enabled {
@@ -115,18 +155,39 @@ func startRuntimeSecurity(hostname string, endpoints *config.Endpoints, context
 		return nil, nil
 	}
 
-	reporter, err := newRuntimeReporter(stopper, "runtime-security-agent", "runtime-security", endpoints, context)
-	if err != nil {
+	var reporter event.Reporter
+	var uploader *secagent.BatchUploader
+	var err error
+	if coreconfig.Datadog.GetBool("runtime_security_config.event_forwarder.enabled") {
+		if uploader, err = newRuntimeUploader(hostname, "runtime-security-agent", context); err != nil {
+			return nil, err
+		}
+		uploader.Start()
+		reporter = uploader
+	} else if reporter, err = newRuntimeReporter(stopper, "runtime-security-agent", "runtime-security", endpoints, context); err != nil {
 		return nil, err
 	}
 
 	agent, err := secagent.NewRuntimeSecurityAgent(hostname, reporter)
 	if err != nil {
+		if uploader != nil {
+			uploader.Stop()
+		}
 		return nil, errors.Wrap(err, "unable to create a runtime security agent instance")
 	}
 	agent.Start()
 
 	stopper.Add(agent)
+	// the uploader is stopped after the agent so that its last events are queued
+	if uploader != nil {
+		stopper.Add(uploader)
+	}
+
+	if coreconfig.Datadog.GetBool("runtime_security_config.policies.watch_cluster_agent") {
+		watcher := secagent.NewClusterPoliciesWatcher(coreconfig.Datadog.GetString("runtime_security_config.policies.cluster_dir"))
+		watcher.Start()
+		stopper.Add(watcher)
+	}
 
 	log.Info("Datadog runtime security agent is now running")
 
@@ -0,0 +1,130 @@
+// +build linux_bpf
+
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	secagentcommon "github.com/DataDog/datadog-agent/cmd/security-agent/common"
+	secconfig "github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/policy"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+var (
+	replayCmd = &cobra.Command{
+		Use:   "replay <recording>",
+		Short: "Replay a recording of the kernel events against the policies",
+		Long: `Evaluate the kernel events of a recording, saved by the probe when runtime_security_config.event_recorder
+is enabled, against the rules of the policies, and print the events matching a rule. The resolvers are restored
+from the recording so that the events can be replayed on another host, without a live kernel.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runReplay,
+	}
+
+	replayArgs = struct {
+		dir string
+	}{}
+)
+
+func init() {
+	runtimeCmd.AddCommand(replayCmd)
+	replayCmd.Flags().StringVar(&replayArgs.dir, "policies-dir", "", "Path to policies directory (default: the one of the configuration)")
+}
+
+// replayHandler evaluates the replayed events against the rules and prints the ones matching a rule
+type replayHandler struct {
+	ruleSet *rules.RuleSet
+	events  int
+	matches int
+}
+
+// HandleEvent is called by the probe for each replayed event
+func (h *replayHandler) HandleEvent(event *sprobe.Event) {
+	h.events++
+	h.ruleSet.Evaluate(event)
+}
+
+// RuleMatch is called by the rule set when an event matches a rule
+func (h *replayHandler) RuleMatch(rule *eval.Rule, event eval.Event) {
+	h.matches++
+
+	data, err := event.(*sprobe.Event).MarshalJSON()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to marshal the event matching %s: %s\n", rule.ID, err)
+		return
+	}
+
+	content, _ := json.Marshal(struct {
+		RuleID string          `json:"rule_id"`
+		Event  json.RawMessage `json:"event"`
+	}{
+		RuleID: rule.ID,
+		Event:  data,
+	})
+	fmt.Printf("%s\n", string(content))
+}
+
+// EventDiscarderFound is called by the rule set when a discarder is found, the replay doesn't use them
+func (h *replayHandler) EventDiscarderFound(rs *rules.RuleSet, event eval.Event, field eval.Field, eventType eval.EventType) {
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	// Read configuration files received from the command line arguments '-c'
+	if err := secagentcommon.MergeConfigurationFiles("datadog", confPathArray); err != nil {
+		return err
+	}
+
+	cfg, err := secconfig.NewConfig(nil)
+	if err != nil {
+		return err
+	}
+	if replayArgs.dir != "" {
+		cfg.PoliciesDir = replayArgs.dir
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	probe, err := sprobe.NewReplayProbe(cfg, file)
+	if err != nil {
+		return err
+	}
+
+	ruleSet := probe.NewRuleSet(rules.NewOptsWithParams(sprobe.SECLConstants, sprobe.SupportedDiscarders))
+	if err := policy.LoadPolicies(cfg, ruleSet); err != nil {
+		return err
+	}
+
+	handler := &replayHandler{ruleSet: ruleSet}
+	ruleSet.AddListener(handler)
+	probe.SetEventHandler(handler)
+
+	header, err := probe.RecordingHeader()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Replaying the events recorded on kernel %s since %s\n", header.KernelVersion, header.StartTime)
+
+	if err := probe.Replay(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d events replayed, %d rules matched\n", handler.events, handler.matches)
+
+	return nil
+}
@@ -0,0 +1,138 @@
+// +build linux
+
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	secagentcommon "github.com/DataDog/datadog-agent/cmd/security-agent/common"
+	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
+	pconfig "github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/process/net"
+	"github.com/DataDog/datadog-agent/pkg/security/module"
+	"github.com/DataDog/datadog-agent/pkg/security/triage"
+)
+
+var (
+	triageCmd = &cobra.Command{
+		Use:   "triage",
+		Short: "Collect a forensic triage bundle of a process subtree or of a container",
+		Long: `Collect the processes, open file descriptors, sockets, loaded libraries and environment of a process
+subtree, or of all the processes of a container, along with the recent security events they triggered, in a
+single archive for incident responders.`,
+		RunE: runTriage,
+	}
+
+	triageArgs = struct {
+		pid         uint32
+		containerID string
+		output      string
+		since       time.Duration
+		noScrub     bool
+	}{}
+)
+
+func init() {
+	runtimeCmd.AddCommand(triageCmd)
+	triageCmd.Flags().Uint32Var(&triageArgs.pid, "pid", 0, "Root of the process subtree to collect")
+	triageCmd.Flags().StringVar(&triageArgs.containerID, "container-id", "", "ID of the container whose processes are collected")
+	triageCmd.Flags().StringVarP(&triageArgs.output, "output", "o", "", "Path of the archive (default: ./security-triage-<target>-<timestamp>.zip)")
+	triageCmd.Flags().DurationVar(&triageArgs.since, "events-since", time.Hour, "Age of the oldest security event included in the archive")
+	triageCmd.Flags().BoolVar(&triageArgs.noScrub, "no-scrub", false, "Don't scrub the sensitive arguments and environment variables")
+}
+
+func runTriage(cmd *cobra.Command, args []string) error {
+	if flagNoColor {
+		color.NoColor = true
+	}
+
+	if (triageArgs.pid == 0) == (triageArgs.containerID == "") {
+		return errors.New("exactly one of --pid and --container-id is required")
+	}
+
+	// Read configuration files received from the command line arguments '-c'
+	if err := secagentcommon.MergeConfigurationFiles("datadog", confPathArray); err != nil {
+		return err
+	}
+
+	opts := triage.Options{
+		Pid:         triageArgs.pid,
+		ContainerID: triageArgs.containerID,
+	}
+
+	if !triageArgs.noScrub {
+		opts.Scrubber = pconfig.NewDefaultDataScrubber()
+		opts.Scrubber.AddCustomSensitiveWords(coreconfig.Datadog.GetStringSlice("runtime_security_config.custom_sensitive_words"))
+	}
+
+	output := triageArgs.output
+	if output == "" {
+		target := triageArgs.containerID
+		if target == "" {
+			target = strconv.Itoa(int(triageArgs.pid))
+		}
+		output = fmt.Sprintf("security-triage-%s-%s.zip", target, time.Now().UTC().Format("2006-01-02-15-04-05"))
+	}
+
+	// the bundle is still useful without the security events, when system-probe or its event store is unavailable
+	events, err := fetchSecurityEvents(triageArgs.containerID, triageArgs.since)
+	if err != nil {
+		fmt.Fprintln(color.Output, color.YellowString(fmt.Sprintf("The security events couldn't be retrieved: %s", err)))
+	}
+
+	metadata, err := triage.Collect(opts, events, output)
+	if err != nil {
+		return err
+	}
+
+	for _, collectErr := range metadata.Errors {
+		fmt.Fprintln(color.Output, color.YellowString(collectErr))
+	}
+	fmt.Fprintln(color.Output, color.GreenString(fmt.Sprintf("Triage bundle of %d process(es) written to %s", len(metadata.Pids), output)))
+
+	return nil
+}
+
+// fetchSecurityEvents returns the recent security events from the event store of system-probe
+func fetchSecurityEvents(containerID string, since time.Duration) ([]module.StoredEvent, error) {
+	socketPath := pconfig.GetSocketPath()
+	if coreconfig.Datadog.IsSet("system_probe_config.sysprobe_socket") {
+		socketPath = coreconfig.Datadog.GetString("system_probe_config.sysprobe_socket")
+	}
+	net.SetSystemProbePath(socketPath)
+
+	probeUtil, err := net.GetRemoteSystemProbeUtil()
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("from", time.Now().Add(-since).UTC().Format(time.RFC3339))
+	if containerID != "" {
+		query.Set("container_id", containerID)
+	}
+
+	data, err := probeUtil.GetSecurityEvents(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []module.StoredEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
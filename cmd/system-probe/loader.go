@@ -3,22 +3,30 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/DataDog/datadog-agent/cmd/system-probe/api"
+	"github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/process/statsd"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/pkg/errors"
 )
 
+// loadErrorMetric is the metric sent when the eBPF programs of a module fail to load
+const loadErrorMetric = "datadog.system_probe.ebpf.load_error"
+
 // Loader is responsible for managing the lifecyle of each api.Module, which includes:
 // * Module initialization;
 // * Module termination;
 // * Module telemetry consolidation;
 type Loader struct {
-	once    sync.Once
-	modules map[string]api.Module
+	once       sync.Once
+	modules    map[string]api.Module
+	loadErrors map[string]*ebpf.LoadError
 }
 
 // Register a set of modules, which involves:
@@ -36,12 +44,16 @@ func (l *Loader) Register(cfg *config.AgentConfig, httpMux *http.ServeMux, facto
 		// In case a module failed to be started, do not make the whole `system-probe` abort.
 		// Let `system-probe` run the other modules.
 		if err != nil {
-			log.Errorf("new module `%s` error: %s", factory.Name, err)
+			if !l.reportLoadError(factory.Name, err) {
+				log.Errorf("new module `%s` error: %s", factory.Name, err)
+			}
 			continue
 		}
 
 		if err = module.Register(httpMux); err != nil {
-			log.Errorf("error registering HTTP endpoints for module `%s` error: %s", factory.Name, err)
+			if !l.reportLoadError(factory.Name, err) {
+				log.Errorf("error registering HTTP endpoints for module `%s` error: %s", factory.Name, err)
+			}
 			continue
 		}
 
@@ -63,9 +75,37 @@ func (l *Loader) GetStats() map[string]interface{} {
 	for name, module := range l.modules {
 		stats[name] = module.GetStats()
 	}
+	if len(l.loadErrors) > 0 {
+		stats["load_errors"] = l.loadErrors
+	}
 	return stats
 }
 
+// reportLoadError records the failure of a module to load its eBPF programs, to surface it through the
+// status and the flare, and sends the load error metric. It returns false if err isn't such a failure.
+func (l *Loader) reportLoadError(name string, err error) bool {
+	var loadErr *ebpf.LoadError
+	if !errors.As(err, &loadErr) {
+		return false
+	}
+
+	l.loadErrors[name] = loadErr
+
+	log.Errorf("module `%s` failed to load its eBPF programs (reason: %s): %s [%s]", name, loadErr.Reason, loadErr.Message, strings.Join(loadErr.Diagnostics, ", "))
+	if loadErr.VerifierLog != "" {
+		log.Debugf("module `%s` verifier log:\n%s", name, loadErr.VerifierLog)
+	}
+
+	if statsd.Client != nil {
+		tags := []string{fmt.Sprintf("module:%s", name), fmt.Sprintf("reason:%s", loadErr.Reason)}
+		if err := statsd.Client.Count(loadErrorMetric, 1, tags, 1); err != nil {
+			log.Debugf("unable to send the %s metric: %s", loadErrorMetric, err)
+		}
+	}
+
+	return true
+}
+
 // Close each registered module
 func (l *Loader) Close() {
 	l.once.Do(func() {
@@ -78,6 +118,7 @@ func (l *Loader) Close() {
 // NewLoader returns a new Loader instance
 func NewLoader() *Loader {
 	return &Loader{
-		modules: make(map[string]api.Module),
+		modules:    make(map[string]api.Module),
+		loadErrors: make(map[string]*ebpf.LoadError),
 	}
 }
@@ -20,6 +20,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/process/config"
 	"github.com/DataDog/datadog-agent/pkg/process/net"
 	"github.com/DataDog/datadog-agent/pkg/process/statsd"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -28,6 +29,7 @@ var factories = []api.Factory{
 	modules.NetworkTracer,
 	modules.TCPQueueLength,
 	modules.OOMKillProbe,
+	modules.UprobeTracer,
 	modules.SecurityRuntime,
 }
 
@@ -135,6 +137,9 @@ func runAgent(exit <-chan struct{}) {
 		utils.WriteAsJSON(w, stats)
 	})
 
+	// Register the endpoint of the internal metrics of the modules, in the Prometheus format
+	httpMux.Handle("/telemetry", telemetry.Handler())
+
 	go func() {
 		err = http.Serve(conn.GetListener(), httpMux)
 		if err != nil {
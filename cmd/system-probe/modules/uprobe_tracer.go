@@ -0,0 +1,49 @@
+package modules
+
+import (
+	"net/http"
+
+	"github.com/DataDog/datadog-agent/cmd/system-probe/api"
+	"github.com/DataDog/datadog-agent/cmd/system-probe/utils"
+	"github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/pkg/errors"
+)
+
+// UprobeTracer Factory
+var UprobeTracer = api.Factory{
+	Name: "uprobe_tracer",
+	Fn: func(cfg *config.AgentConfig) (api.Module, error) {
+		if !cfg.CheckIsEnabled("Uprobes") {
+			log.Info("Uprobe tracer disabled")
+			return nil, api.ErrNotEnabled
+		}
+
+		log.Infof("Starting the uprobe tracer")
+		t, err := ebpf.NewUprobeTracer(config.SysProbeConfigFromConfig(cfg))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to start the uprobe tracer")
+		}
+		return &uprobeModule{t}, nil
+	},
+}
+
+var _ api.Module = &uprobeModule{}
+
+type uprobeModule struct {
+	*ebpf.UprobeTracer
+}
+
+func (u *uprobeModule) Register(httpMux *http.ServeMux) error {
+	httpMux.HandleFunc("/check/uprobe", func(w http.ResponseWriter, req *http.Request) {
+		stats := u.UprobeTracer.GetAndFlush()
+		utils.WriteAsJSON(w, stats)
+	})
+
+	return nil
+}
+
+func (u *uprobeModule) GetStats() map[string]interface{} {
+	return nil
+}
@@ -33,4 +33,13 @@ build do
 
   copy 'pkg/ebpf/c/tcp-queue-length-kern.c', "#{install_dir}/embedded/share/system-probe/ebpf/"
   copy 'pkg/ebpf/tcp-queue-length-kern-user.h', "#{install_dir}/embedded/share/system-probe/ebpf/"
+
+  # sources of the network tracer, compiled at runtime when the pre-built programs can't be loaded
+  copy 'pkg/ebpf/c/tracer-ebpf.c', "#{install_dir}/embedded/share/system-probe/ebpf/"
+  copy 'pkg/ebpf/c/tracer-ebpf.h', "#{install_dir}/embedded/share/system-probe/ebpf/"
+  copy 'pkg/ebpf/c/offset-guess.c', "#{install_dir}/embedded/share/system-probe/ebpf/"
+  copy 'pkg/ebpf/c/offset-guess.h', "#{install_dir}/embedded/share/system-probe/ebpf/"
+  copy 'pkg/ebpf/c/bpf_helpers.h', "#{install_dir}/embedded/share/system-probe/ebpf/"
+  copy 'pkg/ebpf/c/syscalls.h', "#{install_dir}/embedded/share/system-probe/ebpf/"
+  copy 'pkg/ebpf/c/asm_goto_workaround.h', "#{install_dir}/embedded/share/system-probe/ebpf/"
 end
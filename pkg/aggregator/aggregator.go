@@ -100,6 +100,7 @@ var (
 	aggregatorEventsFlushed                    = expvar.Int{}
 	aggregatorNumberOfFlush                    = expvar.Int{}
 	aggregatorDogstatsdMetricSample            = expvar.Int{}
+	aggregatorDogstatsdMetricSampleDownsampled = expvar.Int{}
 	aggregatorChecksMetricSample               = expvar.Int{}
 	aggregatorCheckHistogramBucketMetricSample = expvar.Int{}
 	aggregatorServiceCheck                     = expvar.Int{}
@@ -142,6 +143,7 @@ func init() {
 	aggregatorExpvars.Set("EventsFlushed", &aggregatorEventsFlushed)
 	aggregatorExpvars.Set("NumberOfFlush", &aggregatorNumberOfFlush)
 	aggregatorExpvars.Set("DogstatsdMetricSample", &aggregatorDogstatsdMetricSample)
+	aggregatorExpvars.Set("DogstatsdMetricSampleDownsampled", &aggregatorDogstatsdMetricSampleDownsampled)
 	aggregatorExpvars.Set("ChecksMetricSample", &aggregatorChecksMetricSample)
 	aggregatorExpvars.Set("ChecksHistogramBucketMetricSample", &aggregatorCheckHistogramBucketMetricSample)
 	aggregatorExpvars.Set("ServiceCheck", &aggregatorServiceCheck)
@@ -198,6 +200,8 @@ type BufferedAggregator struct {
 	MetricSamplePool *metrics.MetricSamplePool
 
 	statsdSampler      TimeSampler
+	downsampler        *downsampler
+	downsampledSampler map[int64]*TimeSampler // samplers of the downsampled dogstatsd metrics, by bucket interval
 	checkSamplers      map[check.ID]*CheckSampler
 	serviceChecks      metrics.ServiceChecks
 	events             metrics.Events
@@ -258,9 +262,32 @@ func NewBufferedAggregator(s serializer.MetricSerializer, hostname string, flush
 		agentTags:               tagger.AgentTags,
 	}
 
+	aggregator.initDownsampling()
+
 	return aggregator
 }
 
+// initDownsampling sets up the downsampling rules of the dogstatsd metrics, they are all ignored
+// if one of them is invalid
+func (agg *BufferedAggregator) initDownsampling() {
+	rules, err := config.GetDownsamplingRules()
+	if err == nil {
+		agg.downsampler, err = newDownsampler(rules)
+	}
+	if err != nil {
+		log.Errorf("Ignoring the aggregator downsampling rules: %s", err)
+		return
+	}
+	if agg.downsampler == nil {
+		return
+	}
+
+	agg.downsampledSampler = make(map[int64]*TimeSampler)
+	for _, interval := range agg.downsampler.intervals() {
+		agg.downsampledSampler[interval] = NewTimeSampler(interval)
+	}
+}
+
 // AddRecurrentSeries adds a serie to the series that are sent at every flush
 func AddRecurrentSeries(newSerie *metrics.Serie) {
 	recurrentSeriesLock.Lock()
@@ -384,7 +411,16 @@ func (agg *BufferedAggregator) addEvent(e metrics.Event) {
 
 // addSample adds the metric sample
 func (agg *BufferedAggregator) addSample(metricSample *metrics.MetricSample, timestamp float64) {
+	var interval int64
+	if agg.downsampler != nil {
+		interval = agg.downsampler.apply(metricSample)
+	}
+
 	metricSample.Tags = util.SortUniqInPlace(metricSample.Tags)
+	if sampler, ok := agg.downsampledSampler[interval]; ok {
+		sampler.addSample(metricSample, timestamp)
+		return
+	}
 	agg.statsdSampler.addSample(metricSample, timestamp)
 }
 
@@ -393,6 +429,12 @@ func (agg *BufferedAggregator) GetSeriesAndSketches() (metrics.Series, metrics.S
 	agg.mu.Lock()
 	series, sketches := agg.statsdSampler.flush(timeNowNano())
 
+	for _, sampler := range agg.downsampledSampler {
+		s, sk := sampler.flush(timeNowNano())
+		series = append(series, s...)
+		sketches = append(sketches, sk...)
+	}
+
 	for _, checkSampler := range agg.checkSamplers {
 		s, sk := checkSampler.flush()
 		series = append(series, s...)
@@ -424,6 +466,10 @@ func (agg *BufferedAggregator) pushSeries(start time.Time, series metrics.Series
 		log.Warnf("Error flushing series: %v", err)
 		aggregatorSeriesFlushErrors.Add(1)
 		state = stateError
+		// the aggregator keeps running, the series of the next flushes may be sent
+		health.SetState("aggregator", health.StateDegraded, fmt.Sprintf("unable to flush the series: %v", err))
+	} else {
+		health.SetState("aggregator", health.StateReady, "")
 	}
 	addFlushTime("ChecksMetricSampleFlushTime", int64(time.Since(start)))
 	aggregatorSeriesFlushed.Add(int64(len(series)))
@@ -629,7 +675,7 @@ func (agg *BufferedAggregator) flush(start time.Time, waitForSerializer bool) {
 func (agg *BufferedAggregator) Stop() {
 	agg.stopChan <- struct{}{}
 
-	timeout := config.Datadog.GetDuration("aggregator_stop_timeout") * time.Second
+	timeout := config.Datadog.GetDurationWithUnit("aggregator_stop_timeout", time.Second)
 	if timeout > 0 {
 		done := make(chan struct{})
 		go func() {
@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aggregator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// downsamplingRule is the compiled form of a config.DownsamplingRule
+type downsamplingRule struct {
+	match          *regexp.Regexp
+	dropTags       map[string]bool
+	interval       int64
+	toDistribution bool
+}
+
+// downsampler applies the first rule matching the name of the dogstatsd samples, reducing the
+// number of contexts and points sent for them
+type downsampler struct {
+	rules []*downsamplingRule
+}
+
+// newDownsampler compiles the rules, it returns nil when there is no rule
+func newDownsampler(rules []config.DownsamplingRule) (*downsampler, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	d := &downsampler{}
+	for _, rule := range rules {
+		if rule.Interval%bucketSize != 0 {
+			return nil, fmt.Errorf("the interval of the downsampling rule %q must be a multiple of %d seconds", rule.Match, bucketSize)
+		}
+
+		pattern := strings.Replace(regexp.QuoteMeta(rule.Match), `\*`, ".*", -1)
+		match, err := regexp.Compile("^" + pattern + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid downsampling rule %q: %s", rule.Match, err)
+		}
+
+		compiled := &downsamplingRule{
+			match:          match,
+			dropTags:       make(map[string]bool, len(rule.DropTags)),
+			interval:       rule.Interval,
+			toDistribution: rule.ToDistribution,
+		}
+		for _, tag := range rule.DropTags {
+			compiled.dropTags[tag] = true
+		}
+		d.rules = append(d.rules, compiled)
+	}
+	return d, nil
+}
+
+// intervals returns the bucket intervals used by the rules other than the default one
+func (d *downsampler) intervals() []int64 {
+	var intervals []int64
+	seen := make(map[int64]bool)
+	for _, rule := range d.rules {
+		if rule.interval == 0 || rule.interval == bucketSize || seen[rule.interval] {
+			continue
+		}
+		seen[rule.interval] = true
+		intervals = append(intervals, rule.interval)
+	}
+	return intervals
+}
+
+// apply rewrites the sample according to the first rule matching its name and returns the
+// interval of the buckets it has to be aggregated in, 0 when it keeps the default one
+func (d *downsampler) apply(sample *metrics.MetricSample) int64 {
+	for _, rule := range d.rules {
+		if !rule.match.MatchString(sample.Name) {
+			continue
+		}
+
+		if len(rule.dropTags) > 0 {
+			sample.Tags = rule.filterTags(sample.Tags)
+		}
+
+		if rule.toDistribution {
+			switch sample.Mtype {
+			case metrics.GaugeType, metrics.HistogramType:
+				sample.Mtype = metrics.DistributionType
+			}
+		}
+
+		aggregatorDogstatsdMetricSampleDownsampled.Add(1)
+		return rule.interval
+	}
+	return 0
+}
+
+// filterTags removes the tags whose name is dropped by the rule, in place as the samples own their tags
+func (r *downsamplingRule) filterTags(tags []string) []string {
+	filtered := tags[:0]
+	for _, tag := range tags {
+		name := tag
+		if i := strings.IndexByte(tag, ':'); i >= 0 {
+			name = tag[:i]
+		}
+		if !r.dropTags[name] {
+			filtered = append(filtered, tag)
+		}
+	}
+	return filtered
+}
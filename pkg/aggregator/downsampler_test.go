@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestDownsamplerApply(t *testing.T) {
+	d, err := newDownsampler([]config.DownsamplingRule{
+		{Match: "checkout.request.*", DropTags: []string{"request_id", "user_id"}, Interval: 60},
+		{Match: "checkout.*", ToDistribution: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{60}, d.intervals())
+
+	sample := &metrics.MetricSample{
+		Name:  "checkout.request.count",
+		Mtype: metrics.CounterType,
+		Tags:  []string{"env:prod", "request_id:1234", "user_id:42", "user_id"},
+	}
+	assert.Equal(t, int64(60), d.apply(sample))
+	assert.Equal(t, []string{"env:prod"}, sample.Tags)
+	assert.Equal(t, metrics.CounterType, sample.Mtype)
+
+	sample = &metrics.MetricSample{
+		Name:  "checkout.latency",
+		Mtype: metrics.HistogramType,
+		Tags:  []string{"env:prod", "request_id:1234"},
+	}
+	assert.Equal(t, int64(0), d.apply(sample))
+	assert.Equal(t, []string{"env:prod", "request_id:1234"}, sample.Tags)
+	assert.Equal(t, metrics.DistributionType, sample.Mtype)
+
+	sample = &metrics.MetricSample{
+		Name:  "payment.latency",
+		Mtype: metrics.GaugeType,
+		Tags:  []string{"request_id:1234"},
+	}
+	assert.Equal(t, int64(0), d.apply(sample))
+	assert.Equal(t, []string{"request_id:1234"}, sample.Tags)
+	assert.Equal(t, metrics.GaugeType, sample.Mtype)
+}
+
+func TestDownsamplerInvalidInterval(t *testing.T) {
+	_, err := newDownsampler([]config.DownsamplingRule{{Match: "checkout.*", Interval: 15}})
+	assert.Error(t, err)
+
+	d, err := newDownsampler(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, d)
+}
+
+func TestDownsampledSampling(t *testing.T) {
+	config.Datadog.Set("aggregator_downsampling_rules", []map[string]interface{}{
+		{"match": "checkout.*", "drop_tags": []string{"user_id"}, "interval": 60},
+	})
+	defer config.Datadog.Set("aggregator_downsampling_rules", nil)
+
+	agg := NewBufferedAggregator(nil, "hostname", time.Second)
+	require.NotNil(t, agg.downsampler)
+	require.Contains(t, agg.downsampledSampler, int64(60))
+
+	for i, user := range []string{"user_id:1", "user_id:2", "user_id:3"} {
+		agg.addSample(&metrics.MetricSample{
+			Name:       "checkout.count",
+			Value:      1,
+			Mtype:      metrics.CountType,
+			Tags:       []string{"env:prod", user},
+			SampleRate: 1,
+		}, float64(12000+10*i))
+	}
+	agg.addSample(&metrics.MetricSample{
+		Name:       "other.count",
+		Value:      1,
+		Mtype:      metrics.CountType,
+		Tags:       []string{"user_id:1"},
+		SampleRate: 1,
+	}, 12000)
+
+	series, _ := agg.downsampledSampler[60].flush(12060)
+	require.Len(t, series, 1)
+	assert.Equal(t, "checkout.count", series[0].Name)
+	assert.Equal(t, []string{"env:prod"}, series[0].Tags)
+	assert.Equal(t, int64(60), series[0].Interval)
+	assert.Equal(t, []metrics.Point{{Ts: 12000, Value: 3}}, series[0].Points)
+
+	series, _ = agg.statsdSampler.flush(12060)
+	require.Len(t, series, 1)
+	assert.Equal(t, "other.count", series[0].Name)
+	assert.Equal(t, []string{"user_id:1"}, series[0].Tags)
+}
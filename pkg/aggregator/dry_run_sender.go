@@ -0,0 +1,131 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aggregator
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util"
+)
+
+// DryRunResults holds what a check submitted to a DryRunSender since the previous flush
+type DryRunResults struct {
+	Series        metrics.Series
+	Sketches      metrics.SketchSeriesList
+	ServiceChecks metrics.ServiceChecks
+	Events        metrics.Events
+}
+
+// DryRunSender is a Sender whose submissions never reach the aggregator: the metrics are aggregated by a check
+// sampler of its own and kept, along with the service checks and the events, until they're flushed. It's used
+// to run a scheduled check on demand without sending its metrics to Datadog.
+type DryRunSender struct {
+	*checkSender
+
+	sampler       *CheckSampler
+	serviceChecks metrics.ServiceChecks
+	events        metrics.Events
+
+	smsIn          chan senderMetricSample
+	serviceCheckIn chan metrics.ServiceCheck
+	eventIn        chan metrics.Event
+	bucketIn       chan senderHistogramBucket
+	flushes        chan chan DryRunResults
+	stop           chan struct{}
+}
+
+// NewDryRunSender returns a DryRunSender for the given check. It submits the samples with the default hostname
+// and the custom tags of the current sender of the check, if any. Close must be called once it's not used anymore.
+func NewDryRunSender(id check.ID) (*DryRunSender, error) {
+	if aggregatorInstance == nil {
+		return nil, errors.New("Aggregator was not initialized")
+	}
+
+	s := &DryRunSender{
+		sampler:        newCheckSampler(),
+		smsIn:          make(chan senderMetricSample),
+		serviceCheckIn: make(chan metrics.ServiceCheck),
+		eventIn:        make(chan metrics.Event),
+		bucketIn:       make(chan senderHistogramBucket),
+		flushes:        make(chan chan DryRunResults),
+		stop:           make(chan struct{}),
+	}
+	s.checkSender = newCheckSender(id, aggregatorInstance.hostname, s.smsIn, s.serviceCheckIn, s.eventIn, s.bucketIn)
+
+	// the custom tags already hold the service tag, which is added once the check is configured
+	if current, err := senderPool.getSender(id); err == nil {
+		if cs, ok := current.(*checkSender); ok {
+			s.defaultHostname = cs.defaultHostname
+			s.defaultHostnameDisabled = cs.defaultHostnameDisabled
+			s.checkTags = append([]string(nil), cs.checkTags...)
+		}
+	}
+
+	go s.run()
+	return s, nil
+}
+
+func (s *DryRunSender) run() {
+	for {
+		select {
+		case sms := <-s.smsIn:
+			if sms.commit {
+				s.sampler.commit(timeNowNano())
+			} else {
+				sms.metricSample.Tags = util.SortUniqInPlace(sms.metricSample.Tags)
+				s.sampler.addSample(sms.metricSample)
+			}
+		case bucket := <-s.bucketIn:
+			bucket.bucket.Tags = util.SortUniqInPlace(bucket.bucket.Tags)
+			s.sampler.addBucket(bucket.bucket)
+		case sc := <-s.serviceCheckIn:
+			if sc.Ts == 0 {
+				sc.Ts = time.Now().Unix()
+			}
+			sc.Tags = util.SortUniqInPlace(sc.Tags)
+			s.serviceChecks = append(s.serviceChecks, &sc)
+		case e := <-s.eventIn:
+			if e.Ts == 0 {
+				e.Ts = time.Now().Unix()
+			}
+			e.Tags = util.SortUniqInPlace(e.Tags)
+			s.events = append(s.events, &e)
+		case results := <-s.flushes:
+			series, sketches := s.sampler.flush()
+			results <- DryRunResults{
+				Series:        series,
+				Sketches:      sketches,
+				ServiceChecks: s.serviceChecks,
+				Events:        s.events,
+			}
+			s.serviceChecks, s.events = nil, nil
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Flush returns the metrics committed, and the service checks and events submitted, since the previous flush
+func (s *DryRunSender) Flush() DryRunResults {
+	results := make(chan DryRunResults)
+	s.flushes <- results
+	return <-results
+}
+
+// Close releases the check sampler of the sender, the sender must not be used afterwards
+func (s *DryRunSender) Close() {
+	close(s.stop)
+}
+
+// SwapSender replaces the sender of the given check, without altering the samples the aggregator holds for it,
+// and returns the function restoring the previous sender. The previous sender isn't restored when the sender of
+// the check was replaced or destroyed in between, for example because the check was unscheduled.
+func SwapSender(sender Sender, id check.ID) (restore func()) {
+	return senderPool.swapSender(sender, id)
+}
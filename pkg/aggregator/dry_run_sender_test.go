@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestDryRunSender(t *testing.T) {
+	resetAggregator()
+	InitAggregator(nil, "testhostname")
+
+	sender, err := GetSender(checkID1)
+	require.NoError(t, err)
+	sender.SetCheckCustomTags([]string{"custom:tag"})
+
+	dryRunSender, err := NewDryRunSender(checkID1)
+	require.NoError(t, err)
+	defer dryRunSender.Close()
+
+	restore := SwapSender(dryRunSender, checkID1)
+	current, err := GetSender(checkID1)
+	require.NoError(t, err)
+	assert.Equal(t, dryRunSender, current)
+
+	current.Gauge("my.gauge", 1, "", []string{"foo:bar"})
+	current.ServiceCheck("my.service_check", metrics.ServiceCheckOK, "", nil, "")
+	current.Event(metrics.Event{Title: "my event"})
+	current.Commit()
+
+	results := dryRunSender.Flush()
+	require.Len(t, results.Series, 1)
+	assert.Equal(t, "my.gauge", results.Series[0].Name)
+	assert.Equal(t, "testhostname", results.Series[0].Host)
+	assert.Equal(t, []string{"custom:tag", "foo:bar"}, results.Series[0].Tags)
+	require.Len(t, results.ServiceChecks, 1)
+	assert.Equal(t, "my.service_check", results.ServiceChecks[0].CheckName)
+	require.Len(t, results.Events, 1)
+	assert.Equal(t, "my event", results.Events[0].Title)
+	assert.Equal(t, int64(1), current.GetMetricStats()["MetricSamples"])
+
+	// the flush empties the results
+	assert.Empty(t, dryRunSender.Flush().Series)
+
+	// the samples of the check in the aggregator are left untouched
+	assert.Len(t, aggregatorInstance.checkSamplers, 1)
+	assert.Empty(t, aggregatorInstance.checkSamplers[checkID1].series)
+
+	restore()
+	current, err = GetSender(checkID1)
+	require.NoError(t, err)
+	assert.Equal(t, sender, current)
+}
+
+func TestSwapSenderOfDestroyedSender(t *testing.T) {
+	resetAggregator()
+	InitAggregator(nil, "testhostname")
+
+	_, err := GetSender(checkID1)
+	require.NoError(t, err)
+
+	dryRunSender, err := NewDryRunSender(checkID1)
+	require.NoError(t, err)
+	defer dryRunSender.Close()
+
+	restore := SwapSender(dryRunSender, checkID1)
+	DestroySender(checkID1)
+	restore()
+
+	// the sender destroyed while it was replaced isn't restored
+	_, err = senderPool.getSender(checkID1)
+	assert.Error(t, err)
+}
@@ -429,6 +429,28 @@ func (sp *checkSenderPool) setSender(sender Sender, id check.ID) error {
 	return err
 }
 
+func (sp *checkSenderPool) swapSender(sender Sender, id check.ID) func() {
+	sp.m.Lock()
+	defer sp.m.Unlock()
+
+	previous, found := sp.senders[id]
+	sp.senders[id] = sender
+
+	return func() {
+		sp.m.Lock()
+		defer sp.m.Unlock()
+
+		if current, ok := sp.senders[id]; !ok || current != sender {
+			return
+		}
+		if found {
+			sp.senders[id] = previous
+		} else {
+			delete(sp.senders, id)
+		}
+	}
+}
+
 func (sp *checkSenderPool) removeSender(id check.ID) {
 	sp.m.Lock()
 	defer sp.m.Unlock()
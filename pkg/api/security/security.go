@@ -131,14 +131,13 @@ func fetchAuthToken(tokenCreationAllowed bool) (string, error) {
 
 	// Create a new token if it doesn't exist and if permitted by calling func
 	if _, e := os.Stat(authTokenFile); os.IsNotExist(e) && tokenCreationAllowed {
-		key := make([]byte, authTokenMinimalLen)
-		_, e = rand.Read(key)
+		token, e := generateAuthToken()
 		if e != nil {
-			return "", fmt.Errorf("can't create agent authentication token value: %s", e)
+			return "", e
 		}
 
 		// Write the auth token to the auth token file (platform-specific)
-		e = saveAuthToken(hex.EncodeToString(key), authTokenFile)
+		e = saveAuthToken(token, authTokenFile)
 		if e != nil {
 			return "", fmt.Errorf("error writing authentication token file on fs: %s", e)
 		}
@@ -159,6 +158,49 @@ func fetchAuthToken(tokenCreationAllowed bool) (string, error) {
 	return authToken, nil
 }
 
+// RotateAuthToken generates a new authentication token and replaces the auth token file with it. The token
+// is written to a temporary file of the same directory renamed over the auth token file, so that the clients
+// reading it never see a partially written token.
+// The callers must serialize the rotations, the last one renamed wins.
+// Requires that the config has been set up before calling
+func RotateAuthToken() (string, error) {
+	authTokenFile := GetAuthTokenFilepath()
+
+	token, err := generateAuthToken()
+	if err != nil {
+		return "", err
+	}
+
+	// the temporary file must be on the same filesystem for the rename to be atomic
+	tmp, err := ioutil.TempFile(filepath.Dir(authTokenFile), authTokenName+".tmp")
+	if err != nil {
+		return "", fmt.Errorf("error creating temporary authentication token file: %s", err)
+	}
+	tmpFile := tmp.Name()
+	tmp.Close() //nolint:errcheck
+
+	if err := saveAuthToken(token, tmpFile); err != nil {
+		os.Remove(tmpFile) //nolint:errcheck
+		return "", fmt.Errorf("error writing authentication token file on fs: %s", err)
+	}
+
+	if err := os.Rename(tmpFile, authTokenFile); err != nil {
+		os.Remove(tmpFile) //nolint:errcheck
+		return "", fmt.Errorf("error replacing authentication token file: %s", err)
+	}
+	log.Infof("Saved a new authentication token to %s", authTokenFile)
+
+	return token, nil
+}
+
+func generateAuthToken() (string, error) {
+	key := make([]byte, authTokenMinimalLen)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("can't create agent authentication token value: %s", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
 // DeleteAuthToken removes auth_token file (test clean up)
 func DeleteAuthToken() error {
 	authTokenFile := filepath.Join(filepath.Dir(config.Datadog.ConfigFileUsed()), authTokenName)
@@ -70,3 +70,24 @@ func TestFetchAuthToken(t *testing.T) {
 	require.Nil(t, err, fmt.Sprintf("%v", err))
 	require.Equal(t, newToken, token)
 }
+
+func TestRotateAuthToken(t *testing.T) {
+	expectTokenPath := initMockConf(t)
+	defer cleanMockConf(expectTokenPath)
+
+	token, err := CreateOrFetchToken()
+	require.Nil(t, err, fmt.Sprintf("%v", err))
+
+	newToken, err := RotateAuthToken()
+	require.Nil(t, err, fmt.Sprintf("%v", err))
+	assert.NotEqual(t, token, newToken)
+
+	fetchedToken, err := FetchAuthToken()
+	require.Nil(t, err, fmt.Sprintf("%v", err))
+	assert.Equal(t, newToken, fetchedToken)
+
+	// the temporary file is renamed over the auth token file
+	tmpFiles, err := filepath.Glob(filepath.Join(filepath.Dir(expectTokenPath), authTokenName+".tmp*"))
+	require.NoError(t, err)
+	assert.Empty(t, tmpFiles)
+}
@@ -6,8 +6,8 @@
 package util
 
 import (
+	"bytes"
 	"crypto/tls"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -28,49 +28,61 @@ func GetClient(verify bool) *http.Client {
 
 // DoGet is a wrapper around performing HTTP GET requests
 func DoGet(c *http.Client, url string) (body []byte, e error) {
-	req, e := http.NewRequest("GET", url, nil)
-	if e != nil {
-		return body, e
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+GetAuthToken())
-
-	r, e := c.Do(req)
-	if e != nil {
-		return body, e
-	}
-	body, e = ioutil.ReadAll(r.Body)
-	r.Body.Close()
-	if e != nil {
-		return body, e
-	}
-	if r.StatusCode >= 400 {
-		return body, fmt.Errorf("%s", body)
-	}
-	return body, nil
-
+	return doRequest(c, func() (*http.Request, error) {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		setRequestHeaders(req, "application/json")
+		return req, nil
+	})
 }
 
 // DoPost is a wrapper around performing HTTP POST requests
 func DoPost(c *http.Client, url string, contentType string, body io.Reader) (resp []byte, e error) {
-	req, e := http.NewRequest("POST", url, body)
-	if e != nil {
-		return resp, e
+	// the body is buffered as the request is sent again if the session token was rotated
+	var payload []byte
+	if body != nil {
+		if payload, e = ioutil.ReadAll(body); e != nil {
+			return resp, e
+		}
 	}
-	req.Header.Set("Content-Type", contentType)
-	req.Header.Set("Authorization", "Bearer "+GetAuthToken())
 
-	r, e := c.Do(req)
-	if e != nil {
-		return resp, e
-	}
-	resp, e = ioutil.ReadAll(r.Body)
-	r.Body.Close()
-	if e != nil {
-		return resp, e
-	}
-	if r.StatusCode >= 400 {
-		return resp, fmt.Errorf("%s", resp)
+	return doRequest(c, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		setRequestHeaders(req, contentType)
+		return req, nil
+	})
+}
+
+// doRequest performs the request returned by newRequest. When the session token is rejected, which
+// happens once the agent has rotated it, the token is read from the auth token file again and the
+// request is sent once more if it changed.
+func doRequest(c *http.Client, newRequest func() (*http.Request, error)) ([]byte, error) {
+	for retried := false; ; retried = true {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := c.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return body, err
+		}
+
+		if r.StatusCode == http.StatusForbidden && !retried {
+			if reloaded, reloadErr := ReloadAuthToken(); reloadErr == nil && reloaded {
+				continue
+			}
+		}
+		return body, checkResponse(r, body)
 	}
-	return resp, nil
 }
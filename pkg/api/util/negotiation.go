@@ -0,0 +1,167 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// APIVersion is the version of the IPC API implemented by this build. It is increased when endpoints
+	// are added or removed, or when the payload of an endpoint changes.
+	APIVersion = 1
+	// MinAPIVersion is the oldest version of the IPC API this build can still serve. Clients announcing
+	// an older version are refused with an explicit error.
+	MinAPIVersion = 1
+
+	// APIVersionHeader is the HTTP header holding the IPC API version of the client, in the requests,
+	// and of the server, in the responses
+	APIVersionHeader = "DD-Agent-API-Version"
+	// APIVersionMetadataKey is the gRPC metadata key holding the IPC API version of the client, in the
+	// requests, and of the server, in the response headers
+	APIVersionMetadataKey = "dd-agent-api-version"
+	// DeprecationHeader is the HTTP header set on the responses of the deprecated endpoints. It holds
+	// the endpoint replacing the deprecated one, if any, or "true".
+	DeprecationHeader = "Deprecation"
+
+	// VersionNegotiationPath is the path of the endpoint describing the IPC API served by an agent
+	VersionNegotiationPath = "/agent/version-negotiation"
+)
+
+// EndpointInfo describes an endpoint of the IPC API
+type EndpointInfo struct {
+	// Path is the path of a REST endpoint, which can contain `{variable}` segments, or
+	// the full name of a gRPC method
+	Path    string   `json:"path"`
+	Methods []string `json:"methods,omitempty"`
+	// Deprecated endpoints are still served, but will be removed in a later API version
+	Deprecated bool `json:"deprecated,omitempty"`
+	// Replacement is the endpoint to use instead of a deprecated one, if any
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// VersionNegotiation describes the IPC API served by an agent
+type VersionNegotiation struct {
+	AgentVersion  string         `json:"agent_version"`
+	APIVersion    int            `json:"api_version"`
+	MinAPIVersion int            `json:"min_api_version"`
+	Endpoints     []EndpointInfo `json:"endpoints"`
+}
+
+// GetEndpoint returns the endpoint serving a REST path with the given method, or a gRPC method
+// if method is empty, or nil if the agent doesn't serve it
+func (v *VersionNegotiation) GetEndpoint(method, path string) *EndpointInfo {
+	for i, endpoint := range v.Endpoints {
+		if !matchEndpointPath(endpoint.Path, path) {
+			continue
+		}
+		if method == "" || len(endpoint.Methods) == 0 {
+			return &v.Endpoints[i]
+		}
+		for _, m := range endpoint.Methods {
+			if strings.EqualFold(m, method) {
+				return &v.Endpoints[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Supports returns whether the agent serves a REST path with the given method, or a gRPC method
+// if method is empty
+func (v *VersionNegotiation) Supports(method, path string) bool {
+	return v.GetEndpoint(method, path) != nil
+}
+
+// matchEndpointPath returns whether a path matches an endpoint path, whose `{variable}` segments
+// match any segment
+func matchEndpointPath(template, path string) bool {
+	templateSegments := strings.Split(strings.Trim(template, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(templateSegments) != len(pathSegments) {
+		return false
+	}
+
+	for i, segment := range templateSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseAPIVersion parses an IPC API version announced by a client or a server. An empty
+// version is reported as 0, the version of the agents predating the version negotiation.
+func ParseAPIVersion(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid API version %q", value)
+	}
+	return version, nil
+}
+
+// NegotiateAPIVersion queries the IPC API served by the agent listening on baseURL. The agents predating
+// the version negotiation are reported with an API version of 0 and no endpoints.
+func NegotiateAPIVersion(c *http.Client, baseURL string) (*VersionNegotiation, error) {
+	req, err := http.NewRequest("GET", strings.TrimSuffix(baseURL, "/")+VersionNegotiationPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	setRequestHeaders(req, "application/json")
+
+	r, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode == http.StatusNotFound && r.Header.Get(APIVersionHeader) == "" {
+		return &VersionNegotiation{}, nil
+	}
+	if r.StatusCode >= 400 {
+		return nil, fmt.Errorf("version negotiation failed with status code %d", r.StatusCode)
+	}
+
+	var negotiation VersionNegotiation
+	if err := json.NewDecoder(r.Body).Decode(&negotiation); err != nil {
+		return nil, fmt.Errorf("invalid version negotiation response: %v", err)
+	}
+	return &negotiation, nil
+}
+
+func setRequestHeaders(req *http.Request, contentType string) {
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+GetAuthToken())
+	req.Header.Set(APIVersionHeader, strconv.Itoa(APIVersion))
+}
+
+// checkResponse returns an error describing a failed request, explaining the 404 returned by
+// agents with a different API version
+func checkResponse(r *http.Response, body []byte) error {
+	if r.StatusCode < 400 {
+		return nil
+	}
+
+	if r.StatusCode == http.StatusNotFound {
+		serverVersion, err := ParseAPIVersion(r.Header.Get(APIVersionHeader))
+		if err == nil && serverVersion != APIVersion {
+			return fmt.Errorf("%s %s is not supported by the agent, which serves API version %d while this client uses API version %d: %s",
+				r.Request.Method, r.Request.URL.Path, serverVersion, APIVersion, strings.TrimSpace(string(body)))
+		}
+	}
+	return fmt.Errorf("%s", body)
+}
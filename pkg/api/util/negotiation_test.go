@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionNegotiationSupports(t *testing.T) {
+	negotiation := &VersionNegotiation{
+		APIVersion: APIVersion,
+		Endpoints: []EndpointInfo{
+			{Path: "/agent/status", Methods: []string{"GET"}},
+			{Path: "/agent/{component}/status", Methods: []string{"GET", "POST"}},
+			{Path: "/datadog.api.v1.Agent/GetHostname"},
+		},
+	}
+
+	assert.True(t, negotiation.Supports("GET", "/agent/status"))
+	assert.False(t, negotiation.Supports("POST", "/agent/status"))
+	assert.True(t, negotiation.Supports("post", "/agent/jmx/status"))
+	assert.False(t, negotiation.Supports("GET", "/agent/jmx/status/details"))
+	assert.False(t, negotiation.Supports("GET", "/agent/workload-list"))
+	assert.True(t, negotiation.Supports("", "/datadog.api.v1.Agent/GetHostname"))
+}
+
+func TestParseAPIVersion(t *testing.T) {
+	version, err := ParseAPIVersion("")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	version, err = ParseAPIVersion("3")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, version)
+
+	_, err = ParseAPIVersion("v3")
+	assert.Error(t, err)
+
+	_, err = ParseAPIVersion("-1")
+	assert.Error(t, err)
+}
+
+func TestNegotiateAPIVersion(t *testing.T) {
+	t.Run("legacy agent", func(t *testing.T) {
+		ts := httptest.NewServer(http.NotFoundHandler())
+		defer ts.Close()
+
+		negotiation, err := NegotiateAPIVersion(ts.Client(), ts.URL)
+		require.NoError(t, err)
+		assert.Equal(t, 0, negotiation.APIVersion)
+	})
+
+	t.Run("negotiating agent", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, strconv.Itoa(APIVersion), r.Header.Get(APIVersionHeader))
+			w.Header().Set(APIVersionHeader, "7")
+			if r.URL.Path != VersionNegotiationPath {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(VersionNegotiation{
+				AgentVersion: "7.99.0",
+				APIVersion:   7,
+				Endpoints:    []EndpointInfo{{Path: "/agent/status", Methods: []string{"GET"}}},
+			})
+		}))
+		defer ts.Close()
+
+		negotiation, err := NegotiateAPIVersion(ts.Client(), ts.URL)
+		require.NoError(t, err)
+		assert.Equal(t, 7, negotiation.APIVersion)
+		assert.True(t, negotiation.Supports("GET", "/agent/status"))
+
+		// the 404 of the agents serving another API version are explained
+		_, err = DoGet(ts.Client(), ts.URL+"/agent/unknown")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "GET /agent/unknown is not supported by the agent, which serves API version 7")
+	})
+}
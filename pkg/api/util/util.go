@@ -9,24 +9,37 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/api/security"
 )
 
 var (
-	token    string
-	dcaToken string
+	tokenLock sync.RWMutex
+	token     string
+	dcaToken  string
+
+	// previousToken stays valid until previousTokenExpiry after a rotation, the clients
+	// still using it are given time to read the new token from the auth token file
+	previousToken       string
+	previousTokenExpiry time.Time
+
+	// rotationHandlers are called after each rotation, for the clients that were handed the token when they started
+	rotationHandlers []func()
 )
 
 // SetAuthToken sets the session token
 // Requires that the config has been set up before calling
 func SetAuthToken() error {
+	tokenLock.Lock()
+	defer tokenLock.Unlock()
+
 	// Noop if token is already set
 	if token != "" {
 		return nil
 	}
 
-	// token is only set once, no need to mutex protect
 	var err error
 	token, err = security.FetchAuthToken()
 	return err
@@ -35,12 +48,14 @@ func SetAuthToken() error {
 // CreateAndSetAuthToken creates and sets the authorization token
 // Requires that the config has been set up before calling
 func CreateAndSetAuthToken() error {
+	tokenLock.Lock()
+	defer tokenLock.Unlock()
+
 	// Noop if token is already set
 	if token != "" {
 		return nil
 	}
 
-	// token is only set once, no need to mutex protect
 	var err error
 	token, err = security.CreateOrFetchToken()
 	return err
@@ -48,9 +63,77 @@ func CreateAndSetAuthToken() error {
 
 // GetAuthToken gets the session token
 func GetAuthToken() string {
+	tokenLock.RLock()
+	defer tokenLock.RUnlock()
 	return token
 }
 
+// RotateAuthToken replaces the session token with a new one, saved to the auth token file. The
+// previous token stays valid for the given grace period.
+// The file is replaced under the token lock, so that concurrent rotations can't leave the session
+// token different from the one in the auth token file.
+// Requires that the config has been set up before calling
+func RotateAuthToken(gracePeriod time.Duration) error {
+	tokenLock.Lock()
+	newToken, err := security.RotateAuthToken()
+	if err != nil {
+		tokenLock.Unlock()
+		return err
+	}
+
+	previousToken = token
+	previousTokenExpiry = time.Now().Add(gracePeriod)
+	token = newToken
+	handlers := rotationHandlers
+	tokenLock.Unlock()
+
+	for _, handler := range handlers {
+		handler()
+	}
+	return nil
+}
+
+// OnAuthTokenRotation registers a handler called after each rotation of the session token. The clients given the
+// token when they started, like the subprocesses, must pick up the new one before the end of the grace period.
+func OnAuthTokenRotation(handler func()) {
+	tokenLock.Lock()
+	defer tokenLock.Unlock()
+	rotationHandlers = append(rotationHandlers, handler)
+}
+
+// ReloadAuthToken reads the session token from the auth token file again, to pick up a token
+// rotated by the agent. It returns true if the token changed.
+func ReloadAuthToken() (bool, error) {
+	newToken, err := security.FetchAuthToken()
+	if err != nil {
+		return false, err
+	}
+
+	tokenLock.Lock()
+	defer tokenLock.Unlock()
+
+	if newToken == token {
+		return false, nil
+	}
+	token = newToken
+	return true, nil
+}
+
+// IsValidAuthToken returns true if the given token is the session token, or the previous one
+// within the grace period following a rotation
+func IsValidAuthToken(t string) bool {
+	tokenLock.RLock()
+	defer tokenLock.RUnlock()
+
+	if t == "" {
+		return false
+	}
+	if t == token {
+		return true
+	}
+	return t == previousToken && time.Now().Before(previousTokenExpiry)
+}
+
 // InitDCAAuthToken initialize the session token for the Cluster Agent based on config options
 // Requires that the config has been set up before calling
 func InitDCAAuthToken() error {
@@ -89,7 +172,7 @@ func Validate(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	if len(tok) < 2 || tok[1] != GetAuthToken() {
+	if len(tok) < 2 || !IsValidAuthToken(tok[1]) {
 		err = fmt.Errorf("invalid session token")
 		http.Error(w, err.Error(), 403)
 	}
@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestIsValidAuthToken(t *testing.T) {
+	defer func() {
+		token, previousToken, previousTokenExpiry = "", "", time.Time{}
+	}()
+
+	token = "current"
+	assert.True(t, IsValidAuthToken("current"))
+	assert.False(t, IsValidAuthToken("other"))
+	assert.False(t, IsValidAuthToken(""))
+
+	// the previous token is only accepted during the grace period
+	previousToken = "previous"
+	previousTokenExpiry = time.Now().Add(time.Minute)
+	assert.True(t, IsValidAuthToken("previous"))
+
+	previousTokenExpiry = time.Now().Add(-time.Second)
+	assert.False(t, IsValidAuthToken("previous"))
+	assert.True(t, IsValidAuthToken("current"))
+}
+
+func TestRotateAuthTokenHandlers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auth-token")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	config.Datadog.Set("auth_token_file_path", filepath.Join(dir, "auth_token"))
+	defer config.Datadog.Set("auth_token_file_path", "")
+	defer func() {
+		token, previousToken, previousTokenExpiry, rotationHandlers = "", "", time.Time{}, nil
+	}()
+
+	token = "current"
+	var rotatedTo string
+	OnAuthTokenRotation(func() { rotatedTo = GetAuthToken() })
+
+	require.NoError(t, RotateAuthToken(time.Minute))
+	assert.NotEqual(t, "current", rotatedTo)
+	assert.Equal(t, GetAuthToken(), rotatedTo)
+	assert.True(t, IsValidAuthToken("current"))
+}
+
+func TestRotateAuthTokenConcurrently(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auth-token")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tokenPath := filepath.Join(dir, "auth_token")
+	config.Datadog.Set("auth_token_file_path", tokenPath)
+	defer config.Datadog.Set("auth_token_file_path", "")
+	defer func() {
+		token, previousToken, previousTokenExpiry, rotationHandlers = "", "", time.Time{}, nil
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, RotateAuthToken(time.Minute))
+		}()
+	}
+	wg.Wait()
+
+	// the session token is the one the last rotation left in the auth token file
+	onDisk, err := ioutil.ReadFile(tokenPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(onDisk), GetAuthToken())
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
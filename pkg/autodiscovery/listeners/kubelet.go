@@ -90,7 +90,7 @@ func NewKubeletListener() (ServiceListener, error) {
 		watcher:  watcher,
 		filters:  filters,
 		services: make(map[string]Service),
-		ticker:   time.NewTicker(config.Datadog.GetDuration("kubelet_listener_polling_interval") * time.Second),
+		ticker:   time.NewTicker(config.Datadog.GetDurationWithUnit("kubelet_listener_polling_interval", time.Second)),
 		stop:     make(chan bool),
 		health:   health.RegisterLiveness("ad-kubeletlistener"),
 	}, nil
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package providers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// configWatchRetryInterval is the interval between two attempts to open a configurations stream
+const configWatchRetryInterval = 30 * time.Second
+
+// watchConfigsFunc opens a configurations stream on the cluster agent
+type watchConfigsFunc func(ctx context.Context) (<-chan types.ConfigResponse, error)
+
+// configWatcher keeps the last configurations streamed by the cluster agent, so that
+// the providers don't have to poll it while the stream is open
+type configWatcher struct {
+	name          string
+	watch         watchConfigsFunc
+	retryInterval time.Duration
+
+	m         sync.Mutex
+	streaming bool
+	changed   bool
+	response  types.ConfigResponse
+}
+
+func newConfigWatcher(name string, watch watchConfigsFunc) *configWatcher {
+	return &configWatcher{
+		name:          name,
+		watch:         watch,
+		retryInterval: configWatchRetryInterval,
+	}
+}
+
+// run opens the configurations stream and opens it again when it ends, until the context is cancelled
+func (w *configWatcher) run(ctx context.Context) {
+	for {
+		responses, err := w.watch(ctx)
+		if err != nil {
+			log.Debugf("Cannot watch the %s configurations, polling the cluster agent: %v", w.name, err)
+		} else {
+			for response := range responses {
+				w.m.Lock()
+				w.streaming = true
+				w.changed = true
+				w.response = response
+				w.m.Unlock()
+			}
+
+			w.m.Lock()
+			w.streaming = false
+			w.m.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.retryInterval):
+		}
+	}
+}
+
+// get returns the last configurations received, and false if the stream
+// is not open, in which case the cluster agent has to be polled
+func (w *configWatcher) get() (types.ConfigResponse, bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if !w.streaming {
+		return types.ConfigResponse{}, false
+	}
+	w.changed = false
+	return w.response, true
+}
+
+// isUpToDate returns whether the stream is open and no configurations were
+// received since the last call to get
+func (w *configWatcher) isUpToDate() bool {
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.streaming && !w.changed
+}
@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+)
+
+func TestConfigWatcher(t *testing.T) {
+	streams := make(chan chan types.ConfigResponse, 1)
+	watcher := newConfigWatcher("test", func(ctx context.Context) (<-chan types.ConfigResponse, error) {
+		select {
+		case stream := <-streams:
+			return stream, nil
+		default:
+			return nil, errors.New("not available")
+		}
+	})
+	watcher.retryInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.run(ctx)
+
+	// Not streaming, the cluster agent has to be polled
+	_, streaming := watcher.get()
+	assert.False(t, streaming)
+	assert.False(t, watcher.isUpToDate())
+
+	stream := make(chan types.ConfigResponse)
+	streams <- stream
+	stream <- types.ConfigResponse{LastChange: 1, Configs: []integration.Config{{Name: "check"}}}
+
+	assert.Eventually(t, func() bool { return !watcher.isUpToDate() }, time.Second, 5*time.Millisecond)
+	response, streaming := watcher.get()
+	assert.True(t, streaming)
+	assert.Equal(t, int64(1), response.LastChange)
+	assert.Len(t, response.Configs, 1)
+	assert.True(t, watcher.isUpToDate())
+
+	stream <- types.ConfigResponse{LastChange: 2}
+	assert.Eventually(t, func() bool { return !watcher.isUpToDate() }, time.Second, 5*time.Millisecond)
+	response, _ = watcher.get()
+	assert.Equal(t, int64(2), response.LastChange)
+
+	// The stream ends, fall back to polling
+	close(stream)
+	assert.Eventually(t, func() bool {
+		_, streaming := watcher.get()
+		return !streaming
+	}, time.Second, 5*time.Millisecond)
+	assert.False(t, watcher.isUpToDate())
+}
@@ -6,6 +6,7 @@
 package providers
 
 import (
+	"context"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
@@ -28,6 +29,7 @@ type ClusterChecksConfigProvider struct {
 	lastChange     int64
 	nodeName       string
 	flushedConfigs bool
+	watcher        *configWatcher
 }
 
 // NewClusterChecksConfigProvider returns a new ConfigProvider collecting
@@ -62,6 +64,12 @@ func (c *ClusterChecksConfigProvider) initClient() error {
 	dcaClient, err := clusteragent.GetClusterAgentClient()
 	if err == nil {
 		c.dcaClient = dcaClient
+		if config.Datadog.GetBool("cluster_agent.watch_configs") {
+			c.watcher = newConfigWatcher(names.ClusterChecks, func(ctx context.Context) (<-chan types.ConfigResponse, error) {
+				return dcaClient.WatchClusterCheckConfigs(ctx, c.nodeName)
+			})
+			go c.watcher.run(context.Background())
+		}
 	}
 	return err
 }
@@ -76,7 +84,9 @@ func (c *ClusterChecksConfigProvider) withinGracePeriod() bool {
 }
 
 // IsUpToDate queries the cluster-agent to update its status and
-// query if new configurations are available
+// query if new configurations are available. The status is reported
+// even when the configurations are streamed, as it's the heartbeat
+// of the node agent.
 func (c *ClusterChecksConfigProvider) IsUpToDate() (bool, error) {
 	if c.dcaClient == nil {
 		err := c.initClient()
@@ -118,7 +128,15 @@ func (c *ClusterChecksConfigProvider) Collect() ([]integration.Config, error) {
 		}
 	}
 
-	reply, err := c.dcaClient.GetClusterCheckConfigs(c.nodeName)
+	reply, streaming := types.ConfigResponse{}, false
+	if c.watcher != nil {
+		reply, streaming = c.watcher.get()
+	}
+
+	var err error
+	if !streaming {
+		reply, err = c.dcaClient.GetClusterCheckConfigs(c.nodeName)
+	}
 	if err != nil {
 		if !c.flushedConfigs {
 			// On first error after grace period, mask the error once
@@ -8,10 +8,12 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
@@ -24,6 +26,7 @@ type EndpointsChecksConfigProvider struct {
 	dcaClient      clusteragent.DCAClientInterface
 	nodeName       string
 	flushedConfigs bool
+	watcher        *configWatcher
 }
 
 // NewEndpointsChecksConfigProvider returns a new ConfigProvider collecting
@@ -49,9 +52,10 @@ func (c *EndpointsChecksConfigProvider) String() string {
 	return names.EndpointsChecks
 }
 
-// IsUpToDate updates the list of AD templates versions in the Agent's cache and checks the list is up to date compared to Kubernetes's data.
+// IsUpToDate returns true when the configurations are streamed by the cluster-agent
+// and didn't change since the last Collect. They're polled at every call otherwise.
 func (c *EndpointsChecksConfigProvider) IsUpToDate() (bool, error) {
-	return false, nil
+	return c.watcher != nil && c.watcher.isUpToDate(), nil
 }
 
 // Collect retrieves endpoints checks configurations the cluster-agent dispatched to this agent
@@ -62,7 +66,16 @@ func (c *EndpointsChecksConfigProvider) Collect() ([]integration.Config, error)
 			return nil, err
 		}
 	}
-	reply, err := c.dcaClient.GetEndpointsCheckConfigs(c.nodeName)
+
+	reply, streaming := types.ConfigResponse{}, false
+	if c.watcher != nil {
+		reply, streaming = c.watcher.get()
+	}
+
+	var err error
+	if !streaming {
+		reply, err = c.dcaClient.GetEndpointsCheckConfigs(c.nodeName)
+	}
 	if err != nil {
 		if !c.flushedConfigs {
 			// On first error after grace period, mask the error once
@@ -99,6 +112,12 @@ func (c *EndpointsChecksConfigProvider) initClient() error {
 	dcaClient, err := clusteragent.GetClusterAgentClient()
 	if err == nil {
 		c.dcaClient = dcaClient
+		if config.Datadog.GetBool("cluster_agent.watch_configs") {
+			c.watcher = newConfigWatcher(names.EndpointsChecks, func(ctx context.Context) (<-chan types.ConfigResponse, error) {
+				return dcaClient.WatchEndpointsCheckConfigs(ctx, c.nodeName)
+			})
+			go c.watcher.run(context.Background())
+		}
 	}
 	return err
 }
@@ -34,6 +34,26 @@ type configFormat struct {
 	IgnoreAutodiscoveryTags bool     `yaml:"ignore_autodiscovery_tags"` // Use to ignore tags coming from autodiscovery
 }
 
+// defaultConfigFeatures lists the integrations whose default config files are only loaded when one of
+// the features of the environment is present
+var defaultConfigFeatures = map[string][]config.Feature{
+	"gpu": {config.NvidiaGPU, config.AMDGPU},
+}
+
+// defaultConfigFeaturesPresent returns whether the default config file of an integration should be loaded
+func defaultConfigFeaturesPresent(integrationName string) bool {
+	features, found := defaultConfigFeatures[integrationName]
+	if !found {
+		return true
+	}
+	for _, feature := range features {
+		if config.IsFeaturePresent(feature) {
+			return true
+		}
+	}
+	return false
+}
+
 type configPkg struct {
 	confs    []integration.Config
 	defaults []integration.Config
@@ -181,6 +201,13 @@ func (c *FileConfigProvider) collectEntry(file os.FileInfo, path string, integra
 	}
 	entry.name = integrationName
 
+	// skip the default config files of the integrations whose features aren't present
+	if entry.isDefault && !defaultConfigFeaturesPresent(integrationName) {
+		log.Debugf("Skipping the default config file '%s', the environment doesn't have the features of the integration", absPath)
+		entry.err = fmt.Errorf("default config file for integration '%s' is skipped", integrationName)
+		return entry
+	}
+
 	if ext != ".yaml" && ext != ".yml" {
 		log.Tracef("Skipping file: %s", absPath)
 		entry.err = errors.New("Invalid config file extension")
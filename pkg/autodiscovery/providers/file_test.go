@@ -162,3 +162,37 @@ func TestEnvVarReplacement(t *testing.T) {
 	assert.Len(t, rc[0].Instances, 2)
 	assert.Contains(t, string(rc[0].Instances[1]), "test_envvar_not_set")
 }
+
+func TestDefaultConfigFeatures(t *testing.T) {
+	defer func(features map[string][]config.Feature) { defaultConfigFeatures = features }(defaultConfigFeatures)
+	defer config.SetDetectedFeatures(config.GetDetectedFeatures())
+
+	defaultConfigFeatures = map[string][]config.Feature{
+		"nested_default": {config.NvidiaGPU, config.AMDGPU},
+		// regular configs aren't affected
+		"testcheck": {config.NvidiaGPU},
+	}
+
+	count := func(name string) int {
+		provider := NewFileConfigProvider([]string{"tests"})
+		configs, err := provider.Collect()
+		require.NoError(t, err)
+
+		n := 0
+		for _, c := range configs {
+			if c.Name == name {
+				n++
+			}
+		}
+		return n
+	}
+
+	config.SetDetectedFeatures(config.FeatureMap{})
+	assert.Equal(t, 0, count("nested_default"))
+	assert.Equal(t, 3, count("testcheck"))
+	// the default configs of the other integrations are loaded
+	assert.Equal(t, 1, count("bar"))
+
+	config.SetDetectedFeatures(config.FeatureMap{config.AMDGPU: struct{}{}})
+	assert.Equal(t, 1, count("nested_default"))
+}
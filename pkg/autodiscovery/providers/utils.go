@@ -211,5 +211,5 @@ func GetPollInterval(cp config.ConfigurationProviders) time.Duration {
 			return customInterval
 		}
 	}
-	return config.Datadog.GetDuration("ad_config_poll_interval") * time.Second
+	return config.Datadog.GetDurationWithUnit("ad_config_poll_interval", time.Second)
 }
@@ -29,7 +29,7 @@ import (
 	"k8s.io/client-go/dynamic"
 )
 
-var ownerCacheTTL = config.Datadog.GetDuration("admission_controller.pod_owners_cache_validity") * time.Minute
+var ownerCacheTTL = config.Datadog.GetDurationWithUnit("admission_controller.pod_owners_cache_validity", time.Minute)
 
 var labelsToEnv = map[string]string{
 	kubernetes.EnvTagLabelKey:     kubernetes.EnvTagEnvVar,
@@ -39,8 +39,8 @@ func StartControllers(ctx ControllerContext) error {
 	}
 
 	certConfig := secret.NewCertConfig(
-		config.Datadog.GetDuration("admission_controller.certificate.expiration_threshold")*time.Hour,
-		config.Datadog.GetDuration("admission_controller.certificate.validity_bound")*time.Hour)
+		config.Datadog.GetDurationWithUnit("admission_controller.certificate.expiration_threshold", time.Hour),
+		config.Datadog.GetDurationWithUnit("admission_controller.certificate.validity_bound", time.Hour))
 	secretConfig := secret.NewConfig(
 		common.GetResourcesNamespace(),
 		config.Datadog.GetString("admission_controller.certificate.secret_name"),
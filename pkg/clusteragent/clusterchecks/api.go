@@ -60,6 +60,12 @@ func (h *Handler) GetConfigs(nodeName string) (types.ConfigResponse, error) {
 	return response, err
 }
 
+// ConfigChanges returns a channel closed on the next change of the cluster and endpoints
+// configurations, so that their watchers can send them again
+func (h *Handler) ConfigChanges() <-chan struct{} {
+	return h.dispatcher.configChanges()
+}
+
 // PostStatus handles status reports from the node agents
 func (h *Handler) PostStatus(nodeName, clientIP string, status types.NodeStatus) (types.StatusResponse, error) {
 	upToDate, err := h.dispatcher.processNodeStatus(nodeName, clientIP, status)
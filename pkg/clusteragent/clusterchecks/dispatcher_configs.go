@@ -45,6 +45,8 @@ func (d *dispatcher) addConfig(config integration.Config, targetNodeName string)
 	d.store.Lock()
 	defer d.store.Unlock()
 
+	defer d.store.notifyChange()
+
 	// Register config
 	digest := config.Digest()
 	d.store.digestToConfig[digest] = config
@@ -82,6 +84,7 @@ func (d *dispatcher) addConfig(config integration.Config, targetNodeName string)
 func (d *dispatcher) removeConfig(digest string) {
 	d.store.Lock()
 	defer d.store.Unlock()
+	defer d.store.notifyChange()
 
 	node, found := d.store.getNodeStore(d.store.digestToNode[digest])
 	delete(d.store.digestToNode, digest)
@@ -161,6 +164,13 @@ func (d *dispatcher) patchConfiguration(in integration.Config) (integration.Conf
 	return out, nil
 }
 
+// configChanges returns a channel closed on the next change of the dispatched configurations
+func (d *dispatcher) configChanges() <-chan struct{} {
+	d.store.RLock()
+	defer d.store.RUnlock()
+	return d.store.changed
+}
+
 // getConfigAndDigest returns config and digest of a check by checkID
 func (d *dispatcher) getConfigAndDigest(checkID string) (integration.Config, string) {
 	d.store.RLock()
@@ -45,6 +45,7 @@ func (d *dispatcher) addEndpointConfig(config integration.Config, nodename strin
 		d.store.endpointsConfigs[nodename] = map[string]integration.Config{}
 	}
 	d.store.endpointsConfigs[nodename][config.Digest()] = config
+	d.store.notifyChange()
 }
 
 // removeEndpointConfig deletes a given endpoint configuration
@@ -52,6 +53,7 @@ func (d *dispatcher) removeEndpointConfig(config integration.Config, nodename st
 	d.store.Lock()
 	defer d.store.Unlock()
 	delete(d.store.endpointsConfigs[nodename], config.Digest())
+	d.store.notifyChange()
 }
 
 // patchEndpointsConfiguration transforms the endpoint configuration from AD into a config
@@ -112,6 +112,7 @@ func (d *dispatcher) expireNodes() {
 	defer d.store.Unlock()
 
 	initialNodeCount := len(d.store.nodes)
+	expired := false
 
 	for name, node := range d.store.nodes {
 		node.RLock()
@@ -127,6 +128,7 @@ func (d *dispatcher) expireNodes() {
 				danglingConfigs.Inc(le.JoinLeaderValue)
 			}
 			delete(d.store.nodes, name)
+			expired = true
 
 			// Remove metrics linked to this node
 			nodeAgents.Dec(le.JoinLeaderValue)
@@ -137,6 +139,10 @@ func (d *dispatcher) expireNodes() {
 		node.RUnlock()
 	}
 
+	if expired {
+		d.store.notifyChange()
+	}
+
 	if initialNodeCount != 0 && len(d.store.nodes) == 0 {
 		log.Warn("No nodes reporting, cluster checks will not run")
 	}
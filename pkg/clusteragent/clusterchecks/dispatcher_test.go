@@ -428,6 +428,46 @@ func TestReset(t *testing.T) {
 	requireNotLocked(t, dispatcher.store)
 }
 
+func TestConfigChanges(t *testing.T) {
+	dispatcher := newDispatcher()
+	config := generateIntegration("cluster-check")
+	endpointsConfig := generateEndpointsIntegration("endpoints-check", "node1")
+
+	isClosed := func(c <-chan struct{}) bool {
+		select {
+		case <-c:
+			return true
+		default:
+			return false
+		}
+	}
+
+	changes := dispatcher.configChanges()
+	assert.False(t, isClosed(changes))
+
+	dispatcher.addConfig(config, "node1")
+	assert.True(t, isClosed(changes))
+
+	changes = dispatcher.configChanges()
+	assert.False(t, isClosed(changes))
+	dispatcher.addEndpointConfig(endpointsConfig, "node1")
+	assert.True(t, isClosed(changes))
+
+	changes = dispatcher.configChanges()
+	dispatcher.removeEndpointConfig(endpointsConfig, "node1")
+	assert.True(t, isClosed(changes))
+
+	changes = dispatcher.configChanges()
+	dispatcher.removeConfig(config.Digest())
+	assert.True(t, isClosed(changes))
+
+	changes = dispatcher.configChanges()
+	dispatcher.reset()
+	assert.True(t, isClosed(changes))
+
+	requireNotLocked(t, dispatcher.store)
+}
+
 func TestPatchConfiguration(t *testing.T) {
 	checkConfig := integration.Config{
 		Name:          "test",
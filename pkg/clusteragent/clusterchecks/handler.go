@@ -63,7 +63,7 @@ func NewHandler(ac pluggableAutoConfig) (*Handler, error) {
 	h := &Handler{
 		autoconfig:       ac,
 		leaderStatusFreq: 5 * time.Second,
-		warmupDuration:   config.Datadog.GetDuration("cluster_checks.warmup_duration") * time.Second,
+		warmupDuration:   config.Datadog.GetDurationWithUnit("cluster_checks.warmup_duration", time.Second),
 		leadershipChan:   make(chan state, 1),
 		dispatcher:       newDispatcher(),
 		port:             config.Datadog.GetInt("cluster_agent.cmd_port"),
@@ -30,6 +30,7 @@ type clusterStore struct {
 	danglingConfigs  map[string]integration.Config            // Configs we could not dispatch to any node
 	endpointsConfigs map[string]map[string]integration.Config // Endpoints configs to be consumed by node agents
 	idToDigest       map[check.ID]string                      // link check IDs to check configs
+	changed          chan struct{}                            // closed on the next configuration change
 }
 
 func newClusterStore() *clusterStore {
@@ -47,6 +48,16 @@ func (s *clusterStore) reset() {
 	s.danglingConfigs = make(map[string]integration.Config)
 	s.endpointsConfigs = make(map[string]map[string]integration.Config)
 	s.idToDigest = make(map[check.ID]string)
+	s.notifyChange()
+}
+
+// notifyChange wakes up the watchers of the dispatched configurations.
+// The store lock is to be held by the caller.
+func (s *clusterStore) notifyChange() {
+	if s.changed != nil {
+		close(s.changed)
+	}
+	s.changed = make(chan struct{})
 }
 
 // getNodeStore retrieves the store struct for a given node name, if it exists
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package types
+
+import (
+	"github.com/DataDog/datadog-agent/cmd/cluster-agent/api/pb"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+)
+
+// ToProto converts a ConfigResponse to its protobuf representation,
+// streamed by the cluster agent to the node agents
+func (r ConfigResponse) ToProto() *pb.ConfigsResponse {
+	response := &pb.ConfigsResponse{
+		LastChange: r.LastChange,
+		Configs:    make([]*pb.Config, 0, len(r.Configs)),
+	}
+
+	for _, config := range r.Configs {
+		instances := make([][]byte, 0, len(config.Instances))
+		for _, instance := range config.Instances {
+			instances = append(instances, instance)
+		}
+
+		response.Configs = append(response.Configs, &pb.Config{
+			Name:                    config.Name,
+			Instances:               instances,
+			InitConfig:              config.InitConfig,
+			MetricConfig:            config.MetricConfig,
+			LogsConfig:              config.LogsConfig,
+			AdIdentifiers:           config.ADIdentifiers,
+			Provider:                config.Provider,
+			ClusterCheck:            config.ClusterCheck,
+			NodeName:                config.NodeName,
+			Source:                  config.Source,
+			IgnoreAutodiscoveryTags: config.IgnoreAutodiscoveryTags,
+		})
+	}
+
+	return response
+}
+
+// ConfigResponseFromProto converts the protobuf representation of a ConfigResponse back
+func ConfigResponseFromProto(in *pb.ConfigsResponse) ConfigResponse {
+	response := ConfigResponse{
+		LastChange: in.GetLastChange(),
+		Configs:    make([]integration.Config, 0, len(in.GetConfigs())),
+	}
+
+	for _, config := range in.GetConfigs() {
+		instances := make([]integration.Data, 0, len(config.GetInstances()))
+		for _, instance := range config.GetInstances() {
+			instances = append(instances, instance)
+		}
+
+		response.Configs = append(response.Configs, integration.Config{
+			Name:                    config.GetName(),
+			Instances:               instances,
+			InitConfig:              config.GetInitConfig(),
+			MetricConfig:            config.GetMetricConfig(),
+			LogsConfig:              config.GetLogsConfig(),
+			ADIdentifiers:           config.GetAdIdentifiers(),
+			Provider:                config.GetProvider(),
+			ClusterCheck:            config.GetClusterCheck(),
+			NodeName:                config.GetNodeName(),
+			Source:                  config.GetSource(),
+			IgnoreAutodiscoveryTags: config.GetIgnoreAutodiscoveryTags(),
+		})
+	}
+
+	return response
+}
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+// Package securitypolicies watches the runtime security policies declared with the
+// DatadogSecurityPolicy custom resources, to distribute them to the node agents.
+package securitypolicies
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// SecurityPolicyGVR identifies the DatadogSecurityPolicy custom resources
+var SecurityPolicyGVR = schema.GroupVersionResource{
+	Group:    "datadoghq.com",
+	Version:  "v1alpha1",
+	Resource: "datadogsecuritypolicies",
+}
+
+// Store lists the runtime security policies declared with the DatadogSecurityPolicy custom
+// resources. It can be used by any cluster agent, the leader or a follower.
+type Store struct {
+	lister cache.GenericLister
+	synced cache.InformerSynced
+}
+
+// NewStore starts watching the DatadogSecurityPolicy custom resources until stopCh is closed
+func NewStore(client dynamic.Interface, stopCh <-chan struct{}) *Store {
+	resyncPeriod := time.Duration(config.Datadog.GetInt64("kubernetes_informers_resync_period")) * time.Second
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(client, resyncPeriod)
+	informer := factory.ForResource(SecurityPolicyGVR)
+
+	s := &Store{
+		lister: informer.Lister(),
+		synced: informer.Informer().HasSynced,
+	}
+	factory.Start(stopCh)
+
+	return s
+}
+
+// GetPolicies returns the policies, by name, in the policy file format. The custom resources
+// whose spec can't be converted are skipped.
+func (s *Store) GetPolicies() (map[string][]byte, error) {
+	if !s.synced() {
+		return nil, errors.New("the security policies are not synced yet")
+	}
+
+	objects, err := s.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make(map[string][]byte, len(objects))
+	for _, object := range objects {
+		resource, ok := object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+
+		content, err := policyFromUnstructured(resource)
+		if err != nil {
+			log.Warnf("Ignoring the security policy %s: %v", resource.GetName(), err)
+			continue
+		}
+		policies[resource.GetName()] = content
+	}
+
+	return policies, nil
+}
+
+// policyFromUnstructured converts the spec of a DatadogSecurityPolicy, which holds the version, the
+// rules and the macros of the policy, to the YAML document of a policy file
+func policyFromUnstructured(resource *unstructured.Unstructured) ([]byte, error) {
+	spec, found, err := unstructured.NestedMap(resource.Object, "spec")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("the custom resource has no spec")
+	}
+
+	content, err := yaml.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal the spec: %v", err)
+	}
+	return content, nil
+}
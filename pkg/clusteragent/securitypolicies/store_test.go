@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build kubeapiserver
+
+package securitypolicies
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPolicyFromUnstructured(t *testing.T) {
+	resource := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "datadoghq.com/v1alpha1",
+			"kind":       "DatadogSecurityPolicy",
+			"metadata": map[string]interface{}{
+				"name": "shells",
+			},
+			"spec": map[string]interface{}{
+				"version": "1.0.0",
+				"macros": []interface{}{
+					map[string]interface{}{
+						"id":         "shells",
+						"expression": `["sh", "bash"]`,
+					},
+				},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"id":         "shell_exec",
+						"expression": `exec.name in shells`,
+					},
+				},
+			},
+		},
+	}
+
+	content, err := policyFromUnstructured(resource)
+	require.NoError(t, err)
+
+	var policy struct {
+		Version string `yaml:"version"`
+		Rules   []struct {
+			ID         string `yaml:"id"`
+			Expression string `yaml:"expression"`
+		} `yaml:"rules"`
+		Macros []struct {
+			ID         string `yaml:"id"`
+			Expression string `yaml:"expression"`
+		} `yaml:"macros"`
+	}
+	require.NoError(t, yaml.Unmarshal(content, &policy))
+
+	assert.Equal(t, "1.0.0", policy.Version)
+	require.Len(t, policy.Rules, 1)
+	assert.Equal(t, "shell_exec", policy.Rules[0].ID)
+	assert.Equal(t, "exec.name in shells", policy.Rules[0].Expression)
+	require.Len(t, policy.Macros, 1)
+	assert.Equal(t, "shells", policy.Macros[0].ID)
+}
+
+func TestPolicyFromUnstructuredWithoutSpec(t *testing.T) {
+	resource := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": "empty",
+			},
+		},
+	}
+
+	_, err := policyFromUnstructured(resource)
+	assert.Error(t, err)
+}
@@ -7,7 +7,14 @@ package clusteragent
 
 import "github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks"
 
+// SecurityPoliciesGetter returns the runtime security policies declared in the cluster,
+// by name, in the policy file format
+type SecurityPoliciesGetter interface {
+	GetPolicies() (map[string][]byte, error)
+}
+
 // ServerContext holds business logic classes required to setup API endpoints
 type ServerContext struct {
 	ClusterCheckHandler *clusterchecks.Handler
+	SecurityPolicies    SecurityPoliciesGetter
 }
@@ -7,6 +7,7 @@ package collector
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -88,6 +89,27 @@ func (c *Collector) Stop() {
 	c.state = stopped
 }
 
+// Shed reduces the frequency of the scheduled checks, it is called by the
+// watchdog when the agent goes over its resource budgets
+func (c *Collector) Shed() {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	if c.scheduler != nil {
+		c.scheduler.Shed()
+	}
+}
+
+// Restore restores the frequency of the scheduled checks
+func (c *Collector) Restore() {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	if c.scheduler != nil {
+		c.scheduler.Restore()
+	}
+}
+
 // RunCheck sends a Check in the execution queue
 func (c *Collector) RunCheck(ch check.Check) (check.ID, error) {
 	c.m.Lock()
@@ -215,6 +237,28 @@ func (c *Collector) GetAllInstanceIDs(checkName string) []check.ID {
 	return instances
 }
 
+// GetChecksByName returns the scheduled instances of a check, sorted by ID
+func (c *Collector) GetChecksByName(checkName string) []check.Check {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	checks := []check.Check{}
+	for _, ch := range c.checks {
+		if ch.String() == checkName {
+			checks = append(checks, ch)
+		}
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].ID() < checks[j].ID() })
+
+	return checks
+}
+
+// RunExclusively calls fn while the runner skips the scheduled runs of the check, so that fn can run the check
+// itself. It returns false, without calling fn, when the check is running.
+func (c *Collector) RunExclusively(ch check.Check, fn func()) bool {
+	return c.runner.RunExclusively(ch, fn)
+}
+
 // ReloadAllCheckInstances completely restarts a check with a new configuration
 func (c *Collector) ReloadAllCheckInstances(name string, newInstances []check.Check) ([]check.ID, error) {
 	if !c.started() {
@@ -196,6 +196,18 @@ func (suite *CollectorTestSuite) TestGetAllInstanceIDs() {
 	}
 }
 
+func (suite *CollectorTestSuite) TestGetChecksByName() {
+	ch1 := NewCheckUnique("foo", "TestCheck1")
+	ch2 := NewCheckUnique("bar", "TestCheck1")
+	ch3 := NewCheckUnique("baz", "TestCheck2")
+	suite.c.checks[ch1.ID()] = ch1
+	suite.c.checks[ch2.ID()] = ch2
+	suite.c.checks[ch3.ID()] = ch3
+
+	assert.Equal(suite.T(), []check.Check{ch2, ch1}, suite.c.GetChecksByName("TestCheck1"))
+	assert.Empty(suite.T(), suite.c.GetChecksByName("TestCheck3"))
+}
+
 func (suite *CollectorTestSuite) TestReloadAllCheckInstances() {
 	// Schedule 2 check instances
 	ch1 := NewCheckUnique("foo", "TestCheck")
@@ -16,6 +16,8 @@ import (
 	cache "github.com/patrickmn/go-cache"
 	"gopkg.in/yaml.v2"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
@@ -45,13 +47,17 @@ const (
 
 // KubeASConfig is the config of the API server.
 type KubeASConfig struct {
-	CollectEvent             bool     `yaml:"collect_events"`
-	CollectOShiftQuotas      bool     `yaml:"collect_openshift_clusterquotas"`
-	FilteredEventTypes       []string `yaml:"filtered_event_types"`
-	EventCollectionTimeoutMs int      `yaml:"kubernetes_event_read_timeout_ms"`
-	MaxEventCollection       int      `yaml:"max_events_per_run"`
-	LeaderSkip               bool     `yaml:"skip_leader_election"`
-	ResyncPeriodEvents       int      `yaml:"kubernetes_event_resync_period_s"`
+	CollectEvent             bool              `yaml:"collect_events"`
+	CollectOShiftQuotas      bool              `yaml:"collect_openshift_clusterquotas"`
+	FilteredEventTypes       []string          `yaml:"filtered_event_types"`
+	EventCollectionTimeoutMs int               `yaml:"kubernetes_event_read_timeout_ms"`
+	MaxEventCollection       int               `yaml:"max_events_per_run"`
+	LeaderSkip               bool              `yaml:"skip_leader_election"`
+	ResyncPeriodEvents       int               `yaml:"kubernetes_event_resync_period_s"`
+	FieldSelector            string            `yaml:"field_selector"`
+	LabelSelector            string            `yaml:"label_selector"`
+	SeverityMapping          map[string]string `yaml:"severity_mapping"`
+	DedupWindow              int               `yaml:"dedup_window_s"`
 }
 
 // EventC holds the information pertaining to which event we collected last and when we last re-synced.
@@ -63,12 +69,16 @@ type EventC struct {
 // KubeASCheck grabs metrics and events from the API server.
 type KubeASCheck struct {
 	core.CheckBase
-	instance        *KubeASConfig
-	eventCollection EventC
-	ignoredEvents   string
-	ac              *apiserver.APIClient
-	oshiftAPILevel  apiserver.OpenShiftAPILevel
-	providerIDCache *cache.Cache
+	instance          *KubeASConfig
+	eventCollection   EventC
+	fieldSelector     string
+	labelSelector     labels.Selector
+	ac                *apiserver.APIClient
+	oshiftAPILevel    apiserver.OpenShiftAPILevel
+	providerIDCache   *cache.Cache
+	objectLabelsCache *cache.Cache
+	alertTypes        map[string]metrics.EventAlertType
+	dedupCache        *cache.Cache
 }
 
 func (c *KubeASConfig) parse(data []byte) error {
@@ -82,9 +92,10 @@ func (c *KubeASConfig) parse(data []byte) error {
 
 func NewKubeASCheck(base core.CheckBase, instance *KubeASConfig) *KubeASCheck {
 	return &KubeASCheck{
-		CheckBase:       base,
-		instance:        instance,
-		providerIDCache: cache.New(defaultCacheExpire, defaultCachePurge),
+		CheckBase:         base,
+		instance:          instance,
+		providerIDCache:   cache.New(defaultCacheExpire, defaultCachePurge),
+		objectLabelsCache: cache.New(defaultCacheExpire, defaultCachePurge),
 	}
 }
 
@@ -113,7 +124,27 @@ func (k *KubeASCheck) Configure(config, initConfig integration.Data, source stri
 	if k.instance.MaxEventCollection == 0 {
 		k.instance.MaxEventCollection = maxEventCardinality
 	}
-	k.ignoredEvents = convertFilter(k.instance.FilteredEventTypes)
+	k.fieldSelector, err = convertFieldSelector(k.instance.FilteredEventTypes, k.instance.FieldSelector)
+	if err != nil {
+		return err
+	}
+
+	if k.instance.LabelSelector != "" {
+		k.labelSelector, err = labels.Parse(k.instance.LabelSelector)
+		if err != nil {
+			return fmt.Errorf("invalid label_selector %q: %s", k.instance.LabelSelector, err)
+		}
+	}
+
+	k.alertTypes, err = convertSeverityMapping(k.instance.SeverityMapping)
+	if err != nil {
+		return err
+	}
+
+	if k.instance.DedupWindow > 0 {
+		window := time.Duration(k.instance.DedupWindow) * time.Second
+		k.dedupCache = cache.New(window, 2*window)
+	}
 
 	return nil
 }
@@ -131,6 +162,35 @@ func convertFilter(conf []string) string {
 	return strings.Join(formatedFilters, ",")
 }
 
+// convertFieldSelector combines the filtered event types and the field selector
+// into the field selector of the list and watch requests
+func convertFieldSelector(filteredEventTypes []string, fieldSelector string) (string, error) {
+	filter := convertFilter(filteredEventTypes)
+	if fieldSelector == "" {
+		return filter, nil
+	}
+	if _, err := fields.ParseSelector(fieldSelector); err != nil {
+		return "", fmt.Errorf("invalid field_selector %q: %s", fieldSelector, err)
+	}
+	if filter == "" {
+		return fieldSelector, nil
+	}
+	return filter + "," + fieldSelector, nil
+}
+
+// convertSeverityMapping validates the Datadog alert types the event reasons are mapped to
+func convertSeverityMapping(conf map[string]string) (map[string]metrics.EventAlertType, error) {
+	alertTypes := make(map[string]metrics.EventAlertType, len(conf))
+	for reason, severity := range conf {
+		alertType, err := metrics.GetAlertTypeFromString(severity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid severity for the event reason %q: %s", reason, err)
+		}
+		alertTypes[reason] = alertType
+	}
+	return alertTypes, nil
+}
+
 // Run executes the check.
 func (k *KubeASCheck) Run() error {
 	sender, err := aggregator.GetSender(k.ID())
@@ -205,6 +265,7 @@ func (k *KubeASCheck) Run() error {
 	if err != nil {
 		return err
 	}
+	events = k.filterEventsByLabels(events, k.ac.GetInvolvedObjectLabels)
 
 	// Process the events to have a Datadog format.
 	err = k.processEvents(sender, events)
@@ -251,7 +312,7 @@ func (k *KubeASCheck) eventCollectionCheck() (newEvents []*v1.Event, err error)
 	timeout := int64(k.instance.EventCollectionTimeoutMs / 1000)
 	limit := int64(k.instance.MaxEventCollection)
 	resync := int64(k.instance.ResyncPeriodEvents)
-	newEvents, k.eventCollection.LastResVer, k.eventCollection.LastTime, err = k.ac.RunEventCollection(resVer, lastTime, timeout, limit, resync, k.ignoredEvents)
+	newEvents, k.eventCollection.LastResVer, k.eventCollection.LastTime, err = k.ac.RunEventCollection(resVer, lastTime, timeout, limit, resync, k.fieldSelector)
 
 	if err != nil {
 		k.Warnf("Could not collect events from the api server: %s", err.Error()) //nolint:errcheck
@@ -300,18 +361,56 @@ func (k *KubeASCheck) parseComponentStatus(sender aggregator.Sender, componentsS
 	return nil
 }
 
+// filterEventsByLabels only keeps the events whose involved object matches the label selector
+func (k *KubeASCheck) filterEventsByLabels(events []*v1.Event, getLabels func(v1.ObjectReference) (map[string]string, error)) []*v1.Event {
+	if k.labelSelector == nil {
+		return events
+	}
+
+	var filtered []*v1.Event
+	for _, event := range events {
+		objLabels, err := k.getInvolvedObjectLabels(event.InvolvedObject, getLabels)
+		if err != nil {
+			log.Debugf("Skipping the event %s/%s, could not get the labels of the %s %s/%s: %s", event.Namespace, event.Name, event.InvolvedObject.Kind, event.InvolvedObject.Namespace, event.InvolvedObject.Name, err)
+			continue
+		}
+		if k.labelSelector.Matches(labels.Set(objLabels)) {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// getInvolvedObjectLabels returns the labels of an involved object, cached by UID
+func (k *KubeASCheck) getInvolvedObjectLabels(obj v1.ObjectReference, getLabels func(v1.ObjectReference) (map[string]string, error)) (map[string]string, error) {
+	if cached, found := k.objectLabelsCache.Get(string(obj.UID)); found {
+		return cached.(map[string]string), nil
+	}
+	objLabels, err := getLabels(obj)
+	if err != nil {
+		return nil, err
+	}
+	k.objectLabelsCache.Set(string(obj.UID), objLabels, cache.DefaultExpiration)
+	return objLabels, nil
+}
+
 // processEvents:
 // - iterates over the Kubernetes Events
+// - skips the events already submitted within the deduplication window
 // - extracts some attributes and builds a structure ready to be submitted as a Datadog event (bundle)
 // - formats the bundle and submit the Datadog event
 func (k *KubeASCheck) processEvents(sender aggregator.Sender, events []*v1.Event) error {
 	eventsByObject := make(map[string]*kubernetesEventBundle)
 
 	for _, event := range events {
-		id := bundleID(event)
+		if k.isDuplicate(event) {
+			continue
+		}
+		alertType := k.getAlertType(event)
+		id := bundleID(event, alertType)
 		bundle, found := eventsByObject[id]
 		if found == false {
-			bundle = newKubernetesEventBundler(event)
+			bundle = newKubernetesEventBundler(event, alertType)
 			eventsByObject[id] = bundle
 		}
 		err := bundle.addEvent(event)
@@ -332,10 +431,33 @@ func (k *KubeASCheck) processEvents(sender aggregator.Sender, events []*v1.Event
 	return nil
 }
 
+// getAlertType returns the alert type the reason of the event is mapped to,
+// or the one matching the event type
+func (k *KubeASCheck) getAlertType(e *v1.Event) metrics.EventAlertType {
+	if alertType, found := k.alertTypes[e.Reason]; found {
+		return alertType
+	}
+	return getDDAlertType(e.Type)
+}
+
+// isDuplicate returns whether the same event was already seen for the object
+// within the deduplication window
+func (k *KubeASCheck) isDuplicate(e *v1.Event) bool {
+	if k.dedupCache == nil {
+		return false
+	}
+	key := fmt.Sprintf("%s/%s/%s", e.InvolvedObject.UID, e.Reason, e.Message)
+	if err := k.dedupCache.Add(key, struct{}{}, cache.DefaultExpiration); err != nil {
+		log.Tracef("Skipping the event %s/%s: already submitted within the last %ds", e.Namespace, e.Name, k.instance.DedupWindow)
+		return true
+	}
+	return false
+}
+
 // bundleID generates a unique ID to separate k8s events
-// based on their InvolvedObject UIDs and event Types
-func bundleID(e *v1.Event) string {
-	return fmt.Sprintf("%s/%s", e.InvolvedObject.UID, e.Type)
+// based on their InvolvedObject UIDs and Datadog alert types
+func bundleID(e *v1.Event, alertType metrics.EventAlertType) string {
+	return fmt.Sprintf("%s/%s", e.InvolvedObject.UID, alertType)
 }
 
 func init() {
@@ -333,3 +333,118 @@ func TestProcessEventsType(t *testing.T) {
 	mocked.AssertNumberOfCalls(t, "Event", 2)
 	mocked.AssertExpectations(t)
 }
+
+func TestConvertSeverityMapping(t *testing.T) {
+	alertTypes, err := convertSeverityMapping(map[string]string{"BackOff": "error", "Scheduled": "success"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]metrics.EventAlertType{"BackOff": metrics.EventAlertTypeError, "Scheduled": metrics.EventAlertTypeSuccess}, alertTypes)
+
+	_, err = convertSeverityMapping(map[string]string{"BackOff": "critical"})
+	assert.Error(t, err)
+}
+
+func TestProcessEventsSeverityMapping(t *testing.T) {
+	ev1 := createEvent(2, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "default-scheduler", "machine-blue", "Scheduled", "Successfully assigned dca-789976f5d7-2ljx6 to ip-10-0-0-54", "Normal", 709662600)
+	ev2 := createEvent(4, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "BackOff", "Back-off restarting failed container", "Normal", 709662600)
+
+	kubeASCheck := NewKubeASCheck(core.NewCheckBase(kubernetesAPIServerCheckName), &KubeASConfig{})
+	kubeASCheck.alertTypes = map[string]metrics.EventAlertType{"BackOff": metrics.EventAlertTypeError}
+
+	mocked := mocksender.NewMockSender(kubeASCheck.ID())
+	mocked.On("Event", mock.AnythingOfType("metrics.Event"))
+
+	kubeASCheck.processEvents(mocked, []*v1.Event{ev1, ev2})
+
+	// The BackOff event is mapped to an error, it isn't bundled with the Scheduled one
+	alertTypes := make(map[metrics.EventAlertType]string)
+	for _, call := range mocked.Calls {
+		event := call.Arguments.Get(0).(metrics.Event)
+		alertTypes[event.AlertType] = event.Text
+	}
+	assert.Contains(t, alertTypes[metrics.EventAlertTypeInfo], "2 **Scheduled**")
+	assert.Contains(t, alertTypes[metrics.EventAlertTypeError], "4 **BackOff**")
+	mocked.AssertNumberOfCalls(t, "Event", 2)
+}
+
+func TestProcessEventsDedup(t *testing.T) {
+	ev1 := createEvent(4, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "BackOff", "Back-off restarting failed container", "Warning", 709662600)
+	ev2 := createEvent(5, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "BackOff", "Back-off restarting failed container", "Warning", 709662660)
+	ev3 := createEvent(1, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "Killing", "Stopping container", "Warning", 709662660)
+
+	kubeASCheck := NewKubeASCheck(core.NewCheckBase(kubernetesAPIServerCheckName), &KubeASConfig{})
+	err := kubeASCheck.Configure([]byte("dedup_window_s: 60"), nil, "test")
+	assert.NoError(t, err)
+	assert.NotNil(t, kubeASCheck.dedupCache)
+
+	mocked := mocksender.NewMockSender(kubeASCheck.ID())
+	mocked.On("Event", mock.AnythingOfType("metrics.Event"))
+	kubeASCheck.processEvents(mocked, []*v1.Event{ev1, ev2})
+
+	res := (mocked.Calls[0].Arguments.Get(0)).(metrics.Event).Text
+	assert.Contains(t, res, "4 **BackOff**")
+	mocked.AssertNumberOfCalls(t, "Event", 1)
+
+	// Only the new event is submitted by the next run
+	mocked = mocksender.NewMockSender(kubeASCheck.ID())
+	mocked.On("Event", mock.AnythingOfType("metrics.Event"))
+	kubeASCheck.processEvents(mocked, []*v1.Event{ev2, ev3})
+
+	res = (mocked.Calls[0].Arguments.Get(0)).(metrics.Event).Text
+	assert.Contains(t, res, "1 **Killing**")
+	assert.NotContains(t, res, "BackOff")
+	mocked.AssertNumberOfCalls(t, "Event", 1)
+}
+
+func TestConvertFieldSelector(t *testing.T) {
+	selector, err := convertFieldSelector([]string{"FailedGetScale"}, "involvedObject.kind=Pod")
+	assert.NoError(t, err)
+	assert.Equal(t, "reason!=FailedGetScale,involvedObject.kind=Pod", selector)
+
+	selector, err = convertFieldSelector(nil, "involvedObject.kind=Pod")
+	assert.NoError(t, err)
+	assert.Equal(t, "involvedObject.kind=Pod", selector)
+
+	selector, err = convertFieldSelector([]string{"FailedGetScale"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "reason!=FailedGetScale", selector)
+
+	_, err = convertFieldSelector(nil, "involvedObject.kind")
+	assert.Error(t, err)
+}
+
+func TestFilterEventsByLabels(t *testing.T) {
+	ev1 := createEvent(2, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "default-scheduler", "machine-blue", "Scheduled", "Successfully assigned dca-789976f5d7-2ljx6 to ip-10-0-0-54", "Normal", 709662600)
+	ev2 := createEvent(3, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "Started", "Started container", "Normal", 709662600)
+	ev3 := createEvent(1, "default", "localhost", "Node", "e63e74fa-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "MissingClusterDNS", "MountVolume.SetUp succeeded", "Normal", 709662600)
+	ev4 := createEvent(1, "default", "deleted-pod", "Pod", "f7528b8a-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "Killing", "Stopping container", "Normal", 709662600)
+
+	objectLabels := map[types.UID]map[string]string{
+		"e6417a7f-f566-11e7-9749-0e4863e1cbf4": {"app": "dca"},
+		"e63e74fa-f566-11e7-9749-0e4863e1cbf4": {"kubernetes.io/os": "linux"},
+	}
+	calls := 0
+	getLabels := func(obj v1.ObjectReference) (map[string]string, error) {
+		calls++
+		objLabels, found := objectLabels[obj.UID]
+		if !found {
+			return nil, fmt.Errorf("%s not found", obj.Name)
+		}
+		return objLabels, nil
+	}
+
+	kubeASCheck := NewKubeASCheck(core.NewCheckBase(kubernetesAPIServerCheckName), &KubeASConfig{})
+	events := []*v1.Event{ev1, ev2, ev3, ev4}
+	assert.Equal(t, events, kubeASCheck.filterEventsByLabels(events, getLabels))
+	assert.Equal(t, 0, calls)
+
+	err := kubeASCheck.Configure([]byte("label_selector: app=dca"), nil, "test")
+	assert.NoError(t, err)
+
+	// The labels of the involved objects are matched, and cached by UID
+	assert.Equal(t, []*v1.Event{ev1, ev2}, kubeASCheck.filterEventsByLabels(events, getLabels))
+	assert.Equal(t, 3, calls)
+
+	kubeASCheck = NewKubeASCheck(core.NewCheckBase(kubernetesAPIServerCheckName), &KubeASConfig{})
+	err = kubeASCheck.Configure([]byte("label_selector: app in (dca"), nil, "test")
+	assert.Error(t, err)
+}
@@ -38,12 +38,12 @@ type kubernetesEventBundle struct {
 	alertType     metrics.EventAlertType // The Datadog event type
 }
 
-func newKubernetesEventBundler(event *v1.Event) *kubernetesEventBundle {
+func newKubernetesEventBundler(event *v1.Event, alertType metrics.EventAlertType) *kubernetesEventBundle {
 	return &kubernetesEventBundle{
 		objUID:        event.InvolvedObject.UID,
 		component:     event.Source.Component,
 		countByAction: make(map[string]int),
-		alertType:     getDDAlertType(event.Type),
+		alertType:     alertType,
 	}
 }
 
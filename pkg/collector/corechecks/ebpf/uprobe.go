@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// FIXME: we require the `cgo` build tag because of this dep relationship:
+// github.com/DataDog/datadog-agent/pkg/process/net depends on `github.com/DataDog/agent-payload/process`,
+// which has a hard dependency on `github.com/DataDog/zstd`, which requires CGO.
+// Should be removed once `github.com/DataDog/agent-payload/process` can be imported with CGO disabled.
+// +build cgo,linux
+
+package ebpf
+
+import (
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	dd_config "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/uprobe"
+	process_net "github.com/DataDog/datadog-agent/pkg/process/net"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	uprobeCheckName = "uprobe"
+)
+
+// UprobeConfig is the config of the uprobe check
+type UprobeConfig struct {
+	CollectUprobeStats bool `yaml:"collect_uprobe_stats"`
+}
+
+// UprobeCheck grabs the call count and latency metrics of the functions instrumented by system-probe
+type UprobeCheck struct {
+	core.CheckBase
+	instance *UprobeConfig
+}
+
+// UprobeFactory is exported for integration testing
+func UprobeFactory() check.Check {
+	return &UprobeCheck{
+		CheckBase: core.NewCheckBase(uprobeCheckName),
+		instance:  &UprobeConfig{},
+	}
+}
+
+func init() {
+	core.RegisterCheck(uprobeCheckName, UprobeFactory)
+}
+
+// Parse parses the check configuration
+func (c *UprobeConfig) Parse(data []byte) error {
+	// default values
+	c.CollectUprobeStats = true
+
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Configure parses the check configuration and init the check
+func (u *UprobeCheck) Configure(config, initConfig integration.Data, source string) error {
+	// TODO: Remove that hard-code and put it somewhere else
+	process_net.SetSystemProbePath(dd_config.Datadog.GetString("system_probe_config.sysprobe_socket"))
+
+	err := u.CommonConfigure(config, source)
+	if err != nil {
+		return err
+	}
+
+	return u.instance.Parse(config)
+}
+
+// Run executes the check
+func (u *UprobeCheck) Run() error {
+	if !u.instance.CollectUprobeStats {
+		return nil
+	}
+
+	sysProbeUtil, err := process_net.GetRemoteSystemProbeUtil()
+	if err != nil {
+		return err
+	}
+
+	data, err := sysProbeUtil.GetCheck("uprobe")
+	if err != nil {
+		return err
+	}
+
+	sender, err := aggregator.GetSender(u.ID())
+	if err != nil {
+		return err
+	}
+
+	stats, ok := data.([]uprobe.Stats)
+	if !ok {
+		return log.Errorf("Raw data has incorrect type")
+	}
+
+	// the stats are flushed by system-probe on each run, so they hold the calls since the last run
+	for _, s := range stats {
+		entityID := containers.BuildTaggerEntityName(s.ContainerID)
+		var tags []string
+		if entityID != "" {
+			tags, err = tagger.Tag(entityID, tagger.ChecksCardinality)
+			if err != nil {
+				log.Errorf("Error collecting tags for container %s: %s", s.ContainerID, err)
+			}
+		}
+		tags = append(tags, "binary:"+s.Path, "function:"+s.Function)
+
+		sender.Count("uprobe.calls", float64(s.Calls), "", tags)
+		sender.Count("uprobe.latency.total", float64(s.LatencyNs), "", tags)
+		if s.Calls > 0 {
+			sender.Gauge("uprobe.latency.avg", float64(s.LatencyNs)/float64(s.Calls), "", tags)
+		}
+	}
+
+	sender.Commit()
+	return nil
+}
@@ -10,10 +10,12 @@ package jmx
 import (
 	"time"
 
+	api "github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/jmxfetch"
 	"github.com/DataDog/datadog-agent/pkg/status"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 type runner struct {
@@ -36,6 +38,13 @@ func (r *runner) startRunner() error {
 		return err
 	}
 	r.started = true
+
+	// JMXFetch authenticates to the agent with the token it was launched with
+	api.OnAuthTokenRotation(func() {
+		if err := r.jmxfetch.Restart(); err != nil {
+			log.Errorf("Unable to restart JMXFetch after the rotation of the auth token: %s", err)
+		}
+	})
 	return nil
 }
 
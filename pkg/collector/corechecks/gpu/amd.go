@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package gpu
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// amdVendorID is the PCI vendor ID of AMD
+const amdVendorID = "0x1002"
+
+// amdCollector collects the usage of the AMD GPUs from the sysfs attributes of the amdgpu driver,
+// and the usage of the processes from the fdinfo of their DRM file descriptors
+type amdCollector struct {
+	drmDir  string
+	procDir string
+}
+
+func newAMDCollector(drmDir, procDir string) *amdCollector {
+	return &amdCollector{drmDir: drmDir, procDir: procDir}
+}
+
+// cards returns the sysfs device directories of the AMD GPUs, by card index
+func (a *amdCollector) cards() map[int]string {
+	matches, _ := filepath.Glob(filepath.Join(a.drmDir, "card*"))
+
+	cards := make(map[int]string)
+	for _, match := range matches {
+		// skip the connectors, like card0-DP-1
+		index, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(match), "card"))
+		if err != nil {
+			continue
+		}
+		deviceDir := filepath.Join(match, "device")
+		if readSysfsString(filepath.Join(deviceDir, "vendor")) == amdVendorID {
+			cards[index] = deviceDir
+		}
+	}
+	return cards
+}
+
+func (a *amdCollector) available() bool {
+	return len(a.cards()) > 0
+}
+
+func (a *amdCollector) collect(collectProcesses bool) ([]*device, []process, error) {
+	cards := a.cards()
+	indexes := make([]int, 0, len(cards))
+	for index := range cards {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	devices := make([]*device, 0, len(cards))
+	// the devices by PCI address, to attribute the usage of the processes
+	devicesByAddress := make(map[string]*device, len(cards))
+	for _, index := range indexes {
+		deviceDir := cards[index]
+		d, err := readAMDDevice(index, deviceDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		devices = append(devices, d)
+
+		if address, err := filepath.EvalSymlinks(deviceDir); err == nil {
+			devicesByAddress[filepath.Base(address)] = d
+		}
+	}
+	if !collectProcesses {
+		return devices, nil, nil
+	}
+	return devices, a.collectProcesses(devicesByAddress), nil
+}
+
+func readAMDDevice(index int, deviceDir string) (*device, error) {
+	d := &device{
+		vendor:      vendorAMD,
+		index:       index,
+		uuid:        readSysfsString(filepath.Join(deviceDir, "unique_id")),
+		model:       readSysfsString(filepath.Join(deviceDir, "product_name")),
+		utilization: -1,
+	}
+
+	var err error
+	if d.memoryUsed, err = readSysfsValue(filepath.Join(deviceDir, "mem_info_vram_used")); err != nil {
+		return nil, err
+	}
+	if d.memoryTotal, err = readSysfsValue(filepath.Join(deviceDir, "mem_info_vram_total")); err != nil {
+		return nil, err
+	}
+	// gpu_busy_percent is only exposed by the recent GPUs
+	if busy, err := readSysfsValue(filepath.Join(deviceDir, "gpu_busy_percent")); err == nil {
+		d.utilization = busy
+	}
+	return d, nil
+}
+
+// collectProcesses returns the VRAM used by the processes, summed over their DRM clients
+func (a *amdCollector) collectProcesses(devicesByAddress map[string]*device) []process {
+	fdinfos, err := filepath.Glob(filepath.Join(a.procDir, "[0-9]*", "fdinfo", "*"))
+	if err != nil {
+		log.Debugf("Could not list the file descriptors of the processes: %s", err)
+		return nil
+	}
+
+	type usageKey struct {
+		pid     int
+		address string
+	}
+	usage := make(map[usageKey]float64)
+	// the file descriptors of a process can share the same DRM client
+	seenClients := make(map[string]struct{})
+	var keys []usageKey
+	for _, fdinfo := range fdinfos {
+		pid, err := strconv.Atoi(filepath.Base(filepath.Dir(filepath.Dir(fdinfo))))
+		if err != nil {
+			continue
+		}
+		client, ok := readAMDClient(fdinfo)
+		if !ok {
+			continue
+		}
+		if _, found := devicesByAddress[client.address]; !found {
+			continue
+		}
+
+		clientKey := fmt.Sprintf("%d/%s/%s", pid, client.address, client.id)
+		if _, seen := seenClients[clientKey]; seen {
+			continue
+		}
+		seenClients[clientKey] = struct{}{}
+
+		key := usageKey{pid: pid, address: client.address}
+		if _, found := usage[key]; !found {
+			keys = append(keys, key)
+		}
+		usage[key] += client.vram
+	}
+
+	processes := make([]process, 0, len(keys))
+	for _, key := range keys {
+		processes = append(processes, process{pid: key.pid, device: devicesByAddress[key.address], memoryUsed: usage[key]})
+	}
+	return processes
+}
+
+type amdClient struct {
+	id      string
+	address string
+	// vram is the VRAM used by the client, in bytes
+	vram float64
+}
+
+// readAMDClient parses the DRM fdinfo of a file descriptor, which looks like
+//
+//	drm-driver:     amdgpu
+//	drm-pdev:       0000:03:00.0
+//	drm-client-id:  12
+//	drm-memory-vram:        1024 KiB
+//
+// ok is false when the file descriptor isn't an amdgpu client
+func readAMDClient(path string) (client amdClient, ok bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return client, false
+	}
+	defer f.Close()
+
+	var driver string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(fields[1])
+		switch fields[0] {
+		case "drm-driver":
+			driver = value
+		case "drm-pdev":
+			client.address = value
+		case "drm-client-id":
+			client.id = value
+		case "drm-memory-vram":
+			client.vram = parseKiB(value)
+		}
+	}
+	return client, driver == "amdgpu" && client.address != ""
+}
+
+// parseKiB parses the memory sizes of the fdinfo, like "1024 KiB", and returns them in bytes
+func parseKiB(value string) float64 {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	if len(fields) > 1 && fields[1] == "KiB" {
+		v *= 1024
+	}
+	return v
+}
+
+func readSysfsString(path string) string {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func readSysfsValue(path string) (float64, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(content)), 64)
+}
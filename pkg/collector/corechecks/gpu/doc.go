@@ -0,0 +1,10 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+/*
+Package gpu provides a core check collecting the usage of the NVIDIA and AMD GPUs
+of the host, and of the processes and containers using them
+*/
+package gpu
@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package gpu
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	checkName = "gpu"
+
+	vendorNVIDIA = "nvidia"
+	vendorAMD    = "amd"
+
+	mib = 1024 * 1024
+)
+
+// device is a GPU of the host
+type device struct {
+	vendor string
+	index  int
+	uuid   string
+	model  string
+	// utilization is the percentage of time the GPU was busy, -1 when unknown
+	utilization float64
+	// memoryUsed and memoryTotal are the used and total memory of the GPU, in bytes
+	memoryUsed  float64
+	memoryTotal float64
+}
+
+func (d *device) tags() []string {
+	tags := []string{
+		"gpu_vendor:" + d.vendor,
+		fmt.Sprintf("gpu_index:%d", d.index),
+	}
+	if d.uuid != "" {
+		tags = append(tags, "gpu_uuid:"+d.uuid)
+	}
+	if d.model != "" {
+		tags = append(tags, "gpu_model:"+d.model)
+	}
+	return tags
+}
+
+// process is a process using a GPU
+type process struct {
+	pid    int
+	device *device
+	// memoryUsed is the GPU memory used by the process, in bytes
+	memoryUsed float64
+}
+
+// collector collects the usage of the GPUs of a vendor
+type collector interface {
+	// available returns whether the GPUs of the vendor and their tools are present on the host
+	available() bool
+	// collect returns the GPUs of the vendor, and the processes using them when collectProcesses is set
+	collect(collectProcesses bool) ([]*device, []process, error)
+}
+
+type checkCfg struct {
+	NvidiaSmiPath    string   `yaml:"nvidia_smi_path"`
+	Vendors          []string `yaml:"vendors"`
+	CollectProcesses *bool    `yaml:"collect_processes"`
+}
+
+// Check collects the utilization and memory usage of the GPUs, and the GPU memory used
+// by the processes, attributed to their container
+type Check struct {
+	core.CheckBase
+	collectors       map[string]collector
+	collectProcesses bool
+}
+
+// Configure parses the check configuration and finds the GPUs of the host
+func (c *Check) Configure(data integration.Data, initConfig integration.Data, source string) error {
+	if err := c.CommonConfigure(data, source); err != nil {
+		return err
+	}
+
+	conf := checkCfg{
+		NvidiaSmiPath: "nvidia-smi",
+		Vendors:       []string{vendorNVIDIA, vendorAMD},
+	}
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+	c.collectProcesses = conf.CollectProcesses == nil || *conf.CollectProcesses
+
+	c.collectors = make(map[string]collector)
+	for _, vendor := range conf.Vendors {
+		var col collector
+		switch vendor {
+		case vendorNVIDIA:
+			col = newNvidiaCollector(conf.NvidiaSmiPath)
+		case vendorAMD:
+			col = newAMDCollector(util.HostSys("class", "drm"), util.HostProc())
+		default:
+			return fmt.Errorf("unknown GPU vendor %q, supported vendors are %s and %s", vendor, vendorNVIDIA, vendorAMD)
+		}
+
+		if col.available() {
+			c.collectors[vendor] = col
+		} else {
+			log.Debugf("No %s GPU found", vendor)
+		}
+	}
+
+	if len(c.collectors) == 0 {
+		return errors.New("no GPU found on the host")
+	}
+	return nil
+}
+
+// Run executes the check
+func (c *Check) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for vendor, col := range c.collectors {
+		devices, processes, err := col.collect(c.collectProcesses)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", vendor, err))
+			continue
+		}
+
+		for _, d := range devices {
+			tags := d.tags()
+			if d.utilization >= 0 {
+				sender.Gauge("gpu.utilization", d.utilization, "", tags)
+			}
+			sender.Gauge("gpu.memory.used", d.memoryUsed, "", tags)
+			sender.Gauge("gpu.memory.total", d.memoryTotal, "", tags)
+		}
+
+		for _, p := range processes {
+			tags := append(p.device.tags(), fmt.Sprintf("pid:%d", p.pid))
+			if name := processName(p.pid); name != "" {
+				tags = append(tags, "process_name:"+name)
+			}
+			tags = append(tags, containerTags(p.pid)...)
+			sender.Gauge("gpu.process.memory.used", p.memoryUsed, "", tags)
+		}
+	}
+	sender.Commit()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("could not collect the GPU usage: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func processName(pid int) string {
+	comm, err := ioutil.ReadFile(util.HostProc(strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// containerTags returns the tags of the container of the process, including the ones of its pod
+// for the processes of GPU-scheduled pods, or nil when the process isn't running in a container
+func containerTags(pid int) []string {
+	containerID, err := providers.ContainerImpl().ContainerIDForPID(pid)
+	if err != nil || containerID == "" {
+		return nil
+	}
+
+	tags, err := tagger.Tag(containers.BuildTaggerEntityName(containerID), collectors.HighCardinality)
+	if err != nil {
+		log.Debugf("Could not get the tags of the container %s: %s", containerID, err)
+	}
+	return append(tags, "container_id:"+containerID)
+}
+
+func gpuFactory() check.Check {
+	return &Check{
+		CheckBase: core.NewCheckBase(checkName),
+	}
+}
+
+func init() {
+	core.RegisterCheck(checkName, gpuFactory)
+}
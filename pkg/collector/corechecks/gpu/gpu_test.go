@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package gpu
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	nvidiaGPUSample = `0, GPU-5c3b5b5e-0d1f-4b1c-9a5e-1f2f3a4b5c6d, Tesla T4, 37, 1024, 15109
+1, GPU-8a7b6c5d-4e3f-2a1b-0c9d-8e7f6a5b4c3d, Tesla T4, [Not Supported], 0, 15109
+`
+	nvidiaProcessSample = `4242, GPU-5c3b5b5e-0d1f-4b1c-9a5e-1f2f3a4b5c6d, 512
+4343, GPU-5c3b5b5e-0d1f-4b1c-9a5e-1f2f3a4b5c6d, [Not Supported]
+4444, GPU-00000000-0000-0000-0000-000000000000, 256
+`
+)
+
+func TestParseNvidiaDevices(t *testing.T) {
+	devices, err := parseNvidiaDevices([]byte(nvidiaGPUSample))
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+
+	assert.Equal(t, &device{
+		vendor:      vendorNVIDIA,
+		index:       0,
+		uuid:        "GPU-5c3b5b5e-0d1f-4b1c-9a5e-1f2f3a4b5c6d",
+		model:       "Tesla T4",
+		utilization: 37,
+		memoryUsed:  1024 * mib,
+		memoryTotal: 15109 * mib,
+	}, devices[0])
+	assert.Equal(t, -1.0, devices[1].utilization)
+	assert.Equal(t, []string{
+		"gpu_vendor:nvidia",
+		"gpu_index:1",
+		"gpu_uuid:GPU-8a7b6c5d-4e3f-2a1b-0c9d-8e7f6a5b4c3d",
+		"gpu_model:Tesla T4",
+	}, devices[1].tags())
+
+	_, err = parseNvidiaDevices([]byte("0, GPU-5c3b5b5e, Tesla T4\n"))
+	assert.Error(t, err)
+	_, err = parseNvidiaDevices([]byte("0, GPU-5c3b5b5e, Tesla T4, 37, [N/A], 15109\n"))
+	assert.Error(t, err)
+}
+
+func TestParseNvidiaProcesses(t *testing.T) {
+	devices, err := parseNvidiaDevices([]byte(nvidiaGPUSample))
+	require.NoError(t, err)
+
+	processes, err := parseNvidiaProcesses([]byte(nvidiaProcessSample), devices)
+	require.NoError(t, err)
+	assert.Equal(t, []process{{pid: 4242, device: devices[0], memoryUsed: 512 * mib}}, processes)
+
+	processes, err = parseNvidiaProcesses(nil, devices)
+	require.NoError(t, err)
+	assert.Empty(t, processes)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestAMDCollector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gpu")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	drmDir := filepath.Join(dir, "sys", "class", "drm")
+	procDir := filepath.Join(dir, "proc")
+
+	// card0 is an AMD GPU, card1 is not
+	pciDevice := filepath.Join(dir, "sys", "devices", "pci0000:00", "0000:03:00.0")
+	writeFile(t, filepath.Join(pciDevice, "vendor"), "0x1002\n")
+	writeFile(t, filepath.Join(pciDevice, "gpu_busy_percent"), "42\n")
+	writeFile(t, filepath.Join(pciDevice, "mem_info_vram_used"), "1073741824\n")
+	writeFile(t, filepath.Join(pciDevice, "mem_info_vram_total"), "8589934592\n")
+	writeFile(t, filepath.Join(pciDevice, "unique_id"), "a1b2c3d4e5f6\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(drmDir, "card0"), 0755))
+	require.NoError(t, os.Symlink(pciDevice, filepath.Join(drmDir, "card0", "device")))
+	require.NoError(t, os.MkdirAll(filepath.Join(drmDir, "card0-DP-1"), 0755))
+	writeFile(t, filepath.Join(drmDir, "card1", "device", "vendor"), "0x8086\n")
+
+	// the process 100 has two file descriptors of the same client, and another client
+	client := "drm-driver:\tamdgpu\ndrm-pdev:\t0000:03:00.0\ndrm-client-id:\t%s\ndrm-memory-vram:\t%s KiB\n"
+	writeFile(t, filepath.Join(procDir, "100", "fdinfo", "5"), fmt.Sprintf(client, "1", "1024"))
+	writeFile(t, filepath.Join(procDir, "100", "fdinfo", "6"), fmt.Sprintf(client, "1", "1024"))
+	writeFile(t, filepath.Join(procDir, "100", "fdinfo", "7"), fmt.Sprintf(client, "2", "2048"))
+	writeFile(t, filepath.Join(procDir, "100", "fdinfo", "0"), "pos:\t0\nflags:\t02\n")
+	writeFile(t, filepath.Join(procDir, "200", "fdinfo", "3"), "drm-driver:\ti915\ndrm-pdev:\t0000:00:02.0\n")
+
+	col := newAMDCollector(drmDir, procDir)
+	require.True(t, col.available())
+
+	devices, processes, err := col.collect(true)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, &device{
+		vendor:      vendorAMD,
+		index:       0,
+		uuid:        "a1b2c3d4e5f6",
+		utilization: 42,
+		memoryUsed:  1073741824,
+		memoryTotal: 8589934592,
+	}, devices[0])
+	assert.Equal(t, []process{{pid: 100, device: devices[0], memoryUsed: 3072 * 1024}}, processes)
+
+	_, processes, err = col.collect(false)
+	require.NoError(t, err)
+	assert.Empty(t, processes)
+
+	assert.False(t, newAMDCollector(filepath.Join(dir, "missing"), procDir).available())
+}
+
+func TestParseKiB(t *testing.T) {
+	assert.Equal(t, 2048.0, parseKiB("2 KiB"))
+	assert.Equal(t, 12.0, parseKiB("12"))
+	assert.Equal(t, 0.0, parseKiB(""))
+	assert.Equal(t, 0.0, parseKiB("n/a KiB"))
+}
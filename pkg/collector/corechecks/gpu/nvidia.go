@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package gpu
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/subprocess"
+)
+
+// nvidiaSmiTimeout is the maximum duration of an nvidia-smi query
+const nvidiaSmiTimeout = 10 * time.Second
+
+var (
+	nvidiaGPUQuery     = []string{"--query-gpu=index,uuid,name,utilization.gpu,memory.used,memory.total", "--format=csv,noheader,nounits"}
+	nvidiaProcessQuery = []string{"--query-compute-apps=pid,gpu_uuid,used_memory", "--format=csv,noheader,nounits"}
+)
+
+// nvidiaCollector collects the usage of the NVIDIA GPUs with nvidia-smi, which reads it from NVML
+type nvidiaCollector struct {
+	nvidiaSmiPath string
+}
+
+func newNvidiaCollector(nvidiaSmiPath string) *nvidiaCollector {
+	return &nvidiaCollector{nvidiaSmiPath: nvidiaSmiPath}
+}
+
+func (n *nvidiaCollector) available() bool {
+	_, err := exec.LookPath(n.nvidiaSmiPath)
+	return err == nil
+}
+
+func (n *nvidiaCollector) collect(collectProcesses bool) ([]*device, []process, error) {
+	out, err := n.query(nvidiaGPUQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	devices, err := parseNvidiaDevices(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !collectProcesses {
+		return devices, nil, nil
+	}
+
+	out, err = n.query(nvidiaProcessQuery)
+	if err != nil {
+		return devices, nil, err
+	}
+	processes, err := parseNvidiaProcesses(out, devices)
+	return devices, processes, err
+}
+
+func (n *nvidiaCollector) query(args []string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), nvidiaSmiTimeout)
+	defer cancel()
+
+	out, err := subprocess.Output(exec.CommandContext(ctx, n.nvidiaSmiPath, args...))
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %s", n.nvidiaSmiPath, args[0], err)
+	}
+	return out, nil
+}
+
+// parseNvidiaDevices parses the output of the nvidia-smi GPU query, the memory is reported in MiB
+func parseNvidiaDevices(out []byte) ([]*device, error) {
+	records, err := readNvidiaCSV(out, 6)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]*device, 0, len(records))
+	for _, record := range records {
+		index, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid GPU index %q", record[0])
+		}
+		d := &device{
+			vendor:      vendorNVIDIA,
+			index:       index,
+			uuid:        record[1],
+			model:       record[2],
+			utilization: parseNvidiaValue(record[3], 1),
+			memoryUsed:  parseNvidiaValue(record[4], mib),
+			memoryTotal: parseNvidiaValue(record[5], mib),
+		}
+		if d.memoryUsed < 0 || d.memoryTotal < 0 {
+			return nil, fmt.Errorf("the memory usage of the GPU %d is not reported", index)
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// parseNvidiaProcesses parses the output of the nvidia-smi compute apps query, the processes of the GPUs
+// which aren't in devices, and the ones whose memory usage isn't reported, are skipped
+func parseNvidiaProcesses(out []byte, devices []*device) ([]process, error) {
+	records, err := readNvidiaCSV(out, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	devicesByUUID := make(map[string]*device, len(devices))
+	for _, d := range devices {
+		devicesByUUID[d.uuid] = d
+	}
+
+	processes := make([]process, 0, len(records))
+	for _, record := range records {
+		pid, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pid %q", record[0])
+		}
+		d, found := devicesByUUID[record[1]]
+		if !found {
+			continue
+		}
+		memoryUsed := parseNvidiaValue(record[2], mib)
+		if memoryUsed < 0 {
+			continue
+		}
+		processes = append(processes, process{pid: pid, device: d, memoryUsed: memoryUsed})
+	}
+	return processes, nil
+}
+
+func readNvidiaCSV(out []byte, fields int) ([][]string, error) {
+	reader := csv.NewReader(strings.NewReader(string(out)))
+	reader.FieldsPerRecord = fields
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("unexpected nvidia-smi output: %s", err)
+	}
+	return records, nil
+}
+
+// parseNvidiaValue returns the value multiplied by unit, or -1 when nvidia-smi doesn't report it,
+// for instance "[Not Supported]" or "[N/A]"
+func parseNvidiaValue(value string, unit float64) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return -1
+	}
+	return v * unit
+}
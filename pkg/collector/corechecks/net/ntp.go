@@ -22,6 +22,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/clocksync"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -181,6 +182,7 @@ func (c *NTPCheck) Run() error {
 		sender.Gauge("ntp.offset", clockOffset, "", nil)
 		ntpExpVar.Set(clockOffset)
 		tlmNtpOffset.Set(clockOffset)
+		clocksync.Record(clocksync.SourceNTP, time.Duration(clockOffset*float64(time.Second)))
 	}
 
 	sender.ServiceCheck("ntp.in_sync", serviceCheckStatus, "", nil, serviceCheckMessage)
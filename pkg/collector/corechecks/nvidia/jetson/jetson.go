@@ -15,6 +15,7 @@ import (
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/subprocess"
 	"gopkg.in/yaml.v2"
 	"os/exec"
 	"regexp"
@@ -129,7 +130,7 @@ func (c *JetsonCheck) Run() error {
 		cmd = exec.Command("sh", "-c", cmdStr)
 	}
 
-	tegrastatsOutput, err := cmd.Output()
+	tegrastatsOutput, err := subprocess.Output(cmd)
 	if err != nil {
 		switch err := err.(type) {
 		case *exec.ExitError:
@@ -9,6 +9,7 @@ package systemd
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -145,14 +146,16 @@ type unitSubstateMapping = map[string]string
 type systemdInstanceConfig struct {
 	PrivateSocket         string                         `yaml:"private_socket"`
 	UnitNames             []string                       `yaml:"unit_names"`
+	UnitRegexes           []string                       `yaml:"unit_regexes"`
 	SubstateStatusMapping map[string]unitSubstateMapping `yaml:"substate_status_mapping"`
 }
 
 type systemdInitConfig struct{}
 
 type systemdConfig struct {
-	instance systemdInstanceConfig
-	initConf systemdInitConfig
+	instance     systemdInstanceConfig
+	initConf     systemdInitConfig
+	unitPatterns []*regexp.Regexp
 }
 
 type systemdStats interface {
@@ -498,13 +501,19 @@ func getServiceCheckStatus(state string, mapping map[string]string) metrics.Serv
 	return metrics.ServiceCheckUnknown
 }
 
-// isMonitored verifies if a unit should be monitored.
+// isMonitored verifies if a unit should be monitored: its name is listed in `unit_names`
+// or matches one of the `unit_regexes`.
 func (c *SystemdCheck) isMonitored(unitName string) bool {
 	for _, name := range c.config.instance.UnitNames {
 		if name == unitName {
 			return true
 		}
 	}
+	for _, pattern := range c.config.unitPatterns {
+		if pattern.MatchString(unitName) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -532,13 +541,22 @@ func (c *SystemdCheck) Configure(rawInstance integration.Data, rawInitConfig int
 		return err
 	}
 
-	if len(c.config.instance.UnitNames) == 0 {
-		return fmt.Errorf("instance config `unit_names` must not be empty")
+	if len(c.config.instance.UnitNames) == 0 && len(c.config.instance.UnitRegexes) == 0 {
+		return fmt.Errorf("instance config `unit_names` or `unit_regexes` must not be empty")
+	}
+
+	c.config.unitPatterns = nil
+	for _, regex := range c.config.instance.UnitRegexes {
+		pattern, err := regexp.Compile(regex)
+		if err != nil {
+			return fmt.Errorf("instance config `unit_regexes` contains an invalid regex '%s': %v", regex, err)
+		}
+		c.config.unitPatterns = append(c.config.unitPatterns, pattern)
 	}
 
 	for unitNameInMapping := range c.config.instance.SubstateStatusMapping {
 		if !c.isMonitored(unitNameInMapping) {
-			return fmt.Errorf("instance config specifies a custom substate mapping for unit '%s' but this unit is not monitored. Please add '%s' to 'unit_names'", unitNameInMapping, unitNameInMapping)
+			return fmt.Errorf("instance config specifies a custom substate mapping for unit '%s' but this unit is not monitored. Please add '%s' to 'unit_names' or match it with 'unit_regexes'", unitNameInMapping, unitNameInMapping)
 		}
 	}
 
@@ -106,7 +106,7 @@ func TestMissingUnitNamesShouldRaiseError(t *testing.T) {
 	check := SystemdCheck{}
 	err := check.Configure([]byte(``), []byte(``), "test")
 
-	expectedErrorMsg := "instance config `unit_names` must not be empty"
+	expectedErrorMsg := "instance config `unit_names` or `unit_regexes` must not be empty"
 	assert.EqualError(t, err, expectedErrorMsg)
 }
 
@@ -122,7 +122,7 @@ substate_status_mapping:
 `)
 	err := check.Configure(rawInstanceConfig, []byte(``), "test")
 
-	expectedErrorMsg := "instance config specifies a custom substate mapping for unit 'bar' but this unit is not monitored. Please add 'bar' to 'unit_names'"
+	expectedErrorMsg := "instance config specifies a custom substate mapping for unit 'bar' but this unit is not monitored. Please add 'bar' to 'unit_names' or match it with 'unit_regexes'"
 	assert.EqualError(t, err, expectedErrorMsg)
 }
 
@@ -845,6 +845,48 @@ unit_names:
 	}
 }
 
+func TestIsMonitoredRegexes(t *testing.T) {
+	rawInstanceConfig := []byte(`
+unit_names:
+  - unit1.service
+unit_regexes:
+  - ^docker-.*\.scope$
+  - ^nginx@.+\.service$
+`)
+
+	check := SystemdCheck{}
+	err := check.Configure(rawInstanceConfig, nil, "test")
+	assert.Nil(t, err)
+
+	data := []struct {
+		unitName              string
+		expectedToBeMonitored bool
+	}{
+		{"unit1.service", true},
+		{"docker-abc123.scope", true},
+		{"nginx@site1.service", true},
+		{"nginx.service", false},
+		{"mydocker-abc123.scope", false},
+	}
+	for _, d := range data {
+		t.Run(fmt.Sprintf("check.isMonitored('%s') expected to be %v", d.unitName, d.expectedToBeMonitored), func(t *testing.T) {
+			assert.Equal(t, d.expectedToBeMonitored, check.isMonitored(d.unitName))
+		})
+	}
+}
+
+func TestInvalidUnitRegex(t *testing.T) {
+	check := SystemdCheck{}
+	rawInstanceConfig := []byte(`
+unit_regexes:
+  - nginx@(.service
+`)
+	err := check.Configure(rawInstanceConfig, []byte(``), "test")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "instance config `unit_regexes` contains an invalid regex 'nginx@(.service'")
+}
+
 func TestIsMonitoredEmptyConfigShouldNone(t *testing.T) {
 	rawInstanceConfig := []byte(``)
 	check := SystemdCheck{}
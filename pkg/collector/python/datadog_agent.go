@@ -16,6 +16,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metadata/externalhost"
 	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
+	"github.com/DataDog/datadog-agent/pkg/metadata/metricmetadata"
 	"github.com/DataDog/datadog-agent/pkg/persistentcache"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/clustername"
@@ -35,6 +36,7 @@ import (
 )
 
 // GetVersion exposes the version of the agent to Python checks.
+//
 //export GetVersion
 func GetVersion(agentVersion **C.char) {
 	av, _ := version.Agent()
@@ -43,6 +45,7 @@ func GetVersion(agentVersion **C.char) {
 }
 
 // GetHostname exposes the current hostname of the agent to Python checks.
+//
 //export GetHostname
 func GetHostname(hostname **C.char) {
 	goHostname, err := util.GetHostname()
@@ -55,6 +58,7 @@ func GetHostname(hostname **C.char) {
 }
 
 // GetClusterName exposes the current clustername (if it exists) of the agent to Python checks.
+//
 //export GetClusterName
 func GetClusterName(clusterName **C.char) {
 	goHostname, _ := util.GetHostname()
@@ -64,12 +68,14 @@ func GetClusterName(clusterName **C.char) {
 }
 
 // TracemallocEnabled exposes the tracemalloc configuration of the agent to Python checks.
+//
 //export TracemallocEnabled
 func TracemallocEnabled() C.bool {
 	return C.bool(config.Datadog.GetBool("tracemalloc_debug"))
 }
 
 // Headers returns a basic set of HTTP headers that can be used by clients in Python checks.
+//
 //export Headers
 func Headers(yamlPayload **C.char) {
 	h := util.HTTPHeaders()
@@ -86,6 +92,7 @@ func Headers(yamlPayload **C.char) {
 
 // GetConfig returns a value from the agent configuration.
 // Indirectly used by the C function `get_config` that's mapped to `datadog_agent.get_config`.
+//
 //export GetConfig
 func GetConfig(key *C.char, yamlPayload **C.char) {
 	goKey := C.GoString(key)
@@ -107,6 +114,7 @@ func GetConfig(key *C.char, yamlPayload **C.char) {
 
 // LogMessage logs a message from python through the agent logger (see
 // https://docs.python.org/2.7/library/logging.html#logging-levels)
+//
 //export LogMessage
 func LogMessage(message *C.char, logLevel C.int) {
 	goMsg := C.GoString(message)
@@ -135,6 +143,7 @@ func LogMessage(message *C.char, logLevel C.int) {
 
 // SetExternalTags adds a set of tags for a given hostname to the External Host
 // Tags metadata provider cache.
+//
 //export SetExternalTags
 func SetExternalTags(hostname *C.char, sourceType *C.char, tags **C.char) {
 	hname := C.GoString(hostname)
@@ -157,6 +166,7 @@ func SetExternalTags(hostname *C.char, sourceType *C.char, tags **C.char) {
 
 // SetCheckMetadata updates a metadata value for one check instance in the cache.
 // Indirectly used by the C function `set_check_metadata` that's mapped to `datadog_agent.set_check_metadata`.
+//
 //export SetCheckMetadata
 func SetCheckMetadata(checkID, name, value *C.char) {
 	cid := C.GoString(checkID)
@@ -166,8 +176,26 @@ func SetCheckMetadata(checkID, name, value *C.char) {
 	inventories.SetCheckMetadata(cid, key, val)
 }
 
+// SetMetricMetadata declares the unit, the type and the description of a metric.
+// Indirectly used by the C function `set_metric_metadata` that's mapped to `datadog_agent.set_metric_metadata`.
+//
+//export SetMetricMetadata
+func SetMetricMetadata(metricName, unit, metricType, description *C.char) {
+	name := C.GoString(metricName)
+	metadata := metricmetadata.MetricMetadata{
+		Unit:        C.GoString(unit),
+		Type:        C.GoString(metricType),
+		Description: C.GoString(description),
+	}
+
+	if err := metricmetadata.SetMetricMetadata(name, metadata); err != nil {
+		log.Warnf("Unable to set the metadata of metric '%s': %s", name, err)
+	}
+}
+
 // WritePersistentCache stores a value for one check instance
 // Indirectly used by the C function `write_persistent_cache` that's mapped to `datadog_agent.write_persistent_cache`.
+//
 //export WritePersistentCache
 func WritePersistentCache(key, value *C.char) {
 	keyName := C.GoString(key)
@@ -177,6 +205,7 @@ func WritePersistentCache(key, value *C.char) {
 
 // ReadPersistentCache retrieves a value for one check instance
 // Indirectly used by the C function `read_persistent_cache` that's mapped to `datadog_agent.read_persistent_cache`.
+//
 //export ReadPersistentCache
 func ReadPersistentCache(key *C.char) *C.char {
 	keyName := C.GoString(key)
@@ -192,6 +221,7 @@ var obfuscator = obfuscate.NewObfuscator(nil)
 
 // ObfuscateSQL obfuscates & normalizes the provided SQL query, writing the error into errResult if the operation
 // fails
+//
 //export ObfuscateSQL
 func ObfuscateSQL(rawQuery *C.char, errResult **C.char) *C.char {
 	s := C.GoString(rawQuery)
@@ -83,6 +83,7 @@ void GetVersion(char **);
 void Headers(char **);
 char * ReadPersistentCache(char *);
 void SetCheckMetadata(char *, char *, char *);
+void SetMetricMetadata(char *, char *, char *, char *);
 void SetExternalTags(char *, char *, char **);
 void WritePersistentCache(char *, char *);
 bool TracemallocEnabled();
@@ -95,6 +96,7 @@ void initDatadogAgentModule(rtloader_t *rtloader) {
 	set_get_version_cb(rtloader, GetVersion);
 	set_headers_cb(rtloader, Headers);
 	set_set_check_metadata_cb(rtloader, SetCheckMetadata);
+	set_set_metric_metadata_cb(rtloader, SetMetricMetadata);
 	set_set_external_tags_cb(rtloader, SetExternalTags);
 	set_write_persistent_cache_cb(rtloader, WritePersistentCache);
 	set_read_persistent_cache_cb(rtloader, ReadPersistentCache);
@@ -365,6 +367,9 @@ func Initialize(paths ...string) error {
 
 	sendTelemetry(pythonVersion)
 
+	// the cgroup of the subprocesses is set up before the checks are loaded
+	confineSubprocesses()
+
 	return nil
 }
 
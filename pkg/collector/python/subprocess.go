@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build python
+
+package python
+
+/*
+#include <datadog_agent_rtloader.h>
+#include "rtloader_mem.h"
+*/
+import "C"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unsafe"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/subprocess"
+)
+
+// confineSubprocessesCode wraps the commands run by the subprocess module of Python, so that the
+// subprocesses spawned by the checks without get_subprocess_output run in the cgroup of the
+// subprocesses too. The commands given an explicit executable, and the ones that can't be found so
+// that the usual error is raised, are run as is.
+const confineSubprocessesCode = `
+def _datadog_confine_subprocesses(pre_exec_args):
+    import os
+    import subprocess
+    popen_init = subprocess.Popen.__init__
+
+    def found(executable, cwd, env):
+        try:
+            if os.sep in executable:
+                return os.access(os.path.join(cwd or '', executable), os.X_OK)
+            path = (env if env is not None else os.environ).get('PATH', os.defpath)
+            return any(os.access(os.path.join(d, executable), os.X_OK) for d in path.split(os.pathsep))
+        except Exception:
+            return False
+
+    def __init__(self, args, *posargs, **kwargs):
+        if posargs or kwargs.get('executable') is not None:
+            return popen_init(self, args, *posargs, **kwargs)
+        if not isinstance(args, (list, tuple)):
+            args = [args]
+        if kwargs.get('shell'):
+            args = ['/bin/sh', '-c'] + list(args)
+            kwargs['shell'] = False
+        if not args or not found(args[0], kwargs.get('cwd'), kwargs.get('env')):
+            return popen_init(self, args, **kwargs)
+        return popen_init(self, pre_exec_args + list(args), **kwargs)
+
+    subprocess.Popen.__init__ = __init__
+
+_datadog_confine_subprocesses(%s)
+del _datadog_confine_subprocesses
+`
+
+// confineSubprocesses makes the subprocess module of Python run its commands in the cgroup of the
+// subprocesses, if it was set up
+func confineSubprocesses() {
+	preExecArgs := subprocess.PreExecArgs()
+	if len(preExecArgs) == 0 {
+		return
+	}
+
+	// a JSON list of strings is a Python list literal, as long as its characters aren't escaped
+	var args bytes.Buffer
+	encoder := json.NewEncoder(&args)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(preExecArgs); err != nil {
+		log.Errorf("Could not run the Python subprocesses in the cgroup of the subprocesses: %s", err)
+		return
+	}
+
+	code := TrackedCString(fmt.Sprintf(confineSubprocessesCode, bytes.TrimSpace(args.Bytes())))
+	defer C._free(unsafe.Pointer(code))
+
+	glock := newStickyLock()
+	defer glock.unlock()
+	if C.run_simple_string(rtloader, code) == 0 {
+		log.Errorf("Could not run the Python subprocesses in the cgroup of the subprocesses: %s", C.GoString(C.get_error(rtloader)))
+	}
+}
@@ -20,10 +20,13 @@ import (
 	"os/exec"
 	"sync"
 	"syscall"
+
+	"github.com/DataDog/datadog-agent/pkg/util/subprocess"
 )
 
 // GetSubprocessOutput runs the subprocess and returns the output
 // Indirectly used by the C function `get_subprocess_output` that's mapped to `_util.get_subprocess_output`.
+//
 //export GetSubprocessOutput
 func GetSubprocessOutput(argv **C.char, env **C.char, cStdout **C.char, cStderr **C.char, cRetCode *C.int, exception **C.char) {
 	subprocessArgs := cStringArrayToSlice(argv)
@@ -67,7 +70,7 @@ func GetSubprocessOutput(argv **C.char, env **C.char, cStdout **C.char, cStderr
 		outputErr, _ = ioutil.ReadAll(stderr)
 	}()
 
-	cmd.Start() //nolint:errcheck
+	subprocess.Start(cmd) //nolint:errcheck
 
 	// Wait for the pipes to be closed *before* waiting for the cmd to exit, as per os.exec docs
 	wg.Wait()
@@ -236,6 +236,27 @@ func (r *Runner) StopCheck(id check.ID) error {
 	}
 }
 
+// RunExclusively calls fn while the check is listed as running, so that the workers skip its scheduled runs
+// in the meantime. It returns false, without calling fn, when the check is already running.
+func (r *Runner) RunExclusively(c check.Check, fn func()) bool {
+	r.m.Lock()
+	if _, isRunning := r.runningChecks[c.ID()]; isRunning {
+		r.m.Unlock()
+		return false
+	}
+	r.runningChecks[c.ID()] = c
+	r.m.Unlock()
+
+	defer func() {
+		r.m.Lock()
+		delete(r.runningChecks, c.ID())
+		r.m.Unlock()
+	}()
+
+	fn()
+	return true
+}
+
 // work waits for checks and run them as long as they arrive on the channel
 func (r *Runner) work() {
 	log.Debug("Ready to process checks...")
@@ -176,3 +176,21 @@ func TestStopCheck(t *testing.T) {
 	err = r.StopCheck(c2.ID())
 	assert.Equal(t, "timeout during stop operation on check id TestCheck:2", err.Error())
 }
+
+func TestRunExclusively(t *testing.T) {
+	r := NewRunner()
+	defer r.Stop()
+
+	c := newTestCheck(false, "1")
+	ran := r.RunExclusively(c, func() {
+		r.m.Lock()
+		_, isRunning := r.runningChecks[c.ID()]
+		r.m.Unlock()
+		assert.True(t, isRunning)
+
+		// the check is already running
+		assert.False(t, r.RunExclusively(c, func() { t.Fatal("the check ran twice") }))
+	})
+	assert.True(t, ran)
+	assert.NotContains(t, r.runningChecks, c.ID())
+}
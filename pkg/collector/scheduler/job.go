@@ -65,6 +65,7 @@ type jobQueue struct {
 	lastTick            time.Time
 	sparseStep          uint
 	currentBucketIdx    uint
+	rotations           uint
 	schedulingBucketIdx uint
 	running             bool
 	health              *health.Handle
@@ -195,6 +196,11 @@ func (jq *jobQueue) process(s *Scheduler) bool {
 
 		log.Tracef("Jobs in bucket: %v", jobs)
 
+		// when shedding load, only enqueue the checks every `factor` rotations
+		if factor := s.getIntervalFactor(); factor > 1 && jq.rotations%factor != 0 {
+			jobs = nil
+		}
+
 		for _, check := range jobs {
 			if !s.IsCheckScheduled(check.ID()) {
 				continue
@@ -210,6 +216,9 @@ func (jq *jobQueue) process(s *Scheduler) bool {
 		}
 		jq.mu.Lock()
 		jq.currentBucketIdx = (jq.currentBucketIdx + 1) % uint(len(jq.buckets))
+		if jq.currentBucketIdx == 0 {
+			jq.rotations++
+		}
 		jq.mu.Unlock()
 	case <-jq.health.C:
 		// nothing
@@ -20,6 +20,7 @@ import (
 
 var (
 	minAllowedInterval     = 1 * time.Second
+	degradedIntervalFactor = uint32(2)
 	schedulerExpvars       *expvar.Map
 	schedulerQueuesCount   = expvar.Int{}
 	schedulerChecksEntered = expvar.Int{}
@@ -40,6 +41,7 @@ func init() {
 // More docs to come...
 type Scheduler struct {
 	running          uint32                      // Flag to see if the scheduler is running
+	intervalFactor   uint32                      // Multiplier applied to the check intervals when shedding load
 	checksPipe       chan<- check.Check          // The pipe the Runner pops the checks from, initially set to nil
 	done             chan bool                   // Guard for the main loop
 	halted           chan bool                   // Used to internally communicate all queues are done
@@ -64,6 +66,7 @@ func NewScheduler(checksPipe chan<- check.Check) *Scheduler {
 		checkToQueue:     make(map[check.ID]*jobQueue),
 		tlmTrackedChecks: make(map[check.ID]string),
 		running:          0,
+		intervalFactor:   1,
 		cancelOneTime:    make(chan bool),
 		wgOneTime:        sync.WaitGroup{},
 	}
@@ -198,6 +201,22 @@ func (s *Scheduler) Stop() error {
 	}
 }
 
+// Shed multiplies the scheduling intervals of all the checks by degradedIntervalFactor
+func (s *Scheduler) Shed() {
+	log.Infof("Scheduler: multiplying check intervals by %d", degradedIntervalFactor)
+	atomic.StoreUint32(&s.intervalFactor, degradedIntervalFactor)
+}
+
+// Restore restores the configured scheduling intervals of all the checks
+func (s *Scheduler) Restore() {
+	log.Infof("Scheduler: restoring check intervals")
+	atomic.StoreUint32(&s.intervalFactor, 1)
+}
+
+func (s *Scheduler) getIntervalFactor() uint {
+	return uint(atomic.LoadUint32(&s.intervalFactor))
+}
+
 // IsCheckScheduled returns whether a check is in the schedule or not
 func (s *Scheduler) IsCheckScheduled(id check.ID) bool {
 	s.mu.Lock()
@@ -196,3 +196,14 @@ func TestStopOneTimeSchedule(t *testing.T) {
 	// sleep to make the runtime schedule the hanging goroutines, if there are any
 	time.Sleep(time.Millisecond)
 }
+
+func TestShedRestore(t *testing.T) {
+	s := getScheduler()
+	assert.Equal(t, uint(1), s.getIntervalFactor())
+
+	s.Shed()
+	assert.Equal(t, uint(degradedIntervalFactor), s.getIntervalFactor())
+
+	s.Restore()
+	assert.Equal(t, uint(1), s.getIntervalFactor())
+}
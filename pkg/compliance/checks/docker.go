@@ -17,7 +17,7 @@ import (
 )
 
 func newDockerClient() (env.DockerClient, error) {
-	queryTimeout := config.Datadog.GetDuration("docker_query_timeout") * time.Second
+	queryTimeout := config.Datadog.GetDurationWithUnit("docker_query_timeout", time.Second)
 
 	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
 	defer cancel()
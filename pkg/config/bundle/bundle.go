@@ -0,0 +1,351 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package bundle loads the encrypted and signed configuration bundles distributed to fleets of agents.
+//
+// A bundle is a gzipped tar archive holding a datadog.yaml file and, optionally, a conf.d directory. It is
+// encrypted with AES-256-GCM and signed with ed25519, the signature covering the version of the bundle and
+// the encrypted archive:
+//
+//	magic (4 bytes) | signature (64 bytes) | version (8 bytes) | nonce (12 bytes) | encrypted archive
+//
+// The bundles are extracted in a subdirectory of the bundle directory named after their version, the
+// current file of the bundle directory holding the version of the bundle in use.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ConfigFile is the name of the main configuration file of a bundle
+	ConfigFile = "datadog.yaml"
+	// ConfdDir is the name of the directory of the checks configurations of a bundle
+	ConfdDir = "conf.d"
+
+	// KeySize is the size of the encryption keys of the bundles
+	KeySize = 32
+
+	nonceSize   = 12
+	versionSize = 8
+
+	// currentFile is the name of the file holding the version of the bundle in use
+	currentFile = "current"
+
+	// maxBundleSize bounds the size of the bundles, and of their extracted content
+	maxBundleSize = 64 * 1024 * 1024
+	fetchTimeout  = 30 * time.Second
+)
+
+var (
+	magic = []byte("DDB1")
+
+	errInvalidFormat = errors.New("invalid configuration bundle")
+	errNotSigned     = errors.New("the configuration bundle isn't signed by any of the trusted keys")
+	errTooLarge      = fmt.Errorf("the configuration bundle is larger than %d bytes", maxBundleSize)
+	errNoBundle      = errors.New("no configuration bundle was extracted")
+)
+
+// Fetch reads a bundle from an http or https URL, or from a file
+func Fetch(source string) ([]byte, error) {
+	var reader io.Reader
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		client := &http.Client{Timeout: fetchTimeout}
+		resp, err := client.Get(source)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status code %d fetching the configuration bundle", resp.StatusCode)
+		}
+		reader = resp.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(reader, maxBundleSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBundleSize {
+		return nil, errTooLarge
+	}
+	return data, nil
+}
+
+// Seal encrypts and signs an archive with its version, it's the counterpart of Open used by the provisioning tooling
+func Seal(archive []byte, version uint64, key []byte, signingKey ed25519.PrivateKey) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	signed := make([]byte, versionSize, versionSize+nonceSize+gcm.Overhead()+len(archive))
+	binary.BigEndian.PutUint64(signed, version)
+	signed = append(signed, nonce...)
+	signed = gcm.Seal(signed, nonce, archive, additionalData(signed[:versionSize]))
+	signature := ed25519.Sign(signingKey, signed)
+
+	bundle := make([]byte, 0, len(magic)+len(signature)+len(signed))
+	bundle = append(bundle, magic...)
+	bundle = append(bundle, signature...)
+	return append(bundle, signed...), nil
+}
+
+// Open checks that the bundle was signed by one of the public keys and decrypts its archive, it returns the
+// archive and the version of the bundle
+func Open(bundle []byte, key []byte, publicKeys []ed25519.PublicKey) ([]byte, uint64, error) {
+	if len(bundle) < len(magic)+ed25519.SignatureSize+versionSize+nonceSize || !bytes.Equal(bundle[:len(magic)], magic) {
+		return nil, 0, errInvalidFormat
+	}
+	signature := bundle[len(magic) : len(magic)+ed25519.SignatureSize]
+	signed := bundle[len(magic)+ed25519.SignatureSize:]
+
+	verified := false
+	for _, publicKey := range publicKeys {
+		if ed25519.Verify(publicKey, signed, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, 0, errNotSigned
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	version := signed[:versionSize]
+	nonce := signed[versionSize : versionSize+nonceSize]
+	archive, err := gcm.Open(nil, nonce, signed[versionSize+nonceSize:], additionalData(version))
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to decrypt the configuration bundle: %s", err)
+	}
+	return archive, binary.BigEndian.Uint64(version), nil
+}
+
+// additionalData binds the encrypted archive to the format and to the version of the bundle
+func additionalData(version []byte) []byte {
+	return append(append([]byte(nil), magic...), version...)
+}
+
+// Current returns the directory holding the content of the bundle in use in dir, and its version
+func Current(dir string) (string, uint64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, currentFile))
+	if os.IsNotExist(err) {
+		return "", 0, errNoBundle
+	} else if err != nil {
+		return "", 0, err
+	}
+
+	version, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid version of the current configuration bundle: %s", err)
+	}
+	return filepath.Join(dir, strconv.FormatUint(version, 10)), version, nil
+}
+
+// Extract extracts the datadog.yaml file and the conf.d directory of the archive in the subdirectory of dir
+// dedicated to its version, and then makes it the current one. The archive is refused when its version isn't
+// higher than the one of the current bundle, which is left untouched when the archive is invalid.
+func Extract(archive []byte, version uint64, dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	_, current, err := Current(dir)
+	if err != nil && err != errNoBundle {
+		return err
+	}
+	if version <= current {
+		return fmt.Errorf("the version %d of the configuration bundle isn't higher than the version %d in use", version, current)
+	}
+
+	tmpDir, err := ioutil.TempDir(dir, ".extract")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := extractArchive(archive, tmpDir); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, ConfigFile)); err != nil {
+		return fmt.Errorf("the configuration bundle has no %s file", ConfigFile)
+	}
+
+	// a previous extraction of this version may have been interrupted before it became the current one
+	versionDir := filepath.Join(dir, strconv.FormatUint(version, 10))
+	if err := os.RemoveAll(versionDir); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpDir, versionDir); err != nil {
+		return err
+	}
+
+	if err := writeCurrent(dir, version); err != nil {
+		return err
+	}
+
+	if current != 0 {
+		if err := os.RemoveAll(filepath.Join(dir, strconv.FormatUint(current, 10))); err != nil {
+			return fmt.Errorf("unable to remove the previous configuration bundle: %s", err)
+		}
+	}
+	return nil
+}
+
+// writeCurrent atomically replaces the version of the current bundle
+func writeCurrent(dir string, version uint64) error {
+	f, err := ioutil.TempFile(dir, "."+currentFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(strconv.FormatUint(version, 10)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, currentFile))
+}
+
+func extractArchive(archive []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	var extracted int64
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name, err := entryName(header.Name)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			extracted += header.Size
+			if extracted > maxBundleSize {
+				return errTooLarge
+			}
+			if err := writeFile(target, reader, header.Size); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported entry %s in the configuration bundle", header.Name)
+		}
+	}
+}
+
+// entryName validates the name of an entry of the archive, only the datadog.yaml file and the conf.d
+// directory are extracted
+func entryName(name string) (string, error) {
+	cleaned := path.Clean("/" + name)[1:]
+	if cleaned == "" || cleaned == ConfdDir {
+		return "", nil
+	}
+	if cleaned != ConfigFile && !strings.HasPrefix(cleaned, ConfdDir+"/") {
+		return "", fmt.Errorf("unexpected entry %s in the configuration bundle", name)
+	}
+	return cleaned, nil
+}
+
+func writeFile(target string, reader io.Reader, size int64) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return err
+	}
+
+	// the configuration may hold secrets
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(f, reader, size); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadKeyFile reads a base64 encoded encryption key
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key in %s: %s", path, err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid encryption key in %s: expected %d bytes, got %d", path, KeySize, len(key))
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func makeKeys(t *testing.T) ([]byte, ed25519.PublicKey, ed25519.PrivateKey) {
+	key := make([]byte, KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return key, publicKey, privateKey
+}
+
+func TestSealOpen(t *testing.T) {
+	key, publicKey, privateKey := makeKeys(t)
+	archive := makeArchive(t, map[string]string{ConfigFile: "api_key: abc\n"})
+
+	bundle, err := Seal(archive, 7, key, privateKey)
+	require.NoError(t, err)
+
+	opened, version, err := Open(bundle, key, []ed25519.PublicKey{publicKey})
+	require.NoError(t, err)
+	assert.Equal(t, archive, opened)
+	assert.Equal(t, uint64(7), version)
+
+	// signed by another key
+	otherPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, _, err = Open(bundle, key, []ed25519.PublicKey{otherPublicKey})
+	assert.Equal(t, errNotSigned, err)
+
+	// tampered with
+	tampered := append([]byte(nil), bundle...)
+	tampered[len(tampered)-1] ^= 0xff
+	_, _, err = Open(tampered, key, []ed25519.PublicKey{publicKey})
+	assert.Equal(t, errNotSigned, err)
+
+	// version changed
+	tampered = append([]byte(nil), bundle...)
+	tampered[len(magic)+ed25519.SignatureSize+versionSize-1] = 9
+	_, _, err = Open(tampered, key, []ed25519.PublicKey{publicKey})
+	assert.Equal(t, errNotSigned, err)
+
+	// encrypted with another key
+	otherKey := make([]byte, KeySize)
+	_, _, err = Open(bundle, otherKey, []ed25519.PublicKey{publicKey})
+	assert.Error(t, err)
+
+	_, _, err = Open([]byte("garbage"), key, []ed25519.PublicKey{publicKey})
+	assert.Equal(t, errInvalidFormat, err)
+}
+
+func TestExtract(t *testing.T) {
+	root, err := ioutil.TempDir("", "bundle")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "config_bundle")
+
+	_, _, err = Current(dir)
+	assert.Equal(t, errNoBundle, err)
+
+	archive := makeArchive(t, map[string]string{
+		ConfigFile:                   "api_key: abc\n",
+		"conf.d/nginx.d/conf.yaml":   "instances: [{}]\n",
+		"./conf.d/redis.d/conf.yaml": "instances: [{}]\n",
+	})
+	require.NoError(t, Extract(archive, 2, dir))
+
+	current, version, err := Current(dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "2"), current)
+	assert.Equal(t, uint64(2), version)
+
+	content, err := ioutil.ReadFile(filepath.Join(current, ConfigFile))
+	require.NoError(t, err)
+	assert.Equal(t, "api_key: abc\n", string(content))
+	assert.FileExists(t, filepath.Join(current, "conf.d", "nginx.d", "conf.yaml"))
+	assert.FileExists(t, filepath.Join(current, "conf.d", "redis.d", "conf.yaml"))
+
+	// the current content is kept when the archive is invalid
+	for _, files := range []map[string]string{
+		{"conf.d/nginx.d/conf.yaml": "instances: [{}]\n"},
+		{ConfigFile: "api_key: def\n", "../outside.yaml": "x"},
+		{ConfigFile: "api_key: def\n", "system-probe.yaml": "x"},
+	} {
+		assert.Error(t, Extract(makeArchive(t, files), 3, dir))
+	}
+	_, err = os.Stat(filepath.Join(root, "outside.yaml"))
+	assert.True(t, os.IsNotExist(err))
+
+	// the current content is kept when the bundle isn't newer
+	for _, version := range []uint64{1, 2} {
+		assert.Error(t, Extract(makeArchive(t, map[string]string{ConfigFile: "api_key: def\n"}), version, dir))
+	}
+
+	current, version, err = Current(dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), version)
+	content, err = ioutil.ReadFile(filepath.Join(current, ConfigFile))
+	require.NoError(t, err)
+	assert.Equal(t, "api_key: abc\n", string(content))
+
+	// the files left next to the bundles are kept
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0600))
+
+	// the new content replaces the previous one
+	require.NoError(t, Extract(makeArchive(t, map[string]string{ConfigFile: "api_key: def\n"}), 3, dir))
+	current, version, err = Current(dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), version)
+	_, err = os.Stat(filepath.Join(current, "conf.d"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "2"))
+	assert.True(t, os.IsNotExist(err))
+	assert.FileExists(t, filepath.Join(dir, "notes.txt"))
+
+	// no temporary file is left behind
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"3", currentFile, "notes.txt"}, names)
+}
+
+func TestReadKeyFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "bundle-key")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString("MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE=\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	key, err := ReadKeyFile(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, []byte("01234567890123456789012345678901"), key)
+
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("c2hvcnQ="), 0600))
+	_, err = ReadKeyFile(f.Name())
+	assert.Error(t, err)
+}
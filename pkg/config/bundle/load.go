@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/remote"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Load fetches the bundle set by `config_bundle.source`, verifies it and extracts it, and returns the directory
+// holding its content. The previously extracted bundle, which was verified, is used when the new one can't be
+// fetched, is invalid or is older.
+func Load(cfg config.Config) (string, error) {
+	source := cfg.GetString("config_bundle.source")
+	dir := bundlesDir(cfg)
+
+	err := load(cfg, source, dir)
+	currentDir, version, currentErr := Current(dir)
+	if err == nil && currentErr == nil {
+		log.Infof("Loaded the version %d of the configuration bundle from %s", version, source)
+		return currentDir, nil
+	}
+	if err == nil {
+		err = currentErr
+	}
+
+	if currentErr == nil {
+		log.Warnf("Unable to load the configuration bundle from %s, using the version %d: %s", source, version, err)
+		return currentDir, nil
+	}
+	return "", err
+}
+
+func load(cfg config.Config, source, dir string) error {
+	publicKeys, err := remote.ParsePublicKeys(cfg.GetStringSlice("config_bundle.public_keys"))
+	if err != nil {
+		return err
+	}
+	if len(publicKeys) == 0 {
+		return fmt.Errorf("no public key is set to verify the configuration bundle")
+	}
+
+	key, err := ReadKeyFile(cfg.GetString("config_bundle.encryption_key_file"))
+	if err != nil {
+		return err
+	}
+
+	bundle, err := Fetch(source)
+	if err != nil {
+		return err
+	}
+
+	archive, version, err := Open(bundle, key, publicKeys)
+	if err != nil {
+		return err
+	}
+
+	// the bundle in use is fetched again at each start
+	if _, current, err := Current(dir); err == nil && version == current {
+		return nil
+	}
+	return Extract(archive, version, dir)
+}
+
+// Enabled returns whether the configuration is loaded from a configuration bundle
+func Enabled(cfg config.Config) bool {
+	return cfg.GetString("config_bundle.source") != ""
+}
+
+// LoadConfig replaces the configuration of config.Datadog with the one of the configuration bundle, the checks
+// configurations of the bundle replacing the ones of confd_path. The bundle is fetched and extracted when fetch is
+// true, by the core agent and the agents sharing its setup, the other agents only load the bundle it extracted, so
+// that a single process writes to the bundles directory.
+func LoadConfig(fetch bool, withoutSecrets bool) (*config.Warnings, error) {
+	var dir string
+	var err error
+	if fetch {
+		dir, err = Load(config.Datadog)
+	} else {
+		dir, _, err = Current(bundlesDir(config.Datadog))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the configuration bundle: %w", err)
+	}
+
+	config.Datadog.SetConfigFile(filepath.Join(dir, ConfigFile))
+
+	var warnings *config.Warnings
+	if withoutSecrets {
+		warnings, err = config.LoadWithoutSecret()
+	} else {
+		warnings, err = config.Load()
+	}
+	if err != nil {
+		return warnings, fmt.Errorf("unable to load the config file of the configuration bundle: %w", err)
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, ConfdDir)); err == nil && info.IsDir() {
+		config.Datadog.Set("confd_path", filepath.Join(dir, ConfdDir))
+	}
+	return warnings, nil
+}
+
+// bundlesDir returns the directory the bundles are extracted to
+func bundlesDir(cfg config.Config) string {
+	if dir := cfg.GetString("config_bundle.dir"); dir != "" {
+		return dir
+	}
+	return filepath.Join(cfg.GetString("run_path"), "config_bundle")
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package bundle
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigExtracted(t *testing.T) {
+	root, err := ioutil.TempDir("", "bundle")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "config_bundle")
+
+	mockConfig := config.Mock()
+	mockConfig.Set("config_bundle.source", filepath.Join(root, "unreachable.bundle"))
+	mockConfig.Set("config_bundle.dir", dir)
+	require.True(t, Enabled(config.Datadog))
+
+	// nothing was extracted by the core agent yet
+	_, err = LoadConfig(false, true)
+	assert.Error(t, err)
+
+	archive := makeArchive(t, map[string]string{
+		ConfigFile:                 "api_key: abc\nconfig_bundle:\n  source: unreachable\n",
+		"conf.d/nginx.d/conf.yaml": "instances: [{}]\n",
+	})
+	require.NoError(t, Extract(archive, 4, dir))
+
+	// the bundle extracted is loaded without being fetched
+	_, err = LoadConfig(false, true)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", config.Datadog.GetString("api_key"))
+	assert.Equal(t, filepath.Join(dir, "4", ConfdDir), config.Datadog.GetString("confd_path"))
+}
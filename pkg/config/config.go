@@ -6,7 +6,6 @@
 package config
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -18,8 +17,6 @@ import (
 	"strings"
 	"time"
 
-	yaml "gopkg.in/yaml.v2"
-
 	"github.com/DataDog/datadog-agent/pkg/collector/check/defaults"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -106,6 +103,32 @@ type Proxy struct {
 	HTTP    string   `mapstructure:"http"`
 	HTTPS   string   `mapstructure:"https"`
 	NoProxy []string `mapstructure:"no_proxy"`
+	// Endpoints overrides the proxy of the requests to the given hosts, an empty proxy
+	// meaning that the requests are sent directly
+	Endpoints map[string]string `mapstructure:"endpoints"`
+}
+
+// TenantRoute sends the data matching one of its tag selectors to the org of its API key instead of
+// the main org
+type TenantRoute struct {
+	Name   string `mapstructure:"name" json:"name"`
+	APIKey string `mapstructure:"api_key" json:"api_key"`
+	// DDURL is the site of the org, it defaults to the main endpoint
+	DDURL string `mapstructure:"dd_url" json:"dd_url"`
+	// TagSelectors are tags like `kube_namespace:team-a`, a trailing `*` matches any suffix
+	TagSelectors []string `mapstructure:"tag_selectors" json:"tag_selectors"`
+}
+
+// DownsamplingRule reduces the cardinality of the dogstatsd metrics matching its name before they are sent
+type DownsamplingRule struct {
+	// Match is a metric name, a `*` matches any sequence of characters
+	Match string `mapstructure:"match" json:"match"`
+	// DropTags are the names of the tags removed from the metrics, merging the contexts only differing by them
+	DropTags []string `mapstructure:"drop_tags" json:"drop_tags"`
+	// Interval is the length in seconds of the buckets the metrics are aggregated in, 0 keeps the default one
+	Interval int64 `mapstructure:"interval" json:"interval"`
+	// ToDistribution converts the gauges, histograms and timings to distributions
+	ToDistribution bool `mapstructure:"to_distribution" json:"to_distribution"`
 }
 
 // MappingProfile represent a group of mappings
@@ -126,6 +149,10 @@ type MetricMapping struct {
 // Warnings represent the warnings in the config
 type Warnings struct {
 	TraceMallocEnabledWithPy2 bool
+	// UnknownKeys holds the keys set in the configuration that aren't known by the agent
+	UnknownKeys []string
+	// DeprecatedKeys holds the deprecated keys set in the configuration, along with the keys replacing them
+	DeprecatedKeys map[string]string
 }
 
 func init() {
@@ -144,6 +171,8 @@ func InitConfig(config Config) {
 	config.BindEnv("dd_url") //nolint:errcheck
 	config.BindEnvAndSetDefault("app_key", "")
 	config.BindEnvAndSetDefault("cloud_provider_metadata", []string{"aws", "gcp", "azure", "alibaba"})
+	config.BindEnvAndSetDefault("autoconfig_include_features", []string{})
+	config.BindEnvAndSetDefault("autoconfig_exclude_features", []string{})
 	config.SetDefault("proxy", nil)
 	config.BindEnvAndSetDefault("skip_ssl_validation", false)
 	config.BindEnvAndSetDefault("hostname", "")
@@ -159,6 +188,7 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("log_file_max_size", "10Mb")
 	config.BindEnvAndSetDefault("log_file_max_rolls", 1)
 	config.BindEnvAndSetDefault("log_level", "info")
+	config.BindEnvAndSetDefault("log_level_overrides", map[string]string{})
 	config.BindEnvAndSetDefault("log_to_syslog", false)
 	config.BindEnvAndSetDefault("log_to_console", true)
 	config.BindEnvAndSetDefault("logging_frequency", int64(500))
@@ -170,12 +200,18 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("syslog_tls_verify", true)
 	config.BindEnvAndSetDefault("cmd_host", "localhost")
 	config.BindEnvAndSetDefault("cmd_port", 5001)
+	config.BindEnvAndSetDefault("api_local_socket", "")
+	config.BindEnvAndSetDefault("api_local_port", 0)
+	config.BindEnvAndSetDefault("api_cors_allowed_origins", []string{})
+	config.BindEnvAndSetDefault("api_audit_log_size", 0)
 	config.BindEnvAndSetDefault("cluster_agent.cmd_port", 5005)
 	config.BindEnvAndSetDefault("default_integration_http_timeout", 9)
 	config.BindEnvAndSetDefault("enable_metadata_collection", true)
 	config.BindEnvAndSetDefault("enable_gohai", true)
 	config.BindEnvAndSetDefault("check_runners", int64(4))
 	config.BindEnvAndSetDefault("auth_token_file_path", "")
+	config.BindEnvAndSetDefault("auth_token_rotation_interval", 0)
+	config.BindEnvAndSetDefault("auth_token_grace_period", 300)
 	config.BindEnvAndSetDefault("bind_host", "localhost")
 	config.BindEnvAndSetDefault("ipc_address", "localhost")
 	config.BindEnvAndSetDefault("health_port", int64(0))
@@ -225,12 +261,15 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("secret_backend_output_max_size", secrets.SecretBackendOutputMaxSize)
 	config.BindEnvAndSetDefault("secret_backend_timeout", 5)
 	config.BindEnvAndSetDefault("secret_backend_command_allow_group_exec_perm", false)
+	config.BindEnvAndSetDefault("secret_backend_refresh_interval", 0)
 
 	// Use to output logs in JSON format
 	config.BindEnvAndSetDefault("log_format_json", false)
 
 	// IPC API server timeout
 	config.BindEnvAndSetDefault("server_timeout", 15)
+	// Time given to the in-flight requests to complete when the IPC API server stops
+	config.BindEnvAndSetDefault("server_shutdown_timeout", 5)
 
 	// Use to force client side TLS version to 1.2
 	config.BindEnvAndSetDefault("force_tls_12", false)
@@ -286,6 +325,14 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("histogram_percentiles", []string{"0.95"})
 	config.BindEnvAndSetDefault("aggregator_stop_timeout", 2)
 	config.BindEnvAndSetDefault("aggregator_buffer_size", 100)
+	_ = config.BindEnv("aggregator_downsampling_rules")
+	config.SetEnvKeyTransformer("aggregator_downsampling_rules", func(in string) interface{} {
+		var rules []DownsamplingRule
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"aggregator_downsampling_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
 	config.BindEnvAndSetDefault("basic_telemetry_add_container_tags", false) // configure adding the agent container tags to the basic agent telemetry metrics (e.g. `datadog.agent.running`)
 	// Serializer
 	config.BindEnvAndSetDefault("enable_stream_payload_serialization", true)
@@ -320,6 +367,15 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("forwarder_backoff_max", 64)
 	config.BindEnvAndSetDefault("forwarder_recovery_interval", DefaultForwarderRecoveryInterval)
 	config.BindEnvAndSetDefault("forwarder_recovery_reset", false)
+	// Forwarder tenant routes: send the data matching tag selectors to other orgs
+	_ = config.BindEnv("forwarder_tenant_routes")
+	config.SetEnvKeyTransformer("forwarder_tenant_routes", func(in string) interface{} {
+		var routes []TenantRoute
+		if err := json.Unmarshal([]byte(in), &routes); err != nil {
+			log.Errorf(`"forwarder_tenant_routes" can not be parsed: %v`, err)
+		}
+		return routes
+	})
 
 	// Dogstatsd
 	config.BindEnvAndSetDefault("use_dogstatsd", true)
@@ -457,6 +513,7 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("cluster_agent.url", "")
 	config.BindEnvAndSetDefault("cluster_agent.kubernetes_service_name", "datadog-cluster-agent")
 	config.BindEnvAndSetDefault("cluster_agent.tagging_fallback", false)
+	config.BindEnvAndSetDefault("cluster_agent.watch_configs", true) // stream the cluster and endpoints checks configs instead of polling them
 	config.BindEnvAndSetDefault("metrics_port", "5000")
 
 	// Metadata endpoints
@@ -521,6 +578,38 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("profiling.enabled", false)
 	config.BindEnv("profiling.profile_dd_url", "") //nolint:errcheck
 
+	// Self-monitoring watchdog
+	config.BindEnvAndSetDefault("watchdog.enabled", false)
+	config.BindEnvAndSetDefault("watchdog.max_cpu_percent", 0.0)
+	config.BindEnvAndSetDefault("watchdog.max_rss", 0)
+	config.BindEnvAndSetDefault("watchdog.check_interval", 10)
+	config.BindEnvAndSetDefault("watchdog.tolerance", 3)
+
+	// Cgroup of the processes spawned by the checks
+	config.BindEnvAndSetDefault("subprocess_cgroup.enabled", false)
+	config.BindEnvAndSetDefault("subprocess_cgroup.name", "datadog-agent-subprocesses")
+	config.BindEnvAndSetDefault("subprocess_cgroup.max_cpu_percent", 0.0)
+	config.BindEnvAndSetDefault("subprocess_cgroup.max_memory", 0)
+
+	// Remote configuration
+	config.BindEnvAndSetDefault("remote_configuration.enabled", false)
+	config.BindEnv("remote_configuration.dd_url", "") //nolint:errcheck
+	config.BindEnvAndSetDefault("remote_configuration.refresh_interval", 60)
+	config.BindEnvAndSetDefault("remote_configuration.public_keys", []string{})
+	config.BindEnvAndSetDefault("remote_configuration.allowed_settings", []string{
+		"log_level",
+		"dogstatsd_stats_enable",
+		"apm_config.max_traces_per_second",
+		"apm_config.extra_sample_rate",
+		"apm_config.analyzed_rate_by_service",
+	})
+
+	// Configuration bundle
+	config.BindEnvAndSetDefault("config_bundle.source", "")
+	config.BindEnvAndSetDefault("config_bundle.public_keys", []string{})
+	config.BindEnvAndSetDefault("config_bundle.encryption_key_file", "")
+	config.BindEnvAndSetDefault("config_bundle.dir", "")
+
 	// Process agent
 	config.SetDefault("process_config.enabled", "false")
 	// process_config.enabled is only used on Windows by the core agent to start the process agent service.
@@ -540,6 +629,8 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("log_enabled", false) // deprecated, use logs_enabled instead
 	// collect all logs from all containers:
 	config.BindEnvAndSetDefault("logs_config.container_collect_all", false)
+	// collect the logs of the journal of the host when journald is available:
+	config.BindEnvAndSetDefault("logs_config.journald_collect_all", false)
 	// add a socks5 proxy:
 	config.BindEnvAndSetDefault("logs_config.socks5_proxy_address", "")
 	// send the logs to a proxy:
@@ -656,6 +747,13 @@ func InitConfig(config Config) {
 	config.SetKnown("proxy.http")
 	config.SetKnown("proxy.https")
 	config.SetKnown("proxy.no_proxy")
+	config.SetKnown("proxy.endpoints")
+	for _, key := range proxyOverrideKeys {
+		config.BindEnv(key + ".http")     //nolint:errcheck
+		config.BindEnv(key + ".https")    //nolint:errcheck
+		config.BindEnv(key + ".no_proxy") //nolint:errcheck
+		config.SetKnown(key + ".endpoints")
+	}
 
 	// Orchestrator Explorer DCA and process-agent
 	config.BindEnvAndSetDefault("orchestrator_explorer.enabled", false)
@@ -710,6 +808,7 @@ func InitConfig(config Config) {
 	config.SetKnown("system_probe_config.sysprobe_socket")
 	config.SetKnown("system_probe_config.conntrack_rate_limit")
 	config.SetKnown("system_probe_config.enable_conntrack_all_namespaces")
+	config.SetKnown("system_probe_config.enable_ebpf_conntracker")
 	config.SetKnown("system_probe_config.max_conns_per_message")
 	config.SetKnown("system_probe_config.max_tracked_connections")
 	config.SetKnown("system_probe_config.max_closed_connections_buffered")
@@ -717,16 +816,30 @@ func InitConfig(config Config) {
 	config.SetKnown("system_probe_config.excluded_linux_versions")
 	config.SetKnown("system_probe_config.source_excludes")
 	config.SetKnown("system_probe_config.dest_excludes")
+	config.SetKnown("system_probe_config.ebpf_tunables")
 	config.SetKnown("system_probe_config.closed_channel_size")
 	config.SetKnown("system_probe_config.dns_timeout_in_s")
+	config.SetKnown("system_probe_config.udp_conn_timeout_in_s")
 	config.SetKnown("system_probe_config.collect_dns_stats")
 	config.SetKnown("system_probe_config.offset_guess_threshold")
 	config.SetKnown("system_probe_config.enable_tcp_queue_length")
 	config.SetKnown("system_probe_config.enable_oom_kill")
+	config.SetKnown("system_probe_config.enable_uprobes")
+	config.SetKnown("system_probe_config.uprobes")
 	config.SetKnown("system_probe_config.enable_tracepoints")
+	config.SetKnown("system_probe_config.kernel_header_dirs")
+	config.SetKnown("system_probe_config.enable_kernel_header_download")
+	config.SetKnown("system_probe_config.kernel_header_download_dir")
+	config.SetKnown("system_probe_config.kernel_header_mirrors")
+	config.SetKnown("system_probe_config.kernel_header_download_keyring")
+	config.SetKnown("system_probe_config.enable_runtime_compiler")
+	config.SetKnown("system_probe_config.runtime_compiler_output_dir")
 	config.SetKnown("system_probe_config.windows.enable_monotonic_count")
 	config.SetKnown("system_probe_config.windows.driver_buffer_size")
 	config.SetKnown("network_config.enabled")
+	config.SetKnown("network_config.resolve_kube_services")
+	config.SetKnown("network_config.enable_connection_rollups")
+	config.SetKnown("network_config.enable_tls_metadata")
 
 	// Network
 	config.BindEnv("network.id") //nolint:errcheck
@@ -736,6 +849,11 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("inventories_max_interval", 600) // 10min
 	config.BindEnvAndSetDefault("inventories_min_interval", 300) // 5min
 
+	// metric metadata
+	config.BindEnvAndSetDefault("metric_metadata_enabled", true)
+	config.BindEnvAndSetDefault("metric_metadata_max_interval", 3600) // 1h
+	config.BindEnvAndSetDefault("metric_metadata_min_interval", 300)  // 5min
+
 	// Datadog security agent (common)
 	config.BindEnvAndSetDefault("security_agent.cmd_port", 5010)
 	config.BindEnvAndSetDefault("security_agent.expvar_port", 5011)
@@ -750,6 +868,9 @@ func InitConfig(config Config) {
 	// Datadog security agent (runtime)
 	config.BindEnvAndSetDefault("runtime_security_config.enabled", false)
 	config.BindEnvAndSetDefault("runtime_security_config.policies.dir", DefaultRuntimePoliciesDir)
+	config.BindEnvAndSetDefault("runtime_security_config.policies.watch_cluster_agent", false)
+	config.BindEnvAndSetDefault("runtime_security_config.policies.cluster_dir", filepath.Join(defaultRunPath, "runtime-security", "cluster-policies"))
+	config.BindEnvAndSetDefault("runtime_security_config.policies.custom_resources.enabled", false)
 	config.BindEnvAndSetDefault("runtime_security_config.socket", "/opt/datadog-agent/run/runtime-security.sock")
 	config.BindEnvAndSetDefault("runtime_security_config.enable_kernel_filters", true)
 	config.BindEnvAndSetDefault("runtime_security_config.syscall_monitor.enabled", false)
@@ -759,7 +880,42 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("runtime_security_config.load_controller.events_count_threshold", 20000)
 	config.BindEnvAndSetDefault("runtime_security_config.load_controller.discarder_timeout", 10)
 	config.BindEnvAndSetDefault("runtime_security_config.load_controller.control_period", 2)
+	config.BindEnvAndSetDefault("runtime_security_config.exec_dedup.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.exec_dedup.window", 5)
+	config.BindEnvAndSetDefault("runtime_security_config.process_lifetime.enabled", true)
+	config.BindEnvAndSetDefault("runtime_security_config.event_queue.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.event_queue.size", 4096)
 	config.BindEnvAndSetDefault("runtime_security_config.pid_cache_size", 10000)
+	config.BindEnvAndSetDefault("runtime_security_config.snapshot.async", false)
+	config.BindEnvAndSetDefault("runtime_security_config.snapshot.scan_rate", 500)
+	config.BindEnvAndSetDefault("runtime_security_config.heartbeat.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.heartbeat.period", 60)
+	config.BindEnvAndSetDefault("runtime_security_config.enforcement_enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.event_store.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.event_store.dir", filepath.Join(defaultRunPath, "runtime-security", "events"))
+	config.BindEnvAndSetDefault("runtime_security_config.event_store.max_size", 100)
+	config.BindEnvAndSetDefault("runtime_security_config.event_recorder.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.event_recorder.file", filepath.Join(defaultRunPath, "runtime-security", "events.rec"))
+	config.BindEnvAndSetDefault("runtime_security_config.event_recorder.max_size", 100)
+	config.BindEnvAndSetDefault("runtime_security_config.event_recorder.max_duration", 600)
+	config.BindEnvAndSetDefault("runtime_security_config.rate_limiter.rate", 10)
+	config.BindEnvAndSetDefault("runtime_security_config.rate_limiter.burst", 40)
+	config.BindEnvAndSetDefault("runtime_security_config.event_dedup.enabled", true)
+	config.BindEnvAndSetDefault("runtime_security_config.event_dedup.window", 5)
+	config.BindEnvAndSetDefault("runtime_security_config.event_dedup.size", 4096)
+	config.BindEnvAndSetDefault("runtime_security_config.fim.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.fim.patterns", []string{})
+	config.BindEnvAndSetDefault("runtime_security_config.fim.hash_delay", 1)
+	config.BindEnvAndSetDefault("runtime_security_config.fim.max_file_size", 100)
+	config.BindEnvAndSetDefault("runtime_security_config.fim.cache_size", 10000)
+	config.BindEnvAndSetDefault("runtime_security_config.dentry_prefetch.directories", []string{})
+	config.BindEnvAndSetDefault("runtime_security_config.dentry_prefetch.max_entries", 4096)
+	config.BindEnvAndSetDefault("runtime_security_config.event_forwarder.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.event_forwarder.batch_size", 100)
+	config.BindEnvAndSetDefault("runtime_security_config.event_forwarder.batch_wait", 5)
+	config.BindEnvAndSetDefault("runtime_security_config.event_forwarder.compression_level", 6)
+	config.BindEnvAndSetDefault("runtime_security_config.event_forwarder.queue_dir", filepath.Join(defaultRunPath, "runtime-security", "forwarder"))
+	config.BindEnvAndSetDefault("runtime_security_config.event_forwarder.queue_max_size", 100)
 
 	// command line options
 	config.SetKnown("cmd.check.fullsketches")
@@ -893,10 +1049,31 @@ func load(config Config, origin string, loadSecret bool) (*Warnings, error) {
 		return &warnings, err
 	}
 
-	for _, key := range findUnknownKeys(config) {
+	warnings.UnknownKeys = findUnknownKeys(config)
+	for _, key := range warnings.UnknownKeys {
 		log.Warnf("Unknown key in config file: %v", key)
 	}
 
+	// the values are validated before being coerced to the type of their key
+	schemaErrors, deprecatedKeys := validateSchema(config, schemas)
+	warnings.DeprecatedKeys = deprecatedKeys
+	for key, replacement := range deprecatedKeys {
+		log.Warnf("Deprecated key in config: %s, use %s instead", key, replacement)
+	}
+	// the environment variables are shared by all the agents of a host, so only the invalid settings of the
+	// configuration files stop the agent, the invalid environment variables are reported as warnings
+	var fileErrors SchemaErrors
+	for _, schemaError := range schemaErrors {
+		if schemaError.Source == SourceEnvVar {
+			log.Warnf("Ignoring %s", schemaError)
+			continue
+		}
+		fileErrors = append(fileErrors, schemaError)
+	}
+	if len(fileErrors) > 0 {
+		return &warnings, fileErrors
+	}
+
 	if loadSecret {
 		if err := ResolveSecrets(config, origin); err != nil {
 			return &warnings, err
@@ -923,11 +1100,13 @@ func load(config Config, origin string, loadSecret bool) (*Warnings, error) {
 	// setTracemallocEnabled *must* be called before setNumWorkers
 	warnings.TraceMallocEnabledWithPy2 = setTracemallocEnabled(config)
 	setNumWorkers(config)
+	detectFeatures(config)
 	return &warnings, nil
 }
 
 // ResolveSecrets merges all the secret values from origin into config. Secret values
-// are identified by a value of the form "ENC[key]" where key is the secret key.
+// are identified by a value of the form "ENC[key]" where key is the secret key, and can
+// be used by any setting, including in its lists and maps.
 // See: https://github.com/DataDog/datadog-agent/blob/master/docs/agent/secrets.md
 func ResolveSecrets(config Config, origin string) error {
 	// We have to init the secrets package before we can use it to decrypt
@@ -940,25 +1119,20 @@ func ResolveSecrets(config Config, origin string) error {
 		config.GetBool("secret_backend_command_allow_group_exec_perm"),
 	)
 
-	if config.GetString("secret_backend_command") != "" {
-		// Viper doesn't expose the final location of the file it
-		// loads. Since we are searching for 'datadog.yaml' in multiple
-		// locations we let viper determine the one to use before
-		// updating it.
-		yamlConf, err := yaml.Marshal(config.AllSettings())
-		if err != nil {
-			return fmt.Errorf("unable to marshal configuration to YAML to decrypt secrets: %v", err)
-		}
+	if config.GetString("secret_backend_command") == "" {
+		return nil
+	}
 
-		finalYamlConf, err := secrets.Decrypt(yamlConf, origin)
-		if err != nil {
-			return fmt.Errorf("unable to decrypt secret from datadog.yaml: %v", err)
-		}
-		r := bytes.NewReader(finalYamlConf)
-		if err = config.MergeConfigOverride(r); err != nil {
-			return fmt.Errorf("could not update main configuration after decrypting secrets: %v", err)
-		}
+	// Only the settings referencing secrets are resolved and overridden, so that the
+	// other settings keep their source
+	settings := encryptedSettings(config)
+	if len(settings) == 0 {
+		return nil
 	}
+	if err := resolveSettings(config, settings, origin); err != nil {
+		return err
+	}
+	trackEncryptedSettings(config, settings, origin)
 	return nil
 }
 
@@ -1177,8 +1351,20 @@ func GetEnv(key, def string) string {
 	return value
 }
 
-// IsKubernetes returns whether the Agent is running on a kubernetes cluster
+// IsKubernetes returns whether the Agent is running on a kubernetes cluster, unless the
+// kubernetes feature is forced or excluded
 func IsKubernetes() bool {
+	if IsFeatureExcluded(Kubernetes) {
+		return false
+	}
+	if IsFeaturePresent(Kubernetes) {
+		return true
+	}
+	return isKubernetesEnv()
+}
+
+// isKubernetesEnv returns whether the environment variables of a kubernetes pod are set
+func isKubernetesEnv() bool {
 	// Injected by Kubernetes itself
 	if os.Getenv("KUBERNETES_SERVICE_PORT") != "" {
 		return true
@@ -1248,6 +1434,77 @@ func setNumWorkers(config Config) {
 	config.Set("check_runners", numWorkers)
 }
 
+// GetTenantRoutes returns the routes sending data to other orgs based on their tags
+func GetTenantRoutes() ([]TenantRoute, error) {
+	return getTenantRoutesWithConfig(Datadog)
+}
+
+func getTenantRoutesWithConfig(config Config) ([]TenantRoute, error) {
+	var routes []TenantRoute
+	if !config.IsSet("forwarder_tenant_routes") {
+		return routes, nil
+	}
+
+	if err := config.UnmarshalKey("forwarder_tenant_routes", &routes); err != nil {
+		return nil, fmt.Errorf("could not parse forwarder_tenant_routes: %v", err)
+	}
+
+	names := make(map[string]bool, len(routes))
+	for i, route := range routes {
+		if route.Name == "" {
+			return nil, fmt.Errorf("forwarder tenant route #%d has no name", i)
+		}
+		if names[route.Name] {
+			return nil, fmt.Errorf("forwarder tenant route %q is defined more than once", route.Name)
+		}
+		names[route.Name] = true
+
+		routes[i].APIKey = SanitizeAPIKey(route.APIKey)
+		if routes[i].APIKey == "" {
+			return nil, fmt.Errorf("forwarder tenant route %q has no API key", route.Name)
+		}
+		if len(route.TagSelectors) == 0 {
+			return nil, fmt.Errorf("forwarder tenant route %q has no tag selector", route.Name)
+		}
+
+		if route.DDURL == "" {
+			routes[i].DDURL = getMainInfraEndpointWithConfig(config)
+		} else if _, err := url.Parse(route.DDURL); err != nil {
+			return nil, fmt.Errorf("could not parse the url of the forwarder tenant route %q: %s", route.Name, err)
+		}
+	}
+	return routes, nil
+}
+
+// GetDownsamplingRules returns the rules aggregating the dogstatsd metrics before they are sent
+func GetDownsamplingRules() ([]DownsamplingRule, error) {
+	return getDownsamplingRulesWithConfig(Datadog)
+}
+
+func getDownsamplingRulesWithConfig(config Config) ([]DownsamplingRule, error) {
+	var rules []DownsamplingRule
+	if !config.IsSet("aggregator_downsampling_rules") {
+		return rules, nil
+	}
+
+	if err := config.UnmarshalKey("aggregator_downsampling_rules", &rules); err != nil {
+		return nil, fmt.Errorf("could not parse aggregator_downsampling_rules: %v", err)
+	}
+
+	for i, rule := range rules {
+		if rule.Match == "" {
+			return nil, fmt.Errorf("downsampling rule #%d has no metric name to match", i)
+		}
+		if rule.Interval < 0 {
+			return nil, fmt.Errorf("downsampling rule %q has a negative interval", rule.Match)
+		}
+		if len(rule.DropTags) == 0 && rule.Interval == 0 && !rule.ToDistribution {
+			return nil, fmt.Errorf("downsampling rule %q doesn't change the metrics", rule.Match)
+		}
+	}
+	return rules, nil
+}
+
 // GetDogstatsdMappingProfiles returns mapping profiles used in DogStatsD mapper
 func GetDogstatsdMappingProfiles() ([]MappingProfile, error) {
 	return getDogstatsdMappingProfilesConfig(Datadog)
@@ -904,3 +904,125 @@ func TestDogstatsdMappingProfilesEnv(t *testing.T) {
 	mappings, _ := GetDogstatsdMappingProfiles()
 	assert.Equal(t, mappings, expected)
 }
+
+func TestTenantRoutes(t *testing.T) {
+	datadogYaml := `
+site: datadoghq.eu
+forwarder_tenant_routes:
+  - name: team-a
+    api_key: " abcdef "
+    tag_selectors:
+      - kube_namespace:team-a
+  - name: team-b
+    api_key: "123456"
+    dd_url: "https://app.datadoghq.com"
+    tag_selectors:
+      - kube_namespace:team-b-*
+      - team:b
+`
+	testConfig := setupConfFromYAML(datadogYaml)
+
+	routes, err := getTenantRoutesWithConfig(testConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, []TenantRoute{
+		{
+			Name:         "team-a",
+			APIKey:       "abcdef",
+			DDURL:        "https://app.datadoghq.eu",
+			TagSelectors: []string{"kube_namespace:team-a"},
+		},
+		{
+			Name:         "team-b",
+			APIKey:       "123456",
+			DDURL:        "https://app.datadoghq.com",
+			TagSelectors: []string{"kube_namespace:team-b-*", "team:b"},
+		},
+	}, routes)
+}
+
+func TestTenantRoutesError(t *testing.T) {
+	for name, datadogYaml := range map[string]string{
+		"no name": `
+forwarder_tenant_routes:
+  - api_key: abcdef
+    tag_selectors: [kube_namespace:team-a]
+`,
+		"duplicated name": `
+forwarder_tenant_routes:
+  - name: team-a
+    api_key: abcdef
+    tag_selectors: [kube_namespace:team-a]
+  - name: team-a
+    api_key: abcdef
+    tag_selectors: [kube_namespace:team-b]
+`,
+		"no API key": `
+forwarder_tenant_routes:
+  - name: team-a
+    tag_selectors: [kube_namespace:team-a]
+`,
+		"no tag selector": `
+forwarder_tenant_routes:
+  - name: team-a
+    api_key: abcdef
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			testConfig := setupConfFromYAML(datadogYaml)
+			routes, err := getTenantRoutesWithConfig(testConfig)
+			assert.NotNil(t, err)
+			assert.Empty(t, routes)
+		})
+	}
+}
+
+func TestDownsamplingRules(t *testing.T) {
+	datadogYaml := `
+aggregator_downsampling_rules:
+  - match: "checkout.request.*"
+    drop_tags: [request_id, user_id]
+    interval: 60
+  - match: checkout.latency
+    to_distribution: true
+`
+	testConfig := setupConfFromYAML(datadogYaml)
+
+	rules, err := getDownsamplingRulesWithConfig(testConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, []DownsamplingRule{
+		{
+			Match:    "checkout.request.*",
+			DropTags: []string{"request_id", "user_id"},
+			Interval: 60,
+		},
+		{
+			Match:          "checkout.latency",
+			ToDistribution: true,
+		},
+	}, rules)
+}
+
+func TestDownsamplingRulesError(t *testing.T) {
+	for name, datadogYaml := range map[string]string{
+		"no match": `
+aggregator_downsampling_rules:
+  - drop_tags: [request_id]
+`,
+		"negative interval": `
+aggregator_downsampling_rules:
+  - match: checkout.*
+    interval: -10
+`,
+		"no change": `
+aggregator_downsampling_rules:
+  - match: checkout.*
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			testConfig := setupConfFromYAML(datadogYaml)
+			rules, err := getDownsamplingRulesWithConfig(testConfig)
+			assert.NotNil(t, err)
+			assert.Empty(t, rules)
+		})
+	}
+}
@@ -0,0 +1,354 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Feature represents a feature of the environment the agent runs in
+type Feature string
+
+// FeatureMap represents all the detected features
+type FeatureMap map[Feature]struct{}
+
+const (
+	// CloudAWS is present when the host runs on Amazon Web Services
+	CloudAWS Feature = "aws"
+	// CloudGCP is present when the host runs on Google Cloud Platform
+	CloudGCP Feature = "gcp"
+	// CloudAzure is present when the host runs on Microsoft Azure
+	CloudAzure Feature = "azure"
+	// KernelLockdown is present when the kernel runs in lockdown mode, which restricts the eBPF programs
+	KernelLockdown Feature = "kernel_lockdown"
+	// SecureBoot is present when the host booted with UEFI secure boot
+	SecureBoot Feature = "secure_boot"
+	// InitSystemd is present when the host booted with systemd as its init system
+	InitSystemd Feature = "systemd"
+	// Journald is present when the journald socket of the host is available
+	Journald Feature = "journald"
+	// NvidiaGPU is present when the NVIDIA devices are available
+	NvidiaGPU Feature = "nvidia_gpu"
+	// NVML is present when the NVIDIA Management Library, used by nvidia-smi, is installed
+	NVML Feature = "nvml"
+	// AMDGPU is present when the ROCm kernel driver exposes at least one GPU
+	AMDGPU Feature = "amd_gpu"
+	// Docker is present when the Docker daemon is configured through $DOCKER_HOST or its socket exists
+	Docker Feature = "docker"
+	// Kubernetes is present when the agent runs in a Kubernetes pod
+	Kubernetes Feature = "kubernetes"
+)
+
+// knownFeatures are the features `autoconfig_include_features` and `autoconfig_exclude_features` accept
+var knownFeatures = FeatureMap{
+	CloudAWS:       struct{}{},
+	CloudGCP:       struct{}{},
+	CloudAzure:     struct{}{},
+	KernelLockdown: struct{}{},
+	SecureBoot:     struct{}{},
+	InitSystemd:    struct{}{},
+	Journald:       struct{}{},
+	NvidiaGPU:      struct{}{},
+	NVML:           struct{}{},
+	AMDGPU:         struct{}{},
+	Docker:         struct{}{},
+	Kubernetes:     struct{}{},
+}
+
+// azureChassisAssetTag is the chassis asset tag of all the Azure virtual machines
+const azureChassisAssetTag = "7783-7084-3265-9085-8269-3286-77"
+
+var (
+	detectedFeatures FeatureMap
+	excludedFeatures FeatureMap
+	featureLock      sync.RWMutex
+
+	// dmiPath is where the SMBIOS (DMI) data of the host is exposed
+	dmiPath = "/sys/class/dmi/id"
+	// hypervisorUUIDPath holds the UUID of the Xen based EC2 instances, which don't expose DMI data
+	hypervisorUUIDPath = "/sys/hypervisor/uuid"
+	// lockdownPath exposes the lockdown mode of the kernel when the lockdown LSM is enabled
+	lockdownPath = "/sys/kernel/security/lockdown"
+	// secureBootPath is the EFI variable holding the secure boot state
+	secureBootPath = "/sys/firmware/efi/efivars/SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c"
+	// systemdRuntimePath only exists when the host booted with systemd, see sd_booted(3)
+	systemdRuntimePath = "/run/systemd/system"
+	// journaldSocketPath is the socket the journald daemon receives the native logs on
+	journaldSocketPath = "/run/systemd/journal/socket"
+	// nvidiaDevicesPattern matches the devices of the NVIDIA GPUs, the /dev/nvidiactl and /dev/nvidia-uvm
+	// devices existing without any GPU once the driver is loaded
+	nvidiaDevicesPattern = "/dev/nvidia[0-9]*"
+	// nvmlLibraryName is the name of the NVIDIA Management Library
+	nvmlLibraryName = "libnvidia-ml.so.1"
+	// nvmlLibraryDirs are the directories the NVIDIA Management Library is installed in by the drivers,
+	// the packages of the distributions and the NVIDIA container toolkit, in addition to $LD_LIBRARY_PATH
+	nvmlLibraryDirs = []string{
+		"/usr/lib64",
+		"/usr/lib",
+		"/usr/lib/x86_64-linux-gnu",
+		"/usr/lib/aarch64-linux-gnu",
+		"/usr/lib/powerpc64le-linux-gnu",
+		"/usr/local/nvidia/lib64",
+		"/usr/local/nvidia/lib",
+	}
+	// kfdTopologyPath lists the nodes of the ROCm kernel fusion driver, the CPUs and the GPUs
+	kfdTopologyPath = "/sys/class/kfd/kfd/topology/nodes"
+	// dockerSocketPath is the default socket of the Docker daemon
+	dockerSocketPath = "/var/run/docker.sock"
+	// hostRootPath is where the root file system of the host is mounted when the agent runs in a container
+	hostRootPath = "/host"
+	// cloudMetadataURL is the base URL of the metadata endpoints of the cloud providers
+	cloudMetadataURL = "http://169.254.169.254"
+	// cloudMetadataTimeout is the timeout of the requests to the metadata endpoints
+	cloudMetadataTimeout = 300 * time.Millisecond
+)
+
+// GetDetectedFeatures returns the features detected when the configuration was loaded
+func GetDetectedFeatures() FeatureMap {
+	featureLock.RLock()
+	defer featureLock.RUnlock()
+
+	features := make(FeatureMap, len(detectedFeatures))
+	for feature := range detectedFeatures {
+		features[feature] = struct{}{}
+	}
+	return features
+}
+
+// IsFeaturePresent returns whether the given feature was detected
+func IsFeaturePresent(feature Feature) bool {
+	featureLock.RLock()
+	defer featureLock.RUnlock()
+
+	_, found := detectedFeatures[feature]
+	return found
+}
+
+// IsFeatureExcluded returns whether the given feature is listed in `autoconfig_exclude_features`, the
+// integrations relying on their own detection of the feature shouldn't use it
+func IsFeatureExcluded(feature Feature) bool {
+	featureLock.RLock()
+	defer featureLock.RUnlock()
+
+	_, found := excludedFeatures[feature]
+	return found
+}
+
+// detectFeatures detects the features of the environment. It's called once, when the configuration is loaded.
+// The features listed in `autoconfig_include_features` are always present, the ones listed in
+// `autoconfig_exclude_features` never are, whether they're detected or not.
+func detectFeatures(config Config) {
+	features := make(FeatureMap)
+
+	if cloud := detectCloudFromDMI(); cloud != "" {
+		features[cloud] = struct{}{}
+	} else if cloud := detectCloudFromMetadata(config); cloud != "" {
+		features[cloud] = struct{}{}
+	}
+
+	if mode := GetKernelLockdownMode(); mode != "" && mode != "none" {
+		features[KernelLockdown] = struct{}{}
+	}
+	if IsSecureBootEnabled() {
+		features[SecureBoot] = struct{}{}
+	}
+	detectInitFeatures(features)
+	detectGPUFeatures(features)
+	if IsDockerAvailable() {
+		features[Docker] = struct{}{}
+	}
+	if isKubernetesEnv() {
+		features[Kubernetes] = struct{}{}
+	}
+
+	for feature := range parseFeatureList(config, "autoconfig_include_features") {
+		features[feature] = struct{}{}
+	}
+	excluded := parseFeatureList(config, "autoconfig_exclude_features")
+	for feature := range excluded {
+		delete(features, feature)
+	}
+
+	featureLock.Lock()
+	detectedFeatures = features
+	excludedFeatures = excluded
+	featureLock.Unlock()
+
+	log.Infof("Features detected from environment: %v", features)
+}
+
+// parseFeatureList returns the features listed in the given setting, ignoring the unknown ones
+func parseFeatureList(config Config, key string) FeatureMap {
+	features := make(FeatureMap)
+	for _, name := range config.GetStringSlice(key) {
+		feature := Feature(strings.ToLower(strings.TrimSpace(name)))
+		if _, found := knownFeatures[feature]; !found {
+			log.Warnf("Ignoring the unknown feature %q of %s, the known features are: %s", name, key, strings.Join(knownFeatureNames(), ", "))
+			continue
+		}
+		features[feature] = struct{}{}
+	}
+	return features
+}
+
+// knownFeatureNames returns the sorted names of the known features
+func knownFeatureNames() []string {
+	names := make([]string, 0, len(knownFeatures))
+	for feature := range knownFeatures {
+		names = append(names, string(feature))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsDockerAvailable returns whether the Docker daemon is configured through $DOCKER_HOST, or whether
+// its default socket exists
+func IsDockerAvailable() bool {
+	if os.Getenv("DOCKER_HOST") != "" {
+		return true
+	}
+	_, err := os.Stat(dockerSocketPath)
+	return err == nil
+}
+
+func readDMIField(name string) string {
+	content, err := ioutil.ReadFile(filepath.Join(dmiPath, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// detectCloudFromDMI detects the cloud provider from the SMBIOS data of the host, which
+// doesn't require any network call
+func detectCloudFromDMI() Feature {
+	sysVendor := readDMIField("sys_vendor")
+	productName := readDMIField("product_name")
+	biosVendor := readDMIField("bios_vendor")
+
+	switch {
+	case sysVendor == "Amazon EC2" || biosVendor == "Amazon EC2" || strings.HasPrefix(readDMIField("board_asset_tag"), "i-"):
+		return CloudAWS
+	case sysVendor == "Google" || productName == "Google Compute Engine":
+		return CloudGCP
+	case sysVendor == "Microsoft Corporation" && readDMIField("chassis_asset_tag") == azureChassisAssetTag:
+		return CloudAzure
+	}
+
+	// older Xen based EC2 instances
+	if content, err := ioutil.ReadFile(hypervisorUUIDPath); err == nil && strings.HasPrefix(strings.ToLower(string(content)), "ec2") {
+		return CloudAWS
+	}
+
+	return ""
+}
+
+// cloudMetadataProbe describes a request to the metadata endpoint of a cloud provider
+type cloudMetadataProbe struct {
+	feature       Feature
+	path          string
+	header        string
+	value         string
+	matchResponse func(*http.Response) bool
+}
+
+var cloudMetadataProbes = []cloudMetadataProbe{
+	{
+		feature: CloudAWS,
+		path:    "/latest/meta-data/",
+		// IMDSv2 only instances reply with a 401 to requests without a token
+		matchResponse: func(resp *http.Response) bool {
+			return resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusUnauthorized
+		},
+	},
+	{
+		feature: CloudGCP,
+		path:    "/computeMetadata/v1/",
+		header:  "Metadata-Flavor",
+		value:   "Google",
+		matchResponse: func(resp *http.Response) bool {
+			return resp.Header.Get("Metadata-Flavor") == "Google"
+		},
+	},
+	{
+		feature: CloudAzure,
+		path:    "/metadata/instance?api-version=2017-04-02",
+		header:  "Metadata",
+		value:   "true",
+		matchResponse: func(resp *http.Response) bool {
+			return resp.StatusCode == http.StatusOK
+		},
+	},
+}
+
+// detectCloudFromMetadata detects the cloud provider by querying the metadata endpoints of
+// the cloud providers enabled in `cloud_provider_metadata`, in parallel
+func detectCloudFromMetadata(config Config) Feature {
+	client := http.Client{
+		Timeout: cloudMetadataTimeout,
+		// a redirection means we're not talking to a metadata endpoint
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	enabled := make(map[string]bool)
+	for _, name := range config.GetStringSlice("cloud_provider_metadata") {
+		enabled[strings.ToLower(name)] = true
+	}
+
+	results := make(chan Feature, len(cloudMetadataProbes))
+	var wg sync.WaitGroup
+	for _, probe := range cloudMetadataProbes {
+		if !enabled[string(probe.feature)] {
+			continue
+		}
+
+		wg.Add(1)
+		go func(probe cloudMetadataProbe) {
+			defer wg.Done()
+
+			req, err := http.NewRequest(http.MethodGet, cloudMetadataURL+probe.path, nil)
+			if err != nil {
+				return
+			}
+			if probe.header != "" {
+				req.Header.Set(probe.header, probe.value)
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+
+			if probe.matchResponse(resp) {
+				results <- probe.feature
+			}
+		}(probe)
+	}
+	wg.Wait()
+	close(results)
+
+	// the GCP and Azure endpoints may reply to the AWS request, prefer the most specific match
+	var detected Feature
+	for feature := range results {
+		if detected == "" || detected == CloudAWS {
+			detected = feature
+		}
+	}
+
+	return detected
+}
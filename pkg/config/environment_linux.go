@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package config
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GetKernelLockdownMode returns the lockdown mode of the kernel: none, integrity or confidentiality.
+// It returns an empty string when the kernel doesn't support the lockdown.
+func GetKernelLockdownMode() string {
+	content, err := ioutil.ReadFile(lockdownPath)
+	if err != nil {
+		return ""
+	}
+	return parseLockdownMode(string(content))
+}
+
+// parseLockdownMode returns the active mode, enclosed in brackets, like in `none [integrity] confidentiality`
+func parseLockdownMode(modes string) string {
+	start, end := strings.IndexByte(modes, '['), strings.IndexByte(modes, ']')
+	if start < 0 || end <= start {
+		return ""
+	}
+	return modes[start+1 : end]
+}
+
+// IsSecureBootEnabled returns whether the host booted with UEFI secure boot
+func IsSecureBootEnabled() bool {
+	content, err := ioutil.ReadFile(secureBootPath)
+	// the EFI variable holds 4 bytes of attributes followed by its value
+	return err == nil && len(content) == 5 && content[4] == 1
+}
+
+// hostPath returns where the given path of the host is visible from the agent, which is
+// under the host root mount point when the agent runs in a container
+func hostPath(path string) string {
+	if IsContainerized() {
+		return filepath.Join(hostRootPath, path)
+	}
+	return path
+}
+
+// IsSystemdInit returns whether the host booted with systemd as its init system
+func IsSystemdInit() bool {
+	info, err := os.Stat(hostPath(systemdRuntimePath))
+	return err == nil && info.IsDir()
+}
+
+// IsJournaldAvailable returns whether the journald socket of the host exists
+func IsJournaldAvailable() bool {
+	info, err := os.Stat(hostPath(journaldSocketPath))
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
+
+// detectInitFeatures adds the features of the init system of the host. Journald may run
+// without systemd being the init system, in some containers for instance.
+func detectInitFeatures(features FeatureMap) {
+	if IsSystemdInit() {
+		features[InitSystemd] = struct{}{}
+	}
+	if IsJournaldAvailable() {
+		features[Journald] = struct{}{}
+	}
+}
+
+// HasNvidiaGPU returns whether the devices of NVIDIA GPUs are available. The devices of the
+// GPUs allocated to the container are the only ones available when the agent runs in a container.
+func HasNvidiaGPU() bool {
+	devices, err := filepath.Glob(nvidiaDevicesPattern)
+	return err == nil && len(devices) > 0
+}
+
+// IsNVMLAvailable returns whether the NVIDIA Management Library is installed in one of the usual
+// directories or in the ones listed in $LD_LIBRARY_PATH
+func IsNVMLAvailable() bool {
+	dirs := append(filepath.SplitList(os.Getenv("LD_LIBRARY_PATH")), nvmlLibraryDirs...)
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, nvmlLibraryName)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAMDGPU returns whether the ROCm kernel driver exposes a GPU, the nodes of the GPUs being the
+// ones with SIMD units
+func HasAMDGPU() bool {
+	nodes, err := filepath.Glob(filepath.Join(kfdTopologyPath, "*", "properties"))
+	if err != nil {
+		return false
+	}
+	for _, node := range nodes {
+		if isKFDGPUNode(node) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKFDGPUNode returns whether the properties of a node of the kernel fusion driver, like
+// `simd_count 256`, describe a GPU
+func isKFDGPUNode(propertiesPath string) bool {
+	file, err := os.Open(propertiesPath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "simd_count" {
+			return fields[1] != "0"
+		}
+	}
+	return false
+}
+
+// detectGPUFeatures adds the features of the GPUs of the host, the GPU check being enabled by
+// default when one of them is present
+func detectGPUFeatures(features FeatureMap) {
+	if HasNvidiaGPU() {
+		features[NvidiaGPU] = struct{}{}
+	}
+	if IsNVMLAvailable() {
+		features[NVML] = struct{}{}
+	}
+	if HasAMDGPU() {
+		features[AMDGPU] = struct{}{}
+	}
+}
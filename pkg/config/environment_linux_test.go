@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package config
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLockdownMode(t *testing.T) {
+	assert.Equal(t, "none", parseLockdownMode("[none] integrity confidentiality\n"))
+	assert.Equal(t, "integrity", parseLockdownMode("none [integrity] confidentiality\n"))
+	assert.Equal(t, "confidentiality", parseLockdownMode("none integrity [confidentiality]\n"))
+	assert.Equal(t, "", parseLockdownMode("none integrity confidentiality\n"))
+	assert.Equal(t, "", parseLockdownMode(""))
+}
+
+func TestDetectKernelSecurityFeatures(t *testing.T) {
+	defer setupDMI(t, map[string]string{"sys_vendor": "QEMU"})()
+
+	// no cloud provider is detected without querying the metadata endpoints
+	config := setupConf()
+	config.Set("cloud_provider_metadata", []string{})
+
+	detectFeatures(config)
+	assert.Equal(t, "", GetKernelLockdownMode())
+	assert.False(t, IsSecureBootEnabled())
+	assert.Equal(t, FeatureMap{}, GetDetectedFeatures())
+
+	require.NoError(t, ioutil.WriteFile(lockdownPath, []byte("none [integrity] confidentiality\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(secureBootPath, []byte{0x06, 0x00, 0x00, 0x00, 0x01}, 0644))
+
+	detectFeatures(config)
+	assert.Equal(t, "integrity", GetKernelLockdownMode())
+	assert.True(t, IsSecureBootEnabled())
+	assert.Equal(t, FeatureMap{KernelLockdown: struct{}{}, SecureBoot: struct{}{}}, GetDetectedFeatures())
+
+	// neither the lockdown in none mode nor the secure boot disabled are reported
+	require.NoError(t, ioutil.WriteFile(lockdownPath, []byte("[none] integrity confidentiality\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(secureBootPath, []byte{0x06, 0x00, 0x00, 0x00, 0x00}, 0644))
+
+	detectFeatures(config)
+	assert.Equal(t, FeatureMap{}, GetDetectedFeatures())
+}
+
+func TestDetectInitFeatures(t *testing.T) {
+	defer setupDMI(t, map[string]string{"sys_vendor": "QEMU"})()
+
+	config := setupConf()
+	config.Set("cloud_provider_metadata", []string{})
+
+	detectFeatures(config)
+	assert.False(t, IsSystemdInit())
+	assert.False(t, IsJournaldAvailable())
+	assert.Equal(t, FeatureMap{}, GetDetectedFeatures())
+
+	// a regular file isn't the journald socket
+	require.NoError(t, ioutil.WriteFile(journaldSocketPath, nil, 0644))
+	assert.False(t, IsJournaldAvailable())
+	require.NoError(t, os.Remove(journaldSocketPath))
+
+	require.NoError(t, os.Mkdir(systemdRuntimePath, 0755))
+	listener, err := net.Listen("unix", journaldSocketPath)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	detectFeatures(config)
+	assert.True(t, IsSystemdInit())
+	assert.True(t, IsJournaldAvailable())
+	assert.Equal(t, FeatureMap{InitSystemd: struct{}{}, Journald: struct{}{}}, GetDetectedFeatures())
+
+	config.Set("autoconfig_exclude_features", []string{"journald"})
+	detectFeatures(config)
+	assert.Equal(t, FeatureMap{InitSystemd: struct{}{}}, GetDetectedFeatures())
+}
+
+func TestDetectGPUFeatures(t *testing.T) {
+	defer setupDMI(t, map[string]string{"sys_vendor": "QEMU"})()
+
+	ldLibraryPath := os.Getenv("LD_LIBRARY_PATH")
+	defer os.Setenv("LD_LIBRARY_PATH", ldLibraryPath)
+	os.Setenv("LD_LIBRARY_PATH", "")
+
+	config := setupConf()
+	config.Set("cloud_provider_metadata", []string{})
+
+	detectFeatures(config)
+	assert.False(t, HasNvidiaGPU())
+	assert.False(t, IsNVMLAvailable())
+	assert.False(t, HasAMDGPU())
+	assert.Equal(t, FeatureMap{}, GetDetectedFeatures())
+
+	// the control device exists without any GPU
+	devDir := filepath.Dir(nvidiaDevicesPattern)
+	require.NoError(t, os.MkdirAll(devDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(devDir, "nvidiactl"), nil, 0644))
+	assert.False(t, HasNvidiaGPU())
+	require.NoError(t, ioutil.WriteFile(filepath.Join(devDir, "nvidia0"), nil, 0644))
+
+	// the library is looked up in $LD_LIBRARY_PATH too
+	libDir, err := ioutil.TempDir("", "nvml")
+	require.NoError(t, err)
+	defer os.RemoveAll(libDir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(libDir, nvmlLibraryName), nil, 0644))
+	os.Setenv("LD_LIBRARY_PATH", "/nonexistent:"+libDir)
+
+	// the CPU nodes of the kernel fusion driver don't have any SIMD unit
+	cpuNode := filepath.Join(kfdTopologyPath, "0")
+	require.NoError(t, os.MkdirAll(cpuNode, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cpuNode, "properties"), []byte("cpu_cores_count 8\nsimd_count 0\n"), 0644))
+	assert.False(t, HasAMDGPU())
+	gpuNode := filepath.Join(kfdTopologyPath, "1")
+	require.NoError(t, os.MkdirAll(gpuNode, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(gpuNode, "properties"), []byte("cpu_cores_count 0\nsimd_count 256\n"), 0644))
+
+	detectFeatures(config)
+	assert.True(t, HasNvidiaGPU())
+	assert.True(t, IsNVMLAvailable())
+	assert.True(t, HasAMDGPU())
+	assert.Equal(t, FeatureMap{NvidiaGPU: struct{}{}, NVML: struct{}{}, AMDGPU: struct{}{}}, GetDetectedFeatures())
+}
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux
+
+package config
+
+// GetKernelLockdownMode returns an empty string, the kernel lockdown only exists on Linux
+func GetKernelLockdownMode() string {
+	return ""
+}
+
+// IsSecureBootEnabled returns false, the secure boot is only detected on Linux
+func IsSecureBootEnabled() bool {
+	return false
+}
+
+// IsSystemdInit returns false, systemd only exists on Linux
+func IsSystemdInit() bool {
+	return false
+}
+
+// IsJournaldAvailable returns false, journald only exists on Linux
+func IsJournaldAvailable() bool {
+	return false
+}
+
+// detectInitFeatures doesn't detect anything, the init features are specific to Linux
+func detectInitFeatures(features FeatureMap) {}
+
+// HasNvidiaGPU returns false, the GPUs are only detected on Linux
+func HasNvidiaGPU() bool {
+	return false
+}
+
+// IsNVMLAvailable returns false, the NVIDIA Management Library is only detected on Linux
+func IsNVMLAvailable() bool {
+	return false
+}
+
+// HasAMDGPU returns false, the GPUs are only detected on Linux
+func HasAMDGPU() bool {
+	return false
+}
+
+// detectGPUFeatures doesn't detect anything, the GPUs are only detected on Linux
+func detectGPUFeatures(features FeatureMap) {}
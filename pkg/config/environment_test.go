@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupDMI(t *testing.T, fields map[string]string) func() {
+	dir, err := ioutil.TempDir("", "dmi")
+	require.NoError(t, err)
+
+	for name, value := range fields {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(value+"\n"), 0644))
+	}
+
+	oldDMIPath, oldHypervisorUUIDPath := dmiPath, hypervisorUUIDPath
+	oldLockdownPath, oldSecureBootPath := lockdownPath, secureBootPath
+	oldSystemdRuntimePath, oldJournaldSocketPath := systemdRuntimePath, journaldSocketPath
+	oldNvidiaDevicesPattern, oldNvmlLibraryDirs, oldKFDTopologyPath := nvidiaDevicesPattern, nvmlLibraryDirs, kfdTopologyPath
+	oldDockerSocketPath := dockerSocketPath
+	dmiPath = dir
+	hypervisorUUIDPath = filepath.Join(dir, "hypervisor_uuid")
+	lockdownPath = filepath.Join(dir, "lockdown")
+	secureBootPath = filepath.Join(dir, "secure_boot")
+	systemdRuntimePath = filepath.Join(dir, "systemd")
+	journaldSocketPath = filepath.Join(dir, "journal.socket")
+	nvidiaDevicesPattern = filepath.Join(dir, "dev", "nvidia[0-9]*")
+	nvmlLibraryDirs = []string{filepath.Join(dir, "lib")}
+	kfdTopologyPath = filepath.Join(dir, "kfd")
+	dockerSocketPath = filepath.Join(dir, "docker.sock")
+
+	return func() {
+		dmiPath, hypervisorUUIDPath = oldDMIPath, oldHypervisorUUIDPath
+		lockdownPath, secureBootPath = oldLockdownPath, oldSecureBootPath
+		systemdRuntimePath, journaldSocketPath = oldSystemdRuntimePath, oldJournaldSocketPath
+		nvidiaDevicesPattern, nvmlLibraryDirs, kfdTopologyPath = oldNvidiaDevicesPattern, oldNvmlLibraryDirs, oldKFDTopologyPath
+		dockerSocketPath = oldDockerSocketPath
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDetectCloudFromDMI(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		fields   map[string]string
+		expected Feature
+	}{
+		{"none", map[string]string{"sys_vendor": "QEMU"}, ""},
+		{"aws nitro", map[string]string{"sys_vendor": "Amazon EC2", "product_name": "m5.large"}, CloudAWS},
+		{"aws xen", map[string]string{"sys_vendor": "Xen", "hypervisor_uuid": "ec2e1916-9099-7caf-fd21-012345abcdef"}, CloudAWS},
+		{"gcp", map[string]string{"sys_vendor": "Google", "product_name": "Google Compute Engine"}, CloudGCP},
+		{"azure", map[string]string{"sys_vendor": "Microsoft Corporation", "chassis_asset_tag": azureChassisAssetTag}, CloudAzure},
+		{"hyper-v", map[string]string{"sys_vendor": "Microsoft Corporation", "chassis_asset_tag": "None"}, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			defer setupDMI(t, test.fields)()
+			assert.Equal(t, test.expected, detectCloudFromDMI())
+		})
+	}
+}
+
+func TestDetectCloudFromMetadata(t *testing.T) {
+	oldURL := cloudMetadataURL
+	defer func() { cloudMetadataURL = oldURL }()
+
+	// GCP like endpoint, replying to every request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Metadata-Flavor", "Google")
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+	cloudMetadataURL = ts.URL
+
+	config := setupConf()
+	assert.Equal(t, CloudGCP, detectCloudFromMetadata(config))
+
+	config.Set("cloud_provider_metadata", []string{"aws"})
+	assert.Equal(t, Feature(""), detectCloudFromMetadata(config))
+
+	// IMDSv2 only AWS endpoint
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/latest/meta-data/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	assert.Equal(t, CloudAWS, detectCloudFromMetadata(config))
+}
+
+func TestDetectFeatures(t *testing.T) {
+	defer setupDMI(t, map[string]string{"sys_vendor": "Amazon EC2"})()
+
+	detectFeatures(setupConf())
+	assert.True(t, IsFeaturePresent(CloudAWS))
+	assert.False(t, IsFeaturePresent(CloudGCP))
+	assert.Equal(t, FeatureMap{CloudAWS: struct{}{}}, GetDetectedFeatures())
+}
+
+func TestDetectFeaturesIncludeExclude(t *testing.T) {
+	defer setupDMI(t, map[string]string{"sys_vendor": "Amazon EC2"})()
+
+	config := setupConf()
+	config.Set("autoconfig_include_features", []string{"GCP"})
+	config.Set("autoconfig_exclude_features", []string{"aws"})
+	detectFeatures(config)
+	assert.False(t, IsFeaturePresent(CloudAWS))
+	assert.True(t, IsFeaturePresent(CloudGCP))
+	assert.Equal(t, FeatureMap{CloudGCP: struct{}{}}, GetDetectedFeatures())
+
+	// the exclusion wins over the inclusion
+	config.Set("autoconfig_include_features", []string{"azure"})
+	config.Set("autoconfig_exclude_features", []string{"azure"})
+	detectFeatures(config)
+	assert.Equal(t, FeatureMap{CloudAWS: struct{}{}}, GetDetectedFeatures())
+	assert.True(t, IsFeatureExcluded(CloudAzure))
+
+	// the unknown features are ignored
+	config.Set("autoconfig_include_features", []string{"containerd"})
+	config.Set("autoconfig_exclude_features", []string{"aws "})
+	detectFeatures(config)
+	assert.Equal(t, FeatureMap{}, GetDetectedFeatures())
+}
+
+func TestDetectContainerFeatures(t *testing.T) {
+	defer setupDMI(t, nil)()
+	defer func() {
+		featureLock.Lock()
+		detectedFeatures, excludedFeatures = nil, nil
+		featureLock.Unlock()
+	}()
+
+	config := setupConf()
+	detectFeatures(config)
+	assert.False(t, IsFeaturePresent(Docker))
+
+	require.NoError(t, ioutil.WriteFile(dockerSocketPath, nil, 0644))
+	detectFeatures(config)
+	assert.True(t, IsFeaturePresent(Docker))
+
+	config.Set("autoconfig_exclude_features", []string{"docker"})
+	detectFeatures(config)
+	assert.False(t, IsFeaturePresent(Docker))
+	assert.True(t, IsFeatureExcluded(Docker))
+
+	config.Set("autoconfig_include_features", []string{"kubernetes"})
+	detectFeatures(config)
+	assert.True(t, IsKubernetes())
+
+	config.Set("autoconfig_include_features", []string{})
+	config.Set("autoconfig_exclude_features", []string{"kubernetes"})
+	os.Setenv("KUBERNETES_SERVICE_PORT", "443")
+	defer os.Unsetenv("KUBERNETES_SERVICE_PORT")
+	detectFeatures(config)
+	assert.False(t, IsKubernetes())
+}
@@ -100,7 +100,11 @@ func SetupLogger(loggerName LoggerName, logLevel, logFile, syslogURI string, sys
 	if err != nil {
 		return err
 	}
-	seelogConfig, err = buildLoggerConfig(loggerName, seelogLogLevel, logFile, syslogURI, syslogRFC, logToConsole, jsonFormat)
+	overrides, err := validateLogLevelOverrides(Datadog.GetStringMapString("log_level_overrides"))
+	if err != nil {
+		return err
+	}
+	seelogConfig, err = buildLoggerConfig(loggerName, lowestLogLevel(seelogLogLevel, overrides), logFile, syslogURI, syslogRFC, logToConsole, jsonFormat)
 	if err != nil {
 		return err
 	}
@@ -110,6 +114,11 @@ func SetupLogger(loggerName LoggerName, logLevel, logFile, syslogURI string, sys
 	}
 	_ = seelog.ReplaceLogger(loggerInterface)
 	log.SetupLogger(loggerInterface, seelogLogLevel)
+	if len(overrides) > 0 {
+		if err := log.ChangeLogLevelOverrides(loggerInterface, overrides); err != nil {
+			return err
+		}
+	}
 	log.AddStrippedKeys(Datadog.GetStringSlice("flare_stripped_keys"))
 	return nil
 }
@@ -143,7 +152,7 @@ func buildLoggerConfig(loggerName LoggerName, seelogLogLevel, logFile, syslogURI
 
 	config := seelogCfg.NewSeelogConfig(string(loggerName), seelogLogLevel, formatID, buildJSONFormat(loggerName), buildCommonFormat(loggerName), syslogRFC)
 	config.EnableConsoleLog(logToConsole)
-	config.EnableFileLogging(logFile, Datadog.GetSizeInBytes("log_file_max_size"), uint(Datadog.GetInt("log_file_max_rolls")))
+	config.EnableFileLogging(logFile, uint(Datadog.GetBytesSize("log_file_max_size")), uint(Datadog.GetInt("log_file_max_rolls")))
 
 	if syslogURI != "" { // non-blank uri enables syslog
 		syslogTLSKeyPair, err := getSyslogTLSKeyPair()
@@ -450,20 +459,54 @@ func ChangeLogLevel(level string) error {
 		return err
 	}
 	// We create a new logger to propagate the new log level everywhere seelog is used (including dependencies)
+	logger, err := replaceSeelogLogger(lowestLogLevel(seelogLogLevel, log.GetLogLevelOverrides()))
+	if err != nil {
+		return err
+	}
+
+	// We wire the new logger with the Datadog logic
+	return log.ChangeLogLevel(logger, seelogLogLevel)
+}
+
+// ChangeLogLevelOverrides immediately changes the log levels overriding the global one for the packages
+// matching the patterns, e.g. `pkg/security` or `pkg/forwarder`. Empty overrides restore the global
+// log level everywhere.
+func ChangeLogLevelOverrides(overrides map[string]string) error {
+	overrides, err := validateLogLevelOverrides(overrides)
+	if err != nil {
+		return err
+	}
+	level, err := log.GetLogLevel()
+	if err != nil {
+		return err
+	}
+
+	// the seelog logger must let the messages of the most verbose package through, the Datadog logic
+	// filters them by package
+	logger, err := replaceSeelogLogger(lowestLogLevel(level.String(), overrides))
+	if err != nil {
+		return err
+	}
+
+	return log.ChangeLogLevelOverrides(logger, overrides)
+}
+
+// replaceSeelogLogger creates a new seelog logger with the given level and makes it the seelog global
+// logger
+func replaceSeelogLogger(seelogLogLevel string) (seelog.LoggerInterface, error) {
 	seelogConfig.SetLogLevel(seelogLogLevel)
 	configTemplate, err := seelogConfig.Render()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	logger, err := seelog.LoggerFromConfigAsString(configTemplate)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	seelog.ReplaceLogger(logger) //nolint:errcheck
 
-	// We wire the new logger with the Datadog logic
-	return log.ChangeLogLevel(logger, seelogLogLevel)
+	return logger, nil
 }
 
 func validateLogLevel(logLevel string) (string, error) {
@@ -478,6 +521,31 @@ func validateLogLevel(logLevel string) (string, error) {
 	return seelogLogLevel, nil
 }
 
+// validateLogLevelOverrides validates the levels of the overrides and returns them in the seelog format
+func validateLogLevelOverrides(overrides map[string]string) (map[string]string, error) {
+	validated := make(map[string]string, len(overrides))
+	for pattern, level := range overrides {
+		seelogLogLevel, err := validateLogLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level override for %s: %s", pattern, err)
+		}
+		validated[pattern] = seelogLogLevel
+	}
+	return validated, nil
+}
+
+// lowestLogLevel returns the lowest of the global log level and the levels of the overrides
+func lowestLogLevel(seelogLogLevel string, overrides map[string]string) string {
+	lowest, _ := seelog.LogLevelFromString(seelogLogLevel)
+	for _, level := range overrides {
+		if lvl, _ := seelog.LogLevelFromString(level); lvl < lowest {
+			lowest = lvl
+			seelogLogLevel = level
+		}
+	}
+	return seelogLogLevel
+}
+
 func init() {
 	seelog.RegisterCustomFormatter("CustomSyslogHeader", createSyslogHeaderFormatter) //nolint:errcheck
 	seelog.RegisterCustomFormatter("ShortFilePath", parseShortFilePath)               //nolint:errcheck
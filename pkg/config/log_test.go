@@ -41,6 +41,19 @@ func TestSeelogConfig(t *testing.T) {
 	assert.NotNil(t, logger)
 }
 
+func TestLogLevelOverrides(t *testing.T) {
+	overrides, err := validateLogLevelOverrides(map[string]string{"pkg/security": "TRACE", "pkg/forwarder": "warning"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"pkg/security": "trace", "pkg/forwarder": "warn"}, overrides)
+
+	_, err = validateLogLevelOverrides(map[string]string{"pkg/security": "verbose"})
+	assert.NotNil(t, err)
+
+	assert.Equal(t, "trace", lowestLogLevel("info", overrides))
+	assert.Equal(t, "info", lowestLogLevel("info", map[string]string{"pkg/forwarder": "error"}))
+	assert.Equal(t, "debug", lowestLogLevel("debug", nil))
+}
+
 func benchmarkLogFormat(logFormat string, b *testing.B) {
 	var buff bytes.Buffer
 	w := bufio.NewWriter(&buff)
@@ -25,3 +25,10 @@ func Mock() *MockConfig {
 	InitConfig(Datadog)
 	return &MockConfig{Datadog}
 }
+
+// SetDetectedFeatures overrides the detected features, it should only be used in tests
+func SetDetectedFeatures(features FeatureMap) {
+	featureLock.Lock()
+	detectedFeatures = features
+	featureLock.Unlock()
+}
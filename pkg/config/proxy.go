@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+// Products whose proxy settings can be overridden
+const (
+	ProxyProductCore         = "core"
+	ProxyProductLogs         = "logs"
+	ProxyProductAPM          = "apm"
+	ProxyProductRemoteConfig = "remote_configuration"
+)
+
+// proxyOverrideKeys maps the products to the key of their proxy settings, which override the
+// agent proxy settings
+var proxyOverrideKeys = map[string]string{
+	ProxyProductLogs:         "logs_config.proxy",
+	ProxyProductAPM:          "apm_config.proxy",
+	ProxyProductRemoteConfig: "remote_configuration.proxy",
+}
+
+// ProxyProducts returns the products sending data through the proxies
+func ProxyProducts() []string {
+	return []string{ProxyProductCore, ProxyProductLogs, ProxyProductAPM, ProxyProductRemoteConfig}
+}
+
+// GetProductProxies returns the proxy settings of a product: the agent proxy settings, overridden by
+// the settings set in the `proxy` section of the product. An empty proxy set for a product disables
+// the agent proxy for it. It returns nil when no proxy is configured.
+func GetProductProxies(product string) *Proxy {
+	return getProductProxies(Datadog, proxies, product)
+}
+
+func getProductProxies(config Config, agentProxies *Proxy, product string) *Proxy {
+	key, found := proxyOverrideKeys[product]
+	if !found {
+		return agentProxies
+	}
+
+	p := &Proxy{}
+	if agentProxies != nil {
+		*p = *agentProxies
+	}
+
+	overridden := false
+	if config.IsSet(key + ".http") {
+		p.HTTP = config.GetString(key + ".http")
+		overridden = true
+	}
+	if config.IsSet(key + ".https") {
+		p.HTTPS = config.GetString(key + ".https")
+		overridden = true
+	}
+	if config.IsSet(key + ".no_proxy") {
+		p.NoProxy = config.GetStringSlice(key + ".no_proxy")
+		overridden = true
+	}
+	if config.IsSet(key + ".endpoints") {
+		p.Endpoints = config.GetStringMapString(key + ".endpoints")
+		overridden = true
+	}
+
+	if !overridden {
+		return agentProxies
+	}
+	return p
+}
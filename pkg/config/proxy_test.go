@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductProxies(t *testing.T) {
+	agentProxies := &Proxy{
+		HTTP:    "http://proxy.com:3128",
+		HTTPS:   "http://proxy.com:3128",
+		NoProxy: []string{"localhost"},
+	}
+
+	config := setupConfFromYAML(`
+logs_config:
+  proxy:
+    https: http://logs-proxy.com:3128
+    endpoints:
+      agent-http-intake.logs.datadoghq.com: ""
+apm_config:
+  proxy:
+    http: ""
+    https: ""
+`)
+
+	// products without overrides use the agent proxies
+	assert.Equal(t, agentProxies, getProductProxies(config, agentProxies, ProxyProductCore))
+	assert.Equal(t, agentProxies, getProductProxies(config, agentProxies, ProxyProductRemoteConfig))
+	assert.Nil(t, getProductProxies(config, nil, ProxyProductRemoteConfig))
+
+	logsProxies := getProductProxies(config, agentProxies, ProxyProductLogs)
+	require.NotNil(t, logsProxies)
+	assert.Equal(t, "http://proxy.com:3128", logsProxies.HTTP)
+	assert.Equal(t, "http://logs-proxy.com:3128", logsProxies.HTTPS)
+	assert.Equal(t, []string{"localhost"}, logsProxies.NoProxy)
+	assert.Equal(t, map[string]string{"agent-http-intake.logs.datadoghq.com": ""}, logsProxies.Endpoints)
+	// the agent proxies are left untouched
+	assert.Equal(t, "http://proxy.com:3128", agentProxies.HTTPS)
+
+	// an empty product proxy disables the agent proxy
+	apmProxies := getProductProxies(config, agentProxies, ProxyProductAPM)
+	require.NotNil(t, apmProxies)
+	assert.Empty(t, apmProxies.HTTP)
+	assert.Empty(t, apmProxies.HTTPS)
+
+	// product proxies are set even without agent proxies
+	logsProxies = getProductProxies(config, nil, ProxyProductLogs)
+	require.NotNil(t, logsProxies)
+	assert.Equal(t, "http://logs-proxy.com:3128", logsProxies.HTTPS)
+	assert.Empty(t, logsProxies.HTTP)
+}
+
+func TestProductProxiesFromEnv(t *testing.T) {
+	os.Setenv("DD_REMOTE_CONFIGURATION_PROXY_HTTPS", "http://rc-proxy.com:3128")
+	defer os.Unsetenv("DD_REMOTE_CONFIGURATION_PROXY_HTTPS")
+
+	config := setupConf()
+	proxies := getProductProxies(config, nil, ProxyProductRemoteConfig)
+	require.NotNil(t, proxies)
+	assert.Equal(t, "http://rc-proxy.com:3128", proxies.HTTPS)
+}
@@ -0,0 +1,501 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package remote
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	configurationsPath = "/api/v0.1/configurations"
+	statusPath         = "/api/v0.1/configurations/status"
+	requestTimeout     = 10 * time.Second
+	maxPayloadSize     = 1024 * 1024
+	versionFileName    = "remote_config_version"
+)
+
+var (
+	remoteConfigExpvars = expvar.NewMap("remote_config")
+	versionExpvar       = expvar.Int{}
+	stateExpvar         = expvar.String{}
+	lastErrorExpvar     = expvar.String{}
+)
+
+func init() {
+	remoteConfigExpvars.Set("Version", &versionExpvar)
+	remoteConfigExpvars.Set("State", &stateExpvar)
+	remoteConfigExpvars.Set("LastError", &lastErrorExpvar)
+}
+
+// ApplyState is the outcome of the application of a configuration
+type ApplyState string
+
+const (
+	// Applied means that all the settings of the configuration were applied
+	Applied ApplyState = "applied"
+	// PartiallyApplied means that some settings of the configuration were refused
+	PartiallyApplied ApplyState = "partially_applied"
+	// Rejected means that the configuration was refused as a whole, because its signature or content is invalid
+	Rejected ApplyState = "rejected"
+)
+
+// ApplyStatus is reported to the backend after every configuration received
+type ApplyStatus struct {
+	Hostname     string            `json:"hostname"`
+	AgentVersion string            `json:"agent_version"`
+	Version      uint64            `json:"version"`
+	State        ApplyState        `json:"state"`
+	Errors       map[string]string `json:"errors,omitempty"`
+}
+
+// Config holds the settings of the remote configuration client
+type Config struct {
+	// Endpoint is the base URL of the remote configuration API
+	Endpoint string
+	APIKey   string
+	Hostname string
+	// PublicKeys are the keys trusted to sign the configurations
+	PublicKeys []ed25519.PublicKey
+	// RefreshInterval is the interval between two polls of the backend
+	RefreshInterval time.Duration
+	// AllowedSettings lists the settings that can be set remotely, along with their sub-settings
+	AllowedSettings []string
+	// VersionFile persists the version of the last configuration applied across the restarts, no version is
+	// persisted when it's empty
+	VersionFile string
+}
+
+// setter applies the value of a setting
+type setter func(cfg config.Config, key string, value interface{}, source config.Source) error
+
+// unsetter removes the value of a setting set by a source
+type unsetter func(cfg config.Config, key string, source config.Source) error
+
+// setters holds the settings requiring more than a configuration override to be applied
+var setters = map[string]setter{
+	"log_level": setLogLevel,
+}
+
+// unsetters holds the settings requiring more than the removal of their override to be restored
+var unsetters = map[string]unsetter{
+	"log_level": unsetLogLevel,
+}
+
+// Client polls the backend for the configuration of the agent and applies it as overrides
+// of the agent configuration
+type Client struct {
+	sync.Mutex
+	config     Config
+	agentCfg   config.Config
+	httpClient *http.Client
+	setters    map[string]setter
+	unsetters  map[string]unsetter
+	version    uint64
+	minVersion uint64
+	// remoteKeys are the settings currently set remotely
+	remoteKeys map[string]struct{}
+	now        func() time.Time
+	stop       chan struct{}
+	stopped    chan struct{}
+}
+
+// NewClient returns a new remote configuration client applying the configurations to agentCfg
+func NewClient(cfg Config, agentCfg config.Config) (*Client, error) {
+	if cfg.RefreshInterval <= 0 {
+		return nil, fmt.Errorf("invalid remote configuration refresh interval: %s", cfg.RefreshInterval)
+	}
+	if len(cfg.PublicKeys) == 0 {
+		return nil, fmt.Errorf("no public key configured to verify the remote configurations")
+	}
+	if _, err := url.Parse(cfg.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid remote configuration endpoint: %s", err)
+	}
+
+	// the settings applied remotely aren't kept across the restarts, so the last configuration applied is accepted
+	// again, but the older ones are refused
+	minVersion, err := readVersionFile(cfg.VersionFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the version of the last remote configuration applied: %s", err)
+	}
+
+	return &Client{
+		config:     cfg,
+		minVersion: minVersion,
+		agentCfg:   agentCfg,
+		httpClient: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: httputils.CreateProductHTTPTransport(config.ProxyProductRemoteConfig),
+		},
+		setters:    setters,
+		unsetters:  unsetters,
+		remoteKeys: make(map[string]struct{}),
+		now:        time.Now,
+	}, nil
+}
+
+// NewClientFromConfig returns a remote configuration client set up from the agent configuration
+func NewClientFromConfig(hostname string) (*Client, error) {
+	keys, err := ParsePublicKeys(config.Datadog.GetStringSlice("remote_configuration.public_keys"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(Config{
+		Endpoint:        config.GetMainEndpoint("https://config.", "remote_configuration.dd_url"),
+		APIKey:          config.SanitizeAPIKey(config.Datadog.GetString("api_key")),
+		Hostname:        hostname,
+		PublicKeys:      keys,
+		RefreshInterval: time.Duration(config.Datadog.GetInt("remote_configuration.refresh_interval")) * time.Second,
+		AllowedSettings: config.Datadog.GetStringSlice("remote_configuration.allowed_settings"),
+		VersionFile:     filepath.Join(config.Datadog.GetString("run_path"), versionFileName),
+	}, config.Datadog)
+}
+
+// Start starts polling the backend
+func (c *Client) Start() {
+	c.stop = make(chan struct{})
+	c.stopped = make(chan struct{})
+
+	go func() {
+		defer close(c.stopped)
+
+		ticker := time.NewTicker(c.config.RefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			c.poll()
+
+			select {
+			case <-ticker.C:
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops polling the backend, the settings applied remotely are kept
+func (c *Client) Stop() {
+	if c.stop == nil {
+		return
+	}
+	close(c.stop)
+	<-c.stopped
+	c.stop = nil
+}
+
+// Version returns the version of the last configuration applied
+func (c *Client) Version() uint64 {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.version
+}
+
+// SetAPIKey updates the API key sent to the backend, when its secret was rotated
+func (c *Client) SetAPIKey(apiKey string) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.config.APIKey = apiKey
+}
+
+func (c *Client) apiKey() string {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.config.APIKey
+}
+
+func (c *Client) poll() {
+	payload, err := c.fetch()
+	if err != nil {
+		log.Debugf("Unable to fetch the remote configuration: %s", err)
+		lastErrorExpvar.Set(err.Error())
+		return
+	}
+	if payload == nil {
+		return
+	}
+
+	status := c.Apply(payload)
+	if err := c.reportStatus(status); err != nil {
+		log.Debugf("Unable to report the status of the remote configuration %d: %s", status.Version, err)
+	}
+}
+
+// fetch returns the latest configuration, or nil if the agent already runs it
+func (c *Client) fetch() (*SignedPayload, error) {
+	query := url.Values{}
+	query.Set("hostname", c.config.Hostname)
+	query.Set("agent_version", version.AgentVersion)
+	query.Set("current_version", strconv.FormatUint(c.Version(), 10))
+
+	req, err := http.NewRequest("GET", c.config.Endpoint+configurationsPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("DD-API-KEY", c.apiKey())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s", httputils.SanitizeURL(err.Error()))
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusNotModified:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPayloadSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var payload SignedPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid payload: %s", err)
+	}
+	return &payload, nil
+}
+
+// Apply verifies the configuration held by the payload and applies its settings
+func (c *Client) Apply(payload *SignedPayload) ApplyStatus {
+	c.Lock()
+	defer c.Unlock()
+
+	status := ApplyStatus{
+		Hostname:     c.config.Hostname,
+		AgentVersion: version.AgentVersion,
+	}
+
+	configuration, err := payload.Verify(c.config.PublicKeys, c.now())
+	if err != nil {
+		return c.reject(status, err)
+	}
+	status.Version = configuration.Version
+
+	if err := configuration.CheckTarget(c.config.Hostname, c.config.APIKey); err != nil {
+		return c.reject(status, err)
+	}
+
+	// refuse to go back to an older configuration, that could be replayed
+	if configuration.Version <= c.version {
+		return c.reject(status, fmt.Errorf("configuration %d isn't newer than the current configuration %d", configuration.Version, c.version))
+	}
+	if configuration.Version < c.minVersion {
+		return c.reject(status, fmt.Errorf("configuration %d is older than the last configuration applied %d", configuration.Version, c.minVersion))
+	}
+
+	status.State = Applied
+	settings := make(map[string]interface{}, len(configuration.Settings))
+	for key, value := range configuration.Settings {
+		key = strings.ToLower(key)
+		settings[key] = value
+		if err := c.set(key, value); err != nil {
+			if status.Errors == nil {
+				status.Errors = make(map[string]string)
+			}
+			status.Errors[key] = err.Error()
+			status.State = PartiallyApplied
+		}
+	}
+
+	// restore the settings the backend doesn't send anymore
+	for key := range c.remoteKeys {
+		if _, found := settings[key]; found {
+			continue
+		}
+		if err := c.unsetter(key)(c.agentCfg, key, config.SourceRemote); err != nil {
+			log.Warnf("Unable to restore the setting %s: %s", key, err)
+			continue
+		}
+		delete(c.remoteKeys, key)
+		log.Infof("Setting %s restored to %v", key, c.agentCfg.Get(key))
+	}
+
+	c.version = configuration.Version
+	c.minVersion = configuration.Version
+	if err := writeVersionFile(c.config.VersionFile, c.version); err != nil {
+		log.Warnf("Unable to persist the version of the remote configuration %d: %s", c.version, err)
+	}
+	versionExpvar.Set(int64(c.version))
+	stateExpvar.Set(string(status.State))
+	lastErrorExpvar.Set(formatErrors(status.Errors))
+
+	log.Infof("Remote configuration %d %s", configuration.Version, strings.Replace(string(status.State), "_", " ", -1))
+	return status
+}
+
+func (c *Client) reject(status ApplyStatus, err error) ApplyStatus {
+	log.Warnf("Remote configuration rejected: %s", err)
+	status.State = Rejected
+	status.Errors = map[string]string{"configuration": err.Error()}
+	stateExpvar.Set(string(status.State))
+	lastErrorExpvar.Set(err.Error())
+	return status
+}
+
+func (c *Client) set(key string, value interface{}) error {
+	if !c.isAllowed(key) {
+		return fmt.Errorf("setting %s can't be set remotely", key)
+	}
+
+	if err := c.setter(key)(c.agentCfg, key, value, config.SourceRemote); err != nil {
+		return err
+	}
+	c.remoteKeys[key] = struct{}{}
+
+	log.Infof("Setting %s set remotely to %v", key, value)
+	return nil
+}
+
+func (c *Client) setter(key string) setter {
+	if set, found := c.setters[key]; found {
+		return set
+	}
+	return setOverride
+}
+
+func (c *Client) unsetter(key string) unsetter {
+	if unset, found := c.unsetters[key]; found {
+		return unset
+	}
+	return unsetOverride
+}
+
+func (c *Client) isAllowed(key string) bool {
+	for _, allowed := range c.config.AllowedSettings {
+		allowed = strings.ToLower(allowed)
+		if key == allowed || strings.HasPrefix(key, allowed+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) reportStatus(status ApplyStatus) error {
+	body, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", c.config.Endpoint+statusPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("DD-API-KEY", c.apiKey())
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s", httputils.SanitizeURL(err.Error()))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return nil
+}
+
+// readVersionFile returns the version persisted in the file, or 0 if it doesn't exist yet
+func readVersionFile(path string) (uint64, error) {
+	if path == "" {
+		return 0, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// writeVersionFile persists the version, the file is replaced atomically so that it's never left truncated
+func writeVersionFile(path string, version uint64) error {
+	if path == "" {
+		return nil
+	}
+
+	tmpFile := path + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, []byte(strconv.FormatUint(version, 10)), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile) //nolint:errcheck
+		return err
+	}
+	return nil
+}
+
+func setOverride(cfg config.Config, key string, value interface{}, source config.Source) error {
+	cfg.SetWithSource(key, value, source)
+	return nil
+}
+
+func unsetOverride(cfg config.Config, key string, source config.Source) error {
+	cfg.UnsetForSource(key, source)
+	return nil
+}
+
+func setLogLevel(cfg config.Config, key string, value interface{}, source config.Source) error {
+	level, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("invalid log level %v", value)
+	}
+	if err := config.ChangeLogLevel(level); err != nil {
+		return err
+	}
+	cfg.SetWithSource(key, level, source)
+	return nil
+}
+
+func formatErrors(errors map[string]string) string {
+	keys := make([]string, 0, len(errors))
+	for key := range errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	messages := make([]string, 0, len(keys))
+	for _, key := range keys {
+		messages = append(messages, fmt.Sprintf("%s: %s", key, errors[key]))
+	}
+	return strings.Join(messages, ", ")
+}
+
+func unsetLogLevel(cfg config.Config, key string, source config.Source) error {
+	cfg.UnsetForSource(key, source)
+	return config.ChangeLogLevel(cfg.GetString(key))
+}
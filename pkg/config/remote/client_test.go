@@ -0,0 +1,300 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package remote
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// signPayload signs the configuration, binding it to the test agent unless it's bound to another one
+func signPayload(t *testing.T, key ed25519.PrivateKey, configuration Configuration) *SignedPayload {
+	if configuration.Hostname == "" {
+		configuration.Hostname = "host"
+	}
+	if configuration.APIKeyHash == "" {
+		configuration.APIKeyHash = HashAPIKey("123")
+	}
+	return sign(t, key, configuration)
+}
+
+func sign(t *testing.T, key ed25519.PrivateKey, configuration Configuration) *SignedPayload {
+	signed, err := json.Marshal(configuration)
+	require.NoError(t, err)
+
+	return &SignedPayload{
+		Signed:     base64.StdEncoding.EncodeToString(signed),
+		Signatures: []string{base64.StdEncoding.EncodeToString(ed25519.Sign(key, signed))},
+	}
+}
+
+func newTestConfig(t *testing.T, endpoint string) (Config, ed25519.PrivateKey) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	return Config{
+		Endpoint:        endpoint,
+		APIKey:          "123",
+		Hostname:        "host",
+		PublicKeys:      []ed25519.PublicKey{publicKey},
+		RefreshInterval: time.Minute,
+		AllowedSettings: []string{"dogstatsd_stats_enable", "apm_config.analyzed_rate_by_service"},
+	}, privateKey
+}
+
+func newTestClient(t *testing.T, endpoint string) (*Client, ed25519.PrivateKey, config.Config) {
+	cfg, privateKey := newTestConfig(t, endpoint)
+
+	mockConfig := config.Mock()
+	client, err := NewClient(cfg, mockConfig)
+	require.NoError(t, err)
+
+	return client, privateKey, mockConfig
+}
+
+func TestVerify(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	otherKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	now := time.Now()
+
+	payload := signPayload(t, privateKey, Configuration{
+		Version:  2,
+		Expires:  now.Add(time.Hour),
+		Settings: map[string]interface{}{"dogstatsd_stats_enable": true},
+	})
+
+	configuration, err := payload.Verify([]ed25519.PublicKey{otherKey, publicKey}, now)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), configuration.Version)
+	assert.Equal(t, true, configuration.Settings["dogstatsd_stats_enable"])
+
+	_, err = payload.Verify([]ed25519.PublicKey{otherKey}, now)
+	assert.Error(t, err)
+
+	_, err = payload.Verify([]ed25519.PublicKey{publicKey}, now.Add(2*time.Hour))
+	assert.Error(t, err)
+
+	// the expiry is mandatory
+	_, err = signPayload(t, privateKey, Configuration{Version: 3}).Verify([]ed25519.PublicKey{publicKey}, now)
+	assert.Error(t, err)
+
+	tampered := *payload
+	tampered.Signed = base64.StdEncoding.EncodeToString([]byte(`{"version":3,"settings":{"api_key":"abc"}}`))
+	_, err = tampered.Verify([]ed25519.PublicKey{publicKey}, now)
+	assert.Error(t, err)
+}
+
+func TestParsePublicKeys(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	keys, err := ParsePublicKeys([]string{base64.StdEncoding.EncodeToString(publicKey)})
+	require.NoError(t, err)
+	assert.Equal(t, []ed25519.PublicKey{publicKey}, keys)
+
+	_, err = ParsePublicKeys([]string{"not a key"})
+	assert.Error(t, err)
+
+	_, err = ParsePublicKeys([]string{base64.StdEncoding.EncodeToString([]byte("short"))})
+	assert.Error(t, err)
+}
+
+func TestApply(t *testing.T) {
+	client, privateKey, mockConfig := newTestClient(t, "http://localhost")
+
+	status := client.Apply(signPayload(t, privateKey, Configuration{
+		Version: 1,
+		Expires: time.Now().Add(time.Hour),
+		Settings: map[string]interface{}{
+			"dogstatsd_stats_enable": true,
+			"api_key":                "abc",
+		},
+	}))
+	assert.Equal(t, uint64(1), status.Version)
+	assert.Equal(t, PartiallyApplied, status.State)
+	assert.Contains(t, status.Errors, "api_key")
+	assert.NotContains(t, status.Errors, "dogstatsd_stats_enable")
+	assert.True(t, mockConfig.GetBool("dogstatsd_stats_enable"))
+	assert.Equal(t, config.SourceRemote, mockConfig.GetSource("dogstatsd_stats_enable"))
+	assert.NotEqual(t, "abc", mockConfig.GetString("api_key"))
+	assert.NotEqual(t, config.SourceRemote, mockConfig.GetSource("api_key"))
+	assert.Equal(t, uint64(1), client.Version())
+
+	// an older configuration is refused
+	status = client.Apply(signPayload(t, privateKey, Configuration{
+		Version:  1,
+		Expires:  time.Now().Add(time.Hour),
+		Settings: map[string]interface{}{"dogstatsd_stats_enable": false},
+	}))
+	assert.Equal(t, Rejected, status.State)
+	assert.True(t, mockConfig.GetBool("dogstatsd_stats_enable"))
+
+	// the settings that are not sent anymore are restored
+	status = client.Apply(signPayload(t, privateKey, Configuration{
+		Version:  2,
+		Expires:  time.Now().Add(time.Hour),
+		Settings: map[string]interface{}{"apm_config.analyzed_rate_by_service.web": 0.5},
+	}))
+	assert.Equal(t, Applied, status.State)
+	assert.Empty(t, status.Errors)
+	assert.False(t, mockConfig.GetBool("dogstatsd_stats_enable"))
+	assert.Equal(t, config.SourceDefault, mockConfig.GetSource("dogstatsd_stats_enable"))
+	assert.NotContains(t, mockConfig.GetLayeredView()["dogstatsd_stats_enable"].Layers, config.SourceRemote)
+	assert.Equal(t, 0.5, mockConfig.GetFloat64("apm_config.analyzed_rate_by_service.web"))
+	assert.Equal(t, uint64(2), client.Version())
+}
+
+func TestApplyRestoresRuntimeSetting(t *testing.T) {
+	client, privateKey, mockConfig := newTestClient(t, "http://localhost")
+	mockConfig.SetWithSource("dogstatsd_stats_enable", true, config.SourceRuntime)
+
+	status := client.Apply(signPayload(t, privateKey, Configuration{
+		Version:  1,
+		Expires:  time.Now().Add(time.Hour),
+		Settings: map[string]interface{}{"dogstatsd_stats_enable": false},
+	}))
+	require.Equal(t, Applied, status.State)
+	assert.False(t, mockConfig.GetBool("dogstatsd_stats_enable"))
+
+	status = client.Apply(signPayload(t, privateKey, Configuration{
+		Version: 2,
+		Expires: time.Now().Add(time.Hour),
+	}))
+	require.Equal(t, Applied, status.State)
+	assert.True(t, mockConfig.GetBool("dogstatsd_stats_enable"))
+	assert.Equal(t, config.SourceRuntime, mockConfig.GetSource("dogstatsd_stats_enable"))
+}
+
+func TestApplyTarget(t *testing.T) {
+	client, privateKey, mockConfig := newTestClient(t, "http://localhost")
+	settings := map[string]interface{}{"dogstatsd_stats_enable": true}
+
+	for name, configuration := range map[string]Configuration{
+		"unbound":       {Version: 1, Expires: time.Now().Add(time.Hour), Settings: settings},
+		"other host":    {Version: 1, Expires: time.Now().Add(time.Hour), Settings: settings, Hostname: "other", APIKeyHash: HashAPIKey("123")},
+		"other api key": {Version: 1, Expires: time.Now().Add(time.Hour), Settings: settings, Hostname: "host", APIKeyHash: HashAPIKey("456")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			status := client.Apply(sign(t, privateKey, configuration))
+			assert.Equal(t, Rejected, status.State)
+			assert.False(t, mockConfig.GetBool("dogstatsd_stats_enable"))
+		})
+	}
+
+	// the configuration follows the rotation of the API key
+	client.SetAPIKey("456")
+	status := client.Apply(signPayload(t, privateKey, Configuration{
+		Version:    1,
+		Expires:    time.Now().Add(time.Hour),
+		Settings:   settings,
+		APIKeyHash: HashAPIKey("456"),
+	}))
+	assert.Equal(t, Applied, status.State)
+	assert.True(t, mockConfig.GetBool("dogstatsd_stats_enable"))
+}
+
+func TestApplyPersistedVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg, privateKey := newTestConfig(t, "http://localhost")
+	cfg.VersionFile = filepath.Join(dir, versionFileName)
+
+	client, err := NewClient(cfg, config.Mock())
+	require.NoError(t, err)
+	status := client.Apply(signPayload(t, privateKey, Configuration{
+		Version:  5,
+		Expires:  time.Now().Add(time.Hour),
+		Settings: map[string]interface{}{"dogstatsd_stats_enable": true},
+	}))
+	require.Equal(t, Applied, status.State)
+
+	// after a restart, the last configuration applied is accepted again, but not the older ones
+	client, err = NewClient(cfg, config.Mock())
+	require.NoError(t, err)
+	status = client.Apply(signPayload(t, privateKey, Configuration{
+		Version:  4,
+		Expires:  time.Now().Add(time.Hour),
+		Settings: map[string]interface{}{"dogstatsd_stats_enable": false},
+	}))
+	assert.Equal(t, Rejected, status.State)
+
+	status = client.Apply(signPayload(t, privateKey, Configuration{
+		Version:  5,
+		Expires:  time.Now().Add(time.Hour),
+		Settings: map[string]interface{}{"dogstatsd_stats_enable": true},
+	}))
+	assert.Equal(t, Applied, status.State)
+	assert.Equal(t, uint64(5), client.Version())
+}
+
+func TestPoll(t *testing.T) {
+	var payload *SignedPayload
+	var statuses []ApplyStatus
+	apiKey := "123"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, apiKey, r.Header.Get("DD-API-KEY"))
+
+		switch r.URL.Path {
+		case configurationsPath:
+			if payload == nil || r.URL.Query().Get("current_version") == "1" {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			json.NewEncoder(w).Encode(payload) //nolint:errcheck
+		case statusPath:
+			var status ApplyStatus
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+			statuses = append(statuses, status)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, privateKey, mockConfig := newTestClient(t, server.URL)
+
+	// nothing to apply
+	client.poll()
+	assert.Empty(t, statuses)
+
+	payload = signPayload(t, privateKey, Configuration{
+		Version:  1,
+		Expires:  time.Now().Add(time.Hour),
+		Settings: map[string]interface{}{"dogstatsd_stats_enable": true},
+	})
+	client.poll()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, ApplyStatus{Hostname: "host", AgentVersion: statuses[0].AgentVersion, Version: 1, State: Applied}, statuses[0])
+	assert.True(t, mockConfig.GetBool("dogstatsd_stats_enable"))
+
+	// the agent already runs the latest configuration
+	client.poll()
+	assert.Len(t, statuses, 1)
+
+	// the rotated API key is used by the next polls
+	apiKey = "456"
+	client.SetAPIKey(apiKey)
+	client.poll()
+	assert.Len(t, statuses, 1)
+}
@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package remote
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SignedPayload is the envelope of the configurations sent by the backend. The configuration is
+// kept encoded so that the signatures are verified against the exact bytes that were signed.
+type SignedPayload struct {
+	// Signed is the base64 encoded JSON Configuration
+	Signed string `json:"signed"`
+	// Signatures are the base64 encoded ed25519 signatures of the decoded Signed bytes
+	Signatures []string `json:"signatures"`
+}
+
+// Configuration holds the settings pushed to the agent
+type Configuration struct {
+	// Version is increased by the backend every time the configuration changes
+	Version uint64 `json:"version"`
+	// Expires is the time after which the configuration must not be applied anymore, it's mandatory
+	Expires time.Time `json:"expires"`
+	// Hostname is the hostname of the agent the configuration is for, it's mandatory
+	Hostname string `json:"hostname"`
+	// APIKeyHash is the hex encoded SHA-256 of the API key of the agent, binding the configuration
+	// to the org of the agent, it's mandatory
+	APIKeyHash string `json:"api_key_hash"`
+	// Settings maps configuration keys to their remote value
+	Settings map[string]interface{} `json:"settings"`
+}
+
+// ParsePublicKeys decodes the base64 encoded ed25519 public keys trusted to sign the configurations
+func ParsePublicKeys(encodedKeys []string) ([]ed25519.PublicKey, error) {
+	keys := make([]ed25519.PublicKey, 0, len(encodedKeys))
+	for _, encodedKey := range encodedKeys {
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key %q: %s", encodedKey, err)
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key %q: expected %d bytes, got %d", encodedKey, ed25519.PublicKeySize, len(key))
+		}
+		keys = append(keys, ed25519.PublicKey(key))
+	}
+	return keys, nil
+}
+
+// Verify checks that the payload was signed by one of the keys and returns the configuration it holds
+func (p *SignedPayload) Verify(keys []ed25519.PublicKey, now time.Time) (*Configuration, error) {
+	signed, err := base64.StdEncoding.DecodeString(p.Signed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signed configuration: %s", err)
+	}
+
+	if !isSigned(signed, p.Signatures, keys) {
+		return nil, errors.New("the configuration isn't signed by any of the trusted keys")
+	}
+
+	var configuration Configuration
+	if err := json.Unmarshal(signed, &configuration); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %s", err)
+	}
+
+	// a configuration without expiry could be replayed forever
+	if configuration.Expires.IsZero() {
+		return nil, fmt.Errorf("configuration %d has no expiry", configuration.Version)
+	}
+	if now.After(configuration.Expires) {
+		return nil, fmt.Errorf("configuration %d expired at %s", configuration.Version, configuration.Expires)
+	}
+
+	return &configuration, nil
+}
+
+// CheckTarget checks that the configuration was signed for the agent with the given hostname and API key,
+// so that it can't be replayed to the other agents
+func (c *Configuration) CheckTarget(hostname, apiKey string) error {
+	if c.Hostname == "" || c.APIKeyHash == "" {
+		return fmt.Errorf("configuration %d isn't bound to a hostname and an API key", c.Version)
+	}
+	if c.Hostname != hostname {
+		return fmt.Errorf("configuration %d is for the host %s", c.Version, c.Hostname)
+	}
+	if c.APIKeyHash != HashAPIKey(apiKey) {
+		return fmt.Errorf("configuration %d is for another API key", c.Version)
+	}
+	return nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 of an API key
+func HashAPIKey(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])
+}
+
+func isSigned(signed []byte, signatures []string, keys []ed25519.PublicKey) bool {
+	for _, encodedSignature := range signatures {
+		signature, err := base64.StdEncoding.DecodeString(encodedSignature)
+		if err != nil {
+			continue
+		}
+		for _, key := range keys {
+			if ed25519.Verify(key, signed, signature) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of the values accepted for a configuration key
+type Kind string
+
+// Declare every known Kind
+const (
+	// KindUnknown is used for the keys whose values aren't validated
+	KindUnknown Kind = ""
+	KindBool    Kind = "boolean"
+	KindInt     Kind = "integer"
+	KindFloat   Kind = "number"
+	KindString  Kind = "string"
+	KindList    Kind = "list"
+	KindMap     Kind = "map"
+	// KindSize is a size in bytes, given as a number of bytes or with a unit, see ParseBytesSize
+	KindSize Kind = "size"
+	// KindDuration is a duration, given as a number of units or with its own unit, see ParseDuration
+	KindDuration Kind = "duration"
+)
+
+// KeySchema describes the values accepted for a configuration key. The kind of the keys having a
+// default value is inferred from it when it isn't set.
+type KeySchema struct {
+	Kind Kind
+	// HasRange enables the check of the numeric values against Min and Max, both included
+	HasRange bool
+	Min      float64
+	Max      float64
+	// Enum lists the accepted values of the key, compared case-insensitively
+	Enum []string
+	// DeprecatedBy is the key to use instead of a deprecated key
+	DeprecatedBy string
+}
+
+var portSchema = KeySchema{HasRange: true, Min: 0, Max: 65535}
+
+// schemas holds the ranges, enum values and deprecations of the keys of the Datadog configuration,
+// on top of the kinds inferred from their default values
+var schemas = map[string]KeySchema{
+	"log_level":                     {Enum: []string{"trace", "debug", "info", "warn", "warning", "error", "err", "critical", "off"}},
+	"python_version":                {Enum: []string{"2", "3"}},
+	"cmd_port":                      portSchema,
+	"gui_port":                      {HasRange: true, Min: -1, Max: 65535},
+	"health_port":                   portSchema,
+	"dogstatsd_port":                portSchema,
+	"dogstatsd_stats_port":          portSchema,
+	"statsd_forward_port":           portSchema,
+	"cluster_agent.cmd_port":        portSchema,
+	"security_agent.cmd_port":       portSchema,
+	"security_agent.expvar_port":    portSchema,
+	"logs_config.dd_port":           portSchema,
+	"apm_config.receiver_port":      portSchema,
+	"logs_config.compression_level": {HasRange: true, Min: 0, Max: 9},
+	"check_runners":                 {HasRange: true, Min: 0, Max: 1000},
+	"forwarder_num_workers":         {HasRange: true, Min: 1, Max: 1000},
+	"log_enabled":                   {DeprecatedBy: "logs_enabled"},
+	"tracemalloc_whitelist":         {DeprecatedBy: "tracemalloc_include"},
+	"tracemalloc_blacklist":         {DeprecatedBy: "tracemalloc_exclude"},
+	"process_config.orchestrator_dd_url": {
+		DeprecatedBy: "orchestrator_explorer.orchestrator_dd_url",
+	},
+
+	// the sizes and durations accept units, like "128MiB" or "500ms"
+	"forwarder_retry_queue_payloads_max_size":               {Kind: KindSize},
+	"log_file_max_size":                                     {Kind: KindSize},
+	"serializer_max_payload_size":                           {Kind: KindSize},
+	"serializer_max_uncompressed_payload_size":              {Kind: KindSize},
+	"ad_config_poll_interval":                               {Kind: KindDuration},
+	"admission_controller.certificate.expiration_threshold": {Kind: KindDuration},
+	"admission_controller.certificate.validity_bound":       {Kind: KindDuration},
+	"admission_controller.pod_owners_cache_validity":        {Kind: KindDuration},
+	"aggregator_stop_timeout":                               {Kind: KindDuration},
+	"auth_token_grace_period":                               {Kind: KindDuration},
+	"auth_token_rotation_interval":                          {Kind: KindDuration},
+	"cache_sync_timeout":                                    {Kind: KindDuration},
+	"clc_runner_server_readheader_timeout":                  {Kind: KindDuration},
+	"clc_runner_server_write_timeout":                       {Kind: KindDuration},
+	"cluster_checks.warmup_duration":                        {Kind: KindDuration},
+	"cri_connection_timeout":                                {Kind: KindDuration},
+	"cri_query_timeout":                                     {Kind: KindDuration},
+	"docker_query_timeout":                                  {Kind: KindDuration},
+	"forwarder_stop_timeout":                                {Kind: KindDuration},
+	"forwarder_timeout":                                     {Kind: KindDuration},
+	"inventories_max_interval":                              {Kind: KindDuration},
+	"inventories_min_interval":                              {Kind: KindDuration},
+	"kubelet_cache_pods_duration":                           {Kind: KindDuration},
+	"kubelet_listener_polling_interval":                     {Kind: KindDuration},
+	"kubelet_wait_on_missing_container":                     {Kind: KindDuration},
+	"kubernetes_pod_expiration_duration":                    {Kind: KindDuration},
+	"logs_config.close_timeout":                             {Kind: KindDuration},
+	"logs_config.tagger_warmup_duration":                    {Kind: KindDuration},
+	"metric_metadata_max_interval":                          {Kind: KindDuration},
+	"metric_metadata_min_interval":                          {Kind: KindDuration},
+	"server_shutdown_timeout":                               {Kind: KindDuration},
+	"server_timeout":                                        {Kind: KindDuration},
+}
+
+// SchemaError describes a setting whose value doesn't match the schema of its key
+type SchemaError struct {
+	Key    string
+	Source Source
+	Value  interface{}
+	Reason string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("invalid value %#v for %s (from %s): %s", e.Value, e.Key, e.Source, e.Reason)
+}
+
+// SchemaErrors holds all the settings whose value doesn't match the schema of their key
+type SchemaErrors []*SchemaError
+
+func (e SchemaErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// kindOf returns the kind of the values of a key from its default value
+func kindOf(value interface{}) Kind {
+	if value == nil {
+		return KindUnknown
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Bool:
+		return KindBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if reflect.TypeOf(value) == reflect.TypeOf(time.Duration(0)) {
+			return KindDuration
+		}
+		if reflect.TypeOf(value).PkgPath() != "" {
+			return KindUnknown
+		}
+		return KindInt
+	case reflect.Float32, reflect.Float64:
+		return KindFloat
+	case reflect.String:
+		return KindString
+	case reflect.Slice, reflect.Array:
+		return KindList
+	case reflect.Map:
+		return KindMap
+	}
+	return KindUnknown
+}
+
+// toFloat returns the numeric value of a setting, strings are parsed as they're used by the
+// environment variables
+func toFloat(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// validate returns the reason why the value doesn't match the schema, or an empty string
+func (s KeySchema) validate(value interface{}) string {
+	kind := reflect.ValueOf(value).Kind()
+	isString := kind == reflect.String
+	isCollection := kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+
+	switch s.Kind {
+	case KindBool:
+		if _, ok := value.(bool); ok {
+			return ""
+		}
+		if str, ok := value.(string); ok {
+			if _, err := strconv.ParseBool(strings.TrimSpace(str)); err == nil {
+				return ""
+			}
+		}
+		return "expected a boolean"
+	case KindInt, KindFloat:
+		f, ok := toFloat(value)
+		if !ok && s.Kind == KindFloat {
+			return "expected a number"
+		}
+		if !ok || (s.Kind == KindInt && f != float64(int64(f))) {
+			return "expected an integer"
+		}
+		if s.HasRange && (f < s.Min || f > s.Max) {
+			return fmt.Sprintf("expected a value between %v and %v", s.Min, s.Max)
+		}
+	case KindSize:
+		if _, err := ParseBytesSize(value); err != nil {
+			return err.Error()
+		}
+	case KindDuration:
+		// the unit of the numbers doesn't matter to validate them
+		if _, err := ParseDuration(value, time.Second); err != nil {
+			return err.Error()
+		}
+	case KindString:
+		if isCollection {
+			return "expected a string"
+		}
+	case KindList:
+		// the lists can also be set with space separated strings
+		if !isString && kind != reflect.Slice && kind != reflect.Array {
+			return "expected a list"
+		}
+	case KindMap:
+		// the maps can also be set with JSON strings
+		if !isString && kind != reflect.Map {
+			return "expected a map"
+		}
+	}
+
+	if len(s.Enum) > 0 && !isCollection {
+		str := strings.ToLower(strings.TrimSpace(fmt.Sprint(value)))
+		for _, accepted := range s.Enum {
+			if str == accepted {
+				return ""
+			}
+		}
+		return fmt.Sprintf("expected one of %s", strings.Join(s.Enum, ", "))
+	}
+	return ""
+}
+
+// isEncryptedSecret returns true for the values decrypted by the secrets backend
+func isEncryptedSecret(value interface{}) bool {
+	str, ok := value.(string)
+	return ok && strings.HasPrefix(str, "ENC[") && strings.HasSuffix(str, "]")
+}
+
+// validateSchema checks the settings read from the configuration files and the environment variables
+// against the schema of their key. It returns the invalid settings and the deprecated keys in use,
+// along with the keys replacing them.
+func validateSchema(config Config, schemas map[string]KeySchema) (SchemaErrors, map[string]string) {
+	var errs SchemaErrors
+	deprecated := make(map[string]string)
+
+	for key, setting := range config.GetLayeredView() {
+		schema := schemas[key]
+		if schema.Kind == KindUnknown {
+			schema.Kind = kindOf(setting.Layers[SourceDefault])
+		}
+
+		for _, source := range []Source{SourceFile, SourceEnvVar} {
+			// the empty values, like the ones written by the config import, stand for the default value
+			value, found := setting.Layers[source]
+			if !found || value == nil || value == "" {
+				continue
+			}
+
+			if schema.DeprecatedBy != "" {
+				deprecated[key] = schema.DeprecatedBy
+			}
+			if isEncryptedSecret(value) {
+				continue
+			}
+			if reason := schema.validate(value); reason != "" {
+				errs = append(errs, &SchemaError{Key: key, Source: source, Value: value, Reason: reason})
+			}
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		if errs[i].Key != errs[j].Key {
+			return errs[i].Key < errs[j].Key
+		}
+		return errs[i].Source < errs[j].Source
+	})
+	return errs, deprecated
+}
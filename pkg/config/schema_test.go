@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKindOf(t *testing.T) {
+	assert.Equal(t, KindBool, kindOf(true))
+	assert.Equal(t, KindInt, kindOf(int64(4)))
+	assert.Equal(t, KindFloat, kindOf(0.5))
+	assert.Equal(t, KindString, kindOf(""))
+	assert.Equal(t, KindList, kindOf([]string{}))
+	assert.Equal(t, KindMap, kindOf(map[string]string{}))
+	assert.Equal(t, KindDuration, kindOf(time.Second))
+	assert.Equal(t, KindUnknown, kindOf(nil))
+}
+
+func TestKeySchemaValidate(t *testing.T) {
+	for _, tc := range []struct {
+		schema KeySchema
+		value  interface{}
+		reason string
+	}{
+		{KeySchema{Kind: KindBool}, true, ""},
+		{KeySchema{Kind: KindBool}, "false", ""},
+		{KeySchema{Kind: KindBool}, "yes", "expected a boolean"},
+		{KeySchema{Kind: KindBool}, 1, "expected a boolean"},
+		{KeySchema{Kind: KindInt}, 42, ""},
+		{KeySchema{Kind: KindInt}, "42", ""},
+		{KeySchema{Kind: KindInt}, 4.5, "expected an integer"},
+		{KeySchema{Kind: KindInt}, "abc", "expected an integer"},
+		{KeySchema{Kind: KindFloat}, "0.5", ""},
+		{KeySchema{Kind: KindFloat}, true, "expected a number"},
+		{portSchema, 8125, ""},
+		{KeySchema{Kind: KindInt, HasRange: true, Min: 0, Max: 65535}, 70000, "expected a value between 0 and 65535"},
+		{KeySchema{Kind: KindString}, 5000, ""},
+		{KeySchema{Kind: KindString}, []interface{}{"a"}, "expected a string"},
+		{KeySchema{Kind: KindList}, []interface{}{"a"}, ""},
+		{KeySchema{Kind: KindList}, "a b", ""},
+		{KeySchema{Kind: KindList}, map[string]interface{}{}, "expected a list"},
+		{KeySchema{Kind: KindMap}, map[interface{}]interface{}{}, ""},
+		{KeySchema{Kind: KindMap}, true, "expected a map"},
+		{KeySchema{Kind: KindString, Enum: []string{"info", "debug"}}, "DEBUG", ""},
+		{KeySchema{Kind: KindString, Enum: []string{"info", "debug"}}, "verbose", "expected one of info, debug"},
+		{KeySchema{Kind: KindSize}, "128MiB", ""},
+		{KeySchema{Kind: KindSize}, 4096, ""},
+		{KeySchema{Kind: KindSize}, "12 parsecs", `unknown size unit "parsecs" in "12 parsecs", expected one of B, KB, MB, GB, TB, KiB, MiB, GiB, TiB`},
+		{KeySchema{Kind: KindDuration}, "500ms", ""},
+		{KeySchema{Kind: KindDuration}, 15, ""},
+		{KeySchema{Kind: KindDuration}, "soon", `expected a duration like 500ms or 2h, got "soon"`},
+		{KeySchema{}, []interface{}{1}, ""},
+	} {
+		assert.Equal(t, tc.reason, tc.schema.validate(tc.value), "%v %#v", tc.schema, tc.value)
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	config := NewConfig("test", "DD", strings.NewReplacer(".", "_"))
+	config.SetConfigType("yaml")
+	config.BindEnvAndSetDefault("enabled", true)
+	config.BindEnvAndSetDefault("port", 5001)
+	config.BindEnvAndSetDefault("level", "info")
+	config.BindEnvAndSetDefault("tags", []string{})
+	config.BindEnvAndSetDefault("old_enabled", false)
+	config.BindEnvAndSetDefault("secret_port", 0)
+	config.BindEnvAndSetDefault("empty_level", "info")
+
+	os.Setenv("DD_PORT", "not a port")
+	defer os.Unsetenv("DD_PORT")
+
+	err := config.ReadConfig(strings.NewReader("enabled: yes please\nlevel: verbose\ntags: [a, b]\nold_enabled: true\nsecret_port: ENC[port]\nempty_level: \"\"\n"))
+	require.NoError(t, err)
+
+	schemas := map[string]KeySchema{
+		"port":        portSchema,
+		"level":       {Enum: []string{"info", "debug"}},
+		"old_enabled": {DeprecatedBy: "enabled"},
+		"empty_level": {Enum: []string{"info", "debug"}},
+	}
+	errs, deprecated := validateSchema(config, schemas)
+
+	assert.Equal(t, SchemaErrors{
+		{Key: "enabled", Source: SourceFile, Value: "yes please", Reason: "expected a boolean"},
+		{Key: "level", Source: SourceFile, Value: "verbose", Reason: "expected one of info, debug"},
+		{Key: "port", Source: SourceEnvVar, Value: "not a port", Reason: "expected an integer"},
+	}, errs)
+	assert.Equal(t, map[string]string{"old_enabled": "enabled"}, deprecated)
+	assert.Equal(t, `invalid value "yes please" for enabled (from file): expected a boolean`, errs[0].Error())
+}
+
+func TestLoadSchemaErrors(t *testing.T) {
+	f, err := ioutil.TempFile("", "datadog-*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("cmd_port: 70000\nlog_enabled: true\nunknown_key: true\n")
+	require.NoError(t, err)
+	f.Close()
+
+	// the invalid environment variables are only reported as warnings
+	os.Setenv("DD_DOGSTATSD_PORT", "not a port")
+	defer os.Unsetenv("DD_DOGSTATSD_PORT")
+
+	config := setupConf()
+	config.SetConfigFile(f.Name())
+
+	var warnings *Warnings
+	warnings, err = load(config, "datadog.yaml", false)
+	require.Error(t, err)
+	schemaErrors, ok := err.(SchemaErrors)
+	require.True(t, ok)
+	require.Len(t, schemaErrors, 1)
+	assert.Equal(t, "cmd_port", schemaErrors[0].Key)
+
+	assert.Equal(t, []string{"unknown_key"}, warnings.UnknownKeys)
+	assert.Equal(t, map[string]string{"log_enabled": "logs_enabled"}, warnings.DeprecatedKeys)
+}
@@ -0,0 +1,221 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/secrets"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// resolvedConfig holds the settings of a configuration referencing secrets, with their encrypted value
+type resolvedConfig struct {
+	origin   string
+	settings map[string]interface{}
+}
+
+var (
+	secretsMutex          sync.Mutex
+	resolvedConfigs       = make(map[Config]*resolvedConfig)
+	secretRefreshHandlers []func(keys []string)
+	secretRefreshStop     chan struct{}
+)
+
+// hasEncryptedValue returns true if the value, or one of the values it contains, is an encrypted secret
+func hasEncryptedValue(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return isEncryptedSecret(strings.Trim(v, " \t"))
+	case []string:
+		for _, item := range v {
+			if hasEncryptedValue(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if hasEncryptedValue(item) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if hasEncryptedValue(item) {
+				return true
+			}
+		}
+	case map[interface{}]interface{}:
+		for _, item := range v {
+			if hasEncryptedValue(item) {
+				return true
+			}
+		}
+	case map[string]string:
+		for _, item := range v {
+			if hasEncryptedValue(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// encryptedSettings returns the settings of a configuration referencing secrets, indexed by key
+func encryptedSettings(config Config) map[string]interface{} {
+	settings := make(map[string]interface{})
+	for _, key := range config.AllKeys() {
+		if value := config.Get(key); hasEncryptedValue(value) {
+			settings[key] = value
+		}
+	}
+	return settings
+}
+
+// resolveSettings decrypts the secrets referenced by the settings and overrides them in the configuration
+func resolveSettings(config Config, settings map[string]interface{}, origin string) error {
+	// the settings are merged as a single document, so that the secret backend is called only once
+	doc := make(map[string]interface{})
+	for key, value := range settings {
+		setNested(doc, strings.Split(key, "."), value)
+	}
+
+	yamlConf, err := yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("unable to marshal configuration to YAML to decrypt secrets: %v", err)
+	}
+	finalYamlConf, err := secrets.Decrypt(yamlConf, origin)
+	if err != nil {
+		return fmt.Errorf("unable to decrypt secret from %s: %v", origin, err)
+	}
+	if err := config.MergeConfigOverride(bytes.NewReader(finalYamlConf)); err != nil {
+		return fmt.Errorf("could not update main configuration after decrypting secrets: %v", err)
+	}
+	return nil
+}
+
+// setNested sets a value in a tree of maps, creating the intermediate maps
+func setNested(doc map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := doc[key].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			doc[key] = child
+		}
+		doc = child
+	}
+	doc[path[len(path)-1]] = value
+}
+
+// trackEncryptedSettings keeps the encrypted value of the settings, to resolve them again when a secret is rotated
+func trackEncryptedSettings(config Config, settings map[string]interface{}, origin string) {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	resolvedConfigs[config] = &resolvedConfig{origin: origin, settings: settings}
+}
+
+// OnSecretRefresh registers a handler called with the keys of the settings updated when the secrets are refreshed
+func OnSecretRefresh(handler func(keys []string)) {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	secretRefreshHandlers = append(secretRefreshHandlers, handler)
+}
+
+// RefreshSecrets fetches the secrets again from the secret backend and resolves the settings referencing
+// the secrets that changed. When the secret backend fails, the settings keep their previous value.
+func RefreshSecrets() error {
+	changed, err := secrets.Refresh()
+	if err != nil {
+		return fmt.Errorf("unable to refresh the secrets: %v", err)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	log.Infof("%d secrets changed, updating the settings using them", len(changed))
+
+	secretsMutex.Lock()
+	updated := make(map[string]struct{})
+	for config, resolved := range resolvedConfigs {
+		previous := make(map[string]interface{}, len(resolved.settings))
+		for key := range resolved.settings {
+			previous[key] = config.Get(key)
+		}
+		if err := resolveSettings(config, resolved.settings, resolved.origin); err != nil {
+			secretsMutex.Unlock()
+			return err
+		}
+		for key, value := range previous {
+			if !reflect.DeepEqual(value, config.Get(key)) {
+				updated[key] = struct{}{}
+			}
+		}
+	}
+	handlers := append([]func(keys []string){}, secretRefreshHandlers...)
+	secretsMutex.Unlock()
+
+	if len(updated) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(updated))
+	for key := range updated {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	log.Infof("Settings updated after the rotation of their secret: %s", strings.Join(keys, ", "))
+	for _, handler := range handlers {
+		handler(keys)
+	}
+	return nil
+}
+
+// StartSecretRefresh refreshes the secrets every secret_backend_refresh_interval seconds, if set
+func StartSecretRefresh() {
+	interval := Datadog.GetInt("secret_backend_refresh_interval")
+	if interval <= 0 || Datadog.GetString("secret_backend_command") == "" {
+		return
+	}
+
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	if secretRefreshStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	secretRefreshStop = stop
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := RefreshSecrets(); err != nil {
+					log.Errorf("%v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopSecretRefresh stops the periodic refresh of the secrets
+func StopSecretRefresh() {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	if secretRefreshStop != nil {
+		close(secretRefreshStop)
+		secretRefreshStop = nil
+	}
+}
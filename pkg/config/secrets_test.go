@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasEncryptedValue(t *testing.T) {
+	assert.True(t, hasEncryptedValue("ENC[api_key]"))
+	assert.True(t, hasEncryptedValue(" ENC[api_key] "))
+	assert.True(t, hasEncryptedValue([]string{"tag", "ENC[tag]"}))
+	assert.True(t, hasEncryptedValue([]interface{}{"tag", map[interface{}]interface{}{"password": "ENC[password]"}}))
+	assert.True(t, hasEncryptedValue(map[string]interface{}{"https://app.datadoghq.com": []interface{}{"ENC[key]"}}))
+	assert.True(t, hasEncryptedValue(map[string]string{"header": "ENC[header]"}))
+
+	assert.False(t, hasEncryptedValue("api_key"))
+	assert.False(t, hasEncryptedValue("prefix ENC[api_key]"))
+	assert.False(t, hasEncryptedValue(42))
+	assert.False(t, hasEncryptedValue([]string{"tag"}))
+	assert.False(t, hasEncryptedValue(nil))
+}
+
+func TestEncryptedSettings(t *testing.T) {
+	config := setupConfFromYAML(`
+api_key: ENC[api_key]
+site: datadoghq.eu
+tags:
+- ENC[tag]
+- env:prod
+logs_config:
+  use_http: true
+  custom_setting: ENC[custom]
+`)
+
+	assert.Equal(t, map[string]interface{}{
+		"api_key":                    "ENC[api_key]",
+		"tags":                       []string{"ENC[tag]", "env:prod"},
+		"logs_config.custom_setting": "ENC[custom]",
+	}, encryptedSettings(config))
+}
+
+func TestSetNested(t *testing.T) {
+	doc := make(map[string]interface{})
+	setNested(doc, []string{"api_key"}, "ENC[api_key]")
+	setNested(doc, []string{"logs_config", "custom_setting"}, "ENC[custom]")
+	setNested(doc, []string{"logs_config", "api_key"}, "ENC[logs]")
+
+	assert.Equal(t, map[string]interface{}{
+		"api_key": "ENC[api_key]",
+		"logs_config": map[string]interface{}{
+			"custom_setting": "ENC[custom]",
+			"api_key":        "ENC[logs]",
+		},
+	}, doc)
+}
@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+// Source is the origin of the value of a configuration setting
+type Source string
+
+// Declare every known Source
+const (
+	// SourceUnknown is used for keys that are neither set nor known
+	SourceUnknown Source = "unknown"
+	// SourceDefault is used for values coming from the defaults set in InitConfig
+	SourceDefault Source = "default"
+	// SourceFile is used for values read from the configuration files
+	SourceFile Source = "file"
+	// SourceEnvVar is used for values read from the environment variables
+	SourceEnvVar Source = "environment-variable"
+	// SourceCLI is used for values read from the command line flags
+	SourceCLI Source = "cli"
+	// SourceRemote is used for values received from a remote source
+	SourceRemote Source = "remote"
+	// SourceRuntime is used for values overridden at runtime, by the agent itself or through a runtime setting
+	SourceRuntime Source = "runtime"
+)
+
+// overrideSourcesPrecedence are the sources overriding the values of the settings, by decreasing precedence
+var overrideSourcesPrecedence = []Source{SourceRuntime, SourceRemote}
+
+// LayeredSetting holds the effective value of a setting, its source and
+// the value it has in every layer where it is defined
+type LayeredSetting struct {
+	Value  interface{}            `json:"value"`
+	Source Source                 `json:"source"`
+	Layers map[Source]interface{} `json:"layers"`
+}
+
+// GetSource returns the source of the value of a key of the Datadog configuration
+func GetSource(key string) Source {
+	return Datadog.GetSource(key)
+}
@@ -21,6 +21,7 @@ import (
 // - files
 // - environment variables
 // - flags
+// - runtime and remote overrides
 type Config interface {
 
 	// API implemented by viper.Viper
@@ -85,4 +86,20 @@ type Config interface {
 	BindEnvAndSetDefault(key string, val interface{}, env ...string)
 	// GetEnvVars returns a list of the non-sensitive env vars that the config supports
 	GetEnvVars() []string
+
+	// SetWithSource overrides the value of a key and records where the new value comes from
+	SetWithSource(key string, value interface{}, source Source)
+	// UnsetForSource removes the value set by a source for a key, which falls back to its value in the next layer
+	UnsetForSource(key string, source Source)
+	// GetSource returns the source of the effective value of a key
+	GetSource(key string) Source
+	// GetLayeredView returns, for every key, its effective value and source along with its value in each layer
+	GetLayeredView() map[string]LayeredSetting
+
+	// GetBytesSize returns the size in bytes of a setting, given in bytes or with a unit like "128MiB" or "1.5GB".
+	// The default value of the key is returned when the setting isn't a valid size.
+	GetBytesSize(key string) uint64
+	// GetDurationWithUnit returns the duration of a setting, given as a number of units or with its own unit like
+	// "500ms" or "2h". The default value of the key is returned when the setting isn't a valid duration.
+	GetDurationWithUnit(key string, unit time.Duration) time.Duration
 }
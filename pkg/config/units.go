@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// byteSizeUnits maps the suffixes of the sizes to their multiplier, KB, MB, GB and TB being powers of 1024
+// like KiB, MiB, GiB and TiB, as they are for the sizes parsed by viper and seelog
+var byteSizeUnits = map[string]float64{
+	"b":   1,
+	"kb":  1 << 10,
+	"mb":  1 << 20,
+	"gb":  1 << 30,
+	"tb":  1 << 40,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// ParseBytesSize returns the size in bytes of a setting. The numbers are sizes in bytes, the strings are
+// numbers optionally followed by a unit, like "512", "1.5GB" or "128MiB". A nil value is a size of 0.
+func ParseBytesSize(value interface{}) (uint64, error) {
+	if value == nil {
+		return 0, nil
+	}
+
+	str, isString := value.(string)
+	if !isString {
+		size, ok := toFloat(value)
+		if !ok {
+			return 0, fmt.Errorf("expected a size, got %#v", value)
+		}
+		return checkBytesSize(size, 1, value)
+	}
+
+	str = strings.TrimSpace(str)
+	end := strings.LastIndexAny(str, "0123456789.") + 1
+	number, unit := strings.TrimSpace(str[:end]), strings.ToLower(strings.TrimSpace(str[end:]))
+
+	multiplier := 1.0
+	if unit != "" {
+		var found bool
+		if multiplier, found = byteSizeUnits[unit]; !found {
+			return 0, fmt.Errorf("unknown size unit %q in %q, expected one of B, KB, MB, GB, TB, KiB, MiB, GiB, TiB", strings.TrimSpace(str[end:]), str)
+		}
+	}
+
+	size, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected a size, got %q", str)
+	}
+	return checkBytesSize(size, multiplier, value)
+}
+
+// checkBytesSize returns the size in bytes of a number of units, rejecting the negative and the too large sizes
+func checkBytesSize(size float64, multiplier float64, value interface{}) (uint64, error) {
+	bytes := math.Round(size * multiplier)
+	if size < 0 || bytes >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid size %#v", value)
+	}
+	return uint64(bytes), nil
+}
+
+// ParseDuration returns the duration of a setting. The numbers are durations in the given unit, the strings
+// are either numbers, also in the given unit, or durations in the format of time.ParseDuration, like "500ms",
+// "2h" or "1h30m". Negative durations are rejected. A nil value is a duration of 0.
+func ParseDuration(value interface{}, unit time.Duration) (time.Duration, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case time.Duration:
+		if v < 0 {
+			return 0, fmt.Errorf("invalid negative duration %s", v)
+		}
+		return v, nil
+	case string:
+		str := strings.TrimSpace(v)
+		if number, err := strconv.ParseFloat(str, 64); err == nil {
+			return checkDuration(number, unit, value)
+		}
+		duration, err := time.ParseDuration(str)
+		if err != nil {
+			return 0, fmt.Errorf("expected a duration like 500ms or 2h, got %q", v)
+		}
+		if duration < 0 {
+			return 0, fmt.Errorf("invalid negative duration %q", v)
+		}
+		return duration, nil
+	}
+
+	if kind := reflect.ValueOf(value).Kind(); kind == reflect.Bool {
+		return 0, fmt.Errorf("expected a duration, got %#v", value)
+	}
+	number, ok := toFloat(value)
+	if !ok {
+		return 0, fmt.Errorf("expected a duration, got %#v", value)
+	}
+	return checkDuration(number, unit, value)
+}
+
+// checkDuration returns the duration of a number of units, rejecting the negative and the too long durations
+func checkDuration(number float64, unit time.Duration, value interface{}) (time.Duration, error) {
+	duration := number * float64(unit)
+	if number < 0 || duration >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration %#v", value)
+	}
+	return time.Duration(duration), nil
+}
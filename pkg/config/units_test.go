@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBytesSize(t *testing.T) {
+	for _, tc := range []struct {
+		value interface{}
+		size  uint64
+		err   bool
+	}{
+		{nil, 0, false},
+		{512, 512, false},
+		{int64(1 << 40), 1 << 40, false},
+		{"512", 512, false},
+		{"512B", 512, false},
+		{"128MiB", 128 << 20, false},
+		{"10Mb", 10 << 20, false},
+		{"1.5GB", 3 << 29, false},
+		{" 2 kib ", 2048, false},
+		{-1, 0, true},
+		{"-1KB", 0, true},
+		{"12 parsecs", 0, true},
+		{"MB", 0, true},
+		{true, 0, true},
+	} {
+		size, err := ParseBytesSize(tc.value)
+		if tc.err {
+			assert.Error(t, err, "%#v", tc.value)
+			continue
+		}
+		assert.NoError(t, err, "%#v", tc.value)
+		assert.Equal(t, tc.size, size, "%#v", tc.value)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for _, tc := range []struct {
+		value    interface{}
+		unit     time.Duration
+		duration time.Duration
+		err      bool
+	}{
+		{nil, time.Second, 0, false},
+		{15, time.Second, 15 * time.Second, false},
+		{"15", time.Minute, 15 * time.Minute, false},
+		{0.5, time.Second, 500 * time.Millisecond, false},
+		{"500ms", time.Second, 500 * time.Millisecond, false},
+		{"2h", time.Second, 2 * time.Hour, false},
+		{"1h30m", time.Minute, 90 * time.Minute, false},
+		{time.Minute, time.Second, time.Minute, false},
+		{-5, time.Second, 0, true},
+		{"-5s", time.Second, 0, true},
+		{"soon", time.Second, 0, true},
+		{true, time.Second, 0, true},
+	} {
+		duration, err := ParseDuration(tc.value, tc.unit)
+		if tc.err {
+			assert.Error(t, err, "%#v", tc.value)
+			continue
+		}
+		assert.NoError(t, err, "%#v", tc.value)
+		assert.Equal(t, tc.duration, duration, "%#v", tc.value)
+	}
+}
@@ -6,7 +6,10 @@
 package config
 
 import (
+	"bytes"
 	"io"
+	"io/ioutil"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -21,18 +24,78 @@ import (
 // safeConfig implements Config:
 // - wraps viper with a safety lock
 // - implements the additional DDHelpers
+// - keeps track of the source of every setting
 type safeConfig struct {
 	*viper.Viper
 	sync.RWMutex
-	envPrefix     string
-	configEnvVars []string
+	envPrefix      string
+	envKeyReplacer *strings.Replacer
+	configEnvVars  []string
+
+	// fileLayer only holds the settings read from the configuration files
+	fileLayer *viper.Viper
+	// defaults holds the default value of every key
+	defaults map[string]interface{}
+	// overrides holds the values set through Set, by source
+	overrides map[Source]map[string]interface{}
+	// overrideSources holds the source of the last override of every key
+	overrideSources map[string]Source
+	// envBindings holds the environment variables bound to every key
+	envBindings map[string][]string
+	// flags holds the command line flags bound to every key
+	flags map[string]*pflag.Flag
+}
+
+// Set wraps Viper for concurrent access, the value is considered to be set at runtime
+func (c *safeConfig) Set(key string, value interface{}) {
+	c.SetWithSource(key, value, SourceRuntime)
 }
 
-// Set wraps Viper for concurrent access
-func (c *safeConfig) Set(key string, value interface{}) {
+// SetWithSource wraps Viper for concurrent access, and records the source of the value
+func (c *safeConfig) SetWithSource(key string, value interface{}, source Source) {
 	c.Lock()
 	defer c.Unlock()
 	c.Viper.Set(key, value)
+
+	key = strings.ToLower(key)
+	if c.overrides == nil {
+		c.overrides = make(map[Source]map[string]interface{})
+		c.overrideSources = make(map[string]Source)
+	}
+	if c.overrides[source] == nil {
+		c.overrides[source] = make(map[string]interface{})
+	}
+	c.overrides[source][key] = value
+	c.overrideSources[key] = source
+}
+
+// UnsetForSource removes the override of a key set by the given source. The key falls back to the
+// override of another source if there is one, or to its value from the flags, the environment,
+// the configuration files or the defaults.
+func (c *safeConfig) UnsetForSource(key string, source Source) {
+	c.Lock()
+	defer c.Unlock()
+
+	key = strings.ToLower(key)
+	if _, found := c.overrides[source][key]; !found {
+		return
+	}
+	delete(c.overrides[source], key)
+	if c.overrideSources[key] != source {
+		// the value of another source overrides it
+		return
+	}
+	delete(c.overrideSources, key)
+
+	for _, other := range overrideSourcesPrecedence {
+		if value, found := c.overrides[other][key]; found {
+			c.Viper.Set(key, value)
+			c.overrideSources[key] = other
+			return
+		}
+	}
+	// viper has no way to delete an override, but ignores the nil ones
+	c.Viper.Set(key, nil)
 }
 
 // SetDefault wraps Viper for concurrent access
@@ -40,6 +103,11 @@ func (c *safeConfig) SetDefault(key string, value interface{}) {
 	c.Lock()
 	defer c.Unlock()
 	c.Viper.SetDefault(key, value)
+
+	if c.defaults == nil {
+		c.defaults = make(map[string]interface{})
+	}
+	c.defaults[strings.ToLower(key)] = value
 }
 
 // SetKnown adds a key to the set of known valid config keys
@@ -70,6 +138,7 @@ func (c *safeConfig) SetFs(fs afero.Fs) {
 	c.Lock()
 	defer c.Unlock()
 	c.Viper.SetFs(fs)
+	c.getFileLayer().SetFs(fs)
 }
 
 // IsSet wraps Viper for concurrent access
@@ -222,6 +291,30 @@ func (c *safeConfig) GetSizeInBytes(key string) uint {
 	return val
 }
 
+// GetBytesSize implements the Config interface
+func (c *safeConfig) GetBytesSize(key string) uint64 {
+	c.RLock()
+	defer c.RUnlock()
+	size, err := ParseBytesSize(c.Viper.GetRaw(key))
+	if err != nil {
+		log.Warnf("failed to get configuration value for key %q, using its default value: %s", key, err)
+		size, _ = ParseBytesSize(c.defaults[strings.ToLower(key)])
+	}
+	return size
+}
+
+// GetDurationWithUnit implements the Config interface
+func (c *safeConfig) GetDurationWithUnit(key string, unit time.Duration) time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	duration, err := ParseDuration(c.Viper.GetRaw(key), unit)
+	if err != nil {
+		log.Warnf("failed to get configuration value for key %q, using its default value: %s", key, err)
+		duration, _ = ParseDuration(c.defaults[strings.ToLower(key)], unit)
+	}
+	return duration
+}
+
 // SetEnvPrefix wraps Viper for concurrent access, and keeps the envPrefix for
 // future reference
 func (c *safeConfig) SetEnvPrefix(in string) {
@@ -235,21 +328,33 @@ func (c *safeConfig) SetEnvPrefix(in string) {
 func (c *safeConfig) BindEnv(input ...string) error {
 	c.Lock()
 	defer c.Unlock()
+	if len(input) == 0 {
+		return c.Viper.BindEnv(input...)
+	}
+
+	key := strings.ToLower(input[0])
+	envVarNames := input[1:]
 	if len(input) == 1 {
 		// FIXME: for the purposes of GetEnvVars implementation, we only track env var keys
 		// that are interpolated by viper from the config option key name
-		key := input[0]
 		envVarName := strings.Join([]string{c.envPrefix, strings.ToUpper(key)}, "_")
 		c.configEnvVars = append(c.configEnvVars, envVarName)
+		envVarNames = []string{envVarName}
+	}
+
+	if c.envBindings == nil {
+		c.envBindings = make(map[string][]string)
 	}
+	c.envBindings[key] = append(c.envBindings[key], envVarNames...)
 	return c.Viper.BindEnv(input...)
 }
 
 // SetEnvKeyReplacer wraps Viper for concurrent access
 func (c *safeConfig) SetEnvKeyReplacer(r *strings.Replacer) {
-	c.RLock()
-	defer c.RUnlock()
+	c.Lock()
+	defer c.Unlock()
 	c.Viper.SetEnvKeyReplacer(r)
+	c.envKeyReplacer = r
 }
 
 // UnmarshalKey wraps Viper for concurrent access
@@ -277,30 +382,55 @@ func (c *safeConfig) UnmarshalExact(rawVal interface{}) error {
 func (c *safeConfig) ReadInConfig() error {
 	c.Lock()
 	defer c.Unlock()
-	return c.Viper.ReadInConfig()
+	if err := c.Viper.ReadInConfig(); err != nil {
+		return err
+	}
+
+	fileLayer := c.getFileLayer()
+	fileLayer.SetConfigFile(c.Viper.ConfigFileUsed())
+	if err := fileLayer.ReadInConfig(); err != nil {
+		log.Debugf("Unable to track the settings of the configuration file %s: %s", c.Viper.ConfigFileUsed(), err)
+	}
+	return nil
 }
 
 // ReadConfig wraps Viper for concurrent access
 func (c *safeConfig) ReadConfig(in io.Reader) error {
 	c.Lock()
 	defer c.Unlock()
-	return c.Viper.ReadConfig(in)
+	return c.readConfig(in, c.Viper.ReadConfig, c.getFileLayer().ReadConfig)
 }
 
 // MergeConfig wraps Viper for concurrent access
 func (c *safeConfig) MergeConfig(in io.Reader) error {
 	c.Lock()
 	defer c.Unlock()
-	return c.Viper.MergeConfig(in)
+	return c.readConfig(in, c.Viper.MergeConfig, c.getFileLayer().MergeConfig)
 }
 
-// MergeConfigOverride wraps Viper for concurrent access
+// MergeConfigOverride wraps Viper for concurrent access. It is used to resolve the
+// secrets of the whole configuration, so the sources of the settings are left untouched.
 func (c *safeConfig) MergeConfigOverride(in io.Reader) error {
 	c.Lock()
 	defer c.Unlock()
 	return c.Viper.MergeConfigOverride(in)
 }
 
+// readConfig reads a configuration with the given method, and applies it to the file layer
+func (c *safeConfig) readConfig(in io.Reader, read func(io.Reader) error, readFileLayer func(io.Reader) error) error {
+	content, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	if err := read(bytes.NewReader(content)); err != nil {
+		return err
+	}
+	if err := readFileLayer(bytes.NewReader(content)); err != nil {
+		log.Debugf("Unable to track the settings of the configuration: %s", err)
+	}
+	return nil
+}
+
 // AllSettings wraps Viper for concurrent access
 func (c *safeConfig) AllSettings() map[string]interface{} {
 	c.Lock()
@@ -334,6 +464,7 @@ func (c *safeConfig) SetConfigType(in string) {
 	c.Lock()
 	defer c.Unlock()
 	c.Viper.SetConfigType(in)
+	c.getFileLayer().SetConfigType(in)
 }
 
 // ConfigFileUsed wraps Viper for concurrent access
@@ -347,7 +478,15 @@ func (c *safeConfig) ConfigFileUsed() string {
 func (c *safeConfig) BindPFlag(key string, flag *pflag.Flag) error {
 	c.Lock()
 	defer c.Unlock()
-	return c.Viper.BindPFlag(key, flag)
+	if err := c.Viper.BindPFlag(key, flag); err != nil {
+		return err
+	}
+
+	if c.flags == nil {
+		c.flags = make(map[string]*pflag.Flag)
+	}
+	c.flags[strings.ToLower(key)] = flag
+	return nil
 }
 
 // GetEnvVars implements the Config interface
@@ -361,10 +500,98 @@ func (c *safeConfig) BindEnvAndSetDefault(key string, val interface{}, env ...st
 	c.BindEnv(append([]string{key}, env...)...) //nolint:errcheck
 }
 
+// GetSource implements the Config interface
+func (c *safeConfig) GetSource(key string) Source {
+	c.RLock()
+	defer c.RUnlock()
+	return c.getSource(strings.ToLower(key))
+}
+
+// GetLayeredView implements the Config interface
+func (c *safeConfig) GetLayeredView() map[string]LayeredSetting {
+	c.Lock()
+	defer c.Unlock()
+
+	view := make(map[string]LayeredSetting)
+	for _, key := range c.Viper.AllKeys() {
+		layers := make(map[Source]interface{})
+		if value, found := c.defaults[key]; found {
+			layers[SourceDefault] = value
+		}
+		if c.fileLayer != nil && c.fileLayer.IsSet(key) {
+			layers[SourceFile] = c.fileLayer.Get(key)
+		}
+		if value, found := c.lookupEnv(key); found {
+			layers[SourceEnvVar] = value
+		}
+		if flag, found := c.flags[key]; found && flag.Changed {
+			layers[SourceCLI] = flag.Value.String()
+		}
+		for source, overrides := range c.overrides {
+			if value, found := overrides[key]; found {
+				layers[source] = value
+			}
+		}
+
+		view[key] = LayeredSetting{
+			Value:  c.Viper.Get(key),
+			Source: c.getSource(key),
+			Layers: layers,
+		}
+	}
+	return view
+}
+
+// getSource returns the source of a lowercase key, following the viper precedence order.
+// The caller must hold the lock.
+func (c *safeConfig) getSource(key string) Source {
+	if source, found := c.overrideSources[key]; found {
+		return source
+	}
+	if flag, found := c.flags[key]; found && flag.Changed {
+		return SourceCLI
+	}
+	if _, found := c.lookupEnv(key); found {
+		return SourceEnvVar
+	}
+	if c.fileLayer != nil && c.fileLayer.IsSet(key) {
+		return SourceFile
+	}
+	if _, found := c.defaults[key]; found {
+		return SourceDefault
+	}
+	return SourceUnknown
+}
+
+// lookupEnv returns the value of the first non-empty environment variable bound to
+// a lowercase key. The caller must hold the lock.
+func (c *safeConfig) lookupEnv(key string) (string, bool) {
+	for _, envVarName := range c.envBindings[key] {
+		if c.envKeyReplacer != nil {
+			envVarName = c.envKeyReplacer.Replace(envVarName)
+		}
+		// viper ignores empty environment variables
+		if value, found := os.LookupEnv(envVarName); found && value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// getFileLayer returns the viper instance holding the settings read from the
+// configuration files. The caller must hold the lock.
+func (c *safeConfig) getFileLayer() *viper.Viper {
+	if c.fileLayer == nil {
+		c.fileLayer = viper.New()
+	}
+	return c.fileLayer
+}
+
 // NewConfig returns a new Config object.
 func NewConfig(name string, envPrefix string, envKeyReplacer *strings.Replacer) Config {
 	config := safeConfig{
-		Viper: viper.New(),
+		Viper:     viper.New(),
+		fileLayer: viper.New(),
 	}
 	config.SetConfigName(name)
 	config.SetEnvPrefix(envPrefix)
@@ -6,8 +6,11 @@
 package config
 
 import (
+	"os"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -86,3 +89,127 @@ func TestGetConfigEnvVars(t *testing.T) {
 	config.BindEnv("config_option", "DD_CONFIG_OPTION")
 	assert.NotContains(t, config.GetEnvVars(), "DD_CONFIG_OPTION")
 }
+
+func TestGetSource(t *testing.T) {
+	config := NewConfig("test", "DD", strings.NewReplacer(".", "_"))
+	config.SetConfigType("yaml")
+	config.BindEnvAndSetDefault("default_key", "default")
+	config.BindEnvAndSetDefault("file_key", "default")
+	config.BindEnvAndSetDefault("nested.env_key", "default")
+	config.BindEnvAndSetDefault("runtime_key", "default")
+	config.BindEnvAndSetDefault("remote_key", "default")
+
+	os.Setenv("DD_NESTED_ENV_KEY", "env")
+	defer os.Unsetenv("DD_NESTED_ENV_KEY")
+
+	err := config.ReadConfig(strings.NewReader("file_key: file\nnested:\n  env_key: file\nruntime_key: file\n"))
+	assert.NoError(t, err)
+	config.Set("runtime_key", "runtime")
+	config.SetWithSource("remote_key", "remote", SourceRemote)
+
+	assert.Equal(t, SourceDefault, config.GetSource("default_key"))
+	assert.Equal(t, SourceFile, config.GetSource("file_key"))
+	assert.Equal(t, SourceEnvVar, config.GetSource("nested.env_key"))
+	assert.Equal(t, SourceRuntime, config.GetSource("runtime_key"))
+	assert.Equal(t, SourceRemote, config.GetSource("remote_key"))
+	assert.Equal(t, SourceUnknown, config.GetSource("unknown_key"))
+
+	// the last override wins
+	config.Set("remote_key", "runtime")
+	assert.Equal(t, SourceRuntime, config.GetSource("remote_key"))
+}
+
+func TestUnsetForSource(t *testing.T) {
+	config := NewConfig("test", "DD", strings.NewReplacer(".", "_"))
+	config.SetConfigType("yaml")
+	config.BindEnvAndSetDefault("file_key", "default")
+	config.BindEnvAndSetDefault("nested.default_key", "default")
+	config.BindEnvAndSetDefault("runtime_key", "default")
+
+	err := config.ReadConfig(strings.NewReader("file_key: file\n"))
+	assert.NoError(t, err)
+
+	config.SetWithSource("file_key", "remote", SourceRemote)
+	config.SetWithSource("nested.default_key", "remote", SourceRemote)
+	config.Set("runtime_key", "runtime")
+	config.SetWithSource("runtime_key", "remote", SourceRemote)
+
+	config.UnsetForSource("file_key", SourceRemote)
+	assert.Equal(t, "file", config.GetString("file_key"))
+	assert.Equal(t, SourceFile, config.GetSource("file_key"))
+
+	config.UnsetForSource("nested.default_key", SourceRemote)
+	assert.Equal(t, "default", config.GetString("nested.default_key"))
+	assert.Equal(t, SourceDefault, config.GetSource("nested.default_key"))
+	assert.NotContains(t, config.GetLayeredView()["nested.default_key"].Layers, SourceRemote)
+
+	// the runtime override set before is restored
+	config.UnsetForSource("runtime_key", SourceRemote)
+	assert.Equal(t, "runtime", config.GetString("runtime_key"))
+	assert.Equal(t, SourceRuntime, config.GetSource("runtime_key"))
+
+	// unsetting a source that doesn't override the key is a no-op
+	config.UnsetForSource("runtime_key", SourceRemote)
+	assert.Equal(t, "runtime", config.GetString("runtime_key"))
+}
+
+func TestGetBytesSize(t *testing.T) {
+	config := NewConfig("test", "DD", strings.NewReplacer(".", "_"))
+	config.SetConfigType("yaml")
+	config.BindEnvAndSetDefault("max_size", "10MB")
+	config.BindEnvAndSetDefault("payload_size", 1024)
+	config.BindEnvAndSetDefault("invalid_size", 2048)
+
+	err := config.ReadConfig(strings.NewReader("payload_size: 128MiB\ninvalid_size: 12 parsecs\n"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(10<<20), config.GetBytesSize("max_size"))
+	assert.Equal(t, uint64(128<<20), config.GetBytesSize("payload_size"))
+	assert.Equal(t, uint64(2048), config.GetBytesSize("invalid_size"))
+	assert.Equal(t, uint64(0), config.GetBytesSize("unknown_size"))
+}
+
+func TestGetDurationWithUnit(t *testing.T) {
+	config := NewConfig("test", "DD", strings.NewReplacer(".", "_"))
+	config.SetConfigType("yaml")
+	config.BindEnvAndSetDefault("timeout", 20)
+	config.BindEnvAndSetDefault("interval", 10)
+	config.BindEnvAndSetDefault("invalid_interval", 10)
+
+	os.Setenv("DD_INTERVAL", "500ms")
+	defer os.Unsetenv("DD_INTERVAL")
+
+	err := config.ReadConfig(strings.NewReader("invalid_interval: -3\n"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 20*time.Second, config.GetDurationWithUnit("timeout", time.Second))
+	assert.Equal(t, 20*time.Minute, config.GetDurationWithUnit("timeout", time.Minute))
+	assert.Equal(t, 500*time.Millisecond, config.GetDurationWithUnit("interval", time.Second))
+	assert.Equal(t, 10*time.Second, config.GetDurationWithUnit("invalid_interval", time.Second))
+
+	config.Set("timeout", "2h")
+	assert.Equal(t, 2*time.Hour, config.GetDurationWithUnit("timeout", time.Second))
+}
+
+func TestGetLayeredView(t *testing.T) {
+	config := NewConfig("test", "DD", strings.NewReplacer(".", "_"))
+	config.SetConfigType("yaml")
+	config.BindEnvAndSetDefault("nested.env_key", "default")
+
+	os.Setenv("DD_NESTED_ENV_KEY", "env")
+	defer os.Unsetenv("DD_NESTED_ENV_KEY")
+
+	err := config.ReadConfig(strings.NewReader("nested:\n  env_key: file\n"))
+	assert.NoError(t, err)
+
+	view := config.GetLayeredView()
+	assert.Equal(t, LayeredSetting{
+		Value:  "env",
+		Source: SourceEnvVar,
+		Layers: map[Source]interface{}{
+			SourceDefault: "default",
+			SourceFile:    "file",
+			SourceEnvVar:  "env",
+		},
+	}, view["nested.env_key"])
+}
@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package connectivity
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+	logsconfig "github.com/DataDog/datadog-agent/pkg/logs/config"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	traceEndpointPrefix = "https://trace.agent."
+	tracePath           = "/api/v0.2/traces"
+	seriesPath          = "/api/v1/series"
+)
+
+var submissionTimeout = 10 * time.Second
+
+func init() {
+	diagnosis.Register("Metrics intake connectivity", diagnoseMetrics)
+	diagnosis.Register("Logs intake connectivity", diagnoseLogs)
+	diagnosis.Register("Traces intake connectivity", diagnoseTraces)
+}
+
+// intakeEndpoint is an endpoint receiving a dry-run submission: a payload holding no data, sent with the
+// TLS and proxy settings of the agent, so that nothing is ingested.
+type intakeEndpoint struct {
+	// url is the url the payload is sent to, it may contain the api key
+	url string
+	// displayURL is the url reported in the diagnosis, without the api key
+	displayURL  string
+	headers     map[string]string
+	contentType string
+	payload     []byte
+}
+
+func obfuscateAPIKey(apiKey string) string {
+	if len(apiKey) > 5 {
+		return "***" + apiKey[len(apiKey)-5:]
+	}
+	return "***"
+}
+
+// metricsEndpoints returns the series endpoint of each configured domain and api key
+func metricsEndpoints() ([]intakeEndpoint, error) {
+	keysPerDomain, err := config.GetMultipleEndpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []intakeEndpoint
+	for domain, apiKeys := range keysPerDomain {
+		for _, apiKey := range apiKeys {
+			endpoints = append(endpoints, intakeEndpoint{
+				url:         fmt.Sprintf("%s%s?api_key=%s", domain, seriesPath, apiKey),
+				displayURL:  fmt.Sprintf("%s%s (api key %s)", domain, seriesPath, obfuscateAPIKey(apiKey)),
+				contentType: "application/json",
+				payload:     []byte(`{"series":[]}`),
+			})
+		}
+	}
+	return endpoints, nil
+}
+
+// logsEndpoints returns the main and additional logs HTTP endpoints
+func logsEndpoints() ([]intakeEndpoint, error) {
+	logsEndpoints, err := logsconfig.BuildHTTPEndpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []intakeEndpoint
+	for _, e := range append([]logsconfig.Endpoint{logsEndpoints.Main}, logsEndpoints.Additionals...) {
+		scheme := "http"
+		if e.UseSSL {
+			scheme = "https"
+		}
+		address := e.Host
+		if e.Port != 0 {
+			address = fmt.Sprintf("%s:%d", e.Host, e.Port)
+		}
+
+		endpoints = append(endpoints, intakeEndpoint{
+			url:         fmt.Sprintf("%s://%s/v1/input/%s", scheme, address, e.APIKey),
+			displayURL:  fmt.Sprintf("%s://%s/v1/input (api key %s)", scheme, address, obfuscateAPIKey(e.APIKey)),
+			contentType: "application/json",
+			payload:     []byte("[]"),
+		})
+	}
+	return endpoints, nil
+}
+
+// tracesEndpoints returns the main and additional trace endpoints
+func tracesEndpoints() []intakeEndpoint {
+	keysPerHost := map[string][]string{
+		config.GetMainEndpoint(traceEndpointPrefix, "apm_config.apm_dd_url"): {config.SanitizeAPIKey(config.Datadog.GetString("api_key"))},
+	}
+	if config.Datadog.IsSet("apm_config.additional_endpoints") {
+		for host, apiKeys := range config.Datadog.GetStringMapStringSlice("apm_config.additional_endpoints") {
+			for _, apiKey := range apiKeys {
+				keysPerHost[host] = append(keysPerHost[host], config.SanitizeAPIKey(apiKey))
+			}
+		}
+	}
+
+	var endpoints []intakeEndpoint
+	for host, apiKeys := range keysPerHost {
+		for _, apiKey := range apiKeys {
+			endpoints = append(endpoints, intakeEndpoint{
+				url:         host + tracePath,
+				displayURL:  fmt.Sprintf("%s%s (api key %s)", host, tracePath, obfuscateAPIKey(apiKey)),
+				headers:     map[string]string{"DD-Api-Key": apiKey},
+				contentType: "application/x-protobuf",
+			})
+		}
+	}
+	return endpoints
+}
+
+// redactURLError replaces the url of the request, which may contain the api key, by the display url of the
+// endpoint in the errors returned by the http package
+func redactURLError(endpoint intakeEndpoint, err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return fmt.Errorf("%s %s: %s", urlErr.Op, endpoint.displayURL, urlErr.Err)
+	}
+	return err
+}
+
+// submit sends the dry-run payload to the endpoint and returns the latency of the request
+func submit(client *http.Client, endpoint intakeEndpoint) (time.Duration, error) {
+	req, err := http.NewRequest("POST", endpoint.url, bytes.NewReader(endpoint.payload))
+	if err != nil {
+		return 0, redactURLError(endpoint, err)
+	}
+	req.Header.Set("Content-Type", endpoint.contentType)
+	req.Header.Set("User-Agent", fmt.Sprintf("datadog-agent/%s", version.AgentVersion))
+	for key, value := range endpoint.headers {
+		req.Header.Set(key, value)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return latency, redactURLError(endpoint, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body) //nolint:errcheck
+
+	if resp.StatusCode >= 400 {
+		return latency, fmt.Errorf("unexpected response code %d", resp.StatusCode)
+	}
+	return latency, nil
+}
+
+// diagnoseIntake submits a dry-run payload to each endpoint, through the proxies of the product, and reports
+// their latency and failures
+func diagnoseIntake(intake string, product string, endpoints []intakeEndpoint) error {
+	if len(endpoints) == 0 {
+		log.Infof("No %s intake endpoint configured", intake)
+		return nil
+	}
+
+	client := &http.Client{
+		Transport: httputils.CreateProductHTTPTransport(product),
+		Timeout:   submissionTimeout,
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].displayURL < endpoints[j].displayURL
+	})
+
+	failures := 0
+	for _, endpoint := range endpoints {
+		latency, err := submit(client, endpoint)
+		if err != nil {
+			log.Errorf("Dry-run %s submission to %s failed after %s: %s", intake, endpoint.displayURL, latency, err)
+			failures++
+			continue
+		}
+		log.Infof("Dry-run %s submission to %s succeeded in %s", intake, endpoint.displayURL, latency)
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of the %d %s intake endpoints can't be reached", failures, len(endpoints), intake)
+	}
+	return nil
+}
+
+func diagnoseMetrics() error {
+	endpoints, err := metricsEndpoints()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return diagnoseIntake("metrics", config.ProxyProductCore, endpoints)
+}
+
+func diagnoseLogs() error {
+	if !config.Datadog.GetBool("logs_enabled") && !config.Datadog.GetBool("log_enabled") {
+		log.Info("Logs collection is disabled, skipping")
+		return nil
+	}
+
+	endpoints, err := logsEndpoints()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return diagnoseIntake("logs", config.ProxyProductLogs, endpoints)
+}
+
+func diagnoseTraces() error {
+	if !config.Datadog.GetBool("apm_config.enabled") {
+		log.Info("APM is disabled, skipping")
+		return nil
+	}
+
+	return diagnoseIntake("traces", config.ProxyProductAPM, tracesEndpoints())
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package connectivity
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestDiagnoseMetrics(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		assert.Equal(t, seriesPath, r.URL.Path)
+		if r.URL.Query().Get("api_key") != "validkey" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	mockConfig := config.Mock()
+	mockConfig.Set("dd_url", ts.URL)
+	mockConfig.Set("api_key", "validkey")
+	defer mockConfig.Set("additional_endpoints", nil)
+
+	require.NoError(t, diagnoseMetrics())
+	assert.Equal(t, []string{`{"series":[]}`}, bodies)
+
+	mockConfig.Set("additional_endpoints", map[string][]string{ts.URL: {"invalidkey"}})
+	assert.EqualError(t, diagnoseMetrics(), "1 of the 2 metrics intake endpoints can't be reached")
+}
+
+func TestSubmitRedactsAPIKey(t *testing.T) {
+	endpoint := intakeEndpoint{
+		url:        "http://127.0.0.1:0" + seriesPath + "?api_key=secretkey12345",
+		displayURL: "http://127.0.0.1:0" + seriesPath + " (api key ***12345)",
+	}
+
+	_, err := submit(&http.Client{}, endpoint)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secretkey")
+	assert.Contains(t, err.Error(), endpoint.displayURL)
+}
+
+func TestTracesEndpoints(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("api_key", "mainkey12345")
+	mockConfig.Set("apm_config.apm_dd_url", "https://trace.example.com")
+	mockConfig.Set("apm_config.additional_endpoints", map[string][]string{"https://other.example.com": {"otherkey"}})
+	defer mockConfig.Set("apm_config.additional_endpoints", nil)
+	defer mockConfig.Set("apm_config.apm_dd_url", "")
+
+	endpoints := tracesEndpoints()
+	require.Len(t, endpoints, 2)
+
+	hosts := map[string]string{}
+	for _, e := range endpoints {
+		hosts[e.url] = e.headers["DD-Api-Key"]
+		assert.NotContains(t, e.displayURL, e.headers["DD-Api-Key"])
+	}
+	assert.Equal(t, map[string]string{
+		"https://trace.example.com" + tracePath: "mainkey12345",
+		"https://other.example.com" + tracePath: "otherkey",
+	}, hosts)
+}
@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package connectivity
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+	logsconfig "github.com/DataDog/datadog-agent/pkg/logs/config"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var proxyDialTimeout = 5 * time.Second
+
+// proxyDefaultPorts are the ports used for the proxy URLs that don't have one
+var proxyDefaultPorts = map[string]string{
+	"http":   "80",
+	"https":  "443",
+	"socks5": "1080",
+}
+
+func init() {
+	diagnosis.Register("Proxy configuration", diagnoseProxies)
+}
+
+// productEndpoints returns the main endpoint of each product sending data through the proxies
+func productEndpoints() map[string]string {
+	endpoints := map[string]string{
+		config.ProxyProductCore:         config.GetMainInfraEndpoint(),
+		config.ProxyProductAPM:          config.GetMainEndpoint(traceEndpointPrefix, "apm_config.apm_dd_url"),
+		config.ProxyProductRemoteConfig: config.GetMainEndpoint("https://config.", "remote_configuration.dd_url"),
+	}
+
+	if logsEndpoints, err := logsconfig.BuildHTTPEndpoints(); err == nil {
+		main := logsEndpoints.Main
+		scheme := "http"
+		if main.UseSSL {
+			scheme = "https"
+		}
+		address := main.Host
+		if main.Port != 0 {
+			address = fmt.Sprintf("%s:%d", main.Host, main.Port)
+		}
+		endpoints[config.ProxyProductLogs] = fmt.Sprintf("%s://%s", scheme, address)
+	}
+
+	return endpoints
+}
+
+// redactProxyURL returns the proxy URL without its credentials
+func redactProxyURL(proxyURL *url.URL) string {
+	redacted := *proxyURL
+	if redacted.User != nil {
+		if _, isSet := redacted.User.Password(); isSet {
+			redacted.User = url.UserPassword("*****", "*****")
+		} else {
+			redacted.User = url.User("*****")
+		}
+	}
+	return redacted.String()
+}
+
+// checkProxy validates a proxy URL and checks that the proxy accepts connections
+func checkProxy(proxyURL *url.URL) error {
+	defaultPort, supported := proxyDefaultPorts[proxyURL.Scheme]
+	if !supported {
+		return fmt.Errorf("unsupported proxy scheme '%s'", proxyURL.Scheme)
+	}
+	if proxyURL.Hostname() == "" {
+		return fmt.Errorf("no proxy host")
+	}
+
+	port := proxyURL.Port()
+	if port == "" {
+		port = defaultPort
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(proxyURL.Hostname(), port), proxyDialTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// effectiveProxy returns the proxy used by a product to reach its endpoint, or nil if the endpoint is
+// reached directly
+func effectiveProxy(product string, endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %s", err)
+	}
+
+	proxies := config.GetProductProxies(product)
+	if proxies == nil {
+		return nil, nil
+	}
+	return httputils.GetProxyTransportFunc(proxies)(&http.Request{URL: u})
+}
+
+// diagnoseProxies reports the proxy used by each product to reach its main endpoint, and checks that
+// the proxy is valid and can be reached
+func diagnoseProxies() error {
+	endpoints := productEndpoints()
+
+	failures := 0
+	for _, product := range config.ProxyProducts() {
+		endpoint, found := endpoints[product]
+		if !found {
+			log.Infof("No %s endpoint configured, skipping", product)
+			continue
+		}
+
+		proxyURL, err := effectiveProxy(product, endpoint)
+		if err != nil {
+			log.Errorf("Invalid %s proxy configuration for %s: %s", product, httputils.SanitizeURL(endpoint), err)
+			failures++
+			continue
+		}
+		if proxyURL == nil {
+			log.Infof("The %s endpoint %s is reached without proxy", product, httputils.SanitizeURL(endpoint))
+			continue
+		}
+
+		if err := checkProxy(proxyURL); err != nil {
+			log.Errorf("The %s proxy %s for %s can't be used: %s", product, redactProxyURL(proxyURL), httputils.SanitizeURL(endpoint), err)
+			failures++
+			continue
+		}
+		log.Infof("The %s endpoint %s is reached through the proxy %s", product, httputils.SanitizeURL(endpoint), redactProxyURL(proxyURL))
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d products have an invalid proxy", failures)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+var tlmCaptureDropped = telemetry.NewCounter("dogstatsd", "capture_dropped_samples",
+	nil, "Count of parsed samples dropped because a capture subscriber was too slow")
+
+// CapturedSample is a parsed metric sample sent to the capture subscribers
+type CapturedSample struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Value  float64  `json:"value"`
+	Tags   []string `json:"tags"`
+	Origin string   `json:"origin,omitempty"`
+}
+
+// captureSubscriber receives the samples whose name starts with its prefix
+type captureSubscriber struct {
+	prefix  string
+	samples chan CapturedSample
+}
+
+// captureHub fans the parsed samples out to the capture subscribers. A subscriber that doesn't
+// keep up loses samples instead of slowing the workers down.
+type captureHub struct {
+	sync.RWMutex
+	// count is read by the workers, without the lock, to skip the captures when nobody subscribed
+	count       int32
+	nextID      uint64
+	subscribers map[uint64]*captureSubscriber
+	closed      bool
+}
+
+func newCaptureHub() *captureHub {
+	return &captureHub{
+		subscribers: make(map[uint64]*captureSubscriber),
+	}
+}
+
+// active returns whether at least one subscriber is registered
+func (h *captureHub) active() bool {
+	return atomic.LoadInt32(&h.count) > 0
+}
+
+// subscribe registers a subscriber buffering up to bufferSize samples. The returned function
+// unregisters it, the channel is closed when it's unregistered or when the hub is closed.
+func (h *captureHub) subscribe(prefix string, bufferSize int) (<-chan CapturedSample, func()) {
+	h.Lock()
+	defer h.Unlock()
+
+	samples := make(chan CapturedSample, bufferSize)
+	if h.closed {
+		close(samples)
+		return samples, func() {}
+	}
+
+	id := h.nextID
+	h.nextID++
+	h.subscribers[id] = &captureSubscriber{prefix: prefix, samples: samples}
+	atomic.AddInt32(&h.count, 1)
+
+	return samples, func() { h.unsubscribe(id) }
+}
+
+func (h *captureHub) unsubscribe(id uint64) {
+	h.Lock()
+	defer h.Unlock()
+
+	if subscriber, found := h.subscribers[id]; found {
+		delete(h.subscribers, id)
+		atomic.AddInt32(&h.count, -1)
+		close(subscriber.samples)
+	}
+}
+
+// publish sends the sample to the subscribers whose prefix matches its name
+func (h *captureHub) publish(sample *metrics.MetricSample, origin string) {
+	h.RLock()
+	defer h.RUnlock()
+
+	for _, subscriber := range h.subscribers {
+		if !strings.HasPrefix(sample.Name, subscriber.prefix) {
+			continue
+		}
+
+		captured := CapturedSample{
+			Name:   sample.Name,
+			Type:   sample.Mtype.String(),
+			Value:  sample.Value,
+			Tags:   append([]string(nil), sample.Tags...),
+			Origin: origin,
+		}
+
+		select {
+		case subscriber.samples <- captured:
+		default:
+			tlmCaptureDropped.Inc()
+		}
+	}
+}
+
+// close unregisters all the subscribers and rejects the new ones
+func (h *captureHub) close() {
+	h.Lock()
+	defer h.Unlock()
+
+	h.closed = true
+	for id, subscriber := range h.subscribers {
+		delete(h.subscribers, id)
+		close(subscriber.samples)
+	}
+	atomic.StoreInt32(&h.count, 0)
+}
+
+// SubscribeCapture streams the parsed metric samples whose name starts with prefix, an empty
+// prefix matches all of them. Up to bufferSize samples are buffered, the next ones are dropped
+// until the subscriber catches up. The returned function ends the subscription and closes the channel.
+func (s *Server) SubscribeCapture(prefix string, bufferSize int) (<-chan CapturedSample, func()) {
+	return s.captures.subscribe(prefix, bufferSize)
+}
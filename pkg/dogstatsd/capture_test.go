@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestCaptureHubPrefix(t *testing.T) {
+	hub := newCaptureHub()
+	assert.False(t, hub.active())
+
+	all, unsubscribeAll := hub.subscribe("", 10)
+	defer unsubscribeAll()
+	filtered, unsubscribeFiltered := hub.subscribe("app.", 10)
+	defer unsubscribeFiltered()
+	assert.True(t, hub.active())
+
+	hub.publish(&metrics.MetricSample{Name: "app.requests", Value: 2, Mtype: metrics.CounterType, Tags: []string{"env:prod"}}, "container_id://abc")
+	hub.publish(&metrics.MetricSample{Name: "other.requests", Value: 1, Mtype: metrics.GaugeType}, "")
+
+	require.Len(t, all, 2)
+	require.Len(t, filtered, 1)
+
+	sample := <-filtered
+	assert.Equal(t, CapturedSample{
+		Name:   "app.requests",
+		Type:   "Counter",
+		Value:  2,
+		Tags:   []string{"env:prod"},
+		Origin: "container_id://abc",
+	}, sample)
+}
+
+func TestCaptureHubSlowSubscriber(t *testing.T) {
+	hub := newCaptureHub()
+
+	samples, unsubscribe := hub.subscribe("", 1)
+	defer unsubscribe()
+
+	hub.publish(&metrics.MetricSample{Name: "first"}, "")
+	hub.publish(&metrics.MetricSample{Name: "second"}, "")
+
+	require.Len(t, samples, 1)
+	assert.Equal(t, "first", (<-samples).Name)
+}
+
+func TestCaptureHubUnsubscribe(t *testing.T) {
+	hub := newCaptureHub()
+
+	samples, unsubscribe := hub.subscribe("", 1)
+	unsubscribe()
+	assert.False(t, hub.active())
+
+	_, ok := <-samples
+	assert.False(t, ok)
+
+	// unsubscribing twice is harmless
+	unsubscribe()
+}
+
+func TestCaptureHubClose(t *testing.T) {
+	hub := newCaptureHub()
+
+	samples, unsubscribe := hub.subscribe("", 1)
+	hub.close()
+	assert.False(t, hub.active())
+
+	_, ok := <-samples
+	assert.False(t, ok)
+	unsubscribe()
+
+	// the subscribers registered after the hub was closed get a closed channel
+	samples, _ = hub.subscribe("", 1)
+	_, ok = <-samples
+	assert.False(t, ok)
+}
@@ -14,11 +14,13 @@ const (
 	metricSampleType messageType = iota
 	serviceCheckType
 	eventType
+	metricMetadataType
 )
 
 var (
-	eventPrefix        = []byte("_e{")
-	serviceCheckPrefix = []byte("_sc")
+	eventPrefix          = []byte("_e{")
+	serviceCheckPrefix   = []byte("_sc")
+	metricMetadataPrefix = []byte("_mm|")
 
 	fieldSeparator = []byte("|")
 	colonSeparator = []byte(":")
@@ -44,6 +46,8 @@ func findMessageType(message []byte) messageType {
 		return eventType
 	} else if bytes.HasPrefix(message, serviceCheckPrefix) {
 		return serviceCheckType
+	} else if bytes.HasPrefix(message, metricMetadataPrefix) {
+		return metricMetadataType
 	}
 	// Note that random gibberish is interpreted as a metric since they don't
 	// contain any easily identifiable feature
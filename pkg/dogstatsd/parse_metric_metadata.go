@@ -0,0 +1,74 @@
+package dogstatsd
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/metadata/metricmetadata"
+)
+
+type dogstatsdMetricMetadata struct {
+	name     string
+	metadata metricmetadata.MetricMetadata
+}
+
+var (
+	metricMetadataUnitPrefix        = []byte("u:")
+	metricMetadataTypePrefix        = []byte("t:")
+	metricMetadataDescriptionPrefix = []byte("d:")
+)
+
+// sanity checks a given message against the metric metadata format
+func hasMetricMetadataFormat(message []byte) bool {
+	if message == nil {
+		return false
+	}
+	separatorCount := bytes.Count(message, fieldSeparator)
+	if separatorCount < 2 {
+		return false
+	}
+	if len(message) < 4 {
+		return false
+	}
+	return true
+}
+
+// parseMetricMetadata parses a metric metadata message, in the following format:
+// _mm|<metric name>|u:<unit>|t:<type>|d:<description>
+// The fields following the metric name are optional, but at least one of them must be set.
+func (p *parser) parseMetricMetadata(message []byte) (dogstatsdMetricMetadata, error) {
+	if !hasMetricMetadataFormat(message) {
+		return dogstatsdMetricMetadata{}, fmt.Errorf("invalid dogstatsd metric metadata format")
+	}
+	// pop the _mm| header
+	message = message[4:]
+
+	rawName, message := nextField(message)
+	if len(rawName) == 0 {
+		return dogstatsdMetricMetadata{}, fmt.Errorf("invalid dogstatsd metric metadata name: empty name")
+	}
+
+	metricMetadata := dogstatsdMetricMetadata{
+		name: string(rawName),
+	}
+
+	var optionalField []byte
+	for message != nil {
+		optionalField, message = nextField(message)
+		switch {
+		case bytes.HasPrefix(optionalField, metricMetadataUnitPrefix):
+			metricMetadata.metadata.Unit = string(optionalField[len(metricMetadataUnitPrefix):])
+		case bytes.HasPrefix(optionalField, metricMetadataTypePrefix):
+			metricMetadata.metadata.Type = string(optionalField[len(metricMetadataTypePrefix):])
+		case bytes.HasPrefix(optionalField, metricMetadataDescriptionPrefix):
+			metricMetadata.metadata.Description = string(optionalField[len(metricMetadataDescriptionPrefix):])
+		}
+	}
+
+	metadata, err := metricMetadata.metadata.Validate()
+	if err != nil {
+		return dogstatsdMetricMetadata{}, fmt.Errorf("invalid dogstatsd metric metadata: %s", err)
+	}
+	metricMetadata.metadata = metadata
+	return metricMetadata, nil
+}
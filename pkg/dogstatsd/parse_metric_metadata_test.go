@@ -0,0 +1,63 @@
+package dogstatsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metadata/metricmetadata"
+)
+
+func parseMetricMetadata(rawMetricMetadata []byte) (dogstatsdMetricMetadata, error) {
+	parser := newParser()
+	return parser.parseMetricMetadata(rawMetricMetadata)
+}
+
+func TestMetricMetadataMinimal(t *testing.T) {
+	mm, err := parseMetricMetadata([]byte("_mm|queue.size|u:item"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "queue.size", mm.name)
+	assert.Equal(t, metricmetadata.MetricMetadata{Unit: "item"}, mm.metadata)
+}
+
+func TestMetricMetadataAllFields(t *testing.T) {
+	mm, err := parseMetricMetadata([]byte("_mm|queue.latency|t:Gauge|d:Time spent in the queue|u:millisecond|x:unknown"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "queue.latency", mm.name)
+	assert.Equal(t, metricmetadata.MetricMetadata{
+		Unit:        "millisecond",
+		Type:        "gauge",
+		Description: "Time spent in the queue",
+	}, mm.metadata)
+}
+
+func TestMetricMetadataError(t *testing.T) {
+	// not enough information
+	_, err := parseMetricMetadata([]byte("_mm|queue.size"))
+	assert.Error(t, err)
+
+	_, err = parseMetricMetadata([]byte("_mm|queue.size|"))
+	assert.Error(t, err)
+
+	_, err = parseMetricMetadata([]byte("_mm||u:item"))
+	assert.Error(t, err)
+
+	_, err = parseMetricMetadata([]byte("_mm|"))
+	assert.Error(t, err)
+
+	// invalid type
+	_, err = parseMetricMetadata([]byte("_mm|queue.size|t:histogram"))
+	assert.Error(t, err)
+
+	// invalid unit
+	_, err = parseMetricMetadata([]byte("_mm|queue.size|u:items per second"))
+	assert.Error(t, err)
+}
+
+func TestMetricMetadataMessageType(t *testing.T) {
+	assert.Equal(t, metricMetadataType, findMessageType([]byte("_mm|queue.size|u:item")))
+	assert.Equal(t, metricSampleType, findMessageType([]byte("_mm:1|g")))
+}
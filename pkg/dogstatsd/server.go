@@ -24,6 +24,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd/listeners"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd/mapper"
+	"github.com/DataDog/datadog-agent/pkg/metadata/metricmetadata"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
@@ -42,6 +43,8 @@ var (
 	dogstatsdEventPackets            = expvar.Int{}
 	dogstatsdMetricParseErrors       = expvar.Int{}
 	dogstatsdMetricPackets           = expvar.Int{}
+	dogstatsdMetricMetadataErrors    = expvar.Int{}
+	dogstatsdMetricMetadataPackets   = expvar.Int{}
 	dogstatsdPacketsLastSec          = expvar.Int{}
 
 	tlmProcessed = telemetry.NewCounter("dogstatsd", "processed",
@@ -57,6 +60,8 @@ func init() {
 	dogstatsdExpvars.Set("EventPackets", &dogstatsdEventPackets)
 	dogstatsdExpvars.Set("MetricParseErrors", &dogstatsdMetricParseErrors)
 	dogstatsdExpvars.Set("MetricPackets", &dogstatsdMetricPackets)
+	dogstatsdExpvars.Set("MetricMetadataErrors", &dogstatsdMetricMetadataErrors)
+	dogstatsdExpvars.Set("MetricMetadataPackets", &dogstatsdMetricMetadataPackets)
 }
 
 // Server represent a Dogstatsd server
@@ -89,6 +94,8 @@ type Server struct {
 	// package (pkg/trace/logutils) for a possible throttler implemetation.
 	disableVerboseLogs bool
 	UdsListenerRunning bool
+	// captures streams the parsed samples to the debug capture subscribers
+	captures *captureHub
 }
 
 // metricStat holds how many times a metric has been
@@ -229,6 +236,7 @@ func NewServer(aggregator *aggregator.BufferedAggregator) (*Server, error) {
 			keyGen: ckey.NewKeyGenerator(),
 		},
 		UdsListenerRunning: udsListenerRunning,
+		captures:           newCaptureHub(),
 	}
 
 	// packets forwarding
@@ -392,6 +400,11 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*liste
 					continue
 				}
 				batcher.appendEvent(event)
+			case metricMetadataType:
+				if err := s.handleMetricMetadataMessage(parser, message); err != nil {
+					s.errLog("Dogstatsd: error handling metric metadata '%q': %s", message, err)
+					continue
+				}
 			case metricSampleType:
 				sample, err := s.parseMetricMessage(parser, message, originTagger.getTags)
 				if err != nil {
@@ -406,6 +419,9 @@ func (s *Server) parsePackets(batcher *batcher, parser *parser, packets []*liste
 				if atomic.LoadUint64(&s.Debug.Enabled) == 1 {
 					s.storeMetricStats(sample)
 				}
+				if s.captures.active() {
+					s.captures.publish(&sample, packet.Origin)
+				}
 				batcher.appendSample(sample)
 				if s.histToDist && sample.Mtype == metrics.HistogramType {
 					distSample := sample.Copy()
@@ -478,6 +494,32 @@ func (s *Server) parseServiceCheckMessage(parser *parser, message []byte, origin
 	return serviceCheck, nil
 }
 
+// handleMetricMetadataMessage declares the metadata of a metric, whose name is mapped and
+// namespaced as the name of its samples
+func (s *Server) handleMetricMetadataMessage(parser *parser, message []byte) error {
+	metricMetadata, err := parser.parseMetricMetadata(message)
+	if err == nil {
+		name := metricMetadata.name
+		if s.mapper != nil {
+			if mapResult := s.mapper.Map(name); mapResult != nil {
+				name = mapResult.Name
+			}
+		}
+		if !isBlacklisted(name, s.metricPrefix, s.metricPrefixBlacklist) {
+			name = s.metricPrefix + name
+		}
+		err = metricmetadata.SetMetricMetadata(name, metricMetadata.metadata)
+	}
+	if err != nil {
+		dogstatsdMetricMetadataErrors.Add(1)
+		tlmProcessed.Inc("metric_metadata", "error")
+		return err
+	}
+	dogstatsdMetricMetadataPackets.Add(1)
+	tlmProcessed.Inc("metric_metadata", "ok")
+	return nil
+}
+
 // Stop stops a running Dogstatsd server
 func (s *Server) Stop() {
 	close(s.stopChan)
@@ -487,6 +529,7 @@ func (s *Server) Stop() {
 	if s.Statistics != nil {
 		s.Statistics.Stop()
 	}
+	s.captures.close()
 	s.health.Deregister() //nolint:errcheck
 	s.Started = false
 }
@@ -20,6 +20,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metadata/metricmetadata"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 )
 
@@ -381,6 +382,43 @@ func TestExtraTags(t *testing.T) {
 	}
 }
 
+func TestMetricMetadata(t *testing.T) {
+	port, err := getAvailableUDPPort()
+	require.NoError(t, err)
+	config.Datadog.SetDefault("dogstatsd_port", port)
+	config.Datadog.SetDefault("statsd_metric_namespace", "testns")
+	defer config.Datadog.SetDefault("statsd_metric_namespace", "")
+
+	agg := mockAggregator()
+	metricOut, _, _ := agg.GetBufferedChannels()
+	s, err := NewServer(agg)
+	require.NoError(t, err, "cannot start DSD")
+	defer s.Stop()
+
+	url := fmt.Sprintf("127.0.0.1:%d", config.Datadog.GetInt("dogstatsd_port"))
+	conn, err := net.Dial("udp", url)
+	require.NoError(t, err, "cannot connect to DSD socket")
+	defer conn.Close()
+
+	// the metric sample is processed after the metadata of the packet
+	conn.Write([]byte("_mm|queue.size|u:item|t:gauge|d:Size of the queue\nqueue.size:42|g"))
+	select {
+	case res := <-metricOut:
+		assert.Equal(t, 1, len(res))
+		assert.Equal(t, "testns.queue.size", res[0].Name)
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+
+	payload := metricmetadata.CreatePayload("test-host")
+	require.NotNil(t, payload)
+	assert.Equal(t, metricmetadata.MetricMetadata{
+		Unit:        "item",
+		Type:        "gauge",
+		Description: "Size of the queue",
+	}, payload.MetricMetadata["testns.queue.size"])
+}
+
 func TestDebugStatsSpike(t *testing.T) {
 	assert := assert.New(t)
 	agg := mockAggregator()
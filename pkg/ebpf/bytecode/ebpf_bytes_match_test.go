@@ -16,7 +16,9 @@ func TestEbpfBytesCorrect(t *testing.T) {
 		"../c/tracer-ebpf.o":           "pkg/ebpf/c/tracer-ebpf.o",
 		"../c/tracer-ebpf-debug.o":     "pkg/ebpf/c/tracer-ebpf-debug.o",
 		"../c/oom-kill-kern.c":         "pkg/ebpf/c/oom-kill-kern.c",
+		"../c/conntrack-kern.c":        "pkg/ebpf/c/conntrack-kern.c",
 		"../c/tcp-queue-length-kern.c": "pkg/ebpf/c/tcp-queue-length-kern.c",
+		"../c/uprobe-kern.c":           "pkg/ebpf/c/uprobe-kern.c",
 		"../c/offset-guess.o":          "pkg/ebpf/c/offset-guess.o",
 		"../c/offset-guess-debug.o":    "pkg/ebpf/c/offset-guess-debug.o",
 	}
@@ -4,29 +4,84 @@ package bytecode
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/DataDog/ebpf/manager"
 )
 
+const (
+	// minReadBatchSize and maxReadBatchSize bound the number of events handed over at once to the consumer
+	minReadBatchSize = 1
+	maxReadBatchSize = 256
+
+	// maxPendingFactor bounds the number of events buffered while the consumer falls behind, relative to
+	// the size of the closed channel
+	maxPendingFactor = 4
+)
+
+// PerfHandler buffers the events read from a perf map so that the reader of the perf map doesn't wait for
+// the consumer, which would make the kernel drop the events once the ring buffers are full. The events are
+// handed over in batches whose size grows while the consumer falls behind and shrinks back once it catches up.
+// The reader only waits for the consumer once the buffered events reach the limit.
 type PerfHandler struct {
-	ClosedChannel chan []byte
+	ClosedChannel chan [][]byte
 	LostChannel   chan uint64
-	once          sync.Once
-	closed        bool
+
+	mux        sync.Mutex
+	notFull    *sync.Cond
+	pending    [][]byte
+	maxPending int
+	batchSize  int
+	wake       chan struct{}
+	done       chan struct{}
+
+	// stats
+	received          int64
+	backpressureWaits int64
+	maxPendingSeen    int
+
+	once   sync.Once
+	closed bool
 }
 
+// NewPerfHandler returns a PerfHandler buffering up to maxPendingFactor times closedChannelSize events
 func NewPerfHandler(closedChannelSize int) *PerfHandler {
-	return &PerfHandler{
-		ClosedChannel: make(chan []byte, closedChannelSize),
+	c := &PerfHandler{
+		ClosedChannel: make(chan [][]byte, 1),
 		LostChannel:   make(chan uint64, 10),
+		maxPending:    closedChannelSize * maxPendingFactor,
+		batchSize:     minReadBatchSize,
+		wake:          make(chan struct{}, 1),
+		done:          make(chan struct{}),
 	}
+	c.notFull = sync.NewCond(&c.mux)
+	go c.dispatch()
+	return c
 }
 
 func (c *PerfHandler) dataHandler(CPU int, batchData []byte, perfMap *manager.PerfMap, manager *manager.Manager) {
 	if c.closed {
 		return
 	}
-	c.ClosedChannel <- batchData
+
+	c.mux.Lock()
+	if len(c.pending) >= c.maxPending {
+		atomic.AddInt64(&c.backpressureWaits, 1)
+		for len(c.pending) >= c.maxPending && !c.closed {
+			c.notFull.Wait()
+		}
+	}
+	c.pending = append(c.pending, batchData)
+	if len(c.pending) > c.maxPendingSeen {
+		c.maxPendingSeen = len(c.pending)
+	}
+	c.mux.Unlock()
+
+	atomic.AddInt64(&c.received, 1)
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
 }
 
 func (c *PerfHandler) lostHandler(CPU int, lostCount uint64, perfMap *manager.PerfMap, manager *manager.Manager) {
@@ -36,10 +91,94 @@ func (c *PerfHandler) lostHandler(CPU int, lostCount uint64, perfMap *manager.Pe
 	c.LostChannel <- lostCount
 }
 
+// dispatch hands over the buffered events to the consumer until the handler is stopped
+func (c *PerfHandler) dispatch() {
+	defer close(c.ClosedChannel)
+
+	for {
+		select {
+		case <-c.wake:
+		case <-c.done:
+			return
+		}
+
+		for batch := c.nextBatch(); batch != nil; batch = c.nextBatch() {
+			select {
+			case c.ClosedChannel <- batch:
+			case <-c.done:
+				return
+			}
+		}
+	}
+}
+
+// nextBatch returns the next batch of buffered events, or nil if there's none, and adapts the size of the
+// batches to the number of events left behind
+func (c *PerfHandler) nextBatch() [][]byte {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if len(c.pending) == 0 {
+		return nil
+	}
+
+	n := c.batchSize
+	if n > len(c.pending) {
+		n = len(c.pending)
+	}
+	batch := make([][]byte, n)
+	copy(batch, c.pending)
+
+	remaining := copy(c.pending, c.pending[n:])
+	switch {
+	case remaining > 0:
+		// the consumer falls behind, hand over more events at once
+		c.batchSize *= 2
+		if c.batchSize > maxReadBatchSize {
+			c.batchSize = maxReadBatchSize
+		}
+	case n <= c.batchSize/2:
+		c.batchSize /= 2
+		if c.batchSize < minReadBatchSize {
+			c.batchSize = minReadBatchSize
+		}
+	}
+
+	if remaining == 0 && cap(c.pending) > maxReadBatchSize {
+		// release the memory grown during a storm
+		c.pending = nil
+	} else {
+		c.pending = c.pending[:remaining]
+	}
+
+	c.notFull.Broadcast()
+	return batch
+}
+
+// GetStats returns the number of events received, the number of times the reader of the perf map had to wait
+// for the consumer, the highest number of buffered events since the last call and the current batch size
+func (c *PerfHandler) GetStats() map[string]int64 {
+	c.mux.Lock()
+	batchSize, maxPending := c.batchSize, c.maxPendingSeen
+	c.maxPendingSeen = 0
+	c.mux.Unlock()
+
+	return map[string]int64{
+		"received":           atomic.LoadInt64(&c.received),
+		"backpressure_waits": atomic.LoadInt64(&c.backpressureWaits),
+		"max_pending":        int64(maxPending),
+		"read_batch_size":    int64(batchSize),
+	}
+}
+
 func (c *PerfHandler) Stop() {
 	c.once.Do(func() {
+		c.mux.Lock()
 		c.closed = true
-		close(c.ClosedChannel)
+		c.notFull.Broadcast()
+		c.mux.Unlock()
+
+		close(c.done)
 		close(c.LostChannel)
 	})
 }
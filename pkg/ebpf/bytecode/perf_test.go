@@ -0,0 +1,84 @@
+// +build linux_bpf
+
+package bytecode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerfHandlerBatches(t *testing.T) {
+	perf := NewPerfHandler(10)
+	defer perf.Stop()
+
+	// the consumer isn't reading, the events are buffered instead of blocking the reader
+	for i := 0; i < 20; i++ {
+		perf.dataHandler(0, []byte{byte(i)}, nil, nil)
+	}
+
+	var received []byte
+	var batchSizes []int
+	for len(received) < 20 {
+		select {
+		case batch := <-perf.ClosedChannel:
+			batchSizes = append(batchSizes, len(batch))
+			for _, data := range batch {
+				received = append(received, data[0])
+			}
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timeout waiting for the events")
+		}
+	}
+
+	for i, data := range received {
+		assert.Equal(t, byte(i), data)
+	}
+	// the batches grow while events are left behind
+	assert.Greater(t, batchSizes[len(batchSizes)-1], batchSizes[0])
+
+	stats := perf.GetStats()
+	assert.Equal(t, int64(20), stats["received"])
+	assert.Equal(t, int64(0), stats["backpressure_waits"])
+	assert.Equal(t, int64(0), perf.GetStats()["max_pending"])
+}
+
+func TestPerfHandlerBackpressure(t *testing.T) {
+	perf := NewPerfHandler(1)
+	defer perf.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 4*maxPendingFactor; i++ {
+			perf.dataHandler(0, []byte{byte(i)}, nil, nil)
+		}
+	}()
+
+	received := 0
+	for received < 4*maxPendingFactor {
+		select {
+		case batch := <-perf.ClosedChannel:
+			received += len(batch)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timeout waiting for the events")
+		}
+	}
+	<-done
+
+	stats := perf.GetStats()
+	assert.Equal(t, int64(4*maxPendingFactor), stats["received"])
+	assert.LessOrEqual(t, stats["max_pending"], int64(maxPendingFactor))
+}
+
+func TestPerfHandlerStop(t *testing.T) {
+	perf := NewPerfHandler(10)
+	perf.Stop()
+
+	_, ok := <-perf.ClosedChannel
+	assert.False(t, ok)
+	_, ok = <-perf.LostChannel
+	assert.False(t, ok)
+}
@@ -0,0 +1,56 @@
+// +build linux_bpf
+
+package bytecode
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/DataDog/ebpf/manager"
+)
+
+// Tunable is a constant of an eBPF program, read with the LOAD_CONSTANT macro, whose value is
+// patched when the program is loaded instead of being set at compile time
+type Tunable struct {
+	// Name is the name of the constant in the eBPF program
+	Name string
+	// Default is the value of the tunable when it isn't overridden
+	Default uint64
+	// Max is the maximum value of the tunable, 0 meaning no maximum
+	Max uint64
+}
+
+// TunableEditors returns the constant editors setting the given tunables. The values of the tunables can be
+// overridden by name, usually from the configuration. Unknown names and invalid values are reported as errors.
+func TunableEditors(tunables []Tunable, overrides map[string]string) ([]manager.ConstantEditor, error) {
+	values := make(map[string]uint64, len(tunables))
+	for _, tunable := range tunables {
+		values[tunable.Name] = tunable.Default
+	}
+
+	for name, override := range overrides {
+		if _, exists := values[name]; !exists {
+			return nil, fmt.Errorf("unknown eBPF tunable `%s`", name)
+		}
+
+		value, err := strconv.ParseUint(override, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for eBPF tunable `%s`: %s", name, err)
+		}
+		values[name] = value
+	}
+
+	editors := make([]manager.ConstantEditor, 0, len(tunables))
+	for _, tunable := range tunables {
+		value := values[tunable.Name]
+		if tunable.Max != 0 && value > tunable.Max {
+			return nil, fmt.Errorf("invalid value for eBPF tunable `%s`: %d is greater than %d", tunable.Name, value, tunable.Max)
+		}
+		editors = append(editors, manager.ConstantEditor{Name: tunable.Name, Value: value})
+	}
+
+	sort.Slice(editors, func(i, j int) bool { return editors[i].Name < editors[j].Name })
+
+	return editors, nil
+}
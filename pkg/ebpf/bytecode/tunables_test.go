@@ -0,0 +1,41 @@
+// +build linux_bpf
+
+package bytecode
+
+import (
+	"testing"
+
+	"github.com/DataDog/ebpf/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTunableEditors(t *testing.T) {
+	tunables := []Tunable{
+		{Name: "sampling_rate", Default: 100},
+		{Name: "feature_enabled", Default: 1, Max: 1},
+	}
+
+	editors, err := TunableEditors(tunables, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []manager.ConstantEditor{
+		{Name: "feature_enabled", Value: uint64(1)},
+		{Name: "sampling_rate", Value: uint64(100)},
+	}, editors)
+
+	editors, err = TunableEditors(tunables, map[string]string{"sampling_rate": "0x10", "feature_enabled": "0"})
+	require.NoError(t, err)
+	assert.Equal(t, []manager.ConstantEditor{
+		{Name: "feature_enabled", Value: uint64(0)},
+		{Name: "sampling_rate", Value: uint64(16)},
+	}, editors)
+
+	_, err = TunableEditors(tunables, map[string]string{"unknown": "1"})
+	assert.Error(t, err)
+
+	_, err = TunableEditors(tunables, map[string]string{"sampling_rate": "-1"})
+	assert.Error(t, err)
+
+	_, err = TunableEditors(tunables, map[string]string{"feature_enabled": "2"})
+	assert.Error(t, err)
+}
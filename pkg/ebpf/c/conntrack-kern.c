@@ -0,0 +1,115 @@
+#include <linux/kconfig.h>
+#define KBUILD_MODNAME "foo"
+#include <linux/bpf.h>
+#include <linux/ptrace.h>
+#include <linux/socket.h>
+#include <linux/in.h>
+#include <net/netfilter/nf_conntrack.h>
+
+#include "pkg/ebpf/conntrack-kern-user.h"
+
+/*
+ * The `conntrack` map holds, for each direction of a connection with NAT, the tuple of the other direction.
+ * It is an LRU map so that the entries of the connections never seen by the tracer can't fill it.
+ */
+BPF_TABLE("lru_hash", struct conntrack_tuple, struct conntrack_tuple, conntrack, CONNTRACK_MAX_STATE_SIZE);
+
+/*
+ * The `conntrack_telemetry` map is used to share with the userland program system-probe
+ * the number of registered and dropped conntrack entries
+ */
+BPF_ARRAY(conntrack_telemetry, struct conntrack_telemetry, 1);
+
+// TODO: replace all `bpf_probe_read` by `bpf_probe_read_kernel` once we can assume that we have at least kernel 5.5
+static inline int nf_conntrack_tuple_to_conntrack_tuple(struct conntrack_tuple* t, const struct nf_conntrack_tuple* ct) {
+    __builtin_memset(t, 0, sizeof(struct conntrack_tuple));
+
+    switch (ct->dst.protonum) {
+    case IPPROTO_TCP:
+        t->metadata = CONNTRACK_TYPE_TCP;
+        break;
+    case IPPROTO_UDP:
+        t->metadata = CONNTRACK_TYPE_UDP;
+        break;
+    default:
+        return 0;
+    }
+
+    switch (ct->src.l3num) {
+    case AF_INET:
+        t->metadata |= CONNTRACK_V4;
+        t->saddr_l = ct->src.u3.ip;
+        t->daddr_l = ct->dst.u3.ip;
+        break;
+    case AF_INET6:
+        t->metadata |= CONNTRACK_V6;
+        __builtin_memcpy(&t->saddr_h, &ct->src.u3.ip6[0], sizeof(t->saddr_h));
+        __builtin_memcpy(&t->saddr_l, &ct->src.u3.ip6[2], sizeof(t->saddr_l));
+        __builtin_memcpy(&t->daddr_h, &ct->dst.u3.ip6[0], sizeof(t->daddr_h));
+        __builtin_memcpy(&t->daddr_l, &ct->dst.u3.ip6[2], sizeof(t->daddr_l));
+        break;
+    default:
+        return 0;
+    }
+
+    t->sport = ntohs(ct->src.u.all);
+    t->dport = ntohs(ct->dst.u.all);
+
+    return 1;
+}
+
+static inline int is_nat(const struct conntrack_tuple* orig, const struct conntrack_tuple* reply) {
+    return orig->saddr_h != reply->daddr_h || orig->saddr_l != reply->daddr_l ||
+        orig->daddr_h != reply->saddr_h || orig->daddr_l != reply->saddr_l ||
+        orig->sport != reply->dport || orig->dport != reply->sport;
+}
+
+static inline int register_conntrack(struct nf_conn* ct) {
+    u32 zero = 0;
+    struct conntrack_telemetry* telemetry = conntrack_telemetry.lookup(&zero);
+
+    struct nf_conntrack_tuple_hash tuplehash[IP_CT_DIR_MAX];
+    bpf_probe_read(&tuplehash, sizeof(tuplehash), &ct->tuplehash);
+
+    struct conntrack_tuple orig, reply;
+    if (!nf_conntrack_tuple_to_conntrack_tuple(&orig, &tuplehash[IP_CT_DIR_ORIGINAL].tuple) ||
+        !nf_conntrack_tuple_to_conntrack_tuple(&reply, &tuplehash[IP_CT_DIR_REPLY].tuple))
+        return 0;
+
+    // don't bother storing if the connection is not NAT
+    if (!is_nat(&orig, &reply)) {
+        if (telemetry != NULL)
+            __sync_fetch_and_add(&telemetry->registers_dropped, 1);
+        return 0;
+    }
+
+    conntrack.update(&orig, &reply);
+    conntrack.update(&reply, &orig);
+
+    if (telemetry != NULL)
+        __sync_fetch_and_add(&telemetry->registers, 1);
+    return 0;
+}
+
+/*
+ * `__nf_conntrack_hash_insert` is called when a conntrack entry is confirmed,
+ * i.e. when its first packet leaves the netfilter hooks
+ */
+int kprobe____nf_conntrack_hash_insert(struct pt_regs* ctx) {
+    struct nf_conn* ct = (struct nf_conn*)PT_REGS_PARM1(ctx);
+    return register_conntrack(ct);
+}
+
+/*
+ * `ctnetlink_fill_info` is called for each conntrack entry sent over netlink.
+ * It is used to register the conntrack entries that existed before system-probe started,
+ * by filtering on the dump of the conntrack table done by system-probe.
+ */
+int kprobe__ctnetlink_fill_info(struct pt_regs* ctx) {
+    u32 pid = bpf_get_current_pid_tgid() >> 32;
+    if (pid != SYSTEM_PROBE_PID)
+        return 0;
+
+    struct nf_conn* ct = (struct nf_conn*)PT_REGS_PARM5(ctx);
+    return register_conntrack(ct);
+}
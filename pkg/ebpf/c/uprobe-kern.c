@@ -0,0 +1,68 @@
+#include <linux/kconfig.h>
+#define KBUILD_MODNAME "foo"
+#include <linux/bpf.h>
+#include <linux/ptrace.h>
+
+#include "pkg/ebpf/c/bpf-common.h"
+#include "pkg/ebpf/uprobe-kern-user.h"
+
+/*
+ * The `uprobe_stats` map is used to share with the userland program system-probe
+ * the number of calls and the latency of the instrumented functions per container
+ */
+BPF_HASH(uprobe_stats, struct uprobe_stats_key, struct uprobe_stats_value, 4096);
+
+struct uprobe_call_key {
+    u64 pid_tgid;
+    u32 func_id;
+};
+
+/*
+ * The `uprobe_calls` map is used to remind the start time of a call when we are in the
+ * uretprobe of the function
+ */
+BPF_HASH(uprobe_calls, struct uprobe_call_key, u64, 10240);
+
+// the padding of the keys must be zeroed for the lookups to match
+static inline void uprobe_call_key_init(struct uprobe_call_key *k, u32 func_id) {
+    __builtin_memset(k, 0, sizeof(*k));
+    k->pid_tgid = bpf_get_current_pid_tgid();
+    k->func_id = func_id;
+}
+
+static inline int uprobe_entry(u32 func_id) {
+    struct uprobe_call_key k;
+    uprobe_call_key_init(&k, func_id);
+    u64 ts = bpf_ktime_get_ns();
+    uprobe_calls.update(&k, &ts);
+    return 0;
+}
+
+static inline int uprobe_return(u32 func_id) {
+    struct uprobe_call_key k;
+    uprobe_call_key_init(&k, func_id);
+    u64 *start = uprobe_calls.lookup(&k);
+    if (start == NULL)
+        return 0;
+    u64 latency = bpf_ktime_get_ns() - *start;
+    uprobe_calls.delete(&k);
+
+    struct uprobe_stats_value zero = {};
+    struct uprobe_stats_key sk;
+    __builtin_memset(&sk, 0, sizeof(sk));
+    sk.func_id = func_id;
+    get_cgroup_name(sk.cgroup_name, sizeof(sk.cgroup_name));
+
+    struct uprobe_stats_value *v = uprobe_stats.lookup_or_init(&sk, &zero);
+    if (v == NULL)
+        return 0;
+
+    __sync_fetch_and_add(&v->calls, 1);
+    __sync_fetch_and_add(&v->latency_ns, latency);
+    return 0;
+}
+
+/*
+ * The uprobe and uretprobe of each instrumented function, calling uprobe_entry and uprobe_return
+ * with the index of the function, are appended by system-probe
+ */
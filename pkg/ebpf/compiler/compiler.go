@@ -0,0 +1,184 @@
+// +build linux
+
+package compiler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// defaultFlags are the flags used to compile the eBPF programs, matching the ones used to build the pre-built bytecode
+var defaultFlags = []string{
+	"-D__KERNEL__",
+	"-DCONFIG_64BIT",
+	"-D__BPF_TRACING__",
+	`-DKBUILD_MODNAME="foo"`,
+	"-Wno-unused-value",
+	"-Wno-pointer-sign",
+	"-Wno-compare-distinct-pointer-types",
+	"-Wunused",
+	"-Wall",
+	"-Werror",
+	"-O2",
+	"-emit-llvm",
+	// Some linux distributions enable stack protector by default which is not available on eBPF
+	"-fno-stack-protector",
+}
+
+// Source is a C source or header file of an eBPF program
+type Source struct {
+	Name    string
+	Content []byte
+}
+
+// Options holds the options of the runtime compilation of the eBPF programs
+type Options struct {
+	// HeaderDirs are the base directories of the kernel headers of the running kernel
+	HeaderDirs []string
+	// OutputDir is the directory where the object files are compiled and cached
+	OutputDir string
+	// Flags are added to the default compilation flags
+	Flags []string
+	// ClangPath is the path to clang, looked up in PATH if empty
+	ClangPath string
+	// LLCPath is the path to llc, looked up in PATH if empty
+	LLCPath string
+}
+
+// CompileObjectFile compiles the first of the sources, which can include the others, into an eBPF object file
+// in the output directory and returns its path. The object files are cached by their sources, flags and kernel
+// headers, so that the programs are only compiled once per kernel.
+func CompileObjectFile(name string, sources []Source, opts Options) (string, error) {
+	if len(sources) == 0 {
+		return "", fmt.Errorf("no source to compile for %s", name)
+	}
+	if len(opts.HeaderDirs) == 0 {
+		return "", fmt.Errorf("no kernel headers to compile %s", name)
+	}
+
+	flags := append(append([]string{}, defaultFlags...), opts.Flags...)
+	flags = append(flags, HeaderFlags(opts.HeaderDirs)...)
+
+	outputFile := filepath.Join(opts.OutputDir, fmt.Sprintf("%s-%s.o", name, cacheKey(sources, flags)))
+	if _, err := os.Stat(outputFile); err == nil {
+		log.Debugf("using the cached eBPF object file %s", outputFile)
+		return outputFile, nil
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return "", fmt.Errorf("could not create the compilation output directory: %s", err)
+	}
+
+	// compile in a temporary directory next to the output file, so that it only appears once complete
+	buildDir, err := ioutil.TempDir(opts.OutputDir, name)
+	if err != nil {
+		return "", fmt.Errorf("could not create the compilation directory: %s", err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	for _, source := range sources {
+		if err := ioutil.WriteFile(filepath.Join(buildDir, filepath.Base(source.Name)), source.Content, 0644); err != nil {
+			return "", fmt.Errorf("could not write %s: %s", source.Name, err)
+		}
+	}
+
+	bcFile := name + ".bc"
+	objFile := name + ".o"
+	clangArgs := append(flags, "-I", buildDir, "-c", filepath.Base(sources[0].Name), "-o", bcFile)
+	if err := run(buildDir, binaryPath(opts.ClangPath, "clang"), clangArgs...); err != nil {
+		return "", err
+	}
+	if err := run(buildDir, binaryPath(opts.LLCPath, "llc"), "-march=bpf", "-filetype=obj", "-o", objFile, bcFile); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(filepath.Join(buildDir, objFile), outputFile); err != nil {
+		return "", fmt.Errorf("could not store the eBPF object file: %s", err)
+	}
+	log.Infof("compiled %s against the kernel headers in %s", name, strings.Join(opts.HeaderDirs, ", "))
+	return outputFile, nil
+}
+
+// cacheKey hashes what the compiled object file depends on: the sources, the flags
+// (including the kernel headers directories) and the running kernel version
+func cacheKey(sources []Source, flags []string) string {
+	h := sha256.New()
+	for _, source := range sources {
+		fmt.Fprintf(h, "%s\x00%d\x00", source.Name, len(source.Content))
+		h.Write(source.Content)
+	}
+	fmt.Fprintf(h, "%s\x00", strings.Join(flags, "\x00"))
+	if version, err := kernel.HostVersion(); err == nil {
+		fmt.Fprintf(h, "%s", version)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func run(dir, binary string, args ...string) error {
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %s: %s", filepath.Base(binary), err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+func binaryPath(path, name string) string {
+	if path != "" {
+		return path
+	}
+	return name
+}
+
+// HeaderFlags returns the flags adding the kernel headers trees of the given base directories to the include paths
+func HeaderFlags(dirs []string) []string {
+	var flags []string
+	for _, dir := range dirs {
+		for _, subdir := range headerSubdirs(kernelArch()) {
+			flags = append(flags, "-isystem", filepath.Join(dir, subdir))
+		}
+	}
+	return flags
+}
+
+// headerSubdirs are the directories of a kernel headers tree holding the headers included by the eBPF programs
+func headerSubdirs(arch string) []string {
+	return []string{
+		"include",
+		"include/uapi",
+		"include/generated/uapi",
+		fmt.Sprintf("arch/%s/include", arch),
+		fmt.Sprintf("arch/%s/include/uapi", arch),
+		fmt.Sprintf("arch/%s/include/generated", arch),
+	}
+}
+
+// kernelArch returns the name of the architecture in the kernel headers tree
+func kernelArch() string {
+	switch runtime.GOARCH {
+	case "386", "amd64":
+		return "x86"
+	case "riscv64":
+		return "riscv"
+	case "ppc64", "ppc64le":
+		return "powerpc"
+	case "s390x":
+		return "s390"
+	default:
+		return runtime.GOARCH
+	}
+}
@@ -0,0 +1,108 @@
+// +build linux
+
+package compiler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScript writes a fake compiler copying its C or LLVM bitcode input
+// to the file following `-o`, and counting its invocations
+func writeScript(t *testing.T, dir, name string) string {
+	path := filepath.Join(dir, name)
+	script := `#!/bin/sh
+echo >> "$0.calls"
+in=""
+out=""
+while [ $# -gt 0 ]; do
+	case "$1" in
+	-o) out="$2"; shift ;;
+	*.c|*.bc) in="$1" ;;
+	esac
+	shift
+done
+[ -n "$in" ] && [ -n "$out" ] && cat "$in" > "$out"
+`
+	require.NoError(t, ioutil.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func countCalls(t *testing.T, script string) int {
+	content, err := ioutil.ReadFile(script + ".calls")
+	if os.IsNotExist(err) {
+		return 0
+	}
+	require.NoError(t, err)
+	return len(content)
+}
+
+func TestCompileObjectFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compiler")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	opts := Options{
+		HeaderDirs: []string{filepath.Join(dir, "headers")},
+		OutputDir:  filepath.Join(dir, "build"),
+		ClangPath:  writeScript(t, dir, "clang"),
+		LLCPath:    writeScript(t, dir, "llc"),
+	}
+	sources := []Source{
+		{Name: "pkg/ebpf/c/prog.c", Content: []byte("#include \"prog.h\"\n")},
+		{Name: "pkg/ebpf/c/prog.h", Content: []byte("#define PROG 1\n")},
+	}
+
+	objectFile, err := CompileObjectFile("prog", sources, opts)
+	require.NoError(t, err)
+	assert.Equal(t, opts.OutputDir, filepath.Dir(objectFile))
+	content, err := ioutil.ReadFile(objectFile)
+	require.NoError(t, err)
+	assert.Equal(t, "#include \"prog.h\"\n", string(content))
+	assert.Equal(t, 1, countCalls(t, opts.ClangPath))
+	assert.Equal(t, 1, countCalls(t, opts.LLCPath))
+
+	// only the object file is left in the output directory
+	files, err := ioutil.ReadDir(opts.OutputDir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	// the object file is cached
+	cached, err := CompileObjectFile("prog", sources, opts)
+	require.NoError(t, err)
+	assert.Equal(t, objectFile, cached)
+	assert.Equal(t, 1, countCalls(t, opts.ClangPath))
+
+	// and compiled again when the flags change
+	opts.Flags = []string{"-DDEBUG=1"}
+	debugFile, err := CompileObjectFile("prog", sources, opts)
+	require.NoError(t, err)
+	assert.NotEqual(t, objectFile, debugFile)
+	assert.Equal(t, 2, countCalls(t, opts.ClangPath))
+}
+
+func TestCompileObjectFileErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compiler")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sources := []Source{{Name: "prog.c", Content: []byte("int x;\n")}}
+
+	_, err = CompileObjectFile("prog", sources, Options{OutputDir: dir})
+	assert.Error(t, err, "the kernel headers are required")
+
+	_, err = CompileObjectFile("prog", nil, Options{OutputDir: dir, HeaderDirs: []string{dir}})
+	assert.Error(t, err, "a source is required")
+
+	_, err = CompileObjectFile("prog", sources, Options{
+		OutputDir:  dir,
+		HeaderDirs: []string{dir},
+		ClangPath:  filepath.Join(dir, "missing-clang"),
+	})
+	assert.Error(t, err)
+}
@@ -2,6 +2,8 @@ package ebpf
 
 import (
 	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/uprobe"
 )
 
 // Config stores all flags used by the eBPF tracer
@@ -85,6 +87,10 @@ type Config struct {
 	// default is true
 	EnableConntrackAllNamespaces bool
 
+	// EnableEBPFConntracker enables resolving network address translation from conntrack entries collected
+	// with eBPF instead of netlink events. It falls back to netlink if the eBPF conntracker can't be loaded.
+	EnableEBPFConntracker bool
+
 	// DebugPort specifies a port to run golang's expvar and pprof debug endpoint
 	DebugPort int
 
@@ -108,6 +114,43 @@ type Config struct {
 
 	// DriverBufferSize (Windows only) determines the size (in bytes) of the buffer we pass to the driver when reading flows
 	DriverBufferSize int
+
+	// EBPFTunables overrides, by name, the values of the constants patched in the eBPF programs when they are loaded
+	EBPFTunables map[string]string
+
+	// KernelHeadersDirs are user provided kernel headers directories, checked before the default locations
+	KernelHeadersDirs []string
+
+	// KernelHeadersDownloadEnabled enables the download of the kernel headers from the mirrors when they are not found on the host
+	KernelHeadersDownloadEnabled bool
+
+	// KernelHeadersDownloadDir is the directory where the downloaded kernel headers are stored
+	KernelHeadersDownloadDir string
+
+	// KernelHeadersMirrors are the base URLs of the mirrors serving the kernel headers archives
+	KernelHeadersMirrors []string
+
+	// KernelHeadersKeyring is the path of the OpenPGP keyring the downloaded kernel headers archives are verified with
+	KernelHeadersKeyring string
+
+	// EnableRuntimeCompiler enables the compilation of the network tracer against the kernel headers
+	// when the pre-built eBPF programs can't be loaded
+	EnableRuntimeCompiler bool
+
+	// RuntimeCompilerOutputDir is the directory where the eBPF programs compiled at runtime are cached
+	RuntimeCompilerOutputDir string
+
+	// EnableConnectionRollups collapses the ephemeral ports of the clients, merging their connections to
+	// the same server port in rollups
+	EnableConnectionRollups bool
+
+	// EnableTLSMetadata captures the TLS handshakes of the TCP connections to report the server name, the
+	// version and the cipher suite of their TLS sessions
+	EnableTLSMetadata bool
+
+	// UprobeTargets are the binaries and functions instrumented with uprobes to count their calls and
+	// measure their latency
+	UprobeTargets []uprobe.Target
 }
 
 // NewDefaultConfig enables traffic collection for all connection types
@@ -139,5 +182,8 @@ func NewDefaultConfig() *Config {
 		DNSTimeout:           15 * time.Second,
 		OffsetGuessThreshold: 400,
 		EnableMonotonicCount: false,
+		// Kernel headers configuration
+		KernelHeadersDownloadDir: "/var/tmp/datadog-agent/system-probe/kernel-headers",
+		RuntimeCompilerOutputDir: "/var/tmp/datadog-agent/system-probe/build",
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/ebpf/manager"
@@ -13,6 +14,9 @@ import (
 
 const x64SyscallPrefix = "__x64_"
 
+// kernelLockdownMode returns the lockdown mode of the kernel, it's overridden in the tests
+var kernelLockdownMode = coreconfig.GetKernelLockdownMode
+
 // EnabledProbes returns a map of probes that are enabled per config settings.
 // This map does not include the probes used exclusively in the offset guessing process.
 func (c *Config) EnabledProbes(pre410Kernel bool) (map[bytecode.ProbeName]struct{}, error) {
@@ -106,8 +110,15 @@ func (c *Config) chooseSyscallProbe(tracepoint bytecode.ProbeName, x64probe byte
 		}
 	}
 
-	if id, err := manager.GetTracepointID(category, tpName); c.EnableTracepoints && err == nil && id != -1 {
-		log.Info("Using a tracepoint to probe bind syscall")
+	// the kernel lockdown restricts the kprobes, so the tracepoints are preferred whenever they're available
+	lockdown := kernelLockdownMode()
+	lockedDown := lockdown != "" && lockdown != "none"
+	if id, err := manager.GetTracepointID(category, tpName); (c.EnableTracepoints || lockedDown) && err == nil && id != -1 {
+		if lockedDown && !c.EnableTracepoints {
+			log.Infof("Using a tracepoint to probe %s syscall, the kernel is in lockdown mode %s", syscall, lockdown)
+		} else {
+			log.Info("Using a tracepoint to probe bind syscall")
+		}
 		return tracepoint, nil
 	}
 
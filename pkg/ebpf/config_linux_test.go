@@ -3,6 +3,7 @@
 package ebpf
 
 import (
+	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
 	"github.com/DataDog/ebpf/manager"
 	"github.com/stretchr/testify/assert"
@@ -13,6 +14,9 @@ import (
 )
 
 func TestChooseSyscall(t *testing.T) {
+	defer func() { kernelLockdownMode = coreconfig.GetKernelLockdownMode }()
+	kernelLockdownMode = func() string { return "none" }
+
 	c := NewDefaultConfig()
 
 	_, err := c.chooseSyscallProbe("wrongformat", "", "")
@@ -48,4 +52,12 @@ func TestChooseSyscall(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, bytecode.TraceSysBindEnter, tp)
+
+	// the tracepoints are preferred when the kernel is locked down
+	c.EnableTracepoints = false
+	kernelLockdownMode = func() string { return "integrity" }
+	tp, err = c.chooseSyscallProbe(bytecode.TraceSysBindEnter, bytecode.SysBindX64, bytecode.SysBind)
+	require.NoError(t, err)
+
+	assert.Equal(t, bytecode.TraceSysBindEnter, tp)
 }
@@ -0,0 +1,37 @@
+#ifndef CONNTRACK_KERN_USER_H
+#define CONNTRACK_KERN_USER_H
+
+#include <linux/types.h>
+
+// Metadata bit masks, the same values as the ones of conn_tuple_t
+// 0 << x is only for readability
+typedef enum {
+    // Connection type
+    CONNTRACK_TYPE_UDP = 0,
+    CONNTRACK_TYPE_TCP = 1,
+
+    // Connection family
+    CONNTRACK_V4 = 0 << 1,
+    CONNTRACK_V6 = 1 << 1,
+} conntrack_metadata_mask_t;
+
+struct conntrack_tuple {
+    /* Using the type unsigned __int128 generates an error in the ebpf verifier */
+    __u64 saddr_h;
+    __u64 saddr_l;
+    __u64 daddr_h;
+    __u64 daddr_l;
+    __u16 sport;
+    __u16 dport;
+    // Metadata description:
+    // First bit indicates if the connection is TCP (1) or UDP (0)
+    // Second bit indicates if the connection is V6 (1) or V4 (0)
+    __u32 metadata; // This is that big because it seems that we atleast need a 32-bit aligned struct
+};
+
+struct conntrack_telemetry {
+    __u64 registers;
+    __u64 registers_dropped;
+};
+
+#endif /* defined(CONNTRACK_KERN_USER_H) */
@@ -0,0 +1,261 @@
+// +build linux_bpf,bcc
+
+package ebpf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/netlink"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	bpflib "github.com/iovisor/gobpf/bcc"
+	"golang.org/x/sys/unix"
+)
+
+/*
+#include <string.h>
+#include "conntrack-kern-user.h"
+*/
+import "C"
+
+type conntrackTuple C.struct_conntrack_tuple
+
+// ebpfConntracker is a netlink.Conntracker resolving the NAT translations from a map
+// filled by kprobes on the insertion of the conntrack entries in the kernel
+type ebpfConntracker struct {
+	m            *bpflib.Module
+	ctMap        *bpflib.Table
+	telemetryMap *bpflib.Table
+
+	stats struct {
+		gets                 int64
+		getTimeTotal         int64
+		unregisters          int64
+		unregistersTotalTime int64
+	}
+}
+
+// NewEBPFConntracker creates a netlink.Conntracker which doesn't need to receive every
+// conntrack event from netlink, as the NAT translations are collected from eBPF
+func NewEBPFConntracker(cfg *Config) (netlink.Conntracker, error) {
+	source, err := processHeaders(cfg.BPFDir, "pkg/ebpf/c/conntrack-kern.c")
+	if err != nil {
+		return nil, fmt.Errorf("Couldn’t process headers for asset “pkg/ebpf/c/conntrack-kern.c”: %v", err)
+	}
+
+	headerFlags, headersErr := setupKernelHeaders(cfg)
+	if headersErr != nil {
+		log.Warnf("Couldn’t find kernel headers: %s", headersErr)
+	}
+
+	m := bpflib.NewModule(source.String(), append([]string{
+		fmt.Sprintf("-DCONNTRACK_MAX_STATE_SIZE=%d", cfg.ConntrackMaxStateSize),
+		fmt.Sprintf("-DSYSTEM_PROBE_PID=%d", os.Getpid()),
+	}, headerFlags...))
+	if m == nil {
+		if headersErr != nil {
+			return nil, fmt.Errorf("Failed to compile “conntrack-kern.c”: %s", headersErr)
+		}
+		return nil, fmt.Errorf("Failed to compile “conntrack-kern.c”")
+	}
+
+	for probe, fn := range map[string]string{
+		"kprobe____nf_conntrack_hash_insert": "__nf_conntrack_hash_insert",
+		"kprobe__ctnetlink_fill_info":        "ctnetlink_fill_info",
+	} {
+		kprobe, err := m.LoadKprobe(probe)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("Failed to load %s: %s", probe, err)
+		}
+
+		if err := m.AttachKprobe(fn, kprobe, -1); err != nil {
+			m.Close()
+			return nil, fmt.Errorf("Failed to attach %s: %s", fn, err)
+		}
+	}
+
+	ctr := &ebpfConntracker{
+		m:            m,
+		ctMap:        bpflib.NewTable(m.TableId("conntrack"), m),
+		telemetryMap: bpflib.NewTable(m.TableId("conntrack_telemetry"), m),
+	}
+
+	if err := ctr.loadInitialState(cfg); err != nil {
+		log.Warnf("could not load the initial conntrack state, NAT of the connections opened before system-probe started won't be resolved: %s", err)
+	}
+
+	log.Infof("initialized eBPF conntrack")
+	return ctr, nil
+}
+
+// loadInitialState dumps the conntrack table over netlink, so that the existing entries
+// are registered by the kprobe on ctnetlink_fill_info
+func (ctr *ebpfConntracker) loadInitialState(cfg *Config) error {
+	consumer, err := netlink.NewConsumer(cfg.ProcRoot, cfg.ConntrackRateLimit, cfg.EnableConntrackAllNamespaces)
+	if err != nil {
+		return err
+	}
+	defer consumer.Stop()
+
+	for _, family := range []uint8{unix.AF_INET, unix.AF_INET6} {
+		for e := range consumer.DumpTable(family) {
+			e.Done()
+		}
+	}
+	return nil
+}
+
+func (ctr *ebpfConntracker) GetTranslationForConn(c network.ConnectionStats) *network.IPTranslation {
+	then := time.Now().UnixNano()
+	defer func() {
+		atomic.AddInt64(&ctr.stats.gets, 1)
+		atomic.AddInt64(&ctr.stats.getTimeTotal, time.Now().UnixNano()-then)
+	}()
+
+	key := newConntrackTuple(c.Source, c.Dest, c.SPort, c.DPort, c.Type)
+	if key == nil {
+		return nil
+	}
+
+	reply, ok := ctr.get(key)
+	if !ok {
+		return nil
+	}
+
+	return &network.IPTranslation{
+		ReplSrcIP:   reply.sourceAddress(),
+		ReplDstIP:   reply.destAddress(),
+		ReplSrcPort: uint16(reply.sport),
+		ReplDstPort: uint16(reply.dport),
+	}
+}
+
+func (ctr *ebpfConntracker) DeleteTranslation(c network.ConnectionStats) {
+	then := time.Now().UnixNano()
+	defer func() {
+		atomic.AddInt64(&ctr.stats.unregistersTotalTime, time.Now().UnixNano()-then)
+	}()
+
+	keys := []*conntrackTuple{
+		newConntrackTuple(c.Source, c.Dest, c.SPort, c.DPort, c.Type),
+		newConntrackTuple(c.Dest, c.Source, c.DPort, c.SPort, c.Type),
+	}
+
+	for _, k := range keys {
+		if k == nil {
+			continue
+		}
+
+		reply, ok := ctr.get(k)
+		if !ok {
+			log.Tracef("not deleting %+v from conntrack", k)
+			continue
+		}
+
+		_ = ctr.ctMap.Delete(k.bytes())
+		_ = ctr.ctMap.Delete(reply.bytes())
+		log.Tracef("deleted %+v from conntrack", k)
+		atomic.AddInt64(&ctr.stats.unregisters, 1)
+		break
+	}
+}
+
+func (ctr *ebpfConntracker) GetStats() map[string]int64 {
+	m := map[string]int64{}
+
+	var telemetry C.struct_conntrack_telemetry
+	if data, err := ctr.telemetryMap.Get(make([]byte, 4)); err == nil && len(data) >= C.sizeof_struct_conntrack_telemetry {
+		C.memcpy(unsafe.Pointer(&telemetry), unsafe.Pointer(&data[0]), C.sizeof_struct_conntrack_telemetry)
+		m["registers_total"] = int64(telemetry.registers)
+		m["registers_dropped"] = int64(telemetry.registers_dropped)
+	}
+
+	gets := atomic.LoadInt64(&ctr.stats.gets)
+	if gets != 0 {
+		m["gets_total"] = gets
+		m["nanoseconds_per_get"] = atomic.LoadInt64(&ctr.stats.getTimeTotal) / gets
+	}
+	unregisters := atomic.LoadInt64(&ctr.stats.unregisters)
+	if unregisters != 0 {
+		m["unregisters_total"] = unregisters
+		m["nanoseconds_per_unregister"] = atomic.LoadInt64(&ctr.stats.unregistersTotalTime) / unregisters
+	}
+
+	return m
+}
+
+func (ctr *ebpfConntracker) Close() {
+	ctr.m.Close()
+}
+
+func (ctr *ebpfConntracker) get(key *conntrackTuple) (*conntrackTuple, bool) {
+	data, err := ctr.ctMap.Get(key.bytes())
+	if err != nil || len(data) < C.sizeof_struct_conntrack_tuple {
+		return nil, false
+	}
+
+	value := new(conntrackTuple)
+	C.memcpy(unsafe.Pointer(value), unsafe.Pointer(&data[0]), C.sizeof_struct_conntrack_tuple)
+	return value, true
+}
+
+func newConntrackTuple(saddr, daddr util.Address, sport, dport uint16, proto network.ConnectionType) *conntrackTuple {
+	t := &conntrackTuple{
+		sport: C.__u16(sport),
+		dport: C.__u16(dport),
+	}
+	sbytes := saddr.Bytes()
+	dbytes := daddr.Bytes()
+	if len(sbytes) == 4 && len(dbytes) == 4 {
+		t.metadata |= C.CONNTRACK_V4
+		t.saddr_l = C.__u64(binary.LittleEndian.Uint32(sbytes))
+		t.daddr_l = C.__u64(binary.LittleEndian.Uint32(dbytes))
+	} else if len(sbytes) == 16 && len(dbytes) == 16 {
+		t.metadata |= C.CONNTRACK_V6
+		t.saddr_h = C.__u64(binary.LittleEndian.Uint64(sbytes[:8]))
+		t.saddr_l = C.__u64(binary.LittleEndian.Uint64(sbytes[8:]))
+		t.daddr_h = C.__u64(binary.LittleEndian.Uint64(dbytes[:8]))
+		t.daddr_l = C.__u64(binary.LittleEndian.Uint64(dbytes[8:]))
+	} else {
+		return nil
+	}
+
+	switch proto {
+	case network.TCP:
+		t.metadata |= C.CONNTRACK_TYPE_TCP
+	case network.UDP:
+		t.metadata |= C.CONNTRACK_TYPE_UDP
+	}
+
+	return t
+}
+
+func (t *conntrackTuple) isIPv4() bool {
+	return t.metadata&C.CONNTRACK_V6 == 0
+}
+
+func (t *conntrackTuple) sourceAddress() util.Address {
+	if t.isIPv4() {
+		return util.V4Address(uint32(t.saddr_l))
+	}
+	return util.V6Address(uint64(t.saddr_l), uint64(t.saddr_h))
+}
+
+func (t *conntrackTuple) destAddress() util.Address {
+	if t.isIPv4() {
+		return util.V4Address(uint32(t.daddr_l))
+	}
+	return util.V6Address(uint64(t.daddr_l), uint64(t.daddr_h))
+}
+
+func (t *conntrackTuple) bytes() []byte {
+	return C.GoBytes(unsafe.Pointer(t), C.sizeof_struct_conntrack_tuple)
+}
@@ -0,0 +1,12 @@
+// +build linux_bpf,!bcc
+
+package ebpf
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/netlink"
+)
+
+// NewEBPFConntracker is not implemented without bcc
+func NewEBPFConntracker(cfg *Config) (netlink.Conntracker, error) {
+	return nil, ErrNotImplemented
+}
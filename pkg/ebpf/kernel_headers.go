@@ -0,0 +1,30 @@
+// +build linux_bpf,bcc
+
+package ebpf
+
+import (
+	"os"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/compiler"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+)
+
+// setupKernelHeaders points bcc to the kernel headers matching the running kernel, as it only
+// looks for them in /lib/modules/<release>/build unless BCC_KERNEL_SOURCE is set. BCC_KERNEL_SOURCE
+// holds a single directory, the returned compilation flags add the other ones to the include paths.
+func setupKernelHeaders(cfg *Config) ([]string, error) {
+	dirs, err := kernel.FindHeaderDirs(kernel.HeaderOptions{
+		Dirs:            cfg.KernelHeadersDirs,
+		DownloadEnabled: cfg.KernelHeadersDownloadEnabled,
+		DownloadDir:     cfg.KernelHeadersDownloadDir,
+		Mirrors:         cfg.KernelHeadersMirrors,
+		Keyring:         cfg.KernelHeadersKeyring,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Setenv("BCC_KERNEL_SOURCE", dirs[0]); err != nil {
+		return nil, err
+	}
+	return compiler.HeaderFlags(dirs[1:]), nil
+}
@@ -0,0 +1,46 @@
+package ebpf
+
+import (
+	"fmt"
+)
+
+// LoadErrorReason is the classified cause of the failure to load eBPF programs
+type LoadErrorReason string
+
+const (
+	// LoadErrorKernelTooOld is reported when the kernel lacks a feature, program type, helper or function used by the programs
+	LoadErrorKernelTooOld LoadErrorReason = "kernel_too_old"
+	// LoadErrorLockdown is reported when the kernel lockdown mode forbids the use of eBPF
+	LoadErrorLockdown LoadErrorReason = "lockdown"
+	// LoadErrorMemlock is reported when the locked memory limit is too low for the eBPF maps and programs
+	LoadErrorMemlock LoadErrorReason = "memlock"
+	// LoadErrorBTFMissing is reported when the programs require BTF type information the kernel doesn't provide
+	LoadErrorBTFMissing LoadErrorReason = "btf_missing"
+	// LoadErrorPermission is reported when system-probe lacks the privileges to load eBPF programs
+	LoadErrorPermission LoadErrorReason = "permission"
+	// LoadErrorVerifier is reported when the verifier rejects a program
+	LoadErrorVerifier LoadErrorReason = "verifier"
+	// LoadErrorUnknown is reported for all the other failures
+	LoadErrorUnknown LoadErrorReason = "unknown"
+)
+
+// LoadError describes the failure to load, or to attach, the eBPF programs of a probe. It holds the verifier log,
+// if any, and diagnostics about the features of the host to report through the status and the flare.
+type LoadError struct {
+	Probe       string          `json:"probe"`
+	Reason      LoadErrorReason `json:"reason"`
+	Message     string          `json:"message"`
+	VerifierLog string          `json:"verifier_log,omitempty"`
+	Diagnostics []string        `json:"diagnostics,omitempty"`
+
+	err error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("failed to load the eBPF programs of %s (%s): %s", e.Probe, e.Reason, e.Message)
+}
+
+// Unwrap returns the original error
+func (e *LoadError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,222 @@
+// +build linux
+
+package ebpf
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+)
+
+const (
+	// maxVerifierLogSize is the size of the end of the verifier log kept in the load errors, where the verifier
+	// explains why it rejected the program
+	maxVerifierLogSize = 4096
+	// capSysAdmin is the bit of the CAP_SYS_ADMIN capability in the capability sets
+	capSysAdmin = 21
+)
+
+var (
+	// verifierInstructionPattern matches the instructions dumped in the verifier logs, like `0: (bf) r6 = r1`
+	verifierInstructionPattern = regexp.MustCompile(`\d+: \([0-9a-f]{2}\)`)
+	// verifierSummaryPattern matches the summary line ending the verifier logs
+	verifierSummaryPattern = regexp.MustCompile(`processed \d+ insns`)
+	// minKernelVersion is the oldest kernel supporting the program types used by the probes
+	minKernelVersion = kernel.VersionCode(4, 4, 0)
+)
+
+// loadEnvironment holds the features of the host that can prevent eBPF programs from loading
+type loadEnvironment struct {
+	kernelVersion  kernel.Version
+	lockdown       string
+	secureBoot     bool
+	btfAvailable   bool
+	memlockLimit   uint64
+	memlockKnown   bool
+	capSysAdmin    bool
+	capKnown       bool
+	debugFSMounted bool
+}
+
+// NewLoadError classifies the failure to load, or to attach, the eBPF programs of a probe
+func NewLoadError(probe string, err error) *LoadError {
+	if err == nil {
+		return nil
+	}
+
+	// the failure may have been classified already by the caller
+	var loadErr *LoadError
+	if errors.As(err, &loadErr) {
+		return loadErr
+	}
+
+	return classifyLoadError(probe, err, getLoadEnvironment())
+}
+
+func getLoadEnvironment() loadEnvironment {
+	var env loadEnvironment
+
+	if version, err := kernel.HostVersion(); err == nil {
+		env.kernelVersion = version
+	}
+
+	env.lockdown = coreconfig.GetKernelLockdownMode()
+	env.secureBoot = coreconfig.IsSecureBootEnabled()
+
+	if _, err := os.Stat(util.HostSys("kernel/btf/vmlinux")); err == nil {
+		env.btfAvailable = true
+	}
+
+	var limit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_MEMLOCK, &limit); err == nil {
+		env.memlockLimit = limit.Cur
+		env.memlockKnown = true
+	}
+
+	if capabilities, err := getEffectiveCapabilities(); err == nil {
+		env.capSysAdmin = capabilities&(1<<capSysAdmin) != 0
+		env.capKnown = true
+	}
+
+	env.debugFSMounted, _ = kernel.IsDebugFSMounted()
+
+	return env
+}
+
+// getEffectiveCapabilities returns the effective capabilities of system-probe
+func getEffectiveCapabilities() (uint64, error) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "CapEff:") {
+			return strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "CapEff:")), 16, 64)
+		}
+	}
+	return 0, errors.New("no effective capabilities found")
+}
+
+// diagnostics describes the features of the host relevant to the eBPF load failures
+func (env loadEnvironment) diagnostics() []string {
+	var diagnostics []string
+
+	if env.kernelVersion != 0 {
+		diagnostics = append(diagnostics, fmt.Sprintf("kernel version: %s", env.kernelVersion))
+	}
+
+	if env.lockdown != "" {
+		diagnostics = append(diagnostics, fmt.Sprintf("kernel lockdown mode: %s", env.lockdown))
+	}
+
+	if env.secureBoot {
+		diagnostics = append(diagnostics, "secure boot: enabled")
+	}
+
+	if env.btfAvailable {
+		diagnostics = append(diagnostics, "BTF type information: available")
+	} else {
+		diagnostics = append(diagnostics, "BTF type information: not available")
+	}
+
+	if env.memlockKnown {
+		if env.memlockLimit == unix.RLIM_INFINITY {
+			diagnostics = append(diagnostics, "locked memory limit: unlimited")
+		} else {
+			diagnostics = append(diagnostics, fmt.Sprintf("locked memory limit: %d bytes", env.memlockLimit))
+		}
+	}
+
+	if env.capKnown {
+		diagnostics = append(diagnostics, fmt.Sprintf("CAP_SYS_ADMIN capability: %t", env.capSysAdmin))
+	}
+
+	if env.debugFSMounted {
+		diagnostics = append(diagnostics, "debugfs: mounted")
+	} else {
+		diagnostics = append(diagnostics, "debugfs: not mounted or not accessible")
+	}
+
+	return diagnostics
+}
+
+// extractVerifierLog returns the end of the verifier log included in the message of a load error, if any
+func extractVerifierLog(message string) string {
+	start := -1
+	if loc := verifierInstructionPattern.FindStringIndex(message); loc != nil {
+		start = loc[0]
+	} else if loc := verifierSummaryPattern.FindStringIndex(message); loc != nil {
+		start = strings.LastIndex(message[:loc[0]], ": ") + 1
+	}
+
+	if start < 0 {
+		return ""
+	}
+
+	verifierLog := strings.TrimSpace(message[start:])
+	if len(verifierLog) > maxVerifierLogSize {
+		verifierLog = verifierLog[len(verifierLog)-maxVerifierLogSize:]
+	}
+	return verifierLog
+}
+
+func hasErrno(err error, errno unix.Errno, message string) bool {
+	return errors.Is(err, errno) || strings.Contains(message, errno.Error())
+}
+
+func classifyLoadError(probe string, err error, env loadEnvironment) *LoadError {
+	message := err.Error()
+	loadErr := &LoadError{
+		Probe:       probe,
+		Reason:      LoadErrorUnknown,
+		Message:     message,
+		VerifierLog: extractVerifierLog(message),
+		Diagnostics: env.diagnostics(),
+		err:         err,
+	}
+	lowerMessage := strings.ToLower(message)
+	kernelTooOld := env.kernelVersion != 0 && env.kernelVersion < minKernelVersion
+
+	switch {
+	case hasErrno(err, unix.EPERM, message):
+		switch {
+		case env.lockdown == "confidentiality":
+			// the confidentiality mode forbids the kprobes and the eBPF reads of the kernel memory
+			loadErr.Reason = LoadErrorLockdown
+		case env.capKnown && !env.capSysAdmin:
+			loadErr.Reason = LoadErrorPermission
+		case strings.Contains(lowerMessage, "memlock") || strings.Contains(lowerMessage, "map create") ||
+			(env.memlockKnown && env.memlockLimit != unix.RLIM_INFINITY):
+			loadErr.Reason = LoadErrorMemlock
+		default:
+			loadErr.Reason = LoadErrorPermission
+		}
+	case strings.Contains(lowerMessage, "btf") && !env.btfAvailable:
+		loadErr.Reason = LoadErrorBTFMissing
+	case strings.Contains(lowerMessage, "unknown func"):
+		// the verifier rejects the calls to the helpers the kernel doesn't provide
+		loadErr.Reason = LoadErrorKernelTooOld
+	case kernelTooOld:
+		loadErr.Reason = LoadErrorKernelTooOld
+	case loadErr.VerifierLog != "" || hasErrno(err, unix.EACCES, message):
+		loadErr.Reason = LoadErrorVerifier
+	case hasErrno(err, unix.ENOENT, message) && env.debugFSMounted:
+		// the kprobes can't be attached to the functions the kernel doesn't provide
+		loadErr.Reason = LoadErrorKernelTooOld
+	}
+
+	return loadErr
+}
@@ -0,0 +1,147 @@
+// +build linux
+
+package ebpf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+)
+
+func TestClassifyLoadError(t *testing.T) {
+	env := loadEnvironment{
+		kernelVersion:  kernel.VersionCode(5, 4, 0),
+		lockdown:       "none",
+		btfAvailable:   true,
+		memlockLimit:   unix.RLIM_INFINITY,
+		memlockKnown:   true,
+		capSysAdmin:    true,
+		capKnown:       true,
+		debugFSMounted: true,
+	}
+
+	verifierLog := "0: (bf) r6 = r1\n1: (85) call bpf_get_current_pid_tgid#14\n2: (61) r1 = *(u32 *)(r0 +0)\nR0 invalid mem access 'inv'\nprocessed 3 insns"
+	mapErr := fmt.Errorf("map create: %w", unix.EPERM)
+
+	tests := []struct {
+		name   string
+		err    error
+		env    func(env loadEnvironment) loadEnvironment
+		reason LoadErrorReason
+	}{
+		{
+			name:   "verifier",
+			err:    fmt.Errorf("couldn't load program kprobe/tcp_sendmsg: load program: permission denied: %s", verifierLog),
+			reason: LoadErrorVerifier,
+		},
+		{
+			name:   "missing helper",
+			err:    errors.New("load program: invalid argument: 0: (85) call bpf_get_current_cgroup_id#80\nunknown func bpf_get_current_cgroup_id#80"),
+			reason: LoadErrorKernelTooOld,
+		},
+		{
+			name: "old kernel",
+			err:  fmt.Errorf("load program: %w", unix.EINVAL),
+			env: func(env loadEnvironment) loadEnvironment {
+				env.kernelVersion = kernel.VersionCode(3, 10, 0)
+				return env
+			},
+			reason: LoadErrorKernelTooOld,
+		},
+		{
+			name:   "missing kernel function",
+			err:    errors.Wrap(unix.ENOENT, "couldn't enable kprobe kprobe/tcp_sendmsg"),
+			reason: LoadErrorKernelTooOld,
+		},
+		{
+			name: "missing debugfs",
+			err:  errors.Wrap(unix.ENOENT, "couldn't enable kprobe kprobe/tcp_sendmsg"),
+			env: func(env loadEnvironment) loadEnvironment {
+				env.debugFSMounted = false
+				return env
+			},
+			reason: LoadErrorUnknown,
+		},
+		{
+			name: "lockdown",
+			err:  fmt.Errorf("load program: %w", unix.EPERM),
+			env: func(env loadEnvironment) loadEnvironment {
+				env.lockdown = "confidentiality"
+				return env
+			},
+			reason: LoadErrorLockdown,
+		},
+		{
+			name: "memlock",
+			err:  mapErr,
+			env: func(env loadEnvironment) loadEnvironment {
+				env.memlockLimit = 64 * 1024
+				return env
+			},
+			reason: LoadErrorMemlock,
+		},
+		{
+			name: "missing capability",
+			err:  mapErr,
+			env: func(env loadEnvironment) loadEnvironment {
+				env.capSysAdmin = false
+				return env
+			},
+			reason: LoadErrorPermission,
+		},
+		{
+			name: "missing BTF",
+			err:  errors.New("load kernel spec: can't load BTF: no such file or directory"),
+			env: func(env loadEnvironment) loadEnvironment {
+				env.btfAvailable = false
+				return env
+			},
+			reason: LoadErrorBTFMissing,
+		},
+		{
+			name:   "unknown",
+			err:    errors.New("something went wrong"),
+			reason: LoadErrorUnknown,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testEnv := env
+			if test.env != nil {
+				testEnv = test.env(env)
+			}
+
+			loadErr := classifyLoadError("tracer", test.err, testEnv)
+			assert.Equal(t, test.reason, loadErr.Reason)
+			assert.Equal(t, test.err, errors.Unwrap(loadErr))
+			assert.NotEmpty(t, loadErr.Diagnostics)
+		})
+	}
+}
+
+func TestExtractVerifierLog(t *testing.T) {
+	assert.Empty(t, extractVerifierLog("load program: invalid argument"))
+
+	log := extractVerifierLog("load program: permission denied: 0: (bf) r6 = r1\nR0 invalid mem access 'inv'\nprocessed 1 insns")
+	assert.True(t, strings.HasPrefix(log, "0: (bf) r6 = r1"))
+	assert.True(t, strings.HasSuffix(log, "processed 1 insns"))
+
+	log = extractVerifierLog("load program: permission denied: back-edge from insn 12 to 4\nprocessed 12 insns")
+	assert.True(t, strings.HasPrefix(log, "back-edge from insn 12 to 4"))
+
+	long := "load program: permission denied: 0: (bf) r6 = r1\n" + strings.Repeat("R0 invalid mem access 'inv'\n", 1000)
+	assert.Len(t, extractVerifierLog(long), maxVerifierLogSize)
+}
+
+func TestNewLoadErrorKeepsClassification(t *testing.T) {
+	loadErr := &LoadError{Probe: "tracer", Reason: LoadErrorMemlock, Message: "map create"}
+	assert.Equal(t, loadErr, NewLoadError("network_tracer", errors.Wrap(loadErr, "could not start ebpf manager")))
+	assert.Nil(t, NewLoadError("network_tracer", nil))
+}
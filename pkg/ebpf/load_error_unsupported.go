@@ -0,0 +1,17 @@
+// +build !linux
+
+package ebpf
+
+// NewLoadError returns the failure to load the eBPF programs of a probe
+func NewLoadError(probe string, err error) *LoadError {
+	if err == nil {
+		return nil
+	}
+
+	return &LoadError{
+		Probe:   probe,
+		Reason:  LoadErrorUnknown,
+		Message: err.Error(),
+		err:     err,
+	}
+}
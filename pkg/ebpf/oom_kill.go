@@ -29,8 +29,16 @@ func NewOOMKillProbe(cfg *Config) (*OOMKillProbe, error) {
 		return nil, fmt.Errorf("Couldn’t process headers for asset “pkg/ebpf/c/oom-kill-kern.c”: %v", err)
 	}
 
-	m := bpflib.NewModule(source.String(), []string{})
+	headerFlags, headersErr := setupKernelHeaders(cfg)
+	if headersErr != nil {
+		log.Warnf("Couldn’t find kernel headers: %s", headersErr)
+	}
+
+	m := bpflib.NewModule(source.String(), headerFlags)
 	if m == nil {
+		if headersErr != nil {
+			return nil, fmt.Errorf("failed to compile “oom-kill-kern.c”: %s", headersErr)
+		}
 		return nil, fmt.Errorf("failed to compile “oom-kill-kern.c”")
 	}
 
@@ -0,0 +1,87 @@
+// +build linux_bpf
+
+package ebpf
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/compiler"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+)
+
+// The sources of the network tracer and offset guessing programs, the first one of each list is compiled
+var (
+	tracerSources      = []string{"tracer-ebpf.c", "tracer-ebpf.h", "bpf_helpers.h", "syscalls.h", "asm_goto_workaround.h"}
+	offsetGuessSources = []string{"offset-guess.c", "offset-guess.h", "bpf_helpers.h", "asm_goto_workaround.h"}
+)
+
+// compileTracerModules compiles the network tracer and offset guessing programs against the headers of the
+// running kernel, for the kernels on which the pre-built programs can't be loaded
+func compileTracerModules(config *Config) (bytecode.AssetReader, bytecode.AssetReader, error) {
+	headerDirs, err := kernel.FindHeaderDirs(kernel.HeaderOptions{
+		Dirs:            config.KernelHeadersDirs,
+		DownloadEnabled: config.KernelHeadersDownloadEnabled,
+		DownloadDir:     config.KernelHeadersDownloadDir,
+		Mirrors:         config.KernelHeadersMirrors,
+		Keyring:         config.KernelHeadersKeyring,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not find the kernel headers: %s", err)
+	}
+
+	opts := compiler.Options{
+		HeaderDirs: headerDirs,
+		OutputDir:  config.RuntimeCompilerOutputDir,
+		Flags:      []string{"-include", "asm_goto_workaround.h"},
+	}
+	if config.BPFDebug {
+		opts.Flags = append(opts.Flags, "-DDEBUG=1")
+	}
+
+	tracer, err := compileModule(config.BPFDir, "tracer-ebpf", tracerSources, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	offsetGuess, err := compileModule(config.BPFDir, "offset-guess", offsetGuessSources, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tracer, offsetGuess, nil
+}
+
+func compileModule(bpfDir, name string, files []string, opts compiler.Options) (bytecode.AssetReader, error) {
+	sources := make([]compiler.Source, 0, len(files))
+	for _, file := range files {
+		content, err := readAsset(bpfDir, "pkg/ebpf/c/"+file)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't find the source %s: %s", file, err)
+		}
+		sources = append(sources, compiler.Source{Name: file, Content: content})
+	}
+
+	objectFile, err := compiler.CompileObjectFile(name, sources, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile %s: %s", name, err)
+	}
+
+	module, err := os.Open(objectFile)
+	if err != nil {
+		return nil, err
+	}
+	return module, nil
+}
+
+func readAsset(bpfDir, name string) ([]byte, error) {
+	reader, err := bytecode.GetReader(bpfDir, name)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return ioutil.ReadAll(reader)
+}
@@ -30,8 +30,16 @@ func NewTCPQueueLengthTracer(cfg *Config) (*TCPQueueLengthTracer, error) {
 		return nil, fmt.Errorf("Couldn’t process headers for asset “pkg/ebpf/c/tcp-queue-length-kern.c”: %v", err)
 	}
 
-	m := bpflib.NewModule(source.String(), []string{})
+	headerFlags, headersErr := setupKernelHeaders(cfg)
+	if headersErr != nil {
+		log.Warnf("Couldn’t find kernel headers: %s", headersErr)
+	}
+
+	m := bpflib.NewModule(source.String(), headerFlags)
 	if m == nil {
+		if headersErr != nil {
+			return nil, fmt.Errorf("Failed to compile “tcp-queue-length-kern.c”: %s", headersErr)
+		}
 		return nil, fmt.Errorf("Failed to compile “tcp-queue-length-kern.c”")
 	}
 
@@ -3,6 +3,7 @@
 package ebpf
 
 import (
+	"errors"
 	"expvar"
 	"fmt"
 	"math"
@@ -40,6 +41,7 @@ type Tracer struct {
 	config *Config
 
 	state          network.State
+	rollups        *network.RollupState
 	portMapping    *network.PortMapping
 	udpPortMapping *network.PortMapping
 
@@ -47,6 +49,9 @@ type Tracer struct {
 
 	reverseDNS network.ReverseDNS
 
+	// tls is nil when the capture of the TLS metadata is disabled
+	tls *network.TLSSnooper
+
 	perfMap      *manager.PerfMap
 	perfHandler  *bytecode.PerfHandler
 	batchManager *PerfBatchManager
@@ -96,6 +101,16 @@ func NewTracer(config *Config) (*Tracer, error) {
 		return nil, fmt.Errorf("%s: %s", "system-probe unsupported", err)
 	}
 
+	// the confidentiality lockdown mode forbids the kprobes and the reads of the kernel memory the tracer relies on
+	if lockdown := kernelLockdownMode(); lockdown == "confidentiality" {
+		return nil, &LoadError{
+			Probe:       "network_tracer",
+			Reason:      LoadErrorLockdown,
+			Message:     "the kernel lockdown mode confidentiality forbids the kprobes and the reads of the kernel memory",
+			Diagnostics: getLoadEnvironment().diagnostics(),
+		}
+	}
+
 	buf, err := bytecode.ReadBPFModule(config.BPFDir, config.BPFDebug)
 	if err != nil {
 		return nil, fmt.Errorf("could not read bpf module: %s", err)
@@ -105,6 +120,23 @@ func NewTracer(config *Config) (*Tracer, error) {
 		return nil, fmt.Errorf("could not read offset bpf module: %s", err)
 	}
 
+	tr, err := newTracer(config, buf, offsetBuf)
+	var loadErr *LoadError
+	if err == nil || !config.EnableRuntimeCompiler || !errors.As(err, &loadErr) {
+		return tr, err
+	}
+
+	// the pre-built programs don't match the kernel, for instance when its structures differ from the
+	// upstream ones on some distributions, so they are compiled against its headers instead
+	log.Warnf("could not load the pre-built network tracer, compiling it against the kernel headers: %s", err)
+	buf, offsetBuf, compileErr := compileTracerModules(config)
+	if compileErr != nil {
+		return nil, fmt.Errorf("%s, and the runtime compilation failed: %s", err, compileErr)
+	}
+	return newTracer(config, buf, offsetBuf)
+}
+
+func newTracer(config *Config, buf bytecode.AssetReader, offsetBuf bytecode.AssetReader) (*Tracer, error) {
 	// check if current platform is using old kernel API because it affects what kprobe are we going to enable
 	currKernelVersion, err := kernel.HostVersion()
 	if err != nil {
@@ -138,7 +170,7 @@ func NewTracer(config *Config) (*Tracer, error) {
 	}
 	mgrOptions.ConstantEditors, err = runOffsetGuessing(config, offsetBuf)
 	if err != nil {
-		return nil, fmt.Errorf("error guessing offsets: %s", err)
+		return nil, fmt.Errorf("error guessing offsets: %w", err)
 	}
 
 	closedChannelSize := defaultClosedChannelSize
@@ -157,14 +189,14 @@ func NewTracer(config *Config) (*Tracer, error) {
 	enableSocketFilter := config.DNSInspection && !pre410Kernel
 	if enableSocketFilter {
 		enabledProbes[bytecode.SocketDnsFilter] = struct{}{}
-		if config.CollectDNSStats {
-			mgrOptions.ConstantEditors = append(mgrOptions.ConstantEditors, manager.ConstantEditor{
-				Name:  "dns_stats_enabled",
-				Value: uint64(1),
-			})
-		}
 	}
 
+	tunables, err := bytecode.TunableEditors(tracerTunables(config, enableSocketFilter), config.EBPFTunables)
+	if err != nil {
+		return nil, fmt.Errorf("invalid eBPF tunables: %s", err)
+	}
+	mgrOptions.ConstantEditors = append(mgrOptions.ConstantEditors, tunables...)
+
 	// exclude all non-enabled probes to ensure we don't run into problems with unsupported probe types
 	for _, p := range m.Probes {
 		if _, enabled := enabledProbes[bytecode.ProbeName(p.Section)]; !enabled {
@@ -182,7 +214,7 @@ func NewTracer(config *Config) (*Tracer, error) {
 	}
 	err = m.InitWithOptions(buf, mgrOptions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to init ebpf manager: %v", err)
+		return nil, NewLoadError("network_tracer", fmt.Errorf("failed to init ebpf manager: %w", err))
 	}
 
 	reverseDNS := network.NewNullReverseDNS()
@@ -205,6 +237,13 @@ func NewTracer(config *Config) (*Tracer, error) {
 		}
 	}
 
+	var tlsSnooper *network.TLSSnooper
+	if config.EnableTLSMetadata {
+		if tlsSnooper, err = network.NewTLSSnooper(config.ProcRoot); err != nil {
+			log.Warnf("could not initialize the TLS handshake capture, tracer will continue without TLS metadata: %s", err)
+		}
+	}
+
 	portMapping := network.NewPortMapping(config.ProcRoot, config.CollectTCPConns, config.CollectIPv6Conns)
 	udpPortMapping := network.NewPortMapping(config.ProcRoot, config.CollectTCPConns, config.CollectIPv6Conns)
 	if err := portMapping.ReadInitialState(); err != nil {
@@ -217,7 +256,7 @@ func NewTracer(config *Config) (*Tracer, error) {
 
 	conntracker := netlink.NewNoOpConntracker()
 	if config.EnableConntrack {
-		if c, err := netlink.NewConntracker(config.ProcRoot, config.ConntrackMaxStateSize, config.ConntrackRateLimit, config.EnableConntrackAllNamespaces); err != nil {
+		if c, err := newConntracker(config); err != nil {
 			log.Warnf("could not initialize conntrack, tracer will continue without NAT tracking: %s", err)
 		} else {
 			conntracker = c
@@ -238,6 +277,7 @@ func NewTracer(config *Config) (*Tracer, error) {
 		portMapping:    portMapping,
 		udpPortMapping: udpPortMapping,
 		reverseDNS:     reverseDNS,
+		tls:            tlsSnooper,
 		buffer:         make([]network.ConnectionStats, 0, 512),
 		conntracker:    conntracker,
 		sourceExcludes: network.ParseConnectionFilters(config.ExcludedSourceConnections),
@@ -247,6 +287,9 @@ func NewTracer(config *Config) (*Tracer, error) {
 		stop:           make(chan struct{}),
 		conntrack:      newCachedConntrack(config.ProcRoot, netlink.NewConntrack, 128),
 	}
+	if config.EnableConnectionRollups {
+		tr.rollups = network.NewRollupState(config.ClientStateExpiry)
+	}
 
 	tr.perfMap, tr.batchManager, err = tr.initPerfPolling(perfHandler)
 	if err != nil {
@@ -254,7 +297,8 @@ func NewTracer(config *Config) (*Tracer, error) {
 	}
 
 	if err = m.Start(); err != nil {
-		return nil, fmt.Errorf("could not start ebpf manager: %s", err)
+		tr.Stop()
+		return nil, NewLoadError("network_tracer", fmt.Errorf("could not start ebpf manager: %w", err))
 	}
 
 	go tr.expvarStats()
@@ -262,6 +306,30 @@ func NewTracer(config *Config) (*Tracer, error) {
 	return tr, nil
 }
 
+// newConntracker returns the eBPF conntracker when it is enabled and can be loaded, and the netlink one otherwise
+func newConntracker(config *Config) (netlink.Conntracker, error) {
+	if config.EnableEBPFConntracker {
+		c, err := NewEBPFConntracker(config)
+		if err == nil {
+			return c, nil
+		}
+		log.Warnf("could not initialize the eBPF conntracker, falling back to netlink: %s", err)
+	}
+	return netlink.NewConntracker(config.ProcRoot, config.ConntrackMaxStateSize, config.ConntrackRateLimit, config.EnableConntrackAllNamespaces)
+}
+
+// tracerTunables returns the constants of the tracer eBPF program that can be set when it's loaded
+func tracerTunables(config *Config, enableSocketFilter bool) []bytecode.Tunable {
+	var dnsStatsEnabled uint64
+	if enableSocketFilter && config.CollectDNSStats {
+		dnsStatsEnabled = 1
+	}
+
+	return []bytecode.Tunable{
+		{Name: "dns_stats_enabled", Default: dnsStatsEnabled, Max: 1},
+	}
+}
+
 func runOffsetGuessing(config *Config, buf bytecode.AssetReader) ([]manager.ConstantEditor, error) {
 	// Enable kernel probes used for offset guessing.
 	offsetMgr := bytecode.NewOffsetManager()
@@ -287,11 +355,11 @@ func runOffsetGuessing(config *Config, buf bytecode.AssetReader) ([]manager.Cons
 			})
 	}
 	if err := offsetMgr.InitWithOptions(buf, offsetOptions); err != nil {
-		return nil, fmt.Errorf("could not load bpf module for offset guessing: %s", err)
+		return nil, NewLoadError("network_tracer", fmt.Errorf("could not load bpf module for offset guessing: %w", err))
 	}
 
 	if err := offsetMgr.Start(); err != nil {
-		return nil, fmt.Errorf("could not start offset ebpf manager: %s", err)
+		return nil, NewLoadError("network_tracer", fmt.Errorf("could not start offset ebpf manager: %w", err))
 	}
 	defer func() {
 		err := offsetMgr.Stop(manager.CleanAll)
@@ -302,7 +370,8 @@ func runOffsetGuessing(config *Config, buf bytecode.AssetReader) ([]manager.Cons
 	start := time.Now()
 	editors, err := guessOffsets(offsetMgr, config)
 	if err != nil {
-		return nil, err
+		// the offsets can't be guessed when the kernel structures don't match the ones of the programs
+		return nil, NewLoadError("network_tracer", err)
 	}
 	log.Infof("socket struct offset guessing complete (took %v)", time.Since(start))
 	return editors, nil
@@ -364,16 +433,19 @@ func (t *Tracer) initPerfPolling(perf *bytecode.PerfHandler) (*manager.PerfMap,
 		ticker := time.NewTicker(5 * time.Minute)
 		for {
 			select {
-			case batchData, ok := <-perf.ClosedChannel:
+			case batches, ok := <-perf.ClosedChannel:
 				if !ok {
 					return
 				}
-				atomic.AddInt64(&t.perfReceived, 1)
-
-				batch := toBatch(batchData)
-				conns := t.batchManager.Extract(batch, time.Now())
-				for _, c := range conns {
-					t.storeClosedConn(c)
+				atomic.AddInt64(&t.perfReceived, int64(len(batches)))
+
+				now := time.Now()
+				for _, batchData := range batches {
+					batch := toBatch(batchData)
+					conns := t.batchManager.Extract(batch, now)
+					for _, c := range conns {
+						t.storeClosedConn(c)
+					}
 				}
 			case lostCount, ok := <-perf.LostChannel:
 				if !ok {
@@ -405,7 +477,7 @@ func (t *Tracer) initPerfPolling(perf *bytecode.PerfHandler) (*manager.PerfMap,
 }
 
 // shouldSkipConnection returns whether or not the tracer should ignore a given connection:
-//  • Local DNS (*:53) requests if configured (default: true)
+//   - Local DNS (*:53) requests if configured (default: true)
 func (t *Tracer) shouldSkipConnection(conn *network.ConnectionStats) bool {
 	isDNSConnection := conn.DPort == 53 || conn.SPort == 53
 	if !t.config.CollectLocalDNS && isDNSConnection && conn.Dest.IsLoopback() {
@@ -434,6 +506,9 @@ func (t *Tracer) storeClosedConn(cs network.ConnectionStats) {
 func (t *Tracer) Stop() {
 	close(t.stop)
 	t.reverseDNS.Close()
+	if t.tls != nil {
+		t.tls.Close()
+	}
 	_ = t.m.Stop(manager.CleanAll)
 	_ = t.perfMap.Stop(manager.CleanAll)
 	t.perfHandler.Stop()
@@ -464,6 +539,12 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 	<-done
 
 	conns := t.state.Connections(clientID, latestTime, latestConns, t.reverseDNS.GetDNSStats())
+	if t.tls != nil {
+		t.tls.Decorate(conns, time.Now())
+	}
+	if t.rollups != nil {
+		conns = t.rollups.Rollup(clientID, time.Now(), conns)
+	}
 	names := t.reverseDNS.Resolve(conns)
 	tm := t.getConnTelemetry(len(latestConns))
 
@@ -749,7 +830,7 @@ func (t *Tracer) GetStats() (map[string]interface{}, error) {
 	stateStats := t.state.GetStats()
 	conntrackStats := t.conntracker.GetStats()
 
-	return map[string]interface{}{
+	stats := map[string]interface{}{
 		"conntrack": conntrackStats,
 		"state":     stateStats,
 		"tracer": map[string]int64{
@@ -760,9 +841,14 @@ func (t *Tracer) GetStats() (map[string]interface{}, error) {
 			"pid_collisions":               pidCollisions,
 		},
 		"ebpf":    t.getEbpfTelemetry(),
+		"perf":    t.perfHandler.GetStats(),
 		"kprobes": GetProbeStats(),
 		"dns":     t.reverseDNS.GetStats(),
-	}, nil
+	}
+	if t.tls != nil {
+		stats["tls"] = t.tls.GetStats()
+	}
+	return stats, nil
 }
 
 // DebugNetworkState returns a map with the current tracer's internal state, for debugging
@@ -38,6 +38,7 @@ type Tracer struct {
 	driverInterface *network.DriverInterface
 	stopChan        chan struct{}
 	state           network.State
+	rollups         *network.RollupState
 	reverseDNS      network.ReverseDNS
 
 	connStatsActive *network.DriverBuffer
@@ -74,6 +75,9 @@ func NewTracer(config *Config) (*Tracer, error) {
 		connStatsActive: network.NewDriverBuffer(512),
 		connStatsClosed: network.NewDriverBuffer(512),
 	}
+	if config.EnableConnectionRollups {
+		tr.rollups = network.NewRollupState(config.ClientStateExpiry)
+	}
 
 	go tr.expvarStats(tr.stopChan)
 	return tr, nil
@@ -146,6 +150,9 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 	// check for expired clients in the state
 	t.state.RemoveExpiredClients(time.Now())
 	conns := t.state.Connections(clientID, uint64(time.Now().Nanosecond()), activeConnStats, t.reverseDNS.GetDNSStats())
+	if t.rollups != nil {
+		conns = t.rollups.Rollup(clientID, time.Now(), conns)
+	}
 	return &network.Connections{Conns: conns}, nil
 }
 
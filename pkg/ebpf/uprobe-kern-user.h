@@ -0,0 +1,19 @@
+#ifndef UPROBE_KERN_USER_H
+#define UPROBE_KERN_USER_H
+
+#include <linux/types.h>
+
+struct uprobe_stats_key {
+  // Index of the function in the instrumented functions
+  __u32 func_id;
+  char cgroup_name[129];
+};
+
+struct uprobe_stats_value {
+  // Number of calls which returned
+  __u64 calls;
+  // Total time spent in the function, in nanoseconds
+  __u64 latency_ns;
+};
+
+#endif /* defined(UPROBE_KERN_USER_H) */
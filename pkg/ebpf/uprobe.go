@@ -0,0 +1,157 @@
+// +build linux_bpf,bcc
+
+package ebpf
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/uprobe"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	bpflib "github.com/iovisor/gobpf/bcc"
+)
+
+/*
+#include <string.h>
+#include "uprobe-kern-user.h"
+*/
+import "C"
+
+// uprobeFunction is an instrumented function of a binary
+type uprobeFunction struct {
+	path     string
+	function string
+}
+
+// UprobeTracer counts the calls of the functions of the binaries declared in the configuration and
+// measures their latency with uprobes
+type UprobeTracer struct {
+	m         *bpflib.Module
+	statsMap  *bpflib.Table
+	functions []uprobeFunction
+}
+
+// NewUprobeTracer attaches an uprobe and an uretprobe to each of the configured functions
+func NewUprobeTracer(cfg *Config) (*UprobeTracer, error) {
+	var functions []uprobeFunction
+	for _, target := range cfg.UprobeTargets {
+		for _, function := range target.Functions {
+			functions = append(functions, uprobeFunction{path: target.Path, function: function})
+		}
+	}
+	if len(functions) == 0 {
+		return nil, fmt.Errorf("no function to instrument, check the system_probe_config.uprobes parameter")
+	}
+
+	source, err := processHeaders(cfg.BPFDir, "pkg/ebpf/c/uprobe-kern.c")
+	if err != nil {
+		return nil, fmt.Errorf("Couldn’t process headers for asset “pkg/ebpf/c/uprobe-kern.c”: %v", err)
+	}
+
+	// the probes can't tell which function they're attached to, so each function gets its own pair of probes
+	for i := range functions {
+		fmt.Fprintf(source, "\nint uprobe__entry_%d(struct pt_regs* ctx) {\n    return uprobe_entry(%d);\n}\n", i, i)
+		fmt.Fprintf(source, "\nint uretprobe__return_%d(struct pt_regs* ctx) {\n    return uprobe_return(%d);\n}\n", i, i)
+	}
+
+	headerFlags, headersErr := setupKernelHeaders(cfg)
+	if headersErr != nil {
+		log.Warnf("Couldn’t find kernel headers: %s", headersErr)
+	}
+
+	m := bpflib.NewModule(source.String(), headerFlags)
+	if m == nil {
+		if headersErr != nil {
+			return nil, fmt.Errorf("failed to compile “uprobe-kern.c”: %s", headersErr)
+		}
+		return nil, fmt.Errorf("failed to compile “uprobe-kern.c”")
+	}
+
+	for i, f := range functions {
+		if err := attachUprobes(m, i, f); err != nil {
+			m.Close()
+			return nil, err
+		}
+		log.Debugf("Instrumented function %s of %s", f.function, f.path)
+	}
+
+	table := bpflib.NewTable(m.TableId("uprobe_stats"), m)
+
+	return &UprobeTracer{
+		m:         m,
+		statsMap:  table,
+		functions: functions,
+	}, nil
+}
+
+func attachUprobes(m *bpflib.Module, i int, f uprobeFunction) error {
+	entry, err := m.LoadUprobe(fmt.Sprintf("uprobe__entry_%d", i))
+	if err != nil {
+		return fmt.Errorf("failed to load the uprobe of %s: %s", f.function, err)
+	}
+
+	if err := m.AttachUprobe(f.path, f.function, entry, -1); err != nil {
+		return fmt.Errorf("failed to attach the uprobe of %s in %s: %s", f.function, f.path, err)
+	}
+
+	ret, err := m.LoadUprobe(fmt.Sprintf("uretprobe__return_%d", i))
+	if err != nil {
+		return fmt.Errorf("failed to load the uretprobe of %s: %s", f.function, err)
+	}
+
+	if err := m.AttachUretprobe(f.path, f.function, ret, -1); err != nil {
+		return fmt.Errorf("failed to attach the uretprobe of %s in %s: %s", f.function, f.path, err)
+	}
+
+	return nil
+}
+
+// Close detaches the probes
+func (t *UprobeTracer) Close() {
+	t.m.Close()
+}
+
+// Get returns the calls of the instrumented functions per container
+func (t *UprobeTracer) Get() []uprobe.Stats {
+	if t == nil {
+		return nil
+	}
+
+	var results []uprobe.Stats
+
+	for it := t.statsMap.Iter(); it.Next(); {
+		var statsKey C.struct_uprobe_stats_key
+		data := it.Key()
+		C.memcpy(unsafe.Pointer(&statsKey), unsafe.Pointer(&data[0]), C.sizeof_struct_uprobe_stats_key)
+
+		var statsValue C.struct_uprobe_stats_value
+		data = it.Leaf()
+		C.memcpy(unsafe.Pointer(&statsValue), unsafe.Pointer(&data[0]), C.sizeof_struct_uprobe_stats_value)
+
+		funcID := int(statsKey.func_id)
+		if funcID >= len(t.functions) {
+			log.Errorf("Unknown instrumented function %d", funcID)
+			continue
+		}
+		f := t.functions[funcID]
+
+		results = append(results, uprobe.Stats{
+			Path:        f.path,
+			Function:    f.function,
+			ContainerID: C.GoString(&statsKey.cgroup_name[0]),
+			Calls:       uint64(statsValue.calls),
+			LatencyNs:   uint64(statsValue.latency_ns),
+		})
+	}
+
+	log.Debugf("Uprobe stats gathered from kernel probe: %v", results)
+	return results
+}
+
+// GetAndFlush returns the calls of the instrumented functions since the last flush
+func (t *UprobeTracer) GetAndFlush() []uprobe.Stats {
+	results := t.Get()
+	t.statsMap.DeleteAll()
+	return results
+}
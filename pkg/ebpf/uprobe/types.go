@@ -0,0 +1,18 @@
+package uprobe
+
+// Target is a binary whose functions are instrumented with uprobes
+type Target struct {
+	Path      string   `mapstructure:"path" json:"path"`
+	Functions []string `mapstructure:"functions" json:"functions"`
+}
+
+// Stats contains the invocations of a function of a binary, per container, since the last flush
+type Stats struct {
+	Path        string `json:"path"`
+	Function    string `json:"function"`
+	ContainerID string `json:"containerid"`
+	// Calls is the number of calls of the function which returned
+	Calls uint64 `json:"calls"`
+	// LatencyNs is the total time spent in the function by these calls, in nanoseconds
+	LatencyNs uint64 `json:"latency_ns"`
+}
@@ -0,0 +1,28 @@
+// +build !linux_bpf linux_bpf,!bcc
+
+package ebpf
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/ebpf/uprobe"
+)
+
+// UprobeTracer is not implemented on non-linux systems
+type UprobeTracer struct{}
+
+// NewUprobeTracer is not implemented on non-linux systems
+func NewUprobeTracer(cfg *Config) (*UprobeTracer, error) {
+	return nil, ErrNotImplemented
+}
+
+// Close is not implemented on non-linux systems
+func (t *UprobeTracer) Close() {}
+
+// Get is not implemented on non-linux systems
+func (t *UprobeTracer) Get() []uprobe.Stats {
+	return nil
+}
+
+// GetAndFlush is not implemented on non-linux systems
+func (t *UprobeTracer) GetAndFlush() []uprobe.Stats {
+	return nil
+}
@@ -123,24 +123,68 @@ func CreatePerformanceProfile(prefix, debugURL string, cpusec int, target *Profi
 	return nil
 }
 
+// Progress describes the collection of a flare, after each of its steps
+type Progress struct {
+	// Step is the name of the step just completed
+	Step string
+	// Files and Bytes are the number of files and bytes collected so far, or the size of the archive
+	// after the final "archive" step
+	Files int64
+	Bytes int64
+}
+
+// ProgressFunc is called after each step of the collection of a flare
+type ProgressFunc func(Progress)
+
+// progressReporter reports the files collected in the temporary directory of a flare
+type progressReporter struct {
+	dir      string
+	progress ProgressFunc
+}
+
+// step reports the completion of a step with the files collected so far
+func (r progressReporter) step(name string) {
+	if r.progress == nil {
+		return
+	}
+
+	var files, size int64
+	filepath.Walk(r.dir, func(path string, info os.FileInfo, err error) error { //nolint:errcheck
+		if err == nil && info.Mode().IsRegular() {
+			files++
+			size += info.Size()
+		}
+		return nil
+	})
+	r.progress(Progress{Step: name, Files: files, Bytes: size})
+}
+
 // CreateArchive packages up the files
 func CreateArchive(local bool, distPath, pyChecksPath string, logFilePaths []string, pdata ProfileData) (string, error) {
+	return CreateArchiveWithProgress(local, distPath, pyChecksPath, logFilePaths, pdata, nil)
+}
+
+// CreateArchiveWithProgress packages up the files like CreateArchive, calling progress, if not nil, after each
+// step of the collection
+func CreateArchiveWithProgress(local bool, distPath, pyChecksPath string, logFilePaths []string, pdata ProfileData, progress ProgressFunc) (string, error) {
 	zipFilePath := getArchivePath()
 	confSearchPaths := SearchPaths{
 		"":        config.Datadog.GetString("confd_path"),
 		"dist":    filepath.Join(distPath, "conf.d"),
 		"checksd": pyChecksPath,
 	}
-	return createArchive(confSearchPaths, local, zipFilePath, logFilePaths, pdata)
+	return createArchive(confSearchPaths, local, zipFilePath, logFilePaths, pdata, progress)
 }
 
-func createArchive(confSearchPaths SearchPaths, local bool, zipFilePath string, logFilePaths []string, pdata ProfileData) (string, error) {
+func createArchive(confSearchPaths SearchPaths, local bool, zipFilePath string, logFilePaths []string, pdata ProfileData, progress ProgressFunc) (string, error) {
 	tempDir, err := createTempDir()
 	if err != nil {
 		return "", err
 	}
 	defer os.RemoveAll(tempDir)
 
+	reporter := progressReporter{dir: tempDir, progress: progress}
+
 	// Get hostname, if there's an error in getting the hostname,
 	// set the hostname to unknown
 	hostname, err := util.GetHostname()
@@ -183,6 +227,7 @@ func createArchive(confSearchPaths SearchPaths, local bool, zipFilePath string,
 			log.Errorf("Could not zip tagger list: %s", err)
 		}
 	}
+	reporter.step("status")
 
 	// auth token permissions info (only if existing)
 	if _, err = os.Stat(security.GetAuthTokenFilepath()); err == nil && !os.IsNotExist(err) {
@@ -193,6 +238,7 @@ func createArchive(confSearchPaths SearchPaths, local bool, zipFilePath string,
 	if err != nil {
 		log.Errorf("Could not zip config: %s", err)
 	}
+	reporter.step("config")
 
 	err = zipExpVar(tempDir, hostname)
 	if err != nil {
@@ -274,6 +320,7 @@ func createArchive(confSearchPaths SearchPaths, local bool, zipFilePath string,
 	if err != nil {
 		log.Errorf("Could not export Windows event logs: %s", err)
 	}
+	reporter.step("diagnostics")
 
 	// force a log flush before zipping them
 	log.Flush()
@@ -283,6 +330,7 @@ func createArchive(confSearchPaths SearchPaths, local bool, zipFilePath string,
 			log.Errorf("Could not zip logs: %s", err)
 		}
 	}
+	reporter.step("logs")
 
 	err = zipInstallInfo(tempDir, hostname)
 	if err != nil {
@@ -294,6 +342,7 @@ func createArchive(confSearchPaths SearchPaths, local bool, zipFilePath string,
 		if err != nil {
 			log.Errorf("Could not zip performance profile: %s", err)
 		}
+		reporter.step("profiles")
 	}
 
 	// gets files infos and write the permissions.log file
@@ -306,6 +355,12 @@ func createArchive(confSearchPaths SearchPaths, local bool, zipFilePath string,
 		return "", err
 	}
 
+	if progress != nil {
+		if info, err := os.Stat(zipFilePath); err == nil {
+			progress(Progress{Step: "archive", Files: 1, Bytes: info.Size()})
+		}
+	}
+
 	return zipFilePath, nil
 }
 
@@ -32,7 +32,7 @@ func TestCreateArchive(t *testing.T) {
 	mockConfig.Set("confd_path", "./test/confd")
 	mockConfig.Set("log_file", "./test/logs/agent.log")
 	zipFilePath := getArchivePath()
-	filePath, err := createArchive(SearchPaths{}, true, zipFilePath, []string{""}, nil)
+	filePath, err := createArchive(SearchPaths{}, true, zipFilePath, []string{""}, nil, nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, zipFilePath, filePath)
@@ -44,6 +44,38 @@ func TestCreateArchive(t *testing.T) {
 	}
 }
 
+func TestCreateArchiveProgress(t *testing.T) {
+	common.SetupConfig("./test")
+	mockConfig := config.Mock()
+	mockConfig.Set("confd_path", "./test/confd")
+	mockConfig.Set("log_file", "./test/logs/agent.log")
+
+	var updates []Progress
+	zipFilePath := getArchivePath()
+	filePath, err := createArchive(SearchPaths{"": "./test/confd"}, true, zipFilePath, []string{""}, nil, func(p Progress) {
+		updates = append(updates, p)
+	})
+	assert.Nil(t, err)
+	defer os.Remove(filePath)
+
+	var steps []string
+	for _, update := range updates {
+		steps = append(steps, update.Step)
+	}
+	assert.Equal(t, []string{"status", "config", "diagnostics", "logs", "archive"}, steps)
+
+	// the collected files only grow until the archive is made
+	for i := 1; i < len(updates)-1; i++ {
+		assert.True(t, updates[i].Files >= updates[i-1].Files)
+		assert.True(t, updates[i].Bytes >= updates[i-1].Bytes)
+	}
+	assert.True(t, updates[1].Files > 0)
+
+	info, err := os.Stat(filePath)
+	assert.Nil(t, err)
+	assert.Equal(t, Progress{Step: "archive", Files: 1, Bytes: info.Size()}, updates[len(updates)-1])
+}
+
 func TestCreateArchiveAndGoRoutines(t *testing.T) {
 
 	contents := "No Goroutines for you, my friend!"
@@ -56,7 +88,7 @@ func TestCreateArchiveAndGoRoutines(t *testing.T) {
 	pprofURL = ts.URL
 
 	zipFilePath := getArchivePath()
-	filePath, err := createArchive(SearchPaths{}, true, zipFilePath, []string{""}, nil)
+	filePath, err := createArchive(SearchPaths{}, true, zipFilePath, []string{""}, nil, nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, zipFilePath, filePath)
@@ -100,7 +132,7 @@ func TestCreateArchiveAndGoRoutines(t *testing.T) {
 func TestCreateArchiveBadConfig(t *testing.T) {
 	common.SetupConfig("")
 	zipFilePath := getArchivePath()
-	filePath, err := createArchive(SearchPaths{}, true, zipFilePath, []string{""}, nil)
+	filePath, err := createArchive(SearchPaths{}, true, zipFilePath, []string{""}, nil, nil)
 
 	assert.Nil(t, err)
 	assert.Equal(t, zipFilePath, filePath)
@@ -154,7 +186,7 @@ func TestIncludeSystemProbeConfig(t *testing.T) {
 	defer os.Remove("./test/system-probe.yaml")
 
 	zipFilePath := getArchivePath()
-	filePath, err := createArchive(SearchPaths{"": "./test/confd"}, true, zipFilePath, []string{""}, nil)
+	filePath, err := createArchive(SearchPaths{"": "./test/confd"}, true, zipFilePath, []string{""}, nil, nil)
 	assert.NoError(err)
 	assert.Equal(zipFilePath, filePath)
 
@@ -182,7 +214,7 @@ func TestIncludeConfigFiles(t *testing.T) {
 
 	common.SetupConfig("./test")
 	zipFilePath := getArchivePath()
-	filePath, err := createArchive(SearchPaths{"": "./test/confd"}, true, zipFilePath, []string{""}, nil)
+	filePath, err := createArchive(SearchPaths{"": "./test/confd"}, true, zipFilePath, []string{""}, nil, nil)
 
 	assert.NoError(err)
 	assert.Equal(zipFilePath, filePath)
@@ -289,7 +321,7 @@ func TestPerformanceProfile(t *testing.T) {
 		"third":  []byte{},
 	}
 	zipFilePath := getArchivePath()
-	filePath, err := createArchive(SearchPaths{}, true, zipFilePath, []string{""}, testProfile)
+	filePath, err := createArchive(SearchPaths{}, true, zipFilePath, []string{""}, testProfile, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, zipFilePath, filePath)
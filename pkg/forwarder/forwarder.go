@@ -34,6 +34,23 @@ const (
 	PayloadTypeNode = "node"
 )
 
+const (
+	// V1SeriesEndpointName is the name of the v1 series endpoint
+	V1SeriesEndpointName = "series_v1"
+	// V1CheckRunsEndpointName is the name of the v1 check runs endpoint
+	V1CheckRunsEndpointName = "check_run_v1"
+	// V1IntakeEndpointName is the name of the v1 intake endpoint
+	V1IntakeEndpointName = "intake"
+	// SeriesEndpointName is the name of the v2 series endpoint
+	SeriesEndpointName = "series_v2"
+	// EventsEndpointName is the name of the v2 events endpoint
+	EventsEndpointName = "events_v2"
+	// ServiceChecksEndpointName is the name of the v2 service checks endpoint
+	ServiceChecksEndpointName = "services_checks_v2"
+	// SketchSeriesEndpointName is the name of the v2 sketches endpoint
+	SketchSeriesEndpointName = "sketches_v2"
+)
+
 var (
 	forwarderExpvars             = expvar.NewMap("forwarder")
 	transactionsIntakePod        = expvar.Int{}
@@ -42,16 +59,16 @@ var (
 	transactionsIntakeService    = expvar.Int{}
 	transactionsIntakeNode       = expvar.Int{}
 
-	v1SeriesEndpoint       = endpoint{"/api/v1/series", "series_v1"}
-	v1CheckRunsEndpoint    = endpoint{"/api/v1/check_run", "check_run_v1"}
-	v1IntakeEndpoint       = endpoint{"/intake/", "intake"}
+	v1SeriesEndpoint       = endpoint{"/api/v1/series", V1SeriesEndpointName}
+	v1CheckRunsEndpoint    = endpoint{"/api/v1/check_run", V1CheckRunsEndpointName}
+	v1IntakeEndpoint       = endpoint{"/intake/", V1IntakeEndpointName}
 	v1SketchSeriesEndpoint = endpoint{"/api/v1/sketches", "sketches_v1"} // nolint unused for now
 	v1ValidateEndpoint     = endpoint{"/api/v1/validate", "validate_v1"}
 
-	seriesEndpoint        = endpoint{"/api/v2/series", "series_v2"}
-	eventsEndpoint        = endpoint{"/api/v2/events", "events_v2"}
-	serviceChecksEndpoint = endpoint{"/api/v2/service_checks", "services_checks_v2"}
-	sketchSeriesEndpoint  = endpoint{"/api/beta/sketches", "sketches_v2"}
+	seriesEndpoint        = endpoint{"/api/v2/series", SeriesEndpointName}
+	eventsEndpoint        = endpoint{"/api/v2/events", EventsEndpointName}
+	serviceChecksEndpoint = endpoint{"/api/v2/service_checks", ServiceChecksEndpointName}
+	sketchSeriesEndpoint  = endpoint{"/api/beta/sketches", SketchSeriesEndpointName}
 	hostMetadataEndpoint  = endpoint{"/api/v2/host_metadata", "host_metadata_v2"}
 	metadataEndpoint      = endpoint{"/api/v2/metadata", "metadata_v2"}
 
@@ -165,6 +182,7 @@ type Options struct {
 	DisableAPIKeyChecking          bool
 	APIKeyValidationInterval       time.Duration
 	KeysPerDomain                  map[string][]string
+	TenantRoutes                   []config.TenantRoute
 	ConnectionResetInterval        time.Duration
 	CompletionHandler              HTTPCompletionHandler
 }
@@ -183,13 +201,18 @@ func NewOptions(keysPerDomain map[string][]string) *Options {
 	const forwarderRetryQueueMaxSizeKey = "forwarder_retry_queue_max_size"
 	const forwarderRetryQueuePayloadsMaxSizeKey = "forwarder_retry_queue_payloads_max_size"
 	retryQueueSize := config.Datadog.GetInt(forwarderRetryQueueMaxSizeKey)
-	retryQueuePayloadsTotalMaxSize := config.Datadog.GetInt(forwarderRetryQueuePayloadsMaxSizeKey)
+	retryQueuePayloadsTotalMaxSize := int(config.Datadog.GetBytesSize(forwarderRetryQueuePayloadsMaxSizeKey))
 
 	if retryQueueSize > 0 {
 		log.Warnf("'%s' is deprecated. It is recommended to use '%s' as it takes the payload sizes into account.", forwarderRetryQueueMaxSizeKey, forwarderRetryQueuePayloadsMaxSizeKey)
 		retryQueuePayloadsTotalMaxSize = 0
 	}
 
+	tenantRoutes, err := config.GetTenantRoutes()
+	if err != nil {
+		log.Errorf("Misconfiguration of the forwarder tenant routes, the data of the tenants will be sent to the main endpoints: %s", err)
+	}
+
 	return &Options{
 		NumberOfWorkers:                config.Datadog.GetInt("forwarder_num_workers"),
 		RetryQueueSize:                 retryQueueSize,
@@ -197,6 +220,7 @@ func NewOptions(keysPerDomain map[string][]string) *Options {
 		DisableAPIKeyChecking:          false,
 		APIKeyValidationInterval:       time.Duration(validationInterval) * time.Minute,
 		KeysPerDomain:                  keysPerDomain,
+		TenantRoutes:                   tenantRoutes,
 		ConnectionResetInterval:        time.Duration(config.Datadog.GetInt("forwarder_connection_reset_interval")) * time.Second,
 	}
 }
@@ -208,6 +232,8 @@ type DefaultForwarder struct {
 
 	domainForwarders map[string]*domainForwarder
 	keysPerDomains   map[string][]string
+	keysMutex        sync.RWMutex // To update the API keys when their secret is rotated
+	tenantRoutes     map[string]*tenantRoute
 	healthChecker    *forwarderHealth
 	internalState    uint32
 	m                sync.Mutex // To control Start/Stop races
@@ -221,6 +247,7 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 		NumberOfWorkers:  options.NumberOfWorkers,
 		domainForwarders: map[string]*domainForwarder{},
 		keysPerDomains:   map[string][]string{},
+		tenantRoutes:     newTenantRoutes(options.TenantRoutes, options),
 		internalState:    Stopped,
 		healthChecker: &forwarderHealth{
 			keysPerDomains:        options.KeysPerDomain,
@@ -256,13 +283,20 @@ func (f *DefaultForwarder) Start() error {
 	for _, df := range f.domainForwarders {
 		_ = df.Start()
 	}
+	for _, route := range f.tenantRoutes {
+		_ = route.forwarder.Start()
+	}
 
 	// log endpoints configuration
-	endpointLogs := make([]string, 0, len(f.keysPerDomains))
-	for domain, apiKeys := range f.keysPerDomains {
+	keysPerDomains := f.apiKeys()
+	endpointLogs := make([]string, 0, len(keysPerDomains)+len(f.tenantRoutes))
+	for domain, apiKeys := range keysPerDomains {
 		endpointLogs = append(endpointLogs, fmt.Sprintf("\"%s\" (%v api key(s))",
 			domain, len(apiKeys)))
 	}
+	for name, route := range f.tenantRoutes {
+		endpointLogs = append(endpointLogs, fmt.Sprintf("\"%s\" (tenant route %s)", route.domain, name))
+	}
 	log.Infof("Forwarder started, sending to %v endpoint(s) with %v worker(s) each: %s",
 		len(endpointLogs), f.NumberOfWorkers, strings.Join(endpointLogs, " ; "))
 
@@ -285,7 +319,7 @@ func (f *DefaultForwarder) Stop() {
 
 	f.internalState = Stopped
 
-	purgeTimeout := config.Datadog.GetDuration("forwarder_stop_timeout") * time.Second
+	purgeTimeout := config.Datadog.GetDurationWithUnit("forwarder_stop_timeout", time.Second)
 	if purgeTimeout > 0 {
 		var wg sync.WaitGroup
 
@@ -296,6 +330,13 @@ func (f *DefaultForwarder) Stop() {
 				wg.Done()
 			}(df)
 		}
+		for _, route := range f.tenantRoutes {
+			wg.Add(1)
+			go func(df *domainForwarder) {
+				df.Stop(true)
+				wg.Done()
+			}(route.forwarder)
+		}
 
 		donePurging := make(chan struct{})
 		go func() {
@@ -312,6 +353,9 @@ func (f *DefaultForwarder) Stop() {
 		for _, df := range f.domainForwarders {
 			df.Stop(false)
 		}
+		for _, route := range f.tenantRoutes {
+			route.forwarder.Stop(false)
+		}
 	}
 
 	f.healthChecker.Stop()
@@ -321,6 +365,44 @@ func (f *DefaultForwarder) Stop() {
 
 }
 
+// UpdateAPIKeys replaces the API keys of the domains the forwarder sends to, when their secret was rotated.
+// The domains themselves can't change without restarting the agent.
+func (f *DefaultForwarder) UpdateAPIKeys(keysPerDomain map[string][]string) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.keysMutex.Lock()
+	updated := make(map[string][]string, len(f.keysPerDomains))
+	for domain, keys := range keysPerDomain {
+		domain, _ := config.AddAgentVersionToDomain(domain, "app")
+		if _, found := f.keysPerDomains[domain]; !found {
+			log.Warnf("Ignoring the new domain '%s', the agent must be restarted to send to it", domain)
+		} else if len(keys) == 0 {
+			log.Errorf("No API keys for domain '%s' after the secrets were refreshed, keeping the previous ones", domain)
+		} else {
+			updated[domain] = keys
+		}
+	}
+	for domain, keys := range f.keysPerDomains {
+		if _, found := updated[domain]; !found {
+			updated[domain] = keys
+		}
+	}
+	f.keysPerDomains = updated
+	f.keysMutex.Unlock()
+
+	if f.healthChecker != nil {
+		f.healthChecker.updateAPIKeys(keysPerDomain)
+	}
+	log.Infof("Forwarder API keys updated")
+}
+
+func (f *DefaultForwarder) apiKeys() map[string][]string {
+	f.keysMutex.RLock()
+	defer f.keysMutex.RUnlock()
+	return f.keysPerDomains
+}
+
 // State returns the internal state of the forwarder (Started or Stopped)
 func (f *DefaultForwarder) State() uint32 {
 	// Lock so we can't start/stop a Forwarder while getting its state
@@ -334,14 +416,27 @@ func (f *DefaultForwarder) createHTTPTransactions(endpoint endpoint, payloads Pa
 }
 
 func (f *DefaultForwarder) createPriorityHTTPTransactions(endpoint endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header, priority TransactionPriority) []*HTTPTransaction {
-	transactions := make([]*HTTPTransaction, 0, len(payloads)*len(f.keysPerDomains))
+	keysPerDomains := f.apiKeys()
+	routeName := extra.Get(TenantRouteHTTPHeaderKey)
+	if routeName != "" {
+		// the payloads of a tenant are only sent to its org
+		route, found := f.tenantRoutes[routeName]
+		if !found {
+			log.Errorf("Unknown tenant route %q, dropping its payloads", routeName)
+			return nil
+		}
+		keysPerDomains = map[string][]string{route.domain: {route.apiKey}}
+	}
+
+	transactions := make([]*HTTPTransaction, 0, len(payloads)*len(keysPerDomains))
 	allowArbitraryTags := config.Datadog.GetBool("allow_arbitrary_tags")
 
 	for _, payload := range payloads {
-		for domain, apiKeys := range f.keysPerDomains {
+		for domain, apiKeys := range keysPerDomains {
 			for _, apiKey := range apiKeys {
 				t := NewHTTPTransaction()
 				t.Domain = domain
+				t.tenantRoute = routeName
 				t.Endpoint = endpoint
 				if apiKeyInQueryString {
 					t.Endpoint.route = fmt.Sprintf("%s?api_key=%s", endpoint.route, apiKey)
@@ -367,6 +462,7 @@ func (f *DefaultForwarder) createPriorityHTTPTransactions(endpoint endpoint, pay
 				for key := range extra {
 					t.Headers.Set(key, extra.Get(key))
 				}
+				t.Headers.Del(TenantRouteHTTPHeaderKey)
 				transactions = append(transactions, t)
 			}
 		}
@@ -380,7 +476,11 @@ func (f *DefaultForwarder) sendHTTPTransactions(transactions []*HTTPTransaction)
 	}
 
 	for _, t := range transactions {
-		if err := f.domainForwarders[t.Domain].sendHTTPTransactions(t); err != nil {
+		df := f.domainForwarders[t.Domain]
+		if t.tenantRoute != "" {
+			df = f.tenantRoutes[t.tenantRoute].forwarder
+		}
+		if err := df.sendHTTPTransactions(t); err != nil {
 			log.Errorf(err.Error())
 		}
 	}
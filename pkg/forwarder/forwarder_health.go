@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/status/health"
@@ -20,6 +21,8 @@ import (
 
 const (
 	fakeAPIKey = "00000000000000000000000000000000"
+
+	healthComponentName = "forwarder"
 )
 
 var (
@@ -52,6 +55,7 @@ type forwarderHealth struct {
 	stop                  chan bool
 	stopped               chan struct{}
 	timeout               time.Duration
+	keysMutex             sync.Mutex
 	keysPerDomains        map[string][]string
 	keysPerAPIEndpoint    map[string][]string
 	disableAPIKeyChecking bool
@@ -62,6 +66,9 @@ func (fh *forwarderHealth) init() {
 	fh.stop = make(chan bool, 1)
 	fh.stopped = make(chan struct{})
 
+	fh.keysMutex.Lock()
+	defer fh.keysMutex.Unlock()
+
 	fh.keysPerAPIEndpoint = make(map[string][]string)
 	fh.computeDomainsURL()
 
@@ -83,7 +90,7 @@ func (fh *forwarderHealth) Start() {
 		return
 	}
 
-	fh.health = health.RegisterReadiness("forwarder")
+	fh.health = health.RegisterReadiness(healthComponentName)
 	fh.init()
 	go fh.healthCheckLoop()
 }
@@ -96,6 +103,7 @@ func (fh *forwarderHealth) Stop() {
 	fh.health.Deregister() //nolint:errcheck
 	fh.stop <- true
 	<-fh.stopped
+	health.RemoveState(healthComponentName)
 }
 
 func (fh *forwarderHealth) healthCheckLoop() {
@@ -127,6 +135,16 @@ func (fh *forwarderHealth) healthCheckLoop() {
 	}
 }
 
+// updateAPIKeys replaces the API keys validated by the next checks
+func (fh *forwarderHealth) updateAPIKeys(keysPerDomains map[string][]string) {
+	fh.keysMutex.Lock()
+	defer fh.keysMutex.Unlock()
+
+	fh.keysPerDomains = keysPerDomains
+	fh.keysPerAPIEndpoint = make(map[string][]string)
+	fh.computeDomainsURL()
+}
+
 // computeDomainsURL populates a map containing API Endpoints per API keys that belongs to the forwarderHealth struct
 func (fh *forwarderHealth) computeDomainsURL() {
 	for domain, apiKeys := range fh.keysPerDomains {
@@ -196,7 +214,11 @@ func (fh *forwarderHealth) hasValidAPIKey() bool {
 	validKey := false
 	apiError := false
 
-	for domain, apiKeys := range fh.keysPerAPIEndpoint {
+	fh.keysMutex.Lock()
+	keysPerAPIEndpoint := fh.keysPerAPIEndpoint
+	fh.keysMutex.Unlock()
+
+	for domain, apiKeys := range keysPerAPIEndpoint {
 		for _, apiKey := range apiKeys {
 			v, err := fh.validateAPIKey(apiKey, domain)
 			if err != nil {
@@ -211,10 +233,16 @@ func (fh *forwarderHealth) hasValidAPIKey() bool {
 		}
 	}
 
-	// If there is an error during the api call, we assume that there is a
-	// valid key to avoid killing lots of agent on an outage.
-	if apiError {
+	switch {
+	case validKey:
+		health.SetState(healthComponentName, health.StateReady, "")
+	case apiError:
+		// If there is an error during the api call, we assume that there is a
+		// valid key to avoid killing lots of agent on an outage.
+		health.SetState(healthComponentName, health.StateDegraded, "unable to validate the API keys")
 		return true
+	default:
+		health.SetState(healthComponentName, health.StateNotReady, "no valid API key")
 	}
 	return validKey
 }
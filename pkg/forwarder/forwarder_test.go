@@ -54,6 +54,27 @@ func TestNewDefaultForwarder(t *testing.T) {
 	assert.Equal(t, forwarder.State(), forwarder.internalState)
 }
 
+func TestUpdateAPIKeys(t *testing.T) {
+	forwarder := NewDefaultForwarder(NewOptions(keysPerDomains))
+
+	forwarder.UpdateAPIKeys(map[string][]string{
+		testDomain:    {"api-key-3"},
+		"datadog.bar": {"api-key-4"},
+	})
+
+	// the domains dropped at startup aren't added back
+	assert.Equal(t, map[string][]string{testVersionDomain: {"api-key-3"}}, forwarder.keysPerDomains)
+	require.Len(t, forwarder.domainForwarders, 1)
+
+	// the domains left without keys keep their previous ones
+	forwarder.UpdateAPIKeys(map[string][]string{testDomain: nil})
+	assert.Equal(t, map[string][]string{testVersionDomain: {"api-key-3"}}, forwarder.keysPerDomains)
+
+	transactions := forwarder.createHTTPTransactions(endpoint{route: "/api/foo", name: "foo"}, Payloads{&[]byte{}}, false, nil)
+	require.Len(t, transactions, 1)
+	assert.Equal(t, "api-key-3", transactions[0].Headers.Get(apiHTTPHeaderKey))
+}
+
 func TestStart(t *testing.T) {
 	forwarder := NewDefaultForwarder(NewOptions(monoKeysDomains))
 	err := forwarder.Start()
@@ -596,3 +617,61 @@ func TestCustomCompletionHandler(t *testing.T) {
 
 	assert.True(t, handlerCalled)
 }
+
+func TestCreateHTTPTransactionsWithTenantRoute(t *testing.T) {
+	options := NewOptions(keysPerDomains)
+	options.TenantRoutes = []config.TenantRoute{
+		{Name: "team-a", APIKey: "api-key-a", DDURL: "https://app.datadoghq.eu", TagSelectors: []string{"kube_namespace:team-a"}},
+	}
+	forwarder := NewDefaultForwarder(options)
+	routeDomain, _ := config.AddAgentVersionToDomain("https://app.datadoghq.eu", "app")
+	endpoint := endpoint{"/api/foo", "foo"}
+	p1 := []byte("A payload")
+	headers := make(http.Header)
+	headers.Set("HTTP-MAGIC", "foo")
+	headers.Set(TenantRouteHTTPHeaderKey, "team-a")
+
+	transactions := forwarder.createHTTPTransactions(endpoint, Payloads{&p1}, false, headers)
+	require.Len(t, transactions, 1)
+	assert.Equal(t, routeDomain, transactions[0].Domain)
+	assert.Equal(t, "team-a", transactions[0].tenantRoute)
+	assert.Equal(t, "api-key-a", transactions[0].Headers.Get("DD-Api-Key"))
+	assert.Equal(t, "foo", transactions[0].Headers.Get("HTTP-MAGIC"))
+	assert.Empty(t, transactions[0].Headers.Get(TenantRouteHTTPHeaderKey))
+
+	// the payloads of unknown routes are never sent to the main endpoints
+	headers.Set(TenantRouteHTTPHeaderKey, "team-b")
+	transactions = forwarder.createHTTPTransactions(endpoint, Payloads{&p1}, false, headers)
+	assert.Empty(t, transactions)
+}
+
+func TestSubmitSeriesWithTenantRoute(t *testing.T) {
+	options := NewOptions(monoKeysDomains)
+	options.TenantRoutes = []config.TenantRoute{
+		{Name: "team-a", APIKey: "api-key-a", DDURL: testDomain, TagSelectors: []string{"kube_namespace:team-a"}},
+	}
+	forwarder := NewDefaultForwarder(options)
+	forwarder.Start()
+	defer forwarder.Stop()
+
+	// The tenant route has its own queue, even when it sends to the same domain as the main endpoint
+	inputQueue := make(chan Transaction, 1)
+	df := forwarder.tenantRoutes["team-a"].forwarder
+	bk := df.highPrio
+	df.highPrio = inputQueue
+	defer func() { df.highPrio = bk }()
+
+	p := []byte("test")
+	headers := make(http.Header)
+	headers.Set(TenantRouteHTTPHeaderKey, "team-a")
+	assert.Nil(t, forwarder.SubmitSeries(Payloads{&p}, headers))
+
+	select {
+	case tr := <-df.highPrio:
+		require.NotNil(t, tr)
+		httpTr := tr.(*HTTPTransaction)
+		assert.Equal(t, "api-key-a", httpTr.Headers.Get("DD-Api-Key"))
+	case <-time.After(1 * time.Second):
+		require.Fail(t, "highPrio queue of the tenant route should contain a transaction")
+	}
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"expvar"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// payloadSizeLimitRatio is applied to the size of a payload rejected with a 413
+	// to compute the new limit of its endpoint, so that we converge quickly under
+	// the actual limit of the intake (or of a proxy in front of it)
+	payloadSizeLimitRatio = 0.75
+	// minPayloadSizeLimit is the lowest limit we can learn, to avoid splitting
+	// payloads into tiny chunks because of a single misbehaving response
+	minPayloadSizeLimit = 32 * 1024
+)
+
+var (
+	payloadSizeLimitsExpvars = expvar.Map{}
+
+	tlmPayloadSizeLimit = telemetry.NewGauge("transactions", "payload_size_limit",
+		[]string{"endpoint"}, "Payload size limit learned from 413 responses per endpoint")
+)
+
+func init() {
+	payloadSizeLimitsExpvars.Init()
+	transactionsExpvars.Set("PayloadSizeLimits", &payloadSizeLimitsExpvars)
+}
+
+// payloadSizeLimits keeps track of the payload size limits learned from the
+// 413 responses of the intake, per endpoint name
+type payloadSizeLimits struct {
+	sync.RWMutex
+	limits map[string]int
+}
+
+var learnedPayloadSizeLimits = &payloadSizeLimits{limits: make(map[string]int)}
+
+// recordTooLarge lowers the size limit of an endpoint after a payload of the
+// given size was rejected with a 413. Limits only ever decrease.
+func (p *payloadSizeLimits) recordTooLarge(endpointName string, payloadSize int) {
+	limit := int(float64(payloadSize) * payloadSizeLimitRatio)
+	if limit < minPayloadSizeLimit {
+		limit = minPayloadSizeLimit
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if current, found := p.limits[endpointName]; found && current <= limit {
+		return
+	}
+	p.limits[endpointName] = limit
+
+	log.Warnf("Payload of %d bytes rejected as too large by the %q endpoint, now splitting its payloads above %d bytes", payloadSize, endpointName, limit)
+	limitVar := &expvar.Int{}
+	limitVar.Set(int64(limit))
+	payloadSizeLimitsExpvars.Set(endpointName, limitVar)
+	tlmPayloadSizeLimit.Set(float64(limit), endpointName)
+}
+
+func (p *payloadSizeLimits) get(endpointName string, defaultLimit int) int {
+	p.RLock()
+	defer p.RUnlock()
+
+	if limit, found := p.limits[endpointName]; found && limit < defaultLimit {
+		return limit
+	}
+	return defaultLimit
+}
+
+func (p *payloadSizeLimits) reset() {
+	p.Lock()
+	defer p.Unlock()
+
+	p.limits = make(map[string]int)
+	payloadSizeLimitsExpvars.Init()
+}
+
+// GetPayloadSizeLimit returns the maximum payload size to use for an endpoint: the
+// given default, or a lower limit learned from the 413 responses of that endpoint.
+func GetPayloadSizeLimit(endpointName string, defaultLimit int) int {
+	return learnedPayloadSizeLimits.get(endpointName, defaultLimit)
+}
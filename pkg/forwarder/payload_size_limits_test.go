@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadSizeLimits(t *testing.T) {
+	limits := &payloadSizeLimits{limits: make(map[string]int)}
+	defaultLimit := 2 * 1024 * 1024
+
+	assert.Equal(t, defaultLimit, limits.get("series_v2", defaultLimit))
+
+	limits.recordTooLarge("series_v2", 1024*1024)
+	assert.Equal(t, 768*1024, limits.get("series_v2", defaultLimit))
+	// other endpoints are not affected
+	assert.Equal(t, defaultLimit, limits.get("events_v2", defaultLimit))
+	// a lower default still wins
+	assert.Equal(t, 512*1024, limits.get("series_v2", 512*1024))
+
+	// limits never increase
+	limits.recordTooLarge("series_v2", 2*1024*1024)
+	assert.Equal(t, 768*1024, limits.get("series_v2", defaultLimit))
+
+	// limits never go below the minimum
+	limits.recordTooLarge("series_v2", 1024)
+	assert.Equal(t, minPayloadSizeLimit, limits.get("series_v2", defaultLimit))
+}
+
+func TestProcessRecordsPayloadSizeLimit(t *testing.T) {
+	defer learnedPayloadSizeLimits.reset()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}))
+	defer ts.Close()
+
+	payload := make([]byte, 100*1024)
+	transaction := NewHTTPTransaction()
+	transaction.Domain = ts.URL
+	transaction.Endpoint = endpoint{"/api/v2/series", SeriesEndpointName}
+	transaction.Payload = &payload
+
+	err := transaction.Process(context.Background(), &http.Client{})
+	assert.Nil(t, err)
+	assert.Equal(t, 75*1024, GetPayloadSizeLimit(SeriesEndpointName, 2*1024*1024))
+}
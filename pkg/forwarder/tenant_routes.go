@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package forwarder
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// TenantRouteHTTPHeaderKey is set by the serializer in the extra headers of the payloads holding
+// the data of a tenant route, it is only used by the forwarder and isn't sent to the intake
+const TenantRouteHTTPHeaderKey = "DD-Agent-Tenant-Route"
+
+// tenantRoute sends the payloads of a tenant to the org of its API key, through its own queue so
+// that a tenant whose intake is unavailable doesn't hold back the others
+type tenantRoute struct {
+	name      string
+	domain    string
+	apiKey    string
+	forwarder *domainForwarder
+}
+
+func newTenantRoutes(routes []config.TenantRoute, options *Options) map[string]*tenantRoute {
+	tenantRoutes := make(map[string]*tenantRoute, len(routes))
+	for _, route := range routes {
+		domain, _ := config.AddAgentVersionToDomain(route.DDURL, "app")
+		tenantRoutes[route.Name] = &tenantRoute{
+			name:      route.Name,
+			domain:    domain,
+			apiKey:    route.APIKey,
+			forwarder: newDomainForwarder(domain, options.NumberOfWorkers, options.RetryQueueSize, options.RetryQueuePayloadsTotalMaxSize, options.ConnectionResetInterval),
+		}
+	}
+	return tenantRoutes
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/clocksync"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -202,6 +203,8 @@ type HTTPTransaction struct {
 	completionHandler HTTPCompletionHandler
 
 	priority TransactionPriority
+	// tenantRoute is the name of the tenant route the transaction is sent through, if any
+	tenantRoute string
 }
 
 // Transaction represents the task to process for a Worker.
@@ -292,7 +295,9 @@ func (t *HTTPTransaction) internalProcess(ctx context.Context, client *http.Clie
 		return 0, nil, nil
 	}
 	req = req.WithContext(ctx)
+	clocksync.AnnotateHeaders(t.Headers)
 	req.Header = t.Headers
+	sent := time.Now()
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -307,6 +312,10 @@ func (t *HTTPTransaction) internalProcess(ctx context.Context, client *http.Clie
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	if err := clocksync.RecordHTTPDate(resp.Header.Get("Date"), sent, time.Now()); err != nil {
+		log.Tracef("Could not measure the clock offset from the response of %q: %s", logURL, err)
+	}
+
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		log.Errorf("Fail to read the response Body: %s", err)
@@ -327,6 +336,11 @@ func (t *HTTPTransaction) internalProcess(ctx context.Context, client *http.Clie
 		tlmTxHTTPErrors.Inc(t.Domain, transactionEndpointName, statusCode)
 	}
 
+	if resp.StatusCode == 413 {
+		// the payload can't be split anymore, but the next ones will be split under the learned limit
+		learnedPayloadSizeLimits.recordTooLarge(transactionEndpointName, t.GetPayloadSize())
+	}
+
 	if resp.StatusCode == 400 || resp.StatusCode == 404 || resp.StatusCode == 413 {
 		log.Errorf("Error code %q received while sending transaction to %q: %s, dropping it", resp.Status, logURL, string(body))
 		transactionsDroppedByEndpoint.Add(transactionEndpointName, 1)
@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/util/clocksync"
 )
 
 func TestNewHTTPTransaction(t *testing.T) {
@@ -50,6 +52,37 @@ func TestProcess(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestProcessClockOffset(t *testing.T) {
+	var offsetHeader string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offsetHeader = r.Header.Get(clocksync.OffsetHeader)
+		// the clock of the intake is 2 hours ahead
+		w.Header().Set("Date", time.Now().Add(2*time.Hour).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	transaction := NewHTTPTransaction()
+	transaction.Domain = ts.URL
+	transaction.Endpoint.route = "/endpoint/test"
+	payload := []byte("test payload")
+	transaction.Payload = &payload
+
+	client := &http.Client{}
+
+	err := transaction.Process(context.Background(), client)
+	assert.Nil(t, err)
+
+	offset, ok := clocksync.Offset()
+	assert.True(t, ok)
+	assert.InDelta(t, (2 * time.Hour).Seconds(), offset.Seconds(), 2)
+
+	// the next payloads are annotated with the offset
+	err = transaction.Process(context.Background(), client)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, offsetHeader)
+}
+
 func TestProcessInvalidDomain(t *testing.T) {
 	transaction := NewHTTPTransaction()
 	transaction.Domain = "://invalid"
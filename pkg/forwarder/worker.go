@@ -56,7 +56,7 @@ func newHTTPClient() *http.Client {
 	transport := httputils.CreateHTTPTransport()
 
 	return &http.Client{
-		Timeout:   config.Datadog.GetDuration("forwarder_timeout") * time.Second,
+		Timeout:   config.Datadog.GetDurationWithUnit("forwarder_timeout", time.Second),
 		Transport: transport,
 	}
 }
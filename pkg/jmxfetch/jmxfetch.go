@@ -14,6 +14,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -24,6 +26,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/status"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/subprocess"
 	"gopkg.in/yaml.v2"
 )
 
@@ -73,7 +76,9 @@ type JMXFetch struct {
 	IPCHost            string
 	Output             func(...interface{})
 	cmd                *exec.Cmd
+	cmdLock            sync.Mutex
 	managed            bool
+	restarting         int32
 	shutdown           chan struct{}
 	stopped            chan struct{}
 }
@@ -125,6 +130,18 @@ func (j *JMXFetch) Monitor() {
 			break
 		}
 
+		// the restarts requested by Restart don't count towards jmx_max_restarts
+		if atomic.CompareAndSwapInt32(&j.restarting, 1, 0) {
+			select {
+			case <-j.shutdown:
+				return
+			default:
+				log.Infof("Restarting JMXFetch with the new auth token.")
+				j.Start(false) //nolint:errcheck
+				continue
+			}
+		}
+
 		stopTimes[idx] = time.Now()
 		oldestIdx := (idx + maxRestarts + 1) % maxRestarts
 
@@ -179,6 +196,9 @@ func (j *JMXFetch) setDefaults() {
 
 // Start starts the JMXFetch process
 func (j *JMXFetch) Start(manage bool) error {
+	j.cmdLock.Lock()
+	defer j.cmdLock.Unlock()
+
 	j.setDefaults()
 
 	classpath := filepath.Join(common.GetDistPath(), "jmx", jmxJarName)
@@ -330,7 +350,7 @@ func (j *JMXFetch) Start(manage bool) error {
 
 	log.Debugf("Args: %v", subprocessArgs)
 
-	err = j.cmd.Start()
+	err = subprocess.Start(j.cmd)
 
 	// start synchronization channels
 	if err == nil && manage {
@@ -344,6 +364,30 @@ func (j *JMXFetch) Start(manage bool) error {
 	return err
 }
 
+// Restart kills the JMXFetch process, which is started again by its monitor with the current auth token. JMXFetch
+// is given the auth token when it's launched, so it's restarted when the token is rotated.
+func (j *JMXFetch) Restart() error {
+	if !j.managed {
+		return fmt.Errorf("JMXFetch isn't managed by the agent, it can't be restarted")
+	}
+
+	select {
+	case <-j.shutdown:
+		return nil
+	default:
+	}
+
+	j.cmdLock.Lock()
+	defer j.cmdLock.Unlock()
+
+	atomic.StoreInt32(&j.restarting, 1)
+	if err := j.cmd.Process.Kill(); err != nil {
+		atomic.StoreInt32(&j.restarting, 0)
+		return err
+	}
+	return nil
+}
+
 // Wait waits for the end of the JMXFetch process and returns the error code
 func (j *JMXFetch) Wait() error {
 	return j.cmd.Wait()
@@ -16,7 +16,8 @@ func TestAuditorUnmarshalRegistryV2(t *testing.T) {
 	    "Registry": {
 	        "path1.log": {
 	            "Offset": "1",
-	            "LastUpdated": "2006-01-12T01:01:01.000000001Z"
+	            "LastUpdated": "2006-01-12T01:01:01.000000001Z",
+	            "Fingerprint": "2a:c0ffee:1024"
 	        },
 	        "path2.log": {
 	            "Offset": "2006-01-12T01:01:03.000000001Z",
@@ -30,7 +31,9 @@ func TestAuditorUnmarshalRegistryV2(t *testing.T) {
 
 	assert.Equal(t, "1", r["path1.log"].Offset)
 	assert.Equal(t, 1, r["path1.log"].LastUpdated.Second())
+	assert.Equal(t, "2a:c0ffee:1024", r["path1.log"].Fingerprint)
 
 	assert.Equal(t, "2006-01-12T01:01:03.000000001Z", r["path2.log"].Offset)
 	assert.Equal(t, 2, r["path2.log"].LastUpdated.Second())
+	assert.Equal(t, "", r["path2.log"].Fingerprint)
 }
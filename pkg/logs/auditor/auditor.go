@@ -35,6 +35,7 @@ const registryAPIVersion = 2
 type Registry interface {
 	GetOffset(identifier string) string
 	GetTailingMode(identifier string) string
+	GetFingerprint(identifier string) string
 }
 
 // A RegistryEntry represents an entry in the registry where we keep track
@@ -43,6 +44,7 @@ type RegistryEntry struct {
 	LastUpdated time.Time
 	Offset      string
 	TailingMode string
+	Fingerprint string `json:",omitempty"`
 }
 
 // JSONRegistry represents the registry that will be written on disk
@@ -138,6 +140,17 @@ func (a *Auditor) GetTailingMode(identifier string) string {
 	return entry.TailingMode
 }
 
+// GetFingerprint returns the fingerprint of the content the last committed offset
+// applies to for a given identifier, returns an empty string if it does not exist.
+func (a *Auditor) GetFingerprint(identifier string) string {
+	r := a.readOnlyRegistryCopy()
+	entry, exists := r[identifier]
+	if !exists {
+		return ""
+	}
+	return entry.Fingerprint
+}
+
 // run keeps up to date the registry depending on different events
 func (a *Auditor) run() {
 	cleanUpTicker := time.NewTicker(defaultCleanupPeriod)
@@ -159,7 +172,7 @@ func (a *Auditor) run() {
 				return
 			}
 			// update the registry with new entry
-			a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode)
+			a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode, msg.Origin.Fingerprint)
 		case <-cleanUpTicker.C:
 			// remove expired offsets from registry
 			a.cleanupRegistry()
@@ -211,7 +224,7 @@ func (a *Auditor) cleanupRegistry() {
 }
 
 // updateRegistry updates the registry entry matching identifier with new the offset and timestamp
-func (a *Auditor) updateRegistry(identifier string, offset string, tailingMode string) {
+func (a *Auditor) updateRegistry(identifier string, offset string, tailingMode string, fingerprint string) {
 	a.registryMutex.Lock()
 	defer a.registryMutex.Unlock()
 	if identifier == "" {
@@ -224,6 +237,7 @@ func (a *Auditor) updateRegistry(identifier string, offset string, tailingMode s
 		LastUpdated: time.Now().UTC(),
 		Offset:      offset,
 		TailingMode: tailingMode,
+		Fingerprint: fingerprint,
 	}
 }
 
@@ -9,6 +9,7 @@ package mock
 type Registry struct {
 	offset      string
 	tailingMode string
+	fingerprint string
 }
 
 // NewRegistry returns a new registry.
@@ -35,3 +36,13 @@ func (r *Registry) GetTailingMode(identifier string) string {
 func (r *Registry) SetTailingMode(tailingMode string) {
 	r.tailingMode = tailingMode
 }
+
+// GetFingerprint returns the fingerprint.
+func (r *Registry) GetFingerprint(identifier string) string {
+	return r.fingerprint
+}
+
+// SetFingerprint sets the fingerprint.
+func (r *Registry) SetFingerprint(fingerprint string) {
+	r.fingerprint = fingerprint
+}
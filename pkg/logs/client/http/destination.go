@@ -10,6 +10,8 @@ import (
 	"sync"
 	"time"
 
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/clocksync"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -80,8 +82,10 @@ func (d *Destination) Send(payload []byte) error {
 	}
 	req.Header.Set("Content-Type", d.contentType)
 	req.Header.Set("Content-Encoding", d.contentEncoding.name())
+	clocksync.AnnotateHeaders(req.Header)
 	req = req.WithContext(ctx)
 
+	sent := time.Now()
 	resp, err := d.client.Do(req)
 	if err != nil {
 		if ctx.Err() == context.Canceled {
@@ -92,6 +96,9 @@ func (d *Destination) Send(payload []byte) error {
 	}
 
 	defer resp.Body.Close()
+	if err := clocksync.RecordHTTPDate(resp.Header.Get("Date"), sent, time.Now()); err != nil {
+		log.Tracef("Could not measure the clock offset from the logs intake: %s", err)
+	}
 	_, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
 		// the read failed because the server closed or terminated the connection
@@ -142,7 +149,7 @@ func httpClientFactory(timeout time.Duration) func() *http.Client {
 		return &http.Client{
 			Timeout: timeout,
 			// reusing core agent HTTP transport to benefit from proxy settings.
-			Transport: httputils.CreateHTTPTransport(),
+			Transport: httputils.CreateProductHTTPTransport(coreConfig.ProxyProductLogs),
 		}
 	}
 }
@@ -20,6 +20,9 @@ import (
 // ContainerCollectAll is the name of the docker integration that collect logs from all containers
 const ContainerCollectAll = "container_collect_all"
 
+// JournaldCollectAll is the name of the journald integration that collects logs from the journal of the host
+const JournaldCollectAll = "journald_collect_all"
+
 // SnmpTraps is the name of the integration that collects logs from SNMP traps received by the Agent
 const SnmpTraps = "snmp_traps"
 
@@ -60,6 +63,20 @@ func ContainerCollectAllSource() *LogSource {
 	return nil
 }
 
+// JournaldCollectAllSource returns a source to collect the logs of the journal of the host
+// when it's enabled and journald is available.
+func JournaldCollectAllSource() *LogSource {
+	if coreConfig.Datadog.GetBool("logs_config.journald_collect_all") && coreConfig.IsFeaturePresent(coreConfig.Journald) {
+		// source to collect all logs from the default journal
+		return NewLogSource(JournaldCollectAll, &LogsConfig{
+			Type:    JournaldType,
+			Service: "journald",
+			Source:  "journald",
+		})
+	}
+	return nil
+}
+
 // SNMPTrapsSource returs a source to forward SNMP traps as logs.
 func SNMPTrapsSource() *LogSource {
 	if traps.IsEnabled() && traps.IsRunning() {
@@ -264,5 +281,5 @@ func batchWait(config coreConfig.Config) time.Duration {
 
 // TaggerWarmupDuration is used to configure the tag providers
 func TaggerWarmupDuration() time.Duration {
-	return coreConfig.Datadog.GetDuration("logs_config.tagger_warmup_duration") * time.Second
+	return coreConfig.Datadog.GetDurationWithUnit("logs_config.tagger_warmup_duration", time.Second)
 }
@@ -60,6 +60,29 @@ func (suite *ConfigTestSuite) TestDefaultSources() {
 	suite.Equal(DockerType, source.Config.Type)
 	suite.Equal("docker", source.Config.Source)
 	suite.Equal("docker", source.Config.Service)
+
+	// journald collect all source
+
+	source = JournaldCollectAllSource()
+	suite.Nil(source)
+
+	// the source requires journald to be available
+	suite.config.Set("logs_config.journald_collect_all", true)
+	coreConfig.SetDetectedFeatures(coreConfig.FeatureMap{})
+	defer coreConfig.SetDetectedFeatures(nil)
+
+	source = JournaldCollectAllSource()
+	suite.Nil(source)
+
+	coreConfig.SetDetectedFeatures(coreConfig.FeatureMap{coreConfig.Journald: struct{}{}})
+
+	source = JournaldCollectAllSource()
+	suite.NotNil(source)
+
+	suite.Equal("journald_collect_all", source.Name)
+	suite.Equal(JournaldType, source.Config.Type)
+	suite.Equal("journald", source.Config.Source)
+	suite.Equal("journald", source.Config.Service)
 }
 
 func (suite *ConfigTestSuite) TestGlobalProcessingRulesShouldReturnNoRulesWithEmptyValues() {
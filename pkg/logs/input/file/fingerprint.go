@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package file
+
+import (
+	"fmt"
+	"hash/crc64"
+	"io"
+	"os"
+)
+
+// fingerprintMaxLength is the number of bytes at the beginning of a file covered by its fingerprint
+const fingerprintMaxLength = 1024
+
+var crcTable = crc64.MakeTable(crc64.ECMA)
+
+// Fingerprint identifies a file and the beginning of its content. It makes it possible to detect that a
+// file was truncated and written again (copytruncate rotations), even when its size grew past the last
+// offset read in the meantime, and that the offset recorded for a file still applies to it.
+type Fingerprint struct {
+	// Inode is the inode of the file, 0 when not supported by the OS
+	Inode uint64
+	// Checksum is the CRC-64 of the first Length bytes of the file
+	Checksum uint64
+	Length   int64
+}
+
+// computeFingerprint returns the fingerprint of the first length bytes of a file, or of the whole
+// file when it's smaller
+func computeFingerprint(f *os.File, length int64) (*Fingerprint, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return (&Fingerprint{Inode: fileInode(fi)}).extend(f, length)
+}
+
+// extend returns the fingerprint covering the first length bytes of a file, or the whole file when
+// it's smaller, the bytes already covered by the fingerprint not being read again
+func (fp *Fingerprint) extend(f *os.File, length int64) (*Fingerprint, error) {
+	if length <= fp.Length {
+		return fp, nil
+	}
+
+	buf := make([]byte, length-fp.Length)
+	n, err := f.ReadAt(buf, fp.Length)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &Fingerprint{
+		Inode:    fp.Inode,
+		Checksum: crc64.Update(fp.Checksum, crcTable, buf[:n]),
+		Length:   fp.Length + int64(n),
+	}, nil
+}
+
+// didChange returns true if the file isn't the one the fingerprint was computed for, or if the
+// beginning of its content changed since
+func (fp *Fingerprint) didChange(f *os.File) (bool, error) {
+	current, err := computeFingerprint(f, fp.Length)
+	if err != nil {
+		return false, err
+	}
+	if fp.Inode != 0 && current.Inode != 0 && fp.Inode != current.Inode {
+		return true, nil
+	}
+	return current.Length != fp.Length || current.Checksum != fp.Checksum, nil
+}
+
+// String returns the representation of the fingerprint stored in the registry
+func (fp *Fingerprint) String() string {
+	return fmt.Sprintf("%x:%x:%d", fp.Inode, fp.Checksum, fp.Length)
+}
+
+// ParseFingerprint parses a fingerprint stored in the registry
+func ParseFingerprint(s string) (*Fingerprint, error) {
+	var fp Fingerprint
+	if _, err := fmt.Sscanf(s, "%x:%x:%d", &fp.Inode, &fp.Checksum, &fp.Length); err != nil {
+		return nil, fmt.Errorf("invalid fingerprint %q: %v", s, err)
+	}
+	if fp.Length < 0 || fp.Length > fingerprintMaxLength {
+		return nil, fmt.Errorf("invalid fingerprint %q: length out of bounds", s)
+	}
+	return &fp, nil
+}
+
+// canResume returns true if the offset recorded in the registry along with a fingerprint can be used
+// to resume tailing a file, that is if the file wasn't replaced or truncated since. The offsets recorded
+// without fingerprint, by earlier versions of the agent, are used as long as they don't point past the
+// end of the file.
+func canResume(path string, offset int64, fingerprint string) (bool, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if fi.Size() < offset {
+		return false, nil
+	}
+
+	if fingerprint == "" {
+		return true, nil
+	}
+	fp, err := ParseFingerprint(fingerprint)
+	if err != nil {
+		return false, err
+	}
+	changed, err := fp.didChange(f)
+	if err != nil {
+		return false, err
+	}
+	return !changed, nil
+}
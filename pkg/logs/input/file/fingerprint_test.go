@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !windows
+
+package file
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "log-fingerprint-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	path := filepath.Join(testDir, "test.log")
+	content := bytes.Repeat([]byte("hello world\n"), 200)
+	require.NoError(t, ioutil.WriteFile(path, content, 0644))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	// the fingerprint covers at most the length requested
+	fp, err := computeFingerprint(f, 12)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), fp.Length)
+	assert.NotZero(t, fp.Inode)
+
+	// extending a fingerprint is the same as computing it over the whole length
+	extended, err := fp.extend(f, fingerprintMaxLength)
+	require.NoError(t, err)
+	full, err := computeFingerprint(f, fingerprintMaxLength)
+	require.NoError(t, err)
+	assert.Equal(t, full, extended)
+	assert.Equal(t, int64(fingerprintMaxLength), full.Length)
+
+	changed, err := full.didChange(f)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	parsed, err := ParseFingerprint(full.String())
+	require.NoError(t, err)
+	assert.Equal(t, full, parsed)
+
+	_, err = ParseFingerprint("not a fingerprint")
+	assert.Error(t, err)
+	_, err = ParseFingerprint("2a:c0ffee:4096")
+	assert.Error(t, err)
+
+	// the file is truncated and written again
+	require.NoError(t, ioutil.WriteFile(path, bytes.Repeat([]byte("HELLO WORLD\n"), 200), 0644))
+	changed, err = full.didChange(f)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	// the file is truncated
+	require.NoError(t, ioutil.WriteFile(path, content[:100], 0644))
+	changed, err = full.didChange(f)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	// a small file only covers its content
+	fp, err = computeFingerprint(f, fingerprintMaxLength)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), fp.Length)
+}
+
+func TestCanResume(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "log-fingerprint-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	path := filepath.Join(testDir, "test.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello\nworld\n"), 0644))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	fp, err := computeFingerprint(f, 6)
+	f.Close()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name        string
+		offset      int64
+		fingerprint string
+		expected    bool
+	}{
+		{"same content", 6, fp.String(), true},
+		{"no fingerprint", 6, "", true},
+		{"offset past the end of the file", 42, "", false},
+		{"different content", 6, (&Fingerprint{Inode: fp.Inode, Checksum: fp.Checksum + 1, Length: 6}).String(), false},
+		{"different file", 6, (&Fingerprint{Inode: fp.Inode + 1, Checksum: fp.Checksum, Length: 6}).String(), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resume, err := canResume(path, tc.offset, tc.fingerprint)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, resume)
+		})
+	}
+
+	// the file was rotated by copy and truncate, then written again past the offset
+	require.NoError(t, ioutil.WriteFile(path, []byte("a new line\n"), 0644))
+	resume, err := canResume(path, 6, fp.String())
+	require.NoError(t, err)
+	assert.False(t, resume)
+}
@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build windows
+
+package file
+
+import (
+	"os"
+)
+
+// fileInode is not implemented on windows, the fingerprints only rely on the content of the files
+func fileInode(fi os.FileInfo) uint64 {
+	return 0
+}
@@ -6,6 +6,7 @@
 package file
 
 import (
+	"io"
 	"sync/atomic"
 	"time"
 
@@ -138,6 +139,21 @@ func (s *Scanner) scan() {
 			continue
 		}
 
+		didTruncate, err := tailer.checkTruncation()
+		if err != nil {
+			continue
+		}
+		if didTruncate {
+			// restart tailer because of a copytruncate rotation on file
+			succeeded := s.restartTailerAfterTruncation(tailer, file)
+			if !succeeded {
+				// the setup failed, let's try to tail this file in the next scan
+				continue
+			}
+			filesTailed[tailerKey] = true
+			continue
+		}
+
 		didRotate, err := DidRotate(tailer.file, tailer.GetReadOffset())
 		if err != nil {
 			continue
@@ -222,6 +238,17 @@ func (s *Scanner) startNewTailer(file *File, m config.TailingMode) bool {
 		log.Warnf("Could not recover offset for file with path %v: %v", file.Path, err)
 	}
 
+	if whence == io.SeekStart && offset > 0 {
+		// the file may have been rotated since the offset was recorded
+		resume, err := canResume(file.Path, offset, s.registry.GetFingerprint(tailer.Identifier()))
+		if err != nil {
+			log.Debugf("Could not check the offset recorded for file with path %v: %v", file.Path, err)
+		} else if !resume {
+			log.Infof("File with path %v was rotated since its offset was recorded, tailing it from the beginning", file.Path)
+			offset = 0
+		}
+	}
+
 	log.Infof("Starting a new tailer for: %s (offset: %d, whence: %d) for tailer key %s", file.Path, offset, whence, buildTailerKey(file))
 
 	err = tailer.Start(offset, whence)
@@ -278,6 +305,24 @@ func (s *Scanner) restartTailerAfterFileRotation(tailer *Tailer, file *File) boo
 	return true
 }
 
+// restartTailerAfterTruncation stops the tailer once the content read before the truncation is flushed,
+// and starts a new one reading the new content of the file from the beginning,
+// returns true if the new tailer is up and running, false if an error occurred
+func (s *Scanner) restartTailerAfterTruncation(tailer *Tailer, file *File) bool {
+	log.Info("Log truncation happened to ", tailer.path)
+	tailer.Stop()
+	delete(s.tailers, buildTailerKey(tailer))
+
+	tailer = s.createTailer(file, tailer.outputChan)
+	err := tailer.StartFromBeginning()
+	if err != nil {
+		log.Warn(err)
+		return false
+	}
+	s.tailers[buildTailerKey(file)] = tailer
+	return true
+}
+
 // createTailer returns a new initialized tailer
 func (s *Scanner) createTailer(file *File, outputChan chan *message.Message) *Tailer {
 	return NewTailer(outputChan, file.Source, file.Path, s.tailerSleepDuration, file.IsWildcardPath)
@@ -169,6 +169,41 @@ func (suite *ScannerTestSuite) TestScannerScanWithLogRotationCopyTruncate() {
 	// TODO(remy): add test for when a container ID is available in the source
 }
 
+func (suite *ScannerTestSuite) TestScannerScanWithLogRotationCopyTruncateRewritten() {
+	s := suite.s
+
+	var tailer *Tailer
+	var newTailer *Tailer
+	var err error
+	var msg *message.Message
+
+	tailer = s.tailers[suite.testPath]
+	_, err = suite.testFile.WriteString("hello world\n")
+	suite.Nil(err)
+	msg = <-suite.outputChan
+	suite.Equal("hello world", string(msg.Content))
+
+	// the file is written past the offset read before the next scan
+	suite.testFile.Truncate(0)
+	suite.testFile.Seek(0, 0)
+	suite.testFile.Sync()
+	_, err = suite.testFile.WriteString("a line longer than the previous one\n")
+	suite.Nil(err)
+
+	s.scan()
+	newTailer = s.tailers[suite.testPath]
+	suite.True(tailer != newTailer)
+
+	// the new content is only read from the beginning by the new tailer
+	msg = <-suite.outputChan
+	suite.Equal("a line longer than the previous one", string(msg.Content))
+	select {
+	case msg = <-suite.outputChan:
+		suite.Fail("unexpected message", string(msg.Content))
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 func (suite *ScannerTestSuite) TestScannerScanWithFileRemovedAndCreated() {
 	s := suite.s
 	tailerLen := len(s.tailers)
@@ -300,6 +335,51 @@ func TestScannerTailFromTheBeginning(t *testing.T) {
 	// TODO(remy): test with a container ID in the source
 }
 
+func TestScannerResumeAfterRotation(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "log-scanner-test-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(testDir)
+
+	path := fmt.Sprintf("%s/test.log", testDir)
+	assert.Nil(t, ioutil.WriteFile(path, []byte("hello\nworld\n"), 0644))
+
+	f, err := os.Open(path)
+	assert.Nil(t, err)
+	fingerprint, err := computeFingerprint(f, 6)
+	f.Close()
+	assert.Nil(t, err)
+
+	registry := auditor.NewRegistry()
+	registry.SetOffset("6")
+	registry.SetTailingMode("beginning")
+	registry.SetFingerprint(fingerprint.String())
+
+	sleepDuration := 20 * time.Millisecond
+	scanner := NewScanner(config.NewLogSources(), 2, mock.NewMockProvider(), registry, sleepDuration)
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path, TailingMode: "beginning"})
+	status.Clear()
+	status.InitStatus(config.CreateSources([]*config.LogSource{source}))
+	defer status.Clear()
+
+	// the file didn't change, the tailer resumes from the offset
+	scanner.addSource(source)
+	tailer := scanner.tailers[path]
+	msg := <-tailer.outputChan
+	assert.Equal(t, "world", string(msg.Content))
+	assert.Equal(t, fingerprint.Inode, tailer.getFingerprint().Inode)
+	scanner.cleanup()
+
+	// the file was rotated by copy and truncate then written again while no tailer was running,
+	// the tailer starts from the beginning
+	assert.Nil(t, ioutil.WriteFile(path, []byte("rotated\n"), 0644))
+	scanner.launchTailers(source)
+	tailer = scanner.tailers[path]
+	msg = <-tailer.outputChan
+	assert.Equal(t, "rotated", string(msg.Content))
+	assert.NotEqual(t, fingerprint.String(), msg.Origin.Fingerprint)
+	scanner.cleanup()
+}
+
 func TestScannerScanWithTooManyFiles(t *testing.T) {
 	var err error
 	var path string
@@ -48,9 +48,15 @@ type Tailer struct {
 
 	sleepDuration time.Duration
 
+	// fingerprint holds the *Fingerprint of the beginning of the file, up to the offset read
+	fingerprint atomic.Value
+	// idle is set when the last read didn't return any data
+	idle bool
+
 	closeTimeout  time.Duration
 	shouldStop    int32
 	didFileRotate int32
+	didTruncate   int32
 	stop          chan struct{}
 	done          chan struct{}
 
@@ -92,7 +98,7 @@ func NewTailer(outputChan chan *message.Message, source *config.LogSource, path
 	}
 
 	forwardContext, stopForward := context.WithCancel(context.Background())
-	closeTimeout := coreConfig.Datadog.GetDuration("logs_config.close_timeout") * time.Second
+	closeTimeout := coreConfig.Datadog.GetDurationWithUnit("logs_config.close_timeout", time.Second)
 
 	return &Tailer{
 		path:           path,
@@ -243,6 +249,9 @@ func (t *Tailer) forwardMessages() {
 		origin := message.NewOrigin(t.source)
 		origin.Identifier = identifier
 		origin.Offset = strconv.FormatInt(offset, 10)
+		if fingerprint := t.getFingerprint(); fingerprint != nil && t.shouldTrackOffset() {
+			origin.Fingerprint = fingerprint.String()
+		}
 		origin.SetTags(append(t.tags, t.tagProvider.GetTags()...))
 		// Ignore empty lines once the registry offset is updated
 		if len(output.Content) == 0 {
@@ -280,6 +289,36 @@ func (t *Tailer) SetDecodedOffset(off int64) {
 	atomic.StoreInt64(&t.decodedOffset, off)
 }
 
+// getFingerprint returns the fingerprint of the file, nil if it's not known yet
+func (t *Tailer) getFingerprint() *Fingerprint {
+	fingerprint, _ := t.fingerprint.Load().(*Fingerprint)
+	return fingerprint
+}
+
+// extendFingerprint makes the fingerprint of the file cover the content read so far, up to
+// fingerprintMaxLength bytes
+func (t *Tailer) extendFingerprint(f *os.File) {
+	length := t.GetReadOffset()
+	if length > fingerprintMaxLength {
+		length = fingerprintMaxLength
+	}
+
+	var err error
+	fingerprint := t.getFingerprint()
+	if fingerprint == nil {
+		fingerprint, err = computeFingerprint(f, length)
+	} else if fingerprint.Length < length {
+		fingerprint, err = fingerprint.extend(f, length)
+	} else {
+		return
+	}
+	if err != nil {
+		log.Debugf("Could not compute the fingerprint of %s: %v", t.path, err)
+		return
+	}
+	t.fingerprint.Store(fingerprint)
+}
+
 // shouldTrackOffset returns whether the tailer should track the file offset or not
 func (t *Tailer) shouldTrackOffset() bool {
 	if atomic.LoadInt32(&t.didFileRotate) != 0 {
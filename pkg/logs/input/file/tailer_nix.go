@@ -10,6 +10,7 @@ package file
 import (
 	"io"
 	"path/filepath"
+	"sync/atomic"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/decoder"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -36,6 +37,7 @@ func (t *Tailer) setup(offset int64, whence int) error {
 	ret, _ := f.Seek(offset, whence)
 	t.readOffset = ret
 	t.decodedOffset = ret
+	t.extendFingerprint(f)
 
 	return nil
 }
@@ -43,6 +45,13 @@ func (t *Tailer) setup(offset int64, whence int) error {
 // read lets the tailer tail the content of a file
 // until it is closed or the tailer is stopped.
 func (t *Tailer) read() (int, error) {
+	if t.idle {
+		// the file may have been truncated while waiting for new data
+		if truncated, err := t.checkTruncation(); err != nil || truncated {
+			return 0, nil
+		}
+	}
+
 	// keep reading data from file
 	inBuf := make([]byte, 4096)
 	n, err := t.file.Read(inBuf)
@@ -51,10 +60,39 @@ func (t *Tailer) read() (int, error) {
 		t.source.Status.Error(err)
 		return 0, log.Error("Unexpected error occurred while reading file: ", err)
 	}
+	t.idle = n == 0
 	if n == 0 {
 		return 0, nil
 	}
 	t.decoder.InputChan <- decoder.NewInput(inBuf[:n])
 	t.incrementReadOffset(n)
+	t.extendFingerprint(t.file)
 	return n, nil
 }
+
+// checkTruncation returns true if the file was truncated since it was last read, either because it's
+// smaller than the offset read or because the beginning of its content changed. This is how the
+// copytruncate rotations, which keep the same file, are detected. Once the file is truncated, the
+// tailer stops reading it so that its new content is only read, from the beginning, by the tailer
+// replacing it.
+func (t *Tailer) checkTruncation() (bool, error) {
+	if atomic.LoadInt32(&t.didTruncate) == 1 {
+		return true, nil
+	}
+
+	fi, err := t.file.Stat()
+	if err != nil {
+		return false, err
+	}
+	truncated := fi.Size() < t.GetReadOffset()
+	if fingerprint := t.getFingerprint(); !truncated && fingerprint != nil {
+		if truncated, err = fingerprint.didChange(t.file); err != nil {
+			return false, err
+		}
+	}
+
+	if truncated {
+		atomic.StoreInt32(&t.didTruncate, 1)
+	}
+	return truncated, nil
+}
@@ -33,10 +33,11 @@ func (t *Tailer) setup(offset int64, whence int) error {
 		return err
 	}
 	filePos, _ := f.Seek(offset, whence)
-	f.Close()
 
 	t.readOffset = filePos
 	t.decodedOffset = filePos
+	t.extendFingerprint(f)
+	f.Close()
 
 	return nil
 }
@@ -61,10 +62,12 @@ func (t *Tailer) readAvailable() (err error) {
 		log.Debug("File size now zero, resetting offset")
 		t.SetReadOffset(0)
 		t.SetDecodedOffset(0)
+		t.fingerprint.Store(&Fingerprint{})
 	} else if sz < offset {
 		log.Debug("Offset off end of file, resetting")
 		t.SetReadOffset(0)
 		t.SetDecodedOffset(0)
+		t.fingerprint.Store(&Fingerprint{})
 	}
 	f.Seek(t.GetReadOffset(), io.SeekStart)
 
@@ -78,9 +81,16 @@ func (t *Tailer) readAvailable() (err error) {
 		log.Debugf("Sending %d bytes to input channel", n)
 		t.decoder.InputChan <- decoder.NewInput(inBuf[:n])
 		t.incrementReadOffset(n)
+		t.extendFingerprint(f)
 	}
 }
 
+// checkTruncation is not implemented on windows, truncations are handled by
+// the tailer when reading the file.
+func (t *Tailer) checkTruncation() (bool, error) {
+	return false, nil
+}
+
 // read lets the tailer tail the content of a file until it is closed. The
 // windows version open and close the file between each call to 'read'. This is
 // needed in order not to block the file and prevent the user from renaming it.
@@ -14,6 +14,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -28,6 +29,10 @@ const (
 	// key used to display a warning message on the agent status
 	invalidProcessingRules = "invalid_global_processing_rules"
 	invalidEndpoints       = "invalid_endpoints"
+
+	// name of the logs-agent in the health of the components, the logs-agent being degraded, rather than
+	// failed, when it can't start, the other components of the agent keeping running
+	healthComponentName = "logs-agent"
 )
 
 var (
@@ -62,6 +67,7 @@ func Start(getAC func() *autodiscovery.AutoConfig) error {
 	if err != nil {
 		message := fmt.Sprintf("Invalid endpoints: %v", err)
 		status.AddGlobalError(invalidEndpoints, message)
+		health.SetState(healthComponentName, health.StateDegraded, message)
 		return errors.New(message)
 	}
 	status.CurrentTransport = status.TransportTCP
@@ -77,6 +83,7 @@ func Start(getAC func() *autodiscovery.AutoConfig) error {
 	if err != nil {
 		message := fmt.Sprintf("Invalid processing rules: %v", err)
 		status.AddGlobalError(invalidProcessingRules, message)
+		health.SetState(healthComponentName, health.StateDegraded, message)
 		return errors.New(message)
 	}
 
@@ -85,6 +92,7 @@ func Start(getAC func() *autodiscovery.AutoConfig) error {
 	log.Info("Starting logs-agent...")
 	agent.Start()
 	atomic.StoreInt32(&isRunning, 1)
+	health.SetState(healthComponentName, health.StateReady, "")
 	log.Info("logs-agent started")
 
 	// add SNMP traps source forwarding SNMP traps as logs if enabled.
@@ -93,6 +101,12 @@ func Start(getAC func() *autodiscovery.AutoConfig) error {
 		sources.AddSource(source)
 	}
 
+	// add the source collecting the logs of the journal of the host if enabled and available
+	if source := config.JournaldCollectAllSource(); source != nil {
+		log.Debug("Adding JournaldCollectAll source to the Logs Agent")
+		sources.AddSource(source)
+	}
+
 	// adds the source collecting logs from all containers if enabled,
 	// but ensure that it is enabled after the AutoConfig initialization
 	if source := config.ContainerCollectAllSource(); source != nil {
@@ -137,6 +151,7 @@ func Stop() {
 		status.Clear()
 		atomic.StoreInt32(&isRunning, 0)
 	}
+	health.RemoveState(healthComponentName)
 	log.Info("logs-agent stopped")
 }
 
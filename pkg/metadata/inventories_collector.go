@@ -47,7 +47,7 @@ func (c inventoriesCollector) Send(s *serializer.Serializer) error {
 
 // Init initializes the inventory metadata collection
 func (c inventoriesCollector) Init() error {
-	return inventories.StartMetadataUpdatedGoroutine(c.sc, config.Datadog.GetDuration("inventories_min_interval")*time.Second)
+	return inventories.StartMetadataUpdatedGoroutine(c.sc, config.Datadog.GetDurationWithUnit("inventories_min_interval", time.Second))
 }
 
 // SetupInventoriesExpvar init the expvar function for inventories
@@ -72,7 +72,7 @@ func SetupInventories(sc *Scheduler, ac inventories.AutoConfigInterface, coll in
 	}
 	RegisterCollector("inventories", ic)
 
-	if err := sc.AddCollector("inventories", config.Datadog.GetDuration("inventories_max_interval")*time.Second); err != nil {
+	if err := sc.AddCollector("inventories", config.Datadog.GetDurationWithUnit("inventories_max_interval", time.Second)); err != nil {
 		return err
 	}
 
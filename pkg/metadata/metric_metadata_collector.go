@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metadata
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metadata/metricmetadata"
+	"github.com/DataDog/datadog-agent/pkg/serializer"
+	"github.com/DataDog/datadog-agent/pkg/util"
+)
+
+// metricMetadataCollector sends the metadata declared for their metrics by the checks and
+// the DogStatsD clients
+type metricMetadataCollector struct {
+	sc *Scheduler
+}
+
+// Send collects the data needed and submits the payload
+func (c metricMetadataCollector) Send(s *serializer.Serializer) error {
+	if s == nil {
+		return nil
+	}
+
+	hostname, err := util.GetHostname()
+	if err != nil {
+		return fmt.Errorf("unable to submit metric metadata payload, no hostname: %s", err)
+	}
+
+	payload := metricmetadata.GetPayload(hostname)
+	if payload == nil {
+		// no metric metadata was declared
+		return nil
+	}
+
+	if err := s.SendMetadata(payload); err != nil {
+		return fmt.Errorf("unable to submit metric metadata payload, %s", err)
+	}
+	return nil
+}
+
+// Init initializes the metric metadata collection
+func (c metricMetadataCollector) Init() error {
+	return metricmetadata.StartMetadataUpdatedGoroutine(c.sc, config.Datadog.GetDurationWithUnit("metric_metadata_min_interval", time.Second))
+}
+
+// SetupMetricMetadata registers the metric metadata collector into the Scheduler and schedules it
+func SetupMetricMetadata(sc *Scheduler) error {
+	RegisterCollector("metric_metadata", metricMetadataCollector{sc: sc})
+
+	return sc.AddCollector("metric_metadata", config.Datadog.GetDurationWithUnit("metric_metadata_max_interval", time.Second))
+}
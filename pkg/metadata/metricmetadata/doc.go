@@ -0,0 +1,11 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+/*
+Package metricmetadata collects the metadata, such as the unit, the type and the
+description, declared for their metrics by the checks and the DogStatsD clients,
+so that they can be forwarded to the metadata intake.
+*/
+package metricmetadata
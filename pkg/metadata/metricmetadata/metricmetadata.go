@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metricmetadata
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxMetrics is the maximum number of metrics whose metadata is kept
+	maxMetrics = 10000
+	// maxDescriptionLength is the maximum length of a metric description, longer ones are truncated
+	maxDescriptionLength = 400
+)
+
+// validTypes are the metric types which can be declared in the metadata
+var validTypes = map[string]bool{
+	"gauge":        true,
+	"rate":         true,
+	"count":        true,
+	"distribution": true,
+}
+
+// unitPattern is the pattern of the metric units, such as "byte", "millisecond" or "request"
+var unitPattern = regexp.MustCompile(`^[a-z][a-z_]*$`)
+
+type schedulerInterface interface {
+	TriggerAndResetCollectorTimer(name string, delay time.Duration)
+}
+
+// MetricMetadata holds the metadata declared for a metric
+type MetricMetadata struct {
+	Unit        string `json:"unit,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+var (
+	metadataCache = make(map[string]MetricMetadata)
+	cacheMutex    = &sync.Mutex{}
+
+	lastGetPayload      = timeNow()
+	lastGetPayloadMutex = &sync.Mutex{}
+
+	metadataUpdatedC = make(chan interface{}, 1)
+)
+
+var (
+	// For testing purposes
+	timeNow   = time.Now
+	timeSince = time.Since
+)
+
+// Validate checks the metadata of a metric and normalizes it, the unit and the type being case insensitive
+func (m MetricMetadata) Validate() (MetricMetadata, error) {
+	m.Unit = strings.ToLower(strings.TrimSpace(m.Unit))
+	m.Type = strings.ToLower(strings.TrimSpace(m.Type))
+	m.Description = strings.TrimSpace(m.Description)
+
+	if m.Unit != "" && !unitPattern.MatchString(m.Unit) {
+		return m, fmt.Errorf("invalid unit '%s'", m.Unit)
+	}
+	if m.Type != "" && !validTypes[m.Type] {
+		return m, fmt.Errorf("invalid type '%s', expected gauge, rate, count or distribution", m.Type)
+	}
+	if len(m.Description) > maxDescriptionLength {
+		m.Description = m.Description[:maxDescriptionLength]
+	}
+	if m.Unit == "" && m.Type == "" && m.Description == "" {
+		return m, fmt.Errorf("no unit, type nor description")
+	}
+	return m, nil
+}
+
+// SetMetricMetadata declares the metadata of a metric, replacing the one previously declared
+func SetMetricMetadata(name string, metadata MetricMetadata) error {
+	if name == "" {
+		return fmt.Errorf("empty metric name")
+	}
+	metadata, err := metadata.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid metadata for metric '%s': %s", name, err)
+	}
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	previous, found := metadataCache[name]
+	if found && previous == metadata {
+		return nil
+	}
+	if !found && len(metadataCache) >= maxMetrics {
+		return fmt.Errorf("unable to declare the metadata of metric '%s', the metadata of %d metrics were already declared", name, maxMetrics)
+	}
+	metadataCache[name] = metadata
+
+	select {
+	case metadataUpdatedC <- nil:
+	default: // To make sure this call is not blocking
+	}
+	return nil
+}
+
+// CreatePayload returns the metric metadata payload, nil when no metadata was declared
+func CreatePayload(hostname string) *Payload {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	if len(metadataCache) == 0 {
+		return nil
+	}
+
+	metadata := make(map[string]MetricMetadata, len(metadataCache))
+	for name, m := range metadataCache {
+		metadata[name] = m
+	}
+
+	return &Payload{
+		Hostname:       hostname,
+		Timestamp:      timeNow().UnixNano(),
+		MetricMetadata: metadata,
+	}
+}
+
+// GetPayload returns a new metric metadata payload and updates lastGetPayload
+func GetPayload(hostname string) *Payload {
+	lastGetPayloadMutex.Lock()
+	defer lastGetPayloadMutex.Unlock()
+	lastGetPayload = timeNow()
+
+	return CreatePayload(hostname)
+}
+
+// StartMetadataUpdatedGoroutine starts a routine that listens to the metadataUpdatedC
+// signal to run the collector out of its regular interval.
+func StartMetadataUpdatedGoroutine(sc schedulerInterface, minSendInterval time.Duration) error {
+	go func() {
+		for {
+			<-metadataUpdatedC
+			lastGetPayloadMutex.Lock()
+			delay := minSendInterval - timeSince(lastGetPayload)
+			if delay < 0 {
+				delay = 0
+			}
+			sc.TriggerAndResetCollectorTimer("metric_metadata", delay)
+			lastGetPayloadMutex.Unlock()
+		}
+	}()
+	return nil
+}
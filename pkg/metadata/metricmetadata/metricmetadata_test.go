@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package metricmetadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func clearMetadata() {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	metadataCache = make(map[string]MetricMetadata)
+
+	// purge metadataUpdatedC
+L:
+	for {
+		select {
+		case <-metadataUpdatedC:
+		default: // To make sure this call is not blocking
+			break L
+		}
+	}
+}
+
+type mockScheduler struct {
+	sendNowCalled    chan interface{}
+	lastSendNowDelay time.Duration
+}
+
+func (m *mockScheduler) TriggerAndResetCollectorTimer(name string, delay time.Duration) {
+	m.lastSendNowDelay = delay
+	m.sendNowCalled <- nil
+}
+
+func TestValidate(t *testing.T) {
+	m, err := MetricMetadata{Unit: " Byte ", Type: "GAUGE", Description: " Size of the queue "}.Validate()
+	require.NoError(t, err)
+	assert.Equal(t, MetricMetadata{Unit: "byte", Type: "gauge", Description: "Size of the queue"}, m)
+
+	m, err = MetricMetadata{Description: strings.Repeat("a", maxDescriptionLength+1)}.Validate()
+	require.NoError(t, err)
+	assert.Len(t, m.Description, maxDescriptionLength)
+
+	_, err = MetricMetadata{Unit: "bytes/s"}.Validate()
+	assert.Error(t, err)
+
+	_, err = MetricMetadata{Type: "histogram"}.Validate()
+	assert.Error(t, err)
+
+	_, err = MetricMetadata{}.Validate()
+	assert.Error(t, err)
+}
+
+func TestSetMetricMetadata(t *testing.T) {
+	defer clearMetadata()
+	clearMetadata()
+
+	assert.Nil(t, CreatePayload("test-host"))
+
+	require.NoError(t, SetMetricMetadata("queue.size", MetricMetadata{Unit: "item", Type: "gauge"}))
+	require.NoError(t, SetMetricMetadata("queue.latency", MetricMetadata{Unit: "millisecond", Description: "Time spent in the queue"}))
+	assert.Error(t, SetMetricMetadata("", MetricMetadata{Unit: "item"}))
+	assert.Error(t, SetMetricMetadata("queue.drops", MetricMetadata{Type: "set"}))
+
+	// the metadata is replaced
+	require.NoError(t, SetMetricMetadata("queue.size", MetricMetadata{Unit: "item", Type: "gauge", Description: "Size of the queue"}))
+
+	startNow := timeNow()
+	timeNow = func() time.Time { return startNow }
+	defer func() { timeNow = time.Now }()
+
+	p := CreatePayload("test-host")
+	require.NotNil(t, p)
+	assert.Equal(t, "test-host", p.Hostname)
+	assert.Equal(t, startNow.UnixNano(), p.Timestamp)
+	assert.Equal(t, map[string]MetricMetadata{
+		"queue.size":    {Unit: "item", Type: "gauge", Description: "Size of the queue"},
+		"queue.latency": {Unit: "millisecond", Description: "Time spent in the queue"},
+	}, p.MetricMetadata)
+
+	jsonString, err := json.Marshal(p)
+	require.NoError(t, err)
+	expected := fmt.Sprintf(`{"hostname":"test-host","timestamp":%d,"metric_metadata":{"queue.latency":{"unit":"millisecond","description":"Time spent in the queue"},"queue.size":{"unit":"item","type":"gauge","description":"Size of the queue"}}}`, startNow.UnixNano())
+	assert.Equal(t, expected, string(jsonString))
+}
+
+func TestSetMetricMetadataLimit(t *testing.T) {
+	defer clearMetadata()
+	clearMetadata()
+
+	for i := 0; i < maxMetrics; i++ {
+		require.NoError(t, SetMetricMetadata(fmt.Sprintf("metric.%d", i), MetricMetadata{Unit: "item"}))
+	}
+	assert.Error(t, SetMetricMetadata("metric.extra", MetricMetadata{Unit: "item"}))
+	// the metadata of the known metrics can still be updated
+	assert.NoError(t, SetMetricMetadata("metric.0", MetricMetadata{Unit: "byte"}))
+}
+
+func TestStartMetadataUpdatedGoroutine(t *testing.T) {
+	defer clearMetadata()
+	clearMetadata()
+
+	ms := &mockScheduler{sendNowCalled: make(chan interface{}, 1)}
+	require.NoError(t, StartMetadataUpdatedGoroutine(ms, time.Minute))
+
+	lastGetPayloadMutex.Lock()
+	lastGetPayload = timeNow()
+	lastGetPayloadMutex.Unlock()
+
+	require.NoError(t, SetMetricMetadata("queue.size", MetricMetadata{Unit: "item"}))
+	select {
+	case <-ms.sendNowCalled:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the collector wasn't triggered")
+	}
+	assert.True(t, ms.lastSendNowDelay > 0 && ms.lastSendNowDelay <= time.Minute)
+
+	// an unchanged metadata doesn't trigger the collector
+	require.NoError(t, SetMetricMetadata("queue.size", MetricMetadata{Unit: "item"}))
+	select {
+	case <-metadataUpdatedC:
+		assert.Fail(t, "the metadata wasn't updated")
+	default:
+	}
+}
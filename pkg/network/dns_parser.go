@@ -127,15 +127,21 @@ func (p *dnsParser) parseAnswerInto(
 	t *translation,
 	pktInfo *dnsPacketInfo,
 ) error {
-	// Only consider singleton, A-record questions
+	// Only consider singleton questions
 	if len(dns.Questions) != 1 {
 		return errSkippedPayload
 	}
 
+	// Only A-record questions are relevant for the reverse DNS cache, but the stats
+	// are collected for all the query types
 	question := dns.Questions[0]
-	if question.Type != layers.DNSTypeA || question.Class != layers.DNSClassIN {
+	if question.Class != layers.DNSClassIN || (question.Type != layers.DNSTypeA && !p.collectDNSStats) {
 		return errSkippedPayload
 	}
+	pktInfo.queryType = QueryType(question.Type)
+	if p.collectDNSStats {
+		pktInfo.question = string(question.Name)
+	}
 
 	// Only consider responses
 	if !dns.QR {
@@ -149,6 +155,11 @@ func (p *dnsParser) parseAnswerInto(
 		return nil
 	}
 
+	pktInfo.pktType = SuccessfulResponse
+	if question.Type != layers.DNSTypeA {
+		return nil
+	}
+
 	var alias []byte
 	domainQueried := question.Name
 
@@ -162,8 +173,6 @@ func (p *dnsParser) parseAnswerInto(
 	p.extractIPsInto(alias, domainQueried, dns.Answers, t)
 	p.extractIPsInto(alias, domainQueried, dns.Additionals, t)
 	t.dns = string(domainQueried)
-
-	pktInfo.pktType = SuccessfulResponse
 	return nil
 }
 
@@ -113,7 +113,7 @@ func (s *SocketFilterSnooper) Resolve(connections []ConnectionStats) map[util.Ad
 	return s.cache.Get(connections, time.Now())
 }
 
-func (s *SocketFilterSnooper) GetDNSStats() map[dnsKey]dnsStats {
+func (s *SocketFilterSnooper) GetDNSStats() map[dnsKey]map[string]map[QueryType]dnsStats {
 	if s.statKeeper == nil {
 		return nil
 	}
@@ -256,7 +256,8 @@ func (s *SocketFilterSnooper) getCachedTranslation() *translation {
 	return t
 }
 
-// packetSource provides a RAW_SOCKET attached to an eBPF SOCKET_FILTER
+// packetSource provides a RAW_SOCKET attached to an eBPF SOCKET_FILTER, or to a classic BPF filter when
+// socketFilter is nil
 type packetSource struct {
 	*afpacket.TPacket
 	socketFilter *manager.Probe
@@ -264,16 +265,9 @@ type packetSource struct {
 }
 
 func newPacketSource(filter *manager.Probe) (*packetSource, error) {
-	rawSocket, err := afpacket.NewTPacket(
-		afpacket.OptPollTimeout(1*time.Second),
-		// This setup will require ~4Mb that is mmap'd into the process virtual space
-		// More information here: https://www.kernel.org/doc/Documentation/networking/packet_mmap.txt
-		afpacket.OptFrameSize(4096),
-		afpacket.OptBlockSize(4096*128),
-		afpacket.OptNumBlocks(8),
-	)
+	rawSocket, err := newRawSocket()
 	if err != nil {
-		return nil, fmt.Errorf("error creating raw socket: %s", err)
+		return nil, err
 	}
 
 	// The underlying socket file descriptor is private, hence the use of reflection
@@ -293,9 +287,26 @@ func newPacketSource(filter *manager.Probe) (*packetSource, error) {
 }
 
 func (p *packetSource) Close() {
-	if err := p.socketFilter.Detach(); err != nil {
-		log.Errorf("error detaching socket filter: %s", err)
+	if p.socketFilter != nil {
+		if err := p.socketFilter.Detach(); err != nil {
+			log.Errorf("error detaching socket filter: %s", err)
+		}
 	}
 
 	p.TPacket.Close()
 }
+
+func newRawSocket() (*afpacket.TPacket, error) {
+	rawSocket, err := afpacket.NewTPacket(
+		afpacket.OptPollTimeout(1*time.Second),
+		// This setup will require ~4Mb that is mmap'd into the process virtual space
+		// More information here: https://www.kernel.org/doc/Documentation/networking/packet_mmap.txt
+		afpacket.OptFrameSize(4096),
+		afpacket.OptBlockSize(4096*128),
+		afpacket.OptNumBlocks(8),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating raw socket: %s", err)
+	}
+	return rawSocket, nil
+}
@@ -237,7 +237,7 @@ func getKey(
 func getStats(
 	snooper *SocketFilterSnooper,
 	expectedCount int,
-) map[dnsKey]dnsStats {
+) map[dnsKey]map[string]map[QueryType]dnsStats {
 	timeout := time.After(1 * time.Second)
 Loop:
 	// Wait until DNS stats becomes available
@@ -279,13 +279,18 @@ func TestDNSOverTCPSuccessfulResponseCount(t *testing.T) {
 	// Since all the queries were done using one TCP connection, there should be just one key in the stats map
 	require.Equal(t, 1, len(allStats))
 
-	// Exactly one rcode (0, success) is expected
-	require.Equal(t, 1, len(allStats[key].countByRcode))
+	// The stats are aggregated per domain queried
+	require.Equal(t, len(domains), len(allStats[key]))
 
-	assert.Equal(t, uint32(len(domains)), allStats[key].countByRcode[uint8(layers.DNSResponseCodeNoErr)])
-	assert.True(t, allStats[key].successLatencySum >= uint64(1))
-	assert.Equal(t, uint32(0), allStats[key].timeouts)
-	assert.Equal(t, uint64(0), allStats[key].failureLatencySum)
+	for _, domain := range domains {
+		// Exactly one rcode (0, success) is expected
+		require.Equal(t, 1, len(allStats[key][domain][DNSTypeA].countByRcode))
+
+		assert.Equal(t, uint32(1), allStats[key][domain][DNSTypeA].countByRcode[uint8(layers.DNSResponseCodeNoErr)])
+		assert.True(t, allStats[key][domain][DNSTypeA].successLatencySum >= uint64(1))
+		assert.Equal(t, uint32(0), allStats[key][domain][DNSTypeA].timeouts)
+		assert.Equal(t, uint64(0), allStats[key][domain][DNSTypeA].failureLatencySum)
+	}
 }
 
 type handler struct{}
@@ -335,13 +340,17 @@ func TestDNSFailedResponseCount(t *testing.T) {
 	require.Equal(t, 2, len(allStats))
 
 	// First check the one sent over TCP. Expected error type: NXDomain
-	require.Equal(t, 1, len(allStats[key1].countByRcode))
-	assert.Equal(t, uint32(len(domains)), allStats[key1].countByRcode[uint8(layers.DNSResponseCodeNXDomain)])
+	for _, domain := range domains {
+		require.Equal(t, 1, len(allStats[key1][domain][DNSTypeA].countByRcode))
+		assert.Equal(t, uint32(1), allStats[key1][domain][DNSTypeA].countByRcode[uint8(layers.DNSResponseCodeNXDomain)])
+	}
 
 	// Next check the one sent over UDP. Expected error type: ServFail
 	key2 := getKey(queryIP, queryPort, localhost, UDP)
-	require.Equal(t, 1, len(allStats[key2].countByRcode))
-	assert.Equal(t, uint32(len(domains)), allStats[key2].countByRcode[uint8(layers.DNSResponseCodeServFail)])
+	for _, domain := range domains {
+		require.Equal(t, 1, len(allStats[key2][domain][DNSTypeA].countByRcode))
+		assert.Equal(t, uint32(1), allStats[key2][domain][DNSTypeA].countByRcode[uint8(layers.DNSResponseCodeServFail)])
+	}
 }
 
 func TestDNSOverUDPTimeoutCount(t *testing.T) {
@@ -350,16 +359,17 @@ func TestDNSOverUDPTimeoutCount(t *testing.T) {
 	defer reverseDNS.Close()
 
 	invalidServerIP := "8.8.8.90"
-	queryIP, queryPort, reps := sendDNSQueries(t, []string{"agafsdfsdasdfsd"}, invalidServerIP, UDP)
+	domain := "agafsdfsdasdfsd"
+	queryIP, queryPort, reps := sendDNSQueries(t, []string{domain}, invalidServerIP, UDP)
 	require.Nil(t, reps[0])
 
 	allStats := getStats(reverseDNS, 1)
 	key := getKey(queryIP, queryPort, invalidServerIP, UDP)
 	require.Equal(t, 1, len(allStats))
-	assert.Equal(t, 0, len(allStats[key].countByRcode))
-	assert.Equal(t, uint32(1), allStats[key].timeouts)
-	assert.Equal(t, uint64(0), allStats[key].successLatencySum)
-	assert.Equal(t, uint64(0), allStats[key].failureLatencySum)
+	assert.Equal(t, 0, len(allStats[key][domain][DNSTypeA].countByRcode))
+	assert.Equal(t, uint32(1), allStats[key][domain][DNSTypeA].timeouts)
+	assert.Equal(t, uint64(0), allStats[key][domain][DNSTypeA].successLatencySum)
+	assert.Equal(t, uint64(0), allStats[key][domain][DNSTypeA].failureLatencySum)
 }
 
 func TestParsingError(t *testing.T) {
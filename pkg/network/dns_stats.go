@@ -14,6 +14,28 @@ type dnsStats struct {
 	countByRcode      map[uint8]uint32
 }
 
+// QueryType is the DNS query type (A, AAAA, ...) the stats of a domain are aggregated by
+type QueryType uint16
+
+// The DNS query types we care about. We could have used the layers.DNSType values here,
+// but importing the gopacket library only for these constants is not worth the increased memory cost.
+const (
+	// DNSTypeA is an IPv4 address query
+	DNSTypeA QueryType = 1
+	// DNSTypeCNAME is a canonical name query
+	DNSTypeCNAME QueryType = 5
+	// DNSTypePTR is a reverse lookup query
+	DNSTypePTR QueryType = 12
+	// DNSTypeMX is a mail exchange query
+	DNSTypeMX QueryType = 15
+	// DNSTypeTXT is a text record query
+	DNSTypeTXT QueryType = 16
+	// DNSTypeAAAA is an IPv6 address query
+	DNSTypeAAAA QueryType = 28
+	// DNSTypeSRV is a service locator query
+	DNSTypeSRV QueryType = 33
+)
+
 type dnsKey struct {
 	serverIP   util.Address
 	clientIP   util.Address
@@ -45,16 +67,20 @@ type dnsPacketInfo struct {
 	key           dnsKey
 	pktType       DNSPacketType
 	rCode         uint8 // responseCode
+	question      string
+	queryType     QueryType
 }
 
 type stateKey struct {
-	key dnsKey
-	id  uint16
+	key       dnsKey
+	id        uint16
+	question  string
+	queryType QueryType
 }
 
 type dnsStatKeeper struct {
 	mux              sync.Mutex
-	stats            map[dnsKey]dnsStats
+	stats            map[dnsKey]map[string]map[QueryType]dnsStats
 	state            map[stateKey]uint64
 	expirationPeriod time.Duration
 	exit             chan struct{}
@@ -64,7 +90,7 @@ type dnsStatKeeper struct {
 
 func newDNSStatkeeper(timeout time.Duration) *dnsStatKeeper {
 	statsKeeper := &dnsStatKeeper{
-		stats:            make(map[dnsKey]dnsStats),
+		stats:            make(map[dnsKey]map[string]map[QueryType]dnsStats),
 		state:            make(map[stateKey]uint64),
 		expirationPeriod: timeout,
 		exit:             make(chan struct{}),
@@ -90,18 +116,32 @@ func microSecs(t time.Time) uint64 {
 	return uint64(t.UnixNano() / 1000)
 }
 
-func (d *dnsStatKeeper) getStats(key dnsKey) dnsStats {
-	stats, ok := d.stats[key]
+func (d *dnsStatKeeper) getStats(key dnsKey, question string, queryType QueryType) dnsStats {
+	stats, ok := d.stats[key][question][queryType]
 	if !ok {
 		stats.countByRcode = make(map[uint8]uint32)
 	}
 	return stats
 }
 
+func (d *dnsStatKeeper) setStats(key dnsKey, question string, queryType QueryType, stats dnsStats) {
+	byDomain, ok := d.stats[key]
+	if !ok {
+		byDomain = make(map[string]map[QueryType]dnsStats)
+		d.stats[key] = byDomain
+	}
+	byType, ok := byDomain[question]
+	if !ok {
+		byType = make(map[QueryType]dnsStats)
+		byDomain[question] = byType
+	}
+	byType[queryType] = stats
+}
+
 func (d *dnsStatKeeper) ProcessPacketInfo(info dnsPacketInfo, ts time.Time) {
 	d.mux.Lock()
 	defer d.mux.Unlock()
-	sk := stateKey{key: info.key, id: info.transactionID}
+	sk := stateKey{key: info.key, id: info.transactionID, question: info.question, queryType: info.queryType}
 
 	if info.pktType == Query {
 		if len(d.state) == d.maxSize {
@@ -126,7 +166,7 @@ func (d *dnsStatKeeper) ProcessPacketInfo(info dnsPacketInfo, ts time.Time) {
 
 	latency := microSecs(ts) - start
 
-	stats := d.getStats(info.key)
+	stats := d.getStats(info.key, info.question, info.queryType)
 
 	// Note: time.Duration in the agent version of go (1.12.9) does not have the Microseconds method.
 	if latency > uint64(d.expirationPeriod.Microseconds()) {
@@ -140,14 +180,14 @@ func (d *dnsStatKeeper) ProcessPacketInfo(info dnsPacketInfo, ts time.Time) {
 		}
 	}
 
-	d.stats[info.key] = stats
+	d.setStats(info.key, info.question, info.queryType, stats)
 }
 
-func (d *dnsStatKeeper) GetAndResetAllStats() map[dnsKey]dnsStats {
+func (d *dnsStatKeeper) GetAndResetAllStats() map[dnsKey]map[string]map[QueryType]dnsStats {
 	d.mux.Lock()
 	defer d.mux.Unlock()
 	ret := d.stats // No deep copy needed since `d.stats` gets reset
-	d.stats = make(map[dnsKey]dnsStats)
+	d.stats = make(map[dnsKey]map[string]map[QueryType]dnsStats)
 	return ret
 }
 
@@ -160,9 +200,9 @@ func (d *dnsStatKeeper) removeExpiredStates(earliestTs time.Time) {
 		if v < threshold {
 			delete(d.state, k)
 			d.deleteCount++
-			stats := d.getStats(k.key)
+			stats := d.getStats(k.key, k.question, k.queryType)
 			stats.timeouts++
-			d.stats[k.key] = stats
+			d.setStats(k.key, k.question, k.queryType, stats)
 		}
 	}
 
@@ -35,22 +35,22 @@ func testLatency(
 ) {
 	sk := newDNSStatkeeper(DNSTimeoutSecs * time.Second)
 	key := getSampleDNSKey()
-	qPkt := dnsPacketInfo{transactionID: 1, pktType: Query, key: key}
+	qPkt := dnsPacketInfo{transactionID: 1, pktType: Query, key: key, question: "foo.com", queryType: DNSTypeA}
 	then := time.Now()
 	sk.ProcessPacketInfo(qPkt, then)
 	stats := sk.GetAndResetAllStats()
 	assert.NotContains(t, stats, key)
 
 	now := then.Add(delta)
-	rPkt := dnsPacketInfo{transactionID: 1, key: key, pktType: respType}
+	rPkt := dnsPacketInfo{transactionID: 1, key: key, pktType: respType, question: "foo.com", queryType: DNSTypeA}
 
 	sk.ProcessPacketInfo(rPkt, now)
 	stats = sk.GetAndResetAllStats()
 	require.Contains(t, stats, key)
 
-	assert.Equal(t, expectedSuccessLatency, stats[key].successLatencySum)
-	assert.Equal(t, expectedFailureLatency, stats[key].failureLatencySum)
-	assert.Equal(t, expectedTimeouts, stats[key].timeouts)
+	assert.Equal(t, expectedSuccessLatency, stats[key]["foo.com"][DNSTypeA].successLatencySum)
+	assert.Equal(t, expectedFailureLatency, stats[key]["foo.com"][DNSTypeA].failureLatencySum)
+	assert.Equal(t, expectedTimeouts, stats[key]["foo.com"][DNSTypeA].timeouts)
 }
 
 func TestSuccessLatency(t *testing.T) {
@@ -71,11 +71,11 @@ func TestTimeout(t *testing.T) {
 func TestExpiredStateRemoval(t *testing.T) {
 	sk := newDNSStatkeeper(DNSTimeoutSecs * time.Second)
 	key := getSampleDNSKey()
-	qPkt1 := dnsPacketInfo{transactionID: 1, pktType: Query, key: key}
-	rPkt1 := dnsPacketInfo{transactionID: 1, key: key, pktType: SuccessfulResponse}
-	qPkt2 := dnsPacketInfo{transactionID: 2, pktType: Query, key: key}
-	qPkt3 := dnsPacketInfo{transactionID: 3, pktType: Query, key: key}
-	rPkt3 := dnsPacketInfo{transactionID: 3, key: key, pktType: SuccessfulResponse}
+	qPkt1 := dnsPacketInfo{transactionID: 1, pktType: Query, key: key, question: "foo.com", queryType: DNSTypeA}
+	rPkt1 := dnsPacketInfo{transactionID: 1, key: key, pktType: SuccessfulResponse, question: "foo.com", queryType: DNSTypeA}
+	qPkt2 := dnsPacketInfo{transactionID: 2, pktType: Query, key: key, question: "foo.com", queryType: DNSTypeA}
+	qPkt3 := dnsPacketInfo{transactionID: 3, pktType: Query, key: key, question: "foo.com", queryType: DNSTypeA}
+	rPkt3 := dnsPacketInfo{transactionID: 3, key: key, pktType: SuccessfulResponse, question: "foo.com", queryType: DNSTypeA}
 
 	sk.ProcessPacketInfo(qPkt1, time.Now())
 	sk.ProcessPacketInfo(rPkt1, time.Now())
@@ -89,9 +89,35 @@ func TestExpiredStateRemoval(t *testing.T) {
 	stats := sk.GetAndResetAllStats()
 	require.Contains(t, stats, key)
 
-	require.Contains(t, stats[key].countByRcode, uint8(0))
-	assert.Equal(t, uint32(2), stats[key].countByRcode[0])
-	assert.Equal(t, uint32(1), stats[key].timeouts)
+	require.Contains(t, stats[key]["foo.com"][DNSTypeA].countByRcode, uint8(0))
+	assert.Equal(t, uint32(2), stats[key]["foo.com"][DNSTypeA].countByRcode[0])
+	assert.Equal(t, uint32(1), stats[key]["foo.com"][DNSTypeA].timeouts)
+}
+
+func TestStatsByDomainByQueryType(t *testing.T) {
+	sk := newDNSStatkeeper(DNSTimeoutSecs * time.Second)
+	key := getSampleDNSKey()
+	now := time.Now()
+
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 1, pktType: Query, key: key, question: "foo.com", queryType: DNSTypeA}, now)
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 2, pktType: Query, key: key, question: "foo.com", queryType: DNSTypeAAAA}, now)
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 3, pktType: Query, key: key, question: "bar.com", queryType: DNSTypeA}, now)
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 1, pktType: SuccessfulResponse, key: key, question: "foo.com", queryType: DNSTypeA}, now.Add(10*time.Microsecond))
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 2, pktType: FailedResponse, rCode: 3, key: key, question: "foo.com", queryType: DNSTypeAAAA}, now.Add(20*time.Microsecond))
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 3, pktType: SuccessfulResponse, key: key, question: "bar.com", queryType: DNSTypeA}, now.Add(30*time.Microsecond))
+
+	stats := sk.GetAndResetAllStats()
+	require.Contains(t, stats, key)
+	require.Len(t, stats[key], 2)
+	require.Len(t, stats[key]["foo.com"], 2)
+	require.Len(t, stats[key]["bar.com"], 1)
+
+	assert.Equal(t, uint64(10), stats[key]["foo.com"][DNSTypeA].successLatencySum)
+	assert.Equal(t, uint32(1), stats[key]["foo.com"][DNSTypeA].countByRcode[0])
+	assert.Equal(t, uint64(20), stats[key]["foo.com"][DNSTypeAAAA].failureLatencySum)
+	assert.Equal(t, uint32(1), stats[key]["foo.com"][DNSTypeAAAA].countByRcode[3])
+	assert.Equal(t, uint64(30), stats[key]["bar.com"][DNSTypeA].successLatencySum)
+	assert.Equal(t, uint32(1), stats[key]["bar.com"][DNSTypeA].countByRcode[0])
 }
 
 func BenchmarkStats(b *testing.B) {
@@ -99,7 +125,7 @@ func BenchmarkStats(b *testing.B) {
 
 	var packets []dnsPacketInfo
 	for j := 0; j < MaxStateMapSize*2; j++ {
-		qPkt := dnsPacketInfo{pktType: Query, key: key}
+		qPkt := dnsPacketInfo{pktType: Query, key: key, question: "foo.com", queryType: DNSTypeA}
 		qPkt.transactionID = uint16(j)
 		packets = append(packets, qPkt)
 	}
@@ -42,6 +42,21 @@ func TestSerialization(t *testing.T) {
 				Direction: network.LOCAL,
 
 				DNSCountByRcode: map[uint32]uint32{0: 1},
+				DNSStatsByDomainByQueryType: map[string]map[network.QueryType]network.DNSStats{
+					"foo.com": {
+						network.DNSTypeA: {
+							SuccessfulResponses: 1,
+							Timeouts:            1,
+							SuccessLatencySum:   10,
+							CountByRcode:        map[uint32]uint32{0: 1},
+						},
+						network.DNSTypeAAAA: {
+							FailedResponses:   1,
+							FailureLatencySum: 20,
+							CountByRcode:      map[uint32]uint32{3: 1},
+						},
+					},
+				},
 			},
 		},
 		DNS: map[util.Address][]string{
@@ -73,13 +88,38 @@ func TestSerialization(t *testing.T) {
 				Direction: model.ConnectionDirection_local,
 
 				DnsCountByRcode: map[uint32]uint32{0: 1},
+				DnsStatsByDomainByQueryType: map[int32]*model.DNSStatsByQueryType{
+					0: {
+						DnsStatsByQueryType: map[int32]*model.DNSStats{
+							int32(network.DNSTypeA): {
+								DnsTimeouts:          1,
+								DnsSuccessLatencySum: 10,
+								DnsCountByRcode:      map[uint32]uint32{0: 1},
+							},
+							int32(network.DNSTypeAAAA): {
+								DnsFailureLatencySum: 20,
+								DnsCountByRcode:      map[uint32]uint32{3: 1},
+							},
+						},
+					},
+				},
 			},
 		},
 		Dns: map[string]*model.DNSEntry{
 			"172.217.12.145": {Names: []string{"golang.org"}},
 		},
+		Domains: []string{"foo.com"},
 	}
 
+	// the json marshaler emits the default values, so the fields system-probe leaves unset are unmarshaled
+	// as empty collections rather than nil ones
+	jsonConn := *out.Conns[0]
+	jsonConn.DnsStatsByDomain = map[int32]*model.DNSStats{}
+	jsonOut := *out
+	jsonOut.Conns = []*model.Connection{&jsonConn}
+	jsonOut.Routes = []*model.Route{}
+	jsonOut.CompilationTelemetryByAsset = map[string]*model.RuntimeCompilationTelemetry{}
+
 	t.Run("requesting application/json serialization", func(t *testing.T) {
 		assert := assert.New(t)
 		marshaler := GetMarshaler("application/json")
@@ -91,7 +131,7 @@ func TestSerialization(t *testing.T) {
 		unmarshaler := GetUnmarshaler("application/json")
 		result, err := unmarshaler.Unmarshal(blob)
 		require.NoError(t, err)
-		assert.Equal(out, result)
+		assert.Equal(&jsonOut, result)
 	})
 
 	t.Run("requesting empty serialization", func(t *testing.T) {
@@ -106,7 +146,7 @@ func TestSerialization(t *testing.T) {
 		unmarshaler := GetUnmarshaler("")
 		result, err := unmarshaler.Unmarshal(blob)
 		require.NoError(t, err)
-		assert.Equal(out, result)
+		assert.Equal(&jsonOut, result)
 	})
 
 	t.Run("requesting application/protobuf serialization", func(t *testing.T) {
@@ -136,7 +176,7 @@ func TestSerialization(t *testing.T) {
 		unmarshaler := GetUnmarshaler("application/json")
 		result, err := unmarshaler.Unmarshal(blob)
 		require.NoError(t, err)
-		assert.Equal(out, result)
+		assert.Equal(&jsonOut, result)
 	})
 
 	t.Run("render default values with application/json", func(t *testing.T) {
@@ -163,3 +203,40 @@ func TestSerialization(t *testing.T) {
 		}
 	})
 }
+
+func TestFormatConnectionDomains(t *testing.T) {
+	stats := network.DNSStats{Timeouts: 1}
+	conns := []network.ConnectionStats{
+		{
+			DNSStatsByDomainByQueryType: map[string]map[network.QueryType]network.DNSStats{
+				"foo.com": {network.DNSTypeA: stats},
+			},
+		},
+		{
+			DNSStatsByDomainByQueryType: map[string]map[network.QueryType]network.DNSStats{
+				"foo.com": {network.DNSTypeA: stats},
+				"bar.com": {network.DNSTypeA: stats},
+			},
+		},
+		{},
+	}
+
+	domainSet := make(map[string]int)
+	formatted := make([]*model.Connection, 0, len(conns))
+	for _, conn := range conns {
+		formatted = append(formatted, FormatConnection(conn, domainSet))
+	}
+	domains := FormatDomains(domainSet)
+
+	require.Len(t, domains, 2)
+	assert.Equal(t, "foo.com", domains[0])
+	assert.Equal(t, "bar.com", domains[1])
+
+	// the domains shared by several connections have a single index
+	assert.Len(t, formatted[0].DnsStatsByDomainByQueryType, 1)
+	assert.Contains(t, formatted[0].DnsStatsByDomainByQueryType, int32(0))
+	assert.Len(t, formatted[1].DnsStatsByDomainByQueryType, 2)
+	assert.Contains(t, formatted[1].DnsStatsByDomainByQueryType, int32(0))
+	assert.Contains(t, formatted[1].DnsStatsByDomainByQueryType, int32(1))
+	assert.Empty(t, formatted[2].DnsStatsByDomainByQueryType)
+}
@@ -20,8 +20,9 @@ var connPool = sync.Pool{
 	},
 }
 
-// FormatConnection converts a ConnectionStats into an model.Connection
-func FormatConnection(conn network.ConnectionStats) *model.Connection {
+// FormatConnection converts a ConnectionStats into an model.Connection, the domains of its DNS stats are added
+// to the given domain set, which maps them to their index in the domains of the payload
+func FormatConnection(conn network.ConnectionStats, domainSet map[string]int) *model.Connection {
 	c := connPool.Get().(*model.Connection)
 	c.Pid = int32(conn.Pid)
 	c.Laddr = formatAddr(conn.Source, conn.SPort)
@@ -45,11 +46,21 @@ func FormatConnection(conn network.ConnectionStats) *model.Connection {
 	c.DnsSuccessLatencySum = conn.DNSSuccessLatencySum
 	c.DnsFailureLatencySum = conn.DNSFailureLatencySum
 	c.DnsCountByRcode = conn.DNSCountByRcode
+	c.DnsStatsByDomainByQueryType = formatDNSStatsByDomainByQueryType(conn.DNSStatsByDomainByQueryType, domainSet)
 	c.LastTcpEstablished = conn.LastTCPEstablished
 	c.LastTcpClosed = conn.LastTCPClosed
 	return c
 }
 
+// FormatDomains returns the domains of the given domain set, ordered by their index
+func FormatDomains(domainSet map[string]int) []string {
+	domains := make([]string, len(domainSet))
+	for domain, idx := range domainSet {
+		domains[idx] = domain
+	}
+	return domains
+}
+
 var dnsPool = sync.Pool{
 	New: func() interface{} {
 		return new(model.DNSEntry)
@@ -109,7 +120,7 @@ func returnToPool(c *model.Connections) {
 			dnsPool.Put(e)
 		}
 	}
-	telemetryPool.Put(c.Telemetry)
+	telemetryPool.Put(c.ConnTelemetry)
 	connsPool.Put(c)
 }
 
@@ -158,6 +169,35 @@ func formatDirection(d network.ConnectionDirection) model.ConnectionDirection {
 	}
 }
 
+// formatDNSStatsByDomainByQueryType keys the DNS stats of each domain by the index of the domain in the
+// domain set, then by query type
+func formatDNSStatsByDomainByQueryType(stats map[string]map[network.QueryType]network.DNSStats, domainSet map[string]int) map[int32]*model.DNSStatsByQueryType {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	byDomain := make(map[int32]*model.DNSStatsByQueryType, len(stats))
+	for domain, byQueryType := range stats {
+		idx, ok := domainSet[domain]
+		if !ok {
+			idx = len(domainSet)
+			domainSet[domain] = idx
+		}
+
+		ms := &model.DNSStatsByQueryType{DnsStatsByQueryType: make(map[int32]*model.DNSStats, len(byQueryType))}
+		for qtype, s := range byQueryType {
+			ms.DnsStatsByQueryType[int32(qtype)] = &model.DNSStats{
+				DnsTimeouts:          s.Timeouts,
+				DnsSuccessLatencySum: s.SuccessLatencySum,
+				DnsFailureLatencySum: s.FailureLatencySum,
+				DnsCountByRcode:      s.CountByRcode,
+			}
+		}
+		byDomain[int32(idx)] = ms
+	}
+	return byDomain
+}
+
 func formatIPTranslation(ct *network.IPTranslation) *model.IPTranslation {
 	if ct == nil {
 		return nil
@@ -13,15 +13,17 @@ type protoSerializer struct{}
 
 func (protoSerializer) Marshal(conns *network.Connections) ([]byte, error) {
 	agentConns := make([]*model.Connection, len(conns.Conns))
+	domainSet := make(map[string]int)
 
 	for i, conn := range conns.Conns {
-		agentConns[i] = FormatConnection(conn)
+		agentConns[i] = FormatConnection(conn, domainSet)
 	}
 
 	payload := connsPool.Get().(*model.Connections)
 	payload.Conns = agentConns
 	payload.Dns = FormatDNS(conns.DNS)
-	payload.Telemetry = FormatTelemetry(conns.Telemetry)
+	payload.Domains = FormatDomains(domainSet)
+	payload.ConnTelemetry = FormatTelemetry(conns.Telemetry)
 
 	buf, err := proto.Marshal(payload)
 	returnToPool(payload)
@@ -145,6 +145,25 @@ type ConnectionStats struct {
 	DNSSuccessLatencySum   uint64
 	DNSFailureLatencySum   uint64
 	DNSCountByRcode        map[uint32]uint32
+
+	// DNSStatsByDomainByQueryType holds the DNS stats of the connection per domain queried and per query type
+	DNSStatsByDomainByQueryType map[string]map[QueryType]DNSStats
+
+	// RollupCount is the number of connections merged in this one by RollupConnections, 0 if it isn't a rollup
+	RollupCount uint32
+
+	// TLS holds the TLS metadata of the connection, nil when the handshake of its TLS session wasn't captured
+	TLS *TLSInfo
+}
+
+// DNSStats holds the DNS stats of a connection for a single domain and query type
+type DNSStats struct {
+	SuccessfulResponses uint32
+	FailedResponses     uint32
+	Timeouts            uint32
+	SuccessLatencySum   uint64 // Stored in µs
+	FailureLatencySum   uint64
+	CountByRcode        map[uint32]uint32
 }
 
 // IPTranslation can be associated with a connection to show the connection is NAT'd
@@ -234,6 +253,10 @@ func ConnectionSummary(c *ConnectionStats, names map[util.Address][]string) stri
 		humanize.Bytes(c.MonotonicRecvBytes), humanize.Bytes(c.LastRecvBytes),
 	)
 
+	if c.RollupCount > 0 {
+		str += fmt.Sprintf(", %d connections", c.RollupCount)
+	}
+
 	if c.Type == TCP {
 		str += fmt.Sprintf(
 			", %d retransmits (+%d), RTT %s (± %s)",
@@ -243,6 +266,13 @@ func ConnectionSummary(c *ConnectionStats, names map[util.Address][]string) stri
 		)
 	}
 
+	if c.TLS != nil {
+		str += fmt.Sprintf(", %s, cipher suite 0x%04x", TLSVersionString(c.TLS.Version), c.TLS.CipherSuite)
+		if c.TLS.ServerName != "" {
+			str += fmt.Sprintf(", server name %s", c.TLS.ServerName)
+		}
+	}
+
 	return str
 }
 
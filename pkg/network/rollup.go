@@ -0,0 +1,254 @@
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// rollupKeyMaxLen is the length of a connection byte key followed by the direction and the namespace
+const rollupKeyMaxLen = ConnectionByteKeyMaxLen + 5
+
+// RollupState keeps the monotonic counters of the rollups of each client. They are accumulated from
+// the Last* deltas of the connections of the rollups, as the sum of the monotonic counters of the
+// connections would decrease when some of them close.
+type RollupState struct {
+	sync.Mutex
+
+	clientExpiry time.Duration
+	clients      map[string]*rollupClient
+}
+
+type rollupClient struct {
+	lastFetch time.Time
+	counters  map[string]rollupCounters
+}
+
+// rollupCounters are the monotonic counters of a rollup
+type rollupCounters struct {
+	sentBytes      uint64
+	recvBytes      uint64
+	retransmits    uint32
+	tcpEstablished uint32
+	tcpClosed      uint32
+}
+
+// NewRollupState returns a new RollupState
+func NewRollupState(clientExpiry time.Duration) *RollupState {
+	return &RollupState{
+		clientExpiry: clientExpiry,
+		clients:      make(map[string]*rollupClient),
+	}
+}
+
+// Rollup merges the connections of a client with RollupConnections and sets the monotonic counters of
+// the rollups to the sum of their previous values and of the deltas of their connections. The counters
+// of the rollups which have no connection anymore are dropped, like the ones of the expired clients.
+func (rs *RollupState) Rollup(clientID string, now time.Time, conns []ConnectionStats) []ConnectionStats {
+	rs.Lock()
+	defer rs.Unlock()
+
+	for id, c := range rs.clients {
+		if id != clientID && c.lastFetch.Add(rs.clientExpiry).Before(now) {
+			log.Debugf("expiring the rollups of client %s", id)
+			delete(rs.clients, id)
+		}
+	}
+
+	client, found := rs.clients[clientID]
+	if !found {
+		client = &rollupClient{}
+		rs.clients[clientID] = client
+	}
+	client.lastFetch = now
+
+	rollups, keys := rollupConnections(conns)
+	counters := make(map[string]rollupCounters, len(keys))
+	for i := range rollups {
+		rollup := &rollups[i]
+		key := keys[i]
+		if key == "" {
+			continue
+		}
+
+		c := client.counters[key]
+		c.sentBytes += rollup.LastSentBytes
+		c.recvBytes += rollup.LastRecvBytes
+		c.retransmits += rollup.LastRetransmits
+		c.tcpEstablished += rollup.LastTCPEstablished
+		c.tcpClosed += rollup.LastTCPClosed
+		counters[key] = c
+
+		rollup.MonotonicSentBytes = c.sentBytes
+		rollup.MonotonicRecvBytes = c.recvBytes
+		rollup.MonotonicRetransmits = c.retransmits
+		rollup.MonotonicTCPEstablished = c.tcpEstablished
+		rollup.MonotonicTCPClosed = c.tcpClosed
+	}
+	client.counters = counters
+
+	return rollups
+}
+
+// RollupConnections collapses the ephemeral ports of the clients: the connections of a process between
+// the same client IP, server IP and server port, in the same direction, are merged in a rollup whose client
+// port is 0. The rollups sum the counters of their connections, RollupCount being the number of
+// connections they hold, and keep the RTT of the most recently updated one. Use RollupState.Rollup to
+// get monotonic counters that don't decrease when some of the connections close.
+func RollupConnections(conns []ConnectionStats) []ConnectionStats {
+	rollups, _ := rollupConnections(conns)
+	return rollups
+}
+
+// rollupConnections merges the connections into rollups, and returns the key of each rollup, empty
+// for the connections that couldn't be merged
+func rollupConnections(conns []ConnectionStats) ([]ConnectionStats, []string) {
+	var buf [rollupKeyMaxLen]byte
+	var keyBuf [ConnectionByteKeyMaxLen]byte
+
+	rollups := make([]ConnectionStats, 0, len(conns))
+	keys := make([]string, 0, len(conns))
+	indexes := make(map[string]int, len(conns))
+
+	for _, conn := range conns {
+		collapseClientPort(&conn)
+
+		key, err := conn.ByteKey(keyBuf)
+		if err != nil {
+			rollups = append(rollups, conn)
+			keys = append(keys, "")
+			continue
+		}
+		n := copy(buf[:], key)
+		buf[n] = uint8(conn.Direction)
+		buf[n+1] = uint8(conn.NetNS >> 24)
+		buf[n+2] = uint8(conn.NetNS >> 16)
+		buf[n+3] = uint8(conn.NetNS >> 8)
+		buf[n+4] = uint8(conn.NetNS)
+
+		if i, found := indexes[string(buf[:n+5])]; found {
+			mergeConnection(&rollups[i], &conn)
+			continue
+		}
+
+		conn.RollupCount = 1
+		indexes[string(buf[:n+5])] = len(rollups)
+		keys = append(keys, string(buf[:n+5]))
+		rollups = append(rollups, conn)
+	}
+
+	return rollups, keys
+}
+
+// collapseClientPort zeroes the port of the client of the connection, the source of the outgoing
+// connections and the destination of the incoming ones, along with its NAT translation
+func collapseClientPort(conn *ConnectionStats) {
+	switch conn.Direction {
+	case OUTGOING:
+		conn.SPort = 0
+		if conn.IPTranslation != nil {
+			translation := *conn.IPTranslation
+			translation.ReplDstPort = 0
+			conn.IPTranslation = &translation
+		}
+	case INCOMING:
+		conn.DPort = 0
+		if conn.IPTranslation != nil {
+			translation := *conn.IPTranslation
+			translation.ReplSrcPort = 0
+			conn.IPTranslation = &translation
+		}
+	}
+}
+
+// mergeConnection adds the counters of a connection to a rollup
+func mergeConnection(rollup *ConnectionStats, conn *ConnectionStats) {
+	rollup.RollupCount++
+
+	rollup.MonotonicSentBytes += conn.MonotonicSentBytes
+	rollup.LastSentBytes += conn.LastSentBytes
+	rollup.MonotonicRecvBytes += conn.MonotonicRecvBytes
+	rollup.LastRecvBytes += conn.LastRecvBytes
+	rollup.MonotonicRetransmits += conn.MonotonicRetransmits
+	rollup.LastRetransmits += conn.LastRetransmits
+	rollup.MonotonicTCPEstablished += conn.MonotonicTCPEstablished
+	rollup.LastTCPEstablished += conn.LastTCPEstablished
+	rollup.MonotonicTCPClosed += conn.MonotonicTCPClosed
+	rollup.LastTCPClosed += conn.LastTCPClosed
+
+	if conn.LastUpdateEpoch > rollup.LastUpdateEpoch {
+		rollup.LastUpdateEpoch = conn.LastUpdateEpoch
+		rollup.RTT = conn.RTT
+		rollup.RTTVar = conn.RTTVar
+	}
+	if rollup.IPTranslation == nil {
+		rollup.IPTranslation = conn.IPTranslation
+	}
+	if rollup.TLS == nil {
+		rollup.TLS = conn.TLS
+	}
+	rollup.IntraHost = rollup.IntraHost || conn.IntraHost
+
+	rollup.DNSSuccessfulResponses += conn.DNSSuccessfulResponses
+	rollup.DNSFailedResponses += conn.DNSFailedResponses
+	rollup.DNSTimeouts += conn.DNSTimeouts
+	rollup.DNSSuccessLatencySum += conn.DNSSuccessLatencySum
+	rollup.DNSFailureLatencySum += conn.DNSFailureLatencySum
+	rollup.DNSCountByRcode = mergeRcodeCounts(rollup.DNSCountByRcode, conn.DNSCountByRcode)
+
+	if len(conn.DNSStatsByDomainByQueryType) > 0 {
+		merged := make(map[string]map[QueryType]DNSStats, len(rollup.DNSStatsByDomainByQueryType)+len(conn.DNSStatsByDomainByQueryType))
+		for domain, byQueryType := range rollup.DNSStatsByDomainByQueryType {
+			merged[domain] = byQueryType
+		}
+		for domain, byQueryType := range conn.DNSStatsByDomainByQueryType {
+			merged[domain] = mergeDNSStatsByQueryType(merged[domain], byQueryType)
+		}
+		rollup.DNSStatsByDomainByQueryType = merged
+	}
+}
+
+// mergeDNSStatsByQueryType returns the sum of two DNS stats by query type, without modifying them as they may be
+// shared with the state of the connections
+func mergeDNSStatsByQueryType(a, b map[QueryType]DNSStats) map[QueryType]DNSStats {
+	if len(a) == 0 {
+		return b
+	}
+
+	merged := make(map[QueryType]DNSStats, len(a)+len(b))
+	for qtype, stats := range a {
+		merged[qtype] = stats
+	}
+	for qtype, stats := range b {
+		m := merged[qtype]
+		m.SuccessfulResponses += stats.SuccessfulResponses
+		m.FailedResponses += stats.FailedResponses
+		m.Timeouts += stats.Timeouts
+		m.SuccessLatencySum += stats.SuccessLatencySum
+		m.FailureLatencySum += stats.FailureLatencySum
+		m.CountByRcode = mergeRcodeCounts(m.CountByRcode, stats.CountByRcode)
+		merged[qtype] = m
+	}
+	return merged
+}
+
+// mergeRcodeCounts returns the sum of two counts by rcode, without modifying them as they may be shared
+// with the state of the connections
+func mergeRcodeCounts(a, b map[uint32]uint32) map[uint32]uint32 {
+	if len(b) == 0 {
+		return a
+	}
+	if len(a) == 0 {
+		return b
+	}
+
+	merged := make(map[uint32]uint32, len(a)+len(b))
+	for rcode, count := range a {
+		merged[rcode] = count
+	}
+	for rcode, count := range b {
+		merged[rcode] += count
+	}
+	return merged
+}
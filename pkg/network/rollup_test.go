@@ -0,0 +1,183 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollupConnections(t *testing.T) {
+	client := util.AddressFromString("10.0.0.1")
+	server := util.AddressFromString("10.0.0.2")
+
+	outgoing := func(sport uint16, sent uint64, epoch uint64, rtt uint32) ConnectionStats {
+		return ConnectionStats{
+			Pid:                1,
+			Source:             client,
+			Dest:               server,
+			SPort:              sport,
+			DPort:              443,
+			Direction:          OUTGOING,
+			MonotonicSentBytes: sent,
+			LastSentBytes:      sent,
+			LastUpdateEpoch:    epoch,
+			RTT:                rtt,
+			DNSCountByRcode:    map[uint32]uint32{0: 1},
+		}
+	}
+	incoming := ConnectionStats{
+		Pid:           2,
+		Source:        server,
+		Dest:          client,
+		SPort:         8080,
+		DPort:         50000,
+		Direction:     INCOMING,
+		LastRecvBytes: 10,
+	}
+
+	rollups := RollupConnections([]ConnectionStats{
+		outgoing(40000, 100, 1, 10),
+		incoming,
+		outgoing(40001, 200, 3, 30),
+		outgoing(40002, 300, 2, 20),
+	})
+	require.Len(t, rollups, 2)
+
+	out := rollups[0]
+	assert.Equal(t, uint16(0), out.SPort)
+	assert.Equal(t, uint16(443), out.DPort)
+	assert.Equal(t, uint32(3), out.RollupCount)
+	assert.Equal(t, uint64(600), out.MonotonicSentBytes)
+	assert.Equal(t, uint64(600), out.LastSentBytes)
+	assert.Equal(t, uint64(3), out.LastUpdateEpoch)
+	assert.Equal(t, uint32(30), out.RTT)
+	assert.Equal(t, map[uint32]uint32{0: 3}, out.DNSCountByRcode)
+
+	in := rollups[1]
+	assert.Equal(t, uint16(8080), in.SPort)
+	assert.Equal(t, uint16(0), in.DPort)
+	assert.Equal(t, uint32(1), in.RollupCount)
+	assert.Equal(t, uint64(10), in.LastRecvBytes)
+}
+
+func TestRollupConnectionsDNSStatsByDomain(t *testing.T) {
+	client := util.AddressFromString("10.0.0.1")
+	server := util.AddressFromString("10.0.0.2")
+
+	dnsConn := func(sport uint16, stats map[string]map[QueryType]DNSStats) ConnectionStats {
+		return ConnectionStats{
+			Pid:                         1,
+			Source:                      client,
+			Dest:                        server,
+			SPort:                       sport,
+			DPort:                       53,
+			Type:                        UDP,
+			Direction:                   OUTGOING,
+			DNSStatsByDomainByQueryType: stats,
+		}
+	}
+	a := DNSStats{SuccessfulResponses: 1, SuccessLatencySum: 10, CountByRcode: map[uint32]uint32{0: 1}}
+	aaaa := DNSStats{FailedResponses: 1, FailureLatencySum: 20, CountByRcode: map[uint32]uint32{3: 1}}
+
+	first := dnsConn(40000, map[string]map[QueryType]DNSStats{"foo.com": {DNSTypeA: a}})
+	second := dnsConn(40001, map[string]map[QueryType]DNSStats{"foo.com": {DNSTypeA: a, DNSTypeAAAA: aaaa}, "bar.com": {DNSTypeA: a}})
+	rollups := RollupConnections([]ConnectionStats{first, second})
+	require.Len(t, rollups, 1)
+
+	stats := rollups[0].DNSStatsByDomainByQueryType
+	require.Len(t, stats, 2)
+	assert.Equal(t, DNSStats{SuccessfulResponses: 2, SuccessLatencySum: 20, CountByRcode: map[uint32]uint32{0: 2}}, stats["foo.com"][DNSTypeA])
+	assert.Equal(t, aaaa, stats["foo.com"][DNSTypeAAAA])
+	assert.Equal(t, a, stats["bar.com"][DNSTypeA])
+
+	// the stats of the connections are left untouched
+	assert.Len(t, first.DNSStatsByDomainByQueryType["foo.com"], 1)
+	assert.Equal(t, a, first.DNSStatsByDomainByQueryType["foo.com"][DNSTypeA])
+}
+
+func TestRollupConnectionsKeepsServerPorts(t *testing.T) {
+	client := util.AddressFromString("10.0.0.1")
+	server := util.AddressFromString("10.0.0.2")
+
+	conns := []ConnectionStats{
+		{Pid: 1, Source: client, Dest: server, SPort: 40000, DPort: 80, Direction: OUTGOING},
+		{Pid: 1, Source: client, Dest: server, SPort: 40001, DPort: 443, Direction: OUTGOING},
+		{Pid: 1, Source: client, Dest: server, SPort: 40002, DPort: 443, Direction: OUTGOING, Type: UDP},
+		{Pid: 1, Source: client, Dest: server, SPort: 40003, DPort: 443, Direction: OUTGOING, NetNS: 2},
+	}
+
+	rollups := RollupConnections(conns)
+	assert.Len(t, rollups, 4)
+	for _, rollup := range rollups {
+		assert.Equal(t, uint32(1), rollup.RollupCount)
+	}
+}
+
+func TestRollupConnectionsIPTranslation(t *testing.T) {
+	client := util.AddressFromString("10.0.0.1")
+	service := util.AddressFromString("10.96.0.1")
+	translation := &IPTranslation{
+		ReplSrcIP:   util.AddressFromString("10.0.0.2"),
+		ReplDstIP:   client,
+		ReplSrcPort: 8080,
+		ReplDstPort: 40000,
+	}
+
+	conns := []ConnectionStats{
+		{Source: client, Dest: service, SPort: 40000, DPort: 80, Direction: OUTGOING, IPTranslation: translation},
+	}
+
+	rollups := RollupConnections(conns)
+	require.Len(t, rollups, 1)
+	assert.Equal(t, uint16(8080), rollups[0].IPTranslation.ReplSrcPort)
+	assert.Equal(t, uint16(0), rollups[0].IPTranslation.ReplDstPort)
+	// the translation of the connection is left untouched
+	assert.Equal(t, uint16(40000), translation.ReplDstPort)
+}
+
+func TestRollupStateMonotonicCounters(t *testing.T) {
+	client := util.AddressFromString("10.0.0.1")
+	server := util.AddressFromString("10.0.0.2")
+
+	outgoing := func(sport uint16, monotonic, last uint64) ConnectionStats {
+		return ConnectionStats{
+			Pid:                1,
+			Source:             client,
+			Dest:               server,
+			SPort:              sport,
+			DPort:              443,
+			Direction:          OUTGOING,
+			MonotonicSentBytes: monotonic,
+			LastSentBytes:      last,
+		}
+	}
+
+	now := time.Now()
+	state := NewRollupState(time.Minute)
+
+	rollups := state.Rollup("client", now, []ConnectionStats{outgoing(40000, 100, 100), outgoing(40001, 200, 200)})
+	require.Len(t, rollups, 1)
+	assert.Equal(t, uint32(2), rollups[0].RollupCount)
+	assert.Equal(t, uint64(300), rollups[0].MonotonicSentBytes)
+	assert.Equal(t, uint64(300), rollups[0].LastSentBytes)
+
+	// the second connection closed, the monotonic counter of the rollup keeps growing
+	rollups = state.Rollup("client", now.Add(time.Second), []ConnectionStats{outgoing(40000, 150, 50)})
+	require.Len(t, rollups, 1)
+	assert.Equal(t, uint32(1), rollups[0].RollupCount)
+	assert.Equal(t, uint64(350), rollups[0].MonotonicSentBytes)
+	assert.Equal(t, uint64(50), rollups[0].LastSentBytes)
+
+	// the counters are kept by client
+	rollups = state.Rollup("other", now.Add(time.Second), []ConnectionStats{outgoing(40000, 150, 150)})
+	require.Len(t, rollups, 1)
+	assert.Equal(t, uint64(150), rollups[0].MonotonicSentBytes)
+
+	// the rollups without connections and the expired clients are dropped
+	state.Rollup("client", now.Add(2*time.Minute), nil)
+	assert.Len(t, state.clients, 1)
+	assert.Empty(t, state.clients["client"].counters)
+}
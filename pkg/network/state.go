@@ -35,7 +35,7 @@ type State interface {
 		clientID string,
 		latestTime uint64,
 		latestConns []ConnectionStats,
-		dns map[dnsKey]dnsStats,
+		dns map[dnsKey]map[string]map[QueryType]dnsStats,
 	) []ConnectionStats
 
 	// StoreClosedConnection stores a new closed connection
@@ -80,7 +80,7 @@ type client struct {
 
 	closedConnections map[string]ConnectionStats
 	stats             map[string]*stats
-	dnsStats          map[dnsKey]dnsStats
+	dnsStats          map[dnsKey]map[string]map[QueryType]dnsStats
 }
 
 type networkState struct {
@@ -130,7 +130,7 @@ func (ns *networkState) Connections(
 	id string,
 	latestTime uint64,
 	latestConns []ConnectionStats,
-	dnsStats map[dnsKey]dnsStats,
+	dnsStats map[dnsKey]map[string]map[QueryType]dnsStats,
 ) []ConnectionStats {
 	ns.Lock()
 	defer ns.Unlock()
@@ -209,24 +209,43 @@ func (ns *networkState) addDNSStats(id string, conns []ConnectionStats) {
 			continue
 		}
 
-		if dnsStats, ok := ns.clients[id].dnsStats[key]; ok {
-			conn.DNSTimeouts = dnsStats.timeouts
-			conn.DNSSuccessfulResponses = dnsStats.countByRcode[DNSResponseCodeNoError]
-			conn.DNSSuccessLatencySum = dnsStats.successLatencySum
-			conn.DNSFailureLatencySum = dnsStats.failureLatencySum
+		if statsByDomain, ok := ns.clients[id].dnsStats[key]; ok {
 			conn.DNSCountByRcode = make(map[uint32]uint32)
-			var total uint32
-			for rcode, count := range dnsStats.countByRcode {
-				conn.DNSCountByRcode[uint32(rcode)] = count
-				total += count
+			conn.DNSStatsByDomainByQueryType = make(map[string]map[QueryType]DNSStats, len(statsByDomain))
+			for domain, statsByQueryType := range statsByDomain {
+				byQueryType := make(map[QueryType]DNSStats, len(statsByQueryType))
+				for queryType, dnsStats := range statsByQueryType {
+					typeStats := DNSStats{
+						Timeouts:            dnsStats.timeouts,
+						SuccessfulResponses: dnsStats.countByRcode[DNSResponseCodeNoError],
+						SuccessLatencySum:   dnsStats.successLatencySum,
+						FailureLatencySum:   dnsStats.failureLatencySum,
+						CountByRcode:        make(map[uint32]uint32, len(dnsStats.countByRcode)),
+					}
+					var total uint32
+					for rcode, count := range dnsStats.countByRcode {
+						typeStats.CountByRcode[uint32(rcode)] = count
+						conn.DNSCountByRcode[uint32(rcode)] += count
+						total += count
+					}
+					typeStats.FailedResponses = total - typeStats.SuccessfulResponses
+					byQueryType[queryType] = typeStats
+
+					// the connection totals aggregate all the domains and query types
+					conn.DNSTimeouts += typeStats.Timeouts
+					conn.DNSSuccessfulResponses += typeStats.SuccessfulResponses
+					conn.DNSFailedResponses += typeStats.FailedResponses
+					conn.DNSSuccessLatencySum += typeStats.SuccessLatencySum
+					conn.DNSFailureLatencySum += typeStats.FailureLatencySum
+				}
+				conn.DNSStatsByDomainByQueryType[domain] = byQueryType
 			}
-			conn.DNSFailedResponses = total - conn.DNSSuccessfulResponses
 		}
 		seen[key] = struct{}{}
 	}
 
 	// flush the DNS stats
-	ns.clients[id].dnsStats = make(map[dnsKey]dnsStats)
+	ns.clients[id].dnsStats = make(map[dnsKey]map[string]map[QueryType]dnsStats)
 }
 
 // getConnsByKey returns a mapping of byte-key -> connection for easier access + manipulation
@@ -282,23 +301,46 @@ func (ns *networkState) StoreClosedConnection(conn *ConnectionStats) {
 }
 
 // storeDNSStats stores latest DNS stats for all clients
-func (ns *networkState) storeDNSStats(stats map[dnsKey]dnsStats) {
-	for key, dns := range stats {
+func (ns *networkState) storeDNSStats(stats map[dnsKey]map[string]map[QueryType]dnsStats) {
+	for key, statsByDomain := range stats {
 		for _, client := range ns.clients {
-			// If we've seen DNS stats for this key already, let's combine the two
-			if prev, ok := client.dnsStats[key]; ok {
-				prev.timeouts += dns.timeouts
-				prev.successLatencySum += dns.successLatencySum
-				prev.failureLatencySum += dns.failureLatencySum
-				for rcode, count := range dns.countByRcode {
-					prev.countByRcode[rcode] += count
+			prevByDomain, ok := client.dnsStats[key]
+			if !ok {
+				if len(client.dnsStats) >= ns.maxDNSStats {
+					ns.telemetry.dnsStatsDropped++
+					continue
+				}
+				prevByDomain = make(map[string]map[QueryType]dnsStats, len(statsByDomain))
+				client.dnsStats[key] = prevByDomain
+			}
+
+			for domain, statsByQueryType := range statsByDomain {
+				prevByQueryType, ok := prevByDomain[domain]
+				if !ok {
+					prevByQueryType = make(map[QueryType]dnsStats, len(statsByQueryType))
+					prevByDomain[domain] = prevByQueryType
+				}
+
+				for queryType, dns := range statsByQueryType {
+					// If we've seen DNS stats for this key, domain and query type already, let's combine the two
+					if prev, ok := prevByQueryType[queryType]; ok {
+						prev.timeouts += dns.timeouts
+						prev.successLatencySum += dns.successLatencySum
+						prev.failureLatencySum += dns.failureLatencySum
+						for rcode, count := range dns.countByRcode {
+							prev.countByRcode[rcode] += count
+						}
+						prevByQueryType[queryType] = prev
+					} else {
+						// copy the rcode counts as the stats are shared between all the clients
+						countByRcode := make(map[uint8]uint32, len(dns.countByRcode))
+						for rcode, count := range dns.countByRcode {
+							countByRcode[rcode] = count
+						}
+						dns.countByRcode = countByRcode
+						prevByQueryType[queryType] = dns
+					}
 				}
-				client.dnsStats[key] = prev
-			} else if len(client.dnsStats) >= ns.maxDNSStats {
-				ns.telemetry.dnsStatsDropped++
-				continue
-			} else {
-				client.dnsStats[key] = dns
 			}
 		}
 	}
@@ -314,7 +356,7 @@ func (ns *networkState) newClient(clientID string) (*client, bool) {
 		lastFetch:         time.Now(),
 		stats:             map[string]*stats{},
 		closedConnections: map[string]ConnectionStats{},
-		dnsStats:          map[dnsKey]dnsStats{},
+		dnsStats:          map[dnsKey]map[string]map[QueryType]dnsStats{},
 	}
 	ns.clients[clientID] = c
 	return c, false
@@ -1146,11 +1146,11 @@ func TestDNSStatsWithMultipleClients(t *testing.T) {
 
 	dKey := dnsKey{clientIP: c.Source, clientPort: c.SPort, serverIP: c.Dest, protocol: c.Type}
 
-	getStats := func() map[dnsKey]dnsStats {
-		stats := make(map[dnsKey]dnsStats)
+	getStats := func() map[dnsKey]map[string]map[QueryType]dnsStats {
+		stats := make(map[dnsKey]map[string]map[QueryType]dnsStats)
 		countByRcode := make(map[uint8]uint32)
 		countByRcode[uint8(DNSResponseCodeNoError)] = 1
-		stats[dKey] = dnsStats{countByRcode: countByRcode}
+		stats[dKey] = map[string]map[QueryType]dnsStats{"foo.com": {DNSTypeA: {countByRcode: countByRcode}}}
 		return stats
 	}
 
@@ -1194,10 +1194,10 @@ func TestDNSStatsPIDCollisions(t *testing.T) {
 	}
 
 	dKey := dnsKey{clientIP: c.Source, clientPort: c.SPort, serverIP: c.Dest, protocol: c.Type}
-	stats := make(map[dnsKey]dnsStats)
+	stats := make(map[dnsKey]map[string]map[QueryType]dnsStats)
 	countByRcode := make(map[uint8]uint32)
 	countByRcode[DNSResponseCodeNoError] = 1
-	stats[dKey] = dnsStats{countByRcode: countByRcode}
+	stats[dKey] = map[string]map[QueryType]dnsStats{"foo.com": {DNSTypeA: {countByRcode: countByRcode}}}
 
 	client := "client"
 	state := newDefaultState()
@@ -1217,6 +1217,81 @@ func TestDNSStatsPIDCollisions(t *testing.T) {
 	assert.Equal(t, int64(1), state.(*networkState).telemetry.dnsPidCollisions)
 }
 
+func TestDNSStatsByDomainByQueryType(t *testing.T) {
+	c := ConnectionStats{
+		Pid:    123,
+		Type:   UDP,
+		Family: AFINET,
+		Source: util.AddressFromString("10.0.0.1"),
+		Dest:   util.AddressFromString("8.8.8.8"),
+		SPort:  1000,
+		DPort:  53,
+	}
+
+	dKey := dnsKey{clientIP: c.Source, clientPort: c.SPort, serverIP: c.Dest, protocol: c.Type}
+	stats := map[dnsKey]map[string]map[QueryType]dnsStats{
+		dKey: {
+			"foo.com": {
+				DNSTypeA: {
+					successLatencySum: 100,
+					countByRcode:      map[uint8]uint32{DNSResponseCodeNoError: 2},
+				},
+				DNSTypeAAAA: {
+					failureLatencySum: 50,
+					timeouts:          1,
+					countByRcode:      map[uint8]uint32{DNSResponseCodeNoError: 1, 3: 1},
+				},
+			},
+			"bar.com": {
+				DNSTypeA: {
+					successLatencySum: 20,
+					countByRcode:      map[uint8]uint32{DNSResponseCodeNoError: 1},
+				},
+			},
+		},
+	}
+
+	client := "client"
+	state := newDefaultState()
+
+	// Register the client
+	assert.Len(t, state.Connections(client, latestEpochTime(), nil, nil), 0)
+
+	c.LastUpdateEpoch = latestEpochTime()
+	conns := state.Connections(client, latestEpochTime(), []ConnectionStats{c}, stats)
+	require.Len(t, conns, 1)
+
+	// the connection totals aggregate all the domains and query types
+	assert.EqualValues(t, 4, conns[0].DNSSuccessfulResponses)
+	assert.EqualValues(t, 1, conns[0].DNSFailedResponses)
+	assert.EqualValues(t, 1, conns[0].DNSTimeouts)
+	assert.EqualValues(t, 120, conns[0].DNSSuccessLatencySum)
+	assert.EqualValues(t, 50, conns[0].DNSFailureLatencySum)
+	assert.Equal(t, map[uint32]uint32{DNSResponseCodeNoError: 4, 3: 1}, conns[0].DNSCountByRcode)
+
+	require.Len(t, conns[0].DNSStatsByDomainByQueryType, 2)
+	require.Len(t, conns[0].DNSStatsByDomainByQueryType["foo.com"], 2)
+	assert.Equal(t, DNSStats{
+		SuccessfulResponses: 2,
+		SuccessLatencySum:   100,
+		CountByRcode:        map[uint32]uint32{DNSResponseCodeNoError: 2},
+	}, conns[0].DNSStatsByDomainByQueryType["foo.com"][DNSTypeA])
+	assert.Equal(t, DNSStats{
+		SuccessfulResponses: 1,
+		FailedResponses:     1,
+		Timeouts:            1,
+		FailureLatencySum:   50,
+		CountByRcode:        map[uint32]uint32{DNSResponseCodeNoError: 1, 3: 1},
+	}, conns[0].DNSStatsByDomainByQueryType["foo.com"][DNSTypeAAAA])
+	assert.Equal(t, map[QueryType]DNSStats{
+		DNSTypeA: {
+			SuccessfulResponses: 1,
+			SuccessLatencySum:   20,
+			CountByRcode:        map[uint32]uint32{DNSResponseCodeNoError: 1},
+		},
+	}, conns[0].DNSStatsByDomainByQueryType["bar.com"])
+}
+
 func generateRandConnections(n int) []ConnectionStats {
 	cs := make([]ConnectionStats, 0, n)
 	for i := 0; i < n; i++ {
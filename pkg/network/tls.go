@@ -0,0 +1,203 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TLSInfo holds the TLS metadata of a connection, captured from the handshake of its TLS session
+type TLSInfo struct {
+	// ServerName is the server name indication sent by the client, empty if the client sent none
+	ServerName string
+	// Version is the TLS version negotiated by the server, 0 if the ServerHello wasn't captured
+	Version uint16
+	// CipherSuite is the cipher suite selected by the server, 0 if the ServerHello wasn't captured
+	CipherSuite uint16
+}
+
+// TLSVersionString returns the name of a TLS version
+func TLSVersionString(version uint16) string {
+	switch version {
+	case 0x0300:
+		return "SSL 3.0"
+	case 0x0301:
+		return "TLS 1.0"
+	case 0x0302:
+		return "TLS 1.1"
+	case 0x0303:
+		return "TLS 1.2"
+	case 0x0304:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+const (
+	tlsRecordTypeHandshake        = 0x16
+	tlsHandshakeTypeClientHello   = 1
+	tlsHandshakeTypeServerHello   = 2
+	tlsExtensionServerName        = 0
+	tlsExtensionSupportedVersions = 43
+	tlsServerNameTypeHostName     = 0
+)
+
+var (
+	errNotTLSHello       = errors.New("the payload does not start with a TLS hello")
+	errTLSHelloTruncated = errors.New("the TLS hello is truncated")
+)
+
+// tlsHello holds the fields of a ClientHello or of a ServerHello
+type tlsHello struct {
+	fromServer  bool
+	serverName  string
+	version     uint16
+	cipherSuite uint16
+}
+
+// parseTLSHello parses the ClientHello or the ServerHello starting a TCP payload. A hello can span several TCP
+// segments, only the extensions found in the first one are parsed.
+func parseTLSHello(payload []byte) (tlsHello, error) {
+	var hello tlsHello
+
+	r := tlsReader(payload)
+	if recordType, ok := r.uint8(); !ok || recordType != tlsRecordTypeHandshake {
+		return hello, errNotTLSHello
+	}
+	if _, ok := r.uint16(); !ok {
+		return hello, errTLSHelloTruncated
+	}
+	recordLen, ok := r.uint16()
+	if !ok {
+		return hello, errTLSHelloTruncated
+	}
+	if len(r) > int(recordLen) {
+		r = r[:recordLen]
+	}
+
+	handshakeType, ok := r.uint8()
+	if !ok {
+		return hello, errTLSHelloTruncated
+	}
+	if handshakeType != tlsHandshakeTypeClientHello && handshakeType != tlsHandshakeTypeServerHello {
+		return hello, errNotTLSHello
+	}
+	hello.fromServer = handshakeType == tlsHandshakeTypeServerHello
+
+	// the handshake length, the legacy version and the random
+	if !r.skip(3) {
+		return hello, errTLSHelloTruncated
+	}
+	if hello.version, ok = r.uint16(); !ok {
+		return hello, errTLSHelloTruncated
+	}
+	if !r.skip(32) || !r.skipVector8() {
+		return hello, errTLSHelloTruncated
+	}
+
+	if hello.fromServer {
+		if hello.cipherSuite, ok = r.uint16(); !ok {
+			return hello, errTLSHelloTruncated
+		}
+		// the compression method
+		if !r.skip(1) {
+			return hello, errTLSHelloTruncated
+		}
+	} else if !r.skipVector16() || !r.skipVector8() {
+		// the cipher suites and the compression methods offered
+		return hello, errTLSHelloTruncated
+	}
+
+	// the extensions are optional, and may be cut by the end of the segment
+	extensions, _ := r.vector16()
+	for len(extensions) > 0 {
+		extensionType, ok := extensions.uint16()
+		if !ok {
+			break
+		}
+		data, ok := extensions.vector16()
+		if !ok {
+			break
+		}
+
+		switch {
+		case extensionType == tlsExtensionServerName && !hello.fromServer:
+			hello.serverName = parseServerName(data)
+		case extensionType == tlsExtensionSupportedVersions && hello.fromServer:
+			// the version negotiated by TLS 1.3, the legacy version field being set to TLS 1.2
+			if version, ok := data.uint16(); ok {
+				hello.version = version
+			}
+		}
+	}
+
+	return hello, nil
+}
+
+// parseServerName returns the host name of a server_name extension
+func parseServerName(data tlsReader) string {
+	names, _ := data.vector16()
+	for len(names) > 0 {
+		nameType, ok := names.uint8()
+		if !ok {
+			return ""
+		}
+		name, ok := names.vector16()
+		if !ok {
+			return ""
+		}
+		if nameType == tlsServerNameTypeHostName {
+			return string(name)
+		}
+	}
+	return ""
+}
+
+// tlsReader reads the big-endian fields of a TLS message, advancing over them
+type tlsReader []byte
+
+func (r *tlsReader) skip(n int) bool {
+	if len(*r) < n {
+		return false
+	}
+	*r = (*r)[n:]
+	return true
+}
+
+func (r *tlsReader) uint8() (uint8, bool) {
+	if len(*r) < 1 {
+		return 0, false
+	}
+	v := (*r)[0]
+	*r = (*r)[1:]
+	return v, true
+}
+
+func (r *tlsReader) uint16() (uint16, bool) {
+	if len(*r) < 2 {
+		return 0, false
+	}
+	v := uint16((*r)[0])<<8 | uint16((*r)[1])
+	*r = (*r)[2:]
+	return v, true
+}
+
+// vector16 reads a vector prefixed by its 16 bits length
+func (r *tlsReader) vector16() (tlsReader, bool) {
+	n, ok := r.uint16()
+	if !ok || len(*r) < int(n) {
+		return nil, false
+	}
+	v := (*r)[:n]
+	*r = (*r)[n:]
+	return v, true
+}
+
+func (r *tlsReader) skipVector8() bool {
+	n, ok := r.uint8()
+	return ok && r.skip(int(n))
+}
+
+func (r *tlsReader) skipVector16() bool {
+	_, ok := r.vector16()
+	return ok
+}
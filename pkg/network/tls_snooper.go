@@ -0,0 +1,351 @@
+// +build linux_bpf
+
+package network
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/bpf"
+)
+
+const (
+	tlsSessionTTL              = 10 * time.Minute
+	tlsSessionExpirationPeriod = 1 * time.Minute
+	maxTLSSessions             = 65536
+)
+
+// tlsHandshakeFilter is a classic BPF filter accepting the IPv4 and IPv6 TCP segments whose payload starts
+// with a TLS handshake record, on any port. The IPv6 segments are only accepted without extension headers.
+var tlsHandshakeFilter = []bpf.Instruction{
+	bpf.LoadAbsolute{Off: 12, Size: 2}, // ethertype
+	bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x0800, SkipFalse: 12},
+	// IPv4
+	bpf.LoadAbsolute{Off: 23, Size: 1}, // protocol
+	bpf.JumpIf{Cond: bpf.JumpEqual, Val: syscall.IPPROTO_TCP, SkipFalse: 20},
+	bpf.LoadAbsolute{Off: 20, Size: 2}, // fragment offset
+	bpf.JumpIf{Cond: bpf.JumpBitsSet, Val: 0x1fff, SkipTrue: 18},
+	bpf.LoadMemShift{Off: 14},          // X = IPv4 header length
+	bpf.LoadIndirect{Off: 26, Size: 1}, // TCP data offset
+	bpf.ALUOpConstant{Op: bpf.ALUOpShiftRight, Val: 2},
+	bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: 0x3c},
+	bpf.ALUOpX{Op: bpf.ALUOpAdd}, // A = IPv4 header length + TCP header length
+	bpf.TAX{},
+	bpf.LoadIndirect{Off: 14, Size: 2}, // record type and major version
+	bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x1603, SkipTrue: 9, SkipFalse: 10},
+	// IPv6
+	bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x86dd, SkipFalse: 9},
+	bpf.LoadAbsolute{Off: 20, Size: 1}, // next header
+	bpf.JumpIf{Cond: bpf.JumpEqual, Val: syscall.IPPROTO_TCP, SkipFalse: 7},
+	bpf.LoadAbsolute{Off: 66, Size: 1}, // TCP data offset
+	bpf.ALUOpConstant{Op: bpf.ALUOpShiftRight, Val: 2},
+	bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: 0x3c},
+	bpf.TAX{},                          // X = TCP header length
+	bpf.LoadIndirect{Off: 54, Size: 2}, // record type and major version
+	bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x1603, SkipFalse: 1},
+	bpf.RetConstant{Val: 0xffff},
+	bpf.RetConstant{Val: 0},
+}
+
+// tlsKey identifies the TCP connection of a TLS session, from the point of view of its client
+type tlsKey struct {
+	clientIP   util.Address
+	serverIP   util.Address
+	clientPort uint16
+	serverPort uint16
+}
+
+type tlsSession struct {
+	info     TLSInfo
+	lastSeen time.Time
+}
+
+// TLSSnooper captures the ClientHello and the ServerHello of the TLS sessions of the TCP connections, from a
+// RAW_SOCKET attached to a classic BPF filter like the DNS snooper, and keeps the TLS metadata of each connection
+type TLSSnooper struct {
+	source *packetSource
+	parser *tlsParser
+	exit   chan struct{}
+	wg     sync.WaitGroup
+
+	mux      sync.Mutex
+	sessions map[tlsKey]*tlsSession
+
+	// telemetry
+	hellos          int64
+	decodingErrors  int64
+	droppedSessions int64
+	expiredSessions int64
+}
+
+// NewTLSSnooper returns a new TLSSnooper
+func NewTLSSnooper(rootPath string) (*TLSSnooper, error) {
+	var (
+		packetSrc *packetSource
+		srcErr    error
+	)
+
+	// Create the RAW_SOCKET inside the root network namespace
+	nsErr := util.WithRootNS(rootPath, func() {
+		packetSrc, srcErr = newTLSPacketSource()
+	})
+	if nsErr != nil {
+		return nil, nsErr
+	}
+	if srcErr != nil {
+		return nil, srcErr
+	}
+
+	snooper := newTLSSnooper(packetSrc)
+
+	// Start consuming packets
+	snooper.wg.Add(1)
+	go func() {
+		snooper.pollPackets()
+		snooper.wg.Done()
+	}()
+
+	// Start expiring the sessions
+	snooper.wg.Add(1)
+	go func() {
+		snooper.expireSessions()
+		snooper.wg.Done()
+	}()
+
+	return snooper, nil
+}
+
+func newTLSSnooper(source *packetSource) *TLSSnooper {
+	return &TLSSnooper{
+		source:   source,
+		parser:   newTLSParser(),
+		exit:     make(chan struct{}),
+		sessions: make(map[tlsKey]*tlsSession),
+	}
+}
+
+// Decorate sets the TLS metadata of the TCP connections whose TLS handshake was captured
+func (s *TLSSnooper) Decorate(connections []ConnectionStats, now time.Time) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for i := range connections {
+		conn := &connections[i]
+		if conn.Type != TCP {
+			continue
+		}
+
+		session, found := s.sessions[tlsKey{clientIP: conn.Source, serverIP: conn.Dest, clientPort: conn.SPort, serverPort: conn.DPort}]
+		if !found {
+			// the local end of the incoming connections is the server
+			session, found = s.sessions[tlsKey{clientIP: conn.Dest, serverIP: conn.Source, clientPort: conn.DPort, serverPort: conn.SPort}]
+		}
+		if !found {
+			continue
+		}
+
+		// the sessions are kept as long as their connections are reported
+		session.lastSeen = now
+		info := session.info
+		conn.TLS = &info
+	}
+}
+
+// GetStats returns the telemetry of the snooper
+func (s *TLSSnooper) GetStats() map[string]int64 {
+	s.mux.Lock()
+	size := len(s.sessions)
+	s.mux.Unlock()
+
+	return map[string]int64{
+		"sessions":         int64(size),
+		"hellos":           atomic.LoadInt64(&s.hellos),
+		"decoding_errors":  atomic.LoadInt64(&s.decodingErrors),
+		"dropped_sessions": atomic.LoadInt64(&s.droppedSessions),
+		"expired_sessions": atomic.LoadInt64(&s.expiredSessions),
+	}
+}
+
+// Close terminates the TLS snooper as well as the underlying socket
+func (s *TLSSnooper) Close() {
+	close(s.exit)
+	s.wg.Wait()
+	s.source.Close()
+}
+
+// processPacket records the TLS metadata of the hello of a captured packet. The packet data can't be referenced
+// after this method call since the underlying memory content gets invalidated by `afpacket`.
+func (s *TLSSnooper) processPacket(data []byte, ts time.Time) {
+	key, hello, err := s.parser.Parse(data)
+	if err != nil {
+		if err != errNotTLSHello {
+			atomic.AddInt64(&s.decodingErrors, 1)
+			log.Tracef("error decoding TLS hello: %v", err)
+		}
+		return
+	}
+	atomic.AddInt64(&s.hellos, 1)
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	session, found := s.sessions[key]
+	if !found {
+		if len(s.sessions) >= maxTLSSessions {
+			atomic.AddInt64(&s.droppedSessions, 1)
+			return
+		}
+		session = &tlsSession{}
+		s.sessions[key] = session
+	}
+	session.lastSeen = ts
+
+	if hello.fromServer {
+		session.info.Version = hello.version
+		session.info.CipherSuite = hello.cipherSuite
+		return
+	}
+
+	// a new ClientHello on the same connection starts a new session
+	session.info = TLSInfo{ServerName: hello.serverName}
+}
+
+func (s *TLSSnooper) pollPackets() {
+	for {
+		data, captureInfo, err := s.source.ZeroCopyReadPacketData()
+
+		// Properly synchronizes termination process
+		select {
+		case <-s.exit:
+			return
+		default:
+		}
+
+		if err == nil {
+			s.processPacket(data, captureInfo.Timestamp)
+			continue
+		}
+
+		// Immediately retry for EAGAIN
+		if err == syscall.EAGAIN {
+			continue
+		}
+
+		// Sleep briefly and try again
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (s *TLSSnooper) expireSessions() {
+	ticker := time.NewTicker(tlsSessionExpirationPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			s.expire(now)
+		case <-s.exit:
+			return
+		}
+	}
+}
+
+// expire drops the sessions neither captured nor reported with their connection since tlsSessionTTL
+func (s *TLSSnooper) expire(now time.Time) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for key, session := range s.sessions {
+		if now.Sub(session.lastSeen) > tlsSessionTTL {
+			delete(s.sessions, key)
+			atomic.AddInt64(&s.expiredSessions, 1)
+		}
+	}
+}
+
+func newTLSPacketSource() (*packetSource, error) {
+	rawSocket, err := newRawSocket()
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := bpf.Assemble(tlsHandshakeFilter)
+	if err != nil {
+		rawSocket.Close()
+		return nil, fmt.Errorf("error assembling the TLS handshake filter: %s", err)
+	}
+
+	if err := rawSocket.SetBPF(filter); err != nil {
+		rawSocket.Close()
+		return nil, fmt.Errorf("error attaching filter to socket: %s", err)
+	}
+
+	return &packetSource{TPacket: rawSocket}, nil
+}
+
+// tlsParser decodes the TCP segments captured by tlsHandshakeFilter
+type tlsParser struct {
+	decoder     *gopacket.DecodingLayerParser
+	layers      []gopacket.LayerType
+	ipv4Payload *layers.IPv4
+	ipv6Payload *layers.IPv6
+	tcpPayload  *layers.TCP
+}
+
+func newTLSParser() *tlsParser {
+	ipv4Payload := &layers.IPv4{}
+	ipv6Payload := &layers.IPv6{}
+	tcpPayload := &layers.TCP{}
+
+	decoder := gopacket.NewDecodingLayerParser(layers.LayerTypeEthernet, &layers.Ethernet{}, ipv4Payload, ipv6Payload, tcpPayload)
+	// the TLS records are parsed from the payload of the TCP layer
+	decoder.IgnoreUnsupported = true
+
+	return &tlsParser{
+		decoder:     decoder,
+		ipv4Payload: ipv4Payload,
+		ipv6Payload: ipv6Payload,
+		tcpPayload:  tcpPayload,
+	}
+}
+
+// Parse returns the hello of a TCP segment and the key of its connection
+func (p *tlsParser) Parse(data []byte) (tlsKey, tlsHello, error) {
+	var key tlsKey
+
+	if err := p.decoder.DecodeLayers(data, &p.layers); err != nil {
+		return key, tlsHello{}, err
+	}
+	if len(p.layers) == 0 || p.layers[len(p.layers)-1] != layers.LayerTypeTCP {
+		return key, tlsHello{}, errNotTLSHello
+	}
+
+	hello, err := parseTLSHello(p.tcpPayload.Payload)
+	if err != nil {
+		return key, hello, err
+	}
+
+	var srcIP, dstIP util.Address
+	switch p.layers[len(p.layers)-2] {
+	case layers.LayerTypeIPv4:
+		srcIP, dstIP = util.AddressFromNetIP(p.ipv4Payload.SrcIP), util.AddressFromNetIP(p.ipv4Payload.DstIP)
+	case layers.LayerTypeIPv6:
+		srcIP, dstIP = util.AddressFromNetIP(p.ipv6Payload.SrcIP), util.AddressFromNetIP(p.ipv6Payload.DstIP)
+	default:
+		return key, hello, errNotTLSHello
+	}
+
+	if hello.fromServer {
+		key = tlsKey{clientIP: dstIP, serverIP: srcIP, clientPort: uint16(p.tcpPayload.DstPort), serverPort: uint16(p.tcpPayload.SrcPort)}
+	} else {
+		key = tlsKey{clientIP: srcIP, serverIP: dstIP, clientPort: uint16(p.tcpPayload.SrcPort), serverPort: uint16(p.tcpPayload.DstPort)}
+	}
+	return key, hello, nil
+}
@@ -0,0 +1,112 @@
+// +build linux_bpf
+
+package network
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/bpf"
+)
+
+// tcpPacket returns an ethernet frame carrying a TCP segment with the given payload
+func tcpPacket(t *testing.T, srcIP string, srcPort uint16, dstIP string, dstPort uint16, payload []byte) []byte {
+	tcp := &layers.TCP{
+		SrcPort: layers.TCPPort(srcPort),
+		DstPort: layers.TCPPort(dstPort),
+		PSH:     true,
+		ACK:     true,
+		Window:  512,
+		Options: []layers.TCPOption{{OptionType: layers.TCPOptionKindMSS, OptionLength: 4, OptionData: []byte{5, 180}}},
+	}
+
+	var ip gopacket.NetworkLayer
+	ethernetType := layers.EthernetTypeIPv4
+	if src := net.ParseIP(srcIP); src.To4() != nil {
+		ip = &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: src, DstIP: net.ParseIP(dstIP)}
+	} else {
+		ethernetType = layers.EthernetTypeIPv6
+		ip = &layers.IPv6{Version: 6, HopLimit: 64, NextHeader: layers.IPProtocolTCP, SrcIP: src, DstIP: net.ParseIP(dstIP)}
+	}
+	require.NoError(t, tcp.SetNetworkLayerForChecksum(ip))
+
+	ethernet := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 1, 2, 3, 4, 5},
+		DstMAC:       net.HardwareAddr{0, 1, 2, 3, 4, 6},
+		EthernetType: ethernetType,
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	require.NoError(t, gopacket.SerializeLayers(buf, opts, ethernet, ip.(gopacket.SerializableLayer), tcp, gopacket.Payload(payload)))
+	return buf.Bytes()
+}
+
+func TestTLSHandshakeFilter(t *testing.T) {
+	filter, err := bpf.Assemble(tlsHandshakeFilter)
+	require.NoError(t, err)
+	vm, err := bpf.NewVM(tlsHandshakeFilter)
+	require.NoError(t, err)
+	assert.NotEmpty(t, filter)
+
+	hello := clientHello(t, "example.com")
+	for _, tc := range []struct {
+		packet   []byte
+		accepted bool
+	}{
+		{tcpPacket(t, "10.0.0.1", 40000, "10.0.0.2", 443, hello), true},
+		{tcpPacket(t, "10.0.0.2", 8443, "10.0.0.1", 40000, serverHello(0x0303, 0xc02f, 0)), true},
+		{tcpPacket(t, "fd00::1", 40000, "fd00::2", 443, hello), true},
+		{tcpPacket(t, "10.0.0.1", 40000, "10.0.0.2", 80, []byte("GET / HTTP/1.1\r\n")), false},
+		{tcpPacket(t, "fd00::1", 40000, "fd00::2", 443, []byte{0x17, 3, 3, 0, 0}), false},
+		{tcpPacket(t, "10.0.0.1", 40000, "10.0.0.2", 443, nil), false},
+	} {
+		n, err := vm.Run(tc.packet)
+		require.NoError(t, err)
+		assert.Equal(t, tc.accepted, n > 0)
+	}
+}
+
+func TestTLSSnooperSessions(t *testing.T) {
+	snooper := newTLSSnooper(nil)
+	now := time.Now()
+
+	snooper.processPacket(tcpPacket(t, "10.0.0.1", 40000, "10.0.0.2", 443, clientHello(t, "example.com")), now)
+	snooper.processPacket(tcpPacket(t, "10.0.0.2", 443, "10.0.0.1", 40000, serverHello(0x0303, 0x1301, 0x0304)), now)
+	// a session whose ServerHello wasn't captured
+	snooper.processPacket(tcpPacket(t, "fd00::1", 40001, "fd00::2", 8443, clientHello(t, "datadoghq.com")), now)
+	snooper.processPacket(tcpPacket(t, "10.0.0.1", 40002, "10.0.0.2", 80, []byte("GET / HTTP/1.1\r\n")), now)
+
+	conns := []ConnectionStats{
+		{Source: util.AddressFromString("10.0.0.1"), SPort: 40000, Dest: util.AddressFromString("10.0.0.2"), DPort: 443, Type: TCP, Direction: OUTGOING},
+		// the same connection seen from the server
+		{Source: util.AddressFromString("10.0.0.2"), SPort: 443, Dest: util.AddressFromString("10.0.0.1"), DPort: 40000, Type: TCP, Direction: INCOMING},
+		{Source: util.AddressFromString("fd00::1"), SPort: 40001, Dest: util.AddressFromString("fd00::2"), DPort: 8443, Type: TCP, Direction: OUTGOING},
+		{Source: util.AddressFromString("10.0.0.1"), SPort: 40002, Dest: util.AddressFromString("10.0.0.2"), DPort: 80, Type: TCP, Direction: OUTGOING},
+	}
+	snooper.Decorate(conns, now.Add(tlsSessionTTL))
+
+	expected := &TLSInfo{ServerName: "example.com", Version: 0x0304, CipherSuite: 0x1301}
+	assert.Equal(t, expected, conns[0].TLS)
+	assert.Equal(t, expected, conns[1].TLS)
+	assert.Equal(t, &TLSInfo{ServerName: "datadoghq.com"}, conns[2].TLS)
+	assert.Nil(t, conns[3].TLS)
+
+	stats := snooper.GetStats()
+	assert.Equal(t, int64(3), stats["hellos"])
+	assert.Equal(t, int64(2), stats["sessions"])
+
+	// the sessions reported with their connections are kept
+	snooper.expire(now.Add(tlsSessionTTL + time.Second))
+	assert.Equal(t, int64(2), snooper.GetStats()["sessions"])
+
+	snooper.expire(now.Add(2*tlsSessionTTL + time.Second))
+	assert.Equal(t, int64(0), snooper.GetStats()["sessions"])
+	assert.Equal(t, int64(2), snooper.GetStats()["expired_sessions"])
+}
@@ -0,0 +1,108 @@
+package network
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clientHello returns the ClientHello record sent by a crypto/tls client
+func clientHello(t *testing.T, serverName string) []byte {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	go func() {
+		// the handshake fails once the server side of the pipe is closed, before any certificate is verified
+		config := &tls.Config{ServerName: serverName, InsecureSkipVerify: true} //nolint:gosec
+		tls.Client(client, config).Handshake()                                  //nolint:errcheck
+		client.Close()
+	}()
+
+	record := make([]byte, 5)
+	_, err := io.ReadFull(server, record)
+	require.NoError(t, err)
+
+	body := make([]byte, binary.BigEndian.Uint16(record[3:]))
+	_, err = io.ReadFull(server, body)
+	require.NoError(t, err)
+	return append(record, body...)
+}
+
+// serverHello returns a ServerHello record, with a supported_versions extension unless supportedVersion is 0
+func serverHello(version uint16, cipherSuite uint16, supportedVersion uint16) []byte {
+	body := []byte{byte(version >> 8), byte(version)}
+	body = append(body, make([]byte, 32)...) // random
+	body = append(body, 0)                   // session id
+	body = append(body, byte(cipherSuite>>8), byte(cipherSuite), 0)
+
+	var extensions []byte
+	if supportedVersion != 0 {
+		extensions = []byte{0, tlsExtensionSupportedVersions, 0, 2, byte(supportedVersion >> 8), byte(supportedVersion)}
+	}
+	body = append(body, byte(len(extensions)>>8), byte(len(extensions)))
+	body = append(body, extensions...)
+
+	handshake := append([]byte{tlsHandshakeTypeServerHello, 0, byte(len(body) >> 8), byte(len(body))}, body...)
+	return append([]byte{tlsRecordTypeHandshake, 3, 3, byte(len(handshake) >> 8), byte(len(handshake))}, handshake...)
+}
+
+func TestParseTLSClientHello(t *testing.T) {
+	hello, err := parseTLSHello(clientHello(t, "example.com"))
+	require.NoError(t, err)
+	assert.False(t, hello.fromServer)
+	assert.Equal(t, "example.com", hello.serverName)
+
+	hello, err = parseTLSHello(clientHello(t, ""))
+	require.NoError(t, err)
+	assert.Empty(t, hello.serverName)
+}
+
+func TestParseTLSServerHello(t *testing.T) {
+	hello, err := parseTLSHello(serverHello(0x0303, 0xc02f, 0))
+	require.NoError(t, err)
+	assert.True(t, hello.fromServer)
+	assert.Equal(t, uint16(0x0303), hello.version)
+	assert.Equal(t, uint16(0xc02f), hello.cipherSuite)
+
+	// TLS 1.3 is negotiated with the supported_versions extension
+	hello, err = parseTLSHello(serverHello(0x0303, 0x1301, 0x0304))
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x0304), hello.version)
+	assert.Equal(t, uint16(0x1301), hello.cipherSuite)
+}
+
+func TestParseTLSHelloTruncated(t *testing.T) {
+	record := clientHello(t, "example.com")
+
+	// the hello is cut in the cipher suites
+	_, err := parseTLSHello(record[:50])
+	assert.Equal(t, errTLSHelloTruncated, err)
+
+	// the extensions are cut by the end of the segment
+	hello, err := parseTLSHello(record[:len(record)-1])
+	require.NoError(t, err)
+	assert.False(t, hello.fromServer)
+}
+
+func TestParseTLSHelloInvalid(t *testing.T) {
+	_, err := parseTLSHello([]byte("GET / HTTP/1.1\r\n"))
+	assert.Equal(t, errNotTLSHello, err)
+
+	// a Certificate handshake message
+	_, err = parseTLSHello([]byte{tlsRecordTypeHandshake, 3, 3, 0, 4, 11, 0, 0, 0})
+	assert.Equal(t, errNotTLSHello, err)
+
+	_, err = parseTLSHello(nil)
+	assert.Equal(t, errNotTLSHello, err)
+}
+
+func TestTLSVersionString(t *testing.T) {
+	assert.Equal(t, "TLS 1.0", TLSVersionString(0x0301))
+	assert.Equal(t, "TLS 1.3", TLSVersionString(0x0304))
+	assert.Equal(t, "0x7f1c", TLSVersionString(0x7f1c))
+}
@@ -36,6 +36,7 @@ type ConnectionsCheck struct {
 	networkID              string
 	notInitializedLogLimit *procutil.LogLimit
 	lastTelemetry          *model.CollectorConnectionsTelemetry
+	kubeServiceResolver    *resolver.KubeServiceResolver
 }
 
 // Init initializes a ConnectionsCheck instance.
@@ -55,6 +56,10 @@ func (c *ConnectionsCheck) Init(cfg *config.AgentConfig, _ *model.SystemInfo) {
 	}
 	c.networkID = networkID
 
+	if cfg.ResolveKubeServices {
+		c.kubeServiceResolver = resolver.NewKubeServiceResolver()
+	}
+
 	// Run the check one time on init to register the client on the system probe
 	_, _ = c.Run(cfg, 0)
 }
@@ -86,11 +91,15 @@ func (c *ConnectionsCheck) Run(cfg *config.AgentConfig, groupID int32) ([]model.
 	dockerproxy.NewFilter().Filter(conns)
 	// Resolve the Raddr side of connections for local containers
 	LocalResolver.Resolve(conns)
+	// Resolve the Raddr side of connections to the Kubernetes services
+	if c.kubeServiceResolver != nil {
+		c.kubeServiceResolver.Resolve(conns)
+	}
 
-	tel := c.diffTelemetry(conns.Telemetry)
+	tel := c.diffTelemetry(conns.ConnTelemetry)
 
 	log.Debugf("collected connections in %s", time.Since(start))
-	return batchConnections(cfg, groupID, c.enrichConnections(conns.Conns), conns.Dns, c.networkID, tel), nil
+	return batchConnections(cfg, groupID, c.enrichConnections(conns.Conns), conns.Dns, conns.Domains, c.networkID, tel), nil
 }
 
 func (c *ConnectionsCheck) getConnections() (*model.Connections, error) {
@@ -165,6 +174,7 @@ func batchConnections(
 	groupID int32,
 	cxs []*model.Connection,
 	dns map[string]*model.DNSEntry,
+	domains []string,
 	networkID string,
 	telemetry *model.CollectorConnectionsTelemetry,
 ) []model.MessageBody {
@@ -199,6 +209,7 @@ func batchConnections(
 			}
 		}
 
+		// The DNS stats of the connections reference the domains by index, so every batch has all of them
 		cc := &model.CollectorConnections{
 			HostName:          cfg.HostName,
 			NetworkId:         networkID,
@@ -208,6 +219,7 @@ func batchConnections(
 			ContainerForPid:   ctrIDForPID,
 			EncodedDNS:        dnsEncoder.Encode(batchDNS),
 			ContainerHostType: cfg.ContainerHostType,
+			Domains:           domains,
 		}
 
 		// Add OS telemetry
@@ -220,7 +232,7 @@ func batchConnections(
 
 		// only add the telemetry to the first message to prevent double counting
 		if len(batches) == 0 {
-			cc.Telemetry = telemetry
+			cc.ConnTelemetry = telemetry
 		}
 		batches = append(batches, cc)
 
@@ -70,7 +70,7 @@ func TestNetworkConnectionBatching(t *testing.T) {
 	} {
 		cfg.MaxConnsPerMessage = tc.maxSize
 		tm := &model.CollectorConnectionsTelemetry{}
-		chunks := batchConnections(cfg, 0, tc.cur, map[string]*model.DNSEntry{}, "nid", tm)
+		chunks := batchConnections(cfg, 0, tc.cur, map[string]*model.DNSEntry{}, nil, "nid", tm)
 
 		assert.Len(t, chunks, tc.expectedChunks, "len %d", i)
 		total := 0
@@ -89,9 +89,9 @@ func TestNetworkConnectionBatching(t *testing.T) {
 
 			// ensure only first chunk has telemetry
 			if i == 0 {
-				assert.NotNil(t, connections.Telemetry)
+				assert.NotNil(t, connections.ConnTelemetry)
 			} else {
-				assert.Nil(t, connections.Telemetry)
+				assert.Nil(t, connections.ConnTelemetry)
 			}
 		}
 		assert.Equal(t, tc.expectedTotal, total, "total test %d", i)
@@ -109,7 +109,7 @@ func TestNetworkConnectionBatchingWithDNS(t *testing.T) {
 	cfg := config.NewDefaultAgentConfig(false)
 	cfg.MaxConnsPerMessage = 1
 
-	chunks := batchConnections(cfg, 0, p, dns, "nid", nil)
+	chunks := batchConnections(cfg, 0, p, dns, nil, "nid", nil)
 
 	assert.Len(t, chunks, 4)
 	total := 0
@@ -137,6 +137,31 @@ func TestNetworkConnectionBatchingWithDNS(t *testing.T) {
 	assert.Equal(t, 4, total)
 }
 
+func TestNetworkConnectionBatchingWithDomains(t *testing.T) {
+	p := makeConnections(4)
+
+	domains := []string{"foo.com", "bar.com"}
+	p[0].DnsStatsByDomainByQueryType = map[int32]*model.DNSStatsByQueryType{
+		1: {DnsStatsByQueryType: map[int32]*model.DNSStats{1: {DnsTimeouts: 1}}},
+	}
+	p[3].DnsStatsByDomainByQueryType = map[int32]*model.DNSStatsByQueryType{
+		0: {DnsStatsByQueryType: map[int32]*model.DNSStats{28: {DnsTimeouts: 2}}},
+	}
+
+	cfg := config.NewDefaultAgentConfig(false)
+	cfg.MaxConnsPerMessage = 1
+
+	chunks := batchConnections(cfg, 0, p, map[string]*model.DNSEntry{}, domains, "nid", nil)
+
+	assert.Len(t, chunks, 4)
+	for _, c := range chunks {
+		connections := c.(*model.CollectorConnections)
+
+		// the domains are indexed by the DNS stats of the connections, every batch has all of them
+		assert.Equal(t, domains, connections.Domains)
+	}
+}
+
 func TestBatchSimilarConnectionsTogether(t *testing.T) {
 	p := makeConnections(6)
 
@@ -150,7 +175,7 @@ func TestBatchSimilarConnectionsTogether(t *testing.T) {
 	cfg := config.NewDefaultAgentConfig(false)
 	cfg.MaxConnsPerMessage = 2
 
-	chunks := batchConnections(cfg, 0, p, map[string]*model.DNSEntry{}, "nid", nil)
+	chunks := batchConnections(cfg, 0, p, map[string]*model.DNSEntry{}, nil, "nid", nil)
 
 	assert.Len(t, chunks, 3)
 	total := 0
@@ -16,6 +16,8 @@ import (
 
 	model "github.com/DataDog/agent-payload/process"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/bundle"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/uprobe"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/process/util/api"
 	"github.com/DataDog/datadog-agent/pkg/util/fargate"
@@ -30,6 +32,12 @@ var (
 	// defaultSystemProbeBPFDir is the default path for eBPF programs
 	defaultSystemProbeBPFDir = "/opt/datadog-agent/embedded/share/system-probe/ebpf"
 
+	// defaultKernelHeadersDownloadDir is the default path where the kernel headers are downloaded
+	defaultKernelHeadersDownloadDir = "/var/tmp/datadog-agent/system-probe/kernel-headers"
+
+	// defaultRuntimeCompilerOutputDir is the default path where the eBPF programs compiled at runtime are cached
+	defaultRuntimeCompilerOutputDir = "/var/tmp/datadog-agent/system-probe/build"
+
 	processChecks   = []string{"process", "rtprocess"}
 	containerChecks = []string{"container", "rtcontainer"}
 )
@@ -93,21 +101,45 @@ type AgentConfig struct {
 	ExcludedBPFLinuxVersions       []string
 	ExcludedSourceConnections      map[string][]string
 	ExcludedDestinationConnections map[string][]string
+	EBPFTunables                   map[string]string
 	EnableConntrack                bool
 	ConntrackMaxStateSize          int
 	ConntrackRateLimit             int
 	EnableConntrackAllNamespaces   bool
+	EnableEBPFConntracker          bool
 	SystemProbeDebugPort           int
 	ClosedChannelSize              int
 	MaxClosedConnectionsBuffered   int
 	MaxConnectionsStateBuffered    int
 	OffsetGuessThreshold           uint64
 	EnableTracepoints              bool
+	KernelHeadersDirs              []string
+	KernelHeadersDownloadEnabled   bool
+	KernelHeadersDownloadDir       string
+	KernelHeadersMirrors           []string
+	KernelHeadersKeyring           string
+	EnableRuntimeCompiler          bool
+	RuntimeCompilerOutputDir       string
+	UprobeTargets                  []uprobe.Target
 
 	// DNS stats configuration
 	CollectDNSStats bool
 	DNSTimeout      time.Duration
 
+	// UDPConnTimeout is the inactivity period after which a UDP connection is expired
+	UDPConnTimeout time.Duration
+
+	// ResolveKubeServices adds the names of the Kubernetes services, given by the cluster agent, to the
+	// DNS names of the remote addresses of the connections
+	ResolveKubeServices bool
+
+	// EnableConnectionRollups merges the connections of the clients to the same server port, collapsing
+	// their ephemeral ports
+	EnableConnectionRollups bool
+
+	// EnableTLSMetadata captures the TLS handshakes of the connections to report the metadata of their TLS sessions
+	EnableTLSMetadata bool
+
 	// Orchestrator collection configuration
 	OrchestrationCollectionEnabled bool
 	KubeClusterName                string
@@ -226,6 +258,8 @@ func NewDefaultAgentConfig(canAccessContainers bool) *AgentConfig {
 		OffsetGuessThreshold:         400,
 		EnableTracepoints:            false,
 		CollectDNSStats:              true,
+		KernelHeadersDownloadDir:     defaultKernelHeadersDownloadDir,
+		RuntimeCompilerOutputDir:     defaultRuntimeCompilerOutputDir,
 
 		// Check config
 		EnabledChecks: enabledChecks,
@@ -316,6 +350,13 @@ func NewAgentConfig(loggerName config.LoggerName, yamlPath, netYamlPath string)
 		return nil, err
 	}
 
+	// the bundle is fetched by the core agent
+	if bundle.Enabled(config.Datadog) {
+		if _, err := bundle.LoadConfig(false, true); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := cfg.LoadProcessYamlConfig(yamlPath); err != nil {
 		return nil, err
 	}
@@ -493,6 +534,9 @@ func loadSysProbeEnvVariables() {
 		{"DD_DISABLE_DNS_INSPECTION", "system_probe_config.disable_dns_inspection"},
 		{"DD_COLLECT_LOCAL_DNS", "system_probe_config.collect_local_dns"},
 		{"DD_COLLECT_DNS_STATS", "system_probe_config.collect_dns_stats"},
+		{"DD_NETWORK_CONFIG_RESOLVE_KUBE_SERVICES", "network_config.resolve_kube_services"},
+		{"DD_NETWORK_CONFIG_ENABLE_CONNECTION_ROLLUPS", "network_config.enable_connection_rollups"},
+		{"DD_NETWORK_CONFIG_ENABLE_TLS_METADATA", "network_config.enable_tls_metadata"},
 	} {
 		if v, ok := os.LookupEnv(variable.env); ok {
 			config.Datadog.Set(variable.cfg, v)
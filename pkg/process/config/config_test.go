@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/uprobe"
 	"github.com/DataDog/gopsutil/process"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -501,6 +502,22 @@ func TestSystemProbeNoNetwork(t *testing.T) {
 
 }
 
+func TestSystemProbeUprobes(t *testing.T) {
+	agentConfig, err := NewAgentConfig(
+		"test",
+		"./testdata/TestDDAgentConfigYamlOnly.yaml",
+		"./testdata/TestDDAgentConfig-Uprobes.yaml",
+	)
+	require.NoError(t, err)
+
+	assert.True(t, agentConfig.EnableSystemProbe)
+	assert.ElementsMatch(t, []string{"Uprobes", "process", "rtprocess"}, agentConfig.EnabledChecks)
+	assert.Equal(t, []uprobe.Target{
+		{Path: "/usr/sbin/nginx", Functions: []string{"ngx_http_process_request", "ngx_http_finalize_request"}},
+	}, agentConfig.UprobeTargets)
+	assert.Equal(t, agentConfig.UprobeTargets, SysProbeConfigFromConfig(agentConfig).UprobeTargets)
+}
+
 func TestIsAffirmative(t *testing.T) {
 	value, err := isAffirmative("yes")
 	assert.Nil(t, err)
@@ -522,6 +539,51 @@ func TestIsAffirmative(t *testing.T) {
 	assert.False(t, value)
 }
 
+func TestResolveKubeServices(t *testing.T) {
+	config.Datadog = config.NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
+	defer restoreGlobalConfig()
+	defer os.Unsetenv("DD_NETWORK_CONFIG_RESOLVE_KUBE_SERVICES")
+
+	cfg, err := NewAgentConfig("test", "", "")
+	assert.Nil(t, err)
+	assert.False(t, cfg.ResolveKubeServices)
+
+	os.Setenv("DD_NETWORK_CONFIG_RESOLVE_KUBE_SERVICES", "true")
+	cfg, err = NewAgentConfig("test", "", "")
+	assert.Nil(t, err)
+	assert.True(t, cfg.ResolveKubeServices)
+}
+
+func TestEnableConnectionRollups(t *testing.T) {
+	config.Datadog = config.NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
+	defer restoreGlobalConfig()
+	defer os.Unsetenv("DD_NETWORK_CONFIG_ENABLE_CONNECTION_ROLLUPS")
+
+	cfg, err := NewAgentConfig("test", "", "")
+	assert.Nil(t, err)
+	assert.False(t, cfg.EnableConnectionRollups)
+
+	os.Setenv("DD_NETWORK_CONFIG_ENABLE_CONNECTION_ROLLUPS", "true")
+	cfg, err = NewAgentConfig("test", "", "")
+	assert.Nil(t, err)
+	assert.True(t, cfg.EnableConnectionRollups)
+}
+
+func TestEnableTLSMetadata(t *testing.T) {
+	config.Datadog = config.NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
+	defer restoreGlobalConfig()
+	defer os.Unsetenv("DD_NETWORK_CONFIG_ENABLE_TLS_METADATA")
+
+	cfg, err := NewAgentConfig("test", "", "")
+	assert.Nil(t, err)
+	assert.False(t, cfg.EnableTLSMetadata)
+
+	os.Setenv("DD_NETWORK_CONFIG_ENABLE_TLS_METADATA", "true")
+	cfg, err = NewAgentConfig("test", "", "")
+	assert.Nil(t, err)
+	assert.True(t, cfg.EnableTLSMetadata)
+}
+
 func TestEnablingDNSStatsCollection(t *testing.T) {
 	config.Datadog = config.NewConfig("datadog", "DD", strings.NewReplacer(".", "_"))
 	defer restoreGlobalConfig()
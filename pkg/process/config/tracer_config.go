@@ -45,6 +45,7 @@ func SysProbeConfigFromConfig(cfg *AgentConfig) *ebpf.Config {
 		tracerConfig.ExcludedDestinationConnections = cfg.ExcludedDestinationConnections
 	}
 
+	tracerConfig.EBPFTunables = cfg.EBPFTunables
 	tracerConfig.CollectLocalDNS = cfg.CollectLocalDNS
 	tracerConfig.CollectDNSStats = cfg.CollectDNSStats
 
@@ -52,6 +53,10 @@ func SysProbeConfigFromConfig(cfg *AgentConfig) *ebpf.Config {
 		tracerConfig.DNSTimeout = cfg.DNSTimeout
 	}
 
+	if to := cfg.UDPConnTimeout; to > 0 {
+		tracerConfig.UDPConnTimeout = cfg.UDPConnTimeout
+	}
+
 	tracerConfig.MaxTrackedConnections = cfg.MaxTrackedConnections
 	tracerConfig.ProcRoot = util.GetProcRoot()
 	tracerConfig.BPFDebug = cfg.SysProbeBPFDebug
@@ -59,7 +64,11 @@ func SysProbeConfigFromConfig(cfg *AgentConfig) *ebpf.Config {
 	tracerConfig.EnableConntrack = cfg.EnableConntrack
 	tracerConfig.ConntrackMaxStateSize = cfg.ConntrackMaxStateSize
 	tracerConfig.EnableConntrackAllNamespaces = cfg.EnableConntrackAllNamespaces
+	tracerConfig.EnableEBPFConntracker = cfg.EnableEBPFConntracker
 	tracerConfig.DebugPort = cfg.SystemProbeDebugPort
+	tracerConfig.UprobeTargets = cfg.UprobeTargets
+	tracerConfig.EnableConnectionRollups = cfg.EnableConnectionRollups
+	tracerConfig.EnableTLSMetadata = cfg.EnableTLSMetadata
 
 	if mccb := cfg.MaxClosedConnectionsBuffered; mccb > 0 {
 		tracerConfig.MaxClosedConnectionsBuffered = mccb
@@ -81,6 +90,14 @@ func SysProbeConfigFromConfig(cfg *AgentConfig) *ebpf.Config {
 		tracerConfig.EnableTracepoints = true
 	}
 
+	tracerConfig.KernelHeadersDirs = cfg.KernelHeadersDirs
+	tracerConfig.KernelHeadersDownloadEnabled = cfg.KernelHeadersDownloadEnabled
+	tracerConfig.KernelHeadersDownloadDir = cfg.KernelHeadersDownloadDir
+	tracerConfig.KernelHeadersMirrors = cfg.KernelHeadersMirrors
+	tracerConfig.KernelHeadersKeyring = cfg.KernelHeadersKeyring
+	tracerConfig.EnableRuntimeCompiler = cfg.EnableRuntimeCompiler
+	tracerConfig.RuntimeCompilerOutputDir = cfg.RuntimeCompilerOutputDir
+
 	tracerConfig.EnableMonotonicCount = cfg.Windows.EnableMonotonicCount
 	tracerConfig.DriverBufferSize = cfg.Windows.DriverBufferSize
 
@@ -53,10 +53,18 @@ func (a *AgentConfig) loadSysProbeYamlConfig(path string) error {
 		a.CollectDNSStats = config.Datadog.GetBool(key(spNS, "collect_dns_stats"))
 	}
 
+	a.ResolveKubeServices = config.Datadog.GetBool(key("network_config", "resolve_kube_services"))
+	a.EnableConnectionRollups = config.Datadog.GetBool(key("network_config", "enable_connection_rollups"))
+	a.EnableTLSMetadata = config.Datadog.GetBool(key("network_config", "enable_tls_metadata"))
+
 	if config.Datadog.IsSet(key(spNS, "dns_timeout_in_s")) {
 		a.DNSTimeout = config.Datadog.GetDuration(key(spNS, "dns_timeout_in_s")) * time.Second
 	}
 
+	if config.Datadog.IsSet(key(spNS, "udp_conn_timeout_in_s")) {
+		a.UDPConnTimeout = config.Datadog.GetDuration(key(spNS, "udp_conn_timeout_in_s")) * time.Second
+	}
+
 	if config.Datadog.GetBool(key(spNS, "enabled")) {
 		a.EnableSystemProbe = true
 	}
@@ -87,6 +95,9 @@ func (a *AgentConfig) loadSysProbeYamlConfig(path string) error {
 	if config.Datadog.IsSet(key(spNS, "enable_conntrack_all_namespaces")) {
 		a.EnableConntrackAllNamespaces = config.Datadog.GetBool(key(spNS, "enable_conntrack_all_namespaces"))
 	}
+	if config.Datadog.IsSet(key(spNS, "enable_ebpf_conntracker")) {
+		a.EnableEBPFConntracker = config.Datadog.GetBool(key(spNS, "enable_ebpf_conntracker"))
+	}
 
 	// When reading kernel structs at different offsets, don't go over the threshold
 	// This defaults to 400 and has a max of 3000. These are arbitrary choices to avoid infinite loops.
@@ -153,6 +164,10 @@ func (a *AgentConfig) loadSysProbeYamlConfig(path string) error {
 		a.ExcludedDestinationConnections = config.Datadog.GetStringMapStringSlice(destinationExclude)
 	}
 
+	if tunables := key(spNS, "ebpf_tunables"); config.Datadog.IsSet(tunables) {
+		a.EBPFTunables = config.Datadog.GetStringMapString(tunables)
+	}
+
 	if config.Datadog.GetBool(key(spNS, "enable_tcp_queue_length")) {
 		log.Info("system_probe_config.enable_tcp_queue_length detected, will enable system-probe with TCP queue length check")
 		a.EnableSystemProbe = true
@@ -165,6 +180,15 @@ func (a *AgentConfig) loadSysProbeYamlConfig(path string) error {
 		a.EnabledChecks = append(a.EnabledChecks, "OOM Kill")
 	}
 
+	if config.Datadog.GetBool(key(spNS, "enable_uprobes")) {
+		log.Info("system_probe_config.enable_uprobes detected, will enable system-probe with uprobe check")
+		if err := config.Datadog.UnmarshalKey(key(spNS, "uprobes"), &a.UprobeTargets); err != nil {
+			return fmt.Errorf("invalid %s: %s", key(spNS, "uprobes"), err)
+		}
+		a.EnableSystemProbe = true
+		a.EnabledChecks = append(a.EnabledChecks, "Uprobes")
+	}
+
 	if config.Datadog.GetBool("runtime_security_config.enabled") {
 		log.Info("runtime_security_config.enabled=true, enabling system-probe")
 		a.EnableSystemProbe = true
@@ -174,6 +198,22 @@ func (a *AgentConfig) loadSysProbeYamlConfig(path string) error {
 		a.EnableTracepoints = config.Datadog.GetBool(key(spNS, "enable_tracepoints"))
 	}
 
+	if config.Datadog.IsSet(key(spNS, "kernel_header_dirs")) {
+		a.KernelHeadersDirs = config.Datadog.GetStringSlice(key(spNS, "kernel_header_dirs"))
+	}
+	a.KernelHeadersDownloadEnabled = config.Datadog.GetBool(key(spNS, "enable_kernel_header_download"))
+	if dir := config.Datadog.GetString(key(spNS, "kernel_header_download_dir")); dir != "" {
+		a.KernelHeadersDownloadDir = dir
+	}
+	if config.Datadog.IsSet(key(spNS, "kernel_header_mirrors")) {
+		a.KernelHeadersMirrors = config.Datadog.GetStringSlice(key(spNS, "kernel_header_mirrors"))
+	}
+	a.KernelHeadersKeyring = config.Datadog.GetString(key(spNS, "kernel_header_download_keyring"))
+	a.EnableRuntimeCompiler = config.Datadog.GetBool(key(spNS, "enable_runtime_compiler"))
+	if dir := config.Datadog.GetString(key(spNS, "runtime_compiler_output_dir")); dir != "" {
+		a.RuntimeCompilerOutputDir = dir
+	}
+
 	a.Windows.EnableMonotonicCount = config.Datadog.GetBool(key(spNS, "windows", "enable_monotonic_count"))
 
 	if driverBufferSize := config.Datadog.GetInt(key(spNS, "windows", "driver_buffer_size")); driverBufferSize > 0 {
@@ -10,6 +10,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/ebpf/oomkill"
 	"github.com/DataDog/datadog-agent/pkg/ebpf/tcpqueuelength"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/uprobe"
 )
 
 const (
@@ -49,6 +50,13 @@ func (r *RemoteSysProbeUtil) GetCheck(check string) (interface{}, error) {
 			return nil, err
 		}
 		return stats, nil
+	} else if check == "uprobe" {
+		var stats []uprobe.Stats
+		err = json.Unmarshal(body, &stats)
+		if err != nil {
+			return nil, err
+		}
+		return stats, nil
 	}
 
 	return nil, fmt.Errorf("Invalid check name: %s", check)
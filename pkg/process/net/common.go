@@ -14,6 +14,7 @@ import (
 
 	model "github.com/DataDog/agent-payload/process"
 	"github.com/DataDog/datadog-agent/pkg/network/encoding"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/retry"
 )
@@ -29,6 +30,10 @@ type Conn interface {
 
 const (
 	contentTypeProtobuf = "application/protobuf"
+
+	// healthComponentName is the name under which the connectivity to system-probe is reported in the
+	// health of the components
+	healthComponentName = "system-probe"
 )
 
 var (
@@ -56,6 +61,7 @@ func SetSystemProbePath(path string) {
 func GetRemoteSystemProbeUtil() (*RemoteSysProbeUtil, error) {
 	err := CheckPath()
 	if err != nil {
+		health.SetState(healthComponentName, health.StateDegraded, fmt.Sprintf("system-probe unreachable: %v", err))
 		return nil, fmt.Errorf("error setting up remote system probe util, %v", err)
 	}
 
@@ -73,9 +79,11 @@ func GetRemoteSystemProbeUtil() (*RemoteSysProbeUtil, error) {
 
 	if err := globalUtil.initRetry.TriggerRetry(); err != nil {
 		log.Debugf("system probe init error: %s", err)
+		health.SetState(healthComponentName, health.StateDegraded, fmt.Sprintf("system-probe unreachable: %v", err))
 		return nil, err
 	}
 
+	health.SetState(healthComponentName, health.StateReady, "")
 	return globalUtil, nil
 }
 
@@ -0,0 +1,102 @@
+package resolver
+
+import (
+	"sync"
+	"time"
+
+	model "github.com/DataDog/agent-payload/process"
+	procutil "github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const kubeServicesTTL = time.Minute
+
+// KubeServiceResolver is responsible for resolving the raddr of connections to the Kubernetes services they belong to.
+// The names of the services, given by the cluster agent, are added to the DNS names of the addresses so that
+// connections to a ClusterIP or to the pods of a service show the name of the service.
+type KubeServiceResolver struct {
+	mux       sync.Mutex
+	namesByIP map[string][]string
+	updated   time.Time
+	logLimit  *procutil.LogLimit
+
+	getNamesByIP func() (map[string][]string, error)
+}
+
+// NewKubeServiceResolver returns a KubeServiceResolver querying the cluster agent
+func NewKubeServiceResolver() *KubeServiceResolver {
+	return &KubeServiceResolver{
+		logLimit: procutil.NewLogLimit(1, time.Minute*10),
+		getNamesByIP: func() (map[string][]string, error) {
+			dcaClient, err := clusteragent.GetClusterAgentClient()
+			if err != nil {
+				return nil, err
+			}
+			return dcaClient.GetServiceNamesByIP()
+		},
+	}
+}
+
+// Resolve adds the names of the services of the remote addresses of the connections to their DNS names
+func (r *KubeServiceResolver) Resolve(c *model.Connections) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.refresh()
+	if len(r.namesByIP) == 0 {
+		return
+	}
+
+	if c.Dns == nil {
+		c.Dns = make(map[string]*model.DNSEntry)
+	}
+
+	for _, conn := range c.Conns {
+		names, ok := r.namesByIP[conn.Raddr.Ip]
+		if !ok {
+			continue
+		}
+
+		entry, ok := c.Dns[conn.Raddr.Ip]
+		if !ok {
+			entry = &model.DNSEntry{}
+			c.Dns[conn.Raddr.Ip] = entry
+		}
+
+		for _, name := range names {
+			if !containsName(entry.Names, name) {
+				entry.Names = append(entry.Names, name)
+			}
+		}
+	}
+}
+
+// refresh fetches the names of the services from the cluster agent once the previous ones expired.
+// The previous names are kept if the cluster agent can't be reached.
+func (r *KubeServiceResolver) refresh() {
+	if time.Since(r.updated) < kubeServicesTTL {
+		return
+	}
+	r.updated = time.Now()
+
+	namesByIP, err := r.getNamesByIP()
+	if err != nil {
+		if r.logLimit.ShouldLog() {
+			log.Warnf("could not get the Kubernetes services from the cluster agent: %s (will only log every 10 minutes)", err)
+		}
+		return
+	}
+
+	log.Debugf("resolved %d IPs to Kubernetes services", len(namesByIP))
+	r.namesByIP = namesByIP
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
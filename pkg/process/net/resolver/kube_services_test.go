@@ -0,0 +1,62 @@
+package resolver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	model "github.com/DataDog/agent-payload/process"
+	procutil "github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKubeServiceResolver(t *testing.T) {
+	calls := 0
+	resolver := &KubeServiceResolver{
+		logLimit: procutil.NewLogLimit(1, time.Minute),
+		getNamesByIP: func() (map[string][]string, error) {
+			calls++
+			return map[string][]string{
+				"10.96.0.10": {"checkout-svc.shop"},
+				"10.0.0.2":   {"checkout-svc.shop", "headless.shop"},
+			}, nil
+		},
+	}
+
+	conns := &model.Connections{
+		Conns: []*model.Connection{
+			{Raddr: &model.Addr{Ip: "10.96.0.10", Port: 80}},
+			{Raddr: &model.Addr{Ip: "10.96.0.10", Port: 80}},
+			{Raddr: &model.Addr{Ip: "10.0.0.2", Port: 8080}},
+			{Raddr: &model.Addr{Ip: "8.8.8.8", Port: 53}},
+		},
+		Dns: map[string]*model.DNSEntry{
+			"10.0.0.2": {Names: []string{"10-0-0-2.headless.shop.svc.cluster.local"}},
+		},
+	}
+
+	resolver.Resolve(conns)
+	assert.Equal(t, map[string]*model.DNSEntry{
+		"10.96.0.10": {Names: []string{"checkout-svc.shop"}},
+		"10.0.0.2":   {Names: []string{"10-0-0-2.headless.shop.svc.cluster.local", "checkout-svc.shop", "headless.shop"}},
+	}, conns.Dns)
+
+	// the names are cached
+	resolver.Resolve(&model.Connections{})
+	assert.Equal(t, 1, calls)
+}
+
+func TestKubeServiceResolverError(t *testing.T) {
+	resolver := &KubeServiceResolver{
+		logLimit: procutil.NewLogLimit(1, time.Minute),
+		getNamesByIP: func() (map[string][]string, error) {
+			return nil, errors.New("cluster agent unreachable")
+		},
+	}
+
+	conns := &model.Connections{
+		Conns: []*model.Connection{{Raddr: &model.Addr{Ip: "10.96.0.10", Port: 80}}},
+	}
+	resolver.Resolve(conns)
+	assert.Empty(t, conns.Dns)
+}
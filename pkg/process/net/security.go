@@ -0,0 +1,35 @@
+// +build linux
+
+package net
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+const (
+	securityEventsURL = "http://unix/runtime_security/events"
+)
+
+// GetSecurityEvents returns the JSON encoded security events saved in the event store of the runtime security module,
+// filtered with the given query parameters
+func (r *RemoteSysProbeUtil) GetSecurityEvents(query url.Values) ([]byte, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", securityEventsURL, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("security events request failed: socket %s, url %s, status code: %d", r.path, securityEventsURL, resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
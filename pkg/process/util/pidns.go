@@ -0,0 +1,237 @@
+// +build linux
+
+package util
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// ErrNsPidNotSupported is returned when the kernel doesn't report the pids of the processes in their pid
+// namespaces, which requires a 4.1 kernel or later
+var ErrNsPidNotSupported = errors.New("the pids of the processes in their pid namespaces are not reported by the kernel")
+
+// GetNsPids returns the pids of the given `pid` in each of the nested pid namespaces it belongs to, from the
+// pid namespace of procRoot to the innermost one, as reported by the NSpid field of its status file
+func GetNsPids(procRoot string, pid int) ([]int, error) {
+	return readNsPids(path.Join(procRoot, fmt.Sprintf("%d/status", pid)), pid)
+}
+
+// GetNsTid returns the tid of the thread `tid` of the given `pid` in its innermost pid namespace
+func GetNsTid(procRoot string, pid int, tid int) (int, error) {
+	tids, err := readNsPids(path.Join(procRoot, fmt.Sprintf("%d/task/%d/status", pid, tid)), tid)
+	if err != nil {
+		return 0, err
+	}
+	return tids[len(tids)-1], nil
+}
+
+// readNsPids parses the NSpid field of the status file of the process or thread `id`
+func readNsPids(statusPath string, id int) ([]int, error) {
+	f, err := os.Open(statusPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "NSpid:") {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimPrefix(line, "NSpid:"))
+		pids := make([]int, 0, len(fields))
+		for _, field := range fields {
+			nsPid, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid NSpid field for pid %d: %s", id, line)
+			}
+			pids = append(pids, nsPid)
+		}
+		if len(pids) == 0 {
+			return nil, fmt.Errorf("invalid NSpid field for pid %d: %s", id, line)
+		}
+		return pids, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, ErrNsPidNotSupported
+}
+
+// GetNsPid returns the pid of the given `pid` in its innermost pid namespace, which is the pid seen from
+// inside its container
+func GetNsPid(procRoot string, pid int) (int, error) {
+	pids, err := GetNsPids(procRoot, pid)
+	if err != nil {
+		return 0, err
+	}
+	return pids[len(pids)-1], nil
+}
+
+// GetPidNsInoFromPid gets the pid namespace inode number for the given `pid`
+func GetPidNsInoFromPid(procRoot string, pid int) (uint64, error) {
+	fi, err := os.Stat(path.Join(procRoot, fmt.Sprintf("%d/ns/pid", pid)))
+	if err != nil {
+		return 0, err
+	}
+
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("unable to get the pid namespace inode of pid %d", pid)
+	}
+	return stat.Ino, nil
+}
+
+// FindHostPid returns the pid, in the pid namespace of procRoot, of the process whose pid is `nsPid`
+// in the pid namespace `nsIno`. It walks through all the processes of procRoot.
+func FindHostPid(procRoot string, nsIno uint64, nsPid int) (int, error) {
+	errFound := errors.New("found")
+
+	hostPid := 0
+	err := WithAllProcs(procRoot, func(pid int) error {
+		pids, err := GetNsPids(procRoot, pid)
+		if err != nil {
+			if err == ErrNsPidNotSupported {
+				return err
+			}
+			// the process may have exited in the meantime
+			return nil
+		}
+		if pids[len(pids)-1] != nsPid {
+			return nil
+		}
+
+		if ino, err := GetPidNsInoFromPid(procRoot, pid); err == nil && ino == nsIno {
+			hostPid = pid
+			return errFound
+		}
+		return nil
+	})
+
+	if err == errFound {
+		return hostPid, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no process with pid %d in pid namespace %d", nsPid, nsIno)
+}
+
+// nsPidKey identifies a pid in a pid namespace
+type nsPidKey struct {
+	nsIno uint64
+	nsPid int
+}
+
+// PidNamespaceResolver translates the pids of the processes between the pid namespace of procRoot, usually
+// the one of the host, and their innermost pid namespace, usually the one of their container. The
+// translations are cached, Forget has to be called when a process exits so that its pid can be reused. The
+// translations are resolved under the lock of the resolver, so that Forget never races with the caching of
+// the translations of an exited process.
+type PidNamespaceResolver struct {
+	sync.Mutex
+	procRoot string
+	nsPids   *lru.Cache
+	hostPids *lru.Cache
+}
+
+// NewPidNamespaceResolver returns a new pid namespace resolver caching up to `cacheSize` translations in
+// each direction
+func NewPidNamespaceResolver(procRoot string, cacheSize int) (*PidNamespaceResolver, error) {
+	nsPids, err := lru.New(cacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	hostPids, err := lru.New(cacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PidNamespaceResolver{
+		procRoot: procRoot,
+		nsPids:   nsPids,
+		hostPids: hostPids,
+	}, nil
+}
+
+// HostToNsPid returns the pid of the given host `pid` in its innermost pid namespace
+func (r *PidNamespaceResolver) HostToNsPid(pid int) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if entry, ok := r.nsPids.Get(pid); ok {
+		return entry.(nsPidKey).nsPid, nil
+	}
+
+	nsPid, err := GetNsPid(r.procRoot, pid)
+	if err != nil {
+		return 0, err
+	}
+
+	nsIno, err := GetPidNsInoFromPid(r.procRoot, pid)
+	if err != nil {
+		return 0, err
+	}
+
+	key := nsPidKey{nsIno: nsIno, nsPid: nsPid}
+	r.nsPids.Add(pid, key)
+	r.hostPids.Add(key, pid)
+
+	return nsPid, nil
+}
+
+// HostToNsTid returns the tid of the thread `tid` of the given host `pid` in its innermost pid namespace. The
+// threads aren't cached, their status file is read for each call.
+func (r *PidNamespaceResolver) HostToNsTid(pid int, tid int) (int, error) {
+	if tid == pid {
+		return r.HostToNsPid(pid)
+	}
+	return GetNsTid(r.procRoot, pid, tid)
+}
+
+// NsToHostPid returns the host pid of the process whose pid is `nsPid` in the pid namespace `nsIno`. The
+// inode of the pid namespace of a container can be retrieved from any of its processes with GetPidNsInoFromPid.
+func (r *PidNamespaceResolver) NsToHostPid(nsIno uint64, nsPid int) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	key := nsPidKey{nsIno: nsIno, nsPid: nsPid}
+	if pid, ok := r.hostPids.Get(key); ok {
+		return pid.(int), nil
+	}
+
+	pid, err := FindHostPid(r.procRoot, nsIno, nsPid)
+	if err != nil {
+		return 0, err
+	}
+
+	r.nsPids.Add(pid, key)
+	r.hostPids.Add(key, pid)
+
+	return pid, nil
+}
+
+// Forget removes the cached translations of the given host `pid`
+func (r *PidNamespaceResolver) Forget(pid int) {
+	r.Lock()
+	defer r.Unlock()
+
+	if key, ok := r.nsPids.Peek(pid); ok {
+		r.hostPids.Remove(key)
+	}
+	r.nsPids.Remove(pid)
+}
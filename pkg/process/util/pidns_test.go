@@ -0,0 +1,130 @@
+// +build linux
+
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFakeProcess creates the status and pid namespace files of a process in a fake procfs
+func writeFakeProcess(t *testing.T, procRoot string, pid int, nsPids string, ns string) {
+	dir := filepath.Join(procRoot, fmt.Sprint(pid))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "ns"), 0755))
+
+	status := fmt.Sprintf("Name:\tbash\nTgid:\t%d\nPid:\t%d\n", pid, pid)
+	if nsPids != "" {
+		status += "NSpid:\t" + nsPids + "\n"
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "status"), []byte(status), 0644))
+
+	// the namespace files of a same namespace are hard links, so that they share their inode
+	nsPath := filepath.Join(procRoot, ns)
+	if _, err := os.Stat(nsPath); os.IsNotExist(err) {
+		require.NoError(t, ioutil.WriteFile(nsPath, nil, 0644))
+	}
+	require.NoError(t, os.Link(nsPath, filepath.Join(dir, "ns", "pid")))
+}
+
+func TestGetNsPids(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "proc")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+
+	writeFakeProcess(t, procRoot, 1, "1", "host")
+	writeFakeProcess(t, procRoot, 4242, "4242\t1", "container")
+	writeFakeProcess(t, procRoot, 4243, "4243\t7\t1", "nested")
+	writeFakeProcess(t, procRoot, 4244, "", "host")
+
+	pids, err := GetNsPids(procRoot, 4243)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4243, 7, 1}, pids)
+
+	nsPid, err := GetNsPid(procRoot, 4242)
+	require.NoError(t, err)
+	assert.Equal(t, 1, nsPid)
+
+	nsPid, err = GetNsPid(procRoot, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, nsPid)
+
+	_, err = GetNsPids(procRoot, 4244)
+	assert.Equal(t, ErrNsPidNotSupported, err)
+
+	_, err = GetNsPids(procRoot, 4245)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPidNamespaceResolver(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "proc")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+
+	writeFakeProcess(t, procRoot, 1, "1", "host")
+	writeFakeProcess(t, procRoot, 4242, "4242\t1", "container1")
+	writeFakeProcess(t, procRoot, 4300, "4300\t1", "container2")
+	writeFakeProcess(t, procRoot, 4301, "4301\t2", "container2")
+
+	container1, err := GetPidNsInoFromPid(procRoot, 4242)
+	require.NoError(t, err)
+	container2, err := GetPidNsInoFromPid(procRoot, 4301)
+	require.NoError(t, err)
+	assert.NotEqual(t, container1, container2)
+
+	resolver, err := NewPidNamespaceResolver(procRoot, 10)
+	require.NoError(t, err)
+
+	nsPid, err := resolver.HostToNsPid(4301)
+	require.NoError(t, err)
+	assert.Equal(t, 2, nsPid)
+
+	// the pid 1 of each container is translated to its own host pid
+	pid, err := resolver.NsToHostPid(container1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 4242, pid)
+
+	pid, err = resolver.NsToHostPid(container2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 4300, pid)
+
+	_, err = resolver.NsToHostPid(container1, 2)
+	assert.Error(t, err)
+
+	// the threads are translated with the status file of their task
+	taskDir := filepath.Join(procRoot, "4300", "task", "4310")
+	require.NoError(t, os.MkdirAll(taskDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(taskDir, "status"), []byte("Name:\tbash\nTgid:\t4300\nPid:\t4310\nNSpid:\t4310\t5\n"), 0644))
+
+	nsTid, err := resolver.HostToNsTid(4300, 4310)
+	require.NoError(t, err)
+	assert.Equal(t, 5, nsTid)
+
+	nsTid, err = resolver.HostToNsTid(4300, 4300)
+	require.NoError(t, err)
+	assert.Equal(t, 1, nsTid)
+
+	_, err = resolver.HostToNsTid(4300, 4311)
+	assert.Error(t, err)
+
+	// the translations are cached until the process is forgotten
+	require.NoError(t, os.RemoveAll(filepath.Join(procRoot, "4301")))
+	nsPid, err = resolver.HostToNsPid(4301)
+	require.NoError(t, err)
+	assert.Equal(t, 2, nsPid)
+
+	pid, err = resolver.NsToHostPid(container2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4301, pid)
+
+	resolver.Forget(4301)
+	_, err = resolver.HostToNsPid(4301)
+	assert.Error(t, err)
+	_, err = resolver.NsToHostPid(container2, 2)
+	assert.Error(t, err)
+}
@@ -22,6 +22,14 @@ type SecretInfo struct {
 	SecretsHandles map[string][]string
 }
 
+// SecretStatus holds the state of the resolution of the secrets, exposed in the agent status
+type SecretStatus struct {
+	Handles          int               `json:"handles"`
+	LastRefresh      int64             `json:"lastRefresh,omitempty"`
+	LastRefreshError string            `json:"lastRefreshError,omitempty"`
+	Errors           map[string]string `json:"errors,omitempty"`
+}
+
 // Print output a SecretInfo to a io.Writer
 func (si *SecretInfo) Print(w io.Writer) {
 	fmt.Fprintf(w, "=== Checking executable rights ===\n")
@@ -24,6 +24,16 @@ func Decrypt(data []byte, origin string) ([]byte, error) {
 	return data, nil
 }
 
+// Refresh placeholder when compiled without the 'secrets' build tag
+func Refresh() ([]string, error) {
+	return nil, nil
+}
+
+// GetStatus placeholder when compiled without the 'secrets' build tag
+func GetStatus() *SecretStatus {
+	return nil
+}
+
 // GetDebugInfo exposes debug informations about secrets to be included in a flare
 func GetDebugInfo() (*SecretInfo, error) {
 	return nil, fmt.Errorf("Secret feature is not available in this version of the agent")
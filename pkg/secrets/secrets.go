@@ -9,7 +9,10 @@ package secrets
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -21,6 +24,13 @@ var (
 	secretCache map[string]string
 	// list of handles and where they were found
 	secretOrigin map[string]common.StringSet
+	// secretLock protects the cache, since the secrets are refreshed in the background
+	secretLock sync.Mutex
+
+	// errors returned by the last resolution of every origin, exposed in the agent status
+	resolutionErrors map[string]string
+	lastRefresh      time.Time
+	lastRefreshError string
 
 	secretBackendCommand               string
 	secretBackendArguments             []string
@@ -34,6 +44,7 @@ var (
 func init() {
 	secretCache = make(map[string]string)
 	secretOrigin = make(map[string]common.StringSet)
+	resolutionErrors = make(map[string]string)
 }
 
 // Init initializes the command and other options of the secrets package. Since
@@ -133,6 +144,19 @@ func Decrypt(data []byte, origin string) ([]byte, error) {
 		return data, nil
 	}
 
+	secretLock.Lock()
+	defer secretLock.Unlock()
+
+	finalConfig, err := decrypt(data, origin)
+	if err != nil {
+		resolutionErrors[origin] = err.Error()
+	} else {
+		delete(resolutionErrors, origin)
+	}
+	return finalConfig, err
+}
+
+func decrypt(data []byte, origin string) ([]byte, error) {
 	var config interface{}
 	err := yaml.Unmarshal(data, &config)
 	if err != nil {
@@ -197,11 +221,86 @@ func Decrypt(data []byte, origin string) ([]byte, error) {
 	return finalConfig, nil
 }
 
+// Refresh fetches again all the secrets in the cache and returns the handles whose value changed.
+// If the secret backend fails, the previous values are kept.
+func Refresh() ([]string, error) {
+	if secretBackendCommand == "" {
+		return nil, nil
+	}
+
+	secretLock.Lock()
+	defer secretLock.Unlock()
+
+	if len(secretCache) == 0 {
+		return nil, nil
+	}
+
+	handles := make([]string, 0, len(secretCache))
+	previous := make(map[string]string, len(secretCache))
+	for handle, secret := range secretCache {
+		handles = append(handles, handle)
+		previous[handle] = secret
+	}
+	sort.Strings(handles)
+
+	// fetchSecret resets the origins of the handles, keep the ones recorded when they were resolved
+	origins := secretOrigin
+	secretOrigin = make(map[string]common.StringSet, len(origins))
+	secrets, err := secretFetcher(handles, "refresh")
+	secretOrigin = origins
+
+	lastRefresh = time.Now()
+	if err != nil {
+		secretCache = previous
+		lastRefreshError = err.Error()
+		return nil, err
+	}
+	lastRefreshError = ""
+
+	var changed []string
+	for _, handle := range handles {
+		secretCache[handle] = secrets[handle]
+		if secrets[handle] != previous[handle] {
+			changed = append(changed, handle)
+		}
+	}
+	return changed, nil
+}
+
+// GetStatus returns the state of the resolution of the secrets, or nil if the secrets feature is not enabled
+func GetStatus() *SecretStatus {
+	if secretBackendCommand == "" {
+		return nil
+	}
+
+	secretLock.Lock()
+	defer secretLock.Unlock()
+
+	status := &SecretStatus{
+		Handles:          len(secretCache),
+		LastRefreshError: lastRefreshError,
+	}
+	if !lastRefresh.IsZero() {
+		status.LastRefresh = lastRefresh.Unix()
+	}
+	if len(resolutionErrors) > 0 {
+		status.Errors = make(map[string]string, len(resolutionErrors))
+		for origin, err := range resolutionErrors {
+			status.Errors[origin] = err
+		}
+	}
+	return status
+}
+
 // GetDebugInfo exposes debug informations about secrets to be included in a flare
 func GetDebugInfo() (*SecretInfo, error) {
 	if secretBackendCommand == "" {
 		return nil, fmt.Errorf("No secret_backend_command set: secrets feature is not enabled")
 	}
+
+	secretLock.Lock()
+	defer secretLock.Unlock()
+
 	info := &SecretInfo{ExecutablePath: secretBackendCommand}
 	info.populateRights()
 
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/common"
 	"github.com/stretchr/testify/assert"
@@ -340,3 +341,98 @@ func TestDebugInfo(t *testing.T) {
 		"pass3": {"test2"},
 	}, handles)
 }
+
+func TestDecryptRecordsErrors(t *testing.T) {
+	secretBackendCommand = "some_command"
+
+	defer func() {
+		secretBackendCommand = ""
+		secretCache = map[string]string{}
+		secretOrigin = map[string]common.StringSet{}
+		resolutionErrors = map[string]string{}
+		secretFetcher = fetchSecret
+	}()
+
+	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
+		return nil, fmt.Errorf("some error")
+	}
+	_, err := Decrypt(testConf, "test")
+	require.NotNil(t, err)
+	assert.Equal(t, map[string]string{"test": "some error"}, GetStatus().Errors)
+
+	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
+		return map[string]string{
+			"pass1": "password1",
+			"pass2": "password2",
+		}, nil
+	}
+	_, err = Decrypt(testConf, "test")
+	require.Nil(t, err)
+
+	status := GetStatus()
+	assert.Equal(t, 0, len(status.Errors))
+}
+
+func TestRefresh(t *testing.T) {
+	secretBackendCommand = "some_command"
+
+	defer func() {
+		secretBackendCommand = ""
+		secretCache = map[string]string{}
+		secretOrigin = map[string]common.StringSet{}
+		lastRefresh = time.Time{}
+		lastRefreshError = ""
+		secretFetcher = fetchSecret
+	}()
+
+	secretCache["pass1"] = "password1"
+	secretCache["pass2"] = "password2"
+	secretOrigin["pass1"] = common.NewStringSet("test")
+	secretOrigin["pass2"] = common.NewStringSet("test")
+
+	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
+		assert.Equal(t, []string{"pass1", "pass2"}, secrets)
+		return map[string]string{
+			"pass1": "password1",
+			"pass2": "rotated2",
+		}, nil
+	}
+
+	changed, err := Refresh()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"pass2"}, changed)
+	assert.Equal(t, map[string]string{"pass1": "password1", "pass2": "rotated2"}, secretCache)
+	assert.Equal(t, map[string]common.StringSet{"pass1": common.NewStringSet("test"), "pass2": common.NewStringSet("test")}, secretOrigin)
+
+	status := GetStatus()
+	assert.Equal(t, 2, status.Handles)
+	assert.NotZero(t, status.LastRefresh)
+	assert.Equal(t, "", status.LastRefreshError)
+}
+
+func TestRefreshError(t *testing.T) {
+	secretBackendCommand = "some_command"
+
+	defer func() {
+		secretBackendCommand = ""
+		secretCache = map[string]string{}
+		secretOrigin = map[string]common.StringSet{}
+		lastRefresh = time.Time{}
+		lastRefreshError = ""
+		runCommand = execCommand
+	}()
+
+	secretCache["pass1"] = "password1"
+	secretCache["pass2"] = "password2"
+
+	// the first secret is fetched before the backend fails on the second one
+	runCommand = func(string) ([]byte, error) {
+		return []byte("{\"pass1\":{\"value\":\"rotated1\"},\"pass2\":{\"error\":\"not found\"}}"), nil
+	}
+
+	changed, err := Refresh()
+	require.NotNil(t, err)
+	assert.Nil(t, changed)
+	assert.Equal(t, map[string]string{"pass1": "password1", "pass2": "password2"}, secretCache)
+	assert.Equal(t, "an error occurred while decrypting 'pass2': not found", GetStatus().LastRefreshError)
+}
@@ -20,6 +20,7 @@ import (
 
 	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/security/api"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -32,6 +33,9 @@ type RuntimeSecurityAgent struct {
 	wg            sync.WaitGroup
 	connected     atomic.Value
 	eventReceived uint64
+	lastHeartbeat atomic.Value
+	probeHealth   atomic.Value
+	ruleStats     atomic.Value
 }
 
 // NewRuntimeSecurityAgent instantiates a new RuntimeSecurityAgent
@@ -125,14 +129,54 @@ func (rsa *RuntimeSecurityAgent) SendSecurityEvent(evt *api.SecurityEventMessage
 
 // DispatchEvent dispatches a security event message to the subsytems of the runtime security agent
 func (rsa *RuntimeSecurityAgent) DispatchEvent(evt *api.SecurityEventMessage) {
+	// the heartbeats are status messages, only reported by the status of the agent
+	if evt.RuleID == api.HeartbeatRuleID {
+		rsa.lastHeartbeat.Store(time.Now())
+		rsa.storeProbeHealth(evt.GetData())
+		return
+	}
+
 	// For now simply log to Datadog
 	rsa.SendSecurityEvent(evt, message.StatusAlert)
 }
 
+// storeProbeHealth keeps the health of the probe and the statistics of the slowest rules reported
+// by a heartbeat for the status
+func (rsa *RuntimeSecurityAgent) storeProbeHealth(data []byte) {
+	var heartbeat struct {
+		Probe map[string]interface{}    `json:"probe"`
+		Rules []rules.RuleStatsSnapshot `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &heartbeat); err != nil {
+		log.Debugf("Couldn't decode the runtime security heartbeat: %v", err)
+		return
+	}
+	rsa.probeHealth.Store(heartbeat.Probe)
+
+	ruleStats := make([]map[string]interface{}, 0, len(heartbeat.Rules))
+	for _, stats := range heartbeat.Rules {
+		ruleStats = append(ruleStats, map[string]interface{}{
+			"ruleID":          stats.RuleID,
+			"evaluations":     stats.Evaluations,
+			"matches":         stats.Matches,
+			"evalTime":        stats.EvalTime.Nanoseconds(),
+			"averageEvalTime": stats.AverageEvalTime().Nanoseconds(),
+		})
+	}
+	rsa.ruleStats.Store(ruleStats)
+}
+
 // GetStatus returns the current status on the agent
 func (rsa *RuntimeSecurityAgent) GetStatus() map[string]interface{} {
-	return map[string]interface{}{
+	status := map[string]interface{}{
 		"connected":     rsa.connected.Load(),
 		"eventReceived": atomic.LoadUint64(&rsa.eventReceived),
+		"lastHeartbeat": rsa.lastHeartbeat.Load(),
+		"probeHealth":   rsa.probeHealth.Load(),
+		"ruleStats":     rsa.ruleStats.Load(),
+	}
+	if uploader, ok := rsa.reporter.(*BatchUploader); ok {
+		status["forwarder"] = uploader.GetStatus()
 	}
+	return status
 }
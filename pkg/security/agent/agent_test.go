@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/security/api"
+)
+
+type mockReporter struct {
+	events []*event.Event
+}
+
+func (r *mockReporter) Report(event *event.Event) {
+	r.events = append(r.events, event)
+}
+
+func TestDispatchHeartbeat(t *testing.T) {
+	reporter := &mockReporter{}
+	rsa := &RuntimeSecurityAgent{hostname: "myhost", reporter: reporter}
+
+	rsa.DispatchEvent(&api.SecurityEventMessage{
+		RuleID: api.HeartbeatRuleID,
+		Type:   "heartbeat",
+		Data:   []byte(`{"probe":{"running":true},"rules":[{"rule_id":"rule1","evaluations":2}]}`),
+	})
+
+	// the heartbeats are only kept for the status
+	assert.Empty(t, reporter.events)
+	status := rsa.GetStatus()
+	assert.NotNil(t, status["lastHeartbeat"])
+	assert.Equal(t, map[string]interface{}{"running": true}, status["probeHealth"])
+
+	rsa.DispatchEvent(&api.SecurityEventMessage{RuleID: "heartbeat", Type: "exec", Data: []byte(`{}`)})
+	if assert.Len(t, reporter.events, 1) {
+		assert.Equal(t, "heartbeat", reporter.events[0].AgentRuleID)
+	}
+}
@@ -0,0 +1,191 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	batchFilePrefix = "batch-"
+	batchFileSuffix = ".json"
+)
+
+type queuedBatch struct {
+	id   uint64
+	size int64
+}
+
+// BatchQueue is a bounded on-disk FIFO queue of event batches waiting to be uploaded. Each batch
+// is saved in its own file so that the batches that weren't uploaded are kept across restarts.
+// The oldest batches are dropped once the maximum size of the queue is reached.
+type BatchQueue struct {
+	sync.Mutex
+	dir     string
+	maxSize int64
+	size    int64
+	batches []queuedBatch
+	nextID  uint64
+	dropped int64
+}
+
+// NewBatchQueue returns a batch queue saving the batches in the given directory, using at most
+// maxSize bytes. The batches already queued in the directory are kept.
+func NewBatchQueue(dir string, maxSize int64) (*BatchQueue, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid batch queue size: %d", maxSize)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "unable to create batch queue directory")
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list batch queue directory")
+	}
+
+	q := &BatchQueue{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+
+	for _, file := range files {
+		// remove the batches whose write was interrupted
+		if strings.HasSuffix(file.Name(), ".tmp") {
+			os.Remove(filepath.Join(dir, file.Name()))
+			continue
+		}
+
+		var id uint64
+		if _, err := fmt.Sscanf(file.Name(), batchFilePrefix+"%d"+batchFileSuffix, &id); err != nil {
+			continue
+		}
+		q.batches = append(q.batches, queuedBatch{id: id, size: file.Size()})
+		q.size += file.Size()
+	}
+	sort.Slice(q.batches, func(i, j int) bool { return q.batches[i].id < q.batches[j].id })
+
+	if len(q.batches) > 0 {
+		q.nextID = q.batches[len(q.batches)-1].id + 1
+	}
+
+	return q, nil
+}
+
+func (q *BatchQueue) batchPath(id uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%s%020d%s", batchFilePrefix, id, batchFileSuffix))
+}
+
+// removeOldest removes the oldest batch of the queue
+func (q *BatchQueue) removeOldest() error {
+	oldest := q.batches[0]
+	if err := os.Remove(q.batchPath(oldest.id)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "unable to remove queued batch")
+	}
+	q.batches = q.batches[1:]
+	q.size -= oldest.size
+	return nil
+}
+
+// Push saves a batch at the end of the queue, dropping the oldest batches if the queue is full
+func (q *BatchQueue) Push(payload []byte) error {
+	size := int64(len(payload))
+	if size > q.maxSize {
+		return fmt.Errorf("batch of %d bytes larger than the queue", size)
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	for len(q.batches) > 0 && q.size+size > q.maxSize {
+		if err := q.removeOldest(); err != nil {
+			return err
+		}
+		q.dropped++
+	}
+
+	// the batch is written to a temporary file first so that a truncated batch is never read back
+	id := q.nextID
+	tmpPath := q.batchPath(id) + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, payload, 0600); err != nil {
+		os.Remove(tmpPath)
+		return errors.Wrap(err, "unable to write queued batch")
+	}
+	if err := os.Rename(tmpPath, q.batchPath(id)); err != nil {
+		os.Remove(tmpPath)
+		return errors.Wrap(err, "unable to write queued batch")
+	}
+
+	q.nextID++
+	q.batches = append(q.batches, queuedBatch{id: id, size: size})
+	q.size += size
+
+	return nil
+}
+
+// Peek returns the oldest batch of the queue along with its id, or a nil payload when the queue is empty
+func (q *BatchQueue) Peek() ([]byte, uint64, error) {
+	q.Lock()
+	defer q.Unlock()
+
+	for len(q.batches) > 0 {
+		id := q.batches[0].id
+		payload, err := ioutil.ReadFile(q.batchPath(id))
+		if err == nil {
+			return payload, id, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, 0, errors.Wrap(err, "unable to read queued batch")
+		}
+		// the batch was removed from the disk, skip it
+		q.size -= q.batches[0].size
+		q.batches = q.batches[1:]
+	}
+
+	return nil, 0, nil
+}
+
+// Remove removes a batch returned by Peek once it was uploaded
+func (q *BatchQueue) Remove(id uint64) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if len(q.batches) == 0 || q.batches[0].id != id {
+		// the batch was already dropped
+		return nil
+	}
+	return q.removeOldest()
+}
+
+// Len returns the number of batches in the queue
+func (q *BatchQueue) Len() int {
+	q.Lock()
+	defer q.Unlock()
+	return len(q.batches)
+}
+
+// Size returns the size, in bytes, of the batches in the queue
+func (q *BatchQueue) Size() int64 {
+	q.Lock()
+	defer q.Unlock()
+	return q.size
+}
+
+// Dropped returns the number of batches dropped because the queue was full
+func (q *BatchQueue) Dropped() int64 {
+	q.Lock()
+	defer q.Unlock()
+	return q.dropped
+}
@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "batch-queue")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	q, err := NewBatchQueue(dir, 10)
+	require.NoError(t, err)
+
+	payload, _, err := q.Peek()
+	require.NoError(t, err)
+	assert.Nil(t, payload)
+
+	require.NoError(t, q.Push([]byte("aaaa")))
+	require.NoError(t, q.Push([]byte("bbbb")))
+	assert.Equal(t, 2, q.Len())
+	assert.Equal(t, int64(8), q.Size())
+
+	// the oldest batch is dropped when the queue is full
+	require.NoError(t, q.Push([]byte("cccc")))
+	assert.Equal(t, 2, q.Len())
+	assert.Equal(t, int64(1), q.Dropped())
+	assert.Error(t, q.Push([]byte("too large batch")))
+
+	payload, id, err := q.Peek()
+	require.NoError(t, err)
+	assert.Equal(t, "bbbb", string(payload))
+	require.NoError(t, q.Remove(id))
+
+	// the queued batches are kept across restarts, the interrupted writes are removed
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "batch-00000000000000000010.json.tmp"), []byte("x"), 0600))
+	q, err = NewBatchQueue(dir, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, q.Len())
+	require.NoError(t, q.Push([]byte("dddd")))
+
+	payload, id, err = q.Peek()
+	require.NoError(t, err)
+	assert.Equal(t, "cccc", string(payload))
+	require.NoError(t, q.Remove(id))
+
+	payload, _, err = q.Peek()
+	require.NoError(t, err)
+	assert.Equal(t, "dddd", string(payload))
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/security/policy"
+	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// clusterPoliciesRetryDelay is the delay before the policies stream is opened again once it ended
+var clusterPoliciesRetryDelay = 30 * time.Second
+
+// ClusterPoliciesWatcher writes the runtime security policies declared in the cluster, received from
+// the cluster agent, in the cluster policies directory. The runtime security module reloads its policies
+// when the directory changes.
+type ClusterPoliciesWatcher struct {
+	dir    string
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewClusterPoliciesWatcher returns a watcher writing the policies of the cluster in dir
+func NewClusterPoliciesWatcher(dir string) *ClusterPoliciesWatcher {
+	return &ClusterPoliciesWatcher{
+		dir: dir,
+	}
+}
+
+// Start watching the policies of the cluster
+func (w *ClusterPoliciesWatcher) Start() {
+	var ctx context.Context
+	ctx, w.cancel = context.WithCancel(context.Background())
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		for {
+			w.watch(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(clusterPoliciesRetryDelay):
+			}
+		}
+	}()
+}
+
+// Stop watching the policies of the cluster
+func (w *ClusterPoliciesWatcher) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// watch writes the policies received on a stream until it ends
+func (w *ClusterPoliciesWatcher) watch(ctx context.Context) {
+	client, err := clusteragent.GetClusterAgentClient()
+	if err != nil {
+		log.Debugf("Unable to connect to the cluster agent to watch the security policies: %v", err)
+		return
+	}
+
+	policies, err := client.WatchSecurityPolicies(ctx)
+	if err != nil {
+		log.Warnf("Unable to watch the security policies of the cluster: %v", err)
+		return
+	}
+
+	for received := range policies {
+		changed, err := policy.SyncClusterPolicies(w.dir, received)
+		if err != nil {
+			log.Errorf("Unable to write the security policies of the cluster: %v", err)
+		}
+		if changed {
+			log.Infof("The security policies of the cluster changed in %s, they are reloaded by the runtime security module", w.dir)
+		}
+	}
+}
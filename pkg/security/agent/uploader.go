@@ -0,0 +1,248 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	minRetryDelay = time.Second
+	maxRetryDelay = 2 * time.Minute
+)
+
+// BatchSender sends a batch of events to the intake
+type BatchSender interface {
+	Send(payload []byte) error
+}
+
+// AsyncBatchSender sends a batch of events to the intake in background, without retrying
+type AsyncBatchSender interface {
+	SendAsync(payload []byte)
+}
+
+// uploadedEvent is the representation of an event expected by the logs intake
+type uploadedEvent struct {
+	Message   string `json:"message"`
+	Status    string `json:"status"`
+	Timestamp int64  `json:"timestamp"`
+	Hostname  string `json:"hostname"`
+	Service   string `json:"service"`
+	Source    string `json:"ddsource"`
+	Tags      string `json:"ddtags"`
+}
+
+// BatchUploader is an event reporter uploading the events by batches. The batches are saved in a
+// bounded on-disk queue until they are accepted by the intake, so that the events aren't lost when
+// the intake can't be reached for a while.
+type BatchUploader struct {
+	hostname    string
+	source      string
+	queue       *BatchQueue
+	main        BatchSender
+	additionals []AsyncBatchSender
+	batchSize   int
+	batchWait   time.Duration
+
+	lock    sync.Mutex
+	pending [][]byte
+
+	wakeup     chan struct{}
+	stop       chan struct{}
+	wg         sync.WaitGroup
+	retryDelay time.Duration
+
+	uploaded int64
+	rejected int64
+}
+
+// NewBatchUploader returns a new batch uploader sending the batches queued in the given queue to the main
+// sender, and to the additional senders on a best effort basis. A batch is queued once it holds batchSize
+// events or when batchWait is elapsed.
+func NewBatchUploader(hostname, source string, queue *BatchQueue, main BatchSender, additionals []AsyncBatchSender, batchSize int, batchWait time.Duration) *BatchUploader {
+	return &BatchUploader{
+		hostname:    hostname,
+		source:      source,
+		queue:       queue,
+		main:        main,
+		additionals: additionals,
+		batchSize:   batchSize,
+		batchWait:   batchWait,
+		wakeup:      make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+	}
+}
+
+// Report adds an event to the current batch
+func (u *BatchUploader) Report(event *event.Event) {
+	buf, err := json.Marshal(event)
+	if err != nil {
+		log.Errorf("Failed to serialize rule event for rule %s", event.AgentRuleID)
+		return
+	}
+
+	data, err := json.Marshal(uploadedEvent{
+		Message:   string(buf),
+		Status:    message.StatusInfo,
+		Timestamp: time.Now().UTC().UnixNano() / int64(time.Millisecond),
+		Hostname:  u.hostname,
+		Service:   u.source,
+		Source:    u.source,
+	})
+	if err != nil {
+		log.Errorf("Failed to serialize rule event for rule %s", event.AgentRuleID)
+		return
+	}
+
+	u.lock.Lock()
+	u.pending = append(u.pending, data)
+	full := len(u.pending) >= u.batchSize
+	u.lock.Unlock()
+
+	if full {
+		u.flushPending()
+	}
+}
+
+// flushPending pushes the current batch to the queue
+func (u *BatchUploader) flushPending() {
+	u.lock.Lock()
+	pending := u.pending
+	u.pending = nil
+	u.lock.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	payload := append([]byte{'['}, bytes.Join(pending, []byte{','})...)
+	payload = append(payload, ']')
+	if err := u.queue.Push(payload); err != nil {
+		log.Errorf("Unable to queue a batch of %d security events: %s", len(pending), err)
+		return
+	}
+
+	select {
+	case u.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// upload sends the queued batches until the queue is empty or the intake can't be reached, in which
+// case it returns the delay after which the upload should be retried
+func (u *BatchUploader) upload() time.Duration {
+	for {
+		payload, id, err := u.queue.Peek()
+		if err != nil {
+			log.Errorf("Unable to read the security events queue: %s", err)
+			return u.nextRetryDelay()
+		}
+		if payload == nil {
+			return 0
+		}
+
+		err = u.main.Send(payload)
+		if err != nil {
+			// the batch is kept when the upload is interrupted by the shutdown of the destinations
+			if _, retryable := err.(*client.RetryableError); retryable || err == context.Canceled {
+				delay := u.nextRetryDelay()
+				log.Warnf("Unable to upload a batch of security events, retrying in %s: %s", delay, err)
+				return delay
+			}
+			// the batch will never be accepted, most likely because of an invalid api key
+			log.Errorf("The intake rejected a batch of security events, dropping it: %s", err)
+			atomic.AddInt64(&u.rejected, 1)
+		} else {
+			atomic.AddInt64(&u.uploaded, 1)
+			for _, additional := range u.additionals {
+				additional.SendAsync(payload)
+			}
+		}
+
+		u.retryDelay = 0
+		if err := u.queue.Remove(id); err != nil {
+			log.Errorf("Unable to remove a batch of security events from the queue: %s", err)
+			return u.nextRetryDelay()
+		}
+	}
+}
+
+// nextRetryDelay returns the delay before the next upload attempt, increased exponentially
+func (u *BatchUploader) nextRetryDelay() time.Duration {
+	u.retryDelay *= 2
+	if u.retryDelay < minRetryDelay {
+		u.retryDelay = minRetryDelay
+	}
+	if u.retryDelay > maxRetryDelay {
+		u.retryDelay = maxRetryDelay
+	}
+	return u.retryDelay
+}
+
+func (u *BatchUploader) run() {
+	defer u.wg.Done()
+
+	ticker := time.NewTicker(u.batchWait)
+	defer ticker.Stop()
+
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-ticker.C:
+			u.flushPending()
+		case <-u.wakeup:
+		case <-retry:
+			retry = nil
+		case <-u.stop:
+			return
+		}
+
+		if retry == nil {
+			if delay := u.upload(); delay > 0 {
+				retry = time.After(delay)
+			}
+		}
+	}
+}
+
+// Start starts uploading the queued batches, including the batches queued before a restart
+func (u *BatchUploader) Start() {
+	u.wg.Add(1)
+	go u.run()
+
+	select {
+	case u.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Stop stops the uploads. The current batch is queued so that it's uploaded after a restart.
+func (u *BatchUploader) Stop() {
+	close(u.stop)
+	u.wg.Wait()
+	u.flushPending()
+}
+
+// GetStatus returns the state of the batch queue and the number of uploaded batches
+func (u *BatchUploader) GetStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"queuedBatches":  u.queue.Len(),
+		"queuedBytes":    u.queue.Size(),
+		"droppedBatches": u.queue.Dropped(),
+		"uploaded":       atomic.LoadInt64(&u.uploaded),
+		"rejected":       atomic.LoadInt64(&u.rejected),
+	}
+}
@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+)
+
+type mockSender struct {
+	err      error
+	payloads [][]byte
+}
+
+func (s *mockSender) Send(payload []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.payloads = append(s.payloads, payload)
+	return nil
+}
+
+func newTestUploader(t *testing.T, sender BatchSender) (*BatchUploader, func()) {
+	dir, err := ioutil.TempDir("", "batch-uploader")
+	require.NoError(t, err)
+
+	queue, err := NewBatchQueue(dir, 1024*1024)
+	require.NoError(t, err)
+
+	return NewBatchUploader("myhost", "runtime-security-agent", queue, sender, nil, 2, time.Hour), func() { os.RemoveAll(dir) }
+}
+
+func TestBatchUploaderBatches(t *testing.T) {
+	sender := &mockSender{}
+	uploader, cleanup := newTestUploader(t, sender)
+	defer cleanup()
+
+	uploader.Report(&event.Event{AgentRuleID: "rule1"})
+	assert.Equal(t, 0, uploader.queue.Len())
+	uploader.Report(&event.Event{AgentRuleID: "rule2"})
+	assert.Equal(t, 1, uploader.queue.Len())
+
+	assert.Equal(t, time.Duration(0), uploader.upload())
+	assert.Equal(t, 0, uploader.queue.Len())
+	require.Len(t, sender.payloads, 1)
+
+	var batch []uploadedEvent
+	require.NoError(t, json.Unmarshal(sender.payloads[0], &batch))
+	require.Len(t, batch, 2)
+	assert.Equal(t, "myhost", batch[0].Hostname)
+	assert.Equal(t, "runtime-security-agent", batch[0].Source)
+	assert.Contains(t, batch[1].Message, `"agent_rule_id":"rule2"`)
+}
+
+func TestBatchUploaderRetries(t *testing.T) {
+	sender := &mockSender{err: client.NewRetryableError(errors.New("connection refused"))}
+	uploader, cleanup := newTestUploader(t, sender)
+	defer cleanup()
+
+	uploader.Report(&event.Event{AgentRuleID: "rule1"})
+	uploader.flushPending()
+
+	// the batch is kept and the retry delay increases while the intake can't be reached
+	assert.Equal(t, minRetryDelay, uploader.upload())
+	assert.Equal(t, 2*minRetryDelay, uploader.upload())
+	assert.Equal(t, 1, uploader.queue.Len())
+
+	sender.err = nil
+	assert.Equal(t, time.Duration(0), uploader.upload())
+	assert.Equal(t, 0, uploader.queue.Len())
+	assert.Len(t, sender.payloads, 1)
+
+	// the batches rejected by the intake are dropped
+	sender.err = errors.New("client error")
+	uploader.Report(&event.Event{AgentRuleID: "rule1"})
+	uploader.flushPending()
+	assert.Equal(t, time.Duration(0), uploader.upload())
+	assert.Equal(t, 0, uploader.queue.Len())
+	assert.Equal(t, int64(1), uploader.GetStatus()["rejected"])
+}
@@ -0,0 +1,15 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+// HeartbeatRuleID is the reserved rule ID of the status messages holding the heartbeats of the runtime
+// security module, which are kept by the security agent for its status and never forwarded as events.
+// The dot can't be used in the IDs of the rules of the policies.
+const HeartbeatRuleID = "runtime_security.heartbeat"
+
+// ExecDedupRuleID is the reserved rule ID of the messages reporting the number of identical execs
+// the runtime security module deduplicated
+const ExecDedupRuleID = "exec_dedup"
@@ -26,6 +26,9 @@ type Config struct {
 	BPFDir string
 	// PoliciesDir defines the folder in which the policy files are located
 	PoliciesDir string
+	// ClusterPoliciesDir defines the folder in which the security agent writes the policies of the cluster.
+	// The policies are reloaded when its content changes.
+	ClusterPoliciesDir string
 	// EnableKernelFilters defines if in-kernel filtering should be activated or not
 	EnableKernelFilters bool
 	// EnableApprovers defines if in-kernel approvers should be activated or not
@@ -49,6 +52,69 @@ type Config struct {
 	// LoadControllerControlPeriod defines the period at which the load controller will empty the user space counter used
 	// to evaluate the amount of events brought back to user space
 	LoadControllerControlPeriod time.Duration
+	// ExecDedupEnabled defines if identical execs occurring at high rates should be deduplicated
+	ExecDedupEnabled bool
+	// ExecDedupWindow defines the period during which identical execs are deduplicated
+	ExecDedupWindow time.Duration
+	// ProcessLifetimeEnabled defines if the process churn and lifetime metrics should be collected from the
+	// fork, exec and exit events
+	ProcessLifetimeEnabled bool
+	// EventQueueEnabled defines if the events should be evaluated by priority from a queue, instead of in the perf map readers
+	EventQueueEnabled bool
+	// EventQueueSize defines the maximum number of events waiting to be evaluated
+	EventQueueSize int
+	// EventStoreEnabled defines if the security events should be saved on disk
+	EventStoreEnabled bool
+	// EventStoreDir defines the folder in which the security events are saved
+	EventStoreDir string
+	// EventStoreMaxSize defines the maximum size, in bytes, of the security events saved on disk
+	EventStoreMaxSize int64
+	// SnapshotAsync defines if the running processes should be snapshotted in the background, the probe starting
+	// before the process cache is complete
+	SnapshotAsync bool
+	// SnapshotScanRate defines the maximum number of processes of /proc snapshotted per second by the asynchronous
+	// snapshot, 0 meaning no limit
+	SnapshotScanRate int
+	// EventRecorderEnabled defines if the raw kernel events should be recorded so that they can be replayed
+	EventRecorderEnabled bool
+	// EventRecorderFile defines the path of the recording of the kernel events
+	EventRecorderFile string
+	// EventRecorderMaxSize defines the size, in bytes, after which the recording stops
+	EventRecorderMaxSize int64
+	// EventRecorderMaxDuration defines the duration after which the recording stops
+	EventRecorderMaxDuration time.Duration
+	// HeartbeatEnabled defines if heartbeats, holding the health of the module, should be sent to the backend
+	HeartbeatEnabled bool
+	// HeartbeatPeriod defines the period at which the heartbeats are sent
+	HeartbeatPeriod time.Duration
+	// EnforcementEnabled defines if the actions declared by the rules should be taken. When disabled, the actions
+	// are only logged.
+	EnforcementEnabled bool
+	// RateLimiterRate defines the rate, in events per second, at which each rule can send events
+	RateLimiterRate int
+	// RateLimiterBurst defines the maximum burst of events that each rule can send
+	RateLimiterBurst int
+	// EventDedupEnabled defines if the events of a rule, on the same file from the same process, should be deduplicated
+	EventDedupEnabled bool
+	// EventDedupWindow defines the period during which identical events are deduplicated
+	EventDedupWindow time.Duration
+	// EventDedupSize defines the maximum number of events remembered to deduplicate the following ones
+	EventDedupSize int
+	// FIMEnabled defines if the integrity of the files matching the FIM patterns should be monitored
+	FIMEnabled bool
+	// FIMPatterns defines the patterns of the monitored files, with the syntax of the SECL patterns
+	FIMPatterns []string
+	// FIMHashDelay defines the delay after a change before a file is hashed, so that consecutive writes are merged
+	FIMHashDelay time.Duration
+	// FIMMaxFileSize defines the size, in bytes, above which the files aren't hashed
+	FIMMaxFileSize int64
+	// FIMCacheSize defines the maximum number of file hashes kept in cache
+	FIMCacheSize int
+	// DentryPrefetchDirectories defines the directories whose dentries, and the ones of their direct children, are
+	// loaded in the dentry cache at startup to avoid resolving their paths from the kernel maps
+	DentryPrefetchDirectories []string
+	// DentryPrefetchMaxEntries defines the maximum number of prefetched dentries
+	DentryPrefetchMaxEntries int
 }
 
 // NewConfig returns a new Config object
@@ -61,12 +127,42 @@ func NewConfig(cfg *config.AgentConfig) (*Config, error) {
 		SocketPath:                         aconfig.Datadog.GetString("runtime_security_config.socket"),
 		SyscallMonitor:                     aconfig.Datadog.GetBool("runtime_security_config.syscall_monitor.enabled"),
 		PoliciesDir:                        aconfig.Datadog.GetString("runtime_security_config.policies.dir"),
+		ClusterPoliciesDir:                 aconfig.Datadog.GetString("runtime_security_config.policies.cluster_dir"),
 		EventServerBurst:                   aconfig.Datadog.GetInt("runtime_security_config.event_server.burst"),
 		EventServerRate:                    aconfig.Datadog.GetInt("runtime_security_config.event_server.rate"),
 		PIDCacheSize:                       aconfig.Datadog.GetInt("runtime_security_config.pid_cache_size"),
 		LoadControllerEventsCountThreshold: int64(aconfig.Datadog.GetInt("runtime_security_config.load_controller.events_count_threshold")),
 		LoadControllerDiscarderTimeout:     time.Duration(aconfig.Datadog.GetInt("runtime_security_config.load_controller.discarder_timeout")) * time.Second,
 		LoadControllerControlPeriod:        time.Duration(aconfig.Datadog.GetInt("runtime_security_config.load_controller.control_period")) * time.Second,
+		ExecDedupEnabled:                   aconfig.Datadog.GetBool("runtime_security_config.exec_dedup.enabled"),
+		ExecDedupWindow:                    time.Duration(aconfig.Datadog.GetInt("runtime_security_config.exec_dedup.window")) * time.Second,
+		ProcessLifetimeEnabled:             aconfig.Datadog.GetBool("runtime_security_config.process_lifetime.enabled"),
+		EventQueueEnabled:                  aconfig.Datadog.GetBool("runtime_security_config.event_queue.enabled"),
+		EventQueueSize:                     aconfig.Datadog.GetInt("runtime_security_config.event_queue.size"),
+		EventStoreEnabled:                  aconfig.Datadog.GetBool("runtime_security_config.event_store.enabled"),
+		EventStoreDir:                      aconfig.Datadog.GetString("runtime_security_config.event_store.dir"),
+		EventStoreMaxSize:                  int64(aconfig.Datadog.GetInt("runtime_security_config.event_store.max_size")) * 1024 * 1024,
+		SnapshotAsync:                      aconfig.Datadog.GetBool("runtime_security_config.snapshot.async"),
+		SnapshotScanRate:                   aconfig.Datadog.GetInt("runtime_security_config.snapshot.scan_rate"),
+		EventRecorderEnabled:               aconfig.Datadog.GetBool("runtime_security_config.event_recorder.enabled"),
+		EventRecorderFile:                  aconfig.Datadog.GetString("runtime_security_config.event_recorder.file"),
+		EventRecorderMaxSize:               int64(aconfig.Datadog.GetInt("runtime_security_config.event_recorder.max_size")) * 1024 * 1024,
+		EventRecorderMaxDuration:           time.Duration(aconfig.Datadog.GetInt("runtime_security_config.event_recorder.max_duration")) * time.Second,
+		HeartbeatEnabled:                   aconfig.Datadog.GetBool("runtime_security_config.heartbeat.enabled"),
+		HeartbeatPeriod:                    time.Duration(aconfig.Datadog.GetInt("runtime_security_config.heartbeat.period")) * time.Second,
+		EnforcementEnabled:                 aconfig.Datadog.GetBool("runtime_security_config.enforcement_enabled"),
+		RateLimiterRate:                    aconfig.Datadog.GetInt("runtime_security_config.rate_limiter.rate"),
+		RateLimiterBurst:                   aconfig.Datadog.GetInt("runtime_security_config.rate_limiter.burst"),
+		EventDedupEnabled:                  aconfig.Datadog.GetBool("runtime_security_config.event_dedup.enabled"),
+		EventDedupWindow:                   time.Duration(aconfig.Datadog.GetInt("runtime_security_config.event_dedup.window")) * time.Second,
+		EventDedupSize:                     aconfig.Datadog.GetInt("runtime_security_config.event_dedup.size"),
+		FIMEnabled:                         aconfig.Datadog.GetBool("runtime_security_config.fim.enabled"),
+		FIMPatterns:                        aconfig.Datadog.GetStringSlice("runtime_security_config.fim.patterns"),
+		FIMHashDelay:                       time.Duration(aconfig.Datadog.GetInt("runtime_security_config.fim.hash_delay")) * time.Second,
+		FIMMaxFileSize:                     int64(aconfig.Datadog.GetInt("runtime_security_config.fim.max_file_size")) * 1024 * 1024,
+		FIMCacheSize:                       aconfig.Datadog.GetInt("runtime_security_config.fim.cache_size"),
+		DentryPrefetchDirectories:          aconfig.Datadog.GetStringSlice("runtime_security_config.dentry_prefetch.directories"),
+		DentryPrefetchMaxEntries:           aconfig.Datadog.GetInt("runtime_security_config.dentry_prefetch.max_entries"),
 	}
 
 	if cfg != nil {
@@ -0,0 +1,395 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/xc"
+)
+
+const (
+	// maxTaggedWorkloads is the maximum number of containers, or processes, whose tags set by the rule actions are kept
+	maxTaggedWorkloads = 1024
+)
+
+// Enforcer takes the actions declared by the rules on the workloads that triggered them. Every action is
+// logged and counted. When the enforcement is disabled, the actions are only logged as dry runs.
+type Enforcer struct {
+	sync.Mutex
+	enabled      bool
+	statsdClient *statsd.Client
+	tags         *simplelru.LRU
+
+	// kill and freeze are the functions applying the actions on the process of an event, given its pid and the
+	// monotonic time of the event, replaced in the tests
+	kill   func(pid uint32, eventTime uint64) error
+	freeze func(pid uint32, eventTime uint64) (string, error)
+}
+
+// NewEnforcer returns a new Enforcer
+func NewEnforcer(enabled bool, statsdClient *statsd.Client) (*Enforcer, error) {
+	tags, err := simplelru.NewLRU(maxTaggedWorkloads, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Enforcer{
+		enabled:      enabled,
+		statsdClient: statsdClient,
+		tags:         tags,
+		kill:         killProcess,
+		freeze:       freezeCgroup,
+	}, nil
+}
+
+// workloadKey returns the key under which the tags of the workload of an event are stored: the container ID,
+// or the pid outside of containers
+func workloadKey(event *sprobe.Event) string {
+	if containerID := event.Container.GetContainerID(); containerID != "" {
+		return "container:" + containerID
+	}
+	return fmt.Sprintf("pid:%d", event.Process.Pid)
+}
+
+// Apply takes the actions on the workload of an event that matched a rule
+func (e *Enforcer) Apply(rule *eval.Rule, actions []rules.ActionDefinition, event *sprobe.Event) {
+	for _, action := range actions {
+		var target string
+		var err error
+
+		if e.enabled {
+			target, err = e.apply(action, event)
+		} else {
+			target, err = e.describe(action, event), nil
+		}
+
+		e.audit(rule, action, target, err)
+	}
+}
+
+func (e *Enforcer) apply(action rules.ActionDefinition, event *sprobe.Event) (string, error) {
+	pid := event.Process.Pid
+
+	switch action.Type {
+	case rules.KillAction:
+		return fmt.Sprintf("pid %d", pid), e.kill(pid, event.TimestampRaw)
+	case rules.PauseCgroupAction:
+		cgroup, err := e.freeze(pid, event.TimestampRaw)
+		return fmt.Sprintf("cgroup %s of pid %d", cgroup, pid), err
+	case rules.SetTagAction:
+		key := workloadKey(event)
+		e.addTag(key, action.Tag)
+		return key, nil
+	}
+	return "", fmt.Errorf("unknown action type `%s`", action.Type)
+}
+
+// describe returns the target of the action that would have been taken
+func (e *Enforcer) describe(action rules.ActionDefinition, event *sprobe.Event) string {
+	switch action.Type {
+	case rules.KillAction:
+		return fmt.Sprintf("pid %d", event.Process.Pid)
+	case rules.PauseCgroupAction:
+		return fmt.Sprintf("cgroup of pid %d", event.Process.Pid)
+	}
+	return workloadKey(event)
+}
+
+// audit logs and counts an action taken, or simulated, by the enforcer
+func (e *Enforcer) audit(rule *eval.Rule, action rules.ActionDefinition, target string, err error) {
+	result := "success"
+	switch {
+	case !e.enabled:
+		result = "dry_run"
+		log.Infof("Enforcement disabled, rule `%s` would have taken action `%s` on %s", rule.ID, action.Type, target)
+	case err != nil:
+		result = "error"
+		log.Errorf("Rule `%s` failed to take action `%s` on %s: %s", rule.ID, action.Type, target, err)
+	default:
+		log.Warnf("Rule `%s` took action `%s` on %s", rule.ID, action.Type, target)
+	}
+
+	if e.statsdClient != nil {
+		tags := []string{"rule_id:" + rule.ID, "action:" + action.Type, "result:" + result}
+		if err := e.statsdClient.Count(sprobe.MetricPrefix+".enforcement.actions", 1, tags, 1.0); err != nil {
+			log.Debugf("failed to send the enforcement action metric: %s", err)
+		}
+	}
+}
+
+func (e *Enforcer) addTag(key string, tag string) {
+	e.Lock()
+	defer e.Unlock()
+
+	var tags []string
+	if value, exists := e.tags.Get(key); exists {
+		tags = value.([]string)
+		for _, t := range tags {
+			if t == tag {
+				return
+			}
+		}
+	}
+
+	// copy the tags as the previous slice may be in use by GetTags callers
+	tags = append(append([]string{}, tags...), tag)
+	e.tags.Add(key, tags)
+}
+
+// ProcessExited drops the tags set by the rule actions on a process outside of containers, so that they aren't
+// set on the events of the next process reusing its pid
+func (e *Enforcer) ProcessExited(pid uint32) {
+	e.Lock()
+	defer e.Unlock()
+
+	e.tags.Remove(fmt.Sprintf("pid:%d", pid))
+}
+
+// GetTags returns the tags set by the rule actions on the workload of an event
+func (e *Enforcer) GetTags(event *sprobe.Event) []string {
+	e.Lock()
+	defer e.Unlock()
+
+	if value, exists := e.tags.Get(workloadKey(event)); exists {
+		return value.([]string)
+	}
+	return nil
+}
+
+// processHandle refers to the process of an event. It holds a pidfd, when the kernel supports them, so that the
+// pid can't be reused by another process between the identity check and the action.
+type processHandle struct {
+	pid   uint32
+	pidfd int
+}
+
+// openProcess returns a handle to the process with the given pid, provided it's the process of an event: a
+// process started after the event reuses the pid of the process of the event, which exited in between.
+func openProcess(pid uint32, eventTime uint64) (*processHandle, error) {
+	p := &processHandle{pid: pid, pidfd: -1}
+
+	pidfd, _, errno := unix.Syscall(unix.SYS_PIDFD_OPEN, uintptr(pid), 0, 0)
+	switch errno {
+	case 0:
+		p.pidfd = int(pidfd)
+	case unix.ENOSYS:
+		// pidfds are available since Linux 5.3, without them the pid is checked again after reading the state of
+		// the process, and the actions that can't be undone are refused
+	default:
+		return nil, errors.Wrapf(errno, "couldn't open pid %d", pid)
+	}
+
+	if err := p.checkIdentity(eventTime); err != nil {
+		p.close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// checkIdentity returns an error when the process was started after the event
+func (p *processHandle) checkIdentity(eventTime uint64) error {
+	startTicks, err := processStartTime(p.pid)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't get the start time of pid %d", p.pid)
+	}
+
+	hz, err := xc.GetSystemFreq()
+	if err != nil || hz <= 0 {
+		return errors.New("couldn't get the clock ticks per second")
+	}
+
+	// both times are compared in clock ticks, rounded down, so the process of the event never fails the check. The
+	// start time includes the time the host was suspended, unlike the event time, which only makes the check stricter.
+	if startTicks > nanosecondsToTicks(eventTime, uint64(hz)) {
+		return fmt.Errorf("pid %d was reused by a process started after the event", p.pid)
+	}
+	return nil
+}
+
+// nanosecondsToTicks converts a time in nanoseconds to clock ticks, given the number of ticks per second
+func nanosecondsToTicks(ns uint64, hz uint64) uint64 {
+	return ns/1e9*hz + ns%1e9*hz/1e9
+}
+
+// signal sends a signal to the process, the signal 0 checking that the process is still alive
+func (p *processHandle) signal(sig syscall.Signal) error {
+	if p.pidfd == -1 {
+		return syscall.Kill(int(p.pid), sig)
+	}
+
+	if _, _, errno := unix.Syscall6(unix.SYS_PIDFD_SEND_SIGNAL, uintptr(p.pidfd), uintptr(sig), 0, 0, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (p *processHandle) close() {
+	if p.pidfd != -1 {
+		unix.Close(p.pidfd)
+	}
+}
+
+// processStartTime returns the time the process was started at, in clock ticks since boot, from its stat file
+func processStartTime(pid uint32) (uint64, error) {
+	stat, err := ioutil.ReadFile(util.HostProc(strconv.FormatUint(uint64(pid), 10), "stat"))
+	if err != nil {
+		return 0, err
+	}
+	return parseStartTime(string(stat))
+}
+
+// parseStartTime returns the start time, in clock ticks, of the 22nd field of a stat file, the comm of the process
+// being enclosed in parentheses which may be part of the comm
+func parseStartTime(stat string) (uint64, error) {
+	end := strings.LastIndexByte(stat, ')')
+	if end == -1 {
+		return 0, errors.New("invalid stat file")
+	}
+
+	// the fields following the comm start with the 3rd field
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 20 {
+		return 0, errors.New("invalid stat file")
+	}
+
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid start time")
+	}
+	return ticks, nil
+}
+
+// killProcess sends a SIGKILL to the process of an event. The init process and system-probe itself are never
+// killed. The kill is refused without pidfds, as the pid could be reused between the identity check and the
+// signal, and the process killed can't be checked afterwards.
+func killProcess(pid uint32, eventTime uint64) error {
+	if pid <= 1 || int(pid) == os.Getpid() {
+		return fmt.Errorf("refusing to kill pid %d", pid)
+	}
+
+	p, err := openProcess(pid, eventTime)
+	if err != nil {
+		return err
+	}
+	defer p.close()
+
+	if p.pidfd == -1 {
+		return fmt.Errorf("refusing to kill pid %d without pidfd support, it may have been reused", pid)
+	}
+	return p.signal(syscall.SIGKILL)
+}
+
+// freezeCgroup freezes the cgroup of the process of an event, using the freezer controller with cgroup v1 and
+// cgroup.freeze with cgroup v2. It returns the path of the frozen cgroup. Neither the root cgroup nor
+// the cgroup of system-probe, or one of its parents, are ever frozen.
+func freezeCgroup(pid uint32, eventTime uint64) (string, error) {
+	self := uint32(os.Getpid())
+	if pid == self {
+		return "", errors.New("refusing to freeze the cgroup of system-probe")
+	}
+
+	p, err := openProcess(pid, eventTime)
+	if err != nil {
+		return "", err
+	}
+	defer p.close()
+
+	cgroups, err := utils.GetProcControlGroups(pid, pid)
+	if err != nil {
+		return "", errors.Wrapf(err, "couldn't get the cgroups of pid %d", pid)
+	}
+
+	// the cgroups were read from the process of the event only if it's still alive, or still the owner of the pid
+	// without pidfds
+	if err := p.signal(0); err != nil {
+		return "", errors.Wrapf(err, "pid %d exited", pid)
+	}
+	if p.pidfd == -1 {
+		if err := p.checkIdentity(eventTime); err != nil {
+			return "", err
+		}
+	}
+
+	cgroup, found := freezerCgroup(cgroups)
+	if !found {
+		return "", fmt.Errorf("no freezer cgroup found for pid %d", pid)
+	}
+
+	selfCgroups, err := utils.GetProcControlGroups(self, self)
+	if err != nil {
+		return cgroup.Path, errors.Wrap(err, "couldn't get the cgroups of system-probe")
+	}
+	if err := checkFreezable(cgroup, selfCgroups); err != nil {
+		return cgroup.Path, err
+	}
+
+	if cgroup.ID == 0 {
+		return cgroup.Path, ioutil.WriteFile(filepath.Join(util.HostSys("fs/cgroup"), cgroup.Path, "cgroup.freeze"), []byte("1"), 0644)
+	}
+	return cgroup.Path, ioutil.WriteFile(filepath.Join(util.HostSys("fs/cgroup/freezer"), cgroup.Path, "freezer.state"), []byte("FROZEN"), 0644)
+}
+
+// freezerCgroup returns the cgroup of the freezer hierarchy among the cgroups of a process, or its cgroup of the
+// unified hierarchy when the freezer controller isn't mounted
+func freezerCgroup(cgroups []utils.ControlGroup) (utils.ControlGroup, bool) {
+	var unified *utils.ControlGroup
+	for i, cgroup := range cgroups {
+		for _, controller := range cgroup.Controllers {
+			if controller == "freezer" {
+				return cgroup, true
+			}
+		}
+
+		if cgroup.ID == 0 {
+			unified = &cgroups[i]
+		}
+	}
+
+	if unified != nil {
+		return *unified, true
+	}
+	return utils.ControlGroup{}, false
+}
+
+// checkFreezable returns an error when freezing the cgroup would freeze system-probe, given its own cgroups
+func checkFreezable(cgroup utils.ControlGroup, self []utils.ControlGroup) error {
+	path := filepath.Clean(cgroup.Path)
+	if path == "/" {
+		return errors.New("refusing to freeze the root cgroup")
+	}
+
+	for _, selfCgroup := range self {
+		if selfCgroup.ID != cgroup.ID {
+			continue
+		}
+		selfPath := filepath.Clean(selfCgroup.Path)
+		if selfPath == path || strings.HasPrefix(selfPath, path+"/") {
+			return fmt.Errorf("refusing to freeze the cgroup %s of system-probe", path)
+		}
+	}
+	return nil
+}
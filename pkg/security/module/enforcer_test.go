@@ -0,0 +1,185 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+)
+
+func newTestEnforcer(t *testing.T, enabled bool) (*Enforcer, *[]string) {
+	enforcer, err := NewEnforcer(enabled, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var taken []string
+	enforcer.kill = func(pid uint32, eventTime uint64) error {
+		taken = append(taken, rules.KillAction)
+		return nil
+	}
+	enforcer.freeze = func(pid uint32, eventTime uint64) (string, error) {
+		taken = append(taken, rules.PauseCgroupAction)
+		return "", errors.New("no freezer")
+	}
+	return enforcer, &taken
+}
+
+func TestEnforcerApply(t *testing.T) {
+	rule := &eval.Rule{ID: "rule1"}
+	actions := []rules.ActionDefinition{
+		{Type: rules.KillAction},
+		{Type: rules.PauseCgroupAction},
+		{Type: rules.SetTagAction, Tag: "compromised:true"},
+		{Type: rules.SetTagAction, Tag: "compromised:true"},
+	}
+
+	event := &sprobe.Event{}
+	event.Process.Pid = 4242
+
+	other := &sprobe.Event{}
+	other.Process.Pid = 4343
+
+	t.Run("enabled", func(t *testing.T) {
+		enforcer, taken := newTestEnforcer(t, true)
+
+		assert.Empty(t, enforcer.GetTags(event))
+		enforcer.Apply(rule, actions, event)
+
+		assert.Equal(t, []string{rules.KillAction, rules.PauseCgroupAction}, *taken)
+		assert.Equal(t, []string{"compromised:true"}, enforcer.GetTags(event))
+		assert.Empty(t, enforcer.GetTags(other))
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		enforcer, taken := newTestEnforcer(t, false)
+
+		enforcer.Apply(rule, actions, event)
+
+		assert.Empty(t, *taken)
+		assert.Empty(t, enforcer.GetTags(event))
+	})
+}
+
+func TestEnforcerContainerTags(t *testing.T) {
+	enforcer, _ := newTestEnforcer(t, true)
+
+	containerID := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	event := &sprobe.Event{}
+	event.Process.Pid = 4242
+	event.Container.ID = containerID
+
+	enforcer.Apply(&eval.Rule{ID: "rule1"}, []rules.ActionDefinition{{Type: rules.SetTagAction, Tag: "quarantine"}}, event)
+
+	// the subsequent events of the container are tagged, whatever their process
+	next := &sprobe.Event{}
+	next.Process.Pid = 4343
+	next.Container.ID = containerID
+	assert.Equal(t, []string{"quarantine"}, enforcer.GetTags(next))
+}
+
+func TestEnforcerProcessExited(t *testing.T) {
+	enforcer, _ := newTestEnforcer(t, true)
+
+	event := &sprobe.Event{}
+	event.Process.Pid = 4242
+
+	container := &sprobe.Event{}
+	container.Process.Pid = 4343
+	container.Container.ID = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	actions := []rules.ActionDefinition{{Type: rules.SetTagAction, Tag: "quarantine"}}
+	enforcer.Apply(&eval.Rule{ID: "rule1"}, actions, event)
+	enforcer.Apply(&eval.Rule{ID: "rule1"}, actions, container)
+
+	// the next process reusing the pid isn't tagged, the containers are tagged until they're gone
+	enforcer.ProcessExited(4242)
+	enforcer.ProcessExited(4343)
+	assert.Empty(t, enforcer.GetTags(event))
+	assert.Equal(t, []string{"quarantine"}, enforcer.GetTags(container))
+}
+
+func TestKillProcessSafety(t *testing.T) {
+	assert.Error(t, killProcess(0, 0))
+	assert.Error(t, killProcess(1, 0))
+}
+
+func TestParseStartTime(t *testing.T) {
+	startTicks, err := parseStartTime("4242 (sleep (1)) S 1 4242 4242 0 -1 4194304 107 0 0 0 0 0 0 0 20 0 1 0 12345 8327168 194 18446744073709551615")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12345), startTicks)
+
+	_, err = parseStartTime("4242 (sleep) S 1")
+	assert.Error(t, err)
+}
+
+func TestNanosecondsToTicks(t *testing.T) {
+	assert.Equal(t, uint64(0), nanosecondsToTicks(uint64(9*time.Millisecond), 100))
+	assert.Equal(t, uint64(1), nanosecondsToTicks(uint64(10*time.Millisecond), 100))
+	assert.Equal(t, uint64(123456), nanosecondsToTicks(uint64(1234567*time.Millisecond), 100))
+	assert.Equal(t, uint64(1234567), nanosecondsToTicks(uint64(1234567*time.Millisecond), 1000))
+}
+
+func TestOpenProcess(t *testing.T) {
+	var now unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &now); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := openProcess(uint32(os.Getpid()), uint64(now.Nano()))
+	assert.NoError(t, err)
+	assert.NoError(t, p.signal(0))
+	p.close()
+
+	// the process was started after the event
+	_, err = openProcess(uint32(os.Getpid()), 0)
+	assert.Error(t, err)
+}
+
+func TestFreezerCgroup(t *testing.T) {
+	cgroup, found := freezerCgroup([]utils.ControlGroup{
+		{ID: 0, Path: "/system.slice/docker-abc.scope"},
+		{ID: 7, Controllers: []string{"freezer"}, Path: "/docker/abc"},
+	})
+	assert.True(t, found)
+	assert.Equal(t, utils.ControlGroup{ID: 7, Controllers: []string{"freezer"}, Path: "/docker/abc"}, cgroup)
+
+	cgroup, found = freezerCgroup([]utils.ControlGroup{{ID: 0, Path: "/system.slice/docker-abc.scope"}})
+	assert.True(t, found)
+	assert.Equal(t, "/system.slice/docker-abc.scope", cgroup.Path)
+
+	_, found = freezerCgroup([]utils.ControlGroup{{ID: 3, Controllers: []string{"memory"}, Path: "/docker/abc"}})
+	assert.False(t, found)
+}
+
+func TestCheckFreezable(t *testing.T) {
+	self := []utils.ControlGroup{
+		{ID: 0, Path: "/system.slice/datadog-agent-sysprobe.service"},
+		{ID: 7, Controllers: []string{"freezer"}, Path: "/kubepods/pod1/agent"},
+	}
+
+	assert.Error(t, checkFreezable(utils.ControlGroup{ID: 7, Path: "/"}, self))
+	assert.Error(t, checkFreezable(utils.ControlGroup{ID: 7, Path: "/kubepods/pod1/agent"}, self))
+	assert.Error(t, checkFreezable(utils.ControlGroup{ID: 7, Path: "/kubepods/pod1/"}, self))
+	assert.Error(t, checkFreezable(utils.ControlGroup{ID: 0, Path: "/system.slice/datadog-agent-sysprobe.service"}, self))
+
+	assert.NoError(t, checkFreezable(utils.ControlGroup{ID: 7, Path: "/kubepods/pod1/agent-sidecar"}, self))
+	assert.NoError(t, checkFreezable(utils.ControlGroup{ID: 7, Path: "/kubepods/pod2"}, self))
+	assert.NoError(t, checkFreezable(utils.ControlGroup{ID: 0, Path: "/kubepods/pod1/agent"}, self))
+}
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/hashicorp/golang-lru/simplelru"
+
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+)
+
+// eventDedupKey identifies identical events: the ones matching the same rule, triggered by the same
+// process on the same file
+type eventDedupKey struct {
+	RuleID  string
+	Pid     uint32
+	MountID uint32
+	Inode   uint64
+}
+
+// EventDeduplicator drops the events identical to an event sent within the dedup window, so that a process
+// repeatedly accessing the same file doesn't use the rate limit of a rule. Only the events targeting a file
+// are deduplicated. The number of deduplicated events is reported per rule.
+type EventDeduplicator struct {
+	sync.Mutex
+	firstSeen *simplelru.LRU
+	window    time.Duration
+	// pending holds the deduplicated events counts that haven't been reported yet, per rule
+	pending map[string]int64
+}
+
+// NewEventDeduplicator returns a new event deduplicator remembering at most size events
+func NewEventDeduplicator(size int, window time.Duration) (*EventDeduplicator, error) {
+	lru, err := simplelru.NewLRU(size, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventDeduplicator{
+		firstSeen: lru,
+		window:    window,
+		pending:   make(map[string]int64),
+	}, nil
+}
+
+// IsDuplicate returns true if an identical event was seen within the dedup window. Otherwise the event
+// is remembered to deduplicate the following ones.
+func (d *EventDeduplicator) IsDuplicate(ruleID string, event *sprobe.Event, now time.Time) bool {
+	file := event.GetFile()
+	if file == nil {
+		return false
+	}
+
+	key := eventDedupKey{
+		RuleID:  ruleID,
+		Pid:     event.Process.Pid,
+		MountID: file.MountID,
+		Inode:   file.Inode,
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if value, ok := d.firstSeen.Get(key); ok && now.Sub(value.(time.Time)) <= d.window {
+		d.pending[ruleID]++
+		return true
+	}
+
+	d.firstSeen.Add(key, now)
+	return false
+}
+
+// SendStats sends the counts of deduplicated events
+func (d *EventDeduplicator) SendStats(client *statsd.Client) error {
+	d.Lock()
+	pending := d.pending
+	d.pending = make(map[string]int64)
+	d.Unlock()
+
+	for ruleID, count := range pending {
+		tags := []string{fmt.Sprintf("rule_id:%s", ruleID)}
+		if err := client.Count(sprobe.MetricPrefix+".rules.event_dedup.deduplicated", count, tags, 1.0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
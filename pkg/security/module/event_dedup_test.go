@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+)
+
+func newOpenEvent(pid uint32, mountID uint32, inode uint64) *sprobe.Event {
+	event := &sprobe.Event{Type: uint64(sprobe.FileOpenEventType)}
+	event.Process.Pid = pid
+	event.Open.MountID = mountID
+	event.Open.Inode = inode
+	return event
+}
+
+func TestEventDeduplicator(t *testing.T) {
+	d, err := NewEventDeduplicator(100, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	assert.False(t, d.IsDuplicate("rule1", newOpenEvent(42, 1, 33), now))
+
+	// identical event within the window
+	assert.True(t, d.IsDuplicate("rule1", newOpenEvent(42, 1, 33), now.Add(time.Second)))
+	assert.Equal(t, int64(1), d.pending["rule1"])
+
+	// other rule, process or file
+	assert.False(t, d.IsDuplicate("rule2", newOpenEvent(42, 1, 33), now.Add(time.Second)))
+	assert.False(t, d.IsDuplicate("rule1", newOpenEvent(43, 1, 33), now.Add(time.Second)))
+	assert.False(t, d.IsDuplicate("rule1", newOpenEvent(42, 1, 34), now.Add(time.Second)))
+
+	// the window starts at the first event, the event is sent again once it has expired
+	assert.True(t, d.IsDuplicate("rule1", newOpenEvent(42, 1, 33), now.Add(5*time.Second)))
+	assert.False(t, d.IsDuplicate("rule1", newOpenEvent(42, 1, 33), now.Add(6*time.Second)))
+	assert.True(t, d.IsDuplicate("rule1", newOpenEvent(42, 1, 33), now.Add(7*time.Second)))
+	assert.Equal(t, int64(3), d.pending["rule1"])
+
+	// the events without file aren't deduplicated
+	event := &sprobe.Event{Type: uint64(sprobe.ExitEventType)}
+	event.Process.Pid = 42
+	assert.False(t, d.IsDuplicate("rule3", event, now))
+	assert.False(t, d.IsDuplicate("rule3", event, now))
+}
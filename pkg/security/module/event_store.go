@@ -0,0 +1,347 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package module
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	eventStoreSegmentPrefix = "events-"
+	eventStoreSegmentSuffix = ".json"
+	// eventStoreSegments is the number of files the store size is split into. When the store is full,
+	// the oldest file is removed.
+	eventStoreSegments = 10
+	// eventStoreMaxLineSize is the size of the largest event that can be read back from the store
+	eventStoreMaxLineSize = 1024 * 1024
+	// eventStoreQueueSize is the number of events waiting to be written, the events stored while it's full
+	// are dropped
+	eventStoreQueueSize = 1000
+)
+
+// StoredEvent is a security event saved in the local event store
+type StoredEvent struct {
+	Timestamp   time.Time       `json:"timestamp"`
+	RuleID      string          `json:"rule_id"`
+	ContainerID string          `json:"container_id,omitempty"`
+	Pid         uint32          `json:"pid,omitempty"`
+	Type        string          `json:"type"`
+	Tags        []string        `json:"tags,omitempty"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// EventQuery describes the events returned by an event store query. Empty fields match any event.
+type EventQuery struct {
+	From        time.Time
+	To          time.Time
+	RuleID      string
+	ContainerID string
+	// Limit is the maximum number of events returned, the most recent events being kept
+	Limit int
+}
+
+func (q *EventQuery) match(event *StoredEvent) bool {
+	if !q.From.IsZero() && event.Timestamp.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && event.Timestamp.After(q.To) {
+		return false
+	}
+	if q.RuleID != "" && event.RuleID != q.RuleID {
+		return false
+	}
+	if q.ContainerID != "" && event.ContainerID != q.ContainerID {
+		return false
+	}
+	return true
+}
+
+// EventStore is an on-disk ring buffer of the most recent security events. Events are appended
+// as JSON lines to a set of segment files, the oldest segment being removed once the maximum
+// size of the store is reached. The events are encoded and written by a goroutine of the store,
+// so that the event path never waits for the disk.
+type EventStore struct {
+	sync.Mutex
+	dir         string
+	segmentSize int64
+	segments    []uint64
+	current     *os.File
+	currentSize int64
+
+	requests  chan eventStoreRequest
+	closed    chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+	dropped   int64
+}
+
+// eventStoreRequest is either an event to write or, when flushed is set, a request to be notified once
+// the events queued before it are written
+type eventStoreRequest struct {
+	event   StoredEvent
+	flushed chan struct{}
+}
+
+// NewEventStore returns an event store saving the events in the given directory, using at most
+// maxSize bytes. The events already stored in the directory are kept.
+func NewEventStore(dir string, maxSize int64) (*EventStore, error) {
+	if maxSize < eventStoreSegments {
+		return nil, fmt.Errorf("invalid event store size: %d", maxSize)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "unable to create event store directory")
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list event store directory")
+	}
+
+	s := &EventStore{
+		dir:         dir,
+		segmentSize: maxSize / eventStoreSegments,
+		requests:    make(chan eventStoreRequest, eventStoreQueueSize),
+		closed:      make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+
+	for _, file := range files {
+		var id uint64
+		if _, err := fmt.Sscanf(file.Name(), eventStoreSegmentPrefix+"%d"+eventStoreSegmentSuffix, &id); err != nil {
+			continue
+		}
+		s.segments = append(s.segments, id)
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i] < s.segments[j] })
+
+	// start a new segment, the last one may end with a truncated event
+	if err := s.rotate(); err != nil {
+		return nil, err
+	}
+
+	go s.run()
+
+	return s, nil
+}
+
+// run writes the queued events until the store is closed, the events queued before are written
+func (s *EventStore) run() {
+	defer close(s.done)
+
+	handle := func(req eventStoreRequest) {
+		if req.flushed != nil {
+			close(req.flushed)
+			return
+		}
+		if err := s.write(&req.event); err != nil {
+			log.Debugf("unable to store event of rule `%s`: %s", req.event.RuleID, err)
+		}
+	}
+
+	for {
+		select {
+		case req := <-s.requests:
+			handle(req)
+		case <-s.closed:
+			for {
+				select {
+				case req := <-s.requests:
+					handle(req)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *EventStore) segmentPath(id uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s%020d%s", eventStoreSegmentPrefix, id, eventStoreSegmentSuffix))
+}
+
+func (s *EventStore) openSegment(id uint64) error {
+	f, err := os.OpenFile(s.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Wrap(err, "unable to open event store segment")
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "unable to open event store segment")
+	}
+
+	s.current = f
+	s.currentSize = fi.Size()
+
+	return nil
+}
+
+// rotate closes the current segment, opens a new one and removes the oldest segments
+func (s *EventStore) rotate() error {
+	var id uint64
+	if len(s.segments) > 0 {
+		id = s.segments[len(s.segments)-1] + 1
+	}
+
+	if s.current != nil {
+		s.current.Close()
+		s.current = nil
+	}
+
+	if err := s.openSegment(id); err != nil {
+		return err
+	}
+	s.segments = append(s.segments, id)
+
+	for len(s.segments) > eventStoreSegments {
+		if err := os.Remove(s.segmentPath(s.segments[0])); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "unable to remove event store segment")
+		}
+		s.segments = s.segments[1:]
+	}
+
+	return nil
+}
+
+// Store queues an event to be saved. The event is dropped when too many events are waiting to be written.
+func (s *EventStore) Store(event *StoredEvent) error {
+	select {
+	case <-s.closed:
+		return errors.New("event store closed")
+	default:
+	}
+
+	select {
+	case s.requests <- eventStoreRequest{event: *event}:
+		return nil
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+		return errors.New("event store queue full")
+	}
+}
+
+// flush waits until the events queued so far are written
+func (s *EventStore) flush() {
+	flushed := make(chan struct{})
+	select {
+	case s.requests <- eventStoreRequest{flushed: flushed}:
+	case <-s.closed:
+		<-s.done
+		return
+	}
+
+	select {
+	case <-flushed:
+	case <-s.done:
+	}
+}
+
+// write saves an event
+func (s *EventStore) write(event *StoredEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.current == nil {
+		return errors.New("event store closed")
+	}
+
+	if s.currentSize > 0 && s.currentSize+int64(len(data)) > s.segmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.current.Write(data)
+	s.currentSize += int64(n)
+
+	return err
+}
+
+// Query returns the stored events matching the query, from the oldest to the most recent. The events
+// queued before are written first.
+func (s *EventStore) Query(query EventQuery) ([]StoredEvent, error) {
+	s.flush()
+
+	s.Lock()
+	defer s.Unlock()
+
+	events := []StoredEvent{}
+	for _, id := range s.segments {
+		f, err := os.Open(s.segmentPath(id))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, eventStoreMaxLineSize)
+		for scanner.Scan() {
+			var event StoredEvent
+			// lines can be truncated if the agent was stopped while writing them, skip them
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				continue
+			}
+
+			if !query.match(&event) {
+				continue
+			}
+
+			events = append(events, event)
+			if query.Limit > 0 && len(events) > query.Limit {
+				events = events[1:]
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read event store segment %d", id)
+		}
+	}
+
+	return events, nil
+}
+
+// Close writes the queued events and closes the event store
+func (s *EventStore) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	<-s.done
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.current == nil {
+		return nil
+	}
+
+	err := s.current.Close()
+	s.current = nil
+
+	return err
+}
@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package module
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEvent(ts time.Time, ruleID, containerID string) *StoredEvent {
+	return &StoredEvent{
+		Timestamp:   ts,
+		RuleID:      ruleID,
+		ContainerID: containerID,
+		Type:        "open",
+		Tags:        []string{"rule_id:" + ruleID},
+		Data:        json.RawMessage(`{"file":{"path":"/etc/shadow"}}`),
+	}
+}
+
+func TestEventStoreQuery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewEventStore(dir, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i := 0; i < 10; i++ {
+		containerID := ""
+		if i%2 == 0 {
+			containerID = "abc"
+		}
+		ruleID := "rule_a"
+		if i >= 5 {
+			ruleID = "rule_b"
+		}
+		if err := store.Store(newTestEvent(now.Add(time.Duration(i)*time.Minute), ruleID, containerID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := store.Query(EventQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, events, 10)
+	assert.Equal(t, `{"file":{"path":"/etc/shadow"}}`, string(events[0].Data))
+
+	events, _ = store.Query(EventQuery{RuleID: "rule_b"})
+	assert.Len(t, events, 5)
+
+	events, _ = store.Query(EventQuery{RuleID: "rule_a", ContainerID: "abc"})
+	assert.Len(t, events, 3)
+
+	events, _ = store.Query(EventQuery{From: now.Add(2 * time.Minute), To: now.Add(4 * time.Minute)})
+	assert.Len(t, events, 3)
+
+	// the most recent events are kept
+	events, _ = store.Query(EventQuery{Limit: 2})
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, now.Add(8*time.Minute), events[0].Timestamp)
+		assert.Equal(t, now.Add(9*time.Minute), events[1].Timestamp)
+	}
+}
+
+func TestEventStoreRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	event := newTestEvent(time.Now(), "rule_a", "")
+	data, _ := json.Marshal(event)
+
+	// each segment holds 2 events
+	store, err := NewEventStore(dir, int64(len(data)+1)*2*eventStoreSegments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		event.Timestamp = event.Timestamp.Add(time.Second)
+		if err := store.Store(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := store.Query(EventQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, eventStoreSegmentPrefix+"*"))
+	assert.Len(t, files, eventStoreSegments)
+	assert.Len(t, events, 2*eventStoreSegments)
+	assert.Equal(t, event.Timestamp.UnixNano(), events[len(events)-1].Timestamp.UnixNano())
+
+	store.Close()
+
+	// truncated event
+	f, err := os.OpenFile(files[len(files)-1], os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(f, `{"timestamp":"2020`)
+	f.Close()
+
+	// the stored events are kept when the store is opened again
+	store, err = NewEventStore(dir, int64(len(data)+1)*2*eventStoreSegments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if err := store.Store(event); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err = store.Query(EventQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, events, 2*(eventStoreSegments-1)+1)
+}
+
+func TestEventStoreClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewEventStore(dir, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i := 0; i < 10; i++ {
+		if err := store.Store(newTestEvent(now.Add(time.Duration(i)*time.Second), "rule_a", "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the queued events are written when the store is closed
+	assert.NoError(t, store.Close())
+	assert.Error(t, store.Store(newTestEvent(now, "rule_a", "")))
+
+	store, err = NewEventStore(dir, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	events, err := store.Query(EventQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, events, 10)
+}
@@ -0,0 +1,562 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/hashicorp/golang-lru/simplelru"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// File integrity changes
+const (
+	// FIMChangeModified is reported when the content of a file changed, or when its previous content is unknown
+	FIMChangeModified = "modified"
+	// FIMChangeAttributes is reported when only the owner or the mode of a file changed
+	FIMChangeAttributes = "attributes"
+	// FIMChangeDeleted is reported when a file was removed
+	FIMChangeDeleted = "deleted"
+	// FIMChangeRenamed is reported when a file was moved to another path
+	FIMChangeRenamed = "renamed"
+)
+
+// IDs of the rules generated for the file integrity monitoring. The dot isn't accepted in the IDs of the
+// policy rules, so that they never clash with them.
+const (
+	fimOpenRuleID   = "fim.open"
+	fimRenameRuleID = "fim.rename"
+	fimUnlinkRuleID = "fim.unlink"
+	fimChmodRuleID  = "fim.chmod"
+	fimChownRuleID  = "fim.chown"
+)
+
+// fimRules maps the ids of the rules generated for the file integrity monitoring to the expressions of
+// their event type. The %s is replaced by the field matching the monitored patterns.
+var fimRules = map[string]string{
+	fimOpenRuleID:   `(%s) && open.flags & (O_CREAT | O_TRUNC | O_RDWR | O_WRONLY) > 0`,
+	fimRenameRuleID: `%s`,
+	fimUnlinkRuleID: `%s`,
+	fimChmodRuleID:  `%s`,
+	fimChownRuleID:  `%s`,
+}
+
+// fimRuleFields lists the fields holding the paths of the files changed by the events of each rule
+var fimRuleFields = map[string][]string{
+	fimOpenRuleID:   {"open.filename"},
+	fimRenameRuleID: {"rename.old.filename", "rename.new.filename"},
+	fimUnlinkRuleID: {"unlink.filename"},
+	fimChmodRuleID:  {"chmod.filename"},
+	fimChownRuleID:  {"chown.filename"},
+}
+
+// FileState is the state of a monitored file
+type FileState struct {
+	Hash string `json:"hash,omitempty"`
+	UID  uint32 `json:"uid"`
+	GID  uint32 `json:"gid"`
+	Mode uint32 `json:"mode"`
+	Size int64  `json:"size"`
+}
+
+func (s *FileState) sameContent(other *FileState) bool {
+	return s.Hash != "" && s.Hash == other.Hash && s.Size == other.Size
+}
+
+func (s *FileState) sameAttributes(other *FileState) bool {
+	return s.UID == other.UID && s.GID == other.GID && s.Mode == other.Mode
+}
+
+// FIMProcess is the process that changed a monitored file, along with its ancestors
+type FIMProcess struct {
+	Pid       uint32   `json:"pid"`
+	Filename  string   `json:"filename,omitempty"`
+	UID       uint32   `json:"uid"`
+	User      string   `json:"user,omitempty"`
+	Ancestors []string `json:"ancestors,omitempty"`
+}
+
+// IntegrityEvent describes a change of a monitored file, with its state before and after the change. The
+// previous state is unknown for the files that weren't hashed before the change.
+type IntegrityEvent struct {
+	Timestamp   time.Time  `json:"timestamp"`
+	Path        string     `json:"path"`
+	ContainerID string     `json:"container_id,omitempty"`
+	Change      string     `json:"change"`
+	RenamedTo   string     `json:"renamed_to,omitempty"`
+	Before      *FileState `json:"before,omitempty"`
+	After       *FileState `json:"after,omitempty"`
+	Process     FIMProcess `json:"process"`
+}
+
+// fileChange is a change of a monitored file reported by an event
+type fileChange struct {
+	ruleID      string
+	path        string
+	containerID string
+	// root is the root directory of the process that changed a file of a container, opened when the event was
+	// received so that the file can still be read once the process exited
+	root      *os.File
+	deleted   bool
+	renamedTo string
+	process   FIMProcess
+}
+
+func (c *fileChange) key() string {
+	return c.containerID + ":" + c.path
+}
+
+// hostPath returns the path from which the file can be read by system-probe
+func (c *fileChange) hostPath() string {
+	if c.root == nil {
+		return c.path
+	}
+	return fmt.Sprintf("/proc/self/fd/%d%s", c.root.Fd(), c.path)
+}
+
+// close releases the root directory of the change, if any
+func (c *fileChange) close() {
+	if c.root != nil {
+		c.root.Close()
+		c.root = nil
+	}
+}
+
+type pendingChange struct {
+	fileChange
+	before   *FileState
+	deadline time.Time
+}
+
+// FileIntegrityMonitor hashes the files matching the monitored patterns when they're changed, and reports
+// their changes as integrity events. The changes are detected by rules generated on the open, rename,
+// unlink, chmod and chown events, the files being hashed once no change occurred during the hash delay.
+type FileIntegrityMonitor struct {
+	sync.Mutex
+	patterns    []string
+	regexps     []*regexp.Regexp
+	hashDelay   time.Duration
+	maxFileSize int64
+	states      *simplelru.LRU
+	cacheSize   int
+	pending     map[string]*pendingChange
+	send        func(ruleID string, event *IntegrityEvent)
+
+	// stats holds the counts of integrity events that haven't been reported yet, per change
+	stats      map[string]int64
+	hashErrors int64
+}
+
+// patternRegexp returns the regexp of a pattern, with the syntax of the SECL patterns: a wildcard is only
+// accepted in the last element of the path, and matches any file below.
+func patternRegexp(pattern string) (*regexp.Regexp, error) {
+	if !filepath.IsAbs(pattern) {
+		return nil, fmt.Errorf("invalid FIM pattern `%s`: the path must be absolute", pattern)
+	}
+	if strings.ContainsAny(pattern, `"\`) || strings.Contains(filepath.Dir(pattern), "*") {
+		return nil, fmt.Errorf("invalid FIM pattern `%s`: wildcards are only accepted in the last element of the path", pattern)
+	}
+
+	quoted := strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1)
+	return regexp.Compile("^" + quoted + "$")
+}
+
+// NewFileIntegrityMonitor returns a new file integrity monitor for the given patterns. Up to cacheSize file
+// states are kept, and the files larger than maxFileSize bytes aren't hashed.
+func NewFileIntegrityMonitor(patterns []string, hashDelay time.Duration, maxFileSize int64, cacheSize int) (*FileIntegrityMonitor, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no FIM pattern defined")
+	}
+
+	states, err := simplelru.NewLRU(cacheSize, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	fim := &FileIntegrityMonitor{
+		patterns:    patterns,
+		hashDelay:   hashDelay,
+		maxFileSize: maxFileSize,
+		states:      states,
+		cacheSize:   cacheSize,
+		pending:     make(map[string]*pendingChange),
+		stats:       make(map[string]int64),
+	}
+
+	for _, pattern := range patterns {
+		re, err := patternRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		fim.regexps = append(fim.regexps, re)
+	}
+
+	return fim, nil
+}
+
+// Rules returns the rules detecting the changes of the monitored files
+func (f *FileIntegrityMonitor) Rules() []*rules.RuleDefinition {
+	var ruleDefs []*rules.RuleDefinition
+	for id, expression := range fimRules {
+		var matches []string
+		for _, field := range fimRuleFields[id] {
+			for _, pattern := range f.patterns {
+				matches = append(matches, fmt.Sprintf(`%s =~ "%s"`, field, pattern))
+			}
+		}
+
+		ruleDefs = append(ruleDefs, &rules.RuleDefinition{
+			ID:         id,
+			Expression: fmt.Sprintf(expression, strings.Join(matches, " || ")),
+		})
+	}
+	return ruleDefs
+}
+
+// IsFIMRule returns true for the rules generated by the file integrity monitor
+func (f *FileIntegrityMonitor) IsFIMRule(ruleID string) bool {
+	_, exists := fimRules[ruleID]
+	return exists
+}
+
+// matches returns true if the path matches one of the monitored patterns
+func (f *FileIntegrityMonitor) matches(path string) bool {
+	for _, re := range f.regexps {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// getString returns the value of a string field of an event, or an empty string
+func getString(event *sprobe.Event, field string) string {
+	value, err := event.GetFieldValue(field)
+	if err != nil {
+		return ""
+	}
+	str, _ := value.(string)
+	return str
+}
+
+// HandleEvent records the changes of the monitored files reported by an event matching a FIM rule
+func (f *FileIntegrityMonitor) HandleEvent(ruleID string, event *sprobe.Event, now time.Time) {
+	process := FIMProcess{
+		Pid:      event.Process.Pid,
+		Filename: getString(event, "process.filename"),
+		UID:      event.Process.UID,
+		User:     getString(event, "process.user"),
+	}
+	if value, err := event.GetFieldValue("process.ancestors.filename"); err == nil {
+		process.Ancestors, _ = value.([]string)
+	}
+	containerID := event.Container.GetContainerID()
+
+	newChange := func(path string) fileChange {
+		change := fileChange{
+			ruleID:      ruleID,
+			path:        path,
+			containerID: containerID,
+			process:     process,
+		}
+
+		// the files of the containers are read from the root of the process that changed them
+		if containerID != "" && path != "" {
+			root, err := os.Open(util.HostProc(fmt.Sprintf("%d", event.Process.Pid), "root"))
+			if err != nil {
+				log.Debugf("unable to open the root of pid %d: %s", event.Process.Pid, err)
+			} else {
+				change.root = root
+			}
+		}
+		return change
+	}
+
+	var changes []fileChange
+	switch ruleID {
+	case fimRenameRuleID:
+		oldPath, newPath := getString(event, "rename.old.filename"), getString(event, "rename.new.filename")
+		if f.matches(oldPath) {
+			change := newChange(oldPath)
+			change.renamedTo = newPath
+			changes = append(changes, change)
+		}
+		if f.matches(newPath) {
+			changes = append(changes, newChange(newPath))
+		}
+	case fimUnlinkRuleID:
+		change := newChange(getString(event, "unlink.filename"))
+		change.deleted = true
+		changes = append(changes, change)
+	default:
+		changes = append(changes, newChange(getString(event, fimRuleFields[ruleID][0])))
+	}
+
+	for _, change := range changes {
+		f.recordChange(change, now)
+	}
+}
+
+// recordChange records a change, the file being hashed once no other change occurred during the hash delay.
+// The process reported is the last one that changed the file.
+func (f *FileIntegrityMonitor) recordChange(change fileChange, now time.Time) {
+	if change.path == "" {
+		change.close()
+		return
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	key := change.key()
+	pending, exists := f.pending[key]
+	if !exists {
+		pending = &pendingChange{}
+		if state, found := f.states.Get(key); found {
+			pending.before = state.(*FileState)
+		}
+		f.pending[key] = pending
+	}
+	pending.close()
+	pending.fileChange = change
+	pending.deadline = now.Add(f.hashDelay)
+}
+
+// hashFile returns the state of a file. The files that aren't regular files, or that are larger than
+// the maximum file size, aren't hashed.
+func (f *FileIntegrityMonitor) hashFile(path string) (*FileState, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &FileState{
+		Mode: uint32(fi.Mode().Perm()),
+		Size: fi.Size(),
+	}
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+		state.UID = stat.Uid
+		state.GID = stat.Gid
+	}
+
+	if !fi.Mode().IsRegular() || fi.Size() > f.maxFileSize {
+		return state, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	state.Hash = hex.EncodeToString(hash.Sum(nil))
+
+	return state, nil
+}
+
+// setState updates the cached state of a file, the state being removed when nil
+func (f *FileIntegrityMonitor) setState(key string, state *FileState) {
+	f.Lock()
+	defer f.Unlock()
+
+	if state == nil {
+		f.states.Remove(key)
+	} else {
+		f.states.Add(key, state)
+	}
+}
+
+// integrityEvent returns the integrity event of a change, or nil if the monitored file didn't change
+func (f *FileIntegrityMonitor) integrityEvent(change *pendingChange, now time.Time) *IntegrityEvent {
+	defer change.close()
+
+	event := &IntegrityEvent{
+		Timestamp:   now,
+		Path:        change.path,
+		ContainerID: change.containerID,
+		Before:      change.before,
+		Process:     change.process,
+	}
+
+	switch {
+	case change.renamedTo != "":
+		event.Change = FIMChangeRenamed
+		event.RenamedTo = change.renamedTo
+		f.setState(change.key(), nil)
+		return event
+	case change.deleted:
+		event.Change = FIMChangeDeleted
+		f.setState(change.key(), nil)
+		return event
+	}
+
+	after, err := f.hashFile(change.hostPath())
+	f.setState(change.key(), after)
+	if err != nil {
+		if os.IsNotExist(err) && change.before != nil {
+			// the file was removed before it could be hashed
+			event.Change = FIMChangeDeleted
+			return event
+		}
+		f.Lock()
+		f.hashErrors++
+		f.Unlock()
+		log.Debugf("unable to hash %s: %s", change.hostPath(), err)
+		return nil
+	}
+	event.After = after
+
+	switch {
+	case change.before == nil || !change.before.sameContent(after):
+		event.Change = FIMChangeModified
+	case !change.before.sameAttributes(after):
+		event.Change = FIMChangeAttributes
+	default:
+		return nil
+	}
+	return event
+}
+
+// flush hashes the files whose hash delay is elapsed and reports their changes
+func (f *FileIntegrityMonitor) flush(now time.Time) []*IntegrityEvent {
+	f.Lock()
+	var ready []*pendingChange
+	for key, change := range f.pending {
+		if !now.Before(change.deadline) {
+			ready = append(ready, change)
+			delete(f.pending, key)
+		}
+	}
+	f.Unlock()
+
+	var events []*IntegrityEvent
+	for _, change := range ready {
+		event := f.integrityEvent(change, now)
+		if event == nil {
+			continue
+		}
+
+		f.Lock()
+		f.stats[event.Change]++
+		f.Unlock()
+
+		if f.send != nil {
+			f.send(change.ruleID, event)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// baseline hashes the files matching the monitored patterns, so that their first change is reported
+// with their previous state. The walk stops when the cache is full.
+func (f *FileIntegrityMonitor) baseline(ctx context.Context) {
+	for i, pattern := range f.patterns {
+		root := pattern
+		if index := strings.Index(pattern, "*"); index >= 0 {
+			root = filepath.Dir(pattern[:index+1])
+		}
+
+		err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() || !f.regexps[i].MatchString(path) {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			state, err := f.hashFile(path)
+			if err != nil {
+				return nil
+			}
+
+			f.Lock()
+			defer f.Unlock()
+			f.states.Add((&fileChange{path: path}).key(), state)
+			if f.states.Len() >= f.cacheSize {
+				return io.EOF
+			}
+			return nil
+		})
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Run computes the baseline of the monitored files, then reports their changes with the send function,
+// until the context is cancelled
+func (f *FileIntegrityMonitor) Run(ctx context.Context, send func(ruleID string, event *IntegrityEvent)) {
+	f.send = send
+
+	f.baseline(ctx)
+
+	f.Lock()
+	log.Infof("file integrity monitoring started, %d files hashed", f.states.Len())
+	f.Unlock()
+
+	period := f.hashDelay / 2
+	if period < 100*time.Millisecond {
+		period = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			f.flush(now)
+		case <-ctx.Done():
+			f.Lock()
+			for _, change := range f.pending {
+				change.close()
+			}
+			f.Unlock()
+			return
+		}
+	}
+}
+
+// SendStats sends the counts of integrity events and hash errors
+func (f *FileIntegrityMonitor) SendStats(client *statsd.Client) error {
+	f.Lock()
+	stats, hashErrors := f.stats, f.hashErrors
+	f.stats = make(map[string]int64)
+	f.hashErrors = 0
+	f.Unlock()
+
+	for change, count := range stats {
+		if err := client.Count(sprobe.MetricPrefix+".fim.events", count, []string{"change:" + change}, 1.0); err != nil {
+			return err
+		}
+	}
+	if hashErrors > 0 {
+		return client.Count(sprobe.MetricPrefix+".fim.hash_errors", hashErrors, []string{}, 1.0)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFIMPatterns(t *testing.T) {
+	_, err := NewFileIntegrityMonitor([]string{"etc/passwd"}, time.Second, 1024, 10)
+	assert.Error(t, err)
+	_, err = NewFileIntegrityMonitor([]string{"/etc/*/sshd_config"}, time.Second, 1024, 10)
+	assert.Error(t, err)
+
+	fim, err := NewFileIntegrityMonitor([]string{"/etc/passwd", "/etc/ssh/*"}, time.Second, 1024, 10)
+	require.NoError(t, err)
+
+	assert.True(t, fim.matches("/etc/passwd"))
+	assert.True(t, fim.matches("/etc/ssh/sshd_config"))
+	assert.True(t, fim.matches("/etc/ssh/sshd_config.d/custom.conf"))
+	assert.False(t, fim.matches("/etc/passwd-"))
+	assert.False(t, fim.matches("/etc/shadow"))
+
+	ruleDefs := fim.Rules()
+	assert.Len(t, ruleDefs, len(fimRules))
+	for _, ruleDef := range ruleDefs {
+		assert.True(t, fim.IsFIMRule(ruleDef.ID))
+		if ruleDef.ID == fimRenameRuleID {
+			assert.Equal(t, `rename.old.filename =~ "/etc/passwd" || rename.old.filename =~ "/etc/ssh/*" || rename.new.filename =~ "/etc/passwd" || rename.new.filename =~ "/etc/ssh/*"`, ruleDef.Expression)
+		}
+	}
+	assert.False(t, fim.IsFIMRule("my_rule"))
+}
+
+func TestFIMChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fim")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	monitored := filepath.Join(dir, "monitored")
+	require.NoError(t, ioutil.WriteFile(monitored, []byte("before"), 0600))
+
+	fim, err := NewFileIntegrityMonitor([]string{dir + "/*"}, time.Second, 1024, 10)
+	require.NoError(t, err)
+	fim.baseline(context.Background())
+	assert.Equal(t, 1, fim.states.Len())
+
+	now := time.Now()
+	change := fileChange{ruleID: fimOpenRuleID, path: monitored, process: FIMProcess{Pid: 42, Ancestors: []string{"/bin/bash"}}}
+
+	// an open without any change isn't reported
+	fim.recordChange(change, now)
+	assert.Empty(t, fim.flush(now.Add(2*time.Second)))
+
+	// the file is hashed once the hash delay is elapsed since its last change
+	fim.recordChange(change, now)
+	require.NoError(t, ioutil.WriteFile(monitored, []byte("after"), 0600))
+	fim.recordChange(change, now.Add(500*time.Millisecond))
+	assert.Empty(t, fim.flush(now.Add(time.Second)))
+
+	events := fim.flush(now.Add(2 * time.Second))
+	require.Len(t, events, 1)
+	assert.Equal(t, FIMChangeModified, events[0].Change)
+	assert.Equal(t, "6db7d803e74f1ffa7d8f5adc0bf95b3e15bf4c8373fffadf546227cc6c6742cb", events[0].Before.Hash)
+	assert.NotEqual(t, events[0].Before.Hash, events[0].After.Hash)
+	assert.Equal(t, []string{"/bin/bash"}, events[0].Process.Ancestors)
+
+	// only the mode changed
+	require.NoError(t, os.Chmod(monitored, 0644))
+	fim.recordChange(change, now)
+	events = fim.flush(now.Add(2 * time.Second))
+	require.Len(t, events, 1)
+	assert.Equal(t, FIMChangeAttributes, events[0].Change)
+	assert.Equal(t, uint32(0644), events[0].After.Mode)
+
+	// renamed files are removed from the cache
+	renamed := change
+	renamed.ruleID = fimRenameRuleID
+	renamed.renamedTo = monitored + ".old"
+	fim.recordChange(renamed, now)
+	events = fim.flush(now.Add(2 * time.Second))
+	require.Len(t, events, 1)
+	assert.Equal(t, FIMChangeRenamed, events[0].Change)
+	assert.Equal(t, monitored+".old", events[0].RenamedTo)
+	assert.Nil(t, events[0].After)
+	assert.Equal(t, 0, fim.states.Len())
+
+	// the previous state of the files that weren't hashed is unknown
+	fim.recordChange(change, now)
+	events = fim.flush(now.Add(2 * time.Second))
+	require.Len(t, events, 1)
+	assert.Equal(t, FIMChangeModified, events[0].Change)
+	assert.Nil(t, events[0].Before)
+}
+
+func TestFIMContainerRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fim")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "passwd"), []byte("root"), 0600))
+
+	fim, err := NewFileIntegrityMonitor([]string{"/etc/*"}, time.Second, 1024, 10)
+	require.NoError(t, err)
+
+	// the file is read from the root opened when the change was recorded
+	root, err := os.Open(dir)
+	require.NoError(t, err)
+	now := time.Now()
+	fim.recordChange(fileChange{ruleID: fimOpenRuleID, path: "/passwd", containerID: "abc", root: root}, now)
+
+	events := fim.flush(now.Add(2 * time.Second))
+	require.Len(t, events, 1)
+	assert.Equal(t, FIMChangeModified, events[0].Change)
+	assert.Equal(t, "abc", events[0].ContainerID)
+
+	// the root is closed once the file is hashed
+	assert.Error(t, root.Close())
+}
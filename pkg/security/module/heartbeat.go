@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Heartbeat is the payload periodically sent to the backend so that a host whose runtime
+// security silently stops producing events can be detected
+type Heartbeat struct {
+	Timestamp    time.Time        `json:"timestamp"`
+	PoliciesHash string           `json:"policies_hash"`
+	RulesCount   int              `json:"rules_count"`
+	Probe        ProbeHealth      `json:"probe"`
+	Events       HeartbeatCounter `json:"events"`
+	// Rules holds the evaluation statistics of the slowest rules
+	Rules []rules.RuleStatsSnapshot `json:"rules,omitempty"`
+}
+
+// ProbeHealth holds the health of the probe
+type ProbeHealth struct {
+	Running         bool                        `json:"running"`
+	KernelFilters   bool                        `json:"kernel_filters"`
+	Uptime          float64                     `json:"uptime"`
+	ProcessResolver sprobe.ProcessResolverStats `json:"process_resolver"`
+}
+
+// HeartbeatCounter holds the number of events since the previous heartbeat
+type HeartbeatCounter struct {
+	Received int64 `json:"received"`
+	Matched  int64 `json:"matched"`
+}
+
+// heartbeat returns the current heartbeat of the module and resets its event counters
+func (m *Module) heartbeat(now time.Time) *Heartbeat {
+	ruleSet := m.GetRuleSet()
+	return &Heartbeat{
+		Timestamp:    now,
+		PoliciesHash: ruleSet.Hash(),
+		RulesCount:   len(ruleSet.ListRuleIDs()),
+		Probe: ProbeHealth{
+			Running:         atomic.LoadInt32(&m.running) == 1,
+			KernelFilters:   m.config.EnableKernelFilters,
+			Uptime:          now.Sub(m.startTime).Seconds(),
+			ProcessResolver: m.probe.GetProcessResolverStats(),
+		},
+		Events: HeartbeatCounter{
+			Received: atomic.SwapInt64(&m.eventsReceived, 0),
+			Matched:  atomic.SwapInt64(&m.eventsMatched, 0),
+		},
+		Rules: slowestRules(ruleSet),
+	}
+}
+
+// SendStats sends the heartbeat metrics, whose absence reveals a host whose runtime security stopped running
+func (h *Heartbeat) SendStats(client *statsd.Client) error {
+	tags := []string{"policies_hash:" + h.PoliciesHash, fmt.Sprintf("running:%t", h.Probe.Running)}
+	if err := client.Gauge(sprobe.MetricPrefix+".heartbeat", 1, tags, 1.0); err != nil {
+		return err
+	}
+	if err := client.Gauge(sprobe.MetricPrefix+".heartbeat.rules", float64(h.RulesCount), tags, 1.0); err != nil {
+		return err
+	}
+	if err := client.Count(sprobe.MetricPrefix+".heartbeat.events.received", h.Events.Received, tags, 1.0); err != nil {
+		return err
+	}
+	return client.Count(sprobe.MetricPrefix+".heartbeat.events.matched", h.Events.Matched, tags, 1.0)
+}
+
+// heartbeatLoop sends a heartbeat at every period, as metrics and as a status message to the security
+// agent, until the context is cancelled
+func (m *Module) heartbeatLoop(ctx context.Context) {
+	ticker := time.NewTicker(m.config.HeartbeatPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			heartbeat := m.heartbeat(now)
+			if m.statsdClient != nil {
+				if err := heartbeat.SendStats(m.statsdClient); err != nil {
+					log.Debugf("failed to send the heartbeat metrics: %s", err)
+				}
+			}
+			m.eventServer.SendHeartbeat(heartbeat)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/security/api"
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+func TestSendHeartbeat(t *testing.T) {
+	es := NewEventServer([]string{"rule1"}, &config.Config{EventServerBurst: 10, EventServerRate: 10}, nil)
+
+	heartbeat := &Heartbeat{
+		Timestamp:    time.Now().UTC().Truncate(time.Second),
+		PoliciesHash: "abcd",
+		RulesCount:   1,
+		Probe: ProbeHealth{
+			Running: true,
+			ProcessResolver: sprobe.ProcessResolverStats{
+				CacheSize:        12,
+				KernelMapLookups: 3,
+				KernelMapMisses:  1,
+				SnapshotDuration: 0.5,
+			},
+		},
+		Events: HeartbeatCounter{Received: 42, Matched: 2},
+		Rules: []rules.RuleStatsSnapshot{
+			{RuleID: "rule1", Evaluations: 42, Matches: 2, EvalTime: 84 * time.Microsecond},
+		},
+	}
+	es.SendHeartbeat(heartbeat)
+
+	select {
+	case msg := <-es.msgs:
+		assert.Equal(t, api.HeartbeatRuleID, msg.RuleID)
+		assert.Equal(t, "heartbeat", msg.Type)
+
+		var received Heartbeat
+		if err := json.Unmarshal(msg.Data, &received); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, *heartbeat, received)
+	default:
+		t.Fatal("no heartbeat queued")
+	}
+}
+
+func TestSendExecDedupEvent(t *testing.T) {
+	es := NewEventServer(nil, &config.Config{EventServerBurst: 10, EventServerRate: 10}, nil)
+
+	event := &sprobe.ExecDedupEvent{
+		Timestamp:   time.Now().UTC().Truncate(time.Second),
+		Executable:  "/usr/bin/date",
+		ContainerID: "abc",
+		Count:       42,
+	}
+	es.SendExecDedupEvent(event)
+
+	select {
+	case msg := <-es.msgs:
+		assert.Equal(t, api.ExecDedupRuleID, msg.RuleID)
+		assert.Equal(t, []string{"executable:/usr/bin/date", "container_id:abc"}, msg.Tags)
+
+		var received sprobe.ExecDedupEvent
+		if err := json.Unmarshal(msg.Data, &received); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, *event, received)
+	default:
+		t.Fatal("no exec dedup event queued")
+	}
+}
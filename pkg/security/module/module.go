@@ -15,12 +15,17 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 
 	"github.com/DataDog/datadog-agent/cmd/system-probe/api"
+	"github.com/DataDog/datadog-agent/cmd/system-probe/utils"
 	aconfig "github.com/DataDog/datadog-agent/pkg/process/config"
 	sapi "github.com/DataDog/datadog-agent/pkg/security/api"
 	"github.com/DataDog/datadog-agent/pkg/security/config"
@@ -32,16 +37,34 @@ import (
 	"github.com/DataDog/datadog-go/statsd"
 )
 
+// clusterPoliciesCheckInterval is the interval at which the cluster policies directory is checked
+// for changes, to reload the policies
+var clusterPoliciesCheckInterval = 10 * time.Second
+
 // Module represents the system-probe module for the runtime security agent
 type Module struct {
+	// the 64-bit atomic counters are kept first to be 64-bit aligned on 32-bit platforms
+	eventsReceived int64
+	eventsMatched  int64
+
 	probe        *sprobe.Probe
 	config       *config.Config
-	ruleSet      *rules.RuleSet
+	ruleSet      atomic.Value // *rules.RuleSet
+	eventTypes   map[eval.EventType]bool
+	reloadLock   sync.Mutex
 	eventServer  *EventServer
 	grpcServer   *grpc.Server
 	listener     net.Listener
 	statsdClient *statsd.Client
 	rateLimiter  *RateLimiter
+	eventDedup   *EventDeduplicator
+	eventStore   *EventStore
+	enforcer     *Enforcer
+	fim          *FileIntegrityMonitor
+	ruleStats    *RuleStatsReporter
+	running      int32
+	startTime    time.Time
+	cancelFnc    context.CancelFunc
 }
 
 // Register the runtime security agent module
@@ -65,8 +88,16 @@ func (m *Module) Register(httpMux *http.ServeMux) error {
 		}
 	}()
 
+	if m.eventStore != nil {
+		httpMux.HandleFunc("/runtime_security/events", m.queryEvents)
+	}
+
 	m.probe.SetEventHandler(m)
-	m.ruleSet.AddListener(m)
+	m.probe.SetExecDedupHandler(m.eventServer.SendExecDedupEvent)
+	m.probe.SetExitHandler(m.enforcer.ProcessExited)
+
+	ruleSet := m.GetRuleSet()
+	ruleSet.AddListener(m)
 
 	go m.statsMonitor(context.Background())
 
@@ -78,18 +109,18 @@ func (m *Module) Register(httpMux *http.ServeMux) error {
 	rsa := sprobe.NewRuleSetApplier(m.config)
 
 	// based on the ruleset and the requested rules, select the probes that need to be activated
-	if err := rsa.SelectProbes(m.ruleSet, m.probe); err != nil {
+	if err := rsa.SelectProbes(ruleSet, m.probe); err != nil {
 		return err
 	}
 
 	// initialize the eBPF manager and load the programs and maps in the kernel. At this stage, the probes are not
 	// running yet.
-	if err := m.probe.InitManager(m.ruleSet); err != nil {
+	if err := m.probe.InitManager(ruleSet); err != nil {
 		return err
 	}
 
 	// analyze the ruleset, push default policies in the kernel and generate the policy report
-	report, err := rsa.Apply(m.ruleSet, m.probe)
+	report, err := rsa.Apply(ruleSet, m.probe)
 	if err != nil {
 		return err
 	}
@@ -108,11 +139,106 @@ func (m *Module) Register(httpMux *http.ServeMux) error {
 	content, _ := json.MarshalIndent(report, "", "\t")
 	log.Debug(string(content))
 
+	atomic.StoreInt32(&m.running, 1)
+
+	var ctx context.Context
+	ctx, m.cancelFnc = context.WithCancel(context.Background())
+
+	if m.config.HeartbeatEnabled {
+		go m.heartbeatLoop(ctx)
+	}
+
+	if m.fim != nil {
+		go m.fim.Run(ctx, m.eventServer.SendIntegrityEvent)
+	}
+
+	if m.config.ClusterPoliciesDir != "" {
+		go m.watchClusterPolicies(ctx)
+	}
+
+	return nil
+}
+
+// ReloadPolicies loads the policies again and applies them in place of the current ones. The new
+// policies can only use the event types of the policies loaded when the module started, the probes
+// of the other event types not being activated.
+func (m *Module) ReloadPolicies() error {
+	m.reloadLock.Lock()
+	defer m.reloadLock.Unlock()
+
+	ruleSet, err := newRuleSet(m.probe, m.config, m.fim)
+	if err != nil {
+		return err
+	}
+
+	for _, eventType := range ruleSet.GetEventTypes() {
+		if !m.eventTypes[eventType] {
+			return fmt.Errorf("the policies use the event type `%s`, which requires a restart of system-probe", eventType)
+		}
+	}
+
+	ruleSet.AddListener(m)
+	m.rateLimiter.Apply(ruleSet.ListRuleIDs())
+	m.eventServer.Apply(ruleSet.ListRuleIDs())
+	m.ruleStats.SetRuleSet(ruleSet)
+	m.ruleSet.Store(ruleSet)
+
+	// the discarders of the previous rules may discard events matching the new ones
+	if err := m.probe.FlushDiscarders(); err != nil {
+		return err
+	}
+
+	if _, err := sprobe.NewRuleSetApplier(m.config).Apply(ruleSet, m.probe); err != nil {
+		return err
+	}
+
+	log.Infof("%d security rules loaded", len(ruleSet.ListRuleIDs()))
 	return nil
 }
 
+// watchClusterPolicies reloads the policies every time the policies of the cluster, written by the
+// security agent in the cluster policies directory, change
+func (m *Module) watchClusterPolicies(ctx context.Context) {
+	ticker := time.NewTicker(clusterPoliciesCheckInterval)
+	defer ticker.Stop()
+
+	previous, err := policy.ClusterPoliciesFingerprint(m.config.ClusterPoliciesDir)
+	if err != nil {
+		log.Warnf("unable to check the cluster policies: %s", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		fingerprint, err := policy.ClusterPoliciesFingerprint(m.config.ClusterPoliciesDir)
+		if err != nil {
+			log.Warnf("unable to check the cluster policies: %s", err)
+			continue
+		}
+		if fingerprint == previous {
+			continue
+		}
+		previous = fingerprint
+
+		log.Infof("the cluster policies changed, reloading the security policies")
+		if err := m.ReloadPolicies(); err != nil {
+			log.Errorf("unable to reload the security policies, the previous ones are kept: %s", err)
+		}
+	}
+}
+
 // Close the module
 func (m *Module) Close() {
+	atomic.StoreInt32(&m.running, 0)
+
+	if m.cancelFnc != nil {
+		m.cancelFnc()
+	}
+
 	if m.grpcServer != nil {
 		m.grpcServer.Stop()
 	}
@@ -123,12 +249,72 @@ func (m *Module) Close() {
 	}
 
 	m.probe.Close()
+
+	if m.eventStore != nil {
+		m.eventStore.Close()
+	}
+}
+
+// queryEvents returns the security events saved in the event store. The events can be filtered using
+// the `from` and `to` RFC3339 timestamps, `rule_id`, `container_id` and `limit` query parameters.
+func (m *Module) queryEvents(w http.ResponseWriter, req *http.Request) {
+	var query EventQuery
+	var err error
+
+	params := req.URL.Query()
+	if from := params.Get("from"); from != "" {
+		if query.From, err = time.Parse(time.RFC3339, from); err != nil {
+			http.Error(w, fmt.Sprintf("invalid from parameter: %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if to := params.Get("to"); to != "" {
+		if query.To, err = time.Parse(time.RFC3339, to); err != nil {
+			http.Error(w, fmt.Sprintf("invalid to parameter: %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if limit := params.Get("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil || query.Limit < 0 {
+			http.Error(w, fmt.Sprintf("invalid limit parameter: %s", limit), http.StatusBadRequest)
+			return
+		}
+	}
+	query.RuleID = params.Get("rule_id")
+	query.ContainerID = params.Get("container_id")
+
+	events, err := m.eventStore.Query(query)
+	if err != nil {
+		log.Errorf("unable to query the event store: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(w, events)
 }
 
 // RuleMatch is called by the ruleset when a rule matches
 func (m *Module) RuleMatch(rule *eval.Rule, event eval.Event) {
+	atomic.AddInt64(&m.eventsMatched, 1)
+
+	ev := event.(*sprobe.Event)
+	if m.fim != nil && m.fim.IsFIMRule(rule.ID) {
+		m.fim.HandleEvent(rule.ID, ev, time.Now())
+		return
+	}
+
+	extraTags := m.enforcer.GetTags(ev)
+	if actions := m.GetRuleSet().GetActions(rule.ID); len(actions) > 0 {
+		m.enforcer.Apply(rule, actions, ev)
+	}
+
+	if m.eventDedup != nil && m.eventDedup.IsDuplicate(rule.ID, ev, time.Now()) {
+		log.Tracef("Event on rule %s was dropped as a duplicate", rule.ID)
+		return
+	}
+
 	if m.rateLimiter.Allow(rule.ID) {
-		m.eventServer.SendEvent(rule, event)
+		m.eventServer.SendEvent(rule, event, extraTags...)
 	} else {
 		log.Tracef("Event on rule %s was dropped due to rate limiting", rule.ID)
 	}
@@ -136,6 +322,11 @@ func (m *Module) RuleMatch(rule *eval.Rule, event eval.Event) {
 
 // EventDiscarderFound is called by the ruleset when a new discarder discovered
 func (m *Module) EventDiscarderFound(rs *rules.RuleSet, event eval.Event, field eval.Field, eventType eval.EventType) {
+	// the discarders of a rule set being replaced are ignored
+	if rs != m.GetRuleSet() {
+		return
+	}
+
 	if err := m.probe.OnNewDiscarder(rs, event.(*sprobe.Event), field, eventType); err != nil {
 		log.Trace(err)
 	}
@@ -143,7 +334,8 @@ func (m *Module) EventDiscarderFound(rs *rules.RuleSet, event eval.Event, field
 
 // HandleEvent is called by the probe when an event arrives from the kernel
 func (m *Module) HandleEvent(event *sprobe.Event) {
-	m.ruleSet.Evaluate(event)
+	atomic.AddInt64(&m.eventsReceived, 1)
+	m.GetRuleSet().Evaluate(event)
 }
 
 func (m *Module) statsMonitor(ctx context.Context) {
@@ -162,6 +354,19 @@ func (m *Module) statsMonitor(ctx context.Context) {
 			if err := m.rateLimiter.SendStats(m.statsdClient); err != nil {
 				log.Debug(err)
 			}
+			if m.eventDedup != nil {
+				if err := m.eventDedup.SendStats(m.statsdClient); err != nil {
+					log.Debug(err)
+				}
+			}
+			if m.fim != nil {
+				if err := m.fim.SendStats(m.statsdClient); err != nil {
+					log.Debug(err)
+				}
+			}
+			if err := m.ruleStats.SendStats(m.statsdClient); err != nil {
+				log.Debug(err)
+			}
 			if err := m.eventServer.SendStats(m.statsdClient); err != nil {
 				log.Debug(err)
 			}
@@ -185,7 +390,23 @@ func (m *Module) GetStats() map[string]interface{} {
 
 // GetRuleSet returns the set of loaded rules
 func (m *Module) GetRuleSet() *rules.RuleSet {
-	return m.ruleSet
+	return m.ruleSet.Load().(*rules.RuleSet)
+}
+
+// newRuleSet returns the rule set of the policies and of the file integrity monitoring, if any
+func newRuleSet(probe *sprobe.Probe, config *config.Config, fim *FileIntegrityMonitor) (*rules.RuleSet, error) {
+	ruleSet := probe.NewRuleSet(rules.NewOptsWithParams(sprobe.SECLConstants, sprobe.SupportedDiscarders))
+	if err := policy.LoadPolicies(config, ruleSet); err != nil {
+		return nil, err
+	}
+
+	if fim != nil {
+		if err := ruleSet.AddRules(fim.Rules()); err != nil {
+			return nil, errors.Wrap(err, "unable to add the file integrity monitoring rules")
+		}
+	}
+
+	return ruleSet, nil
 }
 
 // NewModule instantiates a runtime security system-probe module
@@ -221,19 +442,59 @@ func NewModule(cfg *aconfig.AgentConfig) (api.Module, error) {
 		return nil, err
 	}
 
-	ruleSet := probe.NewRuleSet(rules.NewOptsWithParams(sprobe.SECLConstants, sprobe.SupportedDiscarders))
-	if err := policy.LoadPolicies(config, ruleSet); err != nil {
+	var fim *FileIntegrityMonitor
+	if config.FIMEnabled {
+		if fim, err = NewFileIntegrityMonitor(config.FIMPatterns, config.FIMHashDelay, config.FIMMaxFileSize, config.FIMCacheSize); err != nil {
+			return nil, err
+		}
+	}
+
+	ruleSet, err := newRuleSet(probe, config, fim)
+	if err != nil {
 		return nil, err
 	}
 
+	var eventStore *EventStore
+	if config.EventStoreEnabled {
+		if eventStore, err = NewEventStore(config.EventStoreDir, config.EventStoreMaxSize); err != nil {
+			return nil, err
+		}
+	}
+
+	var eventDedup *EventDeduplicator
+	if config.EventDedupEnabled {
+		if eventDedup, err = NewEventDeduplicator(config.EventDedupSize, config.EventDedupWindow); err != nil {
+			return nil, err
+		}
+	}
+
+	enforcer, err := NewEnforcer(config.EnforcementEnabled, statsdClient)
+	if err != nil {
+		return nil, err
+	}
+	if enforcer.enabled {
+		log.Warn("Enforcement enabled, the actions of the rules will be taken")
+	}
+
 	m := &Module{
 		config:       config,
 		probe:        probe,
-		ruleSet:      ruleSet,
-		eventServer:  NewEventServer(ruleSet.ListRuleIDs(), config),
+		eventTypes:   make(map[eval.EventType]bool),
+		eventServer:  NewEventServer(ruleSet.ListRuleIDs(), config, eventStore),
 		grpcServer:   grpc.NewServer(),
 		statsdClient: statsdClient,
-		rateLimiter:  NewRateLimiter(ruleSet.ListRuleIDs()),
+		rateLimiter:  NewRateLimiter(ruleSet.ListRuleIDs(), rate.Limit(config.RateLimiterRate), config.RateLimiterBurst),
+		eventDedup:   eventDedup,
+		eventStore:   eventStore,
+		enforcer:     enforcer,
+		fim:          fim,
+		ruleStats:    NewRuleStatsReporter(ruleSet),
+		startTime:    time.Now(),
+	}
+
+	m.ruleSet.Store(ruleSet)
+	for _, eventType := range ruleSet.GetEventTypes() {
+		m.eventTypes[eventType] = true
 	}
 
 	sapi.RegisterSecurityModuleServer(m.grpcServer, m.eventServer)
@@ -9,6 +9,7 @@ package module
 
 import (
 	"fmt"
+	"sync"
 	"sync/atomic"
 
 	"github.com/DataDog/datadog-go/statsd"
@@ -17,14 +18,6 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/security/probe"
 )
 
-const (
-	// Arbitrary default limit to prevent flooding.
-	defaultLimit = rate.Limit(10)
-	// Default Token bucket size. 40 is meant to handle sudden burst of events while making sure that we prevent
-	// flooding.
-	defaultBurst int = 40
-)
-
 // Limiter describes an object that applies limits on
 // the rate of triggering of a rule to ensure we don't overflow
 // with too permissive rules
@@ -47,23 +40,44 @@ func NewLimiter(limit rate.Limit, burst int) *Limiter {
 
 // RateLimiter describes a set of rule rate limiters
 type RateLimiter struct {
+	sync.RWMutex
 	limiters map[string]*Limiter
+	limit    rate.Limit
+	burst    int
 }
 
-// NewRateLimiter initializes an empty rate limiter
-func NewRateLimiter(ids []string) *RateLimiter {
+// NewRateLimiter initializes a rate limiter allowing each rule to send limit events per second, with
+// bursts of at most burst events
+func NewRateLimiter(ids []string, limit rate.Limit, burst int) *RateLimiter {
 	limiters := make(map[string]*Limiter)
 	for _, id := range ids {
-		limiters[id] = NewLimiter(defaultLimit, defaultBurst)
+		limiters[id] = NewLimiter(limit, burst)
 	}
 	return &RateLimiter{
 		limiters: limiters,
+		limit:    limit,
+		burst:    burst,
+	}
+}
+
+// Apply adds the limiters of the rules of a new rule set. The limiters of the removed rules
+// are kept so that their last statistics are sent.
+func (rl *RateLimiter) Apply(ids []string) {
+	rl.Lock()
+	defer rl.Unlock()
+
+	for _, id := range ids {
+		if _, found := rl.limiters[id]; !found {
+			rl.limiters[id] = NewLimiter(rl.limit, rl.burst)
+		}
 	}
 }
 
 // Allow returns true if a specific rule shall be allowed to sent a new event
 func (rl *RateLimiter) Allow(ruleID string) bool {
+	rl.RLock()
 	ruleLimiter, ok := rl.limiters[ruleID]
+	rl.RUnlock()
 	if !ok {
 		return false
 	}
@@ -84,6 +98,9 @@ type RateLimiterStat struct {
 // GetStats returns a map indexed by ruleIDs that describes the amount of events
 // that were dropped because of the rate limiter
 func (rl *RateLimiter) GetStats() map[string]RateLimiterStat {
+	rl.RLock()
+	defer rl.RUnlock()
+
 	stats := make(map[string]RateLimiterStat)
 	for ruleID, ruleLimiter := range rl.limiters {
 		stats[ruleID] = RateLimiterStat{
@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/DataDog/datadog-go/statsd"
+
+	"github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+// heartbeatSlowestRules is the number of rules, with the highest evaluation time, reported in the heartbeat
+const heartbeatSlowestRules = 10
+
+// RuleStatsReporter sends the evaluation statistics of the rules of a rule set
+type RuleStatsReporter struct {
+	sync.Mutex
+	ruleSet  *rules.RuleSet
+	previous map[string]rules.RuleStatsSnapshot
+}
+
+// NewRuleStatsReporter returns a new RuleStatsReporter for the given rule set
+func NewRuleStatsReporter(ruleSet *rules.RuleSet) *RuleStatsReporter {
+	return &RuleStatsReporter{
+		ruleSet:  ruleSet,
+		previous: make(map[string]rules.RuleStatsSnapshot),
+	}
+}
+
+// SetRuleSet replaces the rule set whose statistics are reported, the statistics of a new rule
+// set starting from zero
+func (r *RuleStatsReporter) SetRuleSet(ruleSet *rules.RuleSet) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.ruleSet = ruleSet
+	r.previous = make(map[string]rules.RuleStatsSnapshot)
+}
+
+// GetStats returns the evaluation statistics of the rules since the previous call
+func (r *RuleStatsReporter) GetStats() []rules.RuleStatsSnapshot {
+	r.Lock()
+	defer r.Unlock()
+
+	var deltas []rules.RuleStatsSnapshot
+	for _, snapshot := range r.ruleSet.GetRuleStats() {
+		delta := snapshot.Sub(r.previous[snapshot.RuleID])
+		r.previous[snapshot.RuleID] = snapshot
+		if delta.Evaluations > 0 {
+			deltas = append(deltas, delta)
+		}
+	}
+	return deltas
+}
+
+// SendStats sends the number of evaluations, the number of matches and the average evaluation
+// time of the rules evaluated since the previous call
+func (r *RuleStatsReporter) SendStats(client *statsd.Client) error {
+	for _, stats := range r.GetStats() {
+		tags := []string{fmt.Sprintf("rule_id:%s", stats.RuleID)}
+		if err := client.Count(probe.MetricPrefix+".rules.evaluations", stats.Evaluations, tags, 1.0); err != nil {
+			return err
+		}
+		if err := client.Count(probe.MetricPrefix+".rules.matches", stats.Matches, tags, 1.0); err != nil {
+			return err
+		}
+		if err := client.Gauge(probe.MetricPrefix+".rules.evaluation_time", float64(stats.AverageEvalTime().Nanoseconds()), tags, 1.0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slowestRules returns the statistics of the rules with the highest evaluation time since the rule
+// set was created
+func slowestRules(ruleSet *rules.RuleSet) []rules.RuleStatsSnapshot {
+	stats := ruleSet.GetRuleStats()
+	if len(stats) > heartbeatSlowestRules {
+		stats = stats[:heartbeatSlowestRules]
+	}
+	return stats
+}
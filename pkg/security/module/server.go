@@ -10,6 +10,7 @@ package module
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -27,9 +28,11 @@ import (
 // EventServer represents a gRPC server in charge of receiving events sent by
 // the runtime security system-probe module and forwards them to Datadog
 type EventServer struct {
+	sync.RWMutex
 	msgs          chan *api.SecurityEventMessage
 	expiredEvents map[string]*int64
 	rate          *Limiter
+	store         *EventStore
 }
 
 // GetEvents waits for security events
@@ -63,14 +66,17 @@ LOOP:
 	return nil
 }
 
-// SendEvent forwards events sent by the runtime security module to Datadog
-func (e *EventServer) SendEvent(rule *eval.Rule, event eval.Event) {
+// SendEvent forwards events sent by the runtime security module to Datadog. The extra tags are added
+// to the tags of the rule and of the event.
+func (e *EventServer) SendEvent(rule *eval.Rule, event eval.Event, extraTags ...string) {
 	data, err := json.Marshal(rules.RuleEvent{Event: event, RuleID: rule.ID})
 	if err != nil {
 		return
 	}
+	ev := event.(*sprobe.Event)
 	tags := append(rule.Tags, "rule_id:"+rule.ID)
-	tags = append(tags, event.(*sprobe.Event).GetTags()...)
+	tags = append(tags, ev.GetTags()...)
+	tags = append(tags, extraTags...)
 	log.Tracef("Sending event message for rule `%s` to security-agent `%s` with tags %v", rule.ID, string(data), tags)
 
 	msg := &api.SecurityEventMessage{
@@ -80,6 +86,96 @@ func (e *EventServer) SendEvent(rule *eval.Rule, event eval.Event) {
 		Data:   data,
 	}
 
+	if e.store != nil {
+		stored := &StoredEvent{
+			Timestamp:   ev.GetTimestamp(),
+			RuleID:      rule.ID,
+			ContainerID: ev.Container.GetContainerID(),
+			Pid:         ev.Process.Pid,
+			Type:        msg.Type,
+			Tags:        tags,
+			Data:        data,
+		}
+		if err := e.store.Store(stored); err != nil {
+			log.Debugf("unable to store event of rule `%s`: %s", rule.ID, err)
+		}
+	}
+
+	e.enqueue(msg)
+}
+
+// SendHeartbeat sends a heartbeat of the runtime security module to the security agent, as a status message
+func (e *EventServer) SendHeartbeat(heartbeat *Heartbeat) {
+	data, err := json.Marshal(heartbeat)
+	if err != nil {
+		return
+	}
+	log.Tracef("Sending heartbeat to security-agent `%s`", string(data))
+
+	e.enqueue(&api.SecurityEventMessage{
+		RuleID: api.HeartbeatRuleID,
+		Type:   "heartbeat",
+		Data:   data,
+	})
+}
+
+// SendExecDedupEvent forwards the number of identical execs of an executable deduplicated by the probe to Datadog
+func (e *EventServer) SendExecDedupEvent(event *sprobe.ExecDedupEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	tags := []string{"executable:" + event.Executable}
+	if event.ContainerID != "" {
+		tags = append(tags, "container_id:"+event.ContainerID)
+	}
+	log.Tracef("Sending exec dedup event to security-agent `%s` with tags %v", string(data), tags)
+
+	e.enqueue(&api.SecurityEventMessage{
+		RuleID: api.ExecDedupRuleID,
+		Type:   "exec_dedup",
+		Tags:   tags,
+		Data:   data,
+	})
+}
+
+// SendIntegrityEvent forwards a change of a file monitored by the file integrity monitoring to Datadog
+func (e *EventServer) SendIntegrityEvent(ruleID string, event *IntegrityEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	tags := []string{"rule_id:" + ruleID, "type:file_integrity", "change:" + event.Change}
+	log.Tracef("Sending integrity event to security-agent `%s` with tags %v", string(data), tags)
+
+	msg := &api.SecurityEventMessage{
+		RuleID: ruleID,
+		Type:   "file_integrity",
+		Tags:   tags,
+		Data:   data,
+	}
+
+	if e.store != nil {
+		stored := &StoredEvent{
+			Timestamp:   event.Timestamp,
+			RuleID:      ruleID,
+			ContainerID: event.ContainerID,
+			Pid:         event.Process.Pid,
+			Type:        msg.Type,
+			Tags:        tags,
+			Data:        data,
+		}
+		if err := e.store.Store(stored); err != nil {
+			log.Debugf("unable to store integrity event of `%s`: %s", event.Path, err)
+		}
+	}
+
+	e.enqueue(msg)
+}
+
+// enqueue queues a message until it is retrieved by the security-agent. The oldest message
+// is dropped when the queue is full.
+func (e *EventServer) enqueue(msg *api.SecurityEventMessage) {
 	select {
 	case e.msgs <- msg:
 		break
@@ -103,7 +199,9 @@ func (e *EventServer) SendEvent(rule *eval.Rule, event eval.Event) {
 // expireEvent updates the count of expired messages for the appropriate rule
 func (e *EventServer) expireEvent(msg *api.SecurityEventMessage) {
 	// Update metric
+	e.RLock()
 	count, ok := e.expiredEvents[msg.RuleID]
+	e.RUnlock()
 	if ok {
 		atomic.AddInt64(count, 1)
 	}
@@ -113,6 +211,9 @@ func (e *EventServer) expireEvent(msg *api.SecurityEventMessage) {
 // GetStats returns a map indexed by ruleIDs that describes the amount of events
 // that were expired or rate limited before reaching
 func (e *EventServer) GetStats() map[string]int64 {
+	e.RLock()
+	defer e.RUnlock()
+
 	stats := make(map[string]int64)
 	for ruleID, val := range e.expiredEvents {
 		stats[ruleID] = atomic.SwapInt64(val, 0)
@@ -130,19 +231,38 @@ func (e *EventServer) SendStats(client *statsd.Client) error {
 			}
 		}
 	}
+	if e.store != nil {
+		if dropped := atomic.SwapInt64(&e.store.dropped, 0); dropped > 0 {
+			if err := client.Count(sprobe.MetricPrefix+".event_store.dropped", dropped, nil, 1.0); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-// NewEventServer returns a new gRPC event server
-func NewEventServer(ids []string, cfg *config.Config) *EventServer {
+// NewEventServer returns a new gRPC event server. The events are also saved in the given
+// event store, if any.
+func NewEventServer(ids []string, cfg *config.Config, store *EventStore) *EventServer {
 	es := &EventServer{
 		msgs:          make(chan *api.SecurityEventMessage, cfg.EventServerBurst*3),
 		expiredEvents: make(map[string]*int64),
 		rate:          NewLimiter(rate.Limit(cfg.EventServerRate), cfg.EventServerBurst),
+		store:         store,
 	}
+	es.Apply(append(ids, api.HeartbeatRuleID, api.ExecDedupRuleID))
+	return es
+}
+
+// Apply adds the counters of expired events of the rules of a new rule set
+func (e *EventServer) Apply(ids []string) {
+	e.Lock()
+	defer e.Unlock()
+
 	for _, id := range ids {
-		var val int64
-		es.expiredEvents[id] = &val
+		if _, found := e.expiredEvents[id]; !found {
+			var val int64
+			e.expiredEvents[id] = &val
+		}
 	}
-	return es
 }
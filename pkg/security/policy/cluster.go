@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// clusterPolicyPrefix is the prefix of the files of the policies received from the cluster agent
+const clusterPolicyPrefix = "cluster-"
+
+// clusterPolicyFilename returns the name of the file of a policy received from the cluster agent
+func clusterPolicyFilename(name string) string {
+	return clusterPolicyPrefix + name + ".policy"
+}
+
+// SyncClusterPolicies writes the policies received from the cluster agent, by name, in the cluster
+// policies directory, and removes the files of the previous policies that are gone. The invalid policies
+// are reported and not written, their previous version being kept. It returns whether a file changed.
+func SyncClusterPolicies(dir string, policies map[string][]byte) (bool, error) {
+	var result *multierror.Error
+	changed := false
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, errors.Wrap(err, "failed to create the cluster policies directory")
+	}
+
+	for name, content := range policies {
+		if name == "" || filepath.Base(name) != name {
+			result = multierror.Append(result, fmt.Errorf("invalid cluster policy name `%s`", name))
+			continue
+		}
+
+		if _, err := LoadPolicy(bytes.NewReader(content)); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "invalid cluster policy `%s`", name))
+			continue
+		}
+
+		path := filepath.Join(dir, clusterPolicyFilename(name))
+		if current, err := ioutil.ReadFile(path); err == nil && bytes.Equal(current, content) {
+			continue
+		}
+
+		// the file is renamed once written so that a partial policy is never loaded
+		tmpPath := path + ".tmp"
+		if err := ioutil.WriteFile(tmpPath, content, 0644); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to write cluster policy `%s`", name))
+			continue
+		}
+		if err := os.Rename(tmpPath, path); err != nil {
+			os.Remove(tmpPath)
+			result = multierror.Append(result, errors.Wrapf(err, "failed to write cluster policy `%s`", name))
+			continue
+		}
+		changed = true
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return changed, multierror.Append(result, err).ErrorOrNil()
+	}
+
+	for _, file := range files {
+		filename := file.Name()
+		if !strings.HasPrefix(filename, clusterPolicyPrefix) || filepath.Ext(filename) != ".policy" {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimPrefix(filename, clusterPolicyPrefix), ".policy")
+		if _, found := policies[name]; found {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(dir, filename)); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to remove cluster policy `%s`", name))
+			continue
+		}
+		changed = true
+	}
+
+	return changed, result.ErrorOrNil()
+}
+
+// ClusterPoliciesFingerprint returns a fingerprint of the policy files of the cluster policies directory,
+// which changes every time a policy is written or removed. It's empty if the directory doesn't exist.
+func ClusterPoliciesFingerprint(dir string) (string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	var fingerprint strings.Builder
+	for _, file := range files {
+		if filepath.Ext(file.Name()) != ".policy" {
+			continue
+		}
+		fmt.Fprintf(&fingerprint, "%s:%d:%d;", file.Name(), file.Size(), file.ModTime().UnixNano())
+	}
+	return fingerprint.String(), nil
+}
@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testClusterPolicy = `
+rules:
+  - id: shell_exec
+    expression: exec.name == "sh"
+`
+
+func readPolicyFiles(t *testing.T, dir string) map[string]string {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := make(map[string]string)
+	for _, file := range files {
+		content, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[file.Name()] = string(content)
+	}
+	return contents
+}
+
+func TestSyncClusterPolicies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cluster-policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "default.policy"), []byte(testClusterPolicy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := SyncClusterPolicies(dir, map[string][]byte{
+		"shells": []byte(testClusterPolicy),
+	})
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, map[string]string{
+		"default.policy":        testClusterPolicy,
+		"cluster-shells.policy": testClusterPolicy,
+	}, readPolicyFiles(t, dir))
+
+	// the same policies don't change anything
+	changed, err = SyncClusterPolicies(dir, map[string][]byte{
+		"shells": []byte(testClusterPolicy),
+	})
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	// the invalid policies are reported, their previous version is kept
+	changed, err = SyncClusterPolicies(dir, map[string][]byte{
+		"shells": []byte("rules:\n  - id: shell_exec\n"),
+		"../etc": []byte(testClusterPolicy),
+	})
+	assert.Error(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, map[string]string{
+		"default.policy":        testClusterPolicy,
+		"cluster-shells.policy": testClusterPolicy,
+	}, readPolicyFiles(t, dir))
+
+	// the removed policies are deleted, the other policy files are left untouched
+	changed, err = SyncClusterPolicies(dir, map[string][]byte{})
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, map[string]string{
+		"default.policy": testClusterPolicy,
+	}, readPolicyFiles(t, dir))
+}
+
+func TestClusterPoliciesFingerprint(t *testing.T) {
+	root, err := ioutil.TempDir("", "cluster-policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "cluster-policies")
+
+	fingerprint, err := ClusterPoliciesFingerprint(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, fingerprint)
+
+	// the directory is created with the first policies
+	_, err = SyncClusterPolicies(dir, map[string][]byte{
+		"shells": []byte(testClusterPolicy),
+	})
+	assert.NoError(t, err)
+	written, err := ClusterPoliciesFingerprint(dir)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, written)
+
+	// the files other than policies are ignored
+	if err := ioutil.WriteFile(filepath.Join(dir, "README"), []byte("policies"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, err = ClusterPoliciesFingerprint(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, written, fingerprint)
+
+	_, err = SyncClusterPolicies(dir, map[string][]byte{})
+	assert.NoError(t, err)
+	fingerprint, err = ClusterPoliciesFingerprint(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, fingerprint)
+}
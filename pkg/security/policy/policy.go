@@ -73,11 +73,26 @@ func LoadPolicy(r io.Reader) (*Policy, error) {
 	return policy, nil
 }
 
-// LoadPolicies loads the policies listed in the configuration and apply them to the given ruleset
+// LoadPolicies loads the policies of the policies directory, then the ones of the cluster policies
+// directory if it exists, and apply them to the given ruleset
 func LoadPolicies(config *config.Config, ruleSet *rules.RuleSet) error {
+	if err := loadPoliciesDir(config.PoliciesDir, ruleSet); err != nil {
+		return err
+	}
+
+	if config.ClusterPoliciesDir == "" {
+		return nil
+	}
+	if _, err := os.Stat(config.ClusterPoliciesDir); os.IsNotExist(err) {
+		return nil
+	}
+	return loadPoliciesDir(config.ClusterPoliciesDir, ruleSet)
+}
+
+func loadPoliciesDir(dir string, ruleSet *rules.RuleSet) error {
 	var result *multierror.Error
 
-	policyFiles, err := ioutil.ReadDir(config.PoliciesDir)
+	policyFiles, err := ioutil.ReadDir(dir)
 	if err != nil {
 		return err
 	}
@@ -93,7 +108,7 @@ func LoadPolicies(config *config.Config, ruleSet *rules.RuleSet) error {
 		}
 
 		// Open policy path
-		f, err := os.Open(filepath.Join(config.PoliciesDir, filename))
+		f, err := os.Open(filepath.Join(dir, filename))
 		if err != nil {
 			result = multierror.Append(result, errors.Wrapf(err, "failed to load policy `%s`", policyPath))
 			continue
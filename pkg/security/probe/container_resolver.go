@@ -16,7 +16,7 @@ type ContainerResolver struct{}
 
 // GetContainerID returns the container id of the given pid
 func (cr *ContainerResolver) GetContainerID(pid uint32) (utils.ContainerID, error) {
-	// Parse /proc/[pid]/moutinfo
+	// Parse /proc/[pid]/cgroup, according to the cgroup hierarchies mounted on the host
 	return utils.GetProcContainerID(pid, pid)
 }
 
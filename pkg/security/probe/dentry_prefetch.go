@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/moby/sys/mountinfo"
+)
+
+// prefetchedDentry is a dentry collected from the file system to warm the dentry cache, it mirrors
+// what the kernel stores in the pathnames map
+type prefetchedDentry struct {
+	MountID     uint32
+	Inode       uint64
+	ParentInode uint64
+	Name        string
+}
+
+// findMount returns the mount holding the given absolute path, which is the one with the longest mount point
+func findMount(mounts []*mountinfo.Info, path string) *mountinfo.Info {
+	var found *mountinfo.Info
+	for _, mnt := range mounts {
+		if mnt.Mountpoint != "/" && path != mnt.Mountpoint && !strings.HasPrefix(path, mnt.Mountpoint+"/") {
+			continue
+		}
+		// the last mount wins when a mount point is mounted over
+		if found == nil || len(mnt.Mountpoint) >= len(found.Mountpoint) {
+			found = mnt
+		}
+	}
+	return found
+}
+
+// lstatDir returns the stat of a directory, without following the symlinks
+func lstatDir(path string) (*syscall.Stat_t, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Lstat(path, &stat); err != nil {
+		return nil, &os.PathError{Op: "lstat", Path: path, Err: err}
+	}
+	if stat.Mode&syscall.S_IFMT != syscall.S_IFDIR {
+		return nil, fmt.Errorf("%s isn't a directory", path)
+	}
+	return &stat, nil
+}
+
+// collectDentries returns up to maxEntries dentries of the directory dir, seen from root with the given mounts:
+// the directory itself, its ancestors up to the root of its file system, and its direct children. The
+// directories of bind mounts aren't supported, the kernel resolves them up to the root of their file
+// system which isn't visible in the mount point.
+func collectDentries(root string, mounts []*mountinfo.Info, dir string, maxEntries int) ([]prefetchedDentry, error) {
+	if maxEntries <= 0 {
+		return nil, nil
+	}
+	if !filepath.IsAbs(dir) {
+		return nil, fmt.Errorf("%s isn't an absolute path", dir)
+	}
+	dir = filepath.Clean(dir)
+
+	mnt := findMount(mounts, dir)
+	if mnt == nil {
+		return nil, fmt.Errorf("no mount found for %s", dir)
+	}
+	if mnt.Root != "/" {
+		return nil, fmt.Errorf("%s is in the bind mount %s", dir, mnt.Mountpoint)
+	}
+	mountID := uint32(mnt.ID)
+
+	var dentries []prefetchedDentry
+
+	// the root of the file system has the name "/" and no parent
+	current := mnt.Mountpoint
+	stat, err := lstatDir(filepath.Join(root, current))
+	if err != nil {
+		return nil, err
+	}
+	dentries = append(dentries, prefetchedDentry{MountID: mountID, Inode: stat.Ino, Name: "/"})
+	parentInode := stat.Ino
+
+	for _, name := range strings.Split(strings.TrimPrefix(dir, mnt.Mountpoint), "/") {
+		if len(name) == 0 {
+			continue
+		}
+		if len(dentries) >= maxEntries {
+			return dentries, nil
+		}
+
+		// the symlinks are rejected, the kernel would resolve their targets and the ancestors would be wrong
+		current = filepath.Join(current, name)
+		if stat, err = lstatDir(filepath.Join(root, current)); err != nil {
+			return nil, err
+		}
+
+		dentries = append(dentries, prefetchedDentry{MountID: mountID, Inode: stat.Ino, ParentInode: parentInode, Name: name})
+		parentInode = stat.Ino
+	}
+
+	if len(dentries) >= maxEntries {
+		return dentries, nil
+	}
+
+	f, err := os.Open(filepath.Join(root, dir))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	children, err := f.Readdir(maxEntries - len(dentries))
+	if err != nil && len(children) == 0 {
+		return dentries, nil
+	}
+
+	for _, child := range children {
+		childStat, ok := child.Sys().(*syscall.Stat_t)
+		// the children that are mount points belong to another mount
+		if !ok || childStat.Dev != stat.Dev {
+			continue
+		}
+		dentries = append(dentries, prefetchedDentry{MountID: mountID, Inode: childStat.Ino, ParentInode: parentInode, Name: child.Name()})
+	}
+
+	return dentries, nil
+}
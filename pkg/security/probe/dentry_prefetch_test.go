@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/moby/sys/mountinfo"
+	"github.com/stretchr/testify/assert"
+)
+
+func inodeOf(t *testing.T, path string) uint64 {
+	var stat syscall.Stat_t
+	if err := syscall.Lstat(path, &stat); err != nil {
+		t.Fatal(err)
+	}
+	return stat.Ino
+}
+
+func TestFindMount(t *testing.T) {
+	mounts := []*mountinfo.Info{
+		{ID: 1, Mountpoint: "/"},
+		{ID: 2, Mountpoint: "/usr"},
+		{ID: 3, Mountpoint: "/usr/local"},
+		{ID: 4, Mountpoint: "/usr"},
+	}
+
+	assert.Equal(t, 1, findMount(mounts, "/etc").ID)
+	assert.Equal(t, 1, findMount(mounts, "/usrbin").ID)
+	assert.Equal(t, 4, findMount(mounts, "/usr").ID)
+	assert.Equal(t, 4, findMount(mounts, "/usr/bin").ID)
+	assert.Equal(t, 3, findMount(mounts, "/usr/local/bin").ID)
+	assert.Nil(t, findMount(mounts[1:], "/etc"))
+}
+
+func TestCollectDentries(t *testing.T) {
+	root, err := ioutil.TempDir("", "dentry-prefetch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	bin := filepath.Join(root, "usr", "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"ls", "cat"} {
+		if err := ioutil.WriteFile(filepath.Join(bin, name), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("usr/bin", filepath.Join(root, "bin")); err != nil {
+		t.Fatal(err)
+	}
+
+	mounts := []*mountinfo.Info{
+		{ID: 42, Mountpoint: "/", Root: "/"},
+		{ID: 43, Mountpoint: "/var", Root: "/lib/docker"},
+	}
+
+	t.Run("directory", func(t *testing.T) {
+		dentries, err := collectDentries(root, mounts, "/usr/bin/", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rootInode, usrInode, binInode := inodeOf(t, root), inodeOf(t, filepath.Join(root, "usr")), inodeOf(t, bin)
+		assert.Equal(t, []prefetchedDentry{
+			{MountID: 42, Inode: rootInode, Name: "/"},
+			{MountID: 42, Inode: usrInode, ParentInode: rootInode, Name: "usr"},
+			{MountID: 42, Inode: binInode, ParentInode: usrInode, Name: "bin"},
+		}, dentries[:3])
+
+		assert.ElementsMatch(t, []prefetchedDentry{
+			{MountID: 42, Inode: inodeOf(t, filepath.Join(bin, "ls")), ParentInode: binInode, Name: "ls"},
+			{MountID: 42, Inode: inodeOf(t, filepath.Join(bin, "cat")), ParentInode: binInode, Name: "cat"},
+		}, dentries[3:])
+	})
+
+	t.Run("max-entries", func(t *testing.T) {
+		dentries, err := collectDentries(root, mounts, "/usr/bin", 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, dentries, 4)
+
+		dentries, err = collectDentries(root, mounts, "/usr/bin", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, dentries, 2)
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		_, err := collectDentries(root, mounts, "/bin", 10)
+		assert.Error(t, err)
+	})
+
+	t.Run("bind-mount", func(t *testing.T) {
+		_, err := collectDentries(root, mounts, "/var/lib", 10)
+		assert.Error(t, err)
+	})
+
+	t.Run("relative", func(t *testing.T) {
+		_, err := collectDentries(root, mounts, "usr/bin", 10)
+		assert.Error(t, err)
+	})
+}
@@ -10,6 +10,7 @@ package probe
 import (
 	"C"
 	"fmt"
+	"sync/atomic"
 	"unsafe"
 
 	lib "github.com/DataDog/ebpf"
@@ -17,13 +18,32 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
+// dentryCacheSize is the number of dentries kept in cache, on top of the prefetched ones
+const dentryCacheSize = 128
+
+// DentryResolverStats holds the number of paths resolved from the cache and from the kernel map, and the number
+// of paths that couldn't be resolved
+type DentryResolverStats struct {
+	CacheHits int64
+	MapHits   int64
+	Failures  int64
+}
+
 // DentryResolver resolves inode/mountID to full paths
 type DentryResolver struct {
+	// stats are updated atomically, they're kept first for the alignment on 32 bits architectures
+	stats DentryResolverStats
+
 	probe     *Probe
 	pathnames *lib.Map
 	cache     *lru.Cache
+
+	// replayed holds the recorded entries of the pathnames map when the events are replayed
+	replayed map[PathKey]PathValue
 }
 
 // ErrInvalidKeyPath is returned when inode or mountid are not valid
@@ -69,6 +89,28 @@ type PathValue struct {
 	Name   [128]byte
 }
 
+// lookup reads an entry of the pathnames map, mapKey being the key given to the kernel map. The entries are read
+// from the recording when the events are replayed, and recorded when the recorder is enabled.
+func (dr *DentryResolver) lookup(key PathKey, mapKey interface{}, path *PathValue) error {
+	if dr.replayed != nil {
+		value, exists := dr.replayed[key]
+		if !exists {
+			return errors.New("entry not recorded")
+		}
+		*path = value
+		return nil
+	}
+
+	if err := dr.pathnames.Lookup(mapKey, path); err != nil {
+		return err
+	}
+
+	if recorder := dr.probe.recorder; recorder != nil {
+		recorder.Record(RecordDentry, 0, marshalDentryRecord(key, path))
+	}
+	return nil
+}
+
 func (dr *DentryResolver) DelCacheEntry(mountID uint32, inode uint64) {
 	key := PathKey{MountID: mountID, Inode: inode}
 	dr.cache.Remove(key)
@@ -90,7 +132,7 @@ func (dr *DentryResolver) getNameFromMap(mountID uint32, inode uint64, pathID ui
 	key := PathKey{MountID: mountID, Inode: inode, PathID: pathID}
 	var path PathValue
 
-	if err := dr.pathnames.Lookup(key, &path); err != nil {
+	if err := dr.lookup(key, key, &path); err != nil {
 		return "", fmt.Errorf("unable to get filename for mountID `%d` and inode `%d`", mountID, inode)
 	}
 
@@ -156,7 +198,7 @@ func (dr *DentryResolver) ResolveFromMap(mountID uint32, inode uint64, pathID ui
 	// Fetch path recursively
 	for {
 		key.Write(keyBuffer)
-		if err = dr.pathnames.Lookup(keyBuffer, &path); err != nil {
+		if err = dr.lookup(key, keyBuffer, &path); err != nil {
 			filename = dentryPathKeyNotFound
 			break
 		}
@@ -193,12 +235,28 @@ func (dr *DentryResolver) ResolveFromMap(mountID uint32, inode uint64, pathID ui
 // Resolve the pathname of a dentry, starting at the pathnameKey in the pathnames table
 func (dr *DentryResolver) Resolve(mountID uint32, inode uint64, pathID uint32) string {
 	path, err := dr.ResolveFromCache(mountID, inode)
-	if err != nil {
-		path, _ = dr.ResolveFromMap(mountID, inode, pathID)
+	if err == nil {
+		atomic.AddInt64(&dr.stats.CacheHits, 1)
+		return path
+	}
+
+	if path, err = dr.ResolveFromMap(mountID, inode, pathID); err != nil {
+		atomic.AddInt64(&dr.stats.Failures, 1)
+	} else {
+		atomic.AddInt64(&dr.stats.MapHits, 1)
 	}
 	return path
 }
 
+// GetAndResetStats returns the number of paths resolved since the last call
+func (dr *DentryResolver) GetAndResetStats() DentryResolverStats {
+	return DentryResolverStats{
+		CacheHits: atomic.SwapInt64(&dr.stats.CacheHits, 0),
+		MapHits:   atomic.SwapInt64(&dr.stats.MapHits, 0),
+		Failures:  atomic.SwapInt64(&dr.stats.Failures, 0),
+	}
+}
+
 func (dr *DentryResolver) getParentFromCache(mountID uint32, inode uint64) (uint32, uint64, error) {
 	key := PathKey{MountID: mountID, Inode: inode}
 
@@ -215,7 +273,7 @@ func (dr *DentryResolver) getParentFromMap(mountID uint32, inode uint64, pathID
 	key := PathKey{MountID: mountID, Inode: inode, PathID: pathID}
 	var path PathValue
 
-	if err := dr.pathnames.Lookup(key, &path); err != nil {
+	if err := dr.lookup(key, key, &path); err != nil {
 		return 0, 0, err
 	}
 
@@ -242,7 +300,17 @@ func (dr *DentryResolver) Start() error {
 	}
 	dr.pathnames = pathnames
 
-	cache, err := lru.New(128)
+	return dr.initCache()
+}
+
+// initCache creates the cache of the resolved dentries
+func (dr *DentryResolver) initCache() error {
+	cacheSize := dentryCacheSize
+	if len(dr.probe.config.DentryPrefetchDirectories) > 0 {
+		cacheSize += dr.probe.config.DentryPrefetchMaxEntries
+	}
+
+	cache, err := lru.New(cacheSize)
 	if err != nil {
 		return err
 	}
@@ -250,3 +318,40 @@ func (dr *DentryResolver) Start() error {
 
 	return nil
 }
+
+// Prefetch warms the cache with the dentries of the given directories, and of their direct children, as seen
+// by the given pid. At most maxEntries dentries are added, the directories that can't be prefetched are skipped.
+func (dr *DentryResolver) Prefetch(pid uint32, dirs []string, maxEntries int) {
+	mounts, err := utils.ParseMountInfoFile(pid)
+	if err != nil {
+		log.Warnf("unable to prefetch the dentries: %s", err)
+		return
+	}
+
+	root := utils.ProcRootPath(pid)
+	for _, dir := range dirs {
+		dentries, err := collectDentries(root, mounts, dir, maxEntries)
+		if err != nil {
+			log.Warnf("unable to prefetch the dentries of %s: %s", dir, err)
+			continue
+		}
+
+		for _, dentry := range dentries {
+			path := PathValue{
+				Parent: PathKey{Inode: dentry.ParentInode},
+			}
+			if dentry.ParentInode != 0 {
+				path.Parent.MountID = dentry.MountID
+			}
+			// the name is truncated, and nul terminated, as in the kernel
+			copy(path.Name[:len(path.Name)-1], dentry.Name)
+
+			dr.cache.Add(PathKey{MountID: dentry.MountID, Inode: dentry.Inode}, path)
+		}
+
+		log.Debugf("%d dentries of %s prefetched", len(dentries), dir)
+		if maxEntries -= len(dentries); maxEntries <= 0 {
+			return
+		}
+	}
+}
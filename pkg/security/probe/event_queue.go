@@ -0,0 +1,275 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// EventPriority defines the order in which the queued events are evaluated, and which ones are dropped first
+// when the queue is full
+type EventPriority int
+
+const (
+	// LowPriority is used for the high volume, low signal events. They are the first ones to be dropped.
+	LowPriority EventPriority = iota
+	// NormalPriority is the default priority
+	NormalPriority
+	// HighPriority is used for the events that should never be starved by floods of lower priority events
+	HighPriority
+	maxEventPriority
+)
+
+// eventPriorities holds the priority of the event types which are not evaluated with the NormalPriority
+var eventPriorities = map[EventType]EventPriority{
+	FileOpenEventType:   LowPriority,
+	FileMountEventType:  HighPriority,
+	FileUmountEventType: HighPriority,
+}
+
+// Priority returns the priority of the events of this type in the event queue
+func (t EventType) Priority() EventPriority {
+	if priority, exists := eventPriorities[t]; exists {
+		return priority
+	}
+	return NormalPriority
+}
+
+// queuedEvent is an event waiting to be evaluated, along with the functions to call once it is
+type queuedEvent struct {
+	seq      uint64
+	event    *Event
+	callback []func()
+}
+
+// deferredFunc is a function to call once the events pushed before it were evaluated or dropped
+type deferredFunc struct {
+	seq uint64
+	fnc func()
+}
+
+func (e *queuedEvent) done() {
+	for _, fnc := range e.callback {
+		fnc()
+	}
+}
+
+// EventQueue is a bounded priority queue decoupling the perf map readers from the evaluation of the rules.
+// When the rule evaluation can't keep up with the events read from the kernel, the lowest priority events are
+// dropped first, so that floods of low signal events don't prevent the evaluation of the high signal ones.
+type EventQueue struct {
+	sync.Mutex
+	cond     *sync.Cond
+	queues   [maxEventPriority]*list.List
+	size     int
+	maxSize  int
+	closed   bool
+	dropped  [maxEventType]int64
+	seq      uint64
+	handling *queuedEvent
+	deferred []deferredFunc
+	// deferredLock keeps the deferred functions called in order
+	deferredLock sync.Mutex
+}
+
+// NewEventQueue returns a new EventQueue holding at most maxSize events
+func NewEventQueue(maxSize int) *EventQueue {
+	q := &EventQueue{
+		maxSize: maxSize,
+	}
+	q.cond = sync.NewCond(&q.Mutex)
+	for i := range q.queues {
+		q.queues[i] = list.New()
+	}
+	return q
+}
+
+// Push queues an event. The callbacks are called once the event was evaluated or dropped. When the queue is full,
+// the oldest event with the lowest priority, if it is lower than the priority of the pushed event, is dropped to
+// make room for it. Otherwise the pushed event is dropped. Push returns false if the pushed event was dropped.
+func (q *EventQueue) Push(event *Event, callback ...func()) bool {
+	priority := EventType(event.Type).Priority()
+
+	q.Lock()
+	q.seq++
+	entry := &queuedEvent{seq: q.seq, event: event, callback: callback}
+	if q.closed {
+		q.Unlock()
+		q.drop(entry)
+		return false
+	}
+
+	var evicted *queuedEvent
+	if q.size >= q.maxSize {
+		for p := LowPriority; p < priority; p++ {
+			if front := q.queues[p].Front(); front != nil {
+				evicted = q.queues[p].Remove(front).(*queuedEvent)
+				q.size--
+				break
+			}
+		}
+
+		if evicted == nil {
+			q.Unlock()
+			q.drop(entry)
+			return false
+		}
+	}
+
+	q.queues[priority].PushBack(entry)
+	q.size++
+	q.cond.Signal()
+	q.Unlock()
+
+	if evicted != nil {
+		q.drop(evicted)
+	}
+	return true
+}
+
+// pop returns the oldest event with the highest priority, waiting for one to be pushed if the queue is empty.
+// It returns nil once the queue is closed.
+func (q *EventQueue) pop() *queuedEvent {
+	q.Lock()
+	defer q.Unlock()
+
+	for q.size == 0 && !q.closed {
+		q.cond.Wait()
+	}
+
+	if q.closed {
+		return nil
+	}
+
+	for p := maxEventPriority - 1; p >= LowPriority; p-- {
+		if front := q.queues[p].Front(); front != nil {
+			q.size--
+			q.handling = q.queues[p].Remove(front).(*queuedEvent)
+			return q.handling
+		}
+	}
+	return nil
+}
+
+func (q *EventQueue) drop(entry *queuedEvent) {
+	atomic.AddInt64(&q.dropped[entry.event.Type], 1)
+	entry.done()
+	q.runDeferred()
+}
+
+// complete is called once the popped event was evaluated
+func (q *EventQueue) complete(entry *queuedEvent) {
+	entry.done()
+
+	q.Lock()
+	if q.handling == entry {
+		q.handling = nil
+	}
+	q.Unlock()
+
+	q.runDeferred()
+}
+
+// Defer calls fnc once all the events pushed so far were evaluated or dropped, right away if there's none. It's
+// used to update the resolvers, on exit or umount for example, without racing with the evaluation of the
+// queued events which still need the previous state.
+func (q *EventQueue) Defer(fnc func()) {
+	q.Lock()
+	q.deferred = append(q.deferred, deferredFunc{seq: q.seq, fnc: fnc})
+	q.Unlock()
+
+	q.runDeferred()
+}
+
+// runDeferred calls, in order, the deferred functions whose preceding events were all evaluated or dropped
+func (q *EventQueue) runDeferred() {
+	q.deferredLock.Lock()
+	defer q.deferredLock.Unlock()
+
+	q.Lock()
+	if len(q.deferred) == 0 {
+		q.Unlock()
+		return
+	}
+
+	// the sequence numbers of the events of each priority are increasing, the oldest pending event is either
+	// the one being evaluated or at the front of a queue
+	oldest := q.seq + 1
+	if q.handling != nil {
+		oldest = q.handling.seq
+	}
+	for _, queue := range q.queues {
+		if front := queue.Front(); front != nil && front.Value.(*queuedEvent).seq < oldest {
+			oldest = front.Value.(*queuedEvent).seq
+		}
+	}
+
+	var ready []deferredFunc
+	for len(q.deferred) > 0 && q.deferred[0].seq < oldest {
+		ready = append(ready, q.deferred[0])
+		q.deferred = q.deferred[1:]
+	}
+	q.Unlock()
+
+	for _, d := range ready {
+		d.fnc()
+	}
+}
+
+// Run evaluates the queued events, by priority, with the provided handler until the queue is closed
+func (q *EventQueue) Run(handler func(event *Event)) {
+	for {
+		entry := q.pop()
+		if entry == nil {
+			return
+		}
+
+		handler(entry.event)
+		q.complete(entry)
+	}
+}
+
+// Close stops the evaluation of the events and drops the queued ones
+func (q *EventQueue) Close() {
+	q.Lock()
+	q.closed = true
+	var entries []*queuedEvent
+	for _, queue := range q.queues {
+		for e := queue.Front(); e != nil; e = e.Next() {
+			entries = append(entries, e.Value.(*queuedEvent))
+		}
+		queue.Init()
+	}
+	q.size = 0
+	q.cond.Broadcast()
+	q.Unlock()
+
+	for _, entry := range entries {
+		q.drop(entry)
+	}
+	q.runDeferred()
+}
+
+// Len returns the number of queued events
+func (q *EventQueue) Len() int {
+	q.Lock()
+	defer q.Unlock()
+	return q.size
+}
+
+// GetDropped returns the number of dropped events of the specified type
+func (q *EventQueue) GetDropped(eventType EventType) int64 {
+	return atomic.LoadInt64(&q.dropped[eventType])
+}
+
+// GetAndResetDropped returns the number of dropped events of the specified type and resets the counter
+func (q *EventQueue) GetAndResetDropped(eventType EventType) int64 {
+	return atomic.SwapInt64(&q.dropped[eventType], 0)
+}
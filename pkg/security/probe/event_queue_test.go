@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventQueuePriority(t *testing.T) {
+	q := NewEventQueue(10)
+
+	for _, eventType := range []EventType{FileOpenEventType, FileChmodEventType, FileMountEventType, FileOpenEventType, FileUmountEventType} {
+		assert.True(t, q.Push(&Event{Type: uint64(eventType)}))
+	}
+	assert.Equal(t, 5, q.Len())
+
+	var popped []EventType
+	for q.Len() > 0 {
+		popped = append(popped, EventType(q.pop().event.Type))
+	}
+
+	assert.Equal(t, []EventType{FileMountEventType, FileUmountEventType, FileChmodEventType, FileOpenEventType, FileOpenEventType}, popped)
+}
+
+func TestEventQueueDrop(t *testing.T) {
+	q := NewEventQueue(2)
+
+	var evicted []uint64
+	push := func(eventType EventType, id uint64) bool {
+		return q.Push(&Event{Type: uint64(eventType), TimestampRaw: id}, func() { evicted = append(evicted, id) })
+	}
+
+	assert.True(t, push(FileOpenEventType, 1))
+	assert.True(t, push(FileOpenEventType, 2))
+
+	// the queue is full of events with a lower priority, the oldest one is dropped
+	assert.True(t, push(FileMountEventType, 3))
+	assert.Equal(t, []uint64{1}, evicted)
+
+	// the pushed event is dropped when there is no lower priority event to drop
+	assert.False(t, push(FileOpenEventType, 4))
+	assert.Equal(t, []uint64{1, 4}, evicted)
+
+	assert.True(t, push(FileMountEventType, 5))
+	assert.False(t, push(FileMountEventType, 6))
+	assert.Equal(t, []uint64{1, 4, 2, 6}, evicted)
+
+	assert.Equal(t, int64(3), q.GetDropped(FileOpenEventType))
+	assert.Equal(t, int64(1), q.GetAndResetDropped(FileMountEventType))
+	assert.Equal(t, int64(0), q.GetDropped(FileMountEventType))
+
+	// the queued events are dropped when the queue is closed
+	q.Close()
+	assert.Equal(t, []uint64{1, 4, 2, 6, 3, 5}, evicted)
+	assert.Nil(t, q.pop())
+	assert.False(t, push(FileOpenEventType, 7))
+}
+
+func TestEventQueueRun(t *testing.T) {
+	q := NewEventQueue(10)
+
+	handled := make(chan uint64, 10)
+	done := make(chan struct{})
+	go func() {
+		q.Run(func(event *Event) {
+			handled <- event.TimestampRaw
+		})
+		close(done)
+	}()
+
+	var called []uint64
+	callback := make(chan struct{}, 1)
+	q.Push(&Event{Type: uint64(FileOpenEventType), TimestampRaw: 1}, func() {
+		called = append(called, 1)
+		callback <- struct{}{}
+	})
+
+	assert.Equal(t, uint64(1), <-handled)
+	<-callback
+	assert.Equal(t, []uint64{1}, called)
+
+	q.Close()
+	<-done
+}
+
+func TestEventQueueDefer(t *testing.T) {
+	q := NewEventQueue(10)
+
+	var called []string
+	q.Defer(func() { called = append(called, "empty") })
+	assert.Equal(t, []string{"empty"}, called)
+
+	q.Push(&Event{Type: uint64(FileOpenEventType)})
+	q.Push(&Event{Type: uint64(FileMountEventType)})
+	q.Defer(func() { called = append(called, "exit") })
+
+	// the high priority event is evaluated first, the open event is still pending
+	entry := q.pop()
+	assert.Equal(t, uint64(FileMountEventType), entry.event.Type)
+	q.complete(entry)
+	assert.Equal(t, []string{"empty"}, called)
+
+	q.Push(&Event{Type: uint64(FileOpenEventType)})
+
+	// the events pushed after the deferred function don't delay it
+	entry = q.pop()
+	q.complete(entry)
+	assert.Equal(t, []string{"empty", "exit"}, called)
+
+	q.Defer(func() { called = append(called, "close") })
+	q.Close()
+	assert.Equal(t, []string{"empty", "exit", "close"}, called)
+}
@@ -9,11 +9,13 @@ package probe
 
 import "sync/atomic"
 
-// EventsStats holds statistics about the number of lost and received events
+// EventsStats holds statistics about the number of lost and received events, and about the number of discarders
+// added per event type
 //nolint:structcheck,unused
 type EventsStats struct {
-	Lost         int64
-	PerEventType [maxEventType]int64
+	Lost                   int64
+	PerEventType           [maxEventType]int64
+	DiscardersPerEventType [maxEventType]int64
 }
 
 // GetLost returns the number of lost events
@@ -45,3 +47,13 @@ func (e *EventsStats) CountLost(count int64) {
 func (e *EventsStats) CountEventType(eventType EventType, count int64) {
 	atomic.AddInt64(&e.PerEventType[eventType], count)
 }
+
+// CountDiscarder adds a discarder to the counter of discarders of the specified event type
+func (e *EventsStats) CountDiscarder(eventType EventType) {
+	atomic.AddInt64(&e.DiscardersPerEventType[eventType], 1)
+}
+
+// GetAndResetDiscarderCount returns the number of discarders added for the specified event type and resets the counter
+func (e *EventsStats) GetAndResetDiscarderCount(eventType EventType) int64 {
+	return atomic.SwapInt64(&e.DiscardersPerEventType[eventType], 0)
+}
@@ -0,0 +1,194 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/hashicorp/golang-lru/simplelru"
+
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// execDedupKey identifies identical execs. The kernel doesn't send the exec arguments
+// so identical execs are the ones of the same binary, in the same container, from the same parent.
+type execDedupKey struct {
+	MountID     uint32
+	Inode       uint64
+	PPid        uint32
+	ContainerID string
+}
+
+// execStat identifies the content of an executable, so that a binary replaced or modified in place
+// isn't deduplicated with the execs of its previous content
+type execStat struct {
+	inode uint64
+	mtime int64
+}
+
+type execDedupEntry struct {
+	entry     ProcessCacheEntry
+	stat      execStat
+	firstSeen time.Time
+}
+
+// execDedupCountKey identifies the execs whose deduplicated count is reported together
+type execDedupCountKey struct {
+	executable  string
+	containerID string
+}
+
+// ExecDedupEvent is reported with the number of identical execs of an executable deduplicated since the
+// previous report, as the deduplicated execs aren't resolved individually
+type ExecDedupEvent struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Executable  string    `json:"executable"`
+	ContainerID string    `json:"container_id,omitempty"`
+	Count       int64     `json:"count"`
+}
+
+// ExecDeduplicator collapses the identical execs occurring at high rates (cron storms, health check shells, ...)
+// so that the resolution of their cache entry is done only once per dedup window. The number of
+// deduplicated execs is reported as an aggregated count per executable.
+type ExecDeduplicator struct {
+	sync.Mutex
+	entries *simplelru.LRU
+	window  time.Duration
+	// pending holds the deduplicated execs counts that haven't been reported yet
+	pending map[execDedupCountKey]int64
+	handler func(*ExecDedupEvent)
+
+	// stat returns the identity of the executable of a pid, replaced in the tests
+	stat func(pid uint32) (execStat, error)
+}
+
+// NewExecDeduplicator instantiates a new exec deduplicator, the handler, if any, is called with the
+// aggregated counts of the deduplicated execs
+func NewExecDeduplicator(probe *Probe, handler func(*ExecDedupEvent)) (*ExecDeduplicator, error) {
+	lru, err := simplelru.NewLRU(probe.config.PIDCacheSize, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExecDeduplicator{
+		entries: lru,
+		window:  probe.config.ExecDedupWindow,
+		pending: make(map[execDedupCountKey]int64),
+		handler: handler,
+		stat:    statExecutable,
+	}, nil
+}
+
+// statExecutable returns the inode and the modification time of the executable of a pid
+func statExecutable(pid uint32) (execStat, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(utils.ProcExePath(pid), &stat); err != nil {
+		return execStat{}, err
+	}
+	return execStat{inode: stat.Ino, mtime: stat.Mtim.Nano()}, nil
+}
+
+func newExecDedupKey(entry *ProcessCacheEntry, ppid uint32) execDedupKey {
+	return execDedupKey{
+		MountID:     entry.MountID,
+		Inode:       entry.Inode,
+		PPid:        ppid,
+		ContainerID: string(entry.ContainerEvent.IDRaw[:]),
+	}
+}
+
+// Deduplicate looks for an identical exec seen within the dedup window. If one is found, and the executable
+// wasn't modified since, the already resolved fields are copied to the given entry and true is returned.
+func (d *ExecDeduplicator) Deduplicate(entry *ProcessCacheEntry, pid, ppid uint32, now time.Time) bool {
+	d.Lock()
+	defer d.Unlock()
+
+	key := newExecDedupKey(entry, ppid)
+	value, ok := d.entries.Get(key)
+	if !ok {
+		return false
+	}
+
+	dedup := value.(*execDedupEntry)
+	if now.Sub(dedup.firstSeen) > d.window {
+		d.entries.Remove(key)
+		return false
+	}
+
+	// the inode can be reused, and the binary rewritten in place, within the window
+	if stat, err := d.stat(pid); err != nil || stat != dedup.stat {
+		d.entries.Remove(key)
+		return false
+	}
+
+	d.pending[execDedupCountKey{executable: dedup.entry.PathnameStr, containerID: dedup.entry.ContainerEvent.ID}]++
+
+	entry.PathnameStr = dedup.entry.PathnameStr
+	entry.BasenameStr = dedup.entry.BasenameStr
+	entry.ContainerPath = dedup.entry.ContainerPath
+	entry.ContainerEvent.ID = dedup.entry.ContainerEvent.ID
+
+	return true
+}
+
+// Add registers the resolved entry of an exec so that the following identical execs can be deduplicated
+func (d *ExecDeduplicator) Add(entry *ProcessCacheEntry, pid, ppid uint32, now time.Time) {
+	stat, err := d.stat(pid)
+	if err != nil {
+		// the process already exited, its executable can't be told apart from a new one
+		return
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	d.entries.Add(newExecDedupKey(entry, ppid), &execDedupEntry{
+		entry:     *entry,
+		stat:      stat,
+		firstSeen: now,
+	})
+}
+
+// flush resets the counts of the deduplicated execs and reports them to the handler
+func (d *ExecDeduplicator) flush(now time.Time) map[execDedupCountKey]int64 {
+	d.Lock()
+	pending := d.pending
+	d.pending = make(map[execDedupCountKey]int64)
+	d.Unlock()
+
+	if d.handler != nil {
+		for key, count := range pending {
+			d.handler(&ExecDedupEvent{
+				Timestamp:   now,
+				Executable:  key.executable,
+				ContainerID: key.containerID,
+				Count:       count,
+			})
+		}
+	}
+	return pending
+}
+
+// SendStats sends the aggregated counts of deduplicated execs, as events and as metrics
+func (d *ExecDeduplicator) SendStats(statsdClient *statsd.Client) error {
+	for key, count := range d.flush(time.Now()) {
+		log.Tracef("deduplicated %d execs of %s", count, key.executable)
+
+		tags := []string{fmt.Sprintf("executable:%s", key.executable)}
+		if err := statsdClient.Count(MetricPrefix+".exec_dedup.deduplicated", count, tags, 1.0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
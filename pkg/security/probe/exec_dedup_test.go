@@ -0,0 +1,129 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+)
+
+func TestExecDeduplicator(t *testing.T) {
+	probe := &Probe{
+		config: &config.Config{
+			PIDCacheSize:    100,
+			ExecDedupWindow: 5 * time.Second,
+		},
+	}
+
+	var events []*ExecDedupEvent
+	d, err := NewExecDeduplicator(probe, func(event *ExecDedupEvent) {
+		events = append(events, event)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := map[uint32]execStat{}
+	d.stat = func(pid uint32) (execStat, error) {
+		stat, found := stats[pid]
+		if !found {
+			return execStat{}, errors.New("no such process")
+		}
+		return stat, nil
+	}
+	for pid := uint32(100); pid < 110; pid++ {
+		stats[pid] = execStat{inode: 33, mtime: 1000}
+	}
+
+	now := time.Now()
+	resolved := ProcessCacheEntry{
+		FileEvent: FileEvent{
+			MountID:     1,
+			Inode:       33,
+			PathnameStr: "/usr/bin/date",
+			BasenameStr: "date",
+		},
+	}
+
+	entry := ProcessCacheEntry{FileEvent: FileEvent{MountID: 1, Inode: 33}}
+	assert.False(t, d.Deduplicate(&entry, 100, 42, now))
+	d.Add(&resolved, 100, 42, now)
+
+	// identical exec within the window
+	entry = ProcessCacheEntry{FileEvent: FileEvent{MountID: 1, Inode: 33}}
+	assert.True(t, d.Deduplicate(&entry, 101, 42, now.Add(time.Second)))
+	assert.Equal(t, "/usr/bin/date", entry.PathnameStr)
+	assert.Equal(t, "date", entry.BasenameStr)
+
+	// different parent
+	entry = ProcessCacheEntry{FileEvent: FileEvent{MountID: 1, Inode: 33}}
+	assert.False(t, d.Deduplicate(&entry, 102, 43, now.Add(time.Second)))
+	assert.Empty(t, entry.PathnameStr)
+
+	// the aggregated count is reported once
+	d.flush(now)
+	assert.Equal(t, []*ExecDedupEvent{{Timestamp: now, Executable: "/usr/bin/date", Count: 1}}, events)
+	d.flush(now)
+	assert.Len(t, events, 1)
+
+	// outside of the window
+	entry = ProcessCacheEntry{FileEvent: FileEvent{MountID: 1, Inode: 33}}
+	assert.False(t, d.Deduplicate(&entry, 104, 42, now.Add(10*time.Second)))
+	assert.Empty(t, entry.PathnameStr)
+}
+
+func TestExecDeduplicatorModifiedExecutable(t *testing.T) {
+	probe := &Probe{
+		config: &config.Config{
+			PIDCacheSize:    100,
+			ExecDedupWindow: 5 * time.Second,
+		},
+	}
+
+	d, err := NewExecDeduplicator(probe, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := map[uint32]execStat{
+		100: {inode: 33, mtime: 1000},
+		101: {inode: 33, mtime: 2000},
+		102: {inode: 33, mtime: 1000},
+	}
+	d.stat = func(pid uint32) (execStat, error) {
+		stat, found := stats[pid]
+		if !found {
+			return execStat{}, errors.New("no such process")
+		}
+		return stat, nil
+	}
+
+	now := time.Now()
+	resolved := ProcessCacheEntry{FileEvent: FileEvent{MountID: 1, Inode: 33, PathnameStr: "/usr/bin/date"}}
+	d.Add(&resolved, 100, 42, now)
+
+	// the binary was rewritten in place, the entry is invalidated
+	entry := ProcessCacheEntry{FileEvent: FileEvent{MountID: 1, Inode: 33}}
+	assert.False(t, d.Deduplicate(&entry, 101, 42, now.Add(time.Second)))
+	assert.Equal(t, 0, d.entries.Len())
+
+	// the process exited before it could be checked
+	d.Add(&resolved, 102, 42, now)
+	entry = ProcessCacheEntry{FileEvent: FileEvent{MountID: 1, Inode: 33}}
+	assert.False(t, d.Deduplicate(&entry, 103, 42, now.Add(time.Second)))
+	assert.Empty(t, entry.PathnameStr)
+
+	// the processes that exited aren't registered
+	d.Add(&resolved, 103, 42, now)
+	assert.Equal(t, 0, d.entries.Len())
+}
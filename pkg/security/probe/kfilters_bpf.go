@@ -57,6 +57,36 @@ func discardPIDWithTimeout(probe *Probe, eventType EventType, pid uint32, timeou
 	return true, nil
 }
 
+// discarderMaps are the maps holding the discarders pushed in the kernel
+var discarderMaps = []string{"pid_discarders", "inode_discarders"}
+
+// FlushDiscarders removes all the discarders pushed in the kernel, so that the events they discard are
+// evaluated again, for instance against new rules
+func (p *Probe) FlushDiscarders() error {
+	for _, name := range discarderMaps {
+		table := p.Map(name)
+		if table == nil {
+			return errors.Errorf("unable to find the %s map", name)
+		}
+
+		// the keys are deleted once listed, deleting keys while iterating restarts the iteration
+		var key, value []byte
+		var keys [][]byte
+		iterator := table.Iterate()
+		for iterator.Next(&key, &value) {
+			keys = append(keys, append([]byte(nil), key...))
+		}
+		if err := iterator.Err(); err != nil {
+			return errors.Wrapf(err, "unable to list the %s", name)
+		}
+
+		for _, key := range keys {
+			_ = table.Delete(key)
+		}
+	}
+	return nil
+}
+
 type inodeDiscarder struct {
 	eventType EventType
 	pathKey   PathKey
@@ -13,9 +13,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"os/user"
 	"path"
-	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -839,14 +837,25 @@ type ProcessEvent struct {
 	Comm      string    `field:"name" handler:"ResolveComm,string"`
 	TTYName   string    `field:"tty_name" handler:"ResolveTTY,string"`
 	Pid       uint32    `field:"pid"`
+	NsPid     uint32    `field:"ns_pid" handler:"ResolveNsPid,int"`
 	Tid       uint32    `field:"tid"`
+	NsTid     uint32    `field:"ns_tid" handler:"ResolveNsTid,int"`
 	UID       uint32    `field:"uid"`
 	GID       uint32    `field:"gid"`
 	User      string    `field:"user" handler:"ResolveUser,string"`
 	Group     string    `field:"group" handler:"ResolveGroup,string"`
 	Timestamp time.Time `field:"-" handler:"ResolveTimestamp,string"`
 
-	CommRaw [16]byte `field:"-"`
+	AncestorsFilenames []string `field:"ancestors.filename" handler:"ResolveAncestorsFilenames,[]string"`
+	AncestorsBasenames []string `field:"ancestors.basename" handler:"ResolveAncestorsBasenames,[]string"`
+	AncestorsNames     []string `field:"ancestors.name" handler:"ResolveAncestorsNames,[]string"`
+	AncestorsPids      []int    `field:"ancestors.pid" handler:"ResolveAncestorsPids,[]int"`
+
+	ExecHistoryFilenames []string `field:"exec_history.filename" handler:"ResolveExecHistoryFilenames,[]string"`
+	ExecHistoryNames     []string `field:"exec_history.name" handler:"ResolveExecHistoryNames,[]string"`
+
+	CommRaw   [16]byte             `field:"-"`
+	ancestors []*ProcessCacheEntry `field:"-"`
 }
 
 // ResolveTimestamp converts a raw timestamp to a time object
@@ -905,7 +914,13 @@ func (p *ProcessEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
 		fmt.Fprintf(&buf, `"tty_name":"%s",`, tty)
 	}
 	fmt.Fprintf(&buf, `"pid":%d,`, p.Pid)
+	if nsPid := p.ResolveNsPid(resolvers); nsPid != 0 {
+		fmt.Fprintf(&buf, `"ns_pid":%d,`, nsPid)
+	}
 	fmt.Fprintf(&buf, `"tid":%d,`, p.Tid)
+	if nsTid := p.ResolveNsTid(resolvers); nsTid != 0 {
+		fmt.Fprintf(&buf, `"ns_tid":%d,`, nsTid)
+	}
 	fmt.Fprintf(&buf, `"uid":%d,`, p.UID)
 	fmt.Fprintf(&buf, `"gid":%d,`, p.GID)
 	fmt.Fprintf(&buf, `"filename":"%s",`, p.ResolveInode(resolvers))
@@ -913,6 +928,14 @@ func (p *ProcessEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
 	fmt.Fprintf(&buf, `"inode":%d,`, p.Inode)
 	fmt.Fprintf(&buf, `"mount_id":%d,`, p.MountID)
 	fmt.Fprintf(&buf, `"overlay_numlower":%d,`, p.OverlayNumLower)
+	// the exec chain is only reported when the pid executed more than the current binary
+	if history := resolvers.ProcessResolver.ExecHistory(p.Pid); len(history) > 1 {
+		historyJSON, err := json.Marshal(history)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, `"exec_history":%s,`, historyJSON)
+	}
 	fmt.Fprintf(&buf, `"timestamp":"%s"`, p.ResolveTimestamp(resolvers))
 	buf.WriteRune('}')
 
@@ -930,6 +953,22 @@ func (p *ProcessEvent) ResolveTTY(resolvers *Resolvers) string {
 	return p.TTYName
 }
 
+// ResolveNsPid resolves the pid of the process in its pid namespace, which is the pid seen from inside its container
+func (p *ProcessEvent) ResolveNsPid(resolvers *Resolvers) uint32 {
+	if p.NsPid == 0 {
+		p.NsPid = resolvers.ProcessResolver.HostToNsPid(p.Pid)
+	}
+	return p.NsPid
+}
+
+// ResolveNsTid resolves the tid of the thread in its pid namespace
+func (p *ProcessEvent) ResolveNsTid(resolvers *Resolvers) uint32 {
+	if p.NsTid == 0 {
+		p.NsTid = resolvers.ProcessResolver.HostToNsTid(p.Pid, p.Tid)
+	}
+	return p.NsTid
+}
+
 // ResolveComm resolves the comm of the process
 func (p *ProcessEvent) ResolveComm(resolvers *Resolvers) string {
 	if len(p.Comm) == 0 {
@@ -940,22 +979,99 @@ func (p *ProcessEvent) ResolveComm(resolvers *Resolvers) string {
 
 // ResolveUser resolves the user id of the process to a username
 func (p *ProcessEvent) ResolveUser(resolvers *Resolvers) string {
-	u, err := user.LookupId(strconv.Itoa(int(p.UID)))
-	if err == nil {
-		p.User = u.Username
+	if len(p.User) == 0 {
+		p.User = resolvers.UserGroupResolver.ResolveUser(p.UID, p.resolveContainerID(resolvers), p.Pid)
 	}
 	return p.User
 }
 
 // ResolveGroup resolves the group id of the process to a group name
 func (p *ProcessEvent) ResolveGroup(resolvers *Resolvers) string {
-	g, err := user.LookupGroupId(strconv.Itoa(int(p.GID)))
-	if err == nil {
-		p.Group = g.Name
+	if len(p.Group) == 0 {
+		p.Group = resolvers.UserGroupResolver.ResolveGroup(p.GID, p.resolveContainerID(resolvers), p.Pid)
 	}
 	return p.Group
 }
 
+// resolveAncestors returns the cache entries of the ancestors of the process, walking the parent chain once per event
+func (p *ProcessEvent) resolveAncestors(resolvers *Resolvers) []*ProcessCacheEntry {
+	if p.ancestors == nil {
+		p.ancestors = resolvers.ProcessResolver.Ancestors(p.Pid)
+	}
+	return p.ancestors
+}
+
+// ResolveAncestorsFilenames resolves the filenames of the ancestors of the process, from the parent to the oldest ancestor
+func (p *ProcessEvent) ResolveAncestorsFilenames(resolvers *Resolvers) []string {
+	if len(p.AncestorsFilenames) == 0 {
+		for _, ancestor := range p.resolveAncestors(resolvers) {
+			p.AncestorsFilenames = append(p.AncestorsFilenames, ancestor.FileEvent.ResolveInode(resolvers))
+		}
+	}
+	return p.AncestorsFilenames
+}
+
+// ResolveAncestorsBasenames resolves the basenames of the ancestors of the process, from the parent to the oldest ancestor
+func (p *ProcessEvent) ResolveAncestorsBasenames(resolvers *Resolvers) []string {
+	if len(p.AncestorsBasenames) == 0 {
+		for _, ancestor := range p.resolveAncestors(resolvers) {
+			p.AncestorsBasenames = append(p.AncestorsBasenames, ancestor.FileEvent.ResolveBasename(resolvers))
+		}
+	}
+	return p.AncestorsBasenames
+}
+
+// ResolveAncestorsNames resolves the names of the ancestors of the process, from the parent to the oldest ancestor
+func (p *ProcessEvent) ResolveAncestorsNames(resolvers *Resolvers) []string {
+	if len(p.AncestorsNames) == 0 {
+		for _, ancestor := range p.resolveAncestors(resolvers) {
+			p.AncestorsNames = append(p.AncestorsNames, ancestor.Comm)
+		}
+	}
+	return p.AncestorsNames
+}
+
+// ResolveAncestorsPids resolves the pids of the ancestors of the process, from the parent to the oldest ancestor
+func (p *ProcessEvent) ResolveAncestorsPids(resolvers *Resolvers) []int {
+	if len(p.AncestorsPids) == 0 {
+		for _, ancestor := range p.resolveAncestors(resolvers) {
+			p.AncestorsPids = append(p.AncestorsPids, int(ancestor.Pid))
+		}
+	}
+	return p.AncestorsPids
+}
+
+// ResolveExecHistoryFilenames resolves the filenames of the execs of the pid, oldest first, the last one being
+// the current exec
+func (p *ProcessEvent) ResolveExecHistoryFilenames(resolvers *Resolvers) []string {
+	if len(p.ExecHistoryFilenames) == 0 {
+		for _, record := range resolvers.ProcessResolver.ExecHistory(p.Pid) {
+			p.ExecHistoryFilenames = append(p.ExecHistoryFilenames, record.Filename)
+		}
+	}
+	return p.ExecHistoryFilenames
+}
+
+// ResolveExecHistoryNames resolves the names of the execs of the pid, oldest first, the last one being the
+// current exec
+func (p *ProcessEvent) ResolveExecHistoryNames(resolvers *Resolvers) []string {
+	if len(p.ExecHistoryNames) == 0 {
+		for _, record := range resolvers.ProcessResolver.ExecHistory(p.Pid) {
+			p.ExecHistoryNames = append(p.ExecHistoryNames, record.Comm)
+		}
+	}
+	return p.ExecHistoryNames
+}
+
+// resolveContainerID returns the container ID of the process, used to resolve the user and group
+// names with the passwd and group files of the container
+func (p *ProcessEvent) resolveContainerID(resolvers *Resolvers) string {
+	if entry := resolvers.ProcessResolver.Resolve(p.Pid); entry != nil {
+		return entry.GetContainerID()
+	}
+	return ""
+}
+
 // UnmarshalBinary unmarshals a binary representation of itself
 func (p *ProcessEvent) UnmarshalBinary(data []byte) (int, error) {
 	if len(data) < 32 {
@@ -1252,12 +1368,47 @@ func (e *Event) GetType() string {
 	return EventType(e.Type).String()
 }
 
+// GetTimestamp returns the absolute timestamp of the event
+func (e *Event) GetTimestamp() time.Time {
+	return e.ResolveMonotonicTimestamp(e.resolvers)
+}
+
 // GetTags returns the list of tags specific to this event
 func (e *Event) GetTags() []string {
 	// TODO: add container tags once we collect them
 	return []string{"type:" + e.GetType()}
 }
 
+// GetFile returns the file targeted by the event, or nil for the events that don't target a file. For the
+// rename and link events, the source file is returned.
+func (e *Event) GetFile() *FileEvent {
+	switch EventType(e.Type) {
+	case FileOpenEventType:
+		return &e.Open.FileEvent
+	case FileMkdirEventType:
+		return &e.Mkdir.FileEvent
+	case FileLinkEventType:
+		return &e.Link.Source
+	case FileRenameEventType:
+		return &e.Rename.Old
+	case FileUnlinkEventType:
+		return &e.Unlink.FileEvent
+	case FileRmdirEventType:
+		return &e.Rmdir.FileEvent
+	case FileChmodEventType:
+		return &e.Chmod.FileEvent
+	case FileChownEventType:
+		return &e.Chown.FileEvent
+	case FileUtimeEventType:
+		return &e.Utimes.FileEvent
+	case FileSetXAttrEventType:
+		return &e.SetXAttr.FileEvent
+	case FileRemoveXAttrEventType:
+		return &e.RemoveXAttr.FileEvent
+	}
+	return nil
+}
+
 // GetPointer return an unsafe.Pointer of the Event
 func (e *Event) GetPointer() unsafe.Pointer {
 	return unsafe.Pointer(e)
@@ -1,3 +1,4 @@
+//go:build linux
 // +build linux
 
 // Code generated - DO NOT EDIT.
@@ -387,6 +388,46 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "process.ancestors.basename":
+
+		return &eval.StringArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []string {
+				return (*Event)(ctx.Object).Process.ResolveAncestorsBasenames((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "process.ancestors.filename":
+
+		return &eval.StringArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []string {
+				return (*Event)(ctx.Object).Process.ResolveAncestorsFilenames((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "process.ancestors.name":
+
+		return &eval.StringArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []string {
+				return (*Event)(ctx.Object).Process.ResolveAncestorsNames((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "process.ancestors.pid":
+
+		return &eval.IntArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []int {
+				return (*Event)(ctx.Object).Process.ResolveAncestorsPids((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "process.basename":
 
 		return &eval.StringEvaluator{
@@ -407,6 +448,26 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "process.exec_history.filename":
+
+		return &eval.StringArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []string {
+				return (*Event)(ctx.Object).Process.ResolveExecHistoryFilenames((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "process.exec_history.name":
+
+		return &eval.StringArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []string {
+				return (*Event)(ctx.Object).Process.ResolveExecHistoryNames((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "process.filename":
 
 		return &eval.StringEvaluator{
@@ -453,6 +514,26 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "process.ns_pid":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int {
+				return int((*Event)(ctx.Object).Process.ResolveNsPid((*Event)(ctx.Object).resolvers))
+			},
+
+			Field: field,
+		}, nil
+
+	case "process.ns_tid":
+
+		return &eval.IntEvaluator{
+			EvalFnc: func(ctx *eval.Context) int {
+				return int((*Event)(ctx.Object).Process.ResolveNsTid((*Event)(ctx.Object).resolvers))
+			},
+
+			Field: field,
+		}, nil
+
 	case "process.overlay_numlower":
 
 		return &eval.IntEvaluator{
@@ -1099,6 +1180,22 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Open.Retval), nil
 
+	case "process.ancestors.basename":
+
+		return e.Process.ResolveAncestorsBasenames(e.resolvers), nil
+
+	case "process.ancestors.filename":
+
+		return e.Process.ResolveAncestorsFilenames(e.resolvers), nil
+
+	case "process.ancestors.name":
+
+		return e.Process.ResolveAncestorsNames(e.resolvers), nil
+
+	case "process.ancestors.pid":
+
+		return e.Process.ResolveAncestorsPids(e.resolvers), nil
+
 	case "process.basename":
 
 		return e.Process.ResolveBasename(e.resolvers), nil
@@ -1107,6 +1204,14 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Process.ResolveContainerPath(e.resolvers), nil
 
+	case "process.exec_history.filename":
+
+		return e.Process.ResolveExecHistoryFilenames(e.resolvers), nil
+
+	case "process.exec_history.name":
+
+		return e.Process.ResolveExecHistoryNames(e.resolvers), nil
+
 	case "process.filename":
 
 		return e.Process.ResolveInode(e.resolvers), nil
@@ -1127,6 +1232,14 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return e.Process.ResolveComm(e.resolvers), nil
 
+	case "process.ns_pid":
+
+		return int(e.Process.ResolveNsPid(e.resolvers)), nil
+
+	case "process.ns_tid":
+
+		return int(e.Process.ResolveNsTid(e.resolvers)), nil
+
 	case "process.overlay_numlower":
 
 		return int(e.Process.OverlayNumLower), nil
@@ -1469,12 +1582,30 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "open.retval":
 		return "open", nil
 
+	case "process.ancestors.basename":
+		return "*", nil
+
+	case "process.ancestors.filename":
+		return "*", nil
+
+	case "process.ancestors.name":
+		return "*", nil
+
+	case "process.ancestors.pid":
+		return "*", nil
+
 	case "process.basename":
 		return "*", nil
 
 	case "process.container_path":
 		return "*", nil
 
+	case "process.exec_history.filename":
+		return "*", nil
+
+	case "process.exec_history.name":
+		return "*", nil
+
 	case "process.filename":
 		return "*", nil
 
@@ -1490,6 +1621,12 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "process.name":
 		return "*", nil
 
+	case "process.ns_pid":
+		return "*", nil
+
+	case "process.ns_tid":
+		return "*", nil
+
 	case "process.overlay_numlower":
 		return "*", nil
 
@@ -1822,6 +1959,22 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "process.ancestors.basename":
+
+		return reflect.String, nil
+
+	case "process.ancestors.filename":
+
+		return reflect.String, nil
+
+	case "process.ancestors.name":
+
+		return reflect.String, nil
+
+	case "process.ancestors.pid":
+
+		return reflect.Int, nil
+
 	case "process.basename":
 
 		return reflect.String, nil
@@ -1830,6 +1983,14 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.String, nil
 
+	case "process.exec_history.filename":
+
+		return reflect.String, nil
+
+	case "process.exec_history.name":
+
+		return reflect.String, nil
+
 	case "process.filename":
 
 		return reflect.String, nil
@@ -1850,6 +2011,14 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.String, nil
 
+	case "process.ns_pid":
+
+		return reflect.Int, nil
+
+	case "process.ns_tid":
+
+		return reflect.Int, nil
+
 	case "process.overlay_numlower":
 
 		return reflect.Int, nil
@@ -2407,6 +2576,54 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Open.Retval = int64(v)
 		return nil
 
+	case "process.ancestors.basename":
+
+		switch v := value.(type) {
+		case string:
+			e.Process.AncestorsBasenames = []string{v}
+		case []string:
+			e.Process.AncestorsBasenames = v
+		default:
+			return &eval.ErrValueTypeMismatch{Field: "Process.AncestorsBasenames"}
+		}
+		return nil
+
+	case "process.ancestors.filename":
+
+		switch v := value.(type) {
+		case string:
+			e.Process.AncestorsFilenames = []string{v}
+		case []string:
+			e.Process.AncestorsFilenames = v
+		default:
+			return &eval.ErrValueTypeMismatch{Field: "Process.AncestorsFilenames"}
+		}
+		return nil
+
+	case "process.ancestors.name":
+
+		switch v := value.(type) {
+		case string:
+			e.Process.AncestorsNames = []string{v}
+		case []string:
+			e.Process.AncestorsNames = v
+		default:
+			return &eval.ErrValueTypeMismatch{Field: "Process.AncestorsNames"}
+		}
+		return nil
+
+	case "process.ancestors.pid":
+
+		switch v := value.(type) {
+		case int:
+			e.Process.AncestorsPids = []int{v}
+		case []int:
+			e.Process.AncestorsPids = v
+		default:
+			return &eval.ErrValueTypeMismatch{Field: "Process.AncestorsPids"}
+		}
+		return nil
+
 	case "process.basename":
 
 		if e.Process.BasenameStr, ok = value.(string); !ok {
@@ -2421,6 +2638,30 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "process.exec_history.filename":
+
+		switch v := value.(type) {
+		case string:
+			e.Process.ExecHistoryFilenames = []string{v}
+		case []string:
+			e.Process.ExecHistoryFilenames = v
+		default:
+			return &eval.ErrValueTypeMismatch{Field: "Process.ExecHistoryFilenames"}
+		}
+		return nil
+
+	case "process.exec_history.name":
+
+		switch v := value.(type) {
+		case string:
+			e.Process.ExecHistoryNames = []string{v}
+		case []string:
+			e.Process.ExecHistoryNames = v
+		default:
+			return &eval.ErrValueTypeMismatch{Field: "Process.ExecHistoryNames"}
+		}
+		return nil
+
 	case "process.filename":
 
 		if e.Process.PathnameStr, ok = value.(string); !ok {
@@ -2460,6 +2701,24 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		}
 		return nil
 
+	case "process.ns_pid":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.NsPid"}
+		}
+		e.Process.NsPid = uint32(v)
+		return nil
+
+	case "process.ns_tid":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.NsTid"}
+		}
+		e.Process.NsTid = uint32(v)
+		return nil
+
 	case "process.overlay_numlower":
 
 		v, ok := value.(int)
@@ -133,6 +133,18 @@ func (mr *MountResolver) delete(mount *MountEvent) {
 	mr.deleteDevice(mount)
 }
 
+// mountPoints returns a copy of the mount points of the cache
+func (mr *MountResolver) mountPoints() []MountEvent {
+	mr.lock.RLock()
+	defer mr.lock.RUnlock()
+
+	mounts := make([]MountEvent, 0, len(mr.mounts))
+	for _, mount := range mr.mounts {
+		mounts = append(mounts, *mount)
+	}
+	return mounts
+}
+
 // Delete a mount from the cache
 func (mr *MountResolver) Delete(mountID uint32) error {
 	mr.lock.Lock()
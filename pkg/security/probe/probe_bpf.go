@@ -18,6 +18,7 @@ import (
 	"github.com/DataDog/ebpf/manager"
 	"github.com/pkg/errors"
 
+	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
 	"github.com/DataDog/datadog-agent/pkg/security/config"
 	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
@@ -31,6 +32,9 @@ import (
 const (
 	// MetricPrefix is the prefix of the metrics sent by the runtime security agent
 	MetricPrefix = "datadog.runtime_security"
+	// HealthMetricPrefix is the prefix of the internal metrics reporting the health of the probe, next to the
+	// other metrics of the security agent
+	HealthMetricPrefix = "datadog.security_agent.runtime"
 )
 
 // EventHandler represents an handler for the events sent by the probe
@@ -56,21 +60,32 @@ var (
 // Probe represents the runtime security eBPF probe in charge of
 // setting up the required kProbes and decoding events sent from the kernel
 type Probe struct {
-	manager           *manager.Manager
-	managerOptions    manager.Options
-	config            *config.Config
-	handler           EventHandler
-	resolvers         *Resolvers
-	onDiscardersFncs  map[eval.EventType][]onDiscarderFnc
-	syscallMonitor    *SyscallMonitor
-	loadController    *LoadController
-	kernelVersion     kernel.Version
-	_                 uint32 // padding for goarch=386
-	eventsStats       EventsStats
-	startTime         time.Time
-	event             *Event
-	mountEvent        *Event
-	invalidDiscarders map[eval.Field]map[interface{}]bool
+	manager              *manager.Manager
+	managerOptions       manager.Options
+	config               *config.Config
+	handler              EventHandler
+	resolvers            *Resolvers
+	onDiscardersFncs     map[eval.EventType][]onDiscarderFnc
+	syscallMonitor       *SyscallMonitor
+	loadController       *LoadController
+	execDedupHandler     func(*ExecDedupEvent)
+	exitHandler          func(pid uint32)
+	execDeduplicator     *ExecDeduplicator
+	eventQueue           *EventQueue
+	kernelVersion        kernel.Version
+	_                    uint32 // padding for goarch=386
+	eventsStats          EventsStats
+	startTime            time.Time
+	event                *Event
+	mountEvent           *Event
+	invalidDiscarders    map[eval.Field]map[interface{}]bool
+	processLifetimeStats *ProcessLifetimeStats
+	recorder             *EventRecorder
+	cancelSnapshot       context.CancelFunc
+	replay               *replay
+
+	// lastProcessResolverStats are the cumulated counters of the process resolver at the last SendStats call
+	lastProcessResolverStats ProcessResolverStats
 }
 
 // Map returns a map by its name
@@ -115,6 +130,13 @@ func (p *Probe) Init() error {
 		fncs = append(fncs, fnc)
 		p.onDiscardersFncs[eventType] = fncs
 	}
+
+	if p.config.ExecDedupEnabled {
+		var err error
+		if p.execDeduplicator, err = NewExecDeduplicator(p, p.execDedupHandler); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -163,7 +185,7 @@ func (p *Probe) InitManager(rs *rules.RuleSet) error {
 	}
 
 	if err := p.manager.InitWithOptions(bytecodeReader, p.managerOptions); err != nil {
-		return err
+		return ddebpf.NewLoadError("runtime_security", errors.Wrap(err, "failed to init ebpf manager"))
 	}
 
 	if err := p.resolvers.Start(); err != nil {
@@ -182,10 +204,32 @@ func (p *Probe) InitManager(rs *rules.RuleSet) error {
 
 // Start the runtime security probe
 func (p *Probe) Start() error {
+	if p.config.EventRecorderEnabled {
+		header := RecordingHeader{
+			KernelVersion: p.kernelVersion.String(),
+			BootTime:      p.resolvers.TimeResolver.bootTime,
+			StartTime:     time.Now(),
+		}
+
+		limits := RecorderLimits{
+			MaxSize:     p.config.EventRecorderMaxSize,
+			MaxDuration: p.config.EventRecorderMaxDuration,
+		}
+		recorder, err := NewEventRecorder(p.config.EventRecorderFile, header, limits)
+		if err != nil {
+			return errors.Wrap(err, "couldn't create the event recorder")
+		}
+		p.recorder = recorder
+		log.Infof("Recording the kernel events to %s", p.config.EventRecorderFile)
+	}
+
 	if err := p.manager.Start(); err != nil {
-		return err
+		return ddebpf.NewLoadError("runtime_security", errors.Wrap(err, "could not start ebpf manager"))
 	}
 	go p.loadController.Start(context.Background())
+	if p.eventQueue != nil {
+		go p.eventQueue.Run(p.DispatchEvent)
+	}
 	return nil
 }
 
@@ -194,6 +238,18 @@ func (p *Probe) SetEventHandler(handler EventHandler) {
 	p.handler = handler
 }
 
+// SetExecDedupHandler sets the function called with the aggregated counts of the deduplicated execs, it must be
+// set before the probe is initialized
+func (p *Probe) SetExecDedupHandler(handler func(*ExecDedupEvent)) {
+	p.execDedupHandler = handler
+}
+
+// SetExitHandler sets the function called with the pid of the processes which exited, once their queued events
+// were dispatched
+func (p *Probe) SetExitHandler(handler func(pid uint32)) {
+	p.exitHandler = handler
+}
+
 // DispatchEvent sends an event to probe event handler
 func (p *Probe) DispatchEvent(event *Event) {
 	if p.handler != nil {
@@ -201,6 +257,34 @@ func (p *Probe) DispatchEvent(event *Event) {
 	}
 }
 
+// queueEvent pushes a copy of the event to the event queue, or dispatches it right away when the queue is
+// disabled. The callbacks are called once the event, and the ones queued before it, were dispatched or dropped.
+func (p *Probe) queueEvent(event *Event, callback ...func()) {
+	if p.eventQueue == nil {
+		p.DispatchEvent(event)
+		for _, fnc := range callback {
+			fnc()
+		}
+		return
+	}
+
+	queued := event.Clone()
+	p.eventQueue.Push(&queued)
+	for _, fnc := range callback {
+		p.eventQueue.Defer(fnc)
+	}
+}
+
+// afterQueuedEvents calls fnc once the queued events were dispatched or dropped, right away when the queue is
+// disabled. The resolvers are updated with it so that the queued events are resolved with the previous state.
+func (p *Probe) afterQueuedEvents(fnc func()) {
+	if p.eventQueue == nil {
+		fnc()
+		return
+	}
+	p.eventQueue.Defer(fnc)
+}
+
 // SendStats sends statistics about the probe to Datadog
 func (p *Probe) SendStats(statsdClient *statsd.Client) error {
 	if p.syscallMonitor != nil {
@@ -209,10 +293,28 @@ func (p *Probe) SendStats(statsdClient *statsd.Client) error {
 		}
 	}
 
+	if p.execDeduplicator != nil {
+		if err := p.execDeduplicator.SendStats(statsdClient); err != nil {
+			return err
+		}
+	}
+
+	if p.processLifetimeStats != nil {
+		if err := p.processLifetimeStats.SendStats(statsdClient); err != nil {
+			return err
+		}
+	}
+
 	if err := statsdClient.Count(MetricPrefix+".events.lost", p.eventsStats.GetAndResetLost(), nil, 1.0); err != nil {
 		return err
 	}
 
+	if p.eventQueue != nil {
+		if err := statsdClient.Gauge(MetricPrefix+".events.queue.size", float64(p.eventQueue.Len()), nil, 1.0); err != nil {
+			return err
+		}
+	}
+
 	receivedEvents := MetricPrefix + ".events.received"
 	for i := range p.eventsStats.PerEventType {
 		if i == 0 {
@@ -225,6 +327,73 @@ func (p *Probe) SendStats(statsdClient *statsd.Client) error {
 			if err := statsdClient.Count(receivedEvents, value, tags, 1.0); err != nil {
 				return err
 			}
+			if err := statsdClient.Count(HealthMetricPrefix+".events.received", value, tags, 1.0); err != nil {
+				return err
+			}
+		}
+
+		if value := p.eventsStats.GetAndResetDiscarderCount(eventType); value > 0 {
+			if err := statsdClient.Count(HealthMetricPrefix+".discarders.added", value, tags, 1.0); err != nil {
+				return err
+			}
+		}
+
+		if p.eventQueue != nil {
+			if value := p.eventQueue.GetAndResetDropped(eventType); value > 0 {
+				if err := statsdClient.Count(MetricPrefix+".events.queue.dropped", value, tags, 1.0); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return p.sendResolverStats(statsdClient)
+}
+
+// sendResolverStats sends the health of the path and process resolvers, the counters of the process resolver
+// being cumulated, the differences since the previous call are sent
+func (p *Probe) sendResolverStats(statsdClient *statsd.Client) error {
+	dentryStats := p.resolvers.DentryResolver.GetAndResetStats()
+	for _, count := range []struct {
+		result string
+		value  int64
+	}{
+		{result: "cache", value: dentryStats.CacheHits},
+		{result: "map", value: dentryStats.MapHits},
+		{result: "failure", value: dentryStats.Failures},
+	} {
+		if count.value == 0 {
+			continue
+		}
+		tags := []string{"result:" + count.result}
+		if err := statsdClient.Count(HealthMetricPrefix+".path_resolution", count.value, tags, 1.0); err != nil {
+			return err
+		}
+	}
+
+	processStats := p.resolvers.ProcessResolver.GetStats()
+	last := p.lastProcessResolverStats
+	p.lastProcessResolverStats = processStats
+
+	if err := statsdClient.Gauge(HealthMetricPrefix+".process_cache.size", float64(processStats.CacheSize), nil, 1.0); err != nil {
+		return err
+	}
+
+	for _, count := range []struct {
+		metric string
+		value  int64
+	}{
+		{metric: ".process_cache.kernel_map_lookups", value: processStats.KernelMapLookups - last.KernelMapLookups},
+		{metric: ".process_cache.kernel_map_misses", value: processStats.KernelMapMisses - last.KernelMapMisses},
+		{metric: ".process_cache.procfs_fallbacks", value: processStats.ProcfsFallbacks - last.ProcfsFallbacks},
+		{metric: ".process_cache.procfs_errors", value: processStats.ProcfsErrors - last.ProcfsErrors},
+		{metric: ".process_cache.unmarshal_errors", value: processStats.UnmarshalErrors - last.UnmarshalErrors},
+	} {
+		if count.value == 0 {
+			continue
+		}
+		if err := statsdClient.Count(HealthMetricPrefix+count.metric, count.value, nil, 1.0); err != nil {
+			return err
 		}
 	}
 
@@ -258,6 +427,25 @@ func (p *Probe) GetStats() (map[string]interface{}, error) {
 		perEventType[eventType.String()] = p.eventsStats.GetEventCount(eventType)
 	}
 
+	if p.eventQueue != nil {
+		dropped := make(map[string]int64)
+		for i := range p.eventsStats.PerEventType {
+			if i == 0 {
+				continue
+			}
+
+			eventType := EventType(i)
+			dropped[eventType.String()] = p.eventQueue.GetDropped(eventType)
+		}
+
+		stats["event_queue"] = map[string]interface{}{
+			"size":    p.eventQueue.Len(),
+			"dropped": dropped,
+		}
+	}
+
+	stats["process_resolver"] = p.resolvers.ProcessResolver.GetStats()
+
 	return stats, err
 }
 
@@ -266,6 +454,11 @@ func (p *Probe) GetEventsStats() EventsStats {
 	return p.eventsStats
 }
 
+// GetProcessResolverStats returns the health of the process resolver
+func (p *Probe) GetProcessResolverStats() ProcessResolverStats {
+	return p.resolvers.ProcessResolver.GetStats()
+}
+
 func (p *Probe) handleLostEvents(CPU int, count uint64, perfMap *manager.PerfMap, manager *manager.Manager) {
 	log.Tracef("lost %d events\n", count)
 	p.eventsStats.CountLost(int64(count))
@@ -300,6 +493,10 @@ func (p *Probe) unmarshalProcessContainer(data []byte, event *Event) (int, error
 }
 
 func (p *Probe) handleMountEvent(CPU int, data []byte, perfMap *manager.PerfMap, manager *manager.Manager) {
+	if p.recorder != nil {
+		p.recorder.Record(RecordMountEvent, CPU, data)
+	}
+
 	offset := 0
 	event := p.zeroMountEvent()
 
@@ -339,10 +536,13 @@ func (p *Probe) handleMountEvent(CPU int, data []byte, perfMap *manager.PerfMap,
 			log.Errorf("failed to decode umount event: %s (offset %d, len %d)", err, offset, len(data))
 			return
 		}
-		// Delete new mount point from cache
-		if err := p.resolvers.MountResolver.Delete(event.Umount.MountID); err != nil {
-			log.Errorf("failed to delete mount point %d from cache: %s", event.Umount.MountID, err)
-		}
+		// Delete new mount point from cache, once the events of the mount point were dispatched
+		mountID := event.Umount.MountID
+		p.afterQueuedEvents(func() {
+			if err := p.resolvers.MountResolver.Delete(mountID); err != nil {
+				log.Errorf("failed to delete mount point %d from cache: %s", mountID, err)
+			}
+		})
 	default:
 		log.Errorf("unsupported event type %d on perf map %s", eventType, perfMap.Name)
 		return
@@ -350,10 +550,14 @@ func (p *Probe) handleMountEvent(CPU int, data []byte, perfMap *manager.PerfMap,
 
 	p.eventsStats.CountEventType(eventType, 1)
 	p.loadController.Count(eventType, event.Process.Pid)
-	p.DispatchEvent(event)
+	p.queueEvent(event)
 }
 
 func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, manager *manager.Manager) {
+	if p.recorder != nil {
+		p.recorder.Record(RecordEvent, CPU, data)
+	}
+
 	offset := 0
 	event := p.zeroEvent()
 
@@ -375,8 +579,38 @@ func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, mana
 			return
 		}
 
+		if p.processLifetimeStats != nil {
+			timestamp := p.resolvers.TimeResolver.ResolveMonotonicTimestamp(event.TimestampRaw)
+			containerID := event.Exec.ProcessCacheEntry.ContainerEvent.GetContainerID()
+			if event.Exec.PPid != 0 {
+				p.processLifetimeStats.Fork(event.Exec.Pid, containerID, timestamp)
+			} else {
+				p.processLifetimeStats.Exec(event.Exec.Pid, containerID, timestamp)
+			}
+		}
+
+		// fork events carry the ppid, only the actual execs are deduplicated
+		if p.execDeduplicator == nil || event.Exec.PPid != 0 {
+			p.resolvers.ProcessResolver.AddEntry(event.Exec.Pid, event.Exec.ProcessCacheEntry)
+			return
+		}
+
+		var ppid uint32
+		if prevEntry := p.resolvers.ProcessResolver.Get(event.Exec.Pid); prevEntry != nil {
+			ppid = prevEntry.PPid
+		}
+
+		now := time.Now()
+		deduplicated := p.execDeduplicator.Deduplicate(&event.Exec.ProcessCacheEntry, event.Exec.Pid, ppid, now)
+
 		p.resolvers.ProcessResolver.AddEntry(event.Exec.Pid, event.Exec.ProcessCacheEntry)
 
+		if !deduplicated {
+			if entry := p.resolvers.ProcessResolver.Get(event.Exec.Pid); entry != nil {
+				p.execDeduplicator.Add(entry, event.Exec.Pid, ppid, now)
+			}
+		}
+
 		return
 	case ExitEventType:
 		if _, err := event.Exit.UnmarshalBinary(data[offset:]); err != nil {
@@ -384,9 +618,24 @@ func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, mana
 			return
 		}
 
-		// as far as we keep only one perf for all the event we can delete the entry right away, there won't be
-		// any race
-		p.resolvers.ProcessResolver.DelEntry(event.Exit.Pid)
+		if p.processLifetimeStats != nil {
+			var containerID string
+			if entry := p.resolvers.ProcessResolver.Get(event.Exit.Pid); entry != nil {
+				containerID = entry.ContainerEvent.ID
+			}
+			timestamp := p.resolvers.TimeResolver.ResolveMonotonicTimestamp(event.TimestampRaw)
+			p.processLifetimeStats.Exit(event.Exit.Pid, containerID, timestamp)
+		}
+
+		// the queued events of the process are resolved with its entry, it's deleted once they were dispatched
+		pid := event.Exit.Pid
+		p.afterQueuedEvents(func() {
+			p.resolvers.ProcessResolver.DelEntry(pid)
+			p.resolvers.PidNamespaceResolver.Forget(int(pid))
+			if p.exitHandler != nil {
+				p.exitHandler(pid)
+			}
+		})
 
 		// no need to dispatch
 		return
@@ -398,7 +647,8 @@ func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, mana
 
 		log.Tracef("remove dentry cache entry for inode %d", event.InvalidateDentry.Inode)
 
-		p.resolvers.DentryResolver.DelCacheEntry(event.InvalidateDentry.MountID, event.InvalidateDentry.Inode)
+		mountID, inode := event.InvalidateDentry.MountID, event.InvalidateDentry.Inode
+		p.afterQueuedEvents(func() { p.resolvers.DentryResolver.DelCacheEntry(mountID, inode) })
 
 		// If a temporary file is created and deleted in a row a discarder can be added
 		// after the in-kernel discarder cleanup and thus a discarder will be pushed for a deleted file.
@@ -418,6 +668,9 @@ func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, mana
 	}
 	offset += read
 
+	// callback is called once the event was dispatched
+	var callback []func()
+
 	switch eventType {
 	case FileOpenEventType:
 		if _, err := event.Open.UnmarshalBinary(data[offset:]); err != nil {
@@ -437,8 +690,9 @@ func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, mana
 
 		log.Tracef("remove dentry cache entry for inode %d", event.Rmdir.Inode)
 
-		// defer it do ensure that it will be done after the dispatch that could re-add it
-		defer p.resolvers.DentryResolver.DelCacheEntry(event.Rmdir.MountID, event.Rmdir.Inode)
+		// delay it to ensure that it will be done after the dispatch that could re-add it
+		mountID, inode := event.Rmdir.MountID, event.Rmdir.Inode
+		callback = append(callback, func() { p.resolvers.DentryResolver.DelCacheEntry(mountID, inode) })
 	case FileUnlinkEventType:
 		if _, err := event.Unlink.UnmarshalBinary(data[offset:]); err != nil {
 			log.Errorf("failed to decode unlink event: %s (offset %d, len %d)", err, offset, len(data))
@@ -447,8 +701,9 @@ func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, mana
 
 		log.Tracef("remove dentry cache entry for inode %d", event.Unlink.Inode)
 
-		// defer it do ensure that it will be done after the dispatch that could re-add it
-		defer p.resolvers.DentryResolver.DelCacheEntry(event.Unlink.MountID, event.Unlink.Inode)
+		// delay it to ensure that it will be done after the dispatch that could re-add it
+		mountID, inode := event.Unlink.MountID, event.Unlink.Inode
+		callback = append(callback, func() { p.resolvers.DentryResolver.DelCacheEntry(mountID, inode) })
 	case FileRenameEventType:
 		if _, err := event.Rename.UnmarshalBinary(data[offset:]); err != nil {
 			log.Errorf("failed to decode rename event: %s (offset %d, len %d)", err, offset, len(data))
@@ -458,8 +713,9 @@ func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, mana
 		log.Tracef("remove dentry cache entry for inode %d", event.Rename.New.Inode)
 
 		// use the new.inode as the old one is a fake one generated from the probe. See RenameEvent.MarshalJSON
-		// defer it do ensure that it will be done after the dispatch that could re-add it
-		defer p.resolvers.DentryResolver.DelCacheEntry(event.Rename.New.MountID, event.Rename.New.Inode)
+		// delay it to ensure that it will be done after the dispatch that could re-add it
+		mountID, inode := event.Rename.New.MountID, event.Rename.New.Inode
+		callback = append(callback, func() { p.resolvers.DentryResolver.DelCacheEntry(mountID, inode) })
 	case FileChmodEventType:
 		if _, err := event.Chmod.UnmarshalBinary(data[offset:]); err != nil {
 			log.Errorf("failed to decode chmod event: %s (offset %d, len %d)", err, offset, len(data))
@@ -499,7 +755,7 @@ func (p *Probe) handleEvent(CPU int, data []byte, perfMap *manager.PerfMap, mana
 
 	p.eventsStats.CountEventType(eventType, 1)
 	p.loadController.Count(eventType, event.Process.Pid)
-	p.DispatchEvent(event)
+	p.queueEvent(event, callback...)
 }
 
 // OnNewDiscarder is called when a new discarder is found
@@ -521,6 +777,10 @@ func (p *Probe) OnNewDiscarder(rs *rules.RuleSet, event *Event, field eval.Field
 		}
 	}
 
+	if len(p.onDiscardersFncs[eventType]) > 0 && event.Type < uint64(maxEventType) {
+		p.eventsStats.CountDiscarder(EventType(event.Type))
+	}
+
 	return nil
 }
 
@@ -566,13 +826,78 @@ func (p *Probe) RegisterProbesSelectors(selectors []manager.ProbesSelector) erro
 }
 
 // Snapshot runs the different snapshot functions of the resolvers that
-// require to sync with the current state of the system
+// require to sync with the current state of the system. When the snapshot is asynchronous, it returns right away
+// and the progress of the snapshot is reported by the stats of the process resolver.
 func (p *Probe) Snapshot() error {
-	return p.resolvers.Snapshot()
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancelSnapshot = cancel
+
+	if !p.config.SnapshotAsync {
+		return p.snapshot(ctx)
+	}
+
+	go func() {
+		if err := p.snapshot(ctx); err != nil && ctx.Err() == nil {
+			log.Errorf("failed to snapshot the running processes: %s", err)
+		}
+	}()
+	return nil
+}
+
+func (p *Probe) snapshot(ctx context.Context) error {
+	start := time.Now()
+	if err := p.resolvers.Snapshot(ctx); err != nil {
+		return err
+	}
+	log.Infof("Snapshot of the running processes completed in %s", time.Since(start))
+
+	if p.recorder != nil {
+		return p.recordSnapshot()
+	}
+	return nil
+}
+
+// recordSnapshot records the entries of the process cache and the mount points known once the snapshot completed,
+// they're restored before the events are replayed. The events are recorded from then on.
+func (p *Probe) recordSnapshot() error {
+	for _, entry := range p.resolvers.ProcessResolver.entries() {
+		if err := p.recorder.RecordJSON(RecordProcessSnapshot, entry); err != nil {
+			return err
+		}
+	}
+
+	for _, mount := range p.resolvers.MountResolver.mountPoints() {
+		if err := p.recorder.RecordJSON(RecordMountSnapshot, mount); err != nil {
+			return err
+		}
+	}
+
+	p.recorder.StartEvents()
+	return nil
 }
 
 func (p *Probe) Close() error {
-	return p.manager.Stop(manager.CleanAll)
+	if p.cancelSnapshot != nil {
+		p.cancelSnapshot()
+	}
+
+	if p.eventQueue != nil {
+		p.eventQueue.Close()
+	}
+
+	// the replayed probes have no manager
+	var err error
+	if p.manager != nil {
+		err = p.manager.Stop(manager.CleanAll)
+	}
+
+	if p.recorder != nil {
+		if recErr := p.recorder.Close(); recErr != nil {
+			log.Errorf("failed to save the recording of the kernel events: %s", recErr)
+		}
+	}
+
+	return err
 }
 
 // IsInvalidDiscarder returns whether the given value is a valid discarder for the given field
@@ -626,6 +951,14 @@ func NewProbe(config *config.Config, client *statsd.Client) (*Probe, error) {
 		return nil, err
 	}
 
+	if config.ProcessLifetimeEnabled {
+		p.processLifetimeStats = NewProcessLifetimeStats(config.PIDCacheSize)
+	}
+
+	if config.EventQueueEnabled {
+		p.eventQueue = NewEventQueue(config.EventQueueSize)
+	}
+
 	return p, nil
 }
 
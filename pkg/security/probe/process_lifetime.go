@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+)
+
+// lifetimeBuckets are the upper bounds of the process lifetime histogram buckets, the last bucket holding the
+// processes living longer than the last bound
+var lifetimeBuckets = []struct {
+	bound time.Duration
+	name  string
+}{
+	{bound: 100 * time.Millisecond, name: "lt_100ms"},
+	{bound: time.Second, name: "lt_1s"},
+	{bound: 10 * time.Second, name: "lt_10s"},
+	{bound: time.Minute, name: "lt_1m"},
+	{bound: 10 * time.Minute, name: "lt_10m"},
+	{bound: time.Hour, name: "lt_1h"},
+}
+
+const lifetimeBucketInf = "ge_1h"
+
+// shortLivedThreshold is the lifetime below which a process is considered as short lived
+const shortLivedThreshold = time.Second
+
+func lifetimeBucket(lifetime time.Duration) string {
+	for _, bucket := range lifetimeBuckets {
+		if lifetime < bucket.bound {
+			return bucket.name
+		}
+	}
+	return lifetimeBucketInf
+}
+
+type processStart struct {
+	time        time.Time
+	containerID string
+}
+
+type containerChurn struct {
+	forks      int64
+	execs      int64
+	exits      int64
+	shortLived int64
+	lifetimes  map[string]int64
+}
+
+// ProcessLifetimeStats tracks the forks, execs and exits seen by the probe to report the process churn and a
+// histogram of the process lifetimes, per container. Unlike the /proc sampling, the processes living less than
+// the sampling interval are accounted for.
+type ProcessLifetimeStats struct {
+	sync.Mutex
+	starts     map[uint32]processStart
+	maxEntries int
+	// pending holds the counts that haven't been reported yet, per container
+	pending map[string]*containerChurn
+}
+
+// NewProcessLifetimeStats returns a new process lifetime tracker following at most maxEntries processes
+func NewProcessLifetimeStats(maxEntries int) *ProcessLifetimeStats {
+	return &ProcessLifetimeStats{
+		starts:     make(map[uint32]processStart),
+		maxEntries: maxEntries,
+		pending:    make(map[string]*containerChurn),
+	}
+}
+
+func (s *ProcessLifetimeStats) churn(containerID string) *containerChurn {
+	churn, ok := s.pending[containerID]
+	if !ok {
+		churn = &containerChurn{lifetimes: make(map[string]int64)}
+		s.pending[containerID] = churn
+	}
+	return churn
+}
+
+// Fork records the creation of a process
+func (s *ProcessLifetimeStats) Fork(pid uint32, containerID string, now time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.churn(containerID).forks++
+
+	// a process whose exit was lost may still be tracked
+	if _, exists := s.starts[pid]; exists || len(s.starts) < s.maxEntries {
+		s.starts[pid] = processStart{time: now, containerID: containerID}
+	}
+}
+
+// Exec records an exec. The lifetime of a process isn't reset by its execs.
+func (s *ProcessLifetimeStats) Exec(pid uint32, containerID string, now time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.churn(containerID).execs++
+
+	if _, exists := s.starts[pid]; !exists && len(s.starts) < s.maxEntries {
+		s.starts[pid] = processStart{time: now, containerID: containerID}
+	}
+}
+
+// Exit records the exit of a process. The lifetime is only known for the processes created after the
+// start of the probe.
+func (s *ProcessLifetimeStats) Exit(pid uint32, containerID string, now time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	start, exists := s.starts[pid]
+	if exists {
+		delete(s.starts, pid)
+		if containerID == "" {
+			containerID = start.containerID
+		}
+	}
+
+	churn := s.churn(containerID)
+	churn.exits++
+
+	if !exists {
+		return
+	}
+
+	lifetime := now.Sub(start.time)
+	if lifetime < shortLivedThreshold {
+		churn.shortLived++
+	}
+	churn.lifetimes[lifetimeBucket(lifetime)]++
+}
+
+// SendStats sends the process churn and lifetime metrics
+func (s *ProcessLifetimeStats) SendStats(client *statsd.Client) error {
+	s.Lock()
+	pending := s.pending
+	s.pending = make(map[string]*containerChurn)
+	s.Unlock()
+
+	for containerID, churn := range pending {
+		var tags []string
+		if containerID != "" {
+			tags = []string{fmt.Sprintf("container_id:%s", containerID)}
+		}
+
+		counts := []struct {
+			metric string
+			value  int64
+		}{
+			{metric: MetricPrefix + ".process.forks", value: churn.forks},
+			{metric: MetricPrefix + ".process.execs", value: churn.execs},
+			{metric: MetricPrefix + ".process.exits", value: churn.exits},
+			{metric: MetricPrefix + ".process.short_lived", value: churn.shortLived},
+		}
+		for _, count := range counts {
+			if count.value == 0 {
+				continue
+			}
+			if err := client.Count(count.metric, count.value, tags, 1.0); err != nil {
+				return err
+			}
+		}
+
+		for bucket, count := range churn.lifetimes {
+			bucketTags := append([]string{fmt.Sprintf("lifetime:%s", bucket)}, tags...)
+			if err := client.Count(MetricPrefix+".process.lifetime", count, bucketTags, 1.0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifetimeBucket(t *testing.T) {
+	assert.Equal(t, "lt_100ms", lifetimeBucket(0))
+	assert.Equal(t, "lt_1s", lifetimeBucket(100*time.Millisecond))
+	assert.Equal(t, "lt_10m", lifetimeBucket(5*time.Minute))
+	assert.Equal(t, "ge_1h", lifetimeBucket(2*time.Hour))
+}
+
+func TestProcessLifetimeStats(t *testing.T) {
+	s := NewProcessLifetimeStats(2)
+
+	now := time.Now()
+	s.Fork(42, "abc", now)
+	s.Exec(42, "abc", now.Add(10*time.Millisecond))
+	s.Exit(42, "abc", now.Add(50*time.Millisecond))
+
+	churn := s.pending["abc"]
+	assert.Equal(t, int64(1), churn.forks)
+	assert.Equal(t, int64(1), churn.execs)
+	assert.Equal(t, int64(1), churn.exits)
+	assert.Equal(t, int64(1), churn.shortLived)
+	assert.Equal(t, map[string]int64{"lt_100ms": 1}, churn.lifetimes)
+
+	// the container of the start is used when the exit doesn't carry one
+	s.Fork(43, "def", now)
+	s.Exit(43, "", now.Add(2*time.Minute))
+	assert.Equal(t, map[string]int64{"lt_10m": 1}, s.pending["def"].lifetimes)
+	assert.Equal(t, int64(0), s.pending["def"].shortLived)
+
+	// the lifetime of the processes started before the probe is unknown
+	s.Exit(44, "", now)
+	assert.Equal(t, int64(1), s.pending[""].exits)
+	assert.Empty(t, s.pending[""].lifetimes)
+
+	// no more processes are tracked once the limit is reached
+	s.Fork(45, "", now)
+	s.Fork(46, "", now)
+	s.Fork(47, "", now)
+	assert.Len(t, s.starts, 2)
+}
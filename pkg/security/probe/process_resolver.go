@@ -16,6 +16,20 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/security/utils"
 )
 
+const (
+	// maxAncestorsDepth is the maximum number of ancestors resolved for a process
+	maxAncestorsDepth = 32
+	// maxExecHistory is the maximum number of execs kept per process
+	maxExecHistory = 8
+)
+
+// ExecRecord describes an exec of a process
+type ExecRecord struct {
+	Filename  string    `json:"filename"`
+	Comm      string    `json:"name"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // ProcessCacheEntry this structure holds the container context that we keep in kernel for each process
 type ProcessCacheEntry struct {
 	FileEvent
@@ -25,8 +39,13 @@ type ProcessCacheEntry struct {
 	Cookie       uint32
 	TTYName      string
 	Comm         string
+	Pid          uint32
 	PPid         uint32
 
+	// ExecHistory holds the execs of the pid, oldest first, the last one being the current exec. It is never
+	// modified in place, a new slice is set for each exec.
+	ExecHistory []ExecRecord
+
 	TTYNameRaw [64]byte
 }
 
@@ -58,3 +77,65 @@ func (pc *ProcessCacheEntry) GetTTY() string {
 	}
 	return pc.TTYName
 }
+
+// appendExecRecord returns a copy of the history with the exec appended, without the oldest execs above
+// maxExecHistory
+func appendExecRecord(history []ExecRecord, record ExecRecord) []ExecRecord {
+	if len(history) >= maxExecHistory {
+		history = history[len(history)-maxExecHistory+1:]
+	}
+
+	result := make([]ExecRecord, len(history), len(history)+1)
+	copy(result, history)
+	return append(result, record)
+}
+
+// Ancestors returns the cache entries of the ancestors of the given pid, from the parent to the
+// oldest ancestor, up to maxAncestorsDepth ancestors. The lineage stops at the first ancestor missing from the cache.
+func (p *ProcessResolver) Ancestors(pid uint32) []*ProcessCacheEntry {
+	var ancestors []*ProcessCacheEntry
+
+	// a reused pid may point back to one of its descendants, the lineage stops at the first pid seen twice
+	visited := map[uint32]bool{pid: true}
+
+	entry := p.Resolve(pid)
+	for entry != nil && entry.PPid != 0 && !visited[entry.PPid] && len(ancestors) < maxAncestorsDepth {
+		pid = entry.PPid
+		visited[pid] = true
+		if entry = p.Resolve(pid); entry != nil {
+			ancestors = append(ancestors, entry)
+		}
+	}
+
+	return ancestors
+}
+
+// HostToNsPid returns the pid of the given host pid in its pid namespace, which is the pid seen from inside its
+// container, or 0 if it can't be resolved
+func (p *ProcessResolver) HostToNsPid(pid uint32) uint32 {
+	nsPid, err := p.resolvers.PidNamespaceResolver.HostToNsPid(int(pid))
+	if err != nil {
+		return 0
+	}
+	return uint32(nsPid)
+}
+
+// HostToNsTid returns the tid of the given host thread of the process pid in its pid namespace, or 0 if it can't
+// be resolved
+func (p *ProcessResolver) HostToNsTid(pid uint32, tid uint32) uint32 {
+	nsTid, err := p.resolvers.PidNamespaceResolver.HostToNsTid(int(pid), int(tid))
+	if err != nil {
+		return 0
+	}
+	return uint32(nsTid)
+}
+
+// NsToHostPid returns the host pid of the process whose pid is nsPid in the pid namespace of inode nsIno, or 0 if
+// it can't be resolved
+func (p *ProcessResolver) NsToHostPid(nsIno uint64, nsPid uint32) uint32 {
+	pid, err := p.resolvers.PidNamespaceResolver.NsToHostPid(nsIno, int(nsPid))
+	if err != nil {
+		return 0
+	}
+	return uint32(pid)
+}
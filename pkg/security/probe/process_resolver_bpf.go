@@ -8,7 +8,9 @@
 package probe
 
 import (
+	"context"
 	"os"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -16,10 +18,12 @@ import (
 	"github.com/DataDog/ebpf/manager"
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
 
 	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/security/ebpf/probes"
 	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/gopsutil/process"
 )
@@ -31,6 +35,51 @@ var snapshotProbeIDs = []manager.ProbeIdentificationPair{
 	},
 }
 
+var (
+	tlmProcessCacheSize = telemetry.NewGauge("runtime_security", "process_cache_size",
+		nil, "Number of entries in the process cache")
+	tlmProcessKernelMapLookups = telemetry.NewCounter("runtime_security", "process_kernel_map_lookups",
+		[]string{"result"}, "Number of processes missing from the cache looked up in the kernel maps")
+	tlmProcessProcfsFallbacks = telemetry.NewCounter("runtime_security", "process_procfs_fallbacks",
+		[]string{"result"}, "Number of processes resolved from /proc")
+	tlmProcessUnmarshalErrors = telemetry.NewCounter("runtime_security", "process_unmarshal_errors",
+		nil, "Number of process entries of the kernel maps that couldn't be decoded")
+	tlmProcessSnapshotDuration = telemetry.NewGauge("runtime_security", "process_snapshot_duration",
+		nil, "Duration in seconds of the last snapshot of the processes")
+)
+
+// snapshot states of the process resolver
+const (
+	snapshotPending int32 = iota
+	snapshotRunning
+	snapshotDone
+	snapshotFailed
+)
+
+var snapshotStateNames = [...]string{
+	snapshotPending: "pending",
+	snapshotRunning: "running",
+	snapshotDone:    "done",
+	snapshotFailed:  "failed",
+}
+
+// ProcessResolverStats holds the health of the process resolver, the counters are cumulated since it started
+type ProcessResolverStats struct {
+	CacheSize        int     `json:"cache_size"`
+	KernelMapLookups int64   `json:"kernel_map_lookups"`
+	KernelMapMisses  int64   `json:"kernel_map_misses"`
+	ProcfsFallbacks  int64   `json:"procfs_fallbacks"`
+	ProcfsErrors     int64   `json:"procfs_errors"`
+	UnmarshalErrors  int64   `json:"unmarshal_errors"`
+	SnapshotDuration float64 `json:"snapshot_duration"`
+	// SnapshotState is the progress of the snapshot of /proc: pending, running, done or failed
+	SnapshotState string `json:"snapshot_state"`
+	// SnapshotScanned and SnapshotTotal are the number of pids of /proc scanned by the current pass of the snapshot,
+	// and the number of pids it found
+	SnapshotScanned int64 `json:"snapshot_scanned"`
+	SnapshotTotal   int64 `json:"snapshot_total"`
+}
+
 // InodeInfo holds information related to inode from kernel
 type InodeInfo struct {
 	MountID         uint32
@@ -46,6 +95,19 @@ type ProcessResolver struct {
 	procCacheMap   *lib.Map
 	pidCookieMap   *lib.Map
 	entryCache     *lru.Cache
+
+	// replayed holds the recorded entries of the proc_cache map, by pid, when the events are replayed
+	replayed map[uint32][]byte
+
+	kernelMapLookups int64
+	kernelMapMisses  int64
+	procfsFallbacks  int64
+	procfsErrors     int64
+	unmarshalErrors  int64
+	snapshotDuration int64
+	snapshotScanned  int64
+	snapshotTotal    int64
+	snapshotState    int32
 }
 
 // UnmarshalBinary unmarshals a binary representation of itself
@@ -64,6 +126,8 @@ func (p *ProcessResolver) AddEntry(pid uint32, entry ProcessCacheEntry) {
 }
 
 func (p *ProcessResolver) addEntry(pid uint32, entry *ProcessCacheEntry) {
+	entry.Pid = pid
+
 	// resolve now, so that the dentry cache is up to date
 	entry.FileEvent.ResolveInode(p.resolvers)
 	entry.FileEvent.ResolveContainerPath(p.resolvers)
@@ -73,43 +137,112 @@ func (p *ProcessResolver) addEntry(pid uint32, entry *ProcessCacheEntry) {
 		entry.Timestamp = p.resolvers.TimeResolver.ResolveMonotonicTimestamp(entry.TimestampRaw)
 	}
 
+	// fork events carry the ppid, an existing entry is replaced by an exec of the process otherwise
+	var execHistory []ExecRecord
+
 	// check for an existing entry first to inherit ppid
 	prevEntry, ok := p.entryCache.Get(pid)
 	if ok {
+		if entry.PPid == 0 {
+			execHistory = prevEntry.(*ProcessCacheEntry).ExecHistory
+		}
 		entry.PPid = prevEntry.(*ProcessCacheEntry).PPid
 	}
 
+	entry.ExecHistory = appendExecRecord(execHistory, ExecRecord{
+		Filename:  entry.FileEvent.PathnameStr,
+		Comm:      entry.Comm,
+		Timestamp: entry.Timestamp,
+	})
+
 	p.entryCache.Add(pid, entry)
+	tlmProcessCacheSize.Set(float64(p.entryCache.Len()))
+}
+
+// ExecHistory returns the execs of the given pid, oldest first, the last one being the current exec
+func (p *ProcessResolver) ExecHistory(pid uint32) []ExecRecord {
+	entry := p.Resolve(pid)
+	if entry == nil {
+		return nil
+	}
+	return entry.ExecHistory
 }
 
 func (p *ProcessResolver) DelEntry(pid uint32) {
 	p.entryCache.Remove(pid)
+	tlmProcessCacheSize.Set(float64(p.entryCache.Len()))
 }
 
-func (p *ProcessResolver) resolve(pid uint32) *ProcessCacheEntry {
+// lookupEntry reads the entry of the given pid in the proc_cache map, or nil if it isn't found. The entries are
+// read from the recording when the events are replayed, and recorded when the recorder is enabled.
+func (p *ProcessResolver) lookupEntry(pid uint32) []byte {
+	if p.replayed != nil {
+		return p.replayed[pid]
+	}
+
 	pidb := make([]byte, 4)
 	ebpf.ByteOrder.PutUint32(pidb, pid)
 
 	cookieb, err := p.pidCookieMap.LookupBytes(pidb)
-	if err != nil {
+	if err != nil || cookieb == nil {
 		return nil
 	}
 
 	entryb, err := p.procCacheMap.LookupBytes(cookieb)
-	if err != nil {
+	if err != nil || entryb == nil {
+		return nil
+	}
+
+	if recorder := p.probe.recorder; recorder != nil {
+		recorder.Record(RecordProcessEntry, 0, marshalProcessRecord(pid, entryb))
+	}
+	return entryb
+}
+
+func (p *ProcessResolver) resolve(pid uint32) *ProcessCacheEntry {
+	atomic.AddInt64(&p.kernelMapLookups, 1)
+
+	entryb := p.lookupEntry(pid)
+	if entryb == nil {
+		p.countKernelMapMiss()
 		return nil
 	}
 
 	var entry ProcessCacheEntry
 	if _, err := entry.UnmarshalBinary(entryb); err != nil {
+		atomic.AddInt64(&p.unmarshalErrors, 1)
+		tlmProcessUnmarshalErrors.Inc()
+		p.countKernelMapMiss()
 		return nil
 	}
+	tlmProcessKernelMapLookups.Inc("hit")
 
 	p.addEntry(pid, &entry)
 
 	return &entry
 }
 
+func (p *ProcessResolver) countKernelMapMiss() {
+	atomic.AddInt64(&p.kernelMapMisses, 1)
+	tlmProcessKernelMapLookups.Inc("miss")
+}
+
+// GetStats returns the health of the resolver
+func (p *ProcessResolver) GetStats() ProcessResolverStats {
+	return ProcessResolverStats{
+		CacheSize:        p.entryCache.Len(),
+		KernelMapLookups: atomic.LoadInt64(&p.kernelMapLookups),
+		KernelMapMisses:  atomic.LoadInt64(&p.kernelMapMisses),
+		ProcfsFallbacks:  atomic.LoadInt64(&p.procfsFallbacks),
+		ProcfsErrors:     atomic.LoadInt64(&p.procfsErrors),
+		UnmarshalErrors:  atomic.LoadInt64(&p.unmarshalErrors),
+		SnapshotDuration: time.Duration(atomic.LoadInt64(&p.snapshotDuration)).Seconds(),
+		SnapshotState:    snapshotStateNames[atomic.LoadInt32(&p.snapshotState)],
+		SnapshotScanned:  atomic.LoadInt64(&p.snapshotScanned),
+		SnapshotTotal:    atomic.LoadInt64(&p.snapshotTotal),
+	}
+}
+
 // Resolve returns the cache entry for the given pid
 func (p *ProcessResolver) Resolve(pid uint32) *ProcessCacheEntry {
 	entry, exists := p.entryCache.Get(pid)
@@ -129,6 +262,17 @@ func (p *ProcessResolver) Get(pid uint32) *ProcessCacheEntry {
 	return nil
 }
 
+// entries returns the entries of the cache
+func (p *ProcessResolver) entries() []*ProcessCacheEntry {
+	var entries []*ProcessCacheEntry
+	for _, pid := range p.entryCache.Keys() {
+		if entry, exists := p.entryCache.Peek(pid); exists {
+			entries = append(entries, entry.(*ProcessCacheEntry))
+		}
+	}
+	return entries
+}
+
 // Start starts the resolver
 func (p *ProcessResolver) Start() error {
 	// initializes the list of snapshot probes
@@ -156,17 +300,33 @@ func (p *ProcessResolver) Start() error {
 	return nil
 }
 
-func (p *ProcessResolver) snapshot() error {
-	processes, err := process.AllProcesses()
+// snapshot reads the processes of /proc missing from the cache, at the rate allowed by the limiter
+func (p *ProcessResolver) snapshot(ctx context.Context, limiter *rate.Limiter) error {
+	pids, err := process.Pids()
 	if err != nil {
 		return err
 	}
 
+	atomic.StoreInt64(&p.snapshotTotal, int64(len(pids)))
+	atomic.StoreInt64(&p.snapshotScanned, 0)
+
 	cacheModified := false
 
-	for _, proc := range processes {
-		// If Exe is not set, the process is a short lived process and its /proc entry has already expired, move on.
-		if len(proc.Exe) == 0 {
+	for _, pid := range pids {
+		atomic.AddInt64(&p.snapshotScanned, 1)
+
+		// the processes already in the cache, snapshotted by a previous pass or added by an event, are skipped
+		if _, exists := p.entryCache.Get(uint32(pid)); exists {
+			continue
+		}
+
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		// If Exe is not set, the process is a short lived process or a kernel thread, move on.
+		proc, err := newFilledProcess(pid)
+		if err != nil || len(proc.Exe) == 0 {
 			continue
 		}
 
@@ -176,8 +336,8 @@ func (p *ProcessResolver) snapshot() error {
 		}
 	}
 
-	// There is a possible race condition where a process could have started right after we did the call to
-	// process.AllProcesses and before we inserted the cache entry of its parent. Call Snapshot again until we
+	// There is a possible race condition where a process could have started right after we listed the pids
+	// and before we inserted the cache entry of its parent. Call Snapshot again until we
 	// do not modify the process cache anymore
 	if cacheModified {
 		return errors.New("cache modified")
@@ -207,6 +367,42 @@ func (p *ProcessResolver) retrieveInodeInfo(inode uint64) (*InodeInfo, error) {
 	return &info, nil
 }
 
+// newFilledProcess reads from /proc the attributes of a process used by the snapshot
+func newFilledProcess(pid int32) (*process.FilledProcess, error) {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	exe, err := proc.Exe()
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := proc.Name()
+	if err != nil {
+		return nil, err
+	}
+
+	cmdline, err := proc.CmdlineSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	createTime, err := proc.CreateTime()
+	if err != nil {
+		return nil, err
+	}
+
+	return &process.FilledProcess{
+		Pid:        pid,
+		Exe:        exe,
+		Name:       name,
+		Cmdline:    cmdline,
+		CreateTime: createTime,
+	}, nil
+}
+
 // snapshotProcess snapshots /proc for the provided pid. This method returns true if it updated the kernel process cache.
 func (p *ProcessResolver) snapshotProcess(proc *process.FilledProcess) bool {
 	pid := uint32(proc.Pid)
@@ -215,22 +411,45 @@ func (p *ProcessResolver) snapshotProcess(proc *process.FilledProcess) bool {
 		return false
 	}
 
+	atomic.AddInt64(&p.procfsFallbacks, 1)
+
+	entry, err := p.newEntryFromProcfs(pid, proc)
+	if err != nil {
+		atomic.AddInt64(&p.procfsErrors, 1)
+		tlmProcessProcfsFallbacks.Inc("error")
+		log.Debug(errors.Wrapf(err, "snapshot failed for %d", pid))
+		return false
+	}
+	tlmProcessProcfsFallbacks.Inc("success")
+
+	// the snapshot runs alongside the events when it is asynchronous, an exec may have added a fresher entry
+	if _, exists := p.entryCache.Get(pid); exists {
+		return false
+	}
+
+	log.Tracef("Add process cache entry: %s %s %d/%d", proc.Name, entry.PathnameStr, pid, entry.Inode)
+
+	p.addEntry(pid, entry)
+
+	return true
+}
+
+// newEntryFromProcfs builds the cache entry of a process from /proc
+func (p *ProcessResolver) newEntryFromProcfs(pid uint32, proc *process.FilledProcess) (*ProcessCacheEntry, error) {
 	// create time
 	timestamp := time.Unix(0, proc.CreateTime*int64(time.Millisecond))
 
 	// Populate the mount point cache for the process
 	if err := p.resolvers.MountResolver.SyncCache(pid); err != nil {
 		if !os.IsNotExist(err) {
-			log.Debug(errors.Wrapf(err, "snapshot failed for %d: couldn't sync mount points", pid))
-			return false
+			return nil, errors.Wrap(err, "couldn't sync mount points")
 		}
 	}
 
 	// Retrieve the container ID of the process
 	containerID, err := p.resolvers.ContainerResolver.GetContainerID(pid)
 	if err != nil {
-		log.Debug(errors.Wrapf(err, "snapshot failed for %d: couldn't parse container ID", pid))
-		return false
+		return nil, errors.Wrap(err, "couldn't parse container ID")
 	}
 
 	procExecPath := utils.ProcExePath(pid)
@@ -238,30 +457,26 @@ func (p *ProcessResolver) snapshotProcess(proc *process.FilledProcess) bool {
 	// Get process filename and pre-fill the cache
 	pathnameStr, err := os.Readlink(procExecPath)
 	if err != nil {
-		log.Debug(errors.Wrapf(err, "snapshot failed for %d: couldn't readlink binary", pid))
-		return false
+		return nil, errors.Wrap(err, "couldn't readlink binary")
 	}
 
 	// Get the inode of the process binary
 	fi, err := os.Stat(procExecPath)
 	if err != nil {
-		log.Debug(errors.Wrapf(err, "snapshot failed for %d: couldn't stat binary", pid))
-		return false
+		return nil, errors.Wrap(err, "couldn't stat binary")
 	}
 	stat, ok := fi.Sys().(*syscall.Stat_t)
 	if !ok {
-		log.Debug(errors.Wrapf(err, "snapshot failed for %d: couldn't stat binary", pid))
-		return false
+		return nil, errors.New("couldn't stat binary")
 	}
 	inode := stat.Ino
 
 	info, err := p.retrieveInodeInfo(inode)
 	if err != nil {
-		log.Debug(errors.Wrapf(err, "snapshot failed for %d: couldn't retrieve inode info", pid))
-		return false
+		return nil, errors.Wrap(err, "couldn't retrieve inode info")
 	}
 
-	// preset and add the entry to the cache
+	// preset the entry of the cache
 	entry := &ProcessCacheEntry{
 		FileEvent: FileEvent{
 			Inode:           inode,
@@ -277,11 +492,7 @@ func (p *ProcessResolver) snapshotProcess(proc *process.FilledProcess) bool {
 		TTYName:   utils.PidTTY(pid),
 	}
 
-	log.Tracef("Add process cache entry: %s %s %d/%d", proc.Name, pathnameStr, pid, inode)
-
-	p.addEntry(pid, entry)
-
-	return true
+	return entry, nil
 }
 
 // startSnapshotProbes starts the probes required for the snapshot to complete
@@ -314,7 +525,20 @@ func (p *ProcessResolver) stopSnapshotProbes() {
 	return
 }
 
-func (p *ProcessResolver) Snapshot(containerResolver *ContainerResolver, mountResolver *MountResolver) error {
+// Snapshot populates the cache with the processes of /proc, it returns early when the context is cancelled
+func (p *ProcessResolver) Snapshot(ctx context.Context, containerResolver *ContainerResolver, mountResolver *MountResolver) error {
+	atomic.StoreInt32(&p.snapshotState, snapshotRunning)
+
+	if err := p.runSnapshot(ctx); err != nil {
+		atomic.StoreInt32(&p.snapshotState, snapshotFailed)
+		return err
+	}
+
+	atomic.StoreInt32(&p.snapshotState, snapshotDone)
+	return nil
+}
+
+func (p *ProcessResolver) runSnapshot(ctx context.Context) error {
 	// start the snapshot probes
 	if err := p.startSnapshotProbes(); err != nil {
 		return err
@@ -329,9 +553,25 @@ func (p *ProcessResolver) Snapshot(containerResolver *ContainerResolver, mountRe
 	// Deregister probes
 	defer p.stopSnapshotProbes()
 
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start)
+		atomic.StoreInt64(&p.snapshotDuration, int64(duration))
+		tlmProcessSnapshotDuration.Set(duration.Seconds())
+	}()
+
+	// only the asynchronous snapshot is rate limited, the probe doesn't start before the synchronous one completes
+	limit := rate.Inf
+	if p.probe.config.SnapshotAsync && p.probe.config.SnapshotScanRate > 0 {
+		limit = rate.Limit(p.probe.config.SnapshotScanRate)
+	}
+	limiter := rate.NewLimiter(limit, 1)
+
 	for retry := 0; retry < 5; retry++ {
-		if err := p.snapshot(); err == nil {
+		if err := p.snapshot(ctx, limiter); err == nil {
 			return nil
+		} else if ctx.Err() != nil {
+			return ctx.Err()
 		}
 	}
 
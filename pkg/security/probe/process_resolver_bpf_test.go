@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestProcessResolverStats(t *testing.T) {
+	cache, err := lru.New(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Add(uint32(1), &ProcessCacheEntry{})
+	cache.Add(uint32(2), &ProcessCacheEntry{})
+
+	p := &ProcessResolver{entryCache: cache}
+	p.countKernelMapMiss()
+	p.kernelMapLookups = 3
+	p.procfsFallbacks = 5
+	p.procfsErrors = 1
+	p.snapshotDuration = int64(1500 * time.Millisecond)
+	assert.Equal(t, "pending", p.GetStats().SnapshotState)
+
+	p.snapshotState = snapshotRunning
+	p.snapshotScanned = 120
+	p.snapshotTotal = 30000
+
+	assert.Equal(t, ProcessResolverStats{
+		CacheSize:        2,
+		KernelMapLookups: 3,
+		KernelMapMisses:  1,
+		ProcfsFallbacks:  5,
+		ProcfsErrors:     1,
+		SnapshotDuration: 1.5,
+		SnapshotState:    "running",
+		SnapshotScanned:  120,
+		SnapshotTotal:    30000,
+	}, p.GetStats())
+}
+
+func TestProcessResolverAncestors(t *testing.T) {
+	cache, err := lru.New(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a lineage deeper than maxAncestorsDepth
+	for pid := uint32(1000); pid <= 1040; pid++ {
+		ppid := pid - 1
+		if pid == 1000 {
+			ppid = 0
+		}
+		cache.Add(pid, &ProcessCacheEntry{Pid: pid, PPid: ppid})
+	}
+
+	// a reused pid pointing back to one of its descendants
+	cache.Add(uint32(2000), &ProcessCacheEntry{Pid: 2000, PPid: 2001})
+	cache.Add(uint32(2001), &ProcessCacheEntry{Pid: 2001, PPid: 2002})
+	cache.Add(uint32(2002), &ProcessCacheEntry{Pid: 2002, PPid: 2001})
+
+	p := &ProcessResolver{entryCache: cache}
+
+	ancestors := p.Ancestors(1040)
+	assert.Len(t, ancestors, maxAncestorsDepth)
+	assert.Equal(t, uint32(1039), ancestors[0].Pid)
+	assert.Equal(t, uint32(1040-maxAncestorsDepth), ancestors[maxAncestorsDepth-1].Pid)
+
+	assert.Len(t, p.Ancestors(1003), 3)
+
+	ancestors = p.Ancestors(2000)
+	assert.Len(t, ancestors, 2)
+	assert.Equal(t, uint32(2001), ancestors[0].Pid)
+	assert.Equal(t, uint32(2002), ancestors[1].Pid)
+}
+
+func TestProcessResolverExecHistory(t *testing.T) {
+	cache, err := lru.New(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &ProcessResolver{entryCache: cache}
+
+	newEntry := func(filename string, ppid uint32) ProcessCacheEntry {
+		return ProcessCacheEntry{
+			FileEvent: FileEvent{PathnameStr: filename, ContainerPath: "/"},
+			Timestamp: time.Now(),
+			PPid:      ppid,
+		}
+	}
+
+	// a forked process starts with the binary of its parent
+	p.AddEntry(2, newEntry("/bin/bash", 1))
+	assert.Equal(t, []string{"/bin/bash"}, execHistoryFilenames(p.ExecHistory(2)))
+
+	// the execs of the pid are chained
+	p.AddEntry(2, newEntry("/usr/bin/curl", 0))
+	p.AddEntry(2, newEntry("/bin/sh", 0))
+	assert.Equal(t, []string{"/bin/bash", "/usr/bin/curl", "/bin/sh"}, execHistoryFilenames(p.ExecHistory(2)))
+	assert.Equal(t, uint32(1), p.Get(2).PPid)
+
+	// a new process reusing the pid starts a new chain
+	p.DelEntry(2)
+	p.AddEntry(2, newEntry("/bin/ls", 1))
+	assert.Equal(t, []string{"/bin/ls"}, execHistoryFilenames(p.ExecHistory(2)))
+
+	// only the most recent execs are kept
+	for i := 0; i < maxExecHistory; i++ {
+		p.AddEntry(2, newEntry("/bin/true", 0))
+	}
+	history := p.ExecHistory(2)
+	assert.Len(t, history, maxExecHistory)
+	assert.Equal(t, "/bin/true", history[0].Filename)
+}
+
+func execHistoryFilenames(history []ExecRecord) []string {
+	var filenames []string
+	for _, record := range history {
+		filenames = append(filenames, record.Filename)
+	}
+	return filenames
+}
+
+func TestProcessResolverNsPidConcurrent(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "proc")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+
+	// a process of pid 1 in its container, with a thread of tid 5
+	writeStatus := func(path string, pid int, nsPids string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		status := fmt.Sprintf("Name:\tbash\nTgid:\t4300\nPid:\t%d\nNSpid:\t%s\n", pid, nsPids)
+		require.NoError(t, ioutil.WriteFile(path, []byte(status), 0644))
+	}
+	writeStatus(filepath.Join(procRoot, "4300", "status"), 4300, "4300\t1")
+	writeStatus(filepath.Join(procRoot, "4300", "task", "4310", "status"), 4310, "4310\t5")
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "4300", "ns"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(procRoot, "4300", "ns", "pid"), nil, 0644))
+
+	pidNamespaceResolver, err := util.NewPidNamespaceResolver(procRoot, 10)
+	require.NoError(t, err)
+	p := &ProcessResolver{resolvers: &Resolvers{PidNamespaceResolver: pidNamespaceResolver}}
+
+	// the translations are resolved while the exit of the process is processed, run with -race
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.Equal(t, uint32(1), p.HostToNsPid(4300))
+				assert.Equal(t, uint32(5), p.HostToNsTid(4300, 4310))
+				pidNamespaceResolver.Forget(4300)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint32(0), p.HostToNsPid(4301))
+	assert.Equal(t, uint32(0), p.HostToNsTid(4300, 4311))
+}
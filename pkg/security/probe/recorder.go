@@ -0,0 +1,331 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// RecordType identifies the content of a record
+type RecordType uint8
+
+const (
+	// RecordEvent is a raw event of the events perf map
+	RecordEvent RecordType = iota + 1
+	// RecordMountEvent is a raw event of the mountpoints_events perf map
+	RecordMountEvent
+	// RecordDentry is an entry of the pathnames kernel map read by the dentry resolver
+	RecordDentry
+	// RecordProcessEntry is an entry of the proc_cache kernel map read by the process resolver, prefixed by its pid
+	RecordProcessEntry
+	// RecordProcessSnapshot is a JSON entry of the process cache when the recording started
+	RecordProcessSnapshot
+	// RecordMountSnapshot is a JSON mount point of the mount cache when the recording started
+	RecordMountSnapshot
+)
+
+func (t RecordType) String() string {
+	switch t {
+	case RecordEvent:
+		return "event"
+	case RecordMountEvent:
+		return "mount_event"
+	case RecordDentry:
+		return "dentry"
+	case RecordProcessEntry:
+		return "process_entry"
+	case RecordProcessSnapshot:
+		return "process_snapshot"
+	case RecordMountSnapshot:
+		return "mount_snapshot"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+const (
+	recordingMagic   = "DDCWSREC"
+	recordingVersion = 1
+
+	// recordHeaderSize is the size of the type, the cpu and the length of the data of a record
+	recordHeaderSize = 9
+	// maxRecordSize is the maximum size of the data of a record, it protects the reader from corrupted files
+	maxRecordSize = 1 << 20
+)
+
+// recordingByteOrder is the byte order of the lengths of the recording, the data of the records is kept in the
+// byte order of the host that recorded them
+var recordingByteOrder = binary.LittleEndian
+
+// ErrInvalidRecording is returned when a file isn't a recording, or was recorded by an unsupported version
+var ErrInvalidRecording = errors.New("invalid recording")
+
+// RecordingHeader describes the host on which the events were recorded
+type RecordingHeader struct {
+	Version       int       `json:"version"`
+	KernelVersion string    `json:"kernel_version"`
+	BootTime      time.Time `json:"boot_time"`
+	StartTime     time.Time `json:"start_time"`
+}
+
+// Record is an entry of a recording
+type Record struct {
+	Type RecordType
+	CPU  int
+	Data []byte
+}
+
+// RecorderLimits bound a recording, the recording stops once one of them is reached. A zero limit is disabled.
+type RecorderLimits struct {
+	// MaxSize is the maximum size of the recording, in bytes
+	MaxSize int64
+	// MaxDuration is the maximum duration of the recording, counted from its creation
+	MaxDuration time.Duration
+}
+
+// EventRecorder writes the raw events of the kernel, and the entries the resolvers read from the kernel maps, to
+// a recording that can be replayed without a live kernel. The events are only recorded once the snapshot of the
+// resolvers was recorded, since they can't be resolved without it when they're replayed.
+type EventRecorder struct {
+	sync.Mutex
+	writer  *bufio.Writer
+	closer  io.Closer
+	buffer  [recordHeaderSize]byte
+	err     error
+	limits  RecorderLimits
+	size    int64
+	started bool
+	stopped bool
+	timer   *time.Timer
+}
+
+// NewEventRecorder creates, or truncates, the given file and writes the header of the recording
+func NewEventRecorder(filename string, header RecordingHeader, limits RecorderLimits) (*EventRecorder, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder, err := newEventRecorder(file, file, header, limits)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return recorder, nil
+}
+
+func newEventRecorder(w io.Writer, closer io.Closer, header RecordingHeader, limits RecorderLimits) (*EventRecorder, error) {
+	header.Version = recordingVersion
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := bufio.NewWriter(w)
+	if _, err := writer.WriteString(recordingMagic); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(writer, recordingByteOrder, uint32(len(data))); err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+
+	r := &EventRecorder{
+		writer: writer,
+		closer: closer,
+		limits: limits,
+		size:   int64(len(recordingMagic) + 4 + len(data)),
+	}
+	if limits.MaxDuration > 0 {
+		r.timer = time.AfterFunc(limits.MaxDuration, func() {
+			r.Lock()
+			defer r.Unlock()
+			r.stop(fmt.Sprintf("it lasted %s", limits.MaxDuration))
+		})
+	}
+	return r, nil
+}
+
+// isSnapshotRecord returns whether the record holds the state of the resolvers, recorded before the events
+func isSnapshotRecord(recordType RecordType) bool {
+	return recordType == RecordProcessSnapshot || recordType == RecordMountSnapshot
+}
+
+// StartEvents starts recording the events, it's called once the snapshot of the resolvers was recorded
+func (r *EventRecorder) StartEvents() {
+	r.Lock()
+	defer r.Unlock()
+	r.started = true
+}
+
+// Record appends a record to the recording. Once a write failed, the following records are dropped and the error
+// is returned by Close.
+func (r *EventRecorder) Record(recordType RecordType, cpu int, data []byte) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.err != nil || r.stopped || (!r.started && !isSnapshotRecord(recordType)) {
+		return
+	}
+
+	if len(data) > maxRecordSize {
+		r.err = errors.Errorf("%s record too large: %d bytes", recordType, len(data))
+		return
+	}
+
+	size := int64(recordHeaderSize + len(data))
+	if r.limits.MaxSize > 0 && r.size+size > r.limits.MaxSize {
+		r.stop(fmt.Sprintf("it reached %d bytes", r.limits.MaxSize))
+		return
+	}
+	r.size += size
+
+	r.buffer[0] = byte(recordType)
+	recordingByteOrder.PutUint32(r.buffer[1:5], uint32(cpu))
+	recordingByteOrder.PutUint32(r.buffer[5:9], uint32(len(data)))
+	if _, r.err = r.writer.Write(r.buffer[:]); r.err != nil {
+		return
+	}
+	_, r.err = r.writer.Write(data)
+}
+
+// stop ends the recording once a limit is reached, the recording is flushed and closed right away so that it can be
+// replayed while the probe keeps running
+func (r *EventRecorder) stop(reason string) {
+	if r.stopped || r.err != nil {
+		return
+	}
+	r.stopped = true
+
+	if err := r.close(); err != nil {
+		log.Errorf("failed to save the recording of the kernel events: %s", err)
+		return
+	}
+	log.Infof("The recording of the kernel events stopped since %s", reason)
+}
+
+// RecordJSON appends the JSON encoding of the given value to the recording
+func (r *EventRecorder) RecordJSON(recordType RecordType, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	r.Record(recordType, 0, data)
+	return nil
+}
+
+// Close flushes the recording and closes its file
+func (r *EventRecorder) Close() error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+
+	// the recording was already closed when it reached a limit
+	if r.stopped {
+		return nil
+	}
+	return r.close()
+}
+
+func (r *EventRecorder) close() error {
+	err := r.err
+	if err == nil {
+		err = r.writer.Flush()
+	}
+	if r.closer != nil {
+		if closeErr := r.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	r.err = errors.New("recorder closed")
+	return err
+}
+
+// RecordingReader reads the records of a recording
+type RecordingReader struct {
+	reader *bufio.Reader
+	buffer [recordHeaderSize]byte
+	Header RecordingHeader
+}
+
+// NewRecordingReader reads the header of a recording
+func NewRecordingReader(r io.Reader) (*RecordingReader, error) {
+	reader := bufio.NewReader(r)
+
+	magic := make([]byte, len(recordingMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != recordingMagic {
+		return nil, ErrInvalidRecording
+	}
+
+	var size uint32
+	if err := binary.Read(reader, recordingByteOrder, &size); err != nil || size > maxRecordSize {
+		return nil, ErrInvalidRecording
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, ErrInvalidRecording
+	}
+
+	rr := &RecordingReader{reader: reader}
+	if err := json.Unmarshal(data, &rr.Header); err != nil {
+		return nil, errors.Wrap(ErrInvalidRecording, err.Error())
+	}
+	if rr.Header.Version != recordingVersion {
+		return nil, errors.Wrapf(ErrInvalidRecording, "unsupported version %d", rr.Header.Version)
+	}
+
+	return rr, nil
+}
+
+// Next returns the next record of the recording, or io.EOF once all the records were read
+func (rr *RecordingReader) Next() (*Record, error) {
+	if _, err := io.ReadFull(rr.reader, rr.buffer[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.Wrap(ErrInvalidRecording, "truncated record")
+		}
+		return nil, err
+	}
+
+	size := recordingByteOrder.Uint32(rr.buffer[5:9])
+	if size > maxRecordSize {
+		return nil, errors.Wrapf(ErrInvalidRecording, "record too large: %d bytes", size)
+	}
+
+	record := &Record{
+		Type: RecordType(rr.buffer[0]),
+		CPU:  int(recordingByteOrder.Uint32(rr.buffer[1:5])),
+		Data: make([]byte, size),
+	}
+	if _, err := io.ReadFull(rr.reader, record.Data); err != nil {
+		return nil, errors.Wrap(ErrInvalidRecording, "truncated record")
+	}
+
+	return record, nil
+}
@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventRecorder(t *testing.T) {
+	var buffer bytes.Buffer
+
+	header := RecordingHeader{
+		KernelVersion: "5.4.0",
+		BootTime:      time.Unix(1600000000, 0).UTC(),
+		StartTime:     time.Unix(1600000100, 0).UTC(),
+	}
+	recorder, err := newEventRecorder(&buffer, nil, header, RecorderLimits{})
+	require.NoError(t, err)
+
+	// the events received before the snapshot was recorded are dropped
+	recorder.Record(RecordEvent, 0, []byte{7})
+	require.NoError(t, recorder.RecordJSON(RecordMountSnapshot, MountEvent{MountID: 27, MountPointStr: "/proc"}))
+	recorder.StartEvents()
+
+	recorder.Record(RecordEvent, 2, []byte{1, 2, 3})
+	recorder.Record(RecordDentry, 0, []byte{4, 5})
+	recorder.Record(RecordMountEvent, 1, nil)
+	require.NoError(t, recorder.Close())
+
+	// nothing is recorded once the recorder is closed
+	recorder.Record(RecordEvent, 0, []byte{6})
+
+	reader, err := NewRecordingReader(&buffer)
+	require.NoError(t, err)
+	header.Version = recordingVersion
+	assert.Equal(t, header, reader.Header)
+
+	var records []*Record
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+
+	require.Len(t, records, 4)
+	assert.Equal(t, RecordMountSnapshot, records[0].Type)
+	assert.JSONEq(t, `{"Retval":0,"MountID":27,"GroupID":0,"Device":0,"ParentMountID":0,"ParentInode":0,"FSType":"","MountPointStr":"/proc","RootMountID":0,"RootInode":0,"RootStr":"","FSTypeRaw":[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0]}`, string(records[0].Data))
+	assert.Equal(t, &Record{Type: RecordEvent, CPU: 2, Data: []byte{1, 2, 3}}, records[1])
+	assert.Equal(t, &Record{Type: RecordDentry, CPU: 0, Data: []byte{4, 5}}, records[2])
+	assert.Equal(t, &Record{Type: RecordMountEvent, CPU: 1, Data: []byte{}}, records[3])
+}
+
+func readRecords(t *testing.T, data []byte) []*Record {
+	reader, err := NewRecordingReader(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	var records []*Record
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestEventRecorderMaxSize(t *testing.T) {
+	var buffer bytes.Buffer
+	recorder, err := newEventRecorder(&buffer, nil, RecordingHeader{}, RecorderLimits{})
+	require.NoError(t, err)
+	headerSize := recorder.size
+	require.NoError(t, recorder.Close())
+
+	// room for the header and two records of 4 bytes
+	buffer.Reset()
+	recorder, err = newEventRecorder(&buffer, nil, RecordingHeader{}, RecorderLimits{MaxSize: headerSize + 2*(recordHeaderSize+4)})
+	require.NoError(t, err)
+	recorder.StartEvents()
+
+	for i := byte(0); i < 4; i++ {
+		recorder.Record(RecordEvent, 0, []byte{i, i, i, i})
+	}
+
+	// the recording was flushed when it stopped
+	records := readRecords(t, buffer.Bytes())
+	require.Len(t, records, 2)
+	assert.Equal(t, []byte{1, 1, 1, 1}, records[1].Data)
+	assert.NoError(t, recorder.Close())
+}
+
+func TestEventRecorderMaxDuration(t *testing.T) {
+	var buffer bytes.Buffer
+	recorder, err := newEventRecorder(&buffer, nil, RecordingHeader{}, RecorderLimits{MaxDuration: 10 * time.Millisecond})
+	require.NoError(t, err)
+	recorder.StartEvents()
+
+	recorder.Record(RecordEvent, 0, []byte{1})
+	time.Sleep(50 * time.Millisecond)
+	recorder.Record(RecordEvent, 0, []byte{2})
+
+	records := readRecords(t, buffer.Bytes())
+	require.Len(t, records, 1)
+	assert.Equal(t, []byte{1}, records[0].Data)
+	assert.NoError(t, recorder.Close())
+}
+
+func TestRecordingReaderInvalid(t *testing.T) {
+	_, err := NewRecordingReader(bytes.NewBufferString("not a recording"))
+	assert.Equal(t, ErrInvalidRecording, err)
+
+	var buffer bytes.Buffer
+	recorder, err := newEventRecorder(&buffer, nil, RecordingHeader{}, RecorderLimits{})
+	require.NoError(t, err)
+	recorder.StartEvents()
+	recorder.Record(RecordEvent, 0, []byte{1, 2, 3, 4})
+	require.NoError(t, recorder.Close())
+
+	// the last record is truncated
+	reader, err := NewRecordingReader(bytes.NewReader(buffer.Bytes()[:buffer.Len()-2]))
+	require.NoError(t, err)
+	_, err = reader.Next()
+	assert.Equal(t, ErrInvalidRecording, errors.Cause(err))
+}
@@ -0,0 +1,196 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package probe
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/DataDog/ebpf/manager"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+)
+
+// pathKeySize is the size of a PathKey in the pathnames map
+const pathKeySize = 16
+
+// replay holds a recording loaded in memory. The entries of the kernel maps are indexed first, since the paths and
+// the processes can be resolved after their events were received.
+type replay struct {
+	header         RecordingHeader
+	events         []*Record
+	dentries       map[PathKey]PathValue
+	processEntries map[uint32][]byte
+	processes      []*ProcessCacheEntry
+	mounts         []MountEvent
+}
+
+func marshalDentryRecord(key PathKey, path *PathValue) []byte {
+	data := make([]byte, 2*pathKeySize+len(path.Name))
+	key.Write(data[0:pathKeySize])
+	path.Parent.Write(data[pathKeySize : 2*pathKeySize])
+	copy(data[2*pathKeySize:], path.Name[:])
+	return data
+}
+
+func readPathKey(data []byte) PathKey {
+	return PathKey{
+		Inode:   ebpf.ByteOrder.Uint64(data[0:8]),
+		MountID: ebpf.ByteOrder.Uint32(data[8:12]),
+		PathID:  ebpf.ByteOrder.Uint32(data[12:16]),
+	}
+}
+
+func unmarshalDentryRecord(data []byte) (PathKey, PathValue, error) {
+	var path PathValue
+	if len(data) < 2*pathKeySize+len(path.Name) {
+		return PathKey{}, path, ErrNotEnoughData
+	}
+
+	path.Parent = readPathKey(data[pathKeySize : 2*pathKeySize])
+	copy(path.Name[:], data[2*pathKeySize:])
+	return readPathKey(data[0:pathKeySize]), path, nil
+}
+
+func marshalProcessRecord(pid uint32, entry []byte) []byte {
+	data := make([]byte, 4+len(entry))
+	ebpf.ByteOrder.PutUint32(data[0:4], pid)
+	copy(data[4:], entry)
+	return data
+}
+
+func unmarshalProcessRecord(data []byte) (uint32, []byte, error) {
+	if len(data) < 4 {
+		return 0, nil, ErrNotEnoughData
+	}
+	return ebpf.ByteOrder.Uint32(data[0:4]), data[4:], nil
+}
+
+// loadReplay reads a whole recording
+func loadReplay(reader io.Reader) (*replay, error) {
+	rr, err := NewRecordingReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &replay{
+		header:         rr.Header,
+		dentries:       make(map[PathKey]PathValue),
+		processEntries: make(map[uint32][]byte),
+	}
+
+	for {
+		record, err := rr.Next()
+		if err == io.EOF {
+			return r, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch record.Type {
+		case RecordEvent, RecordMountEvent:
+			r.events = append(r.events, record)
+		case RecordDentry:
+			key, path, err := unmarshalDentryRecord(record.Data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s record", record.Type)
+			}
+			r.dentries[key] = path
+		case RecordProcessEntry:
+			pid, entry, err := unmarshalProcessRecord(record.Data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s record", record.Type)
+			}
+			r.processEntries[pid] = entry
+		case RecordProcessSnapshot:
+			var entry ProcessCacheEntry
+			if err := json.Unmarshal(record.Data, &entry); err != nil {
+				return nil, errors.Wrapf(err, "invalid %s record", record.Type)
+			}
+			r.processes = append(r.processes, &entry)
+		case RecordMountSnapshot:
+			var mount MountEvent
+			if err := json.Unmarshal(record.Data, &mount); err != nil {
+				return nil, errors.Wrapf(err, "invalid %s record", record.Type)
+			}
+			r.mounts = append(r.mounts, mount)
+		default:
+			return nil, errors.Wrapf(ErrInvalidRecording, "unknown record type %s", record.Type)
+		}
+	}
+}
+
+// NewReplayProbe returns a probe whose events are read from a recording instead of the kernel. Its resolvers are
+// restored from the recording, and Replay dispatches the recorded events to its event handler.
+func NewReplayProbe(cfg *config.Config, reader io.Reader) (*Probe, error) {
+	r, err := loadReplay(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// the events are evaluated in the order they were recorded, and the replay isn't recorded again
+	replayConfig := *cfg
+	replayConfig.EventQueueEnabled = false
+	replayConfig.EventRecorderEnabled = false
+
+	p, err := NewProbe(&replayConfig, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.replay = r
+	p.kernelVersion = kernel.ParseVersion(r.header.KernelVersion)
+	p.resolvers.TimeResolver.bootTime = r.header.BootTime
+
+	p.resolvers.DentryResolver.replayed = r.dentries
+	if err := p.resolvers.DentryResolver.initCache(); err != nil {
+		return nil, err
+	}
+	p.resolvers.ProcessResolver.replayed = r.processEntries
+
+	for _, mount := range r.mounts {
+		p.resolvers.MountResolver.Insert(mount)
+	}
+	for _, entry := range r.processes {
+		p.resolvers.ProcessResolver.AddEntry(entry.Pid, *entry)
+	}
+
+	return p, nil
+}
+
+// RecordingHeader returns the header of the replayed recording
+func (p *Probe) RecordingHeader() (RecordingHeader, error) {
+	if p.replay == nil {
+		return RecordingHeader{}, errors.New("the probe doesn't replay a recording")
+	}
+	return p.replay.header, nil
+}
+
+// Replay dispatches the recorded events to the event handler of the probe, in the order they were received
+func (p *Probe) Replay() error {
+	if p.replay == nil {
+		return errors.New("the probe doesn't replay a recording")
+	}
+
+	events := &manager.PerfMap{Map: manager.Map{Name: "events"}}
+	mountEvents := &manager.PerfMap{Map: manager.Map{Name: "mountpoints_events"}}
+
+	for _, record := range p.replay.events {
+		switch record.Type {
+		case RecordEvent:
+			p.handleEvent(record.CPU, record.Data, events, nil)
+		case RecordMountEvent:
+			p.handleMountEvent(record.CPU, record.Data, mountEvents, nil)
+		}
+	}
+
+	return nil
+}
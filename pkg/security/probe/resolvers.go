@@ -7,6 +7,13 @@
 
 package probe
 
+import (
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// nsPidCacheSize is the number of pids translated by the pid namespace resolver kept in cache
+const nsPidCacheSize = 4096
+
 // NewResolvers creates a new instance of Resolvers
 func NewResolvers(probe *Probe) (*Resolvers, error) {
 	dentryResolver, err := NewDentryResolver(probe)
@@ -19,12 +26,25 @@ func NewResolvers(probe *Probe) (*Resolvers, error) {
 		return nil, err
 	}
 
+	userGroupResolver, err := NewUserGroupResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	pidNamespaceResolver, err := util.NewPidNamespaceResolver(util.HostProc(), nsPidCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
 	resolvers := &Resolvers{
 		probe:             probe,
 		DentryResolver:    dentryResolver,
 		MountResolver:     NewMountResolver(probe),
 		TimeResolver:      timeResolver,
 		ContainerResolver: &ContainerResolver{},
+		UserGroupResolver: userGroupResolver,
+
+		PidNamespaceResolver: pidNamespaceResolver,
 	}
 
 	processResolver, err := NewProcessResolver(probe, resolvers)
@@ -7,6 +7,12 @@
 
 package probe
 
+import (
+	"context"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
 // Resolvers holds the list of the event attribute resolvers
 type Resolvers struct {
 	probe             *Probe
@@ -15,6 +21,9 @@ type Resolvers struct {
 	ContainerResolver *ContainerResolver
 	TimeResolver      *TimeResolver
 	ProcessResolver   *ProcessResolver
+	UserGroupResolver *UserGroupResolver
+
+	PidNamespaceResolver *util.PidNamespaceResolver
 }
 
 // Start the resolvers
@@ -31,6 +40,15 @@ func (r *Resolvers) Start() error {
 }
 
 // Snapshot collects data on the current state of the system to populate user space and kernel space caches.
-func (r *Resolvers) Snapshot() error {
-	return r.ProcessResolver.Snapshot(r.ContainerResolver, r.MountResolver)
+func (r *Resolvers) Snapshot(ctx context.Context) error {
+	if err := r.ProcessResolver.Snapshot(ctx, r.ContainerResolver, r.MountResolver); err != nil {
+		return err
+	}
+
+	// the hot directories are resolved in the mount namespace of the host
+	if dirs := r.probe.config.DentryPrefetchDirectories; len(dirs) > 0 {
+		r.DentryResolver.Prefetch(1, dirs, r.probe.config.DentryPrefetchMaxEntries)
+	}
+
+	return nil
 }
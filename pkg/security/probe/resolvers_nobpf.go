@@ -7,6 +7,10 @@
 
 package probe
 
+import (
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
 // Resolvers holds the list of the event attribute resolvers
 type Resolvers struct {
 	probe             *Probe
@@ -15,4 +19,7 @@ type Resolvers struct {
 	ContainerResolver *ContainerResolver
 	TimeResolver      *TimeResolver
 	ProcessResolver   *ProcessResolver
+	UserGroupResolver *UserGroupResolver
+
+	PidNamespaceResolver *util.PidNamespaceResolver
 }
@@ -0,0 +1,182 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+)
+
+const (
+	passwdPath = "/etc/passwd"
+	groupPath  = "/etc/group"
+
+	// userGroupCacheSize is the maximum number of filesystem roots (host + containers) cached
+	userGroupCacheSize = 256
+	// userGroupRefreshDelay is the minimum delay between two checks of the passwd and group files of a root
+	userGroupRefreshDelay = 30 * time.Second
+	// hostRootPid is the pid used to access the root filesystem of the host
+	hostRootPid = 1
+)
+
+// idNameFile holds the id to name mapping read from a passwd or group file
+type idNameFile struct {
+	names   map[uint32]string
+	modTime time.Time
+}
+
+// userGroupEntry holds the user and group names of a filesystem root, the host or a container
+type userGroupEntry struct {
+	users       idNameFile
+	groups      idNameFile
+	lastChecked time.Time
+}
+
+// UserGroupResolver resolves user and group ids to names, using the passwd and group files
+// of the host or of the container of the process
+type UserGroupResolver struct {
+	sync.Mutex
+	entries *simplelru.LRU
+}
+
+// NewUserGroupResolver returns a new user group resolver
+func NewUserGroupResolver() (*UserGroupResolver, error) {
+	lru, err := simplelru.NewLRU(userGroupCacheSize, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserGroupResolver{entries: lru}, nil
+}
+
+// ResolveUser returns the name of the given uid, in the context of the given container
+// (the host if the container ID is empty). The pid is used to access the container filesystem.
+func (r *UserGroupResolver) ResolveUser(uid uint32, containerID string, pid uint32) string {
+	entry := r.getEntry(containerID, pid)
+	if entry == nil {
+		return ""
+	}
+	return entry.users.names[uid]
+}
+
+// ResolveGroup returns the name of the given gid, in the context of the given container
+// (the host if the container ID is empty). The pid is used to access the container filesystem.
+func (r *UserGroupResolver) ResolveGroup(gid uint32, containerID string, pid uint32) string {
+	entry := r.getEntry(containerID, pid)
+	if entry == nil {
+		return ""
+	}
+	return entry.groups.names[gid]
+}
+
+// Invalidate removes the cached names of the given container
+func (r *UserGroupResolver) Invalidate(containerID string) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.entries.Remove(containerID)
+}
+
+func (r *UserGroupResolver) getEntry(containerID string, pid uint32) *userGroupEntry {
+	if containerID == "" {
+		pid = hostRootPid
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	var entry *userGroupEntry
+	if value, ok := r.entries.Get(containerID); ok {
+		entry = value.(*userGroupEntry)
+		if time.Since(entry.lastChecked) < userGroupRefreshDelay {
+			return entry
+		}
+	} else {
+		entry = &userGroupEntry{}
+	}
+
+	// (re)load the files that changed since the last check. If the process is gone we keep
+	// the names we already know about.
+	usersErr := refreshIDNameFile(&entry.users, utils.ProcRootFilePath(pid, passwdPath))
+	groupsErr := refreshIDNameFile(&entry.groups, utils.ProcRootFilePath(pid, groupPath))
+	if usersErr != nil && groupsErr != nil && entry.lastChecked.IsZero() {
+		return nil
+	}
+
+	entry.lastChecked = time.Now()
+	r.entries.Add(containerID, entry)
+
+	return entry
+}
+
+func refreshIDNameFile(file *idNameFile, path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if file.names != nil && fi.ModTime().Equal(file.modTime) {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	names, err := parseIDNameFile(f)
+	if err != nil {
+		return err
+	}
+
+	file.names = names
+	file.modTime = fi.ModTime()
+
+	return nil
+}
+
+// parseIDNameFile parses a passwd or group file, both having the name as first field
+// and the id as third field: `name:password:id:...`
+func parseIDNameFile(reader io.Reader) (map[uint32]string, error) {
+	names := make(map[uint32]string)
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, ":", 4)
+		if len(fields) < 3 {
+			continue
+		}
+
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+
+		// the first entry wins, like getpwuid/getgrgid
+		if _, exists := names[uint32(id)]; !exists {
+			names[uint32(id)] = fields[0]
+		}
+	}
+
+	return names, scanner.Err()
+}
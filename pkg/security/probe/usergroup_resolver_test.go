@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPasswd = `root:x:0:0:root:/root:/bin/bash
+# comment
+daemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin
+
+invalid line
+www-data:x:33:33:www-data:/var/www:/usr/sbin/nologin
+toor:x:0:0:duplicate root:/root:/bin/sh
+nobody:x:65534:65534:nobody:/nonexistent:/usr/sbin/nologin
+`
+
+const testGroup = `root:x:0:
+daemon:x:1:
+www-data:x:33:alice,bob
+nogroup:x:65534:
+`
+
+func TestParseIDNameFile(t *testing.T) {
+	users, err := parseIDNameFile(strings.NewReader(testPasswd))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[uint32]string{
+		0:     "root",
+		1:     "daemon",
+		33:    "www-data",
+		65534: "nobody",
+	}, users)
+
+	groups, err := parseIDNameFile(strings.NewReader(testGroup))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[uint32]string{
+		0:     "root",
+		1:     "daemon",
+		33:    "www-data",
+		65534: "nogroup",
+	}, groups)
+}
+
+func TestRefreshIDNameFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "usergroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	passwd := path.Join(dir, "passwd")
+	if err := ioutil.WriteFile(passwd, []byte(testPasswd), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var file idNameFile
+	if err := refreshIDNameFile(&file, passwd); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "www-data", file.names[33])
+
+	// same modification time, the file isn't parsed again
+	if err := ioutil.WriteFile(passwd, []byte("nginx:x:33:33::/:/bin/false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(passwd, file.modTime, file.modTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := refreshIDNameFile(&file, passwd); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "www-data", file.names[33])
+
+	// new modification time, the file is parsed again
+	modTime := file.modTime.Add(time.Second)
+	if err := os.Chtimes(passwd, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := refreshIDNameFile(&file, passwd); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "nginx", file.names[33])
+
+	// the file is gone, the known names are kept
+	os.Remove(passwd)
+	assert.Error(t, refreshIDNameFile(&file, passwd))
+	assert.Equal(t, "nginx", file.names[33])
+}
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ActionType represents the type of an action taken when a rule matches
+type ActionType = string
+
+const (
+	// KillAction sends a SIGKILL to the process that triggered the rule
+	KillAction ActionType = "kill"
+	// PauseCgroupAction freezes the cgroup of the process that triggered the rule
+	PauseCgroupAction ActionType = "pause_cgroup"
+	// SetTagAction adds a tag to the subsequent events of the container, or of the process
+	// outside of containers, that triggered the rule
+	SetTagAction ActionType = "set_tag"
+)
+
+// ActionDefinition holds the definition of an action taken when a rule matches
+type ActionDefinition struct {
+	Type ActionType `yaml:"type"`
+	Tag  string     `yaml:"tag"`
+}
+
+// Check returns an error if the action definition is invalid
+func (ad *ActionDefinition) Check() error {
+	switch ad.Type {
+	case KillAction, PauseCgroupAction:
+		if ad.Tag != "" {
+			return fmt.Errorf("action `%s` doesn't take a tag", ad.Type)
+		}
+	case SetTagAction:
+		if ad.Tag == "" || strings.ContainsAny(ad.Tag, " ,") {
+			return fmt.Errorf("invalid tag `%s` for action `%s`", ad.Tag, ad.Type)
+		}
+	case "":
+		return fmt.Errorf("action has no type")
+	default:
+		return fmt.Errorf("unknown action type `%s`", ad.Type)
+	}
+	return nil
+}
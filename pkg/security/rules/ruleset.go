@@ -6,7 +6,11 @@
 package rules
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
@@ -29,9 +33,10 @@ type RuleID = string
 
 // RuleDefinition holds the definition of a rule
 type RuleDefinition struct {
-	ID         RuleID            `yaml:"id"`
-	Expression string            `yaml:"expression"`
-	Tags       map[string]string `yaml:"tags"`
+	ID         RuleID             `yaml:"id"`
+	Expression string             `yaml:"expression"`
+	Tags       map[string]string  `yaml:"tags"`
+	Actions    []ActionDefinition `yaml:"actions"`
 }
 
 // GetTags returns the tags associated to a rule
@@ -75,9 +80,11 @@ type RuleSet struct {
 	opts             *Opts
 	eventRuleBuckets map[eval.EventType]*RuleBucket
 	rules            map[eval.RuleID]*eval.Rule
+	actions          map[eval.RuleID][]ActionDefinition
 	model            eval.Model
 	eventCtor        func() eval.Event
 	listeners        []RuleSetListener
+	stats            map[eval.RuleID]*RuleStats
 	// fields holds the list of event field queries (like "process.uid") used by the entire set of rules
 	fields []string
 }
@@ -91,6 +98,19 @@ func (rs *RuleSet) ListRuleIDs() []string {
 	return ids
 }
 
+// Hash returns a hash of the rules of the ruleset. Identical sets of rules
+// have the same hash, whatever the order in which they were added.
+func (rs *RuleSet) Hash() string {
+	ids := rs.ListRuleIDs()
+	sort.Strings(ids)
+
+	h := sha256.New()
+	for _, id := range ids {
+		fmt.Fprintf(h, "%s\x00%s\x00", id, rs.rules[id].Expression)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // AddMacros parses the macros AST and adds them to the list of macros of the ruleset
 func (rs *RuleSet) AddMacros(macros []*MacroDefinition) error {
 	var result *multierror.Error
@@ -152,6 +172,12 @@ func (rs *RuleSet) AddRule(ruleDef *RuleDefinition) (*eval.Rule, error) {
 		return nil, fmt.Errorf("found multiple definition of the rule '%s'", ruleDef.ID)
 	}
 
+	for _, action := range ruleDef.Actions {
+		if err := action.Check(); err != nil {
+			return nil, err
+		}
+	}
+
 	var tags []string
 	for k, v := range ruleDef.Tags {
 		tags = append(tags, k+":"+v)
@@ -198,10 +224,19 @@ func (rs *RuleSet) AddRule(ruleDef *RuleDefinition) (*eval.Rule, error) {
 	rs.AddFields(rule.GetEvaluator().GetFields())
 
 	rs.rules[ruleDef.ID] = rule
+	rs.stats[ruleDef.ID] = &RuleStats{}
+	if len(ruleDef.Actions) > 0 {
+		rs.actions[ruleDef.ID] = ruleDef.Actions
+	}
 
 	return rule, nil
 }
 
+// GetActions returns the actions to take when the given rule matches
+func (rs *RuleSet) GetActions(ruleID eval.RuleID) []ActionDefinition {
+	return rs.actions[ruleID]
+}
+
 // NotifyRuleMatch notifies all the ruleset listeners that an event matched a rule
 func (rs *RuleSet) NotifyRuleMatch(rule *eval.Rule, event eval.Event) {
 	for _, listener := range rs.listeners {
@@ -301,7 +336,13 @@ func (rs *RuleSet) Evaluate(event eval.Event) bool {
 	log.Tracef("Evaluating event of type `%s` against set of %d rules", eventType, len(bucket.rules))
 
 	for _, rule := range bucket.rules {
-		if rule.GetEvaluator().Eval(ctx) {
+		start := time.Now()
+		matched := rule.GetEvaluator().Eval(ctx)
+		if stats, found := rs.stats[rule.ID]; found {
+			stats.record(matched, time.Since(start))
+		}
+
+		if matched {
 			log.Tracef("Rule `%s` matches with event `%s`\n", rule.ID, event)
 
 			rs.NotifyRuleMatch(rule, event)
@@ -381,5 +422,7 @@ func NewRuleSet(model eval.Model, eventCtor func() eval.Event, opts *Opts) *Rule
 		opts:             opts,
 		eventRuleBuckets: make(map[eval.EventType]*RuleBucket),
 		rules:            make(map[eval.RuleID]*eval.Rule),
+		actions:          make(map[eval.RuleID][]ActionDefinition),
+		stats:            make(map[eval.RuleID]*RuleStats),
 	}
 }
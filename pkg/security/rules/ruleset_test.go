@@ -97,6 +97,93 @@ func TestRuleBuckets(t *testing.T) {
 	}
 }
 
+func TestRuleSetHash(t *testing.T) {
+	newRuleSet := func() *RuleSet {
+		return NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+	}
+
+	rs1 := newRuleSet()
+	addRuleExpr(t, rs1, `open.filename == "/etc/passwd"`, `mkdir.filename == "/tmp/test" && process.uid == 0`)
+
+	rs2 := newRuleSet()
+	addRuleExpr(t, rs2, `open.filename == "/etc/passwd"`, `mkdir.filename == "/tmp/test" && process.uid == 0`)
+
+	if rs1.Hash() != rs2.Hash() {
+		t.Errorf("expected identical rule sets to have the same hash")
+	}
+
+	rs3 := newRuleSet()
+	addRuleExpr(t, rs3, `open.filename == "/etc/shadow"`, `mkdir.filename == "/tmp/test" && process.uid == 0`)
+
+	if rs1.Hash() == rs3.Hash() {
+		t.Errorf("expected different rule sets to have different hashes")
+	}
+}
+
+func TestRuleSetActions(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+
+	actions := []ActionDefinition{{Type: KillAction}, {Type: SetTagAction, Tag: "compromised:true"}}
+	if _, err := rs.AddRule(&RuleDefinition{ID: "kill", Expression: `open.filename == "/etc/shadow"`, Actions: actions}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.AddRule(&RuleDefinition{ID: "audit", Expression: `open.filename == "/etc/passwd"`}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(rs.GetActions("kill"), actions) {
+		t.Errorf("expected actions %v, got %v", actions, rs.GetActions("kill"))
+	}
+	if len(rs.GetActions("audit")) != 0 {
+		t.Errorf("expected no action, got %v", rs.GetActions("audit"))
+	}
+
+	for _, action := range []ActionDefinition{{}, {Type: "reboot"}, {Type: SetTagAction}, {Type: SetTagAction, Tag: "a b"}, {Type: KillAction, Tag: "a:b"}} {
+		ruleDef := &RuleDefinition{ID: "invalid", Expression: `open.filename == "/etc/group"`, Actions: []ActionDefinition{action}}
+		if _, err := rs.AddRule(ruleDef); err == nil {
+			t.Errorf("expected an error for the action %+v", action)
+		}
+	}
+}
+
+func TestRuleSetStats(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(testConstants, testSupportedDiscarders))
+	addRuleExpr(t, rs, `open.filename == "/etc/passwd"`, `open.filename == "/etc/shadow"`, `mkdir.filename == "/tmp/test"`)
+
+	for _, filename := range []string{"/etc/passwd", "/etc/passwd", "/etc/group"} {
+		rs.Evaluate(&testEvent{kind: "open", open: testOpen{filename: filename}})
+	}
+
+	stats := make(map[string]RuleStatsSnapshot)
+	for _, snapshot := range rs.GetRuleStats() {
+		stats[snapshot.RuleID] = snapshot
+	}
+	if len(stats) != 3 {
+		t.Fatalf("expected the stats of 3 rules, got %v", stats)
+	}
+
+	if stats["ID0"].Evaluations != 3 || stats["ID0"].Matches != 2 {
+		t.Errorf("expected 3 evaluations and 2 matches, got %+v", stats["ID0"])
+	}
+	if stats["ID1"].Evaluations != 3 || stats["ID1"].Matches != 0 {
+		t.Errorf("expected 3 evaluations and no match, got %+v", stats["ID1"])
+	}
+	if stats["ID2"].Evaluations != 0 || stats["ID2"].EvalTime != 0 {
+		t.Errorf("expected no evaluation, got %+v", stats["ID2"])
+	}
+
+	previous := stats["ID0"]
+	rs.Evaluate(&testEvent{kind: "open", open: testOpen{filename: "/etc/passwd"}})
+	for _, snapshot := range rs.GetRuleStats() {
+		if snapshot.RuleID != "ID0" {
+			continue
+		}
+		if delta := snapshot.Sub(previous); delta.Evaluations != 1 || delta.Matches != 1 {
+			t.Errorf("expected 1 evaluation and 1 match since the previous snapshot, got %+v", delta)
+		}
+	}
+}
+
 func TestRuleSetDiscarders(t *testing.T) {
 	model := &testModel{}
 
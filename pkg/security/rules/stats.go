@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// RuleStats holds the evaluation counters of a rule. The counters are updated atomically so that they can
+// be read while events are evaluated.
+type RuleStats struct {
+	evaluations int64
+	matches     int64
+	evalTime    int64
+}
+
+func (s *RuleStats) record(matched bool, duration time.Duration) {
+	atomic.AddInt64(&s.evaluations, 1)
+	atomic.AddInt64(&s.evalTime, int64(duration))
+	if matched {
+		atomic.AddInt64(&s.matches, 1)
+	}
+}
+
+// RuleStatsSnapshot holds the evaluation counters of a rule since the rule set was created
+type RuleStatsSnapshot struct {
+	RuleID      eval.RuleID   `json:"rule_id"`
+	Evaluations int64         `json:"evaluations"`
+	Matches     int64         `json:"matches"`
+	EvalTime    time.Duration `json:"eval_time"`
+}
+
+// AverageEvalTime returns the average duration of an evaluation of the rule
+func (s RuleStatsSnapshot) AverageEvalTime() time.Duration {
+	if s.Evaluations == 0 {
+		return 0
+	}
+	return s.EvalTime / time.Duration(s.Evaluations)
+}
+
+// Sub returns the counters of the rule since a previous snapshot
+func (s RuleStatsSnapshot) Sub(previous RuleStatsSnapshot) RuleStatsSnapshot {
+	return RuleStatsSnapshot{
+		RuleID:      s.RuleID,
+		Evaluations: s.Evaluations - previous.Evaluations,
+		Matches:     s.Matches - previous.Matches,
+		EvalTime:    s.EvalTime - previous.EvalTime,
+	}
+}
+
+func (s *RuleStats) snapshot(ruleID eval.RuleID) RuleStatsSnapshot {
+	return RuleStatsSnapshot{
+		RuleID:      ruleID,
+		Evaluations: atomic.LoadInt64(&s.evaluations),
+		Matches:     atomic.LoadInt64(&s.matches),
+		EvalTime:    time.Duration(atomic.LoadInt64(&s.evalTime)),
+	}
+}
+
+// GetRuleStats returns the evaluation counters of the rules, sorted by decreasing evaluation time so that
+// the rules slowing down the evaluation of the events come first
+func (rs *RuleSet) GetRuleStats() []RuleStatsSnapshot {
+	snapshots := make([]RuleStatsSnapshot, 0, len(rs.stats))
+	for ruleID, stats := range rs.stats {
+		snapshots = append(snapshots, stats.snapshot(ruleID))
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].EvalTime != snapshots[j].EvalTime {
+			return snapshots[i].EvalTime > snapshots[j].EvalTime
+		}
+		return snapshots[i].RuleID < snapshots[j].RuleID
+	})
+	return snapshots
+}
@@ -117,6 +117,34 @@ func (s *StringEvaluator) Eval(ctx *Context) interface{} {
 	return s.EvalFnc(ctx)
 }
 
+// StringArrayEvaluator returns an array of strings as result of the evaluation
+type StringArrayEvaluator struct {
+	EvalFnc func(ctx *Context) []string
+	Field   Field
+	Values  []string
+
+	isPartial bool
+}
+
+// Eval returns the result of the evaluation
+func (s *StringArrayEvaluator) Eval(ctx *Context) interface{} {
+	return s.EvalFnc(ctx)
+}
+
+// IntArrayEvaluator returns an array of integers as result of the evaluation
+type IntArrayEvaluator struct {
+	EvalFnc func(ctx *Context) []int
+	Field   Field
+	Values  []int
+
+	isPartial bool
+}
+
+// Eval returns the result of the evaluation
+func (i *IntArrayEvaluator) Eval(ctx *Context) interface{} {
+	return i.EvalFnc(ctx)
+}
+
 // StringArray represents an array of string values
 type StringArray struct {
 	Values []string
@@ -251,6 +279,28 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 					return nil, nil, pos, err
 				}
 				return intEvaluator, nil, obj.Pos, nil
+			case *StringArrayEvaluator:
+				nextStringArray, ok := next.(*StringArray)
+				if !ok {
+					return nil, nil, pos, NewTypeError(pos, reflect.Array)
+				}
+
+				boolEvaluator, err := StringValuesContains(unary, nextStringArray, *obj.ArrayComparison.Op == "notin", opts, state)
+				if err != nil {
+					return nil, nil, pos, err
+				}
+				return boolEvaluator, nil, obj.Pos, nil
+			case *IntArrayEvaluator:
+				nextIntArray, ok := next.(*IntArray)
+				if !ok {
+					return nil, nil, pos, NewTypeError(pos, reflect.Array)
+				}
+
+				boolEvaluator, err := IntValuesContains(unary, nextIntArray, *obj.ArrayComparison.Op == "notin", opts, state)
+				if err != nil {
+					return nil, nil, pos, err
+				}
+				return boolEvaluator, nil, obj.Pos, nil
 			default:
 				return nil, nil, pos, NewTypeError(pos, reflect.Array)
 			}
@@ -309,6 +359,42 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 					return eval, nil, obj.Pos, nil
 				}
 				return nil, nil, pos, NewOpUnknownError(obj.Pos, *obj.ScalarComparison.Op)
+			case *StringArrayEvaluator:
+				nextString, ok := next.(*StringEvaluator)
+				if !ok {
+					return nil, nil, pos, NewTypeError(pos, reflect.String)
+				}
+
+				switch *obj.ScalarComparison.Op {
+				case "==", "!=":
+					boolEvaluator, err := StringValuesEquals(unary, nextString, *obj.ScalarComparison.Op == "!=", opts, state)
+					if err != nil {
+						return nil, nil, pos, err
+					}
+					return boolEvaluator, nil, obj.Pos, nil
+				case "=~", "!~":
+					boolEvaluator, err := StringValuesMatches(unary, nextString, *obj.ScalarComparison.Op == "!~", opts, state)
+					if err != nil {
+						return nil, nil, pos, NewOpError(obj.Pos, *obj.ScalarComparison.Op, err)
+					}
+					return boolEvaluator, nil, obj.Pos, nil
+				}
+				return nil, nil, pos, NewOpUnknownError(obj.Pos, *obj.ScalarComparison.Op)
+			case *IntArrayEvaluator:
+				nextInt, ok := next.(*IntEvaluator)
+				if !ok {
+					return nil, nil, pos, NewTypeError(pos, reflect.Int)
+				}
+
+				switch *obj.ScalarComparison.Op {
+				case "==", "!=":
+					boolEvaluator, err := IntValuesEquals(unary, nextInt, *obj.ScalarComparison.Op == "!=", opts, state)
+					if err != nil {
+						return nil, nil, pos, err
+					}
+					return boolEvaluator, nil, obj.Pos, nil
+				}
+				return nil, nil, pos, NewOpUnknownError(obj.Pos, *obj.ScalarComparison.Op)
 			case *IntEvaluator:
 				nextInt, ok := next.(*IntEvaluator)
 				if !ok {
@@ -326,6 +326,93 @@ func TestInArray(t *testing.T) {
 	}
 }
 
+func TestStringValues(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			name:      "cat",
+			ancestors: []string{"bash", "sshd", "systemd"},
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `process.ancestors.name in [ "sshd", "telnetd" ]`, Expected: true},
+		{Expr: `process.ancestors.name in [ "apache2", "nginx" ]`, Expected: false},
+		{Expr: `process.ancestors.name not in [ "sshd", "telnetd" ]`, Expected: false},
+		{Expr: `process.ancestors.name not in [ "apache2", "nginx" ]`, Expected: true},
+		{Expr: `process.ancestors.name == "bash"`, Expected: true},
+		{Expr: `process.ancestors.name == "cat"`, Expected: false},
+		{Expr: `process.ancestors.name != "bash"`, Expected: false},
+		{Expr: `process.ancestors.name != "cat"`, Expected: true},
+		{Expr: `process.ancestors.name =~ "ss*"`, Expected: true},
+		{Expr: `process.ancestors.name !~ "ss*"`, Expected: false},
+		{Expr: `process.ancestors.name =~ "ng*"`, Expected: false},
+		{Expr: `process.ancestors.name == process.name`, Expected: false},
+		{Expr: `process.name == "cat" && process.ancestors.name in [ "sshd" ]`, Expected: true},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s: %s`", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+
+	// no ancestor
+	event.process.ancestors = nil
+	result, _, err := eval(t, event, `process.ancestors.name in [ "sshd" ]`)
+	if err != nil || result {
+		t.Errorf("expected result `false` not found, got `%t`: %v", result, err)
+	}
+}
+
+func TestIntValues(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			uid:   444,
+			ppids: []int{444, 22, 1},
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `process.ancestors.pid in [ 22, 23 ]`, Expected: true},
+		{Expr: `process.ancestors.pid in [ 2, 3 ]`, Expected: false},
+		{Expr: `process.ancestors.pid not in [ 22, 23 ]`, Expected: false},
+		{Expr: `process.ancestors.pid not in [ 2, 3 ]`, Expected: true},
+		{Expr: `process.ancestors.pid == 1`, Expected: true},
+		{Expr: `process.ancestors.pid == 2`, Expected: false},
+		{Expr: `process.ancestors.pid != 1`, Expected: false},
+		{Expr: `process.ancestors.pid != 2`, Expected: true},
+		{Expr: `process.ancestors.pid == process.uid`, Expected: true},
+		{Expr: `process.uid == 444 && process.ancestors.pid in [ 22 ]`, Expected: true},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s: %s`", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+
+	// the ordering operators aren't supported
+	if _, _, err := eval(t, event, `process.ancestors.pid > 1`); err == nil {
+		t.Error("expected an error")
+	}
+}
+
 func TestComplex(t *testing.T) {
 	event := &testEvent{
 		open: testOpen{
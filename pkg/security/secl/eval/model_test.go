@@ -14,10 +14,12 @@ import (
 )
 
 type testProcess struct {
-	name   string
-	uid    int
-	gid    int
-	isRoot bool
+	name      string
+	uid       int
+	gid       int
+	isRoot    bool
+	ancestors []string
+	ppids     []int
 }
 
 type testOpen struct {
@@ -105,6 +107,20 @@ func (m *testModel) GetEvaluator(key string) (Evaluator, error) {
 			Field:   key,
 		}, nil
 
+	case "process.ancestors.name":
+
+		return &StringArrayEvaluator{
+			EvalFnc: func(ctx *Context) []string { return (*testEvent)(ctx.Object).process.ancestors },
+			Field:   key,
+		}, nil
+
+	case "process.ancestors.pid":
+
+		return &IntArrayEvaluator{
+			EvalFnc: func(ctx *Context) []int { return (*testEvent)(ctx.Object).process.ppids },
+			Field:   key,
+		}, nil
+
 	case "open.filename":
 
 		return &StringEvaluator{
@@ -164,6 +180,14 @@ func (e *testEvent) GetFieldValue(key string) (interface{}, error) {
 
 		return e.process.isRoot, nil
 
+	case "process.ancestors.name":
+
+		return e.process.ancestors, nil
+
+	case "process.ancestors.pid":
+
+		return e.process.ppids, nil
+
 	case "open.filename":
 
 		return e.open.filename, nil
@@ -208,6 +232,14 @@ func (e *testEvent) GetFieldEventType(key string) (string, error) {
 
 		return "*", nil
 
+	case "process.ancestors.name":
+
+		return "*", nil
+
+	case "process.ancestors.pid":
+
+		return "*", nil
+
 	case "open.filename":
 
 		return "open", nil
@@ -256,6 +288,16 @@ func (e *testEvent) SetFieldValue(key string, value interface{}) error {
 		e.process.isRoot = value.(bool)
 		return nil
 
+	case "process.ancestors.name":
+
+		e.process.ancestors = []string{value.(string)}
+		return nil
+
+	case "process.ancestors.pid":
+
+		e.process.ppids = []int{value.(int)}
+		return nil
+
 	case "open.filename":
 
 		e.open.filename = value.(string)
@@ -305,6 +347,14 @@ func (e *testEvent) GetFieldType(key string) (reflect.Kind, error) {
 
 		return reflect.Bool, nil
 
+	case "process.ancestors.name":
+
+		return reflect.String, nil
+
+	case "process.ancestors.pid":
+
+		return reflect.Int, nil
+
 	case "open.filename":
 
 		return reflect.String, nil
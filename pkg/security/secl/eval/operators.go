@@ -272,3 +272,204 @@ func IntArrayContains(a *IntEvaluator, b *IntArray, not bool, opts *Opts, state
 		isPartial: isPartialLeaf,
 	}, nil
 }
+
+// stringValuesAny returns an evaluator checking whether one of the values of an array evaluator matches,
+// or whether none of them matches if not is set
+func stringValuesAny(a *StringArrayEvaluator, isPartialLeaf bool, not bool, match func(ctx *Context, value string) bool) *BoolEvaluator {
+	anyMatch := func(ctx *Context, values []string) bool {
+		for _, value := range values {
+			if match(ctx, value) {
+				return !not
+			}
+		}
+		return not
+	}
+
+	if a.EvalFnc != nil {
+		ea := a.EvalFnc
+
+		evalFnc := func(ctx *Context) bool {
+			return anyMatch(ctx, ea(ctx))
+		}
+
+		return &BoolEvaluator{
+			EvalFnc:   evalFnc,
+			isPartial: isPartialLeaf,
+		}
+	}
+
+	ea := true
+	if !isPartialLeaf {
+		ea = anyMatch(nil, a.Values)
+	}
+
+	return &BoolEvaluator{
+		Value:     ea,
+		isPartial: isPartialLeaf,
+	}
+}
+
+// StringValuesContains - ["a", "b"] in ["...", "..."] operator, true if one of the values is in the array
+func StringValuesContains(a *StringArrayEvaluator, b *StringArray, not bool, opts *Opts, state *state) (*BoolEvaluator, error) {
+	isPartialLeaf := a.isPartial
+	if a.Field != "" && state.field != "" && a.Field != state.field {
+		isPartialLeaf = true
+	}
+
+	if a.Field != "" {
+		for _, value := range b.Values {
+			if err := state.UpdateFieldValues(a.Field, FieldValue{Value: value, Type: ScalarValueType}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return stringValuesAny(a, isPartialLeaf, not, func(ctx *Context, value string) bool {
+		i := sort.SearchStrings(b.Values, value)
+		return i < len(b.Values) && b.Values[i] == value
+	}), nil
+}
+
+// StringValuesEquals - ["a", "b"] == "..." operator, true if one of the values is equal to the string
+func StringValuesEquals(a *StringArrayEvaluator, b *StringEvaluator, not bool, opts *Opts, state *state) (*BoolEvaluator, error) {
+	isPartialLeaf := a.isPartial
+	if a.Field != "" && state.field != "" && a.Field != state.field {
+		isPartialLeaf = true
+	}
+
+	if b.EvalFnc != nil {
+		if b.Field != "" && a.Field != "" {
+			isPartialLeaf = true
+		}
+
+		eb := b.EvalFnc
+		return stringValuesAny(a, isPartialLeaf, not, func(ctx *Context, value string) bool {
+			return value == eb(ctx)
+		}), nil
+	}
+
+	if a.Field != "" {
+		if err := state.UpdateFieldValues(a.Field, FieldValue{Value: b.Value, Type: ScalarValueType}); err != nil {
+			return nil, err
+		}
+	}
+
+	eb := b.Value
+	return stringValuesAny(a, isPartialLeaf, not, func(ctx *Context, value string) bool {
+		return value == eb
+	}), nil
+}
+
+// StringValuesMatches - ["a", "b"] =~ "..." operator, true if one of the values matches the pattern
+func StringValuesMatches(a *StringArrayEvaluator, b *StringEvaluator, not bool, opts *Opts, state *state) (*BoolEvaluator, error) {
+	if b.EvalFnc != nil {
+		return nil, errors.New("regex has to be a scalar string")
+	}
+
+	re, err := patternToRegexp(b.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	isPartialLeaf := a.isPartial
+	if a.Field != "" && state.field != "" && a.Field != state.field {
+		isPartialLeaf = true
+	}
+
+	if a.Field != "" {
+		if err := state.UpdateFieldValues(a.Field, FieldValue{Value: b.Value, Type: PatternValueType}); err != nil {
+			return nil, err
+		}
+	}
+
+	return stringValuesAny(a, isPartialLeaf, not, func(ctx *Context, value string) bool {
+		return re.MatchString(value)
+	}), nil
+}
+
+// intValuesAny returns an evaluator checking whether one of the values of an array evaluator matches,
+// or whether none of them matches if not is set
+func intValuesAny(a *IntArrayEvaluator, isPartialLeaf bool, not bool, match func(ctx *Context, value int) bool) *BoolEvaluator {
+	anyMatch := func(ctx *Context, values []int) bool {
+		for _, value := range values {
+			if match(ctx, value) {
+				return !not
+			}
+		}
+		return not
+	}
+
+	if a.EvalFnc != nil {
+		ea := a.EvalFnc
+
+		evalFnc := func(ctx *Context) bool {
+			return anyMatch(ctx, ea(ctx))
+		}
+
+		return &BoolEvaluator{
+			EvalFnc:   evalFnc,
+			isPartial: isPartialLeaf,
+		}
+	}
+
+	ea := true
+	if !isPartialLeaf {
+		ea = anyMatch(nil, a.Values)
+	}
+
+	return &BoolEvaluator{
+		Value:     ea,
+		isPartial: isPartialLeaf,
+	}
+}
+
+// IntValuesContains - [1, 2] in [...] operator, true if one of the values is in the array
+func IntValuesContains(a *IntArrayEvaluator, b *IntArray, not bool, opts *Opts, state *state) (*BoolEvaluator, error) {
+	isPartialLeaf := a.isPartial
+	if a.Field != "" && state.field != "" && a.Field != state.field {
+		isPartialLeaf = true
+	}
+
+	if a.Field != "" {
+		for _, value := range b.Values {
+			if err := state.UpdateFieldValues(a.Field, FieldValue{Value: value, Type: ScalarValueType}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return intValuesAny(a, isPartialLeaf, not, func(ctx *Context, value int) bool {
+		i := sort.SearchInts(b.Values, value)
+		return i < len(b.Values) && b.Values[i] == value
+	}), nil
+}
+
+// IntValuesEquals - [1, 2] == ... operator, true if one of the values is equal to the integer
+func IntValuesEquals(a *IntArrayEvaluator, b *IntEvaluator, not bool, opts *Opts, state *state) (*BoolEvaluator, error) {
+	isPartialLeaf := a.isPartial
+	if a.Field != "" && state.field != "" && a.Field != state.field {
+		isPartialLeaf = true
+	}
+
+	if b.EvalFnc != nil {
+		if b.Field != "" && a.Field != "" {
+			isPartialLeaf = true
+		}
+
+		eb := b.EvalFnc
+		return intValuesAny(a, isPartialLeaf, not, func(ctx *Context, value int) bool {
+			return value == eb(ctx)
+		}), nil
+	}
+
+	if a.Field != "" {
+		if err := state.UpdateFieldValues(a.Field, FieldValue{Value: b.Value, Type: ScalarValueType}); err != nil {
+			return nil, err
+		}
+	}
+
+	eb := b.Value
+	return intValuesAny(a, isPartialLeaf, not, func(ctx *Context, value int) bool {
+		return value == eb
+	}), nil
+}
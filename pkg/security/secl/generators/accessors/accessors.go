@@ -189,6 +189,19 @@ func handleSpec(astFile *ast.File, spec interface{}, prefix, aliasPrefix, event
 									Event:      event,
 									OrigType:   fieldType.Name,
 								}
+							} else if arrayType, ok := field.Type.(*ast.ArrayType); ok {
+								if itemIdent, ok := arrayType.Elt.(*ast.Ident); ok {
+									module.Fields[fieldAlias] = &structField{
+										Name:       fmt.Sprintf("%s.%s", prefix, fieldName),
+										BasicType:  origTypeToBasicType(itemIdent.Name),
+										Handler:    fmt.Sprintf("%s.%s", prefix, fnc),
+										ReturnType: kind,
+										IsArray:    true,
+										Public:     true,
+										Event:      event,
+										OrigType:   "[]" + itemIdent.Name,
+									}
+								}
 							}
 							continue
 						}
@@ -340,6 +353,12 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 	{{else if eq $Field.ReturnType "bool"}}
 		return &eval.BoolEvaluator{
 			EvalFnc: func(ctx *eval.Context) bool { return {{$Return}} },
+	{{else if eq $Field.ReturnType "[]string"}}
+		return &eval.StringArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []string { return {{$Return}} },
+	{{else if eq $Field.ReturnType "[]int"}}
+		return &eval.IntArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []int { return {{$Return}} },
 	{{end}}
 			Field: field,
 		}, nil
@@ -364,6 +383,10 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 			return int({{$Return}}), nil
 		{{else if eq $Field.ReturnType "bool"}}
 			return {{$Return}}, nil
+		{{else if eq $Field.ReturnType "[]string"}}
+			return {{$Return}}, nil
+		{{else if eq $Field.ReturnType "[]int"}}
+			return {{$Return}}, nil
 		{{end}}
 		{{end}}
 		}
@@ -387,9 +410,9 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 		{{range $Name, $Field := .Fields}}
 
 		case "{{$Name}}":
-		{{if eq $Field.ReturnType "string"}}
+		{{if or (eq $Field.ReturnType "string") (eq $Field.ReturnType "[]string")}}
 			return reflect.String, nil
-		{{else if eq $Field.ReturnType "int"}}
+		{{else if or (eq $Field.ReturnType "int") (eq $Field.ReturnType "[]int")}}
 			return reflect.Int, nil
 		{{else if eq $Field.ReturnType "bool"}}
 			return reflect.Bool, nil
@@ -411,6 +434,26 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 				return &eval.ErrValueTypeMismatch{Field: "{{$Field.Name}}"}
 			}
 			return nil
+		{{else if eq $Field.OrigType "[]string"}}
+			switch v := value.(type) {
+			case string:
+				{{$FieldName}} = []string{v}
+			case []string:
+				{{$FieldName}} = v
+			default:
+				return &eval.ErrValueTypeMismatch{Field: "{{$Field.Name}}"}
+			}
+			return nil
+		{{else if eq $Field.OrigType "[]int"}}
+			switch v := value.(type) {
+			case int:
+				{{$FieldName}} = []int{v}
+			case []int:
+				{{$FieldName}} = v
+			default:
+				return &eval.ErrValueTypeMismatch{Field: "{{$Field.Name}}"}
+			}
+			return nil
 		{{else if eq $Field.BasicType "int"}}
 			v, ok := value.(int)
 			if !ok {
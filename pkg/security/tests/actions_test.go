@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build functionaltests
+
+package tests
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+func TestKillAction(t *testing.T) {
+	rule := &rules.RuleDefinition{
+		ID:         "test_rule",
+		Expression: `open.filename == "{{.Root}}/test-kill"`,
+		Actions:    []rules.ActionDefinition{{Type: rules.KillAction}},
+	}
+
+	test, err := newTestModule(nil, []*rules.RuleDefinition{rule}, testOpts{enableEnforcement: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer test.Close()
+
+	testFile, _, err := test.Path("test-kill")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Create(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(testFile)
+
+	// the shell opens the file before being replaced by sleep, the pid which triggered the rule is then the one of sleep
+	cmd := exec.Command("sh", "-c", "exec 3<"+testFile+"; exec sleep 10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		cmd.Process.Kill()
+		<-done
+		t.Fatal("the process wasn't killed by the rule action")
+	}
+
+	if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); !ok || !status.Signaled() || status.Signal() != syscall.SIGKILL {
+		t.Errorf("expected the process to be killed by SIGKILL, got %s", cmd.ProcessState)
+	}
+}
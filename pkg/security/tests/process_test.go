@@ -189,3 +189,105 @@ func TestProcessContext(t *testing.T) {
 		}
 	})
 }
+
+func TestProcessAncestors(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ruleDef := &rules.RuleDefinition{
+		ID:         "test_rule",
+		Expression: fmt.Sprintf(`process.ancestors.basename in ["%s"] && open.filename == "{{.Root}}/test-process-ancestors"`, path.Base(executable)),
+	}
+
+	test, err := newTestModule(nil, []*rules.RuleDefinition{ruleDef}, testOpts{enableFilters: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer test.Close()
+
+	testFile, _, err := test.Path("test-process-ancestors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(testFile)
+
+	// the file is created by a grandchild of the test process
+	cmd := exec.Command("sh", "-c", "sh -c 'touch "+testFile+"'")
+	if _, err := cmd.CombinedOutput(); err != nil {
+		t.Fatal(err)
+	}
+
+	event, rule, err := test.GetEvent()
+	if err != nil {
+		t.Error(err)
+	} else {
+		if rule.ID != "test_rule" {
+			t.Errorf("expected rule 'test-rule' to be triggered, got %s", rule.ID)
+		}
+
+		if filenames, _ := event.GetFieldValue("process.ancestors.filename"); len(filenames.([]string)) < 2 {
+			t.Errorf("expected at least 2 ancestors, got %v", filenames)
+		}
+
+		pids, _ := event.GetFieldValue("process.ancestors.pid")
+		found := false
+		for _, pid := range pids.([]int) {
+			if pid == os.Getpid() {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected the test process %d to be an ancestor, got %v", os.Getpid(), pids)
+		}
+	}
+}
+
+func TestProcessExecHistory(t *testing.T) {
+	ruleDef := &rules.RuleDefinition{
+		ID:         "test_rule",
+		Expression: `open.filename == "{{.Root}}/test-process-exec-history"`,
+	}
+
+	test, err := newTestModule(nil, []*rules.RuleDefinition{ruleDef}, testOpts{enableFilters: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer test.Close()
+
+	testFile, _, err := test.Path("test-process-exec-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(testFile)
+
+	// the same pid executes sh, env and then touch
+	cmd := exec.Command("sh", "-c", "exec env touch "+testFile)
+	if _, err := cmd.CombinedOutput(); err != nil {
+		t.Fatal(err)
+	}
+
+	event, rule, err := test.GetEvent()
+	if err != nil {
+		t.Error(err)
+	} else {
+		if rule.ID != "test_rule" {
+			t.Errorf("expected rule 'test-rule' to be triggered, got %s", rule.ID)
+		}
+
+		value, _ := event.GetFieldValue("process.exec_history.filename")
+		filenames := value.([]string)
+		if len(filenames) < 3 {
+			t.Fatalf("expected at least 3 execs, got %v", filenames)
+		}
+
+		execs := filenames[len(filenames)-3:]
+		if path.Base(execs[0]) != "sh" && path.Base(execs[0]) != "dash" && path.Base(execs[0]) != "bash" {
+			t.Errorf("expected the chain to start with a shell, got %v", filenames)
+		}
+		if path.Base(execs[1]) != "env" || path.Base(execs[2]) != "touch" {
+			t.Errorf("expected the chain to end with env and touch, got %v", filenames)
+		}
+	}
+}
@@ -53,6 +53,8 @@ system_probe_config:
 runtime_security_config:
   enabled: true
   socket: /tmp/test-security-probe.sock
+  snapshot:
+    async: false
 {{if not .EnableFilters}}
   enable_kernel_filters: false
 {{end}}
@@ -62,6 +64,9 @@ runtime_security_config:
 {{if .DisableDiscarders}}
   enable_discarders: false
 {{end}}
+{{if .EnableEnforcement}}
+  enforcement_enabled: true
+{{end}}
 
   policies:
     dir: {{.TestPoliciesDir}}
@@ -80,6 +85,15 @@ rules:
   - id: {{$Rule.ID}}
     expression: >-
       {{$Rule.Expression}}
+{{- if $Rule.Actions}}
+    actions:
+{{- range $Action := $Rule.Actions}}
+      - type: {{$Action.Type}}
+{{- if $Action.Tag}}
+        tag: {{$Action.Tag}}
+{{- end}}
+{{- end}}
+{{- end}}
 {{end}}
 `
 
@@ -99,6 +113,7 @@ type testOpts struct {
 	enableFilters     bool
 	disableApprovers  bool
 	disableDiscarders bool
+	enableEnforcement bool
 	testDir           string
 	withoutHandler    bool
 }
@@ -179,6 +194,7 @@ func setTestConfig(dir string, macros []*rules.MacroDefinition, rules []*rules.R
 		"EnableFilters":     opts.enableFilters,
 		"DisableApprovers":  opts.disableApprovers,
 		"DisableDiscarders": opts.disableDiscarders,
+		"EnableEnforcement": opts.enableEnforcement,
 	}); err != nil {
 		return "", fail(err)
 	}
@@ -0,0 +1,548 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package triage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mholt/archiver"
+	"github.com/pkg/errors"
+
+	pconfig "github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/security/module"
+	"github.com/DataDog/datadog-agent/pkg/security/utils"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// Options defines the workload whose triage bundle is collected: either the process subtree of Pid,
+// or all the processes of the container ContainerID
+type Options struct {
+	Pid         uint32
+	ContainerID string
+	// Scrubber hides the values of the sensitive arguments and environment variables. Nothing is scrubbed if nil.
+	Scrubber *pconfig.DataScrubber
+}
+
+// Metadata describes a triage bundle
+type Metadata struct {
+	AgentVersion string    `json:"agent_version"`
+	CollectedAt  time.Time `json:"collected_at"`
+	Pid          uint32    `json:"pid,omitempty"`
+	ContainerID  string    `json:"container_id,omitempty"`
+	Pids         []uint32  `json:"pids"`
+	// Errors lists the data that couldn't be collected
+	Errors []string `json:"errors,omitempty"`
+}
+
+// FileDescriptor is an open file descriptor of a process
+type FileDescriptor struct {
+	Fd     int    `json:"fd"`
+	Target string `json:"target"`
+}
+
+// Socket is a socket opened by a process
+type Socket struct {
+	Fd         int    `json:"fd"`
+	Inode      uint64 `json:"inode"`
+	Protocol   string `json:"protocol"`
+	LocalAddr  string `json:"local_addr,omitempty"`
+	RemoteAddr string `json:"remote_addr,omitempty"`
+	State      string `json:"state,omitempty"`
+	Path       string `json:"path,omitempty"`
+}
+
+// Process holds the forensic data of a process
+type Process struct {
+	Pid         uint32           `json:"pid"`
+	PPid        uint32           `json:"ppid"`
+	Comm        string           `json:"comm"`
+	Exe         string           `json:"exe,omitempty"`
+	Cwd         string           `json:"cwd,omitempty"`
+	Args        []string         `json:"args,omitempty"`
+	Envs        []string         `json:"envs,omitempty"`
+	ContainerID string           `json:"container_id,omitempty"`
+	Fds         []FileDescriptor `json:"fds,omitempty"`
+	Sockets     []Socket         `json:"sockets,omitempty"`
+	Libraries   []string         `json:"libraries,omitempty"`
+	// Errors lists the data of the process that couldn't be collected
+	Errors []string `json:"errors,omitempty"`
+}
+
+// tcpStates maps the state values of /proc/net/tcp to their names
+var tcpStates = map[uint64]string{
+	0x01: "ESTABLISHED",
+	0x02: "SYN_SENT",
+	0x03: "SYN_RECV",
+	0x04: "FIN_WAIT1",
+	0x05: "FIN_WAIT2",
+	0x06: "TIME_WAIT",
+	0x07: "CLOSE",
+	0x08: "CLOSE_WAIT",
+	0x09: "LAST_ACK",
+	0x0A: "LISTEN",
+	0x0B: "CLOSING",
+}
+
+// Collect gathers the processes of the workload, their open files, sockets and loaded libraries, along with the
+// security events they triggered, in a zip archive written at archivePath. The events are the most recent ones
+// read from the event store, the ones unrelated to the collected processes are left out.
+func Collect(opts Options, events []module.StoredEvent, archivePath string) (*Metadata, error) {
+	if (opts.Pid == 0) == (opts.ContainerID == "") {
+		return nil, errors.New("either a pid or a container ID is required")
+	}
+
+	pids, err := selectPids(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tempDir, err := ioutil.TempDir("", "security-triage")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	bundleDir := filepath.Join(tempDir, "triage")
+	metadata := &Metadata{
+		AgentVersion: version.AgentVersion,
+		CollectedAt:  time.Now().UTC(),
+		Pid:          opts.Pid,
+		ContainerID:  opts.ContainerID,
+		Pids:         pids,
+	}
+
+	processes := make([]*Process, 0, len(pids))
+	for _, pid := range pids {
+		process := collectProcess(pid, opts.Scrubber)
+		processes = append(processes, process)
+
+		// keep a raw copy of the files parsed for the process, for the data this bundle doesn't extract
+		for _, name := range []string{"status", "maps", "mountinfo", "net/tcp", "net/tcp6", "net/udp", "net/udp6", "net/unix"} {
+			if err := copyProcFile(pid, name, filepath.Join(bundleDir, "proc", strconv.Itoa(int(pid)), name)); err != nil && !os.IsNotExist(errors.Cause(err)) {
+				metadata.Errors = append(metadata.Errors, err.Error())
+			}
+		}
+	}
+
+	related := filterEvents(opts, pids, events)
+
+	for name, data := range map[string]interface{}{
+		"processes.json": processes,
+		"events.json":    related,
+		"metadata.json":  metadata,
+	} {
+		if err := writeJSON(filepath.Join(bundleDir, name), data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archiver.Zip.Make(archivePath, []string{bundleDir}); err != nil {
+		return nil, errors.Wrap(err, "unable to create the triage archive")
+	}
+
+	return metadata, nil
+}
+
+// selectPids returns the pids of the process subtree, or of the container processes
+func selectPids(opts Options) ([]uint32, error) {
+	all, err := listPids()
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []uint32
+	if opts.ContainerID != "" {
+		for _, pid := range all {
+			if containerID, err := utils.GetProcContainerID(pid, pid); err == nil && string(containerID) == opts.ContainerID {
+				pids = append(pids, pid)
+			}
+		}
+		if len(pids) == 0 {
+			return nil, fmt.Errorf("no process found in container %s", opts.ContainerID)
+		}
+		return pids, nil
+	}
+
+	children := make(map[uint32][]uint32)
+	for _, pid := range all {
+		if ppid, _, err := readStat(pid); err == nil {
+			children[ppid] = append(children[ppid], pid)
+		}
+	}
+
+	if _, _, err := readStat(opts.Pid); err != nil {
+		return nil, errors.Wrapf(err, "process %d not found", opts.Pid)
+	}
+
+	queue := []uint32{opts.Pid}
+	seen := map[uint32]bool{opts.Pid: true}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		pids = append(pids, pid)
+
+		for _, child := range children[pid] {
+			if !seen[child] {
+				seen[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+
+	return pids, nil
+}
+
+// listPids returns the pids of all the processes of the host
+func listPids() ([]uint32, error) {
+	files, err := ioutil.ReadDir(util.HostProc())
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []uint32
+	for _, file := range files {
+		if pid, err := strconv.ParseUint(file.Name(), 10, 32); err == nil && file.IsDir() {
+			pids = append(pids, uint32(pid))
+		}
+	}
+	return pids, nil
+}
+
+// readStat returns the parent pid and the command name of a process
+func readStat(pid uint32) (uint32, string, error) {
+	data, err := ioutil.ReadFile(util.HostProc(strconv.Itoa(int(pid)), "stat"))
+	if err != nil {
+		return 0, "", err
+	}
+
+	// the command name is enclosed in parentheses and may contain spaces and parentheses
+	start, end := bytes.IndexByte(data, '('), bytes.LastIndexByte(data, ')')
+	if start < 0 || end < start {
+		return 0, "", fmt.Errorf("invalid stat file for pid %d", pid)
+	}
+
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 2 {
+		return 0, "", fmt.Errorf("invalid stat file for pid %d", pid)
+	}
+
+	ppid, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid stat file for pid %d", pid)
+	}
+
+	return uint32(ppid), string(data[start+1 : end]), nil
+}
+
+func collectProcess(pid uint32, scrubber *pconfig.DataScrubber) *Process {
+	p := &Process{Pid: pid}
+	procPath := func(name ...string) string {
+		return util.HostProc(append([]string{strconv.Itoa(int(pid))}, name...)...)
+	}
+	addError := func(err error) {
+		p.Errors = append(p.Errors, err.Error())
+	}
+
+	var err error
+	if p.PPid, p.Comm, err = readStat(pid); err != nil {
+		addError(err)
+	}
+
+	if p.Exe, err = os.Readlink(procPath("exe")); err != nil {
+		addError(err)
+	}
+
+	if p.Cwd, err = os.Readlink(procPath("cwd")); err != nil {
+		addError(err)
+	}
+
+	if containerID, err := utils.GetProcContainerID(pid, pid); err == nil {
+		p.ContainerID = string(containerID)
+	}
+
+	if args, err := readNulSeparated(procPath("cmdline")); err != nil {
+		addError(err)
+	} else {
+		if scrubber != nil {
+			// the patterns of the scrubber expect the arguments to be preceded by the executable
+			if scrubbed, changed := scrubber.ScrubCommand(append([]string{""}, args...)); changed {
+				args = scrubbed[1:]
+			}
+		}
+		p.Args = args
+	}
+
+	if envs, err := readNulSeparated(procPath("environ")); err != nil {
+		addError(err)
+	} else {
+		for _, env := range envs {
+			if scrubber != nil {
+				if values, changed := scrubber.ScrubCommand([]string{"", env}); changed {
+					env = strings.Join(values[1:], " ")
+				}
+			}
+			p.Envs = append(p.Envs, env)
+		}
+	}
+
+	if p.Libraries, err = readLibraries(procPath("maps")); err != nil {
+		addError(err)
+	}
+
+	if p.Fds, err = readFds(procPath("fd")); err != nil {
+		addError(err)
+	}
+
+	sockets := make(map[uint64]Socket)
+	for _, protocol := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		if err := readInetSockets(procPath("net", protocol), protocol, sockets); err != nil && !os.IsNotExist(err) {
+			addError(err)
+		}
+	}
+	if err := readUnixSockets(procPath("net", "unix"), sockets); err != nil && !os.IsNotExist(err) {
+		addError(err)
+	}
+
+	for _, fd := range p.Fds {
+		var inode uint64
+		if _, err := fmt.Sscanf(fd.Target, "socket:[%d]", &inode); err != nil {
+			continue
+		}
+
+		socket, exists := sockets[inode]
+		if !exists {
+			socket = Socket{Inode: inode, Protocol: "unknown"}
+		}
+		socket.Fd = fd.Fd
+		p.Sockets = append(p.Sockets, socket)
+	}
+
+	return p
+}
+
+func readNulSeparated(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return strings.Split(string(data), "\x00"), nil
+}
+
+// readLibraries returns the shared libraries mapped in the memory of a process
+func readLibraries(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var libraries []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// address perms offset dev inode pathname
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || !strings.HasPrefix(fields[5], "/") {
+			continue
+		}
+
+		pathname := strings.Join(fields[5:], " ")
+		if !strings.Contains(filepath.Base(pathname), ".so") || seen[pathname] {
+			continue
+		}
+		seen[pathname] = true
+		libraries = append(libraries, pathname)
+	}
+
+	return libraries, scanner.Err()
+}
+
+func readFds(path string) ([]FileDescriptor, error) {
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fds []FileDescriptor
+	for _, file := range files {
+		fd, err := strconv.Atoi(file.Name())
+		if err != nil {
+			continue
+		}
+
+		// the fd may have been closed since the directory was read
+		target, err := os.Readlink(filepath.Join(path, file.Name()))
+		if err != nil {
+			continue
+		}
+		fds = append(fds, FileDescriptor{Fd: fd, Target: target})
+	}
+	sort.Slice(fds, func(i, j int) bool { return fds[i].Fd < fds[j].Fd })
+
+	return fds, nil
+}
+
+// readInetSockets parses a /proc/net/{tcp,udp}[6] file of the network namespace of a process
+func readInetSockets(path string, protocol string, sockets map[uint64]Socket) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// skip the header
+	scanner.Scan()
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil || inode == 0 {
+			continue
+		}
+
+		socket := Socket{
+			Inode:      inode,
+			Protocol:   protocol,
+			LocalAddr:  parseProcNetAddr(fields[1]),
+			RemoteAddr: parseProcNetAddr(fields[2]),
+		}
+		if strings.HasPrefix(protocol, "tcp") {
+			if state, err := strconv.ParseUint(fields[3], 16, 8); err == nil {
+				socket.State = tcpStates[state]
+			}
+		}
+		sockets[inode] = socket
+	}
+
+	return scanner.Err()
+}
+
+// parseProcNetAddr converts an address of /proc/net/{tcp,udp}[6], made of 32 bits words in host byte order
+// followed by the port, to its text representation
+func parseProcNetAddr(addr string) string {
+	parts := strings.Split(addr, ":")
+	if len(parts) != 2 || (len(parts[0]) != 8 && len(parts[0]) != 32) {
+		return addr
+	}
+
+	port, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return addr
+	}
+
+	ip := make([]byte, 0, len(parts[0])/2)
+	for i := 0; i < len(parts[0]); i += 8 {
+		word, err := strconv.ParseUint(parts[0][i:i+8], 16, 32)
+		if err != nil {
+			return addr
+		}
+		ip = append(ip, byte(word), byte(word>>8), byte(word>>16), byte(word>>24))
+	}
+
+	if len(ip) == 4 {
+		return fmt.Sprintf("%d.%d.%d.%d:%d", ip[0], ip[1], ip[2], ip[3], port)
+	}
+	return fmt.Sprintf("[%s]:%d", net.IP(ip).String(), port)
+}
+
+// readUnixSockets parses the /proc/net/unix file of the network namespace of a process
+func readUnixSockets(path string, sockets map[uint64]Socket) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// skip the header
+	scanner.Scan()
+	for scanner.Scan() {
+		// Num RefCount Protocol Flags Type St Inode Path
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 7 {
+			continue
+		}
+
+		inode, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		socket := Socket{Inode: inode, Protocol: "unix"}
+		if len(fields) > 7 {
+			socket.Path = fields[7]
+		}
+		sockets[inode] = socket
+	}
+
+	return scanner.Err()
+}
+
+// filterEvents returns the events triggered by the collected processes, or by the container
+func filterEvents(opts Options, pids []uint32, events []module.StoredEvent) []module.StoredEvent {
+	collected := make(map[uint32]bool, len(pids))
+	for _, pid := range pids {
+		collected[pid] = true
+	}
+
+	related := []module.StoredEvent{}
+	for _, event := range events {
+		if (opts.ContainerID != "" && event.ContainerID == opts.ContainerID) || collected[event.Pid] {
+			related = append(related, event)
+		}
+	}
+	return related
+}
+
+func copyProcFile(pid uint32, name string, dst string) error {
+	data, err := ioutil.ReadFile(util.HostProc(strconv.Itoa(int(pid)), name))
+	if err != nil {
+		return errors.Wrapf(err, "unable to read %s of pid %d", name, pid)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, data, 0600)
+}
+
+func writeJSON(path string, data interface{}) error {
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0600)
+}
@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package triage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pconfig "github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/security/module"
+)
+
+const testContainerID = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// newFakeProc creates a fake procfs holding the processes 1 <- 10 <- {11 <- 13, 12}, and 20, the processes 11 and
+// 13 running in a container
+func newFakeProc(t *testing.T) string {
+	root, err := ioutil.TempDir("", "triage-proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	processes := []struct {
+		pid, ppid, comm string
+		container       bool
+	}{
+		{"1", "0", "init", false},
+		{"10", "1", "sh", false},
+		{"11", "10", "my (server)", true},
+		{"12", "10", "sleep", false},
+		{"13", "11", "worker", true},
+		{"20", "1", "cron", false},
+	}
+
+	write := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, p := range processes {
+		dir := filepath.Join(root, p.pid)
+		write(filepath.Join(dir, "stat"), p.pid+" ("+p.comm+") S "+p.ppid+" 1 1 0 -1\n")
+
+		cgroup := "0::/user.slice\n"
+		if p.container {
+			cgroup = "0::/docker/" + testContainerID + "\n"
+		}
+		write(filepath.Join(dir, "task", p.pid, "cgroup"), cgroup)
+	}
+
+	dir := filepath.Join(root, "11")
+	write(filepath.Join(dir, "cmdline"), "/usr/bin/server\x00--password\x00secret\x00--port\x008080\x00")
+	write(filepath.Join(dir, "environ"), "HOME=/root\x00API_KEY=1234\x00")
+	write(filepath.Join(dir, "maps"), `00400000-00452000 r-xp 00000000 08:02 173521      /usr/bin/server
+7f2c4b1c0000-7f2c4b37e000 r-xp 00000000 08:02 135522    /lib/x86_64-linux-gnu/libc-2.31.so
+7f2c4b37e000-7f2c4b57e000 ---p 001be000 08:02 135522    /lib/x86_64-linux-gnu/libc-2.31.so
+7f2c4b5a6000-7f2c4b5c9000 r-xp 00000000 08:02 135520    /lib/x86_64-linux-gnu/ld-2.31.so
+7ffd8a1c2000-7ffd8a1e3000 rw-p 00000000 00:00 0         [stack]
+`)
+	write(filepath.Join(dir, "net", "tcp"), `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4242 1 0000000000000000 100 0 0 10 0
+`)
+	write(filepath.Join(dir, "net", "tcp6"), `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000001000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4343 1 0000000000000000 100 0 0 10 0
+`)
+	write(filepath.Join(dir, "net", "unix"), `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 4444 /run/server.sock
+`)
+
+	if err := os.MkdirAll(filepath.Join(dir, "fd"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for fd, target := range map[string]string{"0": "/dev/null", "3": "socket:[4242]", "4": "socket:[4343]", "5": "socket:[4444]"} {
+		if err := os.Symlink(target, filepath.Join(dir, "fd", fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+func setHostProc(t *testing.T, root string) func() {
+	previous, exists := os.LookupEnv("HOST_PROC")
+	os.Setenv("HOST_PROC", root)
+	return func() {
+		if exists {
+			os.Setenv("HOST_PROC", previous)
+		} else {
+			os.Unsetenv("HOST_PROC")
+		}
+		os.RemoveAll(root)
+	}
+}
+
+func TestSelectPids(t *testing.T) {
+	defer setHostProc(t, newFakeProc(t))()
+
+	pids, err := selectPids(Options{Pid: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []uint32{10, 11, 12, 13}, pids)
+
+	pids, err = selectPids(Options{ContainerID: testContainerID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []uint32{11, 13}, pids)
+
+	_, err = selectPids(Options{Pid: 99})
+	assert.Error(t, err)
+
+	_, err = selectPids(Options{ContainerID: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestCollectProcess(t *testing.T) {
+	defer setHostProc(t, newFakeProc(t))()
+
+	p := collectProcess(11, pconfig.NewDefaultDataScrubber())
+
+	assert.Equal(t, uint32(10), p.PPid)
+	assert.Equal(t, "my (server)", p.Comm)
+	assert.Equal(t, testContainerID, p.ContainerID)
+	assert.Equal(t, []string{"/usr/bin/server", "--password", "********", "--port", "8080"}, p.Args)
+	assert.Equal(t, []string{"HOME=/root", "API_KEY=********"}, p.Envs)
+	assert.Equal(t, []string{"/lib/x86_64-linux-gnu/libc-2.31.so", "/lib/x86_64-linux-gnu/ld-2.31.so"}, p.Libraries)
+	assert.Equal(t, []FileDescriptor{
+		{Fd: 0, Target: "/dev/null"},
+		{Fd: 3, Target: "socket:[4242]"},
+		{Fd: 4, Target: "socket:[4343]"},
+		{Fd: 5, Target: "socket:[4444]"},
+	}, p.Fds)
+	assert.Equal(t, []Socket{
+		{Fd: 3, Inode: 4242, Protocol: "tcp", LocalAddr: "127.0.0.1:8080", RemoteAddr: "0.0.0.0:0", State: "LISTEN"},
+		{Fd: 4, Inode: 4343, Protocol: "tcp6", LocalAddr: "[::1]:8080", RemoteAddr: "[::]:0", State: "LISTEN"},
+		{Fd: 5, Inode: 4444, Protocol: "unix", Path: "/run/server.sock"},
+	}, p.Sockets)
+
+	// the data of the processes that can't be read are reported as errors
+	p = collectProcess(12, nil)
+	assert.Equal(t, "sleep", p.Comm)
+	assert.NotEmpty(t, p.Errors)
+}
+
+func TestFilterEvents(t *testing.T) {
+	events := []module.StoredEvent{
+		{RuleID: "a", Pid: 11},
+		{RuleID: "b", Pid: 42},
+		{RuleID: "c", Pid: 43, ContainerID: testContainerID},
+	}
+
+	related := filterEvents(Options{Pid: 10}, []uint32{10, 11}, events)
+	assert.Len(t, related, 1)
+	assert.Equal(t, "a", related[0].RuleID)
+
+	related = filterEvents(Options{ContainerID: testContainerID}, []uint32{11}, events)
+	assert.Len(t, related, 2)
+	assert.Equal(t, "c", related[1].RuleID)
+}
+
+func TestCollect(t *testing.T) {
+	defer setHostProc(t, newFakeProc(t))()
+
+	output, err := ioutil.TempDir("", "triage-output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(output)
+
+	archive := filepath.Join(output, "triage.zip")
+	metadata, err := Collect(Options{ContainerID: testContainerID}, nil, archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []uint32{11, 13}, metadata.Pids)
+	assert.FileExists(t, archive)
+
+	_, err = Collect(Options{}, nil, archive)
+	assert.Error(t, err)
+}
@@ -16,10 +16,15 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/moby/sys/mountinfo"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
-// containerIDPattern is the pattern of a container ID
-var containerIDPattern = regexp.MustCompile(fmt.Sprintf(`([[:xdigit:]]{%v})`, sha256.Size*2))
+// containerIDPattern is the pattern of a container ID, which must not be part of a longer hexadecimal string
+var containerIDPattern = regexp.MustCompile(fmt.Sprintf(`(?:^|[^[:xdigit:]])([[:xdigit:]]{%v})(?:$|[^[:xdigit:]])`, sha256.Size*2))
 
 // ContainerID is the type holding the container ID
 type ContainerID string
@@ -38,7 +43,80 @@ const ContainerIDLen = sha256.Size * 2
 
 // FindContainerID extracts the first sub string that matches the pattern of a container ID
 func FindContainerID(s string) string {
-	return containerIDPattern.FindString(s)
+	if match := containerIDPattern.FindStringSubmatch(s); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// CgroupMode describes the cgroup hierarchies mounted on the host
+type CgroupMode int
+
+const (
+	// CgroupModeUnknown is used when the cgroup hierarchies couldn't be detected
+	CgroupModeUnknown CgroupMode = iota
+	// CgroupModeLegacy is used when only cgroup v1 hierarchies are mounted
+	CgroupModeLegacy
+	// CgroupModeHybrid is used when the cgroup v2 unified hierarchy is mounted along with cgroup v1 hierarchies
+	CgroupModeHybrid
+	// CgroupModeUnified is used when only the cgroup v2 unified hierarchy is mounted
+	CgroupModeUnified
+)
+
+func (m CgroupMode) String() string {
+	switch m {
+	case CgroupModeLegacy:
+		return "legacy"
+	case CgroupModeHybrid:
+		return "hybrid"
+	case CgroupModeUnified:
+		return "unified"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	cgroupModeOnce sync.Once
+	cgroupMode     CgroupMode
+)
+
+// GetCgroupMode returns the cgroup mode of the host, detected once from the mount points of its init process
+func GetCgroupMode() CgroupMode {
+	cgroupModeOnce.Do(func() {
+		mounts, err := ParseMountInfoFile(1)
+		if err != nil {
+			log.Warnf("couldn't detect the cgroup mode: %s", err)
+			return
+		}
+		cgroupMode = detectCgroupMode(mounts)
+		log.Debugf("cgroup mode detected: %s", cgroupMode)
+	})
+	return cgroupMode
+}
+
+// detectCgroupMode returns the cgroup mode corresponding to the given mount points
+func detectCgroupMode(mounts []*mountinfo.Info) CgroupMode {
+	var v1, v2 bool
+	for _, mount := range mounts {
+		switch mount.Fstype {
+		case "cgroup":
+			v1 = true
+		case "cgroup2":
+			v2 = true
+		}
+	}
+
+	switch {
+	case v1 && v2:
+		return CgroupModeHybrid
+	case v1:
+		return CgroupModeLegacy
+	case v2:
+		return CgroupModeUnified
+	default:
+		return CgroupModeUnknown
+	}
 }
 
 // ControlGroup describes the cgroup membership of a process
@@ -54,35 +132,82 @@ type ControlGroup struct {
 	Path string
 }
 
-// GetContainerID returns the container id extracted from the path of the control group
-func (cg ControlGroup) GetContainerID() ContainerID {
-	return ContainerID(FindContainerID(cg.Path))
+// IsUnified returns whether the control group belongs to the cgroup v2 unified hierarchy
+func (cg ControlGroup) IsUnified() bool {
+	return cg.ID == 0 && len(cg.Controllers) == 0
 }
 
-// GetProcControlGroups returns the cgroup membership of the specified task.
-func GetProcControlGroups(tgid, pid uint32) ([]ControlGroup, error) {
-	data, err := ioutil.ReadFile(CgroupTaskPath(tgid, pid))
-	if err != nil {
-		return nil, err
+// GetContainerID returns the container id extracted from the path of the control group. The components of
+// the path are looked up from the innermost one, so that the ID of a nested container is returned rather
+// than the one of the container running it. The systemd scopes of the container runtimes, such as
+// "docker-<id>.scope", "cri-containerd-<id>.scope" or "crio-<id>.scope", are supported, while the scopes of
+// the container monitors, such as "libpod-conmon-<id>.scope", don't belong to the container.
+func (cg ControlGroup) GetContainerID() ContainerID {
+	components := strings.Split(cg.Path, "/")
+	for i := len(components) - 1; i >= 0; i-- {
+		if strings.Contains(components[i], "conmon") {
+			continue
+		}
+		if containerID := FindContainerID(components[i]); containerID != "" {
+			return ContainerID(containerID)
+		}
 	}
+	return ContainerID("")
+}
+
+// parseProcControlGroups parses the content of a /proc/[pid]/cgroup file
+func parseProcControlGroups(data []byte) []ControlGroup {
 	var cgroups []ControlGroup
 	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
-		t := scanner.Text()
-		parts := strings.Split(t, ":")
-		var ID int
-		ID, err = strconv.Atoi(parts[0])
+		// the path may contain colons
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		ID, err := strconv.Atoi(parts[0])
 		if err != nil {
 			continue
 		}
 		c := ControlGroup{
-			ID:          ID,
-			Controllers: strings.Split(parts[1], ","),
-			Path:        parts[2],
+			ID:   ID,
+			Path: parts[2],
+		}
+		if parts[1] != "" {
+			c.Controllers = strings.Split(parts[1], ",")
 		}
 		cgroups = append(cgroups, c)
 	}
-	return cgroups, nil
+	return cgroups
+}
+
+// GetProcControlGroups returns the cgroup membership of the specified task.
+func GetProcControlGroups(tgid, pid uint32) ([]ControlGroup, error) {
+	data, err := ioutil.ReadFile(CgroupTaskPath(tgid, pid))
+	if err != nil {
+		return nil, err
+	}
+	return parseProcControlGroups(data), nil
+}
+
+// findContainerID returns the container ID found in the control groups of a process. With cgroup v1, the
+// cgroup v2 unified hierarchy isn't looked up, while it's only looked up once the cgroup v1 hierarchies
+// didn't match in hybrid mode, systemd possibly not moving the processes of the containers in it.
+func findContainerID(cgroups []ControlGroup, mode CgroupMode) ContainerID {
+	for _, unified := range []bool{false, true} {
+		if (unified && mode == CgroupModeLegacy) || (!unified && mode == CgroupModeUnified) {
+			continue
+		}
+		for _, cgroup := range cgroups {
+			if cgroup.IsUnified() != unified {
+				continue
+			}
+			if containerID := cgroup.GetContainerID(); containerID != "" {
+				return containerID
+			}
+		}
+	}
+	return ContainerID("")
 }
 
 // GetProcContainerID returns the container ID which the process belongs to. Returns "" if the process does not belong
@@ -92,11 +217,5 @@ func GetProcContainerID(tgid, pid uint32) (ContainerID, error) {
 	if err != nil {
 		return ContainerID(""), err
 	}
-
-	for _, cgroup := range cgroups {
-		if containerID := cgroup.GetContainerID(); containerID != "" {
-			return containerID, nil
-		}
-	}
-	return ContainerID(""), nil
+	return findContainerID(cgroups, GetCgroupMode()), nil
 }
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/moby/sys/mountinfo"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testContainerID       = "99c3e5c4b8bd3b1e6d47c21cdfa0f82b2d6ab1f0d3e4a4d1ba2c7b2a30e6b49c"
+	testNestedContainerID = "0a6e1b4d3c2f9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d"
+)
+
+func TestDetectCgroupMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		fstypes []string
+		mode    CgroupMode
+	}{
+		{name: "none", fstypes: []string{"ext4", "proc"}, mode: CgroupModeUnknown},
+		{name: "legacy", fstypes: []string{"ext4", "tmpfs", "cgroup", "cgroup"}, mode: CgroupModeLegacy},
+		{name: "hybrid", fstypes: []string{"ext4", "tmpfs", "cgroup2", "cgroup", "cgroup"}, mode: CgroupModeHybrid},
+		{name: "unified", fstypes: []string{"ext4", "cgroup2"}, mode: CgroupModeUnified},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mounts []*mountinfo.Info
+			for _, fstype := range test.fstypes {
+				mounts = append(mounts, &mountinfo.Info{Fstype: fstype})
+			}
+			assert.Equal(t, test.mode, detectCgroupMode(mounts))
+		})
+	}
+}
+
+func TestParseProcControlGroups(t *testing.T) {
+	data := []byte(`12:cpu,cpuacct:/docker/` + testContainerID + `
+11:name=systemd:/docker/` + testContainerID + `
+malformed
+0::/system.slice/my:service
+`)
+
+	assert.Equal(t, []ControlGroup{
+		{ID: 12, Controllers: []string{"cpu", "cpuacct"}, Path: "/docker/" + testContainerID},
+		{ID: 11, Controllers: []string{"name=systemd"}, Path: "/docker/" + testContainerID},
+		{ID: 0, Path: "/system.slice/my:service"},
+	}, parseProcControlGroups(data))
+}
+
+func TestControlGroupContainerID(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		containerID ContainerID
+	}{
+		{name: "host", path: "/user.slice/user-1000.slice/session-2.scope"},
+		{name: "docker", path: "/docker/" + testContainerID, containerID: testContainerID},
+		{name: "docker-systemd", path: "/system.slice/docker-" + testContainerID + ".scope", containerID: testContainerID},
+		{name: "containerd-systemd", path: "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1234.slice/cri-containerd-" + testContainerID + ".scope", containerID: testContainerID},
+		{name: "crio-systemd", path: "/kubepods.slice/kubepods-besteffort.slice/crio-" + testContainerID + ".scope", containerID: testContainerID},
+		{name: "kubepods", path: "/kubepods/besteffort/pod1234/" + testContainerID, containerID: testContainerID},
+		{name: "podman", path: "/machine.slice/libpod-" + testContainerID + ".scope/container", containerID: testContainerID},
+		{name: "podman-conmon", path: "/machine.slice/libpod-conmon-" + testContainerID + ".scope"},
+		{name: "nested", path: "/docker/" + testContainerID + "/docker/" + testNestedContainerID, containerID: testNestedContainerID},
+		{name: "too-long", path: "/docker/" + testContainerID + "0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.containerID, ControlGroup{Path: test.path}.GetContainerID())
+		})
+	}
+}
+
+func TestFindContainerID(t *testing.T) {
+	legacy := []ControlGroup{
+		{ID: 12, Controllers: []string{"cpu", "cpuacct"}, Path: "/docker/" + testContainerID},
+		{ID: 0, Path: "/"},
+	}
+	unified := []ControlGroup{
+		{ID: 0, Path: "/system.slice/docker-" + testContainerID + ".scope"},
+	}
+	hybrid := []ControlGroup{
+		{ID: 12, Controllers: []string{"cpu", "cpuacct"}, Path: "/"},
+		{ID: 1, Controllers: []string{"name=systemd"}, Path: "/user.slice"},
+		{ID: 0, Path: "/system.slice/docker-" + testContainerID + ".scope"},
+	}
+
+	assert.Equal(t, ContainerID(testContainerID), findContainerID(legacy, CgroupModeLegacy))
+	assert.Equal(t, ContainerID(testContainerID), findContainerID(unified, CgroupModeUnified))
+	assert.Equal(t, ContainerID(testContainerID), findContainerID(hybrid, CgroupModeHybrid))
+	assert.Equal(t, ContainerID(testContainerID), findContainerID(hybrid, CgroupModeUnknown))
+	assert.Equal(t, ContainerID(""), findContainerID(hybrid, CgroupModeLegacy))
+	assert.Equal(t, ContainerID(""), findContainerID(legacy, CgroupModeUnified))
+}
@@ -39,6 +39,16 @@ func ProcExePath(pid uint32) string {
 	return filepath.Join(util.HostProc(), fmt.Sprintf("%d/exe", pid))
 }
 
+// ProcRootPath returns the path to the root directory of a pid in /proc
+func ProcRootPath(pid uint32) string {
+	return filepath.Join(util.HostProc(), fmt.Sprintf("%d/root", pid))
+}
+
+// ProcRootFilePath returns the path to the input file after prepending the proc root path of the given pid
+func ProcRootFilePath(pid uint32, file string) string {
+	return filepath.Join(ProcRootPath(pid), file)
+}
+
 // PidTTY returns the TTY of the given pid
 func PidTTY(pid uint32) string {
 	fdPath := filepath.Join(util.HostProc(), fmt.Sprintf("%d/fd/0", pid))
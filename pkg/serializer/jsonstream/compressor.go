@@ -97,11 +97,15 @@ type compressor struct {
 	maxUncompressedSize int
 }
 
-func newCompressor(input, output *bytes.Buffer, header, footer []byte) (*compressor, error) {
+// DefaultMaxPayloadSize returns the configured maximum size of the compressed payloads
+func DefaultMaxPayloadSize() int {
 	// the backend accepts payloads up to 3MB compressed / 50MB uncompressed but
 	// prefers small uncompressed payloads of ~4MB
-	maxPayloadSize := config.Datadog.GetInt("serializer_max_payload_size")
-	maxUncompressedSize := config.Datadog.GetInt("serializer_max_uncompressed_payload_size")
+	return int(config.Datadog.GetBytesSize("serializer_max_payload_size"))
+}
+
+func newCompressor(input, output *bytes.Buffer, header, footer []byte, maxPayloadSize int) (*compressor, error) {
+	maxUncompressedSize := int(config.Datadog.GetBytesSize("serializer_max_uncompressed_payload_size"))
 	c := &compressor{
 		header:              header,
 		footer:              footer,
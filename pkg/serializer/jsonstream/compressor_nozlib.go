@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 )
@@ -29,3 +30,8 @@ var (
 func Payloads(m marshaler.StreamJSONMarshaler) (forwarder.Payloads, error) {
 	return nil, fmt.Errorf("jsonstream is not supported on this agent")
 }
+
+// DefaultMaxPayloadSize returns the configured maximum size of the compressed payloads
+func DefaultMaxPayloadSize() int {
+	return int(config.Datadog.GetBytesSize("serializer_max_payload_size"))
+}
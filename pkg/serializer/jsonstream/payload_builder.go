@@ -57,6 +57,15 @@ func (b *PayloadBuilder) Build(m marshaler.StreamJSONMarshaler) (forwarder.Paylo
 func (b *PayloadBuilder) BuildWithOnErrItemTooBigPolicy(
 	m marshaler.StreamJSONMarshaler,
 	policy OnErrItemTooBigPolicy) (forwarder.Payloads, error) {
+	return b.BuildWithMaxPayloadSize(m, policy, DefaultMaxPayloadSize())
+}
+
+// BuildWithMaxPayloadSize serializes a metadata payload in payloads whose compressed size
+// is under maxPayloadSize and sends it to the forwarder
+func (b *PayloadBuilder) BuildWithMaxPayloadSize(
+	m marshaler.StreamJSONMarshaler,
+	policy OnErrItemTooBigPolicy,
+	maxPayloadSize int) (forwarder.Payloads, error) {
 
 	var payloads forwarder.Payloads
 	var i int
@@ -83,7 +92,7 @@ func (b *PayloadBuilder) BuildWithOnErrItemTooBigPolicy(
 		return nil, err
 	}
 
-	compressor, err := newCompressor(input, output, header.Bytes(), footer.Bytes())
+	compressor, err := newCompressor(input, output, header.Bytes(), footer.Bytes(), maxPayloadSize)
 	if err != nil {
 		return nil, err
 	}
@@ -113,7 +122,7 @@ func (b *PayloadBuilder) BuildWithOnErrItemTooBigPolicy(
 			payloads = append(payloads, &payload)
 			input.Reset()
 			output.Reset()
-			compressor, err = newCompressor(input, output, header.Bytes(), footer.Bytes())
+			compressor, err = newCompressor(input, output, header.Bytes(), footer.Bytes(), maxPayloadSize)
 			if err != nil {
 				return nil, err
 			}
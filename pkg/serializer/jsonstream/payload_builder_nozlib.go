@@ -38,3 +38,8 @@ func NewPayloadBuilder() *PayloadBuilder {
 func (b *PayloadBuilder) BuildWithOnErrItemTooBigPolicy(marshaler.StreamJSONMarshaler, OnErrItemTooBigPolicy) (forwarder.Payloads, error) {
 	return nil, fmt.Errorf("not implemented")
 }
+
+// BuildWithMaxPayloadSize is not implemented when zlib is not available.
+func (b *PayloadBuilder) BuildWithMaxPayloadSize(marshaler.StreamJSONMarshaler, OnErrItemTooBigPolicy, int) (forwarder.Payloads, error) {
+	return nil, fmt.Errorf("not implemented")
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/serializer/jsonstream"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
@@ -106,6 +107,9 @@ type Serializer struct {
 
 	seriesPayloadBuilder *jsonstream.PayloadBuilder
 
+	// tenantRouter splits the data sent to the orgs of the tenant routes, it is nil without routes
+	tenantRouter *tenantRouter
+
 	// Those variables allow users to blacklist any kind of payload
 	// from being sent by the agent. This was introduced for
 	// environment where, for example, events or serviceChecks
@@ -153,10 +157,17 @@ func NewSerializer(forwarder forwarder.Forwarder) *Serializer {
 		log.Warn("JSON to V1 intake is disabled: all payloads to that endpoint will be dropped")
 	}
 
+	// the forwarder reports the misconfigurations of the tenant routes
+	if routes, err := config.GetTenantRoutes(); err == nil {
+		s.tenantRouter = newTenantRouter(routes)
+	}
+
 	return s
 }
 
-func (s Serializer) serializePayload(payload marshaler.Marshaler, compress bool, useV1API bool) (forwarder.Payloads, http.Header, error) {
+// serializePayload serializes the payload, splitting it under the payload size limit of the
+// endpoint it is sent to, which may have been lowered by the intake 413 responses
+func (s Serializer) serializePayload(payload marshaler.Marshaler, compress bool, useV1API bool, endpointName string) (forwarder.Payloads, http.Header, error) {
 	var marshalType split.MarshalType
 	var extraHeaders http.Header
 
@@ -176,7 +187,8 @@ func (s Serializer) serializePayload(payload marshaler.Marshaler, compress bool,
 		}
 	}
 
-	payloads, err := split.Payloads(payload, compress, marshalType)
+	maxPayloadSize := forwarder.GetPayloadSizeLimit(endpointName, split.DefaultMaxPayloadSize())
+	payloads, err := split.PayloadsWithMaxSize(payload, compress, marshalType, maxPayloadSize)
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not split payload into small enough chunks: %s", err)
@@ -185,8 +197,9 @@ func (s Serializer) serializePayload(payload marshaler.Marshaler, compress bool,
 	return payloads, extraHeaders, nil
 }
 
-func (s Serializer) serializeStreamablePayload(payload marshaler.StreamJSONMarshaler, policy jsonstream.OnErrItemTooBigPolicy) (forwarder.Payloads, http.Header, error) {
-	payloads, err := s.seriesPayloadBuilder.BuildWithOnErrItemTooBigPolicy(payload, policy)
+func (s Serializer) serializeStreamablePayload(payload marshaler.StreamJSONMarshaler, policy jsonstream.OnErrItemTooBigPolicy, endpointName string) (forwarder.Payloads, http.Header, error) {
+	maxPayloadSize := forwarder.GetPayloadSizeLimit(endpointName, jsonstream.DefaultMaxPayloadSize())
+	payloads, err := s.seriesPayloadBuilder.BuildWithMaxPayloadSize(payload, policy, maxPayloadSize)
 	return payloads, jsonExtraHeadersWithCompression, err
 }
 
@@ -200,9 +213,9 @@ func (s Serializer) serializeStreamablePayload(payload marshaler.StreamJSONMarsh
 //
 // If none of the previous methods work, we fallback to the old serialization method (Serializer.serializePayload).
 func (s Serializer) serializeEventsStreamJSONMarshalerPayload(
-	eventsStreamJSONMarshaler EventsStreamJSONMarshaler, useV1API bool) (forwarder.Payloads, http.Header, error) {
+	eventsStreamJSONMarshaler EventsStreamJSONMarshaler, useV1API bool, endpointName string) (forwarder.Payloads, http.Header, error) {
 	marshaler := eventsStreamJSONMarshaler.CreateSingleMarshaler()
-	eventPayloads, extraHeaders, err := s.serializeStreamablePayload(marshaler, jsonstream.FailOnErrItemTooBig)
+	eventPayloads, extraHeaders, err := s.serializeStreamablePayload(marshaler, jsonstream.FailOnErrItemTooBig, endpointName)
 
 	if err == jsonstream.ErrItemTooBig {
 		expvarsSendEventsErrItemTooBigs.Add(1)
@@ -210,12 +223,12 @@ func (s Serializer) serializeEventsStreamJSONMarshalerPayload(
 		// Do not use CreateMarshalersBySourceType when there are too many source types (Performance issue).
 		if marshaler.Len() > maxItemCountForCreateMarshalersBySourceType {
 			expvarsSendEventsErrItemTooBigsFallback.Add(1)
-			eventPayloads, extraHeaders, err = s.serializePayload(eventsStreamJSONMarshaler, true, useV1API)
+			eventPayloads, extraHeaders, err = s.serializePayload(eventsStreamJSONMarshaler, true, useV1API, endpointName)
 		} else {
 			eventPayloads = nil
 			for _, v := range eventsStreamJSONMarshaler.CreateMarshalersBySourceType() {
 				var eventPayloadsForSourceType forwarder.Payloads
-				eventPayloadsForSourceType, extraHeaders, err = s.serializeStreamablePayload(v, jsonstream.DropItemOnErrItemTooBig)
+				eventPayloadsForSourceType, extraHeaders, err = s.serializeStreamablePayload(v, jsonstream.DropItemOnErrItemTooBig, endpointName)
 				if err != nil {
 					return nil, nil, err
 				}
@@ -233,19 +246,46 @@ func (s *Serializer) SendEvents(e EventsStreamJSONMarshaler) error {
 		return nil
 	}
 
+	if events, ok := e.(metrics.Events); ok && s.tenantRouter != nil {
+		main, routed := s.tenantRouter.splitEvents(events)
+		var routeErr error
+		for route, routeEvents := range routed {
+			if err := s.sendEvents(routeEvents, route); err != nil {
+				routeErr = fmt.Errorf("tenant route %s: %s", route, err)
+			}
+		}
+		if len(main) == 0 && len(routed) > 0 {
+			return routeErr
+		}
+		if err := s.sendEvents(main, ""); err != nil {
+			return err
+		}
+		return routeErr
+	}
+
+	return s.sendEvents(e, "")
+}
+
+func (s *Serializer) sendEvents(e EventsStreamJSONMarshaler, route string) error {
 	useV1API := !config.Datadog.GetBool("use_v2_api.events")
 	var eventPayloads forwarder.Payloads
 	var extraHeaders http.Header
 	var err error
 
+	endpointName := forwarder.EventsEndpointName
+	if useV1API {
+		endpointName = forwarder.V1IntakeEndpointName
+	}
+
 	if useV1API && s.enableEventsJSONStream {
-		eventPayloads, extraHeaders, err = s.serializeEventsStreamJSONMarshalerPayload(e, useV1API)
+		eventPayloads, extraHeaders, err = s.serializeEventsStreamJSONMarshalerPayload(e, useV1API, endpointName)
 	} else {
-		eventPayloads, extraHeaders, err = s.serializePayload(e, true, useV1API)
+		eventPayloads, extraHeaders, err = s.serializePayload(e, true, useV1API, endpointName)
 	}
 	if err != nil {
 		return fmt.Errorf("dropping event payload: %s", err)
 	}
+	extraHeaders = withTenantRoute(extraHeaders, route)
 
 	if useV1API {
 		return s.Forwarder.SubmitV1Intake(eventPayloads, extraHeaders, forwarder.TransactionPriorityNormal)
@@ -260,20 +300,47 @@ func (s *Serializer) SendServiceChecks(sc marshaler.StreamJSONMarshaler) error {
 		return nil
 	}
 
+	if serviceChecks, ok := sc.(metrics.ServiceChecks); ok && s.tenantRouter != nil {
+		main, routed := s.tenantRouter.splitServiceChecks(serviceChecks)
+		var routeErr error
+		for route, routeServiceChecks := range routed {
+			if err := s.sendServiceChecks(routeServiceChecks, route); err != nil {
+				routeErr = fmt.Errorf("tenant route %s: %s", route, err)
+			}
+		}
+		if len(main) == 0 && len(routed) > 0 {
+			return routeErr
+		}
+		if err := s.sendServiceChecks(main, ""); err != nil {
+			return err
+		}
+		return routeErr
+	}
+
+	return s.sendServiceChecks(sc, "")
+}
+
+func (s *Serializer) sendServiceChecks(sc marshaler.StreamJSONMarshaler, route string) error {
 	useV1API := !config.Datadog.GetBool("use_v2_api.service_checks")
 
 	var serviceCheckPayloads forwarder.Payloads
 	var extraHeaders http.Header
 	var err error
 
+	endpointName := forwarder.ServiceChecksEndpointName
+	if useV1API {
+		endpointName = forwarder.V1CheckRunsEndpointName
+	}
+
 	if useV1API && s.enableServiceChecksJSONStream {
-		serviceCheckPayloads, extraHeaders, err = s.serializeStreamablePayload(sc, jsonstream.DropItemOnErrItemTooBig)
+		serviceCheckPayloads, extraHeaders, err = s.serializeStreamablePayload(sc, jsonstream.DropItemOnErrItemTooBig, endpointName)
 	} else {
-		serviceCheckPayloads, extraHeaders, err = s.serializePayload(sc, true, useV1API)
+		serviceCheckPayloads, extraHeaders, err = s.serializePayload(sc, true, useV1API, endpointName)
 	}
 	if err != nil {
 		return fmt.Errorf("dropping service check payload: %s", err)
 	}
+	extraHeaders = withTenantRoute(extraHeaders, route)
 
 	if useV1API {
 		return s.Forwarder.SubmitV1CheckRuns(serviceCheckPayloads, extraHeaders)
@@ -288,21 +355,48 @@ func (s *Serializer) SendSeries(series marshaler.StreamJSONMarshaler) error {
 		return nil
 	}
 
+	if allSeries, ok := series.(metrics.Series); ok && s.tenantRouter != nil {
+		main, routed := s.tenantRouter.splitSeries(allSeries)
+		var routeErr error
+		for route, routeSeries := range routed {
+			if err := s.sendSeries(routeSeries, route); err != nil {
+				routeErr = fmt.Errorf("tenant route %s: %s", route, err)
+			}
+		}
+		if len(main) == 0 && len(routed) > 0 {
+			return routeErr
+		}
+		if err := s.sendSeries(main, ""); err != nil {
+			return err
+		}
+		return routeErr
+	}
+
+	return s.sendSeries(series, "")
+}
+
+func (s *Serializer) sendSeries(series marshaler.StreamJSONMarshaler, route string) error {
 	useV1API := !config.Datadog.GetBool("use_v2_api.series")
 
 	var seriesPayloads forwarder.Payloads
 	var extraHeaders http.Header
 	var err error
 
+	endpointName := forwarder.SeriesEndpointName
+	if useV1API {
+		endpointName = forwarder.V1SeriesEndpointName
+	}
+
 	if useV1API && s.enableJSONStream {
-		seriesPayloads, extraHeaders, err = s.serializeStreamablePayload(series, jsonstream.DropItemOnErrItemTooBig)
+		seriesPayloads, extraHeaders, err = s.serializeStreamablePayload(series, jsonstream.DropItemOnErrItemTooBig, endpointName)
 	} else {
-		seriesPayloads, extraHeaders, err = s.serializePayload(series, true, useV1API)
+		seriesPayloads, extraHeaders, err = s.serializePayload(series, true, useV1API, endpointName)
 	}
 
 	if err != nil {
 		return fmt.Errorf("dropping series payload: %s", err)
 	}
+	extraHeaders = withTenantRoute(extraHeaders, route)
 
 	if useV1API {
 		return s.Forwarder.SubmitV1Series(seriesPayloads, extraHeaders)
@@ -317,12 +411,34 @@ func (s *Serializer) SendSketch(sketches marshaler.Marshaler) error {
 		return nil
 	}
 
+	if sketchSeries, ok := sketches.(metrics.SketchSeriesList); ok && s.tenantRouter != nil {
+		main, routed := s.tenantRouter.splitSketches(sketchSeries)
+		var routeErr error
+		for route, routeSketches := range routed {
+			if err := s.sendSketch(routeSketches, route); err != nil {
+				routeErr = fmt.Errorf("tenant route %s: %s", route, err)
+			}
+		}
+		if len(main) == 0 && len(routed) > 0 {
+			return routeErr
+		}
+		if err := s.sendSketch(main, ""); err != nil {
+			return err
+		}
+		return routeErr
+	}
+
+	return s.sendSketch(sketches, "")
+}
+
+func (s *Serializer) sendSketch(sketches marshaler.Marshaler, route string) error {
 	compress := true
 	useV1API := false // Sketches only have a v2 endpoint
-	splitSketches, extraHeaders, err := s.serializePayload(sketches, compress, useV1API)
+	splitSketches, extraHeaders, err := s.serializePayload(sketches, compress, useV1API, forwarder.SketchSeriesEndpointName)
 	if err != nil {
 		return fmt.Errorf("dropping sketch payload: %s", err)
 	}
+	extraHeaders = withTenantRoute(extraHeaders, route)
 
 	return s.Forwarder.SubmitSketchSeries(splitSketches, extraHeaders)
 }
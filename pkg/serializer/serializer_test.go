@@ -17,6 +17,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
@@ -337,6 +338,25 @@ func TestSendSeries(t *testing.T) {
 	require.NotNil(t, err)
 }
 
+func TestSendV1SeriesWithTenantRoutes(t *testing.T) {
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitV1Series", mock.Anything, jsonExtraHeadersWithCompression).Return(nil).Times(1)
+	f.On("SubmitV1Series", mock.Anything, withTenantRoute(jsonExtraHeadersWithCompression, "team-a")).Return(nil).Times(1)
+	config.Datadog.Set("enable_stream_payload_serialization", false)
+	defer config.Datadog.Set("enable_stream_payload_serialization", nil)
+
+	s := NewSerializer(f)
+	s.tenantRouter = newTenantRouter(testTenantRoutes)
+
+	series := metrics.Series{
+		{Name: "main", Tags: []string{"kube_namespace:default"}, Points: []metrics.Point{{Ts: 1, Value: 1}}},
+		{Name: "tenant", Tags: []string{"kube_namespace:team-a"}, Points: []metrics.Point{{Ts: 1, Value: 2}}},
+	}
+	err := s.SendSeries(series)
+	require.Nil(t, err)
+	f.AssertExpectations(t)
+}
+
 func TestSendSketch(t *testing.T) {
 	f := &forwarder.MockedForwarder{}
 	payloads, _ := mkPayloads(protobufString, true)
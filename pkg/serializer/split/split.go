@@ -58,21 +58,36 @@ func init() {
 // CheckSizeAndSerialize Check the size of a payload and marshall it (optionally compress it)
 // The dual role makes sense as you will never serialize without checking the size of the payload
 func CheckSizeAndSerialize(m marshaler.Marshaler, compress bool, mType MarshalType) (bool, []byte, []byte, error) {
+	return checkSizeAndSerialize(m, compress, mType, maxPayloadSizeCompressed)
+}
+
+func checkSizeAndSerialize(m marshaler.Marshaler, compress bool, mType MarshalType, maxCompressedSize int) (bool, []byte, []byte, error) {
 	compressedPayload, payload, err := serializeMarshaller(m, compress, mType)
 	if err != nil {
 		return false, nil, nil, err
 	}
 
-	mustBeSplit := tooBigCompressed(compressedPayload) || tooBigUnCompressed(payload)
+	mustBeSplit := tooBigCompressed(compressedPayload, maxCompressedSize) || tooBigUnCompressed(payload)
 
 	return mustBeSplit, compressedPayload, payload, nil
 }
 
 // Payloads serializes a metadata payload and sends it to the forwarder
 func Payloads(m marshaler.Marshaler, compress bool, mType MarshalType) (forwarder.Payloads, error) {
+	return PayloadsWithMaxSize(m, compress, mType, maxPayloadSizeCompressed)
+}
+
+// DefaultMaxPayloadSize returns the default maximum size of the compressed payloads
+func DefaultMaxPayloadSize() int {
+	return maxPayloadSizeCompressed
+}
+
+// PayloadsWithMaxSize serializes a payload, splitting it in payloads whose compressed
+// size is under maxCompressedSize
+func PayloadsWithMaxSize(m marshaler.Marshaler, compress bool, mType MarshalType, maxCompressedSize int) (forwarder.Payloads, error) {
 	marshallers := []marshaler.Marshaler{m}
 	smallEnoughPayloads := forwarder.Payloads{}
-	tooBig, compressedPayload, _, err := CheckSizeAndSerialize(m, compress, mType)
+	tooBig, compressedPayload, _, err := checkSizeAndSerialize(m, compress, mType, maxCompressedSize)
 	if err != nil {
 		return smallEnoughPayloads, err
 	}
@@ -108,7 +123,7 @@ func Payloads(m marshaler.Marshaler, compress bool, mType MarshalType) (forwarde
 			// Attempt to account for the compression when estimating the number of chunks that will be needed
 			// This is the same function used in dd-agent
 			compressionRatio := float64(payloadSize) / float64(compressedSize)
-			numChunks := compressedSize/maxPayloadSizeCompressed + 1 + int(compressionRatio/2)
+			numChunks := compressedSize/maxCompressedSize + 1 + int(compressionRatio/2)
 			log.Debugf("split the payload into into %d chunks", numChunks)
 			chunks, err := toSplit.SplitPayload(numChunks)
 			log.Debugf("payload was split into %d chunks", len(chunks))
@@ -121,7 +136,7 @@ func Payloads(m marshaler.Marshaler, compress bool, mType MarshalType) (forwarde
 			// after the payload has been split, loop through the chunks
 			for _, chunk := range chunks {
 				// serialize the payload
-				tooBigChunk, compressedPayload, _, err := CheckSizeAndSerialize(chunk, compress, mType)
+				tooBigChunk, compressedPayload, _, err := checkSizeAndSerialize(chunk, compress, mType, maxCompressedSize)
 				if err != nil {
 					log.Debugf("Error serializing a chunk: %s", err)
 					continue
@@ -174,8 +189,8 @@ func serializeMarshaller(m marshaler.Marshaler, compress bool, mType MarshalType
 }
 
 // returns true if the payload is above the max compressed size limit
-func tooBigCompressed(payload []byte) bool {
-	return len(payload) > maxPayloadSizeCompressed
+func tooBigCompressed(payload []byte, maxCompressedSize int) bool {
+	return len(payload) > maxCompressedSize
 }
 
 // returns true if the payload is above the max unCompressed size limit
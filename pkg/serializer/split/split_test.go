@@ -95,6 +95,33 @@ func testSplitPayloadsSeries(t *testing.T, numPoints int, compress bool) {
 	require.Equal(t, originalLength, newLength)
 }
 
+func TestSplitPayloadsWithMaxSize(t *testing.T) {
+	testSeries := metrics.Series{}
+	for i := 0; i < 20; i++ {
+		testSeries = append(testSeries, &metrics.Serie{
+			Points:   []metrics.Point{{Ts: 12345.0, Value: float64(i)}},
+			MType:    metrics.APIGaugeType,
+			Name:     fmt.Sprintf("test.metrics%d", i),
+			Interval: 1,
+			Host:     "localHost",
+			Tags:     []string{"tag1", "tag2:yes"},
+		})
+	}
+
+	payloads, err := Payloads(testSeries, false, MarshalJSON)
+	require.Nil(t, err)
+	require.Len(t, payloads, 1)
+	fullSize := len(*payloads[0])
+
+	// a lower limit, learned from the intake, splits the payload
+	payloads, err = PayloadsWithMaxSize(testSeries, false, MarshalJSON, fullSize/2)
+	require.Nil(t, err)
+	require.True(t, len(payloads) > 1)
+	for _, payload := range payloads {
+		require.True(t, len(*payload) <= fullSize/2)
+	}
+}
+
 var result forwarder.Payloads
 
 func BenchmarkSplitPayloadsSeries(b *testing.B) {
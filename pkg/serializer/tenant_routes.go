@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package serializer
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// tenantRouteSelector matches the tags of the data of a tenant route
+type tenantRouteSelector struct {
+	name     string
+	tags     map[string]bool
+	prefixes []string
+}
+
+func (s *tenantRouteSelector) matches(tags []string) bool {
+	for _, tag := range tags {
+		if s.tags[tag] {
+			return true
+		}
+		for _, prefix := range s.prefixes {
+			if strings.HasPrefix(tag, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tenantRouter splits the series, sketches, service checks and events by tenant route, the
+// data matching no route is sent to the main endpoints
+type tenantRouter struct {
+	selectors []*tenantRouteSelector
+}
+
+// newTenantRouter returns a router for the routes, or nil when there is no route
+func newTenantRouter(routes []config.TenantRoute) *tenantRouter {
+	if len(routes) == 0 {
+		return nil
+	}
+
+	router := &tenantRouter{}
+	for _, route := range routes {
+		selector := &tenantRouteSelector{
+			name: route.Name,
+			tags: make(map[string]bool),
+		}
+		for _, tag := range route.TagSelectors {
+			if strings.HasSuffix(tag, "*") {
+				selector.prefixes = append(selector.prefixes, strings.TrimSuffix(tag, "*"))
+			} else {
+				selector.tags[tag] = true
+			}
+		}
+		router.selectors = append(router.selectors, selector)
+	}
+	return router
+}
+
+// route returns the first route matching the tags, or an empty string
+func (r *tenantRouter) route(tags []string) string {
+	for _, selector := range r.selectors {
+		if selector.matches(tags) {
+			return selector.name
+		}
+	}
+	return ""
+}
+
+func (r *tenantRouter) splitSeries(series metrics.Series) (metrics.Series, map[string]metrics.Series) {
+	var main metrics.Series
+	routed := make(map[string]metrics.Series)
+	for _, serie := range series {
+		if route := r.route(serie.Tags); route != "" {
+			routed[route] = append(routed[route], serie)
+		} else {
+			main = append(main, serie)
+		}
+	}
+	return main, routed
+}
+
+func (r *tenantRouter) splitSketches(sketches metrics.SketchSeriesList) (metrics.SketchSeriesList, map[string]metrics.SketchSeriesList) {
+	var main metrics.SketchSeriesList
+	routed := make(map[string]metrics.SketchSeriesList)
+	for _, sketch := range sketches {
+		if route := r.route(sketch.Tags); route != "" {
+			routed[route] = append(routed[route], sketch)
+		} else {
+			main = append(main, sketch)
+		}
+	}
+	return main, routed
+}
+
+func (r *tenantRouter) splitServiceChecks(serviceChecks metrics.ServiceChecks) (metrics.ServiceChecks, map[string]metrics.ServiceChecks) {
+	var main metrics.ServiceChecks
+	routed := make(map[string]metrics.ServiceChecks)
+	for _, serviceCheck := range serviceChecks {
+		if route := r.route(serviceCheck.Tags); route != "" {
+			routed[route] = append(routed[route], serviceCheck)
+		} else {
+			main = append(main, serviceCheck)
+		}
+	}
+	return main, routed
+}
+
+func (r *tenantRouter) splitEvents(events metrics.Events) (metrics.Events, map[string]metrics.Events) {
+	var main metrics.Events
+	routed := make(map[string]metrics.Events)
+	for _, event := range events {
+		if route := r.route(event.Tags); route != "" {
+			routed[route] = append(routed[route], event)
+		} else {
+			main = append(main, event)
+		}
+	}
+	return main, routed
+}
+
+// withTenantRoute returns a copy of the headers routing the payloads to the tenant route. The
+// headers are returned as is for the main endpoints.
+func withTenantRoute(headers http.Header, route string) http.Header {
+	if route == "" {
+		return headers
+	}
+
+	routed := make(http.Header, len(headers)+1)
+	for key, values := range headers {
+		routed[key] = values
+	}
+	routed.Set(forwarder.TenantRouteHTTPHeaderKey, route)
+	return routed
+}
@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package serializer
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+var testTenantRoutes = []config.TenantRoute{
+	{Name: "team-a", APIKey: "api-key-a", TagSelectors: []string{"kube_namespace:team-a"}},
+	{Name: "team-b", APIKey: "api-key-b", TagSelectors: []string{"kube_namespace:team-b-*", "team:b"}},
+}
+
+func TestNewTenantRouterWithoutRoutes(t *testing.T) {
+	assert.Nil(t, newTenantRouter(nil))
+}
+
+func TestTenantRouterRoute(t *testing.T) {
+	router := newTenantRouter(testTenantRoutes)
+
+	assert.Equal(t, "team-a", router.route([]string{"env:prod", "kube_namespace:team-a"}))
+	assert.Equal(t, "team-b", router.route([]string{"kube_namespace:team-b-front"}))
+	assert.Equal(t, "team-b", router.route([]string{"team:b"}))
+	assert.Equal(t, "team-a", router.route([]string{"team:b", "kube_namespace:team-a"}))
+	assert.Equal(t, "", router.route([]string{"kube_namespace:team-ab"}))
+	assert.Equal(t, "", router.route(nil))
+}
+
+func TestTenantRouterSplit(t *testing.T) {
+	router := newTenantRouter(testTenantRoutes)
+
+	series := metrics.Series{
+		{Name: "main", Tags: []string{"kube_namespace:default"}},
+		{Name: "a", Tags: []string{"kube_namespace:team-a"}},
+		{Name: "b", Tags: []string{"team:b"}},
+	}
+	mainSeries, routedSeries := router.splitSeries(series)
+	assert.Equal(t, metrics.Series{series[0]}, mainSeries)
+	assert.Equal(t, map[string]metrics.Series{"team-a": {series[1]}, "team-b": {series[2]}}, routedSeries)
+
+	sketches := metrics.SketchSeriesList{
+		{Name: "main"},
+		{Name: "b", Tags: []string{"kube_namespace:team-b-back"}},
+	}
+	mainSketches, routedSketches := router.splitSketches(sketches)
+	assert.Equal(t, metrics.SketchSeriesList{sketches[0]}, mainSketches)
+	assert.Equal(t, map[string]metrics.SketchSeriesList{"team-b": {sketches[1]}}, routedSketches)
+
+	serviceChecks := metrics.ServiceChecks{
+		{CheckName: "a", Tags: []string{"kube_namespace:team-a"}},
+	}
+	mainServiceChecks, routedServiceChecks := router.splitServiceChecks(serviceChecks)
+	assert.Empty(t, mainServiceChecks)
+	assert.Equal(t, map[string]metrics.ServiceChecks{"team-a": serviceChecks}, routedServiceChecks)
+
+	events := metrics.Events{
+		{Title: "main"},
+	}
+	mainEvents, routedEvents := router.splitEvents(events)
+	assert.Equal(t, events, mainEvents)
+	assert.Empty(t, routedEvents)
+}
+
+func TestWithTenantRoute(t *testing.T) {
+	headers := make(http.Header)
+	headers.Set("Content-Type", jsonContentType)
+
+	assert.Equal(t, headers, withTenantRoute(headers, ""))
+
+	routed := withTenantRoute(headers, "team-a")
+	assert.Equal(t, "team-a", routed.Get(forwarder.TenantRouteHTTPHeaderKey))
+	assert.Equal(t, jsonContentType, routed.Get("Content-Type"))
+	// the shared headers are left untouched
+	assert.Empty(t, headers.Get(forwarder.TenantRouteHTTPHeaderKey))
+}
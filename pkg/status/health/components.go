@@ -0,0 +1,263 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package health
+
+import (
+	"sort"
+	"sync"
+)
+
+// State is the state of a component, from which its readiness and its liveness are derived
+type State string
+
+const (
+	// StateReady is used for the components working as expected
+	StateReady State = "ready"
+	// StateDegraded is used for the components which keep working with a reduced functionality, they're
+	// still ready and live
+	StateDegraded State = "degraded"
+	// StateNotReady is used for the components which are live but not ready, starting or waiting for a
+	// dependency
+	StateNotReady State = "not_ready"
+	// StateFailed is used for the components which are neither ready nor live
+	StateFailed State = "failed"
+)
+
+// severity orders the states from the healthiest to the least healthy one
+func (s State) severity() int {
+	switch s {
+	case StateReady:
+		return 0
+	case StateDegraded:
+		return 1
+	case StateNotReady:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Ready returns whether the components in this state are ready
+func (s State) Ready() bool {
+	return s == StateReady || s == StateDegraded
+}
+
+// Live returns whether the components in this state are live
+func (s State) Live() bool {
+	return s != StateFailed
+}
+
+// ComponentStatus is the health of a component
+type ComponentStatus struct {
+	Name  string `json:"name"`
+	State State  `json:"state"`
+	Ready bool   `json:"ready"`
+	Live  bool   `json:"live"`
+	// Reason explains why the component isn't ready or is degraded
+	Reason string `json:"reason,omitempty"`
+}
+
+// DetailedStatus is the health of all the components, the agent being ready, or live, when all of them are
+type DetailedStatus struct {
+	Ready      bool              `json:"ready"`
+	Live       bool              `json:"live"`
+	Components []ComponentStatus `json:"components"`
+}
+
+// registry holds the states reported by the components
+type registry struct {
+	sync.RWMutex
+	components map[string]ComponentStatus
+	watchers   map[chan struct{}]struct{}
+}
+
+var componentRegistry = newRegistry()
+
+func newRegistry() *registry {
+	return &registry{
+		components: make(map[string]ComponentStatus),
+		watchers:   make(map[chan struct{}]struct{}),
+	}
+}
+
+func (r *registry) setState(name string, state State, reason string) {
+	r.Lock()
+	defer r.Unlock()
+
+	status := ComponentStatus{
+		Name:   name,
+		State:  state,
+		Ready:  state.Ready(),
+		Live:   state.Live(),
+		Reason: reason,
+	}
+	if current, found := r.components[name]; found && current == status {
+		return
+	}
+	r.components[name] = status
+	r.notify()
+}
+
+func (r *registry) remove(name string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, found := r.components[name]; !found {
+		return
+	}
+	delete(r.components, name)
+	r.notify()
+}
+
+func (r *registry) getComponents() []ComponentStatus {
+	r.RLock()
+	defer r.RUnlock()
+
+	components := make([]ComponentStatus, 0, len(r.components))
+	for _, status := range r.components {
+		components = append(components, status)
+	}
+	return components
+}
+
+// notify wakes the watchers up, it must be called with the lock held
+func (r *registry) notify() {
+	for watcher := range r.watchers {
+		select {
+		case watcher <- struct{}{}:
+		default:
+			// the watcher hasn't consumed the previous notification yet
+		}
+	}
+}
+
+// changed notifies the watchers of a change of the health of the components of the catalogs
+func (r *registry) changed() {
+	r.Lock()
+	defer r.Unlock()
+	r.notify()
+}
+
+func (r *registry) watch() (<-chan struct{}, func()) {
+	r.Lock()
+	defer r.Unlock()
+
+	watcher := make(chan struct{}, 1)
+	r.watchers[watcher] = struct{}{}
+
+	return watcher, func() {
+		r.Lock()
+		defer r.Unlock()
+		delete(r.watchers, watcher)
+	}
+}
+
+// SetState reports the state of a component, along with the reason why it isn't ready or is degraded
+func SetState(name string, state State, reason string) {
+	componentRegistry.setState(name, state, reason)
+}
+
+// RemoveState removes a component whose state was reported with SetState
+func RemoveState(name string) {
+	componentRegistry.remove(name)
+}
+
+// Watch returns a channel notified when the health of a component changes, and the function to call to stop
+// watching. The notifications are coalesced, GetDetailed returns the latest health.
+func Watch() (<-chan struct{}, func()) {
+	return componentRegistry.watch()
+}
+
+// mergeComponent adds the health of a component to the components, the least healthy state being kept
+// when a component is reported several times, and the latest reason for a same state
+func mergeComponent(components map[string]ComponentStatus, name string, state State, reason string) {
+	if current, found := components[name]; found && current.State.severity() > state.severity() {
+		return
+	}
+	components[name] = ComponentStatus{
+		Name:   name,
+		State:  state,
+		Ready:  state.Ready(),
+		Live:   state.Live(),
+		Reason: reason,
+	}
+}
+
+// GetDetailed returns the health of the components reporting their state and of the ones registered for the
+// readiness and liveness checks
+func GetDetailed() DetailedStatus {
+	components := make(map[string]ComponentStatus)
+
+	live := readinessAndLivenessCatalog.getStatus()
+	for _, name := range live.Healthy {
+		mergeComponent(components, name, StateReady, "")
+	}
+	for _, name := range live.Unhealthy {
+		mergeComponent(components, name, StateFailed, "not responding to the health checks")
+	}
+
+	ready := readinessOnlyCatalog.getStatus()
+	for _, name := range ready.Healthy {
+		mergeComponent(components, name, StateReady, "")
+	}
+	for _, name := range ready.Unhealthy {
+		mergeComponent(components, name, StateNotReady, "not ready yet")
+	}
+
+	for _, status := range componentRegistry.getComponents() {
+		mergeComponent(components, status.Name, status.State, status.Reason)
+	}
+
+	detailed := DetailedStatus{
+		Ready:      true,
+		Live:       true,
+		Components: make([]ComponentStatus, 0, len(components)),
+	}
+	for _, status := range components {
+		detailed.Ready = detailed.Ready && status.Ready
+		detailed.Live = detailed.Live && status.Live
+		detailed.Components = append(detailed.Components, status)
+	}
+	sort.Slice(detailed.Components, func(i, j int) bool {
+		return detailed.Components[i].Name < detailed.Components[j].Name
+	})
+
+	return detailed
+}
+
+// mergeRegistry adds the components reporting their state to the status, the components being healthy
+// when isHealthy returns true
+func mergeRegistry(status Status, isHealthy func(ComponentStatus) bool) Status {
+	for _, component := range componentRegistry.getComponents() {
+		if isHealthy(component) {
+			if !contains(status.Healthy, component.Name) && !contains(status.Unhealthy, component.Name) {
+				status.Healthy = append(status.Healthy, component.Name)
+			}
+			continue
+		}
+
+		healthy := make([]string, 0, len(status.Healthy))
+		for _, name := range status.Healthy {
+			if name != component.Name {
+				healthy = append(healthy, name)
+			}
+		}
+		status.Healthy = healthy
+		if !contains(status.Unhealthy, component.Name) {
+			status.Unhealthy = append(status.Unhealthy, component.Name)
+		}
+	}
+	return status
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
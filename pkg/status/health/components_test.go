@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package health
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resetGlobals replaces the global catalogs and registry until the end of the test
+func resetGlobals(t *testing.T) {
+	liveCatalog, readyCatalog, registry := readinessAndLivenessCatalog, readinessOnlyCatalog, componentRegistry
+	readinessAndLivenessCatalog, readinessOnlyCatalog, componentRegistry = newCatalog(), newCatalog(), newRegistry()
+	t.Cleanup(func() {
+		readinessAndLivenessCatalog, readinessOnlyCatalog, componentRegistry = liveCatalog, readyCatalog, registry
+	})
+}
+
+func TestStates(t *testing.T) {
+	for _, tc := range []struct {
+		state State
+		ready bool
+		live  bool
+	}{
+		{StateReady, true, true},
+		{StateDegraded, true, true},
+		{StateNotReady, false, true},
+		{StateFailed, false, false},
+	} {
+		assert.Equal(t, tc.ready, tc.state.Ready(), "%s", tc.state)
+		assert.Equal(t, tc.live, tc.state.Live(), "%s", tc.state)
+	}
+}
+
+func TestGetDetailed(t *testing.T) {
+	resetGlobals(t)
+
+	detailed := GetDetailed()
+	assert.True(t, detailed.Ready)
+	assert.True(t, detailed.Live)
+	assert.Empty(t, detailed.Components)
+
+	SetState("forwarder", StateDegraded, "unable to validate the API keys")
+	SetState("logs-agent", StateReady, "")
+	detailed = GetDetailed()
+	assert.True(t, detailed.Ready)
+	assert.True(t, detailed.Live)
+	assert.Equal(t, []ComponentStatus{
+		{Name: "forwarder", State: StateDegraded, Ready: true, Live: true, Reason: "unable to validate the API keys"},
+		{Name: "logs-agent", State: StateReady, Ready: true, Live: true},
+	}, detailed.Components)
+
+	SetState("forwarder", StateNotReady, "no valid API key")
+	detailed = GetDetailed()
+	assert.False(t, detailed.Ready)
+	assert.True(t, detailed.Live)
+	assert.Equal(t, "no valid API key", detailed.Components[0].Reason)
+
+	RemoveState("forwarder")
+	detailed = GetDetailed()
+	assert.True(t, detailed.Ready)
+	require.Len(t, detailed.Components, 1)
+	assert.Equal(t, "logs-agent", detailed.Components[0].Name)
+}
+
+func TestGetDetailedMergesCatalogs(t *testing.T) {
+	resetGlobals(t)
+
+	readinessAndLivenessCatalog.register("aggregator")
+	readinessOnlyCatalog.register("forwarder")
+
+	// the components haven't answered the health checks yet
+	detailed := GetDetailed()
+	assert.False(t, detailed.Ready)
+	assert.False(t, detailed.Live)
+
+	components := make(map[string]ComponentStatus)
+	for _, component := range detailed.Components {
+		components[component.Name] = component
+	}
+	assert.Equal(t, StateReady, components["healthcheck"].State)
+	assert.Equal(t, StateFailed, components["aggregator"].State)
+	assert.Equal(t, StateNotReady, components["forwarder"].State)
+
+	// the least healthy state is kept, with the reason reported by the component
+	SetState("forwarder", StateNotReady, "no valid API key")
+	SetState("aggregator", StateDegraded, "unable to flush the series")
+	detailed = GetDetailed()
+	for _, component := range detailed.Components {
+		components[component.Name] = component
+	}
+	assert.Equal(t, StateFailed, components["aggregator"].State)
+	assert.Equal(t, "not responding to the health checks", components["aggregator"].Reason)
+	assert.Equal(t, StateNotReady, components["forwarder"].State)
+	assert.Equal(t, "no valid API key", components["forwarder"].Reason)
+}
+
+func TestGetReadyAndLiveWithStates(t *testing.T) {
+	resetGlobals(t)
+
+	SetState("system-probe", StateDegraded, "system-probe unreachable")
+	SetState("forwarder", StateNotReady, "no valid API key")
+	SetState("logs-agent", StateFailed, "stuck")
+
+	live := GetLive()
+	assert.ElementsMatch(t, []string{"system-probe", "forwarder"}, live.Healthy)
+	assert.ElementsMatch(t, []string{"logs-agent"}, live.Unhealthy)
+
+	ready := GetReady()
+	assert.ElementsMatch(t, []string{"system-probe"}, ready.Healthy)
+	assert.ElementsMatch(t, []string{"forwarder", "logs-agent"}, ready.Unhealthy)
+}
+
+func TestWatch(t *testing.T) {
+	resetGlobals(t)
+
+	changes, stop := Watch()
+
+	SetState("forwarder", StateReady, "")
+	SetState("forwarder", StateDegraded, "unable to validate the API keys")
+	// the notifications are coalesced
+	<-changes
+	select {
+	case <-changes:
+		t.Fatal("unexpected notification")
+	default:
+	}
+
+	// setting the same state again isn't a change
+	SetState("forwarder", StateDegraded, "unable to validate the API keys")
+	select {
+	case <-changes:
+		t.Fatal("unexpected notification")
+	default:
+	}
+
+	RemoveState("forwarder")
+	<-changes
+
+	stop()
+	SetState("forwarder", StateReady, "")
+	select {
+	case <-changes:
+		t.Fatal("unexpected notification after the watch stopped")
+	default:
+	}
+}
+
+func TestWatchCatalogChanges(t *testing.T) {
+	resetGlobals(t)
+
+	changes, stop := Watch()
+	defer stop()
+
+	token := readinessAndLivenessCatalog.register("aggregator")
+	<-token.C
+	readinessAndLivenessCatalog.pingComponents()
+	<-changes
+
+	// the component stays healthy
+	<-token.C
+	readinessAndLivenessCatalog.pingComponents()
+	select {
+	case <-changes:
+		t.Fatal("unexpected notification")
+	default:
+	}
+}
@@ -31,18 +31,19 @@ func Deregister(handle *Handle) error {
 	return readinessOnlyCatalog.deregister(handle)
 }
 
-// GetLive returns health of all components registered for liveness
+// GetLive returns health of all components registered for liveness, and of the components reporting their state
 func GetLive() Status {
-	return readinessAndLivenessCatalog.getStatus()
+	return mergeRegistry(readinessAndLivenessCatalog.getStatus(), func(c ComponentStatus) bool { return c.Live })
 }
 
-// GetReady returns health of all components registered for both readiness and liveness
+// GetReady returns health of all components registered for both readiness and liveness, and of the components
+// reporting their state
 func GetReady() (ret Status) {
 	liveStatus := readinessAndLivenessCatalog.getStatus()
 	readyStatus := readinessOnlyCatalog.getStatus()
 	ret.Healthy = append(liveStatus.Healthy, readyStatus.Healthy...)
 	ret.Unhealthy = append(liveStatus.Unhealthy, readyStatus.Unhealthy...)
-	return
+	return mergeRegistry(ret, func(c ComponentStatus) bool { return c.Ready })
 }
 
 // getStatusNonBlocking allows to query the health status of the agent
@@ -68,6 +69,21 @@ func GetLiveNonBlocking() (Status, error) {
 	return getStatusNonBlocking(GetLive)
 }
 
+// GetDetailedNonBlocking returns the health of all the components with a 500ms timeout
+func GetDetailedNonBlocking() (DetailedStatus, error) {
+	ch := make(chan DetailedStatus, 1)
+	go func() {
+		ch <- GetDetailed()
+	}()
+
+	select {
+	case status := <-ch:
+		return status, nil
+	case <-time.After(500 * time.Millisecond):
+		return DetailedStatus{}, errors.New("timeout when getting health status")
+	}
+}
+
 // GetReadyNonBlocking returns the health of all components registered for both readiness and liveness with a 500ms timeout
 func GetReadyNonBlocking() (Status, error) {
 	return getStatusNonBlocking(GetReady)
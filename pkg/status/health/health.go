@@ -91,15 +91,21 @@ func (c *catalog) run() {
 func (c *catalog) pingComponents() bool {
 	c.Lock()
 	defer c.Unlock()
+	changed := false
 	for _, component := range c.components {
+		healthy := false
 		select {
 		case component.healthChan <- struct{}{}:
-			component.healthy = true
+			healthy = true
 		default:
-			component.healthy = false
 		}
+		changed = changed || healthy != component.healthy
+		component.healthy = healthy
 	}
 	c.latestRun = time.Now()
+	if changed {
+		componentRegistry.changed()
+	}
 	return len(c.components) == 0
 }
 
@@ -22,8 +22,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs"
 	"github.com/DataDog/datadog-agent/pkg/metadata/host"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/DataDog/datadog-agent/pkg/snmp/traps"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/clocksync"
 	"github.com/DataDog/datadog-agent/pkg/util/flavor"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -50,12 +52,16 @@ func GetStatus() (map[string]interface{}, error) {
 	pythonVersion := host.GetPythonVersion()
 	stats["python_version"] = strings.Split(pythonVersion, " ")[0]
 	stats["hostinfo"] = host.GetStatusInformation()
+	stats["kernelLockdown"] = config.GetKernelLockdownMode()
+	stats["secureBoot"] = config.IsSecureBootEnabled()
 
 	stats["JMXStatus"] = GetJMXStatus()
 	stats["JMXStartupError"] = GetJMXStartupError()
 
 	stats["logsStats"] = logs.GetStatus()
 
+	stats["secretsStatus"] = secrets.GetStatus()
+
 	endpointsInfos, err := getEndpointsInfos()
 	if endpointsInfos != nil && err == nil {
 		stats["endpointsInfos"] = endpointsInfos
@@ -361,6 +367,8 @@ func expvarStats(stats map[string]interface{}) (map[string]interface{}, error) {
 		stats["ntpOffset"], err = strconv.ParseFloat(expvar.Get("ntpOffset").String(), 64)
 	}
 
+	stats["clockSkew"] = clocksync.GetStatus()
+
 	inventories := expvar.Get("inventories")
 	var inventoriesStats map[string]interface{}
 	if inventories != nil {
@@ -398,6 +406,16 @@ func expvarStats(stats map[string]interface{}) (map[string]interface{}, error) {
 
 	stats["snmpTrapsStats"] = traps.GetStatus()
 
+	apiVar := expvar.Get("api")
+	if apiVar != nil {
+		apiStatsJSON := []byte(apiVar.String())
+		apiStats := make(map[string]interface{})
+		json.Unmarshal(apiStatsJSON, &apiStats) //nolint:errcheck
+		stats["apiStats"] = apiStats
+	} else {
+		stats["apiStats"] = nil
+	}
+
 	complianceVar := expvar.Get("compliance")
 	if complianceVar != nil {
 		complianceStatusJSON := []byte(complianceVar.String())
@@ -17,8 +17,16 @@ var DefaultCatalog = make(Catalog)
 // CollectorPriorities holds collector priorities
 var CollectorPriorities = make(map[string]CollectorPriority)
 
+// APISource is the source of the tags set on the entities through the agent API
+const APISource = "api"
+
 // registerCollector is to be called by collectors to be added to the default catalog
 func registerCollector(name string, c CollectorFactory, p CollectorPriority) {
 	DefaultCatalog[name] = c
 	CollectorPriorities[name] = p
 }
+
+func init() {
+	// the tags set through the agent API don't take precedence over the collected ones
+	CollectorPriorities[APISource] = NodeRuntime
+}
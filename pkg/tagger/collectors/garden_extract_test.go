@@ -1,6 +1,7 @@
 package collectors
 
 import (
+	"context"
 	"testing"
 
 	"code.cloudfoundry.org/garden"
@@ -108,6 +109,26 @@ func (fakeDCAClient) GetEndpointsCheckConfigs(nodeName string) (types.ConfigResp
 	panic("implement me")
 }
 
+func (fakeDCAClient) WatchClusterCheckConfigs(ctx context.Context, nodeName string) (<-chan types.ConfigResponse, error) {
+	panic("implement me")
+}
+
+func (fakeDCAClient) WatchEndpointsCheckConfigs(ctx context.Context, nodeName string) (<-chan types.ConfigResponse, error) {
+	panic("implement me")
+}
+
+func (fakeDCAClient) WatchPodsMetadataForNode(ctx context.Context, nodeName string) (<-chan apiv1.NamespacesPodsStringsSet, error) {
+	panic("implement me")
+}
+
+func (fakeDCAClient) WatchSecurityPolicies(ctx context.Context) (<-chan map[string][]byte, error) {
+	panic("implement me")
+}
+
+func (fakeDCAClient) GetServiceNamesByIP() (map[string][]string, error) {
+	panic("implement me")
+}
+
 // Unused GardenUtilInterface methodes
 func (fakeGardenUtil) ListContainers() ([]*containers.Container, error) {
 	panic("implement me")
@@ -8,6 +8,7 @@
 package collectors
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
@@ -79,10 +80,30 @@ func (f *FakeDCAClient) GetEndpointsCheckConfigs(nodeName string) (types.ConfigR
 	return f.EndpointsCheckConfigs, f.EndpointsCheckConfigsErr
 }
 
+func (f *FakeDCAClient) WatchClusterCheckConfigs(ctx context.Context, nodeName string) (<-chan types.ConfigResponse, error) {
+	panic("implement me")
+}
+
+func (f *FakeDCAClient) WatchEndpointsCheckConfigs(ctx context.Context, nodeName string) (<-chan types.ConfigResponse, error) {
+	panic("implement me")
+}
+
+func (f *FakeDCAClient) WatchPodsMetadataForNode(ctx context.Context, nodeName string) (<-chan apiv1.NamespacesPodsStringsSet, error) {
+	panic("implement me")
+}
+
+func (f *FakeDCAClient) WatchSecurityPolicies(ctx context.Context) (<-chan map[string][]byte, error) {
+	panic("implement me")
+}
+
 func (f *FakeDCAClient) GetCFAppsMetadataForNode(nodename string) (map[string][]string, error) {
 	panic("implement me")
 }
 
+func (f *FakeDCAClient) GetServiceNamesByIP() (map[string][]string, error) {
+	panic("implement me")
+}
+
 func TestKubeMetadataCollector_getMetadaNames(t *testing.T) {
 	type fields struct {
 		dcaClient           clusteragent.DCAClientInterface
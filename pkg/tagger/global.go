@@ -35,12 +35,12 @@ func Init() {
 		checkCard := config.Datadog.GetString("checks_tag_cardinality")
 		dsdCard := config.Datadog.GetString("dogstatsd_tag_cardinality")
 
-		ChecksCardinality, err = stringToTagCardinality(checkCard)
+		ChecksCardinality, err = StringToTagCardinality(checkCard)
 		if err != nil {
 			log.Warnf("failed to parse check tag cardinality, defaulting to low. Error: %s", err)
 			ChecksCardinality = collectors.LowCardinality
 		}
-		DogstatsdCardinality, err = stringToTagCardinality(dsdCard)
+		DogstatsdCardinality, err = StringToTagCardinality(dsdCard)
 		if err != nil {
 			log.Warnf("failed to parse dogstatsd tag cardinality, defaulting to low. Error: %s", err)
 			DogstatsdCardinality = collectors.LowCardinality
@@ -90,6 +90,21 @@ func List(cardinality collectors.TagCardinality) response.TaggerListResponse {
 	return defaultTagger.List(cardinality)
 }
 
+// ListWithFilter lists the entities of the defaultTagger matching the given filter
+func ListWithFilter(cardinality collectors.TagCardinality, filter *ListFilter) response.TaggerListResponse {
+	return defaultTagger.ListWithFilter(cardinality, filter)
+}
+
+// SetEntityTags sets the tags of an entity in the defaultTagger through the agent API
+func SetEntityTags(entity string, low, orchestrator, high, standard []string) error {
+	return defaultTagger.SetEntityTags(entity, low, orchestrator, high, standard)
+}
+
+// DeleteEntityTags removes the tags set on an entity in the defaultTagger through the agent API
+func DeleteEntityTags(entity string) error {
+	return defaultTagger.DeleteEntityTags(entity)
+}
+
 // GetEntityHash returns the hash for the tags associated with the given entity
 func GetEntityHash(entity string) string {
 	return defaultTagger.GetEntityHash(entity)
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tagger
+
+import (
+	"strings"
+)
+
+// ListFilter selects the entities returned by ListWithFilter. An empty
+// field doesn't filter anything, the entities have to match all the
+// non-empty fields.
+type ListFilter struct {
+	// Prefixes keeps the entities whose ID starts with one of the given
+	// prefixes, ex: container_id, kubernetes_pod_uid
+	Prefixes []string
+	// Entities keeps the entities with one of the given IDs
+	Entities []string
+	// Sources keeps the entities with tags from one of the given sources
+	Sources []string
+}
+
+// MatchEntity returns whether the ID of the entity matches the prefixes and the
+// IDs of the filter, the sources aren't checked
+func (f *ListFilter) MatchEntity(entityID string) bool {
+	if f == nil {
+		return true
+	}
+	filter := *f
+	filter.Sources = nil
+	return filter.match(entityID, nil)
+}
+
+func (f *ListFilter) match(entityID string, sources []string) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.Prefixes) > 0 && !matchAny(f.Prefixes, func(prefix string) bool {
+		return strings.HasPrefix(entityID, prefix)
+	}) {
+		return false
+	}
+
+	if len(f.Entities) > 0 && !matchAny(f.Entities, func(entity string) bool {
+		return entity == entityID
+	}) {
+		return false
+	}
+
+	if len(f.Sources) > 0 && !matchAny(f.Sources, func(source string) bool {
+		for _, s := range sources {
+			if s == source {
+				return true
+			}
+		}
+		return false
+	}) {
+		return false
+	}
+
+	return true
+}
+
+func matchAny(values []string, match func(string) bool) bool {
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
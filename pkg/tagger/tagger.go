@@ -7,6 +7,7 @@ package tagger
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -303,6 +304,12 @@ func (t *Tagger) Standard(entity string) ([]string, error) {
 
 // List the content of the tagger
 func (t *Tagger) List(cardinality collectors.TagCardinality) response.TaggerListResponse {
+	return t.ListWithFilter(cardinality, nil)
+}
+
+// ListWithFilter lists the entities of the tagger matching the given filter,
+// all the entities are listed if the filter is nil
+func (t *Tagger) ListWithFilter(cardinality collectors.TagCardinality, filter *ListFilter) response.TaggerListResponse {
 	r := response.TaggerListResponse{
 		Entities: make(map[string]response.TaggerListEntity),
 	}
@@ -312,6 +319,9 @@ func (t *Tagger) List(cardinality collectors.TagCardinality) response.TaggerList
 	for entityID, et := range t.tagStore.store {
 		entity := response.TaggerListEntity{}
 		tags, sources, _ := et.get(cardinality)
+		if !filter.match(entityID, sources) {
+			continue
+		}
 		entity.Tags = copyArray(tags)
 		entity.Sources = copyArray(sources)
 		r.Entities[entityID] = entity
@@ -332,6 +342,48 @@ func (t *Tagger) Unsubscribe(ch chan []EntityEvent) {
 	t.tagStore.unsubscribe(ch)
 }
 
+// SetEntityTags sets the tags of an entity through the agent API, replacing the
+// ones previously set through it. The collected tags of the entity are kept.
+func (t *Tagger) SetEntityTags(entity string, low, orchestrator, high, standard []string) error {
+	if err := validateEntityID(entity); err != nil {
+		return err
+	}
+
+	t.tagStore.processTagInfo([]*collectors.TagInfo{{
+		Source:               collectors.APISource,
+		Entity:               entity,
+		LowCardTags:          copyArray(low),
+		OrchestratorCardTags: copyArray(orchestrator),
+		HighCardTags:         copyArray(high),
+		StandardTags:         copyArray(standard),
+	}})
+	return nil
+}
+
+// DeleteEntityTags removes the tags set on an entity through the agent API, they
+// are removed from the store when it's next pruned like the deleted entities
+func (t *Tagger) DeleteEntityTags(entity string) error {
+	if err := validateEntityID(entity); err != nil {
+		return err
+	}
+
+	t.tagStore.processTagInfo([]*collectors.TagInfo{{
+		Source:       collectors.APISource,
+		Entity:       entity,
+		DeleteEntity: true,
+	}})
+	return nil
+}
+
+// validateEntityID checks that an entity ID is in the prefix://uid format
+func validateEntityID(entity string) error {
+	parts := strings.SplitN(entity, "://", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("invalid entity ID %q, expected prefix://uid", entity)
+	}
+	return nil
+}
+
 // copyArray makes sure the tagger does not return internal slices
 // that could be modified by others, by explicitly copying the slice
 // contents to a new slice. As strings are references, the size of
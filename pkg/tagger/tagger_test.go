@@ -164,6 +164,94 @@ func TestFetchAllCached(t *testing.T) {
 	puller.AssertNotCalled(t, "Fetch", "entity_name")
 }
 
+func TestListWithFilter(t *testing.T) {
+	tagger := newTagger()
+
+	tagger.tagStore.processTagInfo([]*collectors.TagInfo{
+		{
+			Entity:      "container_id://1",
+			Source:      "docker",
+			LowCardTags: []string{"image_name:redis"},
+		},
+		{
+			Entity:      "container_id://2",
+			Source:      "kubelet",
+			LowCardTags: []string{"image_name:nginx"},
+		},
+		{
+			Entity:      "kubernetes_pod_uid://3",
+			Source:      "kubelet",
+			LowCardTags: []string{"kube_namespace:default"},
+		},
+	})
+
+	entityIDs := func(filter *ListFilter) []string {
+		var ids []string
+		for id := range tagger.ListWithFilter(collectors.LowCardinality, filter).Entities {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, []string{"container_id://1", "container_id://2", "kubernetes_pod_uid://3"}, entityIDs(nil))
+	assert.ElementsMatch(t, []string{"container_id://1", "container_id://2"}, entityIDs(&ListFilter{Prefixes: []string{"container_id://"}}))
+	assert.ElementsMatch(t, []string{"container_id://2", "kubernetes_pod_uid://3"}, entityIDs(&ListFilter{Sources: []string{"kubelet"}}))
+	assert.ElementsMatch(t, []string{"container_id://2"}, entityIDs(&ListFilter{Prefixes: []string{"container_id://"}, Sources: []string{"kubelet"}}))
+	assert.ElementsMatch(t, []string{"kubernetes_pod_uid://3"}, entityIDs(&ListFilter{Entities: []string{"kubernetes_pod_uid://3", "unknown"}}))
+	assert.Empty(t, entityIDs(&ListFilter{Sources: []string{"ecs"}}))
+
+	// the sources are ignored when matching the entity IDs only
+	assert.True(t, (&ListFilter{Prefixes: []string{"container_id://"}, Sources: []string{"ecs"}}).MatchEntity("container_id://1"))
+	assert.False(t, (&ListFilter{Entities: []string{"container_id://2"}}).MatchEntity("container_id://1"))
+}
+
+func TestSetEntityTags(t *testing.T) {
+	tagger := newTagger()
+
+	tagger.tagStore.processTagInfo([]*collectors.TagInfo{
+		{
+			Entity:      "container_id://1",
+			Source:      "docker",
+			LowCardTags: []string{"image_name:redis"},
+		},
+	})
+
+	err := tagger.SetEntityTags("container_id://1", []string{"team:a"}, nil, []string{"request:1"}, nil)
+	assert.NoError(t, err)
+	err = tagger.SetEntityTags("container_id://2", []string{"team:b"}, nil, nil, []string{"service:web"})
+	assert.NoError(t, err)
+
+	tags, err := tagger.Tag("container_id://1", collectors.HighCardinality)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"image_name:redis", "team:a", "request:1"}, tags)
+
+	standard, err := tagger.Standard("container_id://2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"service:web"}, standard)
+
+	// the tags set through the API replace the previous ones
+	err = tagger.SetEntityTags("container_id://1", []string{"team:c"}, nil, nil, nil)
+	assert.NoError(t, err)
+	tags, err = tagger.Tag("container_id://1", collectors.HighCardinality)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"image_name:redis", "team:c"}, tags)
+
+	// the collected tags are kept when the API ones are deleted
+	assert.NoError(t, tagger.DeleteEntityTags("container_id://1"))
+	assert.NoError(t, tagger.DeleteEntityTags("container_id://2"))
+	assert.NoError(t, tagger.tagStore.prune())
+
+	list := tagger.List(collectors.HighCardinality)
+	assert.Len(t, list.Entities, 1)
+	assert.ElementsMatch(t, []string{"image_name:redis"}, list.Entities["container_id://1"].Tags)
+	assert.Equal(t, []string{"docker"}, list.Entities["container_id://1"].Sources)
+
+	for _, entity := range []string{"", "container_id", "container_id://", "://1"} {
+		assert.Error(t, tagger.SetEntityTags(entity, []string{"team:a"}, nil, nil, nil), entity)
+		assert.Error(t, tagger.DeleteEntityTags(entity), entity)
+	}
+}
+
 func TestFetchOneCached(t *testing.T) {
 	catalog := collectors.Catalog{
 		"stream":  NewDummyStreamer,
@@ -14,9 +14,9 @@ const (
 	unknownCardinalityString      = "unknown"
 )
 
-// stringToTagCardinality extracts a TagCardinality from a string.
+// StringToTagCardinality extracts a TagCardinality from a string.
 // In case of failure to parse, returns an error and defaults to Low.
-func stringToTagCardinality(c string) (collectors.TagCardinality, error) {
+func StringToTagCardinality(c string) (collectors.TagCardinality, error) {
 	switch strings.ToLower(c) {
 	case highCardinalityString:
 		return collectors.HighCardinality, nil
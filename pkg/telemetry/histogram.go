@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package telemetry
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Histogram tracks the distribution of the values of one health metric of the Agent.
+type Histogram interface {
+	// Observe records the value for the given tags.
+	Observe(value float64, tagsValue ...string)
+	// Delete deletes the values of the Histogram with the given tags.
+	Delete(tagsValue ...string)
+}
+
+// NewHistogram creates a Histogram with default options for telemetry purpose.
+// Current implementation used: Prometheus Histogram
+func NewHistogram(subsystem, name string, tags []string, help string, buckets []float64) Histogram {
+	return NewHistogramWithOpts(subsystem, name, tags, help, buckets, DefaultOptions)
+}
+
+// NewHistogramWithOpts creates a Histogram with the given options for telemetry purpose.
+// See NewHistogram()
+func NewHistogramWithOpts(subsystem, name string, tags []string, help string, buckets []float64, opts Options) Histogram {
+	// subsystem is optional
+	if subsystem != "" && !opts.NoDoubleUnderscoreSep {
+		// Prefix metrics with a _, prometheus will add a second _
+		// It will create metrics with a custom separator and
+		// will let us replace it to a dot later in the process.
+		name = fmt.Sprintf("_%s", name)
+	}
+
+	h := &promHistogram{
+		ph: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Subsystem: subsystem,
+				Name:      name,
+				Help:      help,
+				Buckets:   buckets,
+			},
+			tags,
+		),
+	}
+	telemetryRegistry.MustRegister(h.ph)
+	return h
+}
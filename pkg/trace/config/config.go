@@ -19,6 +19,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/bundle"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -232,7 +233,7 @@ func (c *AgentConfig) NewHTTPTransport() *http.Transport {
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
 	}
-	if p := coreconfig.GetProxies(); p != nil {
+	if p := coreconfig.GetProductProxies(coreconfig.ProxyProductAPM); p != nil {
 		transport.Proxy = httputils.GetProxyTransportFunc(p)
 	}
 	return transport
@@ -260,6 +261,12 @@ func prepareConfig(path string) (*AgentConfig, error) {
 	if _, err := config.Load(); err != nil {
 		return cfg, err
 	}
+	// the bundle is fetched by the core agent
+	if bundle.Enabled(config.Datadog) {
+		if _, err := bundle.LoadConfig(false, false); err != nil {
+			return cfg, err
+		}
+	}
 	cfg.ConfigPath = path
 	return cfg, nil
 }
@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package clocksync tracks the skew of the local clock, measured by the NTP check and from the
+// Date header of the responses of the intake, so that payloads with bad timestamps don't get
+// silently rejected.
+package clocksync
+
+import (
+	"expvar"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// SourceNTP is the source of the offsets measured by the NTP check
+	SourceNTP = "ntp"
+	// SourceIntake is the source of the offsets measured from the Date header of the intake responses
+	SourceIntake = "intake"
+
+	// OffsetHeader is the header annotating the outgoing payloads with the measured offset, in seconds
+	OffsetHeader = "DD-Agent-Clock-Offset"
+
+	// The intake rejects the points more than 10 minutes in the future and more than 1 hour in the past.
+	// A negative offset means that the local clock is in the future.
+	// See https://docs.datadoghq.com/developers/metrics/#submitting-metrics
+	futureThreshold = -10 * time.Minute
+	pastThreshold   = time.Hour
+
+	// maxSampleAge is the age after which an offset isn't trusted anymore
+	maxSampleAge = time.Hour
+	// dateResolution is the resolution of the Date header
+	dateResolution = time.Second
+)
+
+// Sample is an offset measured by a source, positive when the local clock is late
+type Sample struct {
+	Offset     time.Duration
+	MeasuredAt time.Time
+}
+
+// Tracker holds the latest offset measured by each source
+type Tracker struct {
+	mu      sync.RWMutex
+	samples map[string]Sample
+	skewed  bool
+	now     func() time.Time
+}
+
+// NewTracker returns a new Tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		samples: make(map[string]Sample),
+		now:     time.Now,
+	}
+}
+
+// Record records an offset measured by the given source
+func (t *Tracker) Record(source string, offset time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.samples[source] = Sample{Offset: offset, MeasuredAt: t.now()}
+
+	// only log the changes of state, the intake offset is measured for every payload
+	current, _ := t.offset()
+	skewed := IsSkewed(current)
+	if skewed && !t.skewed {
+		log.Warnf("The clock of this host is off by %s, Datadog may ignore the data sent by this Agent", current)
+	} else if !skewed && t.skewed {
+		log.Infof("The clock of this host is back in sync")
+	}
+	t.skewed = skewed
+}
+
+// RecordHTTPDate records the offset of the local clock from the Date header of a response to a request
+// sent at sent, the response being received at received
+func (t *Tracker) RecordHTTPDate(date string, sent, received time.Time) error {
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return err
+	}
+
+	// the Date header is truncated to the second and the server handled the request in between
+	serverTime = serverTime.Add(dateResolution / 2)
+	localTime := sent.Add(received.Sub(sent) / 2)
+	offset := serverTime.Sub(localTime)
+
+	// offsets within the resolution of the header are noise
+	if offset > -dateResolution && offset < dateResolution {
+		offset = 0
+	}
+
+	t.Record(SourceIntake, offset)
+	return nil
+}
+
+// Offset returns the offset of the local clock, positive when the local clock is late. The NTP check is
+// more accurate than the intake and is preferred when it measured the offset recently.
+func (t *Tracker) Offset() (time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.offset()
+}
+
+func (t *Tracker) offset() (time.Duration, bool) {
+	now := t.now()
+	for _, source := range []string{SourceNTP, SourceIntake} {
+		if sample, ok := t.samples[source]; ok && now.Sub(sample.MeasuredAt) < maxSampleAge {
+			return sample.Offset, true
+		}
+	}
+	return 0, false
+}
+
+// Status returns the offsets measured by each source, in seconds, and whether the clock is skewed
+func (t *Tracker) Status() map[string]interface{} {
+	status := make(map[string]interface{})
+
+	t.mu.RLock()
+	for source, sample := range t.samples {
+		status[source+"Offset"] = sample.Offset.Seconds()
+	}
+	t.mu.RUnlock()
+
+	if offset, ok := t.Offset(); ok {
+		status["offset"] = offset.Seconds()
+		status["skewed"] = IsSkewed(offset)
+	}
+	return status
+}
+
+// IsSkewed returns whether the intake would reject the data timestamped by a clock with the given offset
+func IsSkewed(offset time.Duration) bool {
+	return offset <= futureThreshold || offset >= pastThreshold
+}
+
+var defaultTracker = NewTracker()
+
+func init() {
+	expvar.Publish("clockSkew", expvar.Func(func() interface{} {
+		return GetStatus()
+	}))
+}
+
+// Record records an offset measured by the given source in the default tracker
+func Record(source string, offset time.Duration) {
+	defaultTracker.Record(source, offset)
+}
+
+// RecordHTTPDate records the offset of the local clock from the Date header of a response in the default tracker
+func RecordHTTPDate(date string, sent, received time.Time) error {
+	return defaultTracker.RecordHTTPDate(date, sent, received)
+}
+
+// Offset returns the offset of the local clock measured by the default tracker
+func Offset() (time.Duration, bool) {
+	return defaultTracker.Offset()
+}
+
+// AnnotateHeaders sets the offset measured by the default tracker on the headers of an outgoing payload
+func AnnotateHeaders(headers http.Header) {
+	if offset, ok := defaultTracker.Offset(); ok {
+		headers.Set(OffsetHeader, strconv.FormatFloat(offset.Seconds(), 'f', 3, 64))
+	} else {
+		headers.Del(OffsetHeader)
+	}
+}
+
+// GetStatus returns the status of the default tracker
+func GetStatus() map[string]interface{} {
+	return defaultTracker.Status()
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package clocksync
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordHTTPDate(t *testing.T) {
+	tracker := NewTracker()
+	sent := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	// the local clock is 2 hours late
+	date := sent.Add(2 * time.Hour).Format(http.TimeFormat)
+	require.NoError(t, tracker.RecordHTTPDate(date, sent, sent.Add(200*time.Millisecond)))
+	offset, ok := tracker.Offset()
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Hour+400*time.Millisecond, offset)
+	assert.True(t, IsSkewed(offset))
+
+	// offsets within the resolution of the header are ignored
+	date = sent.Format(http.TimeFormat)
+	require.NoError(t, tracker.RecordHTTPDate(date, sent, sent.Add(200*time.Millisecond)))
+	offset, _ = tracker.Offset()
+	assert.Equal(t, time.Duration(0), offset)
+
+	assert.Error(t, tracker.RecordHTTPDate("not a date", sent, sent))
+}
+
+func TestOffsetSources(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return now }
+
+	_, ok := tracker.Offset()
+	assert.False(t, ok)
+
+	tracker.Record(SourceIntake, 30*time.Second)
+	tracker.Record(SourceNTP, 20*time.Second)
+
+	// the NTP check is preferred
+	offset, ok := tracker.Offset()
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Second, offset)
+
+	// until its offset gets too old
+	now = now.Add(maxSampleAge)
+	tracker.Record(SourceIntake, 40*time.Second)
+	offset, _ = tracker.Offset()
+	assert.Equal(t, 40*time.Second, offset)
+
+	assert.Equal(t, map[string]interface{}{
+		"ntpOffset":    20.0,
+		"intakeOffset": 40.0,
+		"offset":       40.0,
+		"skewed":       false,
+	}, tracker.Status())
+}
+
+func TestIsSkewed(t *testing.T) {
+	assert.False(t, IsSkewed(0))
+	assert.False(t, IsSkewed(-9*time.Minute))
+	assert.True(t, IsSkewed(-10*time.Minute))
+	assert.False(t, IsSkewed(59*time.Minute))
+	assert.True(t, IsSkewed(time.Hour))
+}
@@ -6,6 +6,7 @@
 package clusteragent
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -51,10 +52,16 @@ type DCAClientInterface interface {
 	GetPodsMetadataForNode(nodeName string) (apiv1.NamespacesPodsStringsSet, error)
 	GetKubernetesMetadataNames(nodeName, ns, podName string) ([]string, error)
 	GetCFAppsMetadataForNode(nodename string) (map[string][]string, error)
+	GetServiceNamesByIP() (map[string][]string, error)
 
 	PostClusterCheckStatus(nodeName string, status types.NodeStatus) (types.StatusResponse, error)
 	GetClusterCheckConfigs(nodeName string) (types.ConfigResponse, error)
 	GetEndpointsCheckConfigs(nodeName string) (types.ConfigResponse, error)
+
+	WatchClusterCheckConfigs(ctx context.Context, nodeName string) (<-chan types.ConfigResponse, error)
+	WatchEndpointsCheckConfigs(ctx context.Context, nodeName string) (<-chan types.ConfigResponse, error)
+	WatchPodsMetadataForNode(ctx context.Context, nodeName string) (<-chan apiv1.NamespacesPodsStringsSet, error)
+	WatchSecurityPolicies(ctx context.Context) (<-chan map[string][]byte, error)
 }
 
 // DCAClient is required to query the API of Datadog cluster agent
@@ -303,6 +310,40 @@ func (c *DCAClient) GetCFAppsMetadataForNode(nodename string) (map[string][]stri
 	return tags, err
 }
 
+// GetServiceNamesByIP returns the names of the services, as `<service>.<namespace>`, of the cluster IPs
+// of the services and of the addresses of their endpoints from the Cluster Agent.
+func (c *DCAClient) GetServiceNamesByIP() (map[string][]string, error) {
+	const dcaServicesIPs = "api/v1/services/ips"
+	var err error
+	var namesByIP map[string][]string
+
+	// https://host:port/api/v1/services/ips
+	rawURL := fmt.Sprintf("%s/%s", c.clusterAgentAPIEndpoint, dcaServicesIPs)
+
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = c.clusterAgentAPIRequestHeaders
+
+	resp, err := c.clusterAgentAPIClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from cluster agent: %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(body, &namesByIP)
+	return namesByIP, err
+}
+
 // GetPodsMetadataForNode queries the datadog cluster agent to get nodeName registered
 // Kubernetes pods metadata.
 func (c *DCAClient) GetPodsMetadataForNode(nodeName string) (apiv1.NamespacesPodsStringsSet, error) {
@@ -86,7 +86,8 @@ func newDummyClusterAgent() (*dummyClusterAgent, error) {
 			},
 		},
 		rawResponses: map[string]string{
-			"/version": `{"Major":0, "Minor":0, "Patch":0, "Pre":"test", "Meta":"test", "Commit":"1337"}`,
+			"/version":             `{"Major":0, "Minor":0, "Patch":0, "Pre":"test", "Meta":"test", "Commit":"1337"}`,
+			"/api/v1/services/ips": `{"10.96.0.10": ["checkout-svc.shop"], "10.0.0.2": ["checkout-svc.shop", "headless.shop"]}`,
 		},
 		token:    config.Datadog.GetString("cluster_agent.auth_token"),
 		requests: make(chan *http.Request, 100),
@@ -560,6 +561,27 @@ func (suite *clusterAgentSuite) TestGetCFAppsMetadataForNode() {
 	}
 }
 
+func (suite *clusterAgentSuite) TestGetServiceNamesByIP() {
+	dca, err := newDummyClusterAgent()
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+
+	ts, p, err := dca.StartTLS()
+	defer ts.Close()
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+
+	mockConfig.Set("cluster_agent.url", fmt.Sprintf("https://127.0.0.1:%d", p))
+
+	ca, err := GetClusterAgentClient()
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+
+	namesByIP, err := ca.GetServiceNamesByIP()
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+	assert.Equal(suite.T(), map[string][]string{
+		"10.96.0.10": {"checkout-svc.shop"},
+		"10.0.0.2":   {"checkout-svc.shop", "headless.shop"},
+	}, namesByIP)
+}
+
 func (suite *clusterAgentSuite) TestGetPodsMetadataForNode() {
 	dca, err := newDummyClusterAgent()
 	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
@@ -69,6 +69,23 @@ func (l *leaderClient) resetURL() {
 	l.leaderURL = ""
 }
 
+// setLeaderHost caches the address of the leader, when
+// it's not learnt from an HTTP redirection
+func (l *leaderClient) setLeaderHost(host string) {
+	serviceURL, err := url.Parse(l.serviceURL)
+	if err != nil {
+		return
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+	newURL := &url.URL{
+		Scheme: serviceURL.Scheme,
+		Host:   host,
+	}
+	l.leaderURL = newURL.String()
+}
+
 // redirected is passed to the http client to cache leader
 // redirections for future queries.
 func (l *leaderClient) redirected(req *http.Request, via []*http.Request) error {
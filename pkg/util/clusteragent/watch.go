@@ -0,0 +1,233 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package clusteragent
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/url"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/DataDog/datadog-agent/cmd/cluster-agent/api/pb"
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// LeaderAddressMetadataKey is the key of the gRPC trailer holding the address of the
+// leading cluster agent, set by the followers when they refuse a watch request
+const LeaderAddressMetadataKey = "dca-leader-address"
+
+// bearerCredentials passes the cluster agent token along with the gRPC requests
+type bearerCredentials string
+
+func (b bearerCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": string(b)}, nil
+}
+
+func (b bearerCredentials) RequireTransportSecurity() bool {
+	return true
+}
+
+// configsStream is implemented by the streams of the Watch*Checks methods
+type configsStream interface {
+	Recv() (*pb.ConfigsResponse, error)
+	grpc.ClientStream
+}
+
+// WatchClusterCheckConfigs streams the cluster checks configurations dispatched to a node.
+// The current configurations are received first, then every time they change. The channel
+// is closed when the stream ends, the caller being expected to watch again.
+func (c *DCAClient) WatchClusterCheckConfigs(ctx context.Context, nodeName string) (<-chan types.ConfigResponse, error) {
+	return c.watchConfigs(ctx, func(client pb.ClusterAgentSecureClient, opts ...grpc.CallOption) (configsStream, error) {
+		return client.WatchClusterChecks(ctx, &pb.WatchConfigsRequest{NodeName: nodeName}, opts...)
+	})
+}
+
+// WatchEndpointsCheckConfigs streams the endpoints checks configurations of a node,
+// with the same semantics as WatchClusterCheckConfigs
+func (c *DCAClient) WatchEndpointsCheckConfigs(ctx context.Context, nodeName string) (<-chan types.ConfigResponse, error) {
+	return c.watchConfigs(ctx, func(client pb.ClusterAgentSecureClient, opts ...grpc.CallOption) (configsStream, error) {
+		return client.WatchEndpointsChecks(ctx, &pb.WatchConfigsRequest{NodeName: nodeName}, opts...)
+	})
+}
+
+// WatchPodsMetadataForNode streams the services of the pods running on a node. The
+// current metadata is received first, then every time it changes. The channel is
+// closed when the stream ends.
+func (c *DCAClient) WatchPodsMetadataForNode(ctx context.Context, nodeName string) (<-chan apiv1.NamespacesPodsStringsSet, error) {
+	conn, err := c.dialGRPC(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := pb.NewClusterAgentSecureClient(conn).WatchPodsMetadata(ctx, &pb.WatchPodsMetadataRequest{NodeName: nodeName})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	responses := make(chan apiv1.NamespacesPodsStringsSet, 1)
+	go func() {
+		defer close(responses)
+		defer conn.Close()
+
+		for {
+			response, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF && status.Code(err) != codes.Canceled {
+					log.Debugf("Pods metadata stream of the node %s ended: %v", nodeName, err)
+				}
+				return
+			}
+
+			podsMetadata := apiv1.NewNamespacesPodsStringsSet()
+			for _, pod := range response.GetPods() {
+				podsMetadata.Set(pod.GetNamespace(), pod.GetName(), pod.GetServices()...)
+			}
+
+			select {
+			case responses <- podsMetadata:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return responses, nil
+}
+
+// WatchSecurityPolicies streams the runtime security policies declared in the cluster, by
+// name, in the policy file format. The current policies are received first, then every
+// time they change. The channel is closed when the stream ends.
+func (c *DCAClient) WatchSecurityPolicies(ctx context.Context) (<-chan map[string][]byte, error) {
+	conn, err := c.dialGRPC(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := pb.NewClusterAgentSecureClient(conn).WatchSecurityPolicies(ctx, &pb.WatchSecurityPoliciesRequest{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	responses := make(chan map[string][]byte, 1)
+	go func() {
+		defer close(responses)
+		defer conn.Close()
+
+		for {
+			response, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF && status.Code(err) != codes.Canceled {
+					log.Debugf("Security policies stream ended: %v", err)
+				}
+				return
+			}
+
+			policies := make(map[string][]byte, len(response.GetPolicies()))
+			for _, policy := range response.GetPolicies() {
+				policies[policy.GetName()] = policy.GetContent()
+			}
+
+			select {
+			case responses <- policies:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return responses, nil
+}
+
+// watchConfigs opens a configurations stream on the leading cluster agent and
+// forwards the responses on the returned channel
+func (c *DCAClient) watchConfigs(ctx context.Context, open func(pb.ClusterAgentSecureClient, ...grpc.CallOption) (configsStream, error)) (<-chan types.ConfigResponse, error) {
+	conn, err := c.dialGRPC(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var trailer metadata.MD
+	stream, err := open(pb.NewClusterAgentSecureClient(conn), grpc.Trailer(&trailer))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// Wait for the first response so that the refusals of the followers are returned as errors
+	first, err := stream.Recv()
+	if err != nil {
+		conn.Close()
+		c.handleWatchError(err, trailer)
+		return nil, err
+	}
+
+	responses := make(chan types.ConfigResponse, 1)
+	responses <- types.ConfigResponseFromProto(first)
+
+	go func() {
+		defer close(responses)
+		defer conn.Close()
+
+		for {
+			response, err := stream.Recv()
+			if err != nil {
+				c.handleWatchError(err, trailer)
+				return
+			}
+
+			select {
+			case responses <- types.ConfigResponseFromProto(response):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return responses, nil
+}
+
+// handleWatchError updates the cached leader address when a stream is refused or closed
+func (c *DCAClient) handleWatchError(err error, trailer metadata.MD) {
+	if err == io.EOF || status.Code(err) == codes.Canceled {
+		return
+	}
+	log.Debugf("Configurations stream ended: %v", err)
+
+	if leader := trailer.Get(LeaderAddressMetadataKey); status.Code(err) == codes.Unavailable && len(leader) > 0 {
+		c.leaderClient.setLeaderHost(leader[0])
+		return
+	}
+
+	// Fall back to the service, as the other requests do on errors
+	c.leaderClient.resetURL()
+}
+
+// dialGRPC connects to the gRPC API of the leading cluster agent, or of the
+// service if the leader is not known
+func (c *DCAClient) dialGRPC(ctx context.Context) (*grpc.ClientConn, error) {
+	target, err := url.Parse(c.leaderClient.getBaseURL())
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO remove insecure, like the HTTP client
+	creds := credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})
+
+	return grpc.DialContext(ctx, target.Host,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithPerRPCCredentials(bearerCredentials(c.clusterAgentAPIRequestHeaders.Get(authorizationHeaderKey))),
+	)
+}
@@ -61,8 +61,8 @@ type ContainerdUtil struct {
 func GetContainerdUtil() (ContainerdItf, error) {
 	once.Do(func() {
 		globalContainerdUtil = &ContainerdUtil{
-			queryTimeout:      config.Datadog.GetDuration("cri_query_timeout") * time.Second,
-			connectionTimeout: config.Datadog.GetDuration("cri_connection_timeout") * time.Second,
+			queryTimeout:      config.Datadog.GetDurationWithUnit("cri_query_timeout", time.Second),
+			connectionTimeout: config.Datadog.GetDurationWithUnit("cri_connection_timeout", time.Second),
 			socketPath:        config.Datadog.GetString("cri_socket_path"),
 			namespace:         config.Datadog.GetString("containerd_namespace"),
 		}
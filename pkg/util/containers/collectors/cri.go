@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build cri
+
+package collectors
+
+import (
+	"fmt"
+	"time"
+
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/cri"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	criCollectorName = "cri"
+
+	// nanoToUserHZDivisor converts the CRI cpu usage to the cgroup unit used by the other collectors
+	nanoToUserHZDivisor float64 = 1e9 / 100
+)
+
+// CRICollector lists containers from the CRI socket and populates their
+// performance metrics from the CRI stats API. It is used as a fallback on
+// containerd/CRI-O hosts where neither the docker socket nor the kubelet are available
+type CRICollector struct {
+	criUtil cri.CRIClient
+	filter  *containers.Filter
+}
+
+// Detect tries to connect to the CRI socket
+func (c *CRICollector) Detect() error {
+	util, err := cri.GetUtil()
+	if err != nil {
+		return err
+	}
+	filter, err := containers.GetSharedMetricFilter()
+	if err != nil {
+		return err
+	}
+	c.criUtil = util
+	c.filter = filter
+	return nil
+}
+
+// List gets all running containers
+func (c *CRICollector) List() ([]*containers.Container, error) {
+	ctrs, err := c.criUtil.ListContainers()
+	if err != nil {
+		return nil, fmt.Errorf("could not list containers: %s", err)
+	}
+
+	var ctrList []*containers.Container
+	for _, ctr := range ctrs {
+		container := c.parseContainer(ctr)
+		if c.filter != nil && c.filter.IsExcluded(container.Name, container.Image, ctr.GetLabels()["io.kubernetes.pod.namespace"]) {
+			continue
+		}
+		ctrList = append(ctrList, container)
+	}
+
+	return ctrList, c.UpdateMetrics(ctrList)
+}
+
+// UpdateMetrics updates metrics on an existing list of containers
+func (c *CRICollector) UpdateMetrics(cList []*containers.Container) error {
+	stats, err := c.criUtil.ListContainerStats()
+	if err != nil {
+		return fmt.Errorf("could not fetch container stats: %s", err)
+	}
+
+	for _, container := range cList {
+		if s, found := stats[container.ID]; found && s != nil {
+			container.SetMetrics(statsToMetrics(s))
+		}
+	}
+
+	// The CRI stats API doesn't expose network stats, they are collected
+	// from the procfs of the container processes when it is available
+	if err := providers.ContainerImpl().Prefetch(); err != nil {
+		log.Debugf("Cannot fetch network stats from the container implementation: %s", err)
+		return nil
+	}
+	for _, container := range cList {
+		getNetworkMetrics(container)
+	}
+
+	return nil
+}
+
+// parseContainer converts a CRI container to a containers.Container
+func (c *CRICollector) parseContainer(ctr *pb.Container) *containers.Container {
+	container := &containers.Container{
+		Type:     c.criUtil.GetRuntime(),
+		ID:       ctr.GetId(),
+		EntityID: containers.BuildTaggerEntityName(ctr.GetId()),
+		Name:     ctr.GetMetadata().GetName(),
+		Image:    ctr.GetImage().GetImage(),
+		ImageID:  ctr.GetImageRef(),
+		Created:  time.Unix(0, ctr.GetCreatedAt()).Unix(),
+	}
+
+	switch ctr.GetState() {
+	case pb.ContainerState_CONTAINER_CREATED:
+		container.State = containers.ContainerCreatedState
+	case pb.ContainerState_CONTAINER_RUNNING:
+		container.State = containers.ContainerRunningState
+	case pb.ContainerState_CONTAINER_EXITED:
+		container.State = containers.ContainerExitedState
+	default:
+		container.State = containers.ContainerUnknownState
+	}
+
+	return container
+}
+
+// statsToMetrics converts the CRI stats of a container
+func statsToMetrics(stats *pb.ContainerStats) *metrics.ContainerMetrics {
+	m := &metrics.ContainerMetrics{}
+	if cpu := stats.GetCpu(); cpu != nil {
+		m.CPU = &metrics.ContainerCPUStats{
+			Timestsamp: time.Unix(0, cpu.GetTimestamp()),
+			UsageTotal: float64(cpu.GetUsageCoreNanoSeconds().GetValue()) / nanoToUserHZDivisor,
+		}
+	}
+	if memory := stats.GetMemory(); memory != nil {
+		m.Memory = &metrics.ContainerMemStats{
+			RSS:             memory.GetWorkingSetBytes().GetValue(),
+			MemUsageInBytes: memory.GetWorkingSetBytes().GetValue(),
+		}
+	}
+	return m
+}
+
+// getNetworkMetrics calls a ContainerImplementation, caller should always call Prefetch() before
+func getNetworkMetrics(ctn *containers.Container) {
+	pids, err := providers.ContainerImpl().GetPIDs(ctn.ID)
+	if err != nil {
+		log.Debugf("ContainerImplementation cannot get PIDs for container %s, err: %s", ctn.ID, err)
+		return
+	}
+	ctn.Pids = pids
+
+	networkMetrics, err := providers.ContainerImpl().GetNetworkMetrics(ctn.ID, nil)
+	if err != nil {
+		log.Debugf("Cannot get network stats for container %s: %s", ctn.ID, err)
+		return
+	}
+	ctn.Network = networkMetrics
+}
+
+func criFactory() Collector {
+	return &CRICollector{}
+}
+
+func init() {
+	registerCollector(criCollectorName, criFactory, NodeRuntimeFallback)
+}
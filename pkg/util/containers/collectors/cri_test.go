@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build cri
+
+package collectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/cri/crimock"
+)
+
+func TestCRICollectorList(t *testing.T) {
+	mockedCriUtil := new(crimock.MockCRIClient)
+	mockedCriUtil.On("ListContainers").Return([]*pb.Container{
+		{
+			Id:        "foo",
+			Metadata:  &pb.ContainerMetadata{Name: "redis"},
+			Image:     &pb.ImageSpec{Image: "redis:latest"},
+			ImageRef:  "sha256:1234",
+			State:     pb.ContainerState_CONTAINER_RUNNING,
+			CreatedAt: 1600000000000000000,
+		},
+		{
+			Id:       "excluded",
+			Metadata: &pb.ContainerMetadata{Name: "pause"},
+			Image:    &pb.ImageSpec{Image: "k8s.gcr.io/pause:3.1"},
+			State:    pb.ContainerState_CONTAINER_RUNNING,
+		},
+	}, nil)
+	mockedCriUtil.On("ListContainerStats").Return(map[string]*pb.ContainerStats{
+		"foo": {
+			Attributes: &pb.ContainerAttributes{Id: "foo"},
+			Cpu: &pb.CpuUsage{
+				Timestamp:            1600000000000000000,
+				UsageCoreNanoSeconds: &pb.UInt64Value{Value: 2000000000},
+			},
+			Memory: &pb.MemoryUsage{
+				WorkingSetBytes: &pb.UInt64Value{Value: 1024},
+			},
+		},
+	}, nil)
+
+	filter, err := containers.NewFilter(nil, []string{"image:k8s.gcr.io/pause.*"})
+	require.NoError(t, err)
+
+	c := &CRICollector{criUtil: mockedCriUtil, filter: filter}
+	list, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	ctr := list[0]
+	assert.Equal(t, "fakeruntime", ctr.Type)
+	assert.Equal(t, "foo", ctr.ID)
+	assert.Equal(t, "container_id://foo", ctr.EntityID)
+	assert.Equal(t, "redis", ctr.Name)
+	assert.Equal(t, "redis:latest", ctr.Image)
+	assert.Equal(t, "sha256:1234", ctr.ImageID)
+	assert.Equal(t, int64(1600000000), ctr.Created)
+	assert.Equal(t, containers.ContainerRunningState, ctr.State)
+
+	require.NotNil(t, ctr.CPU)
+	assert.Equal(t, float64(200), ctr.CPU.UsageTotal)
+	require.NotNil(t, ctr.Memory)
+	assert.Equal(t, uint64(1024), ctr.Memory.RSS)
+	assert.Equal(t, uint64(1024), ctr.Memory.MemUsageInBytes)
+}
@@ -7,6 +7,7 @@ package collectors
 
 import (
 	"errors"
+	"sort"
 	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -35,6 +36,7 @@ type Detector struct {
 	detected           map[string]Collector
 	preferredCollector Collector
 	preferredName      string
+	detectedNames      []string
 }
 
 // DetectorInterface is useful to mock the Detector in other packages
@@ -81,7 +83,9 @@ func (d *Detector) GetPreferred() (Collector, string, error) {
 	// Add newly detected detected
 	for name, c := range detected {
 		d.detected[name] = c
+		d.detectedNames = append(d.detectedNames, name)
 	}
+	sort.Strings(d.detectedNames)
 
 	// Pick preferred collector among detected ones
 	preferred := rankCollectors(d.detected, d.preferredName)
@@ -107,6 +111,12 @@ func (d *Detector) GetPreferred() (Collector, string, error) {
 	return d.preferredCollector, d.preferredName, nil
 }
 
+// GetDetectedNames returns the names of all the collectors detected so far,
+// including the ones that were not selected as preferred
+func (d *Detector) GetDetectedNames() []string {
+	return d.detectedNames
+}
+
 // retryCandidates iterates over candidates and returns two new maps:
 // the successfully detected collectors, and the ones to retry later
 func retryCandidates(candidates map[string]Collector) (map[string]Collector, map[string]Collector) {
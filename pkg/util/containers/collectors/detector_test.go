@@ -175,6 +175,7 @@ func (suite *DetectorTestSuite) TestConfigureRetry() {
 
 	assert.Nil(suite.T(), d.candidates)
 	assert.Nil(suite.T(), d.detected)
+	assert.Equal(suite.T(), []string{"ok", "retry"}, d.GetDetectedNames())
 
 	// Third run should be a noop
 	c, n, err = d.GetPreferred()
@@ -234,6 +235,27 @@ func (suite *DetectorTestSuite) TestConfigureTwoByPrio() {
 	assert.Nil(suite.T(), d.detected)
 }
 
+// TestConfigureFallback makes sure a fallback collector is only
+// selected if no runtime collector is available
+func (suite *DetectorTestSuite) TestConfigureFallback() {
+	fallback := registerMock("a-fallback", NodeRuntimeFallback)
+	fallback.On("Detect").Return(nil).Once()
+	runtime := registerMock("runtime", NodeRuntime)
+	runtime.On("Detect").Return(nil).Once()
+
+	d := NewDetector("")
+	assert.Len(suite.T(), d.candidates, 2)
+	assert.Len(suite.T(), d.detected, 0)
+
+	c, n, err := d.GetPreferred()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "runtime", n)
+	assert.Equal(suite.T(), runtime, c)
+
+	fallback.AssertNumberOfCalls(suite.T(), "Detect", 1)
+	runtime.AssertNumberOfCalls(suite.T(), "Detect", 1)
+}
+
 func TestDetectorTestSuite(t *testing.T) {
 	suite.Run(t, new(DetectorTestSuite))
 }
@@ -18,8 +18,10 @@ type Collector interface {
 type CollectorPriority int
 
 // List of collector priorities
-// Same order as the tagger: docker < kubelet
+// Same order as the tagger: docker < kubelet, the CRI collector
+// is only used as a fallback when no other collector is available
 const (
-	NodeRuntime CollectorPriority = iota
+	NodeRuntimeFallback CollectorPriority = iota
+	NodeRuntime
 	NodeOrchestrator
 )
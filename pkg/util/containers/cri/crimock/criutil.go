@@ -17,6 +17,12 @@ type MockCRIClient struct {
 	mock.Mock
 }
 
+// ListContainers sends a ListContainersRequest to the server, and returns the running containers
+func (m *MockCRIClient) ListContainers() ([]*pb.Container, error) {
+	args := m.Called()
+	return args.Get(0).([]*pb.Container), args.Error(1)
+}
+
 // ListContainerStats sends a ListContainerStatsRequest to the server, and parses the returned response
 func (m *MockCRIClient) ListContainerStats() (map[string]*pb.ContainerStats, error) {
 	args := m.Called()
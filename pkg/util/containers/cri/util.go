@@ -27,6 +27,7 @@ var (
 )
 
 type CRIClient interface {
+	ListContainers() ([]*pb.Container, error)
 	ListContainerStats() (map[string]*pb.ContainerStats, error)
 	GetContainerStatus(containerID string) (*pb.ContainerStatus, error)
 	GetRuntime() string
@@ -84,8 +85,8 @@ func (c *CRIUtil) init() error {
 func GetUtil() (*CRIUtil, error) {
 	once.Do(func() {
 		globalCRIUtil = &CRIUtil{
-			queryTimeout:      config.Datadog.GetDuration("cri_query_timeout") * time.Second,
-			connectionTimeout: config.Datadog.GetDuration("cri_connection_timeout") * time.Second,
+			queryTimeout:      config.Datadog.GetDurationWithUnit("cri_query_timeout", time.Second),
+			connectionTimeout: config.Datadog.GetDurationWithUnit("cri_connection_timeout", time.Second),
 			socketPath:        config.Datadog.GetString("cri_socket_path"),
 		}
 		globalCRIUtil.initRetry.SetupRetrier(&retry.Config{ //nolint:errcheck
@@ -104,6 +105,20 @@ func GetUtil() (*CRIUtil, error) {
 	return globalCRIUtil, nil
 }
 
+// ListContainers sends a ListContainersRequest to the server, and returns the running containers
+func (c *CRIUtil) ListContainers() ([]*pb.Container, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+	filter := &pb.ContainerFilter{State: &pb.ContainerStateValue{State: pb.ContainerState_CONTAINER_RUNNING}}
+	request := &pb.ListContainersRequest{Filter: filter}
+	r, err := c.client.ListContainers(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetContainers(), nil
+}
+
 // ListContainerStats sends a ListContainerStatsRequest to the server, and parses the returned response
 func (c *CRIUtil) ListContainerStats() (map[string]*pb.ContainerStats, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
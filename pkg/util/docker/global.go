@@ -8,9 +8,11 @@
 package docker
 
 import (
+	"errors"
 	"sync"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -24,7 +26,12 @@ var (
 )
 
 // GetDockerUtil returns a ready to use DockerUtil. It is backed by a shared singleton.
+// It fails when the docker feature is listed in `autoconfig_exclude_features`.
 func GetDockerUtil() (*DockerUtil, error) {
+	if config.IsFeatureExcluded(config.Docker) {
+		return nil, errors.New("the docker feature is excluded by autoconfig_exclude_features")
+	}
+
 	globalDockerUtilMutex.Lock()
 	defer globalDockerUtilMutex.Unlock()
 	if globalDockerUtil == nil {
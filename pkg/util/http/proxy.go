@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package http
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// GetEndpointProxy returns the proxy overridden for the host of the URL in the proxy settings, if any.
+// An empty proxy means that the requests to the host are sent directly.
+func GetEndpointProxy(p *config.Proxy, u *url.URL) (string, bool) {
+	if proxy, found := p.Endpoints[u.Host]; found {
+		return proxy, true
+	}
+	proxy, found := p.Endpoints[u.Hostname()]
+	return proxy, found
+}
+
+// MatchNoProxy returns the entry of the no_proxy list matching the URL, if any. The entries can be:
+// - `*`, matching all the hosts
+// - an IP address, or a CIDR range matching the IP addresses it contains
+// - a domain name, matching the domain and its subdomains, optionally starting with a dot
+// - any of the above followed by a port, only matching the URLs with this port
+func MatchNoProxy(noProxy []string, u *url.URL) (string, bool) {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	ip := net.ParseIP(host)
+
+	for _, entry := range noProxy {
+		pattern := strings.ToLower(strings.TrimSpace(entry))
+		if pattern == "" {
+			continue
+		}
+		if pattern == "*" {
+			return entry, true
+		}
+
+		// a CIDR range, or an IPv6 address, contains colons without having a port
+		if _, cidr, err := net.ParseCIDR(pattern); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return entry, true
+			}
+			continue
+		}
+		if entryIP := net.ParseIP(strings.Trim(pattern, "[]")); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return entry, true
+			}
+			continue
+		}
+
+		if entryHost, entryPort, err := net.SplitHostPort(pattern); err == nil {
+			if entryPort != port {
+				continue
+			}
+			pattern = entryHost
+		}
+
+		if entryIP := net.ParseIP(pattern); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return entry, true
+			}
+			continue
+		}
+
+		domain := strings.TrimPrefix(pattern, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return entry, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestMatchNoProxy(t *testing.T) {
+	noProxy := []string{"datadoghq.com", ".internal.example", "10.0.0.0/8", "192.168.1.1", "proxied.example:8080", "::1"}
+
+	for rawURL, expected := range map[string]string{
+		"https://datadoghq.com":              "datadoghq.com",
+		"https://app.datadoghq.com/api":      "datadoghq.com",
+		"https://notdatadoghq.com":           "",
+		"http://host.internal.example":       ".internal.example",
+		"http://internal.example":            ".internal.example",
+		"http://10.1.2.3:8080":               "10.0.0.0/8",
+		"http://11.1.2.3":                    "",
+		"https://192.168.1.1":                "192.168.1.1",
+		"http://proxied.example:8080/intake": "proxied.example:8080",
+		"http://proxied.example":             "",
+		"http://[::1]:8126":                  "::1",
+	} {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		entry, found := MatchNoProxy(noProxy, u)
+		assert.Equal(t, expected != "", found, rawURL)
+		assert.Equal(t, expected, entry, rawURL)
+	}
+
+	u, _ := url.Parse("https://anything.com")
+	entry, found := MatchNoProxy([]string{"", "*"}, u)
+	assert.True(t, found)
+	assert.Equal(t, "*", entry)
+
+	u, _ = url.Parse("https://datadoghq.com")
+	_, found = MatchNoProxy([]string{"datadoghq.com:80"}, u)
+	assert.False(t, found)
+}
+
+func TestEndpointProxy(t *testing.T) {
+	proxies := &config.Proxy{
+		HTTPS:   "https://proxy.com:3128",
+		NoProxy: []string{"test.com"},
+		Endpoints: map[string]string{
+			"test.com":        "http://endpoint-proxy.com:3128",
+			"direct.com":      "",
+			"ported.com:8443": "http://ported-proxy.com:3128",
+		},
+	}
+	proxyFunc := GetProxyTransportFunc(proxies)
+
+	for rawURL, expected := range map[string]string{
+		"https://test.com/api/v1":    "http://endpoint-proxy.com:3128",
+		"https://direct.com":         "",
+		"https://ported.com:8443":    "http://ported-proxy.com:3128",
+		"https://ported.com":         "https://proxy.com:3128",
+		"https://other.com/api/v1":   "https://proxy.com:3128",
+		"https://sub.test.com/api/1": "",
+	} {
+		r, err := http.NewRequest("GET", rawURL, nil)
+		require.NoError(t, err)
+		proxyURL, err := proxyFunc(r)
+		assert.NoError(t, err)
+		if expected == "" {
+			assert.Nil(t, proxyURL, rawURL)
+		} else if assert.NotNil(t, proxyURL, rawURL) {
+			assert.Equal(t, expected, proxyURL.String(), rawURL)
+		}
+	}
+}
@@ -19,6 +19,11 @@ import (
 
 // CreateHTTPTransport creates an *http.Transport for use in the agent
 func CreateHTTPTransport() *http.Transport {
+	return CreateProductHTTPTransport(config.ProxyProductCore)
+}
+
+// CreateProductHTTPTransport creates an *http.Transport using the proxy settings of a product
+func CreateProductHTTPTransport(product string) *http.Transport {
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: config.Datadog.GetBool("skip_ssl_validation"),
 	}
@@ -47,7 +52,7 @@ func CreateHTTPTransport() *http.Transport {
 		ExpectContinueTimeout: 1 * time.Second,
 	}
 
-	if proxies := config.GetProxies(); proxies != nil {
+	if proxies := config.GetProductProxies(product); proxies != nil {
 		transport.Proxy = GetProxyTransportFunc(proxies)
 	}
 
@@ -58,22 +63,27 @@ func CreateHTTPTransport() *http.Transport {
 // would return the right proxy depending on the configuration.
 func GetProxyTransportFunc(p *config.Proxy) func(*http.Request) (*url.URL, error) {
 	return func(r *http.Request) (*url.URL, error) {
-		// check no_proxy list first
-		for _, host := range p.NoProxy {
-			if r.URL.Host == host {
-				log.Debugf("URL match no_proxy list item '%s': not using any proxy", host)
+		confProxy, overridden := GetEndpointProxy(p, r.URL)
+		if overridden && confProxy == "" {
+			log.Debugf("URL '%s' has an endpoint proxy override: not using any proxy", SanitizeURL(r.URL.String()))
+			return nil, nil
+		}
+
+		if !overridden {
+			// check no_proxy list first
+			if entry, found := MatchNoProxy(p.NoProxy, r.URL); found {
+				log.Debugf("URL match no_proxy list item '%s': not using any proxy", entry)
 				return nil, nil
 			}
-		}
 
-		// check proxy by scheme
-		confProxy := ""
-		if r.URL.Scheme == "http" {
-			confProxy = p.HTTP
-		} else if r.URL.Scheme == "https" {
-			confProxy = p.HTTPS
-		} else {
-			log.Warnf("Proxy configuration do not support scheme '%s'", r.URL.Scheme)
+			// check proxy by scheme
+			if r.URL.Scheme == "http" {
+				confProxy = p.HTTP
+			} else if r.URL.Scheme == "https" {
+				confProxy = p.HTTPS
+			} else {
+				log.Warnf("Proxy configuration do not support scheme '%s'", r.URL.Scheme)
+			}
 		}
 
 		if confProxy != "" {
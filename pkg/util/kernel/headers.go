@@ -5,73 +5,167 @@ package kernel
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/metadata/host"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/mholt/archiver/v3"
+	"golang.org/x/crypto/openpgp"
 )
 
 const sysfsHeadersPath = "/sys/kernel/kheaders.tar.xz"
-const kernelModulesPath = "/lib/modules/%s/build"
+
+// defaultHeaderDirs are the distribution specific locations of the kernel headers,
+// formatted with the kernel release
+var defaultHeaderDirs = []string{
+	"/lib/modules/%s/build",
+	"/lib/modules/%s/source",
+	"/usr/lib/modules/%s/build",
+	"/usr/src/linux-headers-%s",
+	"/usr/src/kernels/%s",
+}
 
 var versionCodeRegexp = regexp.MustCompile(`^#define[\t ]+LINUX_VERSION_CODE[\t ]+(\d+)$`)
 
+var headersHTTPClient = &http.Client{Timeout: 5 * time.Minute}
+
+// HeaderOptions holds the options of the kernel headers discovery
+type HeaderOptions struct {
+	// Dirs are user provided kernel headers directories, checked before the default locations
+	Dirs []string
+	// DownloadEnabled enables the download of the kernel headers when they are not found on the host
+	DownloadEnabled bool
+	// DownloadDir is the directory where the downloaded kernel headers are extracted
+	DownloadDir string
+	// Mirrors are the base URLs of the mirrors serving the `linux-headers-<kernel release>.tar.gz` archives,
+	// along with their `.asc` armored detached signatures
+	Mirrors []string
+	// Keyring is the path of the OpenPGP keyring, armored or not, holding the keys the archives are signed with
+	Keyring string
+}
+
 // FindHeaderDirs finds kernel header base directories that contain a `LINUX_VERSION_CODE` matching the running kernel.
-// If no directories are found, it will attempt a fallback to extracting from `/sys/kernel/kheaders.tar.xz`
-// which is enabled via the `kheaders` kernel module and the `CONFIG_KHEADERS` kernel config option.
-// The `kheaders` module will be automatically added and removed if present and needed.
-func FindHeaderDirs() ([]string, error) {
+// The user provided directories are checked first, then the distribution specific locations, on the host filesystem
+// mounted at `HOST_ROOT` when running in a container. If no directories are found, it will attempt a fallback to
+// extracting from `/sys/kernel/kheaders.tar.xz` which is enabled via the `kheaders` kernel module and the
+// `CONFIG_KHEADERS` kernel config option. The `kheaders` module will be automatically added and removed if present
+// and needed. As a last resort, the headers are downloaded from the configured mirrors if enabled.
+func FindHeaderDirs(opts HeaderOptions) ([]string, error) {
 	hv, err := HostVersion()
 	if err != nil {
 		return nil, fmt.Errorf("unable to determine host kernel version: %w", err)
 	}
 
-	dir, err := getHeaderDirs(hv)
+	// KernelVersion == uname -r
+	release := host.GetStatusInformation().KernelVersion
+
+	dirs, reasons := validateHeaderDirs(hv, candidateHeaderDirs(opts.Dirs, release, os.Getenv("HOST_ROOT")))
+	if len(dirs) > 0 {
+		return dirs, nil
+	}
+
+	// look for sysfs headers
+	dirs, err = getSysfsHeaderDirs(hv)
 	if err == nil {
-		return dir, nil
+		return dirs, nil
 	}
+	reasons = append(reasons, fmt.Sprintf("%s: %s", sysfsHeadersPath, err))
 
-	if os.IsNotExist(err) {
-		// as last resort, look for sysfs headers
-		if dir, syserr := getSysfsHeaderDirs(hv); syserr == nil {
-			return dir, nil
+	if opts.DownloadEnabled {
+		dirs, err = downloadHeaders(opts, release, hv)
+		if err == nil {
+			return dirs, nil
 		}
+		reasons = append(reasons, fmt.Sprintf("download: %s", err))
 	}
-	return nil, err
+
+	return nil, fmt.Errorf("unable to find kernel headers matching the host kernel %s: %s", hv, strings.Join(reasons, "; "))
 }
 
-func getHeaderDirs(v Version) ([]string, error) {
-	hi := host.GetStatusInformation()
-	if hi.KernelVersion == "" {
-		return nil, fmt.Errorf("unable to get host metadata")
+// candidateHeaderDirs returns the directories that may hold the kernel headers, by order of preference
+func candidateHeaderDirs(userDirs []string, release string, hostRoot string) []string {
+	dirs := append([]string{}, userDirs...)
+	if release == "" {
+		return dirs
 	}
 
-	// KernelVersion == uname -r
-	dirs := []string{fmt.Sprintf(kernelModulesPath, hi.KernelVersion)}
-	if hi.Platform == "debian" {
-		dirs = append(dirs, fmt.Sprintf("/lib/modules/%s/source", hi.KernelVersion))
+	for _, d := range defaultHeaderDirs {
+		d = fmt.Sprintf(d, release)
+		if hostRoot != "" {
+			dirs = append(dirs, resolveHostPath(hostRoot, d))
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs
+}
+
+// resolveHostPath returns the path of a host file in the host filesystem mounted at hostRoot.
+// An absolute symlink, such as `/lib/modules/<release>/build`, is resolved within hostRoot.
+func resolveHostPath(hostRoot, path string) string {
+	hostPath := filepath.Join(hostRoot, path)
+	target, err := os.Readlink(hostPath)
+	if err != nil || !filepath.IsAbs(target) {
+		return hostPath
 	}
+	return filepath.Join(hostRoot, target)
+}
+
+// validateHeaderDirs returns the candidate directories holding the kernel headers of the given version, along
+// with the reason every other directory was discarded. The directories without a version header are kept, as
+// some distributions split the headers across several directories, only when another directory matches.
+func validateHeaderDirs(v Version, candidates []string) ([]string, []string) {
+	var dirs, unversioned, reasons []string
+	seen := make(map[string]bool)
+
+	for _, d := range candidates {
+		realPath, err := filepath.EvalSymlinks(d)
+		if err != nil {
+			if os.IsNotExist(err) {
+				reasons = append(reasons, fmt.Sprintf("%s: not found", d))
+			} else {
+				reasons = append(reasons, fmt.Sprintf("%s: %s", d, err))
+			}
+			continue
+		}
+		if seen[realPath] {
+			continue
+		}
+		seen[realPath] = true
 
-	for _, d := range dirs {
 		hv, err := getHeaderVersion(d)
 		if err != nil {
-			// if version.h is not found in this directory, keep going
 			if os.IsNotExist(err) {
+				unversioned = append(unversioned, d)
 				continue
 			}
-			return nil, fmt.Errorf("error validating headers version: %w", err)
+			reasons = append(reasons, fmt.Sprintf("%s: error validating headers version: %s", d, err))
+			continue
 		}
-		if hv == v {
-			return dirs, nil
+		if hv != v {
+			reasons = append(reasons, fmt.Sprintf("%s: headers version %s does not match", d, hv))
+			continue
 		}
+		dirs = append(dirs, d)
 	}
-	return nil, os.ErrNotExist
+
+	if len(dirs) == 0 {
+		for _, d := range unversioned {
+			reasons = append(reasons, fmt.Sprintf("%s: no kernel version header found", d))
+		}
+		return nil, reasons
+	}
+	return append(dirs, unversioned...), reasons
 }
 
 func getHeaderVersion(path string) (Version, error) {
@@ -161,3 +255,146 @@ func unloadKHeadersModule() error {
 	}
 	return nil
 }
+
+// downloadHeaders downloads the kernel headers of the given release from the first mirror serving them
+func downloadHeaders(opts HeaderOptions, release string, v Version) ([]string, error) {
+	if release == "" {
+		return nil, fmt.Errorf("unable to get the kernel release from the host metadata")
+	}
+
+	dest := filepath.Join(opts.DownloadDir, release)
+	// reuse the headers previously downloaded
+	if dirs, _ := validateHeaderDirs(v, []string{dest}); len(dirs) > 0 {
+		return dirs, nil
+	}
+	_ = os.RemoveAll(dest)
+
+	if len(opts.Mirrors) == 0 {
+		return nil, fmt.Errorf("no kernel headers mirror configured")
+	}
+
+	// the archives are only extracted once their signature is verified
+	if opts.Keyring == "" {
+		return nil, fmt.Errorf("no kernel headers signing keyring configured")
+	}
+	keyring, err := readKeyring(opts.Keyring)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the kernel headers signing keyring %s: %w", opts.Keyring, err)
+	}
+
+	var errs []string
+	for _, mirror := range opts.Mirrors {
+		url := fmt.Sprintf("%s/linux-headers-%s.tar.gz", strings.TrimSuffix(mirror, "/"), release)
+		if err := downloadHeadersArchive(url, keyring, opts.DownloadDir, dest); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", url, err))
+			continue
+		}
+
+		hv, err := getHeaderVersion(dest)
+		if err != nil || hv != v {
+			_ = os.RemoveAll(dest)
+			if err == nil {
+				err = fmt.Errorf("headers version %s does not match", hv)
+			}
+			errs = append(errs, fmt.Sprintf("%s: %s", url, err))
+			continue
+		}
+
+		log.Infof("Downloaded the kernel headers from %s to %s", url, dest)
+		return []string{dest}, nil
+	}
+	return nil, errors.New(strings.Join(errs, ", "))
+}
+
+// readKeyring reads an OpenPGP keyring, armored or not
+func readKeyring(path string) (openpgp.EntityList, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(content)); err == nil {
+		return keyring, nil
+	}
+	return openpgp.ReadKeyRing(bytes.NewReader(content))
+}
+
+// downloadHeadersArchive downloads a kernel headers archive, verifies its detached signature, served next to it
+// with the `.asc` extension, against the keyring and extracts it to dest
+func downloadHeadersArchive(url string, keyring openpgp.EntityList, downloadDir, dest string) error {
+	if err := os.MkdirAll(downloadDir, 0755); err != nil {
+		return fmt.Errorf("unable to create download directory: %w", err)
+	}
+
+	archive, err := ioutil.TempFile(downloadDir, "linux-headers-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+
+	err = downloadFile(url, archive)
+	archive.Close()
+	if err != nil {
+		return fmt.Errorf("unable to download archive: %w", err)
+	}
+
+	var signature bytes.Buffer
+	if err := downloadFile(url+".asc", &signature); err != nil {
+		return fmt.Errorf("unable to download the archive signature: %w", err)
+	}
+	if err := verifyArchiveSignature(archive.Name(), &signature, keyring); err != nil {
+		return err
+	}
+
+	tmpPath, err := ioutil.TempDir(downloadDir, "linux-headers-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpPath)
+
+	if err := archiver.NewTarGz().Unarchive(archive.Name(), tmpPath); err != nil {
+		return fmt.Errorf("unable to extract kernel headers: %w", err)
+	}
+	return os.Rename(headersRoot(tmpPath), dest)
+}
+
+// downloadFile writes the content served at url to w
+func downloadFile(url string, w io.Writer) error {
+	resp, err := headersHTTPClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// verifyArchiveSignature checks the armored detached signature of the archive against the keyring
+func verifyArchiveSignature(path string, signature io.Reader, keyring openpgp.EntityList) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	signer, err := openpgp.CheckArmoredDetachedSignature(keyring, f, signature)
+	if err != nil {
+		return fmt.Errorf("invalid archive signature: %w", err)
+	}
+	log.Debugf("kernel headers archive %s signed by key %X", path, signer.PrimaryKey.KeyId)
+	return nil
+}
+
+// headersRoot returns the root of an extracted headers archive, which may have a single top-level directory
+func headersRoot(path string) string {
+	if _, err := getHeaderVersion(path); err == nil {
+		return path
+	}
+	entries, err := ioutil.ReadDir(path)
+	if err == nil && len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(path, entries[0].Name())
+	}
+	return path
+}
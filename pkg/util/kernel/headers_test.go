@@ -3,19 +3,28 @@
 package kernel
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
 )
 
 func TestFindHeaderDirs(t *testing.T) {
 	if _, ok := os.LookupEnv("INTEGRATION"); !ok {
 		t.Skip("set INTEGRATION environment variable to run")
 	}
-	dirs, err := FindHeaderDirs()
+	dirs, err := FindHeaderDirs(HeaderOptions{})
 	require.NoError(t, err)
 	assert.NotZero(t, len(dirs), "expected to find header directories")
 	t.Log(dirs)
@@ -45,3 +54,155 @@ func TestParseHeaderVersion(t *testing.T) {
 		}
 	}
 }
+
+func createHeaderDir(t *testing.T, path string, v Version) {
+	require.NoError(t, os.MkdirAll(filepath.Join(path, "include/generated/uapi/linux"), 0755))
+	if v != 0 {
+		versionHeader := fmt.Sprintf("#define LINUX_VERSION_CODE %d\n", v)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(path, "include/generated/uapi/linux/version.h"), []byte(versionHeader), 0644))
+	}
+}
+
+func TestValidateHeaderDirs(t *testing.T) {
+	root, err := ioutil.TempDir("", "kernel-headers")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	v := VersionCode(5, 4, 0)
+	matching := filepath.Join(root, "matching")
+	createHeaderDir(t, matching, v)
+	mismatching := filepath.Join(root, "mismatching")
+	createHeaderDir(t, mismatching, VersionCode(4, 15, 0))
+	unversioned := filepath.Join(root, "unversioned")
+	createHeaderDir(t, unversioned, 0)
+	symlink := filepath.Join(root, "symlink")
+	require.NoError(t, os.Symlink(matching, symlink))
+	missing := filepath.Join(root, "missing")
+
+	dirs, reasons := validateHeaderDirs(v, []string{missing, unversioned, mismatching, matching, symlink})
+	assert.Equal(t, []string{matching, unversioned}, dirs)
+	assert.Equal(t, []string{
+		missing + ": not found",
+		mismatching + ": headers version 4.15.0 does not match",
+	}, reasons)
+
+	dirs, reasons = validateHeaderDirs(v, []string{unversioned, mismatching})
+	assert.Empty(t, dirs)
+	assert.Equal(t, []string{
+		mismatching + ": headers version 4.15.0 does not match",
+		unversioned + ": no kernel version header found",
+	}, reasons)
+}
+
+func TestCandidateHeaderDirs(t *testing.T) {
+	hostRoot, err := ioutil.TempDir("", "host-root")
+	require.NoError(t, err)
+	defer os.RemoveAll(hostRoot)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(hostRoot, "lib/modules/5.4.0"), 0755))
+	require.NoError(t, os.Symlink("/usr/src/linux-headers-5.4.0", filepath.Join(hostRoot, "lib/modules/5.4.0/build")))
+
+	dirs := candidateHeaderDirs([]string{"/opt/headers"}, "5.4.0", hostRoot)
+	assert.Equal(t, []string{
+		"/opt/headers",
+		filepath.Join(hostRoot, "/usr/src/linux-headers-5.4.0"),
+		"/lib/modules/5.4.0/build",
+		filepath.Join(hostRoot, "/lib/modules/5.4.0/source"),
+		"/lib/modules/5.4.0/source",
+		filepath.Join(hostRoot, "/usr/lib/modules/5.4.0/build"),
+		"/usr/lib/modules/5.4.0/build",
+		filepath.Join(hostRoot, "/usr/src/linux-headers-5.4.0"),
+		"/usr/src/linux-headers-5.4.0",
+		filepath.Join(hostRoot, "/usr/src/kernels/5.4.0"),
+		"/usr/src/kernels/5.4.0",
+	}, dirs)
+
+	assert.Equal(t, []string{"/opt/headers"}, candidateHeaderDirs([]string{"/opt/headers"}, "", ""))
+}
+
+func TestDownloadHeaders(t *testing.T) {
+	downloadDir, err := ioutil.TempDir("", "kernel-headers-download")
+	require.NoError(t, err)
+	defer os.RemoveAll(downloadDir)
+
+	v := VersionCode(5, 4, 0)
+	archive := new(bytes.Buffer)
+	gw := gzip.NewWriter(archive)
+	tw := tar.NewWriter(gw)
+	versionHeader := []byte(fmt.Sprintf("#define LINUX_VERSION_CODE %d\n", v))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "linux-headers/include/generated/uapi/linux/version.h", Mode: 0644, Size: int64(len(versionHeader))}))
+	_, err = tw.Write(versionHeader)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	signer, err := openpgp.NewEntity("kernel headers", "", "headers@example.com", nil)
+	require.NoError(t, err)
+	forger, err := openpgp.NewEntity("forger", "", "forger@example.com", nil)
+	require.NoError(t, err)
+
+	sign := func(e *openpgp.Entity) []byte {
+		var signature bytes.Buffer
+		require.NoError(t, openpgp.ArmoredDetachSign(&signature, e, bytes.NewReader(archive.Bytes()), nil))
+		return signature.Bytes()
+	}
+	signatures := map[string][]byte{
+		"/forged/linux-headers-5.4.0.tar.gz.asc": sign(forger),
+		"/good/linux-headers-5.4.0.tar.gz.asc":   sign(signer),
+	}
+
+	keyring := filepath.Join(downloadDir, "keyring.asc")
+	f, err := os.Create(keyring)
+	require.NoError(t, err)
+	w, err := armor.Encode(f, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, signer.Serialize(w))
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forged/linux-headers-5.4.0.tar.gz", "/unsigned/linux-headers-5.4.0.tar.gz", "/good/linux-headers-5.4.0.tar.gz":
+			w.Write(archive.Bytes())
+		default:
+			if signature, ok := signatures[r.URL.Path]; ok {
+				w.Write(signature)
+				return
+			}
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	opts := HeaderOptions{
+		DownloadEnabled: true,
+		DownloadDir:     downloadDir,
+		Mirrors:         []string{ts.URL + "/good/"},
+	}
+
+	// the archives aren't downloaded without a keyring to verify them
+	_, err = downloadHeaders(opts, "5.4.0", v)
+	assert.EqualError(t, err, "no kernel headers signing keyring configured")
+
+	// the unsigned archives and the ones signed by another key are rejected
+	opts.Keyring = keyring
+	opts.Mirrors = []string{ts.URL + "/unsigned", ts.URL + "/forged"}
+	_, err = downloadHeaders(opts, "5.4.0", v)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to download the archive signature")
+	assert.Contains(t, err.Error(), "invalid archive signature")
+
+	opts.Mirrors = []string{ts.URL + "/bad", ts.URL + "/forged", ts.URL + "/good/"}
+	dirs, err := downloadHeaders(opts, "5.4.0", v)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(downloadDir, "5.4.0")}, dirs)
+
+	// the downloaded headers are reused
+	opts.Mirrors = nil
+	dirs, err = downloadHeaders(opts, "5.4.0", v)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(downloadDir, "5.4.0")}, dirs)
+
+	_, err = downloadHeaders(opts, "5.8.0", VersionCode(5, 8, 0))
+	assert.EqualError(t, err, "no kernel headers mirror configured")
+}
@@ -54,3 +54,9 @@ func GetNodeLabels(nodeName string) (map[string]string, error) {
 	log.Errorf("GetNodeLabels not implemented %s", ErrNotCompiled.Error())
 	return nil, nil
 }
+
+// GetServiceNamesByIP maps the cluster IPs of the services and the addresses of their endpoints to the names of the services.
+func GetServiceNamesByIP() (map[string][]string, error) {
+	log.Errorf("GetServiceNamesByIP not implemented %s", ErrNotCompiled.Error())
+	return nil, nil
+}
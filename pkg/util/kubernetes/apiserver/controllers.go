@@ -123,6 +123,9 @@ func startMetadataController(ctx ControllerContext, c chan error) {
 		ctx.InformerFactory.Core().V1().Nodes(),
 		ctx.InformerFactory.Core().V1().Endpoints(),
 	)
+	// the services are only read from the cache of their informer to resolve the service names of IPs,
+	// it has to be requested before the informer factory is started
+	ctx.InformerFactory.Core().V1().Services().Informer()
 	go metaController.Run(ctx.StopCh)
 }
 
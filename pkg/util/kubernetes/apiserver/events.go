@@ -12,15 +12,27 @@ package apiserver
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
+const involvedObjectResourceCachePrefix = "KubernetesEventResource"
+
+// involvedObjectResource is the API resource serving a kind of involved object
+type involvedObjectResource struct {
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
 // RunEventCollection will return the most recent events emitted by the apiserver.
 func (c *APIClient) RunEventCollection(resVer string, lastListTime time.Time, eventReadTimeout int64, eventCardinalityLimit int64, resync int64, filter string) ([]*v1.Event, string, time.Time, error) {
 	var added []*v1.Event
@@ -150,3 +162,53 @@ func (c *APIClient) listForEventResync(eventReadTimeout int64, eventCardinalityL
 	}
 	return added, evList.ResourceVersion, time.Now(), nil
 }
+
+// GetInvolvedObjectLabels returns the labels of the object an event is about.
+func (c *APIClient) GetInvolvedObjectLabels(obj v1.ObjectReference) (map[string]string, error) {
+	res, err := c.getInvolvedObjectResource(obj.APIVersion, obj.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var client dynamic.ResourceInterface = c.DynamicCl.Resource(res.gvr)
+	if res.namespaced {
+		client = c.DynamicCl.Resource(res.gvr).Namespace(obj.Namespace)
+	}
+	o, err := client.Get(obj.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return o.GetLabels(), nil
+}
+
+// getInvolvedObjectResource discovers the API resource serving a kind of object
+func (c *APIClient) getInvolvedObjectResource(apiVersion, kind string) (involvedObjectResource, error) {
+	cacheKey := cache.BuildAgentKey(involvedObjectResourceCachePrefix, apiVersion, kind)
+	if cached, found := cache.Cache.Get(cacheKey); found {
+		if res, ok := cached.(involvedObjectResource); ok {
+			return res, nil
+		}
+	}
+
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return involvedObjectResource{}, err
+	}
+	resources, err := c.Cl.Discovery().ServerResourcesForGroupVersion(apiVersion)
+	if err != nil {
+		return involvedObjectResource{}, err
+	}
+	for _, r := range resources.APIResources {
+		// skip the subresources, such as pods/status
+		if r.Kind != kind || strings.Contains(r.Name, "/") {
+			continue
+		}
+		res := involvedObjectResource{
+			gvr:        gv.WithResource(r.Name),
+			namespaced: r.Namespaced,
+		}
+		cache.Cache.Set(cacheKey, res, metadataMapExpire)
+		return res, nil
+	}
+	return involvedObjectResource{}, fmt.Errorf("no resource found for the kind %s in %s", kind, apiVersion)
+}
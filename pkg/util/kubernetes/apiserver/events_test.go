@@ -12,8 +12,14 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestDiffEvents(t *testing.T) {
@@ -137,3 +143,49 @@ func TestDiffEvents(t *testing.T) {
 		})
 	}
 }
+
+func TestGetInvolvedObjectLabels(t *testing.T) {
+	cl := fake.NewSimpleClientset()
+	cl.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods/status", Kind: "Pod", Namespaced: true},
+				{Name: "pods", Kind: "Pod", Namespaced: true},
+				{Name: "nodes", Kind: "Node"},
+			},
+		},
+	}
+
+	pod := &unstructured.Unstructured{}
+	pod.SetAPIVersion("v1")
+	pod.SetKind("Pod")
+	pod.SetNamespace("default")
+	pod.SetName("dca-789976f5d7-2ljx6")
+	pod.SetLabels(map[string]string{"app": "dca"})
+
+	node := &unstructured.Unstructured{}
+	node.SetAPIVersion("v1")
+	node.SetKind("Node")
+	node.SetName("localhost")
+	node.SetLabels(map[string]string{"kubernetes.io/os": "linux"})
+
+	c := &APIClient{
+		Cl:        cl,
+		DynamicCl: fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), pod, node),
+	}
+
+	labels, err := c.GetInvolvedObjectLabels(v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "default", Name: "dca-789976f5d7-2ljx6"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "dca"}, labels)
+
+	labels, err = c.GetInvolvedObjectLabels(v1.ObjectReference{APIVersion: "v1", Kind: "Node", Name: "localhost"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"kubernetes.io/os": "linux"}, labels)
+
+	_, err = c.GetInvolvedObjectLabels(v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "default", Name: "deleted-pod"})
+	assert.Error(t, err)
+
+	_, err = c.GetInvolvedObjectLabels(v1.ObjectReference{APIVersion: "v1", Kind: "Service", Namespace: "default", Name: "dca"})
+	assert.Error(t, err)
+}
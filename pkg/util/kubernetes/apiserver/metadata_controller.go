@@ -327,3 +327,56 @@ func GetNodeLabels(nodeName string) (map[string]string, error) {
 	}
 	return node.Labels, nil
 }
+
+// GetServiceNamesByIP maps the cluster IPs of the services and the addresses of their endpoints to the names
+// of the services, as `<service>.<namespace>`, from the caches of the shared informers.
+func GetServiceNamesByIP() (map[string][]string, error) {
+	as, err := GetAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	if !config.Datadog.GetBool("kubernetes_collect_metadata_tags") {
+		return nil, log.Errorf("Metadata collection is disabled on the Cluster Agent")
+	}
+	services, err := as.InformerFactory.Core().V1().Services().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := as.InformerFactory.Core().V1().Endpoints().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	return mapServiceNamesByIP(services, endpoints), nil
+}
+
+func mapServiceNamesByIP(services []*corev1.Service, endpoints []*corev1.Endpoints) map[string][]string {
+	namesByIP := make(map[string]sets.String)
+	add := func(ip, namespace, name string) {
+		if ip == "" || ip == corev1.ClusterIPNone {
+			return
+		}
+		if _, ok := namesByIP[ip]; !ok {
+			namesByIP[ip] = sets.NewString()
+		}
+		namesByIP[ip].Insert(fmt.Sprintf("%s.%s", name, namespace))
+	}
+
+	for _, svc := range services {
+		add(svc.Spec.ClusterIP, svc.Namespace, svc.Name)
+	}
+
+	// the endpoints resolve the connections made to the pods directly, through headless services for instance
+	for _, ep := range endpoints {
+		for _, subset := range ep.Subsets {
+			for _, address := range subset.Addresses {
+				add(address.IP, ep.Namespace, ep.Name)
+			}
+		}
+	}
+
+	result := make(map[string][]string, len(namesByIP))
+	for ip, names := range namesByIP {
+		result[ip] = names.List()
+	}
+	return result
+}
@@ -504,3 +504,40 @@ func newFakeEndpointAddress(nodeName string, pod v1.Pod) v1.EndpointAddress {
 		},
 	}
 }
+
+func TestMapServiceNamesByIP(t *testing.T) {
+	services := []*v1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "checkout-svc", Namespace: "shop"},
+			Spec:       v1.ServiceSpec{ClusterIP: "10.96.0.10"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "headless", Namespace: "shop"},
+			Spec:       v1.ServiceSpec{ClusterIP: v1.ClusterIPNone},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "default"},
+			Spec:       v1.ServiceSpec{Type: v1.ServiceTypeExternalName},
+		},
+	}
+	endpoints := []*v1.Endpoints{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "checkout-svc", Namespace: "shop"},
+			Subsets: []v1.EndpointSubset{
+				{Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "headless", Namespace: "shop"},
+			Subsets: []v1.EndpointSubset{
+				{Addresses: []v1.EndpointAddress{{IP: "10.0.0.2"}}},
+			},
+		},
+	}
+
+	assert.Equal(t, map[string][]string{
+		"10.96.0.10": {"checkout-svc.shop"},
+		"10.0.0.1":   {"checkout-svc.shop"},
+		"10.0.0.2":   {"checkout-svc.shop", "headless.shop"},
+	}, mapServiceNamesByIP(services, endpoints))
+}
@@ -19,7 +19,7 @@ import (
 )
 
 // syncTimeout can be used to wait for the kubernetes client-go cache to sync.
-var syncTimeout = config.Datadog.GetDuration("cache_sync_timeout") * time.Second
+var syncTimeout = config.Datadog.GetDurationWithUnit("cache_sync_timeout", time.Second)
 
 // SyncInformers should be called after the instantiation of new informers.
 // It's blocking until the informers are synced or the timeout exceeded.
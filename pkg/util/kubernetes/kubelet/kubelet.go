@@ -84,14 +84,11 @@ func (ku *KubeUtil) init() error {
 func NewKubeUtil() *KubeUtil {
 	ku := &KubeUtil{
 		rawConnectionInfo:    make(map[string]string),
-		podListCacheDuration: config.Datadog.GetDuration("kubelet_cache_pods_duration") * time.Second,
+		podListCacheDuration: config.Datadog.GetDurationWithUnit("kubelet_cache_pods_duration", time.Second),
 		podUnmarshaller:      newPodUnmarshaller(),
 	}
 
-	waitOnMissingContainer := config.Datadog.GetDuration("kubelet_wait_on_missing_container")
-	if waitOnMissingContainer > 0 {
-		ku.waitOnMissingContainer = waitOnMissingContainer * time.Second
-	}
+	ku.waitOnMissingContainer = config.Datadog.GetDurationWithUnit("kubelet_wait_on_missing_container", time.Second)
 
 	return ku
 }
@@ -39,6 +39,12 @@ type DatadogLogger struct {
 	extra       map[string]seelog.LoggerInterface
 	l           sync.RWMutex
 	contextLock sync.Mutex
+
+	// overrides hold the levels of the packages logging at another level than the global one,
+	// minLevel being the lowest of them
+	overrides    []levelOverride
+	minLevel     seelog.LogLevel
+	callerLevels *sync.Map
 }
 
 // SetupLogger setup agent wide logger
@@ -63,8 +69,9 @@ func SetupJMXLogger(i seelog.LoggerInterface, level string) {
 func setupCommonLogger(i seelog.LoggerInterface, level string) *DatadogLogger {
 
 	l := &DatadogLogger{
-		inner: i,
-		extra: make(map[string]seelog.LoggerInterface),
+		inner:        i,
+		extra:        make(map[string]seelog.LoggerInterface),
+		callerLevels: &sync.Map{},
 	}
 
 	lvl, ok := seelog.LogLevelFromString(level)
@@ -116,7 +123,13 @@ func (sw *DatadogLogger) changeLogLevel(level string) error {
 
 func (sw *DatadogLogger) shouldLog(level seelog.LogLevel) bool {
 	sw.l.RLock()
-	shouldLog := level >= sw.level
+	var shouldLog bool
+	if len(sw.overrides) == 0 {
+		shouldLog = level >= sw.level
+	} else {
+		// the level of the caller is only looked up when the message may be logged
+		shouldLog = (level >= sw.level || level >= sw.minLevel) && level >= sw.callerLevel()
+	}
 	sw.l.RUnlock()
 
 	return shouldLog
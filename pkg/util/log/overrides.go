@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package log
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cihub/seelog"
+)
+
+const (
+	// agentPackagePrefix is stripped from the package of the callers, so that the patterns of the
+	// overrides are relative to the root of the repository, e.g. `pkg/forwarder`
+	agentPackagePrefix = "github.com/DataDog/datadog-agent/"
+
+	// callerSkip is the number of frames between callerLevel and the caller of the exported
+	// functions: callerLevel, shouldLog, log*, the exported function
+	callerSkip = 4
+)
+
+// levelOverride is the log level of the packages matching a pattern
+type levelOverride struct {
+	pattern string
+	level   seelog.LogLevel
+}
+
+// match returns whether the pattern matches the package or one of its parents
+func (o levelOverride) match(pkg string) bool {
+	for {
+		if matched, _ := path.Match(o.pattern, pkg); matched {
+			return true
+		}
+		parent := strings.LastIndex(pkg, "/")
+		if parent < 0 {
+			return false
+		}
+		pkg = pkg[:parent]
+	}
+}
+
+// parseLevelOverrides parses the overrides, sorted from the most specific pattern to the least
+// specific one, and returns the lowest level among them, or off when there's none
+func parseLevelOverrides(overrides map[string]string) ([]levelOverride, seelog.LogLevel, error) {
+	minLevel := seelog.LogLevel(seelog.Off)
+	parsed := make([]levelOverride, 0, len(overrides))
+	for pattern, level := range overrides {
+		lvl, ok := seelog.LogLevelFromString(strings.ToLower(level))
+		if !ok {
+			return nil, minLevel, fmt.Errorf("bad log level %s for %s", level, pattern)
+		}
+		pattern = strings.Trim(strings.TrimPrefix(pattern, agentPackagePrefix), "/")
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return nil, minLevel, fmt.Errorf("bad package pattern %s", pattern)
+		}
+		parsed = append(parsed, levelOverride{pattern: pattern, level: lvl})
+		if lvl < minLevel {
+			minLevel = lvl
+		}
+	}
+
+	sort.Slice(parsed, func(i, j int) bool {
+		if len(parsed[i].pattern) != len(parsed[j].pattern) {
+			return len(parsed[i].pattern) > len(parsed[j].pattern)
+		}
+		return parsed[i].pattern < parsed[j].pattern
+	})
+
+	return parsed, minLevel, nil
+}
+
+// callerPackage returns the package of the function of the program counter, relative to the root
+// of the repository for the agent packages
+func callerPackage(pc uintptr) string {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+
+	// the name of the function is its package path followed by its name, which may contain dots
+	// for the methods and the closures
+	name := fn.Name()
+	lastSlash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[lastSlash+1:], "."); dot >= 0 {
+		name = name[:lastSlash+1+dot]
+	}
+	return strings.TrimPrefix(name, agentPackagePrefix)
+}
+
+// callerLevel returns the log level of the caller of the exported functions, the global level unless
+// an override matches its package. It must be called with sw.l held, the overrides matching the callers
+// are cached until the overrides change.
+func (sw *DatadogLogger) callerLevel() seelog.LogLevel {
+	pc, _, _, ok := runtime.Caller(callerSkip)
+	if !ok {
+		return sw.level
+	}
+
+	var match *levelOverride
+	if cached, ok := sw.callerLevels.Load(pc); ok {
+		match = cached.(*levelOverride)
+	} else {
+		pkg := callerPackage(pc)
+		for i := range sw.overrides {
+			if sw.overrides[i].match(pkg) {
+				match = &sw.overrides[i]
+				break
+			}
+		}
+		sw.callerLevels.Store(pc, match)
+	}
+
+	if match == nil {
+		return sw.level
+	}
+	return match.level
+}
+
+func (sw *DatadogLogger) changeLogLevelOverrides(overrides map[string]string) error {
+	parsed, minLevel, err := parseLevelOverrides(overrides)
+	if err != nil {
+		return err
+	}
+
+	sw.l.Lock()
+	defer sw.l.Unlock()
+
+	sw.overrides = parsed
+	sw.minLevel = minLevel
+	sw.callerLevels = &sync.Map{}
+	return nil
+}
+
+func (sw *DatadogLogger) getLogLevelOverrides() map[string]string {
+	sw.l.RLock()
+	defer sw.l.RUnlock()
+
+	overrides := make(map[string]string, len(sw.overrides))
+	for _, override := range sw.overrides {
+		overrides[override.pattern] = override.level.String()
+	}
+	return overrides
+}
+
+// GetLogLevelOverrides returns the log levels overriding the global one for the matching packages
+func GetLogLevelOverrides() map[string]string {
+	if logger != nil && logger.inner != nil {
+		return logger.getLogLevelOverrides()
+	}
+	return map[string]string{}
+}
+
+// ChangeLogLevelOverrides changes the log levels overriding the global one for the packages matching
+// the patterns, e.g. `pkg/security` or `pkg/*/probe`. A pattern also matches the sub-packages of the
+// packages it matches and the most specific pattern wins. Like ChangeLogLevel, it requires a new
+// seelog logger whose level is the lowest of the global level and the overrides.
+func ChangeLogLevelOverrides(l seelog.LoggerInterface, overrides map[string]string) error {
+	if logger != nil && logger.inner != nil {
+		err := logger.changeLogLevelOverrides(overrides)
+		if err != nil {
+			return err
+		}
+		// See detailed explanation in SetupLogger(...)
+		err = l.SetAdditionalStackDepth(defaultStackDepth)
+		if err != nil {
+			return err
+		}
+
+		logger.replaceInnerLogger(l)
+		return nil
+	}
+	return fmt.Errorf("cannot change loglevel overrides: logger not initialized")
+}
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cihub/seelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelOverrideMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		pkg     string
+		match   bool
+	}{
+		{"pkg/forwarder", "pkg/forwarder", true},
+		{"pkg/security", "pkg/security/probe", true},
+		{"pkg/security", "pkg/securityagent", false},
+		{"pkg/*/probe", "pkg/security/probe", true},
+		{"pkg/*/probe", "pkg/security/probe/resolvers", true},
+		{"pkg/*/probe", "pkg/security/module", false},
+		{"pkg/forwarder", "github.com/cihub/seelog", false},
+	} {
+		assert.Equal(t, tc.match, levelOverride{pattern: tc.pattern}.match(tc.pkg), "%s / %s", tc.pattern, tc.pkg)
+	}
+}
+
+func TestParseLevelOverrides(t *testing.T) {
+	overrides, minLevel, err := parseLevelOverrides(map[string]string{
+		"pkg/security": "debug",
+		"github.com/DataDog/datadog-agent/pkg/security/probe": "TRACE",
+		"pkg/forwarder/": "warn",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, seelog.LogLevel(seelog.TraceLvl), minLevel)
+	assert.Equal(t, []levelOverride{
+		{pattern: "pkg/security/probe", level: seelog.TraceLvl},
+		{pattern: "pkg/forwarder", level: seelog.WarnLvl},
+		{pattern: "pkg/security", level: seelog.DebugLvl},
+	}, overrides)
+
+	_, _, err = parseLevelOverrides(map[string]string{"pkg/security": "verbose"})
+	assert.Error(t, err)
+	_, _, err = parseLevelOverrides(map[string]string{"pkg/[security": "debug"})
+	assert.Error(t, err)
+}
+
+func TestCallerPackage(t *testing.T) {
+	pc, _, _, _ := runtime.Caller(0)
+	assert.Equal(t, "pkg/util/log", callerPackage(pc))
+
+	func() {
+		pc, _, _, _ := runtime.Caller(0)
+		assert.Equal(t, "pkg/util/log", callerPackage(pc))
+	}()
+}
+
+func TestLogLevelOverrides(t *testing.T) {
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+
+	l, err := seelog.LoggerFromWriterWithMinLevelAndFormat(w, seelog.TraceLvl, "[%LEVEL] %FuncShort: %Msg\n")
+	require.NoError(t, err)
+
+	SetupLogger(l, "warn")
+	require.NoError(t, ChangeLogLevelOverrides(l, map[string]string{
+		"pkg/util/log":  "debug",
+		"pkg/forwarder": "trace",
+	}))
+	assert.Equal(t, map[string]string{"pkg/util/log": "debug", "pkg/forwarder": "trace"}, GetLogLevelOverrides())
+
+	// this package logs at debug, the trace level of pkg/forwarder doesn't apply to it
+	Tracef("%s", "foo")
+	Debugf("%s", "foo")
+	Infof("%s", "foo")
+	Info("foo")
+	Warnf("%s", "foo")
+	w.Flush()
+	assert.Equal(t, 4, strings.Count(b.String(), "foo"))
+
+	// the global level applies again once the overrides are cleared
+	require.NoError(t, ChangeLogLevelOverrides(l, map[string]string{}))
+	Debugf("%s", "bar")
+	Warnf("%s", "bar")
+	w.Flush()
+	assert.Equal(t, 1, strings.Count(b.String(), "bar"))
+
+	// an override can also raise the level of a package above the global one
+	require.NoError(t, changeLogLevel("debug"))
+	require.NoError(t, ChangeLogLevelOverrides(l, map[string]string{"pkg/util": "error"}))
+	Debugf("%s", "baz")
+	Errorf("%s", "baz")
+	w.Flush()
+	assert.Equal(t, 1, strings.Count(b.String(), "baz"))
+
+	assert.Error(t, ChangeLogLevelOverrides(l, map[string]string{"pkg/util": "verbose"}))
+	assert.Equal(t, map[string]string{"pkg/util": "error"}, GetLogLevelOverrides())
+}
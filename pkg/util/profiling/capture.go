@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package profiling
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
+)
+
+// The runtime profiles which can be captured with CaptureProfiles
+const (
+	CPUProfile       = "cpu"
+	HeapProfile      = "heap"
+	MutexProfile     = "mutex"
+	GoroutineProfile = "goroutine"
+)
+
+// mutexProfileFraction is the rate of the mutex contention events reported while capturing a mutex
+// profile, when the mutex profiling isn't already enabled
+const mutexProfileFraction = 10
+
+// CaptureProfileTypes lists the profiles which can be captured, they are all captured when none is requested
+var CaptureProfileTypes = []string{CPUProfile, HeapProfile, MutexProfile, GoroutineProfile}
+
+// ErrCaptureInProgress is returned when a capture is requested while another one is running
+var ErrCaptureInProgress = errors.New("a profile capture is already in progress")
+
+var capturing int32
+
+// IsCaptureProfileType returns whether the profile type can be captured with CaptureProfiles
+func IsCaptureProfileType(profile string) bool {
+	for _, p := range CaptureProfileTypes {
+		if p == profile {
+			return true
+		}
+	}
+	return false
+}
+
+// CaptureProfiles captures the requested runtime profiles and returns them as a gzipped tarball of pprof
+// files. The CPU and mutex profiles cover the given duration, while the heap and goroutine profiles are
+// snapshots taken at the end of it. Only one capture can run at a time.
+func CaptureProfiles(ctx context.Context, duration time.Duration, profiles []string) ([]byte, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("invalid profile duration %s", duration)
+	}
+	if len(profiles) == 0 {
+		profiles = CaptureProfileTypes
+	}
+	requested := make(map[string]bool)
+	for _, profile := range profiles {
+		if !IsCaptureProfileType(profile) {
+			return nil, fmt.Errorf("unknown profile type '%s'", profile)
+		}
+		requested[profile] = true
+	}
+
+	if !atomic.CompareAndSwapInt32(&capturing, 0, 1) {
+		return nil, ErrCaptureInProgress
+	}
+	defer atomic.StoreInt32(&capturing, 0)
+
+	var cpuProfile bytes.Buffer
+	if requested[CPUProfile] {
+		if err := pprof.StartCPUProfile(&cpuProfile); err != nil {
+			return nil, fmt.Errorf("unable to start the CPU profile: %s", err)
+		}
+	}
+
+	// the mutex profiling is left untouched when it was already enabled
+	if requested[MutexProfile] && runtime.SetMutexProfileFraction(-1) == 0 {
+		runtime.SetMutexProfileFraction(mutexProfileFraction)
+		defer runtime.SetMutexProfileFraction(0)
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		if requested[CPUProfile] {
+			pprof.StopCPUProfile()
+		}
+		return nil, ctx.Err()
+	}
+
+	files := make(map[string][]byte)
+	if requested[CPUProfile] {
+		pprof.StopCPUProfile()
+		files[CPUProfile] = cpuProfile.Bytes()
+	}
+	for _, name := range []string{MutexProfile, HeapProfile, GoroutineProfile} {
+		if !requested[name] {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := pprof.Lookup(name).WriteTo(&buf, 0); err != nil {
+			return nil, fmt.Errorf("unable to write the %s profile: %s", name, err)
+		}
+		files[name] = buf.Bytes()
+	}
+
+	return archiveProfiles(files, CaptureProfileTypes)
+}
+
+// archiveProfiles writes the profiles to a gzipped tarball, in the given order
+func archiveProfiles(files map[string][]byte, order []string) ([]byte, error) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+
+	now := time.Now()
+	for _, name := range order {
+		data, found := files[name]
+		if !found {
+			continue
+		}
+		header := &tar.Header{
+			Name:    name + ".pprof",
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return archive.Bytes(), nil
+}
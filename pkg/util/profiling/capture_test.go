@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package profiling
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func archiveEntries(t *testing.T, archive []byte) []string {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	var entries []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.NotZero(t, header.Size, header.Name)
+		entries = append(entries, header.Name)
+	}
+	return entries
+}
+
+func TestCaptureProfiles(t *testing.T) {
+	archive, err := CaptureProfiles(context.Background(), 100*time.Millisecond, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cpu.pprof", "heap.pprof", "mutex.pprof", "goroutine.pprof"}, archiveEntries(t, archive))
+
+	archive, err = CaptureProfiles(context.Background(), time.Millisecond, []string{GoroutineProfile, HeapProfile})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"heap.pprof", "goroutine.pprof"}, archiveEntries(t, archive))
+}
+
+func TestCaptureProfilesInvalid(t *testing.T) {
+	_, err := CaptureProfiles(context.Background(), time.Second, []string{"block"})
+	assert.Error(t, err)
+
+	_, err = CaptureProfiles(context.Background(), 0, nil)
+	assert.Error(t, err)
+}
+
+func TestCaptureProfilesInProgress(t *testing.T) {
+	atomic.StoreInt32(&capturing, 1)
+	defer atomic.StoreInt32(&capturing, 0)
+
+	_, err := CaptureProfiles(context.Background(), time.Millisecond, nil)
+	assert.Equal(t, ErrCaptureInProgress, err)
+}
+
+func TestCaptureProfilesCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := CaptureProfiles(ctx, time.Minute, []string{CPUProfile})
+	assert.Equal(t, context.Canceled, err)
+
+	// the CPU profile was stopped, so that a new capture can start
+	_, err = CaptureProfiles(context.Background(), time.Millisecond, []string{CPUProfile})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,215 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package subprocess
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// cpuPeriod is the CFS period of the cgroup, in microseconds
+	cpuPeriod = 100000
+	// minCPUQuota is the minimum CFS quota accepted by the kernel, in microseconds
+	minCPUQuota = 1000
+	// shellPath is the shell running the confinement wrapper
+	shellPath = "/bin/sh"
+	// confineScript is run by the shell before executing the command: it moves itself to the cgroup,
+	// writing its pid in the cgroup.procs files listed before "--", then executes the command given
+	// after "--" in place of itself. The command still runs when the shell can't be moved.
+	confineScript = `while [ "$1" != -- ]; do { echo $$ > "$1"; } 2>/dev/null; shift; done; shift; exec "$@"`
+)
+
+var (
+	subprocessCgroup *cgroup
+	cgroupMutex      sync.RWMutex
+)
+
+// cgroup is a cgroup with one directory per hierarchy: a single one on cgroup v2, and
+// one for the cpu controller and one for the memory controller on cgroup v1
+type cgroup struct {
+	dirs []string
+}
+
+// Setup creates the cgroup of the subprocesses with the limits set in the configuration.
+// It does nothing unless subprocess_cgroup.enabled is set.
+func Setup() error {
+	if !config.Datadog.GetBool("subprocess_cgroup.enabled") {
+		return nil
+	}
+
+	cg, err := newCgroup(
+		config.Datadog.GetString("container_cgroup_root"),
+		config.Datadog.GetString("subprocess_cgroup.name"),
+		config.Datadog.GetFloat64("subprocess_cgroup.max_cpu_percent"),
+		config.Datadog.GetInt64("subprocess_cgroup.max_memory"),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to create the cgroup of the subprocesses: %s", err)
+	}
+	log.Infof("The subprocesses of the checks run in the cgroup %v", cg.dirs)
+
+	cgroupMutex.Lock()
+	defer cgroupMutex.Unlock()
+	subprocessCgroup = cg
+	return nil
+}
+
+// PreExecArgs returns the arguments to prepend to a command so that it runs in the cgroup of the
+// subprocesses from its start, before it can fork. It's empty if the cgroup wasn't set up.
+func PreExecArgs() []string {
+	cgroupMutex.RLock()
+	defer cgroupMutex.RUnlock()
+	if subprocessCgroup == nil {
+		return nil
+	}
+	if _, err := os.Stat(shellPath); err != nil {
+		return nil
+	}
+
+	args := []string{shellPath, "-c", confineScript, "datadog-subprocess"}
+	for _, dir := range subprocessCgroup.dirs {
+		args = append(args, filepath.Join(dir, "cgroup.procs"))
+	}
+	return append(args, "--")
+}
+
+// newCgroup creates, or reuses, the cgroup name under the cgroup root and sets its limits.
+// A limit of 0 means unlimited.
+func newCgroup(root string, name string, maxCPUPercent float64, maxMemory int64) (*cgroup, error) {
+	if name == "" || filepath.IsAbs(name) || strings.HasPrefix(filepath.Clean(name), "..") {
+		return nil, fmt.Errorf("invalid cgroup name %q", name)
+	}
+	if maxCPUPercent < 0 || maxMemory < 0 {
+		return nil, fmt.Errorf("the limits can't be negative")
+	}
+
+	quota := int64(-1)
+	if maxCPUPercent > 0 {
+		quota = int64(maxCPUPercent / 100 * cpuPeriod)
+		if quota < minCPUQuota {
+			quota = minCPUQuota
+		}
+	}
+
+	// cgroup v2 exposes the available controllers at the root of the unified hierarchy
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		dir := filepath.Join(root, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		// the controllers have to be enabled in every ancestor of the cgroup, from the root down
+		var ancestors []string
+		for parent := filepath.Dir(dir); ; parent = filepath.Dir(parent) {
+			ancestors = append([]string{parent}, ancestors...)
+			if parent == filepath.Clean(root) {
+				break
+			}
+		}
+		for _, ancestor := range ancestors {
+			if err := enableControllers(ancestor, "cpu", "memory"); err != nil {
+				return nil, err
+			}
+		}
+
+		cpuMax := "max"
+		if quota > 0 {
+			cpuMax = strconv.FormatInt(quota, 10)
+		}
+		if err := writeFile(filepath.Join(dir, "cpu.max"), fmt.Sprintf("%s %d", cpuMax, cpuPeriod)); err != nil {
+			return nil, err
+		}
+		memoryMax := "max"
+		if maxMemory > 0 {
+			memoryMax = strconv.FormatInt(maxMemory, 10)
+		}
+		if err := writeFile(filepath.Join(dir, "memory.max"), memoryMax); err != nil {
+			return nil, err
+		}
+		return &cgroup{dirs: []string{dir}}, nil
+	}
+
+	cpuDir := filepath.Join(root, "cpu", name)
+	memoryDir := filepath.Join(root, "memory", name)
+	for _, dir := range []string{cpuDir, memoryDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeFile(filepath.Join(cpuDir, "cpu.cfs_period_us"), strconv.Itoa(cpuPeriod)); err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(cpuDir, "cpu.cfs_quota_us"), strconv.FormatInt(quota, 10)); err != nil {
+		return nil, err
+	}
+	memoryLimit := int64(-1)
+	if maxMemory > 0 {
+		memoryLimit = maxMemory
+	}
+	if err := writeFile(filepath.Join(memoryDir, "memory.limit_in_bytes"), strconv.FormatInt(memoryLimit, 10)); err != nil {
+		return nil, err
+	}
+	return &cgroup{dirs: []string{cpuDir, memoryDir}}, nil
+}
+
+// enableControllers enables the controllers missing from the subtree_control of a cgroup v2, so that
+// they're available to its children
+func enableControllers(dir string, controllers ...string) error {
+	available := readControllers(filepath.Join(dir, "cgroup.controllers"))
+	enabled := readControllers(filepath.Join(dir, "cgroup.subtree_control"))
+
+	var missing []string
+	for _, controller := range controllers {
+		if enabled[controller] {
+			continue
+		}
+		// the controllers of the intermediate cgroups are only known once their parent enabled them
+		if available != nil && !available[controller] {
+			return fmt.Errorf("the %s controller isn't available in %s", controller, dir)
+		}
+		missing = append(missing, "+"+controller)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if err := writeFile(filepath.Join(dir, "cgroup.subtree_control"), strings.Join(missing, " ")); err != nil {
+		if pathErr, ok := err.(*os.PathError); ok && pathErr.Err == syscall.EBUSY {
+			return fmt.Errorf("unable to enable the %s controllers in %s, which holds processes: only the root cgroup and the cgroups without processes can enable them, pick another subprocess_cgroup.name", strings.Join(controllers, " and "), dir)
+		}
+		return fmt.Errorf("unable to enable the %s controllers in %s: %s", strings.Join(controllers, " and "), dir, err)
+	}
+	return nil
+}
+
+// readControllers returns the controllers listed in a cgroup.controllers or cgroup.subtree_control
+// file, or nil if it can't be read
+func readControllers(path string) map[string]bool {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	controllers := make(map[string]bool)
+	for _, controller := range strings.Fields(string(content)) {
+		controllers[strings.TrimPrefix(controller, "+")] = true
+	}
+	return controllers
+}
+
+func writeFile(path string, content string) error {
+	return ioutil.WriteFile(path, []byte(content), 0644)
+}
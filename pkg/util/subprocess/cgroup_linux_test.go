@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package subprocess
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFile(t *testing.T, path string) string {
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestNewCgroupUnified(t *testing.T) {
+	root, err := ioutil.TempDir("", "cgroup")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory pids"), 0644))
+
+	cg, err := newCgroup(root, "datadog-agent-subprocesses", 50, 256*1024*1024)
+	require.NoError(t, err)
+
+	dir := filepath.Join(root, "datadog-agent-subprocesses")
+	assert.Equal(t, []string{dir}, cg.dirs)
+	assert.Equal(t, "+cpu +memory", readFile(t, filepath.Join(root, "cgroup.subtree_control")))
+	assert.Equal(t, "50000 100000", readFile(t, filepath.Join(dir, "cpu.max")))
+	assert.Equal(t, "268435456", readFile(t, filepath.Join(dir, "memory.max")))
+}
+
+func TestNewCgroupUnifiedUnlimited(t *testing.T) {
+	root, err := ioutil.TempDir("", "cgroup")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory"), 0644))
+
+	_, err = newCgroup(root, "subprocesses", 0, 0)
+	require.NoError(t, err)
+
+	dir := filepath.Join(root, "subprocesses")
+	assert.Equal(t, "max 100000", readFile(t, filepath.Join(dir, "cpu.max")))
+	assert.Equal(t, "max", readFile(t, filepath.Join(dir, "memory.max")))
+}
+
+func TestNewCgroupLegacy(t *testing.T) {
+	root, err := ioutil.TempDir("", "cgroup")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	cg, err := newCgroup(root, "datadog-agent-subprocesses", 150, 0)
+	require.NoError(t, err)
+
+	cpuDir := filepath.Join(root, "cpu", "datadog-agent-subprocesses")
+	memoryDir := filepath.Join(root, "memory", "datadog-agent-subprocesses")
+	assert.Equal(t, []string{cpuDir, memoryDir}, cg.dirs)
+	assert.Equal(t, "100000", readFile(t, filepath.Join(cpuDir, "cpu.cfs_period_us")))
+	assert.Equal(t, "150000", readFile(t, filepath.Join(cpuDir, "cpu.cfs_quota_us")))
+	assert.Equal(t, "-1", readFile(t, filepath.Join(memoryDir, "memory.limit_in_bytes")))
+}
+
+func TestNewCgroupInvalid(t *testing.T) {
+	for _, name := range []string{"", "/sys/fs/cgroup/agent", "../agent", ".."} {
+		_, err := newCgroup("/sys/fs/cgroup", name, 0, 0)
+		assert.Error(t, err, name)
+	}
+
+	_, err := newCgroup("/sys/fs/cgroup", "agent", -1, 0)
+	assert.Error(t, err)
+}
+
+func TestNewCgroupUnifiedNested(t *testing.T) {
+	root, err := ioutil.TempDir("", "cgroup")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory"), 0644))
+
+	// the controllers already enabled aren't written again
+	require.NoError(t, os.Mkdir(filepath.Join(root, "datadog"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "datadog", "cgroup.subtree_control"), []byte("cpu"), 0644))
+
+	_, err = newCgroup(root, "datadog/subprocesses", 0.1, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, "+cpu +memory", readFile(t, filepath.Join(root, "cgroup.subtree_control")))
+	assert.Equal(t, "+memory", readFile(t, filepath.Join(root, "datadog", "cgroup.subtree_control")))
+	assert.Equal(t, "1000 100000", readFile(t, filepath.Join(root, "datadog", "subprocesses", "cpu.max")))
+}
+
+func TestNewCgroupUnifiedUnavailableController(t *testing.T) {
+	root, err := ioutil.TempDir("", "cgroup")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu pids"), 0644))
+
+	_, err = newCgroup(root, "subprocesses", 0, 0)
+	assert.EqualError(t, err, "the memory controller isn't available in "+root)
+}
+
+func TestConfine(t *testing.T) {
+	root, err := ioutil.TempDir("", "cgroup")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	cg, err := newCgroup(root, "subprocesses", 0, 0)
+	require.NoError(t, err)
+
+	cgroupMutex.Lock()
+	subprocessCgroup = cg
+	cgroupMutex.Unlock()
+	defer func() {
+		cgroupMutex.Lock()
+		subprocessCgroup = nil
+		cgroupMutex.Unlock()
+	}()
+
+	// the wrapper moves itself to the cgroup before executing the command, keeping its pid
+	cmd := exec.Command("sh", "-c", "echo $$", "arg")
+	out, err := Output(cmd)
+	require.NoError(t, err)
+	pid := strings.TrimSpace(string(out))
+	assert.Equal(t, strconv.Itoa(cmd.Process.Pid), pid)
+	assert.Equal(t, pid+"\n", readFile(t, filepath.Join(root, "cpu", "subprocesses", "cgroup.procs")))
+	assert.Equal(t, pid+"\n", readFile(t, filepath.Join(root, "memory", "subprocesses", "cgroup.procs")))
+
+	// the command still runs when it can't be moved
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "memory")))
+	out, err = Output(exec.Command("echo", "hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(out))
+
+	_, err = Output(exec.Command("/nonexistent/binary"))
+	assert.IsType(t, &exec.Error{}, err)
+}
@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux
+
+package subprocess
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Setup does nothing, the cgroups are only available on Linux
+func Setup() error {
+	if config.Datadog.GetBool("subprocess_cgroup.enabled") {
+		log.Warnf("subprocess_cgroup is only supported on Linux, the subprocesses of the checks run without limits")
+	}
+	return nil
+}
+
+// PreExecArgs returns nothing, the cgroups are only available on Linux
+func PreExecArgs() []string {
+	return nil
+}
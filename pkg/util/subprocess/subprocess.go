@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package subprocess runs the processes spawned on behalf of the checks in a dedicated
+// cgroup, so that their CPU and memory usage can be limited
+package subprocess
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+)
+
+// Start starts a command in the cgroup of the subprocesses. The command is run through a wrapper
+// moving itself to the cgroup before executing it, so that the processes it forks are confined too.
+// The command still runs when it can't be moved to the cgroup.
+func Start(cmd *exec.Cmd) error {
+	preExecArgs := PreExecArgs()
+	if len(preExecArgs) == 0 {
+		return cmd.Start()
+	}
+
+	// the path was looked up by exec.Command, a lookup error is still returned by cmd.Start. A missing
+	// absolute path is reported as well, instead of failing in the wrapper.
+	if filepath.IsAbs(cmd.Path) {
+		if _, err := exec.LookPath(cmd.Path); err != nil {
+			return err
+		}
+	}
+
+	args := append(preExecArgs, cmd.Path)
+	if len(cmd.Args) > 1 {
+		args = append(args, cmd.Args[1:]...)
+	}
+	cmd.Path = preExecArgs[0]
+	cmd.Args = args
+	return cmd.Start()
+}
+
+// Output runs a command like exec.Cmd.Output, in the cgroup of the subprocesses
+func Output(cmd *exec.Cmd) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := Start(cmd); err != nil {
+		return nil, err
+	}
+	err := cmd.Wait()
+	return stdout.Bytes(), err
+}
@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !windows
+
+package subprocess
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutput(t *testing.T) {
+	out, err := Output(exec.Command("echo", "hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(out))
+
+	_, err = Output(exec.Command("sh", "-c", "exit 3"))
+	require.Error(t, err)
+	assert.IsType(t, &exec.ExitError{}, err)
+
+	_, err = Output(exec.Command("/nonexistent/binary"))
+	assert.Error(t, err)
+}
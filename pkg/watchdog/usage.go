@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package watchdog
+
+import (
+	"os"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+// processUsage computes the resource usage of the current process. CPU usage
+// is averaged between two consecutive calls.
+type processUsage struct {
+	proc        *process.Process
+	lastCPUTime float64
+	lastCheck   time.Time
+}
+
+func newProcessUsage() (*processUsage, error) {
+	proc, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return nil, err
+	}
+	return &processUsage{proc: proc}, nil
+}
+
+func (p *processUsage) get() (Usage, error) {
+	var usage Usage
+
+	mem, err := p.proc.MemoryInfo()
+	if err != nil {
+		return usage, err
+	}
+	usage.RSS = mem.RSS
+
+	times, err := p.proc.Times()
+	if err != nil {
+		return usage, err
+	}
+
+	now := time.Now()
+	cpuTime := times.User + times.System
+	if !p.lastCheck.IsZero() {
+		if elapsed := now.Sub(p.lastCheck).Seconds(); elapsed > 0 {
+			usage.CPUPercent = 100 * (cpuTime - p.lastCPUTime) / elapsed
+		}
+	}
+	p.lastCPUTime = cpuTime
+	p.lastCheck = now
+
+	return usage, nil
+}
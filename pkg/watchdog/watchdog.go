@@ -0,0 +1,291 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package watchdog
+
+import (
+	"expvar"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// recoveryRatio is the fraction of the budgets the agent has to go back under
+// before leaving the degraded mode, so that we don't flap around the limits.
+const recoveryRatio = 0.8
+
+var (
+	watchdogExpvars = expvar.NewMap("watchdog")
+	stateExpvar     = expvar.String{}
+	cpuExpvar       = expvar.Float{}
+	rssExpvar       = expvar.Int{}
+)
+
+func init() {
+	watchdogExpvars.Set("State", &stateExpvar)
+	watchdogExpvars.Set("CPUPercent", &cpuExpvar)
+	watchdogExpvars.Set("RSS", &rssExpvar)
+	stateExpvar.Set(Normal.String())
+}
+
+// Shedder is implemented by the components that are able to reduce their
+// resource consumption when the agent goes over its budgets.
+type Shedder interface {
+	// Shed is called when the agent enters the degraded mode
+	Shed()
+	// Restore is called when the agent leaves the degraded mode
+	Restore()
+}
+
+// State represents the state of the watchdog
+type State int
+
+const (
+	// Normal means that the agent runs within its budgets
+	Normal State = iota
+	// Degraded means that the agent went over its budgets and shed load
+	Degraded
+)
+
+func (s State) String() string {
+	switch s {
+	case Normal:
+		return "normal"
+	case Degraded:
+		return "degraded"
+	}
+	return "unknown"
+}
+
+// Config holds the budgets enforced by the watchdog
+type Config struct {
+	// MaxCPUPercent is the maximum CPU usage of the agent, 100 being a full core. 0 disables the check.
+	MaxCPUPercent float64
+	// MaxRSS is the maximum resident memory of the agent in bytes. 0 disables the check.
+	MaxRSS uint64
+	// CheckInterval is the interval between two resource usage checks
+	CheckInterval time.Duration
+	// Tolerance is the number of consecutive checks over (or under) the budgets
+	// required to enter (or leave) the degraded mode
+	Tolerance int
+}
+
+// Usage holds the resource usage of the agent process
+type Usage struct {
+	CPUPercent float64
+	RSS        uint64
+}
+
+// Watchdog tracks the agent resource usage against its budgets and sheds load
+// when they are exceeded
+type Watchdog struct {
+	sync.Mutex
+	config     Config
+	getUsage   func() (Usage, error)
+	sendEvent  func(title, text string, alertType metrics.EventAlertType)
+	shedders   map[string]Shedder
+	state      State
+	strikes    int
+	recoveries int
+	stop       chan struct{}
+	stopped    chan struct{}
+}
+
+// NewWatchdog returns a new watchdog enforcing the given budgets
+func NewWatchdog(config Config) (*Watchdog, error) {
+	if config.CheckInterval <= 0 {
+		return nil, fmt.Errorf("invalid watchdog check interval: %s", config.CheckInterval)
+	}
+	if config.Tolerance < 1 {
+		config.Tolerance = 1
+	}
+
+	usage, err := newProcessUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Watchdog{
+		config:    config,
+		getUsage:  usage.get,
+		sendEvent: sendAgentEvent,
+		shedders:  make(map[string]Shedder),
+	}, nil
+}
+
+// Register adds a component to the list of components that shed load in degraded mode.
+// A component registered while the agent is degraded sheds load right away.
+func (w *Watchdog) Register(name string, shedder Shedder) {
+	w.Lock()
+	defer w.Unlock()
+
+	w.shedders[name] = shedder
+	if w.state == Degraded {
+		shedder.Shed()
+	}
+}
+
+// State returns the current state of the watchdog
+func (w *Watchdog) State() State {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.state
+}
+
+// Start starts monitoring the agent resource usage
+func (w *Watchdog) Start() {
+	w.stop = make(chan struct{})
+	w.stopped = make(chan struct{})
+
+	go func() {
+		defer close(w.stopped)
+
+		ticker := time.NewTicker(w.config.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.check()
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the watchdog and restores the components if the agent was degraded
+func (w *Watchdog) Stop() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	<-w.stopped
+	w.stop = nil
+
+	w.Lock()
+	defer w.Unlock()
+	if w.state == Degraded {
+		w.restore()
+	}
+}
+
+func (w *Watchdog) overBudget(usage Usage, ratio float64) bool {
+	if w.config.MaxCPUPercent > 0 && usage.CPUPercent > w.config.MaxCPUPercent*ratio {
+		return true
+	}
+	if w.config.MaxRSS > 0 && float64(usage.RSS) > float64(w.config.MaxRSS)*ratio {
+		return true
+	}
+	return false
+}
+
+func (w *Watchdog) check() {
+	usage, err := w.getUsage()
+	if err != nil {
+		log.Debugf("Unable to retrieve the agent resource usage: %s", err)
+		return
+	}
+
+	cpuExpvar.Set(usage.CPUPercent)
+	rssExpvar.Set(int64(usage.RSS))
+
+	w.Lock()
+	defer w.Unlock()
+
+	switch w.state {
+	case Normal:
+		if !w.overBudget(usage, 1) {
+			w.strikes = 0
+			return
+		}
+
+		w.strikes++
+		if w.strikes >= w.config.Tolerance {
+			w.shed(usage)
+		}
+	case Degraded:
+		if w.overBudget(usage, recoveryRatio) {
+			w.recoveries = 0
+			return
+		}
+
+		w.recoveries++
+		if w.recoveries >= w.config.Tolerance {
+			w.restore()
+			w.sendEvent(
+				"Datadog Agent left degraded mode",
+				fmt.Sprintf("The agent resource usage went back under its budgets (cpu: %.1f%%, rss: %d bytes), all features were restored.", usage.CPUPercent, usage.RSS),
+				metrics.EventAlertTypeSuccess,
+			)
+		}
+	}
+}
+
+func (w *Watchdog) shed(usage Usage) {
+	names := w.shedderNames()
+	log.Warnf("Agent resource usage over budget (cpu: %.1f%%, rss: %d bytes), entering degraded mode: shedding load from %v", usage.CPUPercent, usage.RSS, names)
+
+	for _, name := range names {
+		w.shedders[name].Shed()
+	}
+
+	w.state = Degraded
+	w.strikes = 0
+	stateExpvar.Set(w.state.String())
+
+	w.sendEvent(
+		"Datadog Agent entered degraded mode",
+		fmt.Sprintf("The agent resource usage went over its budgets (cpu: %.1f%%, rss: %d bytes). The following components were throttled: %v", usage.CPUPercent, usage.RSS, names),
+		metrics.EventAlertTypeWarning,
+	)
+}
+
+func (w *Watchdog) restore() {
+	names := w.shedderNames()
+	log.Infof("Agent resource usage back under budget, leaving degraded mode: restoring %v", names)
+
+	for _, name := range names {
+		w.shedders[name].Restore()
+	}
+
+	w.state = Normal
+	w.recoveries = 0
+	stateExpvar.Set(w.state.String())
+}
+
+func (w *Watchdog) shedderNames() []string {
+	names := make([]string, 0, len(w.shedders))
+	for name := range w.shedders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sendAgentEvent(title, text string, alertType metrics.EventAlertType) {
+	sender, err := aggregator.GetDefaultSender()
+	if err != nil {
+		log.Errorf("Unable to send watchdog event: %s", err)
+		return
+	}
+
+	sender.Event(metrics.Event{
+		Title:          title,
+		Text:           text,
+		AlertType:      alertType,
+		Priority:       metrics.EventPriorityNormal,
+		SourceTypeName: "datadog",
+		EventType:      "datadog.agent.watchdog",
+		Ts:             time.Now().Unix(),
+	})
+	sender.Commit()
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package watchdog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+type mockShedder struct {
+	shed     int
+	restored int
+}
+
+func (m *mockShedder) Shed()    { m.shed++ }
+func (m *mockShedder) Restore() { m.restored++ }
+
+func newTestWatchdog(usage *Usage) (*Watchdog, *[]metrics.EventAlertType) {
+	var events []metrics.EventAlertType
+	w := &Watchdog{
+		config: Config{
+			MaxCPUPercent: 50,
+			MaxRSS:        1000,
+			CheckInterval: time.Second,
+			Tolerance:     2,
+		},
+		getUsage: func() (Usage, error) { return *usage, nil },
+		sendEvent: func(title, text string, alertType metrics.EventAlertType) {
+			events = append(events, alertType)
+		},
+		shedders: make(map[string]Shedder),
+	}
+	return w, &events
+}
+
+func TestWatchdogDegradedMode(t *testing.T) {
+	usage := &Usage{CPUPercent: 10, RSS: 100}
+	w, events := newTestWatchdog(usage)
+
+	shedder := &mockShedder{}
+	w.Register("test", shedder)
+
+	w.check()
+	assert.Equal(t, Normal, w.State())
+
+	// a single spike is tolerated
+	usage.CPUPercent = 80
+	w.check()
+	assert.Equal(t, Normal, w.State())
+	assert.Equal(t, 0, shedder.shed)
+
+	w.check()
+	assert.Equal(t, Degraded, w.State())
+	assert.Equal(t, 1, shedder.shed)
+	assert.Equal(t, []metrics.EventAlertType{metrics.EventAlertTypeWarning}, *events)
+
+	// under the budget but not under the recovery threshold
+	usage.CPUPercent = 45
+	w.check()
+	w.check()
+	assert.Equal(t, Degraded, w.State())
+
+	usage.CPUPercent = 10
+	w.check()
+	assert.Equal(t, Degraded, w.State())
+	w.check()
+	assert.Equal(t, Normal, w.State())
+	assert.Equal(t, 1, shedder.restored)
+	assert.Equal(t, []metrics.EventAlertType{metrics.EventAlertTypeWarning, metrics.EventAlertTypeSuccess}, *events)
+}
+
+func TestWatchdogRSSBudget(t *testing.T) {
+	usage := &Usage{RSS: 2000}
+	w, _ := newTestWatchdog(usage)
+
+	w.check()
+	w.check()
+	assert.Equal(t, Degraded, w.State())
+
+	// components registered while degraded shed load right away
+	shedder := &mockShedder{}
+	w.Register("late", shedder)
+	assert.Equal(t, 1, shedder.shed)
+}
+
+func TestWatchdogStrikesReset(t *testing.T) {
+	usage := &Usage{CPUPercent: 80}
+	w, _ := newTestWatchdog(usage)
+
+	w.check()
+	usage.CPUPercent = 10
+	w.check()
+	usage.CPUPercent = 80
+	w.check()
+	assert.Equal(t, Normal, w.State())
+}
@@ -17,6 +17,7 @@ static cb_tracemalloc_enabled_t cb_tracemalloc_enabled = NULL;
 static cb_get_version_t cb_get_version = NULL;
 static cb_headers_t cb_headers = NULL;
 static cb_set_check_metadata_t cb_set_check_metadata = NULL;
+static cb_set_metric_metadata_t cb_set_metric_metadata = NULL;
 static cb_set_external_tags_t cb_set_external_tags = NULL;
 static cb_write_persistent_cache_t cb_write_persistent_cache = NULL;
 static cb_read_persistent_cache_t cb_read_persistent_cache = NULL;
@@ -31,6 +32,7 @@ static PyObject *get_version(PyObject *self, PyObject *args);
 static PyObject *headers(PyObject *self, PyObject *args, PyObject *kwargs);
 static PyObject *log_message(PyObject *self, PyObject *args);
 static PyObject *set_check_metadata(PyObject *self, PyObject *args);
+static PyObject *set_metric_metadata(PyObject *self, PyObject *args, PyObject *kwargs);
 static PyObject *set_external_tags(PyObject *self, PyObject *args);
 static PyObject *write_persistent_cache(PyObject *self, PyObject *args);
 static PyObject *read_persistent_cache(PyObject *self, PyObject *args);
@@ -45,6 +47,8 @@ static PyMethodDef methods[] = {
     { "headers", (PyCFunction)headers, METH_VARARGS | METH_KEYWORDS, "Get standard set of HTTP headers." },
     { "log", log_message, METH_VARARGS, "Log a message through the agent logger." },
     { "set_check_metadata", set_check_metadata, METH_VARARGS, "Send metadata for Checks." },
+    { "set_metric_metadata", (PyCFunction)set_metric_metadata, METH_VARARGS | METH_KEYWORDS,
+      "Declare the unit, type and description of a metric." },
     { "set_external_tags", set_external_tags, METH_VARARGS, "Send external host tags." },
     { "write_persistent_cache", write_persistent_cache, METH_VARARGS, "Store a value for a given key." },
     { "read_persistent_cache", read_persistent_cache, METH_VARARGS, "Retrieve the value associated with a key." },
@@ -99,6 +103,11 @@ void _set_set_check_metadata_cb(cb_set_check_metadata_t cb)
     cb_set_check_metadata = cb;
 }
 
+void _set_set_metric_metadata_cb(cb_set_metric_metadata_t cb)
+{
+    cb_set_metric_metadata = cb;
+}
+
 void _set_write_persistent_cache_cb(cb_write_persistent_cache_t cb)
 {
     cb_write_persistent_cache = cb;
@@ -422,6 +431,48 @@ static PyObject *set_check_metadata(PyObject *self, PyObject *args)
     Py_RETURN_NONE;
 }
 
+/*! \fn PyObject *set_metric_metadata(PyObject *self, PyObject *args, PyObject *kwargs)
+    \brief This function implements the `datadog_agent.set_metric_metadata` method, declaring
+    the metadata of a metric.
+    \param self A PyObject* pointer to the `datadog_agent` module.
+    \param args A PyObject* pointer to a tuple containing the name of the metric, and
+    optionally its unit, its type and its description.
+    \param kwargs A PyObject* pointer to a dictionary containing the optional `unit`,
+    `metric_type` and `description` arguments.
+    \return A PyObject* pointer to `None`.
+
+    This function is callable as the `datadog_agent.set_metric_metadata` Python method and
+    uses the `cb_set_metric_metadata()` callback to forward the metadata to the agent
+    with CGO. The arguments which are not provided are passed as empty strings. If the
+    callback has not been set `None` will be returned.
+*/
+static PyObject *set_metric_metadata(PyObject *self, PyObject *args, PyObject *kwargs)
+{
+    // callback must be set
+    if (cb_set_metric_metadata == NULL) {
+        Py_RETURN_NONE;
+    }
+
+    char *name = NULL;
+    char *unit = "";
+    char *metric_type = "";
+    char *description = "";
+    static char *kwlist[] = { "name", "unit", "metric_type", "description", NULL };
+
+    PyGILState_STATE gstate = PyGILState_Ensure();
+
+    // datadog_agent.set_metric_metadata(name, unit="", metric_type="", description="")
+    if (!PyArg_ParseTupleAndKeywords(args, kwargs, "s|sss", kwlist, &name, &unit, &metric_type, &description)) {
+        PyGILState_Release(gstate);
+        return NULL;
+    }
+
+    PyGILState_Release(gstate);
+    cb_set_metric_metadata(name, unit, metric_type, description);
+
+    Py_RETURN_NONE;
+}
+
 /*! \fn PyObject *write_persistent_cache(PyObject *self, PyObject *args)
     \brief This function implements the `datadog_agent.write_persistent_cache` method, storing
     the value for the key.
@@ -330,6 +330,15 @@ public:
     */
     virtual void setSetCheckMetadataCb(cb_set_check_metadata_t) = 0;
 
+    //! setMetricMetadataCb member.
+    /*!
+      \param A cb_set_metric_metadata_t function pointer to the CGO callback.
+
+      This allows us to set the relevant CGO callback that will allow declaring the unit, the
+      type and the description of metrics to the go-land Metric Metadata provider cache.
+    */
+    virtual void setSetMetricMetadataCb(cb_set_metric_metadata_t) = 0;
+
     //! setExternalTagsCb member.
     /*!
       \param A cb_set_external_tags_t function pointer to the CGO callback.
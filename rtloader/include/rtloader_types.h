@@ -119,6 +119,8 @@ typedef bool (*cb_tracemalloc_enabled_t)(void);
 typedef void (*cb_log_t)(char *, int);
 // (check_id, name, value)
 typedef void (*cb_set_check_metadata_t)(char *, char *, char *);
+// (metric_name, unit, metric_type, description)
+typedef void (*cb_set_metric_metadata_t)(char *, char *, char *, char *);
 // (hostname, source_type_name, list of tags)
 typedef void (*cb_set_external_tags_t)(char *, char *, char **);
 // (key, value)
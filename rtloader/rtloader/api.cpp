@@ -508,6 +508,11 @@ void set_set_check_metadata_cb(rtloader_t *rtloader, cb_set_check_metadata_t cb)
     AS_TYPE(RtLoader, rtloader)->setSetCheckMetadataCb(cb);
 }
 
+void set_set_metric_metadata_cb(rtloader_t *rtloader, cb_set_metric_metadata_t cb)
+{
+    AS_TYPE(RtLoader, rtloader)->setSetMetricMetadataCb(cb);
+}
+
 void set_set_external_tags_cb(rtloader_t *rtloader, cb_set_external_tags_t cb)
 {
     AS_TYPE(RtLoader, rtloader)->setSetExternalTagsCb(cb);
@@ -25,6 +25,7 @@ extern bool getTracemallocEnabled();
 extern void getVersion(char **);
 extern void headers(char **);
 extern void setCheckMetadata(char*, char*, char*);
+extern void setMetricMetadata(char*, char*, char*, char*);
 extern void setExternalHostTags(char*, char*, char**);
 extern void writePersistentCache(char*, char*);
 extern char* readPersistentCache(char*);
@@ -41,6 +42,7 @@ static void initDatadogAgentTests(rtloader_t *rtloader) {
    set_headers_cb(rtloader, headers);
    set_log_cb(rtloader, doLog);
    set_set_check_metadata_cb(rtloader, setCheckMetadata);
+   set_set_metric_metadata_cb(rtloader, setMetricMetadata);
    set_set_external_tags_cb(rtloader, setExternalHostTags);
    set_write_persistent_cache_cb(rtloader, writePersistentCache);
    set_read_persistent_cache_cb(rtloader, readPersistentCache);
@@ -187,6 +189,14 @@ func setCheckMetadata(checkID, name, value *C.char) {
 	f.WriteString(strings.Join([]string{cid, key, val}, ","))
 }
 
+//export setMetricMetadata
+func setMetricMetadata(metricName, unit, metricType, description *C.char) {
+	f, _ := os.OpenFile(tmpfile.Name(), os.O_APPEND|os.O_RDWR|os.O_CREATE, 0666)
+	defer f.Close()
+
+	f.WriteString(strings.Join([]string{C.GoString(metricName), C.GoString(unit), C.GoString(metricType), C.GoString(description)}, ","))
+}
+
 //export setExternalHostTags
 func setExternalHostTags(hostname *C.char, sourceType *C.char, tags **C.char) {
 	hname := C.GoString(hostname)
@@ -186,6 +186,32 @@ func TestSetCheckMetadata(t *testing.T) {
 	}
 }
 
+func TestSetMetricMetadata(t *testing.T) {
+	code := `
+	datadog_agent.set_metric_metadata("redis.mem.used", "byte", "gauge", "Memory used by redis")
+	`
+	out, err := run(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "redis.mem.used,byte,gauge,Memory used by redis" {
+		t.Errorf("Unexpected printed value: '%s'", out)
+	}
+}
+
+func TestSetMetricMetadataKeywords(t *testing.T) {
+	code := `
+	datadog_agent.set_metric_metadata("redis.mem.used", description="Memory used by redis", unit="byte")
+	`
+	out, err := run(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "redis.mem.used,byte,,Memory used by redis" {
+		t.Errorf("Unexpected printed value: '%s'", out)
+	}
+}
+
 func TestSetExternalTags(t *testing.T) {
 	// Reset memory counters
 	helpers.ResetMemoryStats()
@@ -846,6 +846,11 @@ void Three::setSetCheckMetadataCb(cb_set_check_metadata_t cb)
     _set_set_check_metadata_cb(cb);
 }
 
+void Three::setSetMetricMetadataCb(cb_set_metric_metadata_t cb)
+{
+    _set_set_metric_metadata_cb(cb);
+}
+
 void Three::setSetExternalTagsCb(cb_set_external_tags_t cb)
 {
     _set_set_external_tags_cb(cb);
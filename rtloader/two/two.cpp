@@ -843,6 +843,11 @@ void Two::setSetCheckMetadataCb(cb_set_check_metadata_t cb)
     _set_set_check_metadata_cb(cb);
 }
 
+void Two::setSetMetricMetadataCb(cb_set_metric_metadata_t cb)
+{
+    _set_set_metric_metadata_cb(cb);
+}
+
 void Two::setSetExternalTagsCb(cb_set_external_tags_t cb)
 {
     _set_set_external_tags_cb(cb);
@@ -51,6 +51,7 @@ AGENT_CORECHECKS = [
     "oom_kill",
     "systemd",
     "tcp_queue_length",
+    "uprobe",
     "uptime",
     "winproc",
 ]
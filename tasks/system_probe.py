@@ -355,7 +355,19 @@ def build_object_files(ctx, bundle_ebpf=False):
         os.path.join(bpf_dir, "tcp-queue-length-kern-user.h"),
         os.path.join(c_dir, "oom-kill-kern.c"),
         os.path.join(bpf_dir, "oom-kill-kern-user.h"),
+        os.path.join(c_dir, "uprobe-kern.c"),
+        os.path.join(bpf_dir, "uprobe-kern-user.h"),
+        os.path.join(c_dir, "conntrack-kern.c"),
+        os.path.join(bpf_dir, "conntrack-kern-user.h"),
         os.path.join(c_dir, "bpf-common.h"),
+        # sources of the network tracer, compiled at runtime when the pre-built programs can't be loaded
+        os.path.join(c_dir, "tracer-ebpf.c"),
+        os.path.join(c_dir, "tracer-ebpf.h"),
+        os.path.join(c_dir, "offset-guess.c"),
+        os.path.join(c_dir, "offset-guess.h"),
+        os.path.join(c_dir, "bpf_helpers.h"),
+        os.path.join(c_dir, "syscalls.h"),
+        os.path.join(c_dir, "asm_goto_workaround.h"),
     ]
     for p in compiled_programs:
         # Build both the standard and debug version